@@ -59,6 +59,77 @@ provider "openai" {
 	}
 }
 
+func TestRun_FlattensProviderExtends(t *testing.T) {
+	root := t.TempDir()
+	baseDir := filepath.Join(root, "providers", "base")
+	if err := os.MkdirAll(baseDir, 0o750); err != nil {
+		t.Fatalf("MkdirAll base: %v", err)
+	}
+	sourcePath := filepath.Join(root, "onr.conf")
+	files := map[string]string{
+		sourcePath: `
+syntax "next-router/0.1";
+include providers/*.conf;
+`,
+		filepath.Join(baseDir, "openai-compatible.conf"): `
+syntax "next-router/0.1";
+provider "openai-compatible" {
+  defaults {
+    auth {
+      auth_bearer;
+    }
+  }
+  match api = "chat.completions" {
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+}
+`,
+		filepath.Join(root, "providers", "groq.conf"): `
+syntax "next-router/0.1";
+provider "groq" extends "openai-compatible" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.groq.com/openai";
+    }
+  }
+}
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile %s: %v", path, err)
+		}
+	}
+	outPath := filepath.Join(root, "dist", "providers.conf")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--providers", sourcePath, "--out", outPath}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run code=%d stderr=%q", code, stderr.String())
+	}
+	contentBytes, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("ReadFile bundle: %v", err)
+	}
+	content := string(contentBytes)
+	if strings.Contains(content, "extends") || strings.Contains(content, `provider "openai-compatible"`) {
+		t.Fatalf("bundled output must contain only the flattened provider: %q", content)
+	}
+	if !strings.Contains(content, `set_path "/v1/chat/completions";`) {
+		t.Fatalf("bundled output missing inherited match: %q", content)
+	}
+	res, err := dslconfig.ValidateProvidersFile(outPath)
+	if err != nil {
+		t.Fatalf("ValidateProvidersFile: %v", err)
+	}
+	if strings.Join(res.LoadedProviders, ",") != "groq" {
+		t.Fatalf("loaded=%v", res.LoadedProviders)
+	}
+}
+
 func TestRun_InvalidProvidersDoesNotWriteOutput(t *testing.T) {
 	root := t.TempDir()
 	sourcePath := filepath.Join(root, "bad.conf")
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+	"github.com/spf13/cobra"
+)
+
+type auditOptions struct {
+	cfgPath  string
+	file     string
+	actor    string
+	source   string
+	target   string
+	contains string
+	since    string
+	until    string
+	limit    int
+	showDiff bool
+	asJSON   bool
+}
+
+// newAuditCmd returns a non-nil audit command.
+func newAuditCmd() *cobra.Command {
+	opts := auditOptions{cfgPath: "onr.yaml", limit: 50}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Search the audit log of config changes",
+		Long: "Search the append-only audit log written by onr-admin (cli/web) and onr reloads.\n" +
+			"The log path comes from --file, audit.file in onr.yaml, or ONR_AUDIT_FILE.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(cmd.OutOrStdout(), opts, time.Now())
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.file, "file", "", "audit log path (overrides audit.file)")
+	fs.StringVar(&opts.actor, "actor", "", "filter by actor (substring)")
+	fs.StringVar(&opts.source, "source", "", "filter by source: cli|tui|web|reload")
+	fs.StringVar(&opts.target, "target", "", "filter by target file (substring)")
+	fs.StringVar(&opts.contains, "grep", "", "filter by text in action/target/diff/error")
+	fs.StringVar(&opts.since, "since", "", "only entries at or after this time (RFC3339, YYYY-MM-DD, or a duration like 24h)")
+	fs.StringVar(&opts.until, "until", "", "only entries at or before this time (RFC3339, YYYY-MM-DD, or a duration like 1h)")
+	fs.IntVar(&opts.limit, "limit", 50, "show only the newest N entries (0 = all)")
+	fs.BoolVar(&opts.showDiff, "diff", false, "print the masked diff of each entry")
+	fs.BoolVar(&opts.asJSON, "json", false, "print matching entries as JSON lines")
+	return cmd
+}
+
+func runAudit(out io.Writer, opts auditOptions, now time.Time) error {
+	path := strings.TrimSpace(opts.file)
+	if path == "" {
+		cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(opts.cfgPath))
+		store.ConfigureAudit(cfg, audit.SourceCLI)
+		path = store.AuditFile()
+	}
+	if path == "" {
+		return errors.New("audit log is not configured (set audit.file, ONR_AUDIT_FILE or --file)")
+	}
+	since, err := parseAuditTime(opts.since, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseAuditTime(opts.until, now)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	entries, err := audit.Read(path, audit.Filter{
+		Actor:    opts.actor,
+		Source:   opts.source,
+		Target:   opts.target,
+		Contains: opts.contains,
+		Since:    since,
+		Until:    until,
+		Limit:    opts.limit,
+	})
+	if err != nil {
+		return err
+	}
+	if opts.asJSON {
+		enc := json.NewEncoder(out)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, e := range entries {
+		line := fmt.Sprintf("%s  %-6s  %-20s  %-14s  %-6s  %s",
+			e.Time.Local().Format(time.RFC3339), e.Source, e.Actor, e.Action, e.Validation, e.Target)
+		if e.Error != "" {
+			line += "  error=" + e.Error
+		}
+		_, _ = fmt.Fprintln(out, line)
+		if opts.showDiff && e.Diff != "" {
+			_, _ = fmt.Fprint(out, indentLines(e.Diff, "    "))
+		}
+	}
+	return nil
+}
+
+// parseAuditTime accepts RFC3339, YYYY-MM-DD (local time) or a duration relative to now.
+func parseAuditTime(v string, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(v)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not RFC3339, YYYY-MM-DD, a duration or a day count like 7d", s)
+}
+
+func indentLines(s, prefix string) string {
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line == "" {
+			continue
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
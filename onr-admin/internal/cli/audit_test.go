@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+)
+
+func TestStoreWritesAreAudited(t *testing.T) {
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	t.Setenv("ONR_AUDIT_FILE", auditPath)
+	t.Setenv("ONR_AUDIT_ACTOR", "alice")
+	store.ConfigureAudit(nil, audit.SourceCLI)
+	t.Cleanup(func() { store.ConfigureAudit(nil, "") })
+
+	keysPath := filepath.Join(dir, "keys.yaml")
+	if err := store.WriteAtomic(keysPath, []byte("access_keys:\n  - value: ak-1\n"), false); err != nil {
+		t.Fatalf("WriteAtomic err=%v", err)
+	}
+	store.AuditRejected("", "providers.update", keysPath, []byte("broken"), errors.New("invalid yaml"))
+
+	var out bytes.Buffer
+	if err := runAudit(&out, auditOptions{file: auditPath, showDiff: true}, time.Now()); err != nil {
+		t.Fatalf("runAudit err=%v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"cli", "alice", "write", "ok", keysPath, "+  - value: ***", "failed", "error=invalid yaml"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ak-1") {
+		t.Fatalf("output leaks secret:\n%s", got)
+	}
+
+	out.Reset()
+	if err := runAudit(&out, auditOptions{file: auditPath, contains: "invalid", asJSON: true}, time.Now()); err != nil {
+		t.Fatalf("runAudit err=%v", err)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1 || !strings.Contains(out.String(), `"validation":"failed"`) {
+		t.Fatalf("unexpected json output:\n%s", out.String())
+	}
+}
+
+func TestRunAuditRequiresFile(t *testing.T) {
+	t.Setenv("ONR_AUDIT_FILE", "")
+	err := runAudit(&bytes.Buffer{}, auditOptions{cfgPath: filepath.Join(t.TempDir(), "missing.yaml")}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("expected not configured error, got %v", err)
+	}
+}
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if got, err := parseAuditTime("2h", now); err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("duration got=%v err=%v", got, err)
+	}
+	if got, err := parseAuditTime("2026-04-30T10:00:00Z", now); err != nil || got.Hour() != 10 {
+		t.Fatalf("rfc3339 got=%v err=%v", got, err)
+	}
+	if _, err := parseAuditTime("2026-04-30", now); err != nil {
+		t.Fatalf("date err=%v", err)
+	}
+	if _, err := parseAuditTime("yesterday", now); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
@@ -25,6 +25,7 @@ func newCryptoCmd() *cobra.Command {
 		newCryptoEncryptCmd(),
 		newCryptoDecryptCmd(),
 		newCryptoEncryptKeysCmd(),
+		newCryptoRotateCmd(),
 		newCryptoGenMasterKeyCmd(),
 	)
 	return cmd
@@ -35,7 +36,7 @@ func newCryptoEncryptCmd() *cobra.Command {
 	var text string
 	cmd := &cobra.Command{
 		Use:   "encrypt",
-		Short: "Encrypt plaintext to ENC[v1:aesgcm:...]",
+		Short: "Encrypt plaintext to ENC[v2:aesgcm:<kid>:...]",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			plain, err := resolveCryptoInput(strings.TrimSpace(text), cmd.InOrStdin(), isTerminalReader(cmd.InOrStdin()))
 			if err != nil {
@@ -61,7 +62,7 @@ func newCryptoDecryptCmd() *cobra.Command {
 	var text string
 	cmd := &cobra.Command{
 		Use:   "decrypt",
-		Short: "Decrypt ENC[v1:aesgcm:...] to plaintext",
+		Short: "Decrypt ENC[v1:aesgcm:...] or ENC[v2:aesgcm:<kid>:...] to plaintext",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ciphertext, err := resolveCryptoInput(strings.TrimSpace(text), cmd.InOrStdin(), isTerminalReader(cmd.InOrStdin()))
 			if err != nil {
@@ -208,3 +209,82 @@ type cryptoEncryptKeysOptions struct {
 	backup   bool
 	dryRun   bool
 }
+
+// newCryptoRotateCmd returns a non-nil rotate command.
+func newCryptoRotateCmd() *cobra.Command {
+	opts := cryptoRotateOptions{cfgPath: "onr.yaml", backup: true}
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt ENC[...] values in keys.yaml and OAuth token files with the current ONR_MASTER_KEY",
+		Long: "Re-encrypt ENC[...] values with ONR_MASTER_KEY.\n" +
+			"Set ONR_MASTER_KEY to the new key and ONR_MASTER_KEYS_PREVIOUS to the old key(s) (comma-separated).",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCryptoRotate(cmd.OutOrStdout(), opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.keysPath, "keys", "", "keys.yaml path")
+	fs.StringVar(&opts.oauthDir, "oauth-dir", "", "OAuth token persistence dir (default: oauth.token_persist.dir when enabled)")
+	fs.BoolVar(&opts.backup, "backup", true, "backup files before saving")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print result without writing files")
+	return cmd
+}
+
+type cryptoRotateOptions struct {
+	cfgPath  string
+	keysPath string
+	oauthDir string
+	backup   bool
+	dryRun   bool
+}
+
+func runCryptoRotate(out io.Writer, opts cryptoRotateOptions) error {
+	if !keystore.HasMasterKey() {
+		return errors.New("ONR_MASTER_KEY is required (the new master key)")
+	}
+	cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(opts.cfgPath))
+	keysPath, _ := store.ResolveDataPaths(cfg, opts.keysPath, "")
+	oauthDir := strings.TrimSpace(opts.oauthDir)
+	if oauthDir == "" && cfg != nil && cfg.OAuth.TokenPersist.Enabled {
+		oauthDir = strings.TrimSpace(cfg.OAuth.TokenPersist.Dir)
+	}
+
+	doc, err := store.LoadOrInitKeysDoc(keysPath)
+	if err != nil {
+		return fmt.Errorf("load keys: %w", err)
+	}
+	n, err := store.RotateKeysDocValues(doc)
+	if err != nil {
+		return err
+	}
+	// Verify every OAuth token decrypts before touching keys.yaml.
+	tokens, err := store.RotateOAuthTokenFiles(oauthDir, opts.backup, true)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		_, _ = fmt.Fprintf(out, "rotate: %d keys.yaml value(s) and %d oauth token file(s) would be re-encrypted (dry-run)\n", n, tokens)
+		return nil
+	}
+
+	if n > 0 {
+		if err := store.ValidateKeysDoc(doc); err != nil {
+			return err
+		}
+		b, err := store.EncodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		if err := store.WriteAtomic(keysPath, b, opts.backup); err != nil {
+			return err
+		}
+	}
+	if tokens > 0 {
+		if tokens, err = store.RotateOAuthTokenFiles(oauthDir, opts.backup, false); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(out, "rotate: re-encrypted %d value(s) in %s and %d oauth token file(s)\n", n, keysPath, tokens)
+	return nil
+}
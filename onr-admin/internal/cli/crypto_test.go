@@ -2,6 +2,10 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -63,3 +67,84 @@ func TestCryptoDecryptCmd(t *testing.T) {
 		t.Fatalf("decrypt output=%q want=%q", got, "hello")
 	}
 }
+
+func TestCryptoRotate_KeysAndOAuthTokens(t *testing.T) {
+	const oldKey = "12345678901234567890123456789012"
+	const newKey = "abcdefghijklmnopqrstuvwxyzABCDEF"
+	t.Setenv("ONR_MASTER_KEY", oldKey)
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "")
+	encUp, err := keystore.Encrypt("sk-upstream")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	encAK, err := keystore.Encrypt("ak-client")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	encTok, err := keystore.Encrypt("oauth-access")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "keys.yaml")
+	keysYAML := "providers:\n  openai:\n    keys:\n      - name: k1\n        value: \"" + encUp + "\"\n      - name: k2\n        value: plain\n" +
+		"access_keys:\n  - name: a1\n    value: \"" + encAK + "\"\n"
+	if err := os.WriteFile(keysPath, []byte(keysYAML), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	oauthDir := filepath.Join(dir, "oauth")
+	if err := os.MkdirAll(oauthDir, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	tokPath := filepath.Join(oauthDir, "abc.json")
+	if err := os.WriteFile(tokPath, []byte(`{"access_token":"`+encTok+`","token_type":"Bearer","expires_at":1}`), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	t.Setenv("ONR_MASTER_KEY", newKey)
+	opts := cryptoRotateOptions{cfgPath: filepath.Join(dir, "missing.yaml"), keysPath: keysPath, oauthDir: oauthDir, backup: true}
+
+	// Without the old key nothing may be written.
+	if err := runCryptoRotate(io.Discard, opts); err == nil {
+		t.Fatalf("expected error without previous key")
+	}
+	if b, _ := os.ReadFile(keysPath); string(b) != keysYAML {
+		t.Fatalf("keys.yaml changed after failed rotate")
+	}
+
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", oldKey)
+	var out bytes.Buffer
+	if err := runCryptoRotate(&out, opts); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if !strings.Contains(out.String(), "re-encrypted 2 value(s)") || !strings.Contains(out.String(), "1 oauth token file(s)") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "")
+	st, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("load rotated keys: %v", err)
+	}
+	if k, _ := st.NextKey("openai"); k == nil || k.Value != "sk-upstream" {
+		t.Fatalf("unexpected rotated key: %#v", k)
+	}
+	if _, ok := st.MatchAccessKey("ak-client"); !ok {
+		t.Fatalf("expected rotated access key")
+	}
+	b, err := os.ReadFile(tokPath)
+	if err != nil {
+		t.Fatalf("read token: %v", err)
+	}
+	var tok map[string]any
+	if err := json.Unmarshal(b, &tok); err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if got, err := keystore.Decrypt(tok["access_token"].(string)); err != nil || got != "oauth-access" {
+		t.Fatalf("rotated token got=%q err=%v", got, err)
+	}
+	if matches, _ := filepath.Glob(keysPath + ".bak.*"); len(matches) != 1 {
+		t.Fatalf("expected keys.yaml backup, got %v", matches)
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr/dsltest"
+	"github.com/spf13/cobra"
+)
+
+// newDSLCmd returns a non-nil dsl command group.
+func newDSLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dsl",
+		Short: "Provider DSL tooling",
+	}
+	cmd.AddCommand(newDSLTestCmd(), newDSLFmtCmd(), newDSLLintCmd())
+	return cmd
+}
+
+type dslTestOptions struct {
+	providersDir string
+	update       bool
+	verbose      bool
+	stdout       io.Writer
+}
+
+// newDSLTestCmd returns a non-nil dsl test command.
+func newDSLTestCmd() *cobra.Command {
+	opts := dslTestOptions{stdout: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "test [paths...]",
+		Short: "Run provider golden cases (*.test.yaml) against a fake upstream",
+		Long: "Run provider golden cases (*.test.yaml next to provider files) through the proxy pipeline.\n" +
+			"Each case sends a client request, checks the transformed upstream request, replays a canned\n" +
+			"upstream response and checks the client response plus extracted usage/finish_reason.\n" +
+			"Paths may be files or directories (default: ./config/providers).",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDSLTest(args, opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.providersDir, "providers-dir", "", "providers dir to load (default: directory of each test file)")
+	fs.BoolVarP(&opts.update, "update", "u", false, "rewrite upstream/expect goldens from actual results")
+	fs.BoolVarP(&opts.verbose, "verbose", "v", false, "print passing cases")
+	return cmd
+}
+
+func runDSLTest(args []string, opts dslTestOptions) error {
+	if len(args) == 0 {
+		args = []string{"./config/providers"}
+	}
+	files, err := dsltest.Discover(args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no *%s files found in %s", dsltest.FileSuffix, strings.Join(args, ", "))
+	}
+	out := opts.stdout
+	total, failed := 0, 0
+	for _, f := range files {
+		res, err := dsltest.RunFile(f, dsltest.Options{ProvidersDir: opts.providersDir, Update: opts.update})
+		if err != nil {
+			return err
+		}
+		if res.Updated {
+			_, _ = fmt.Fprintf(out, "UPDATED %s (%d cases)\n", f, len(res.Cases))
+			total += len(res.Cases)
+			continue
+		}
+		for _, c := range res.Cases {
+			total++
+			if c.Passed() {
+				if opts.verbose {
+					_, _ = fmt.Fprintf(out, "PASS %s: %s\n", f, c.Name)
+				}
+				continue
+			}
+			failed++
+			_, _ = fmt.Fprintf(out, "FAIL %s: %s\n", f, c.Name)
+			for _, msg := range c.Failures {
+				_, _ = fmt.Fprintf(out, "    %s\n", strings.ReplaceAll(msg, "\n", "\n    "))
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(out, "%d files, %d cases, %d failed\n", len(files), total, failed)
+	if failed > 0 {
+		return errors.New("dsl test failed")
+	}
+	return nil
+}
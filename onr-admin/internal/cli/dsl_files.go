@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+)
+
+// dslOutputFormats are the --format values shared by dsl fmt and dsl lint.
+var dslOutputFormats = []string{"text", "json", "sarif"}
+
+func validateDSLOutputFormat(format string) error {
+	for _, f := range dslOutputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported --format %q (want %s)", format, strings.Join(dslOutputFormats, ", "))
+}
+
+// collectDSLFiles expands DSL sources into the files they are made of. A file
+// brings its includes; a providers directory brings its *.conf files plus the
+// sibling onr.conf and that file's includes, where global modes live.
+func collectDSLFiles(paths []string) ([]string, error) {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, 16)
+	add := func(p string) {
+		p = filepath.Clean(p)
+		if _, ok := seen[p]; ok {
+			return
+		}
+		seen[p] = struct{}{}
+		out = append(out, p)
+	}
+	addWithIncludes := func(p string) error {
+		content, err := os.ReadFile(p) // #nosec G304 -- admin CLI reads user-selected DSL files.
+		if err != nil {
+			return fmt.Errorf("read %s: %w", p, err)
+		}
+		add(p)
+		included, err := dslconfig.ListIncludedFiles(p, string(content))
+		if err != nil {
+			return fmt.Errorf("expand includes of %s: %w", p, err)
+		}
+		for _, f := range included {
+			add(f)
+		}
+		return nil
+	}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			if err := addWithIncludes(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(path, "*.conf"))
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", path, err)
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			add(f)
+		}
+		global := filepath.Join(filepath.Dir(filepath.Clean(path)), "onr.conf")
+		if _, err := os.Stat(global); err == nil {
+			if err := addWithIncludes(global); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// displayPath returns path relative to the working directory when possible,
+// with forward slashes, for stable CLI and SARIF output.
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
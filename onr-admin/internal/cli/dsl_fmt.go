@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+	"github.com/spf13/cobra"
+)
+
+const dslFmtRuleID = "format"
+
+type dslFmtOptions struct {
+	cfgPath string
+	write   bool
+	list    bool
+	diff    bool
+	format  string
+	stdout  io.Writer
+}
+
+type dslFmtResult struct {
+	File    string `json:"file"`
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"`
+
+	formatted string
+}
+
+// newDSLFmtCmd returns a non-nil dsl fmt command.
+func newDSLFmtCmd() *cobra.Command {
+	opts := dslFmtOptions{cfgPath: "onr.yaml", stdout: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "fmt [paths...]",
+		Short: "Format provider DSL files",
+		Long: "Format provider DSL files with the same rules as the web editor.\n" +
+			"Paths may be files (their includes are formatted too) or providers directories\n" +
+			"(default: the provider source from --config). Without -w/-l/-d the formatted\n" +
+			"content is printed. -l and -d fail when any file needs formatting, so they can gate CI.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDSLFmt(args, opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path (used when no paths are given)")
+	fs.BoolVarP(&opts.write, "write", "w", false, "write result to the source files")
+	fs.BoolVarP(&opts.list, "list", "l", false, "list files whose formatting differs")
+	fs.BoolVarP(&opts.diff, "diff", "d", false, "print unified diffs")
+	fs.StringVar(&opts.format, "format", "text", "output format: text, json or sarif")
+	return cmd
+}
+
+func runDSLFmt(args []string, opts dslFmtOptions) error {
+	if err := validateDSLOutputFormat(opts.format); err != nil {
+		return err
+	}
+	files, err := collectDSLFiles(dslSourcePaths(args, opts.cfgPath))
+	if err != nil {
+		return err
+	}
+	results := make([]dslFmtResult, 0, len(files))
+	changed := 0
+	for _, path := range files {
+		raw, err := os.ReadFile(path) // #nosec G304 -- admin CLI reads user-selected DSL files.
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		src := string(raw)
+		res := dslFmtResult{File: displayPath(path), formatted: dsllang.FormatText(src, dsllang.FormatOptions{TabSize: 2, InsertSpaces: true})}
+		res.Changed = res.formatted != src
+		if res.Changed {
+			changed++
+			if opts.diff {
+				res.Diff = unifiedDiff(res.File, src, res.formatted)
+			}
+			if opts.write {
+				if err := store.WriteAtomic(path, []byte(res.formatted), false); err != nil {
+					return fmt.Errorf("write %s: %w", path, err)
+				}
+			}
+		}
+		results = append(results, res)
+	}
+
+	if err := writeDSLFmtResults(opts, results); err != nil {
+		return err
+	}
+	if changed > 0 && !opts.write && (opts.list || opts.diff || opts.format != "text") {
+		return fmt.Errorf("dsl fmt: %d file(s) need formatting", changed)
+	}
+	return nil
+}
+
+func writeDSLFmtResults(opts dslFmtOptions, results []dslFmtResult) error {
+	out := opts.stdout
+	switch opts.format {
+	case "json":
+		return writeJSONLine(out, results)
+	case "sarif":
+		var sarifResults []sarifResult
+		for _, r := range results {
+			if r.Changed {
+				sarifResults = append(sarifResults, newSARIFResult(dslFmtRuleID, "warning", "file is not formatted; run onr-admin dsl fmt -w", r.File, dsllang.Range{}))
+			}
+		}
+		return writeSARIF(out, "onr-admin dsl fmt", []dsllang.LintRule{{ID: dslFmtRuleID, Description: "DSL file differs from onr-admin dsl fmt output"}}, sarifResults)
+	}
+	for _, r := range results {
+		switch {
+		case opts.list || opts.write || opts.diff:
+			if !r.Changed {
+				continue
+			}
+			if opts.list {
+				_, _ = fmt.Fprintln(out, r.File)
+			}
+			if opts.diff {
+				_, _ = io.WriteString(out, r.Diff)
+			}
+		default:
+			_, _ = io.WriteString(out, r.formatted)
+		}
+	}
+	return nil
+}
+
+func writeJSONLine(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// dslSourcePaths returns args, or the configured provider source when empty.
+func dslSourcePaths(args []string, cfgPath string) []string {
+	if len(args) > 0 {
+		return args
+	}
+	cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(cfgPath))
+	return []string{resolveProviderSourcePath(cfg, "")}
+}
+
+// unifiedDiff returns a unified diff of a and b with three lines of context.
+func unifiedDiff(name, a, b string) string {
+	const context = 3
+	al, bl := splitLines(a), splitLines(b)
+	ops := diffLines(al, bl)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run-end > 2*context || run == len(ops) {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		aStart, bStart, aCount, bCount := ops[start].aLine, ops[start].bLine, 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart+1, aCount, bStart+1, bCount)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+type diffOp struct {
+	kind  byte // ' ', '-' or '+'
+	text  string
+	aLine int
+	bLine int
+}
+
+// diffLines computes a line diff from the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], aLine: i, bLine: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], aLine: i, bLine: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], aLine: i, bLine: j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+)
+
+const unformattedProvider = `syntax "next-router/0.1";
+provider "demo" {
+defaults {
+    upstream_config { base_url = "https://api.example.com"; }
+}
+}
+`
+
+func writeUnformattedProvider(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "demo.conf")
+	if err := os.WriteFile(path, []byte(unformattedProvider), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestDSLFmt_ShippedConfigIsFormatted(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := runDSLFmt([]string{"../../../config/providers"}, dslFmtOptions{list: true, format: "text", stdout: &out}); err != nil {
+		t.Fatalf("runDSLFmt: %v\n%s", err, out.String())
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected unformatted files:\n%s", out.String())
+	}
+}
+
+func TestDSLFmt_ListDiffAndWrite(t *testing.T) {
+	t.Parallel()
+
+	path := writeUnformattedProvider(t)
+
+	var out bytes.Buffer
+	err := runDSLFmt([]string{path}, dslFmtOptions{list: true, diff: true, format: "text", stdout: &out})
+	if err == nil || !strings.Contains(err.Error(), "1 file(s) need formatting") {
+		t.Fatalf("expected formatting error, got %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "demo.conf\n--- ") || !strings.Contains(got, "\n@@ -1,") || !strings.Contains(got, "\n+  defaults {\n") {
+		t.Fatalf("unexpected list/diff output:\n%s", got)
+	}
+
+	out.Reset()
+	if err := runDSLFmt([]string{path}, dslFmtOptions{write: true, format: "text", stdout: &out}); err != nil {
+		t.Fatalf("runDSLFmt -w: %v", err)
+	}
+	out.Reset()
+	if err := runDSLFmt([]string{path}, dslFmtOptions{list: true, format: "text", stdout: &out}); err != nil || out.Len() != 0 {
+		t.Fatalf("expected formatted file after -w, err=%v out=%q", err, out.String())
+	}
+}
+
+func TestDSLFmt_WriteIsAudited(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("ONR_AUDIT_FILE", auditPath)
+	store.ConfigureAudit(nil, audit.SourceCLI)
+	t.Cleanup(func() { store.ConfigureAudit(nil, "") })
+
+	path := writeUnformattedProvider(t)
+	if err := os.Chmod(path, 0o644); err != nil { // #nosec G302 -- provider files are commonly world-readable.
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := runDSLFmt([]string{path}, dslFmtOptions{write: true, format: "text", stdout: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("runDSLFmt -w: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file to be renamed away, stat err=%v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Fatalf("expected formatted file to keep mode 0644, info=%v err=%v", info, err)
+	}
+
+	var out bytes.Buffer
+	if err := runAudit(&out, auditOptions{file: auditPath, showDiff: true}, time.Now()); err != nil {
+		t.Fatalf("runAudit err=%v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, path) || !strings.Contains(got, "+  defaults {") {
+		t.Fatalf("expected audited formatting diff for %s, got:\n%s", path, got)
+	}
+}
+
+func TestDSLFmt_JSONAndSARIF(t *testing.T) {
+	t.Parallel()
+
+	path := writeUnformattedProvider(t)
+
+	var out bytes.Buffer
+	if err := runDSLFmt([]string{path}, dslFmtOptions{format: "json", stdout: &out}); err == nil {
+		t.Fatalf("expected formatting error")
+	}
+	var results []dslFmtResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("decode json: %v\n%s", err, out.String())
+	}
+	if len(results) != 1 || !results[0].Changed {
+		t.Fatalf("unexpected json results: %+v", results)
+	}
+
+	out.Reset()
+	if err := runDSLFmt([]string{path}, dslFmtOptions{format: "sarif", stdout: &out}); err == nil {
+		t.Fatalf("expected formatting error")
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("decode sarif: %v\n%s", err, out.String())
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 || log.Runs[0].Results[0].RuleID != dslFmtRuleID {
+		t.Fatalf("unexpected sarif: %+v", log)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	got := unifiedDiff("x.conf", "a\nb\nc\n", "a\nB\nc\n")
+	want := "--- x.conf\n+++ x.conf\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
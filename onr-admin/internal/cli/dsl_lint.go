@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+	"github.com/spf13/cobra"
+)
+
+type dslLintOptions struct {
+	cfgPath string
+	format  string
+	disable []string
+	stdout  io.Writer
+}
+
+// newDSLLintCmd returns a non-nil dsl lint command.
+func newDSLLintCmd() *cobra.Command {
+	opts := dslLintOptions{cfgPath: "onr.yaml", stdout: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "lint [paths...]",
+		Short: "Report suspicious provider DSL",
+		Long: "Lint provider DSL files for shadowed matches, directives with no effect, unused named modes,\n" +
+			"deprecated directives and token-billed APIs without usage extraction.\n" +
+			"Paths may be files or providers directories (default: the provider source from --config).\n" +
+			"Exits non-zero when any issue is reported.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDSLLint(args, opts)
+		},
+	}
+	ids := make([]string, 0, len(dsllang.LintRules()))
+	for _, r := range dsllang.LintRules() {
+		ids = append(ids, r.ID)
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path (used when no paths are given)")
+	fs.StringVar(&opts.format, "format", "text", "output format: text, json or sarif")
+	fs.StringSliceVar(&opts.disable, "disable", nil, "rule ids to skip (repeatable): "+strings.Join(ids, ", "))
+	return cmd
+}
+
+func runDSLLint(args []string, opts dslLintOptions) error {
+	if err := validateDSLOutputFormat(opts.format); err != nil {
+		return err
+	}
+	rules := dsllang.LintRules()
+	for _, id := range opts.disable {
+		if !slices.ContainsFunc(rules, func(r dsllang.LintRule) bool { return r.ID == id }) {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+	}
+	paths := dslSourcePaths(args, opts.cfgPath)
+	files, err := collectDSLFiles(paths)
+	if err != nil {
+		return err
+	}
+	var providers []dslconfig.ProviderFile
+	for _, path := range paths {
+		pfs, err := loadValidatedProviderFiles(path)
+		if err != nil {
+			return err
+		}
+		providers = append(providers, pfs...)
+	}
+	docs := make([]dsllang.LintDocument, 0, len(files))
+	for _, path := range files {
+		raw, err := os.ReadFile(path) // #nosec G304 -- admin CLI reads user-selected DSL files.
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		docs = append(docs, dsllang.LintDocument{URI: displayPath(path), Text: string(raw)})
+	}
+
+	issues := make([]dsllang.LintIssue, 0, 8)
+	for _, issue := range dsllang.Lint(docs, providers) {
+		if !slices.Contains(opts.disable, issue.Rule) {
+			issues = append(issues, issue)
+		}
+	}
+	if err := writeDSLLintIssues(opts.stdout, opts.format, rules, issues); err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("dsl lint: %d issue(s)", len(issues))
+	}
+	return nil
+}
+
+func writeDSLLintIssues(out io.Writer, format string, rules []dsllang.LintRule, issues []dsllang.LintIssue) error {
+	switch format {
+	case "json":
+		return writeJSONLine(out, issues)
+	case "sarif":
+		results := make([]sarifResult, 0, len(issues))
+		for _, issue := range issues {
+			results = append(results, newSARIFResult(issue.Rule, "warning", issue.Message, issue.URI, issue.Range))
+		}
+		return writeSARIF(out, "onr-admin dsl lint", rules, results)
+	}
+	for _, issue := range issues {
+		_, _ = fmt.Fprintf(out, "%s:%d:%d: %s: %s\n", issue.URI, issue.Range.Start.Line+1, issue.Range.Start.Character+1, issue.Rule, issue.Message)
+	}
+	return nil
+}
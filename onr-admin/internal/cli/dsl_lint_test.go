@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDSLLint_ShippedConfig(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	err := runDSLLint([]string{"../../../config/providers"}, dslLintOptions{format: "text", stdout: &out})
+	if err == nil || !strings.Contains(err.Error(), "issue(s)") {
+		t.Fatalf("expected lint issues, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "edgenext.conf:23:3: missing-usage: ") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	disable := []string{"missing-usage", "no-effect", "unused-mode"}
+	if err := runDSLLint([]string{"../../../config/providers"}, dslLintOptions{format: "text", disable: disable, stdout: &out}); err != nil {
+		t.Fatalf("expected clean run with rules disabled, got %v\n%s", err, out.String())
+	}
+}
+
+func TestDSLLint_SARIF(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	_ = runDSLLint([]string{"../../../config/providers"}, dslLintOptions{format: "sarif", disable: []string{"no-effect", "unused-mode"}, stdout: &out})
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("decode sarif: %v\n%s", err, out.String())
+	}
+	if len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) == 0 {
+		t.Fatalf("unexpected sarif run: %+v", log)
+	}
+	for _, r := range log.Runs[0].Results {
+		if r.RuleID != "missing-usage" {
+			t.Fatalf("disabled rule reported: %+v", r)
+		}
+		region := r.Locations[0].PhysicalLocation.Region
+		if region == nil || region.StartLine < 1 || region.StartColumn < 1 {
+			t.Fatalf("unexpected region: %+v", r.Locations[0])
+		}
+	}
+}
+
+func TestDSLLint_RejectsUnknownRule(t *testing.T) {
+	t.Parallel()
+
+	err := runDSLLint([]string{"../../../config/providers"}, dslLintOptions{format: "text", disable: []string{"nope"}, stdout: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), `unknown lint rule "nope"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
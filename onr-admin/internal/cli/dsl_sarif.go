@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+)
+
+// Minimal SARIF 2.1.0 model used by dsl fmt and dsl lint, enough for code
+// scanning uploads in CI.
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// newSARIFResult converts a zero-based editor range into a 1-based SARIF
+// region. An empty range yields a file-level result.
+func newSARIFResult(ruleID, level, msg, file string, rng dsllang.Range) sarifResult {
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: file}}}
+	if rng != (dsllang.Range{}) {
+		loc.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   rng.Start.Line + 1,
+			StartColumn: rng.Start.Character + 1,
+			EndLine:     rng.End.Line + 1,
+			EndColumn:   rng.End.Character + 1,
+		}
+	}
+	return sarifResult{RuleID: ruleID, Level: level, Message: sarifMessage{Text: msg}, Locations: []sarifLocation{loc}}
+}
+
+func writeSARIF(out io.Writer, tool string, rules []dsllang.LintRule, results []sarifResult) error {
+	driver := sarifDriver{Name: tool, Rules: make([]sarifRule, 0, len(rules))}
+	for _, r := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: r.ID, ShortDescription: sarifMessage{Text: r.Description}})
+	}
+	if results == nil {
+		results = []sarifResult{}
+	}
+	return writeJSONLine(out, sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
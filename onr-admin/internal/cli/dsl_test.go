@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDSLTest_ShippedGoldens(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	err := runDSLTest([]string{"../../../config/providers"}, dslTestOptions{verbose: true, stdout: &out})
+	if err != nil {
+		t.Fatalf("runDSLTest: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "PASS ") || !strings.Contains(out.String(), " 0 failed") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestDSLTest_ReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	src, err := os.ReadFile("../../../config/providers/openai.test.yaml")
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	broken := strings.Replace(string(src), "url: https://api.openai.com/v1/chat/completions", "url: https://api.openai.com/v1/other", 1)
+	path := filepath.Join(t.TempDir(), "openai.test.yaml")
+	if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var out bytes.Buffer
+	err = runDSLTest([]string{path}, dslTestOptions{providersDir: "../../../config/providers", stdout: &out})
+	if err == nil {
+		t.Fatalf("expected failure, output=%q", out.String())
+	}
+	got := out.String()
+	if !strings.Contains(got, "FAIL "+path+": chat_completions") || !strings.Contains(got, "upstream.url") {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestDSLTest_NoFiles(t *testing.T) {
+	t.Parallel()
+
+	err := runDSLTest([]string{t.TempDir()}, dslTestOptions{stdout: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "no *.test.yaml files") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr"
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+	"github.com/spf13/cobra"
+)
+
+type dumpReplayOptions struct {
+	cfgPath      string
+	dir          string
+	gateway      string
+	provider     string
+	headers      []string
+	ignoreFields []string
+	fakeUpstream bool
+	format       string
+	exitCode     bool
+	timeout      time.Duration
+	// dumpWait bounds how long gateway mode waits for the gateway to write its dump.
+	dumpWait time.Duration
+}
+
+// newDumpCmd returns a non-nil dump command.
+func newDumpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Traffic dump tools",
+	}
+	cmd.AddCommand(newDumpReplayCmd())
+	return cmd
+}
+
+// newDumpReplayCmd returns a non-nil dump replay command.
+func newDumpReplayCmd() *cobra.Command {
+	opts := dumpReplayOptions{cfgPath: "onr.yaml", format: "text", timeout: 2 * time.Minute, dumpWait: 3 * time.Second}
+	cmd := &cobra.Command{
+		Use:   "replay <request-id|dump-file>",
+		Short: "Re-send a captured request against the current config and diff the result",
+		Long: "Re-send the origin request of a traffic dump, either in-process against the current\n" +
+			"provider DSL (default) or through a running gateway (--gateway), then diff the new\n" +
+			"upstream request and proxy response against the dump.\n" +
+			"With --fake-upstream the captured upstream response is served instead of calling the\n" +
+			"provider, so a .conf fix can be checked without network access or spend.\n" +
+			"Dumps mask client credentials; pass real ones with -H when the route needs them.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpReplay(cmd.OutOrStdout(), args[0], opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.dir, "dir", "", "traffic dump directory (overrides traffic_dump.dir)")
+	fs.StringVar(&opts.gateway, "gateway", "", "send through this gateway base URL, e.g. http://127.0.0.1:3300")
+	fs.BoolVar(&opts.fakeUpstream, "fake-upstream", false, "serve the captured upstream response instead of calling the provider (in-process only)")
+	fs.StringVar(&opts.provider, "provider", "", "set x-onr-provider on the replayed request")
+	fs.StringArrayVarP(&opts.headers, "header", "H", nil, "extra request header 'Key: Value' (repeatable)")
+	fs.StringSliceVar(&opts.ignoreFields, "ignore-field", nil, "JSON keys ignored at any depth when diffing bodies, e.g. id,created (repeatable)")
+	fs.StringVar(&opts.format, "format", "text", "output format: text or json")
+	fs.BoolVar(&opts.exitCode, "exit-code", false, "exit non-zero when the replay differs from the dump")
+	fs.DurationVar(&opts.timeout, "timeout", 2*time.Minute, "gateway request timeout")
+	return cmd
+}
+
+// dumpReplayComparison is one compared part of the replay.
+type dumpReplayComparison struct {
+	Compared  bool   `json:"compared"`
+	Identical bool   `json:"identical"`
+	Note      string `json:"note,omitempty"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+type dumpReplayReport struct {
+	RequestID       string               `json:"request_id"`
+	ReplayRequestID string               `json:"replay_request_id"`
+	Mode            string               `json:"mode"`
+	CapturedStatus  int                  `json:"captured_status,omitempty"`
+	ReplayStatus    int                  `json:"replay_status"`
+	UpstreamRequest dumpReplayComparison `json:"upstream_request"`
+	ProxyResponse   dumpReplayComparison `json:"proxy_response"`
+}
+
+func (r dumpReplayReport) differs() bool {
+	return (r.UpstreamRequest.Compared && !r.UpstreamRequest.Identical) ||
+		(r.ProxyResponse.Compared && !r.ProxyResponse.Identical)
+}
+
+func runDumpReplay(stdout io.Writer, target string, opts dumpReplayOptions) error {
+	format := strings.ToLower(strings.TrimSpace(opts.format))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported --format %q (want text or json)", opts.format)
+	}
+	gateway := strings.TrimRight(strings.TrimSpace(opts.gateway), "/")
+	if gateway != "" && opts.fakeUpstream {
+		return errors.New("--fake-upstream only applies to in-process replay (drop --gateway)")
+	}
+	headers, err := parseReplayHeaders(opts.headers)
+	if err != nil {
+		return err
+	}
+	dir := resolveDumpDir(opts.cfgPath, opts.dir)
+	orig, err := loadReplayDump(dir, target)
+	if err != nil {
+		return err
+	}
+
+	ropts := onr.ReplayOptions{
+		FakeUpstream: opts.fakeUpstream,
+		Headers:      headers,
+		Provider:     strings.TrimSpace(opts.provider),
+	}
+	report := dumpReplayReport{RequestID: orig.RequestID, CapturedStatus: orig.ProxyStatus}
+	var (
+		status   int
+		body     []byte
+		newDump  *trafficdump.Dump
+		dumpNote string
+	)
+	if gateway == "" {
+		report.Mode = "in-process"
+		if opts.fakeUpstream {
+			report.Mode = "in-process, fake upstream"
+		}
+		ropts.RequestID = orig.RequestID + "-replay"
+		res, err := onr.Replay(strings.TrimSpace(opts.cfgPath), orig, ropts)
+		if err != nil {
+			return err
+		}
+		status, body, newDump = res.Status, res.Body, res.Dump
+	} else {
+		report.Mode = "gateway " + gateway
+		ropts.RequestID = orig.RequestID + "-replay-" + strconv.FormatInt(time.Now().Unix(), 10)
+		status, body, err = sendReplayToGateway(gateway, orig, ropts, opts.timeout)
+		if err != nil {
+			return err
+		}
+		newDump, err = waitForGatewayDump(dir, ropts.RequestID, opts.dumpWait)
+		if err != nil {
+			return err
+		}
+		if newDump == nil {
+			dumpNote = fmt.Sprintf("gateway wrote no traffic dump for %s in %s", ropts.RequestID, dir)
+		}
+	}
+	report.ReplayRequestID = ropts.RequestID
+	report.ReplayStatus = status
+
+	ignore := make(map[string]bool, len(opts.ignoreFields))
+	for _, f := range opts.ignoreFields {
+		if f = strings.TrimSpace(f); f != "" {
+			ignore[f] = true
+		}
+	}
+
+	switch {
+	case orig.UpstreamRequest == nil:
+		report.UpstreamRequest.Note = "dump has no upstream request section"
+	case newDump == nil:
+		report.UpstreamRequest.Note = dumpNote
+	case newDump.UpstreamRequest == nil:
+		report.UpstreamRequest = dumpReplayComparison{Compared: true, Note: "replay sent no upstream request"}
+	default:
+		report.UpstreamRequest = compareReplayText("upstream_request",
+			renderReplayMessage(orig.UpstreamRequest, ignore), renderReplayMessage(newDump.UpstreamRequest, ignore))
+	}
+
+	if orig.ProxyResponse == nil {
+		report.ProxyResponse.Note = "dump has no proxy response section"
+	} else {
+		replayBody := trafficdump.Body{Data: body}
+		if newDump != nil && newDump.ProxyResponse != nil {
+			// Same capture limits and redaction as the original dump.
+			replayBody = *newDump.ProxyResponse
+		}
+		report.ProxyResponse = compareReplayText("proxy_response",
+			fmt.Sprintf("status=%d\n\n%s", orig.ProxyStatus, renderReplayBody(*orig.ProxyResponse, ignore)),
+			fmt.Sprintf("status=%d\n\n%s", status, renderReplayBody(replayBody, ignore)))
+	}
+
+	if format == "json" {
+		if err := writeJSONLine(stdout, report); err != nil {
+			return err
+		}
+	} else if err := writeDumpReplayText(stdout, report); err != nil {
+		return err
+	}
+	if opts.exitCode && report.differs() {
+		return errors.New("replay differs from the dump")
+	}
+	return nil
+}
+
+// resolveDumpDir returns flagDir, traffic_dump.dir or ./dumps.
+func resolveDumpDir(cfgPath, flagDir string) string {
+	if dir := strings.TrimSpace(flagDir); dir != "" {
+		return dir
+	}
+	if cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(cfgPath)); cfg != nil && strings.TrimSpace(cfg.TrafficDump.Dir) != "" {
+		return strings.TrimSpace(cfg.TrafficDump.Dir)
+	}
+	return "./dumps"
+}
+
+// loadReplayDump parses target as a dump file path, or looks it up by request id in dir.
+func loadReplayDump(dir, target string) (*trafficdump.Dump, error) {
+	target = strings.TrimSpace(target)
+	if st, err := os.Stat(target); err == nil && !st.IsDir() {
+		d, err := trafficdump.ParseFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("parse dump %s: %w", target, err)
+		}
+		return d, nil
+	}
+	sum, found, err := store.FindDumpByRequestID(store.DumpFindOptions{Dir: dir, RequestID: target})
+	if err != nil {
+		return nil, fmt.Errorf("find dump: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no traffic dump for request id %q in %s", target, dir)
+	}
+	d, err := trafficdump.ParseFile(sum.Path)
+	if err != nil {
+		return nil, fmt.Errorf("parse dump %s: %w", sum.Path, err)
+	}
+	if d.RequestID == "" {
+		d.RequestID = target
+	}
+	return d, nil
+}
+
+func parseReplayHeaders(raw []string) (map[string]string, error) {
+	out := make(map[string]string, len(raw))
+	for _, h := range raw {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --header %q (want 'Key: Value')", h)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+func sendReplayToGateway(gateway string, orig *trafficdump.Dump, ropts onr.ReplayOptions, timeout time.Duration) (int, []byte, error) {
+	req, err := onr.NewReplayRequest(orig, ropts)
+	if err != nil {
+		return 0, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	out, err := http.NewRequestWithContext(ctx, req.Method, gateway+req.URL.RequestURI(), req.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("build gateway request: %w", err)
+	}
+	out.Header = req.Header
+	resp, err := http.DefaultClient.Do(out)
+	if err != nil {
+		return 0, nil, fmt.Errorf("send to gateway: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read gateway response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// waitForGatewayDump polls dir for the gateway's dump of rid; the gateway closes
+// the file just after the response, so it may appear a moment later. It returns
+// nil when the gateway has traffic dumps disabled or writes them elsewhere.
+func waitForGatewayDump(dir, rid string, wait time.Duration) (*trafficdump.Dump, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		sum, found, err := store.FindDumpByRequestID(store.DumpFindOptions{Dir: dir, RequestID: rid})
+		if err != nil {
+			return nil, fmt.Errorf("find gateway dump: %w", err)
+		}
+		if found {
+			d, err := trafficdump.ParseFile(sum.Path)
+			if err != nil {
+				return nil, fmt.Errorf("parse gateway dump %s: %w", sum.Path, err)
+			}
+			if d.ProxyResponse != nil || d.Stream != nil || !time.Now().Before(deadline) {
+				return d, nil
+			}
+		} else if !time.Now().Before(deadline) {
+			return nil, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func compareReplayText(name, captured, replay string) dumpReplayComparison {
+	if captured == replay {
+		return dumpReplayComparison{Compared: true, Identical: true}
+	}
+	return dumpReplayComparison{Compared: true, Diff: unifiedDiff(name, captured, replay)}
+}
+
+// replayVolatileHeaders differ on every request and are left out of diffs.
+var replayVolatileHeaders = map[string]bool{
+	"Content-Length":   true,
+	"X-Onr-Request-Id": true,
+	"X-Request-Id":     true,
+	"Date":             true,
+}
+
+// renderReplayMessage renders the request line, sorted headers and normalized body.
+func renderReplayMessage(m *trafficdump.Message, ignore map[string]bool) string {
+	var sb strings.Builder
+	sb.WriteString(m.Line)
+	sb.WriteByte('\n')
+	lines := make([]string, 0, len(m.Header))
+	for k, vals := range m.Header {
+		if replayVolatileHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		for _, v := range vals {
+			lines = append(lines, http.CanonicalHeaderKey(k)+": "+v)
+		}
+	}
+	sort.Strings(lines)
+	for _, l := range lines {
+		sb.WriteString(l)
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(renderReplayBody(m.Body, ignore))
+	return sb.String()
+}
+
+// renderReplayBody pretty-prints JSON bodies (and JSON SSE data lines) with keys
+// sorted and ignored fields removed, so only semantic changes show in the diff.
+func renderReplayBody(b trafficdump.Body, ignore map[string]bool) string {
+	var out string
+	switch {
+	case b.Omitted:
+		out = string(b.Data) + "\n"
+	case b.Binary:
+		out = fmt.Sprintf("[binary %d bytes]\n%s\n", len(b.Data), base64.StdEncoding.EncodeToString(b.Data))
+	default:
+		if v, ok := normalizeReplayJSON(b.Data, ignore); ok {
+			pretty, _ := json.MarshalIndent(v, "", "  ")
+			out = string(pretty) + "\n"
+			break
+		}
+		lines := strings.Split(strings.TrimRight(string(b.Data), "\n"), "\n")
+		for i, line := range lines {
+			payload, isData := strings.CutPrefix(line, "data:")
+			if !isData {
+				continue
+			}
+			if v, ok := normalizeReplayJSON([]byte(payload), ignore); ok {
+				compact, _ := json.Marshal(v)
+				lines[i] = "data: " + string(compact)
+			}
+		}
+		out = strings.Join(lines, "\n") + "\n"
+	}
+	if b.Truncated {
+		out += "[truncated]\n"
+	}
+	return out
+}
+
+func normalizeReplayJSON(raw []byte, ignore map[string]bool) (any, bool) {
+	t := bytes.TrimSpace(raw)
+	if len(t) == 0 || (t[0] != '{' && t[0] != '[') {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(t))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return dropReplayFields(v, ignore), true
+}
+
+func dropReplayFields(v any, ignore map[string]bool) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if ignore[k] {
+				delete(t, k)
+				continue
+			}
+			t[k] = dropReplayFields(child, ignore)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = dropReplayFields(child, ignore)
+		}
+	}
+	return v
+}
+
+func writeDumpReplayText(out io.Writer, r dumpReplayReport) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "replay %s -> %s (%s)\n", r.RequestID, r.ReplayRequestID, r.Mode)
+	for _, part := range []struct {
+		name string
+		c    dumpReplayComparison
+	}{
+		{"upstream request", r.UpstreamRequest},
+		{"proxy response", r.ProxyResponse},
+	} {
+		state := "identical"
+		switch {
+		case !part.c.Compared:
+			state = "not compared"
+		case !part.c.Identical:
+			state = "differs"
+		}
+		if part.name == "proxy response" {
+			state += fmt.Sprintf(" (status %d -> %d)", r.CapturedStatus, r.ReplayStatus)
+		}
+		if part.c.Note != "" {
+			state += ": " + part.c.Note
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", part.name, state)
+		sb.WriteString(part.c.Diff)
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const replayChatResponse = `{"id":"c1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`
+
+// writeReplayDump writes a dump whose captured upstream request used model upstreamModel.
+func writeReplayDump(t *testing.T, dir, rid, upstreamModel string) {
+	t.Helper()
+	dump := "=== META ===\ntime=2026-01-02T03:04:05Z\nrequest_id=" + rid + "\nmethod=POST\npath=/v1/chat/completions\nclient_ip=127.0.0.1\nheaders:\n" +
+		"  Authorization: [REDACTED]\n  Content-Type: application/json\n\n" +
+		"=== ORIGIN REQUEST ===\n{\"model\":\"gpt-4o-mini\",\"messages\":[{\"role\":\"user\",\"content\":\"hi\"}]}\n\n" +
+		"=== UPSTREAM REQUEST ===\nPOST https://api.openai.com/v1/chat/completions\n  Content-Type: application/json\n\n\n" +
+		"{\"model\":\"" + upstreamModel + "\",\"messages\":[{\"role\":\"user\",\"content\":\"hi\"}]}\n\n" +
+		"=== UPSTREAM RESPONSE ===\n200 OK\n  Content-Type: application/json\n\n\n" + replayChatResponse + "\n\n" +
+		"=== PROXY RESPONSE ===\nstatus=200\n\n\n" + replayChatResponse + "\n\n"
+	if err := os.WriteFile(filepath.Join(dir, rid+".log"), []byte(dump), 0o600); err != nil {
+		t.Fatalf("write dump: %v", err)
+	}
+}
+
+func TestDumpReplay_InProcessFakeUpstreamDiff(t *testing.T) {
+	t.Parallel()
+
+	cfgPath := writeExplainConfig(t)
+	dir := t.TempDir()
+	writeReplayDump(t, dir, "rid-1", "gpt-4o-old")
+
+	var out bytes.Buffer
+	err := runDumpReplay(&out, "rid-1", dumpReplayOptions{
+		cfgPath:      cfgPath,
+		dir:          dir,
+		fakeUpstream: true,
+		format:       "json",
+		exitCode:     true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "differs") {
+		t.Fatalf("err=%v\n%s", err, out.String())
+	}
+	var report dumpReplayReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	if report.ReplayRequestID != "rid-1-replay" || report.ReplayStatus != http.StatusOK {
+		t.Fatalf("report=%+v", report)
+	}
+	if !report.UpstreamRequest.Compared || report.UpstreamRequest.Identical ||
+		!strings.Contains(report.UpstreamRequest.Diff, `-  "model": "gpt-4o-old"`) ||
+		!strings.Contains(report.UpstreamRequest.Diff, `+  "model": "gpt-4o-mini"`) {
+		t.Fatalf("upstream request comparison=%+v", report.UpstreamRequest)
+	}
+	if !report.ProxyResponse.Identical {
+		t.Fatalf("proxy response comparison=%+v", report.ProxyResponse)
+	}
+}
+
+func TestDumpReplay_GatewayWithoutDump(t *testing.T) {
+	t.Parallel()
+
+	var gotRID, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRID = r.Header.Get("X-Onr-Request-Id")
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(strings.Replace(replayChatResponse, `"c1"`, `"c2"`, 1)))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeReplayDump(t, dir, "rid-2", "gpt-4o-mini")
+	var out bytes.Buffer
+	err := runDumpReplay(&out, filepath.Join(dir, "rid-2.log"), dumpReplayOptions{
+		gateway:      srv.URL,
+		dir:          dir,
+		headers:      []string{"Authorization: Bearer real"},
+		ignoreFields: []string{"id"},
+		format:       "text",
+		timeout:      5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("runDumpReplay: %v\n%s", err, out.String())
+	}
+	if !strings.HasPrefix(gotRID, "rid-2-replay-") || gotAuth != "Bearer real" {
+		t.Fatalf("gateway saw rid=%q auth=%q", gotRID, gotAuth)
+	}
+	got := out.String()
+	for _, want := range []string{"upstream request: not compared: gateway wrote no traffic dump", "proxy response: identical (status 200 -> 200)"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in output:\n%s", want, got)
+		}
+	}
+}
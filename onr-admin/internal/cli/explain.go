@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr"
+	"github.com/spf13/cobra"
+)
+
+type explainOptions struct {
+	cfgPath     string
+	requestPath string
+	stdin       io.Reader
+	stdout      io.Writer
+}
+
+// newExplainCmd returns a non-nil explain command.
+func newExplainCmd() *cobra.Command {
+	opts := explainOptions{cfgPath: "onr.yaml", stdin: os.Stdin, stdout: os.Stdout}
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Show the upstream call ONR would make for a request, without sending it",
+		Long: "Resolve a client request offline against the config (providers, keys, models) and print\n" +
+			"the selected provider/key/match block, the upstream URL, headers (secrets masked), body and\n" +
+			"the DSL directives that fired. The request file has the same shape as POST /admin/explain:\n" +
+			"  {\"path\": \"/v1/chat/completions\", \"headers\": {...}, \"provider\": \"openai\", \"body\": {...}}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExplain(opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.requestPath, "request", "", "explain request json file path ('-' for stdin)")
+	return cmd
+}
+
+func runExplain(opts explainOptions) error {
+	path := strings.TrimSpace(opts.requestPath)
+	if path == "" {
+		return errors.New("--request is required")
+	}
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = io.ReadAll(opts.stdin)
+	} else {
+		raw, err = os.ReadFile(path) // #nosec G304 -- path comes from CLI flag.
+	}
+	if err != nil {
+		return fmt.Errorf("read explain request: %w", err)
+	}
+	status, body, err := onr.Explain(strings.TrimSpace(opts.cfgPath), raw)
+	if err != nil {
+		return err
+	}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") != nil {
+		pretty.Reset()
+		pretty.Write(body)
+	}
+	pretty.WriteByte('\n')
+	if _, err := opts.stdout.Write(pretty.Bytes()); err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("explain: request would fail with status %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeExplainConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	keys := "providers:\n  openai:\n    keys:\n      - name: primary\n        value: sk-test-secret\n"
+	models := "models:\n  gpt-4o-mini:\n    providers: [openai]\n"
+	abs, err := filepath.Abs("../../../config/providers")
+	if err != nil {
+		t.Fatalf("abs: %v", err)
+	}
+	cfg := "providers:\n  dir: " + abs + "\nkeys:\n  file: " + filepath.Join(dir, "keys.yaml") + "\nmodels:\n  file: " + filepath.Join(dir, "models.yaml") + "\n"
+	for name, content := range map[string]string{"keys.yaml": keys, "models.yaml": models, "onr.yaml": cfg} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return filepath.Join(dir, "onr.yaml")
+}
+
+func TestExplain_PrintsUpstreamCall(t *testing.T) {
+	t.Parallel()
+
+	cfgPath := writeExplainConfig(t)
+	var out bytes.Buffer
+	err := runExplain(explainOptions{
+		cfgPath:     cfgPath,
+		requestPath: "-",
+		stdin:       strings.NewReader(`{"path":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hi"}]}}`),
+		stdout:      &out,
+	})
+	if err != nil {
+		t.Fatalf("runExplain: %v\n%s", err, out.String())
+	}
+	got := out.String()
+	for _, want := range []string{`"provider": "openai"`, `"key": "primary"`, `"url": "https://api.openai.com/v1/chat/completions"`, `"directive": "set_path"`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %s in output:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "sk-test-secret") {
+		t.Fatalf("upstream key leaked in output:\n%s", got)
+	}
+}
+
+func TestExplain_FailingRequest(t *testing.T) {
+	t.Parallel()
+
+	cfgPath := writeExplainConfig(t)
+	var out bytes.Buffer
+	err := runExplain(explainOptions{
+		cfgPath:     cfgPath,
+		requestPath: "-",
+		stdin:       strings.NewReader(`{"path":"/v1/chat/completions","body":{"model":"nope"}}`),
+		stdout:      &out,
+	})
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !strings.Contains(out.String(), "provider_not_selected") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestExplain_RequiresRequest(t *testing.T) {
+	t.Parallel()
+
+	err := runExplain(explainOptions{cfgPath: "onr.yaml", stdout: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "--request is required") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
@@ -28,7 +28,7 @@ func newModelsCmd() *cobra.Command {
 		Use:   "models",
 		Short: "Query upstream models via providers DSL",
 	}
-	cmd.AddCommand(newModelsGetCmd())
+	cmd.AddCommand(newModelsGetCmd(), newModelsSyncCmd())
 	return cmd
 }
 
@@ -141,42 +141,7 @@ func runModelsGetWithOptions(opts modelsGetOptions) error {
 			}
 			continue
 		}
-		meta := dslmeta.Meta{
-			API:                 api,
-			IsStream:            opts.stream,
-			APIKey:              keyCfg.APIKey,
-			CredentialFile:      keyCfg.CredentialFile,
-			CredentialProjectID: keyCfg.CredentialProjectID,
-			ChannelLocation:     keyCfg.Location,
-			AWSAccessKeyID:      keyCfg.AWSAccessKeyID,
-			AWSSecretAccessKey:  keyCfg.AWSSecretAccessKey,
-			AWSSessionToken:     keyCfg.AWSSessionToken,
-			AWSRegion:           keyCfg.AWSRegion,
-		}
-
-		if err := prepareOAuthForModels(oauth, p, &pf, &meta); err != nil {
-			fail++
-			fmt.Printf("provider=%s error=%q\n", p, err.Error())
-			if opts.failFast {
-				return err
-			}
-			continue
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		result, qerr := modelsquery.Query(ctx, modelsquery.Params{
-			Provider:           p,
-			File:               pf,
-			Meta:               &meta,
-			BaseURL:            keyCfg.BaseURL,
-			APIKey:             keyCfg.APIKey,
-			AWSAccessKeyID:     keyCfg.AWSAccessKeyID,
-			AWSSecretAccessKey: keyCfg.AWSSecretAccessKey,
-			AWSSessionToken:    keyCfg.AWSSessionToken,
-			AWSRegion:          keyCfg.AWSRegion,
-			DebugOut:           debugOut,
-		})
-		cancel()
+		result, qerr := queryProviderModels(oauth, p, pf, api, opts.stream, keyCfg, debugOut)
 		if qerr != nil {
 			fail++
 			fmt.Printf("provider=%s error=%q\n", p, qerr.Error())
@@ -211,17 +176,22 @@ type providerKeyConfig struct {
 }
 
 func resolveProviderKeyConfig(ks *keystore.Store, provider, keyIn, baseURLIn string) (providerKeyConfig, error) {
+	next, found := ks.NextKey(provider)
+	if !found {
+		return providerKeyConfig{
+			APIKey:  strings.TrimSpace(keyIn),
+			BaseURL: strings.TrimSpace(baseURLIn),
+		}, nil
+	}
+	return providerKeyConfigFromKey(*next, keyIn, baseURLIn)
+}
+
+// providerKeyConfigFromKey fills the fields missing from keyIn/baseURLIn from next.
+func providerKeyConfigFromKey(next keystore.Key, keyIn, baseURLIn string) (providerKeyConfig, error) {
 	out := providerKeyConfig{
 		APIKey:  strings.TrimSpace(keyIn),
 		BaseURL: strings.TrimSpace(baseURLIn),
 	}
-	if ks == nil {
-		return out, nil
-	}
-	next, found := ks.NextKey(provider)
-	if !found {
-		return out, nil
-	}
 	if out.APIKey == "" {
 		out.APIKey = strings.TrimSpace(next.Value)
 	}
@@ -247,6 +217,39 @@ func resolveProviderKeyConfig(ks *keystore.Store, provider, keyIn, baseURLIn str
 	return out, nil
 }
 
+// queryProviderModels fetches the upstream model ids of provider with keyCfg.
+func queryProviderModels(oauth *oauthclient.Client, provider string, pf dslconfig.ProviderFile, api string, stream bool, keyCfg providerKeyConfig, debugOut io.Writer) (*modelsquery.Result, error) {
+	meta := dslmeta.Meta{
+		API:                 api,
+		IsStream:            stream,
+		APIKey:              keyCfg.APIKey,
+		CredentialFile:      keyCfg.CredentialFile,
+		CredentialProjectID: keyCfg.CredentialProjectID,
+		ChannelLocation:     keyCfg.Location,
+		AWSAccessKeyID:      keyCfg.AWSAccessKeyID,
+		AWSSecretAccessKey:  keyCfg.AWSSecretAccessKey,
+		AWSSessionToken:     keyCfg.AWSSessionToken,
+		AWSRegion:           keyCfg.AWSRegion,
+	}
+	if err := prepareOAuthForModels(oauth, provider, &pf, &meta); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return modelsquery.Query(ctx, modelsquery.Params{
+		Provider:           provider,
+		File:               pf,
+		Meta:               &meta,
+		BaseURL:            keyCfg.BaseURL,
+		APIKey:             keyCfg.APIKey,
+		AWSAccessKeyID:     keyCfg.AWSAccessKeyID,
+		AWSSecretAccessKey: keyCfg.AWSSecretAccessKey,
+		AWSSessionToken:    keyCfg.AWSSessionToken,
+		AWSRegion:          keyCfg.AWSRegion,
+		DebugOut:           debugOut,
+	})
+}
+
 func credentialProjectIDFromFile(path string) (string, error) {
 	p := strings.TrimSpace(path)
 	if p == "" {
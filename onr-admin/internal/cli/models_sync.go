@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr"
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/modelsync"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/oauthclient"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+type modelsSyncOptions struct {
+	cfgPath      string
+	keysPath     string
+	modelsPath   string
+	providersDir string
+
+	provider     string
+	providersCSV string
+	allProviders bool
+
+	api     string
+	allow   []string
+	deny    []string
+	addOnly bool
+	ownedBy string
+
+	dryRun bool
+	backup bool
+	reload bool
+	format string
+
+	out io.Writer
+}
+
+// newModelsSyncCmd returns a non-nil models sync command.
+func newModelsSyncCmd() *cobra.Command {
+	opts := modelsSyncOptions{
+		cfgPath: "onr.yaml",
+		backup:  true,
+		format:  "text",
+	}
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Rewrite models.yaml routes from the providers' upstream model lists",
+		Long: "Query every key of each provider through the DSL models block, merge the union of\n" +
+			"model -> providers into models.yaml (models.sync allow/deny/aliases apply), print\n" +
+			"the diff and write the file atomically. Providers whose keys all answered are\n" +
+			"authoritative: they are removed from routes of models they no longer list.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.out = cmd.OutOrStdout()
+			return runModelsSync(opts)
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.keysPath, "keys", "", "keys.yaml path")
+	fs.StringVar(&opts.modelsPath, "models", "", "models.yaml path")
+	fs.StringVar(&opts.providersDir, "providers-dir", "", "providers dir path")
+	fs.StringVarP(&opts.provider, "provider", "p", "", "sync a single provider")
+	fs.StringVar(&opts.providersCSV, "providers", "", "providers to sync, comma separated (default: models.sync.providers or all)")
+	fs.BoolVar(&opts.allProviders, "all", false, "sync every provider with keys and a models block")
+	fs.StringVar(&opts.api, "api", "", "api name for DSL auth/models selection (default: models.sync.api)")
+	fs.StringArrayVar(&opts.allow, "allow", nil, "keep only model ids matching this regexp (repeatable, added to models.sync.allow)")
+	fs.StringArrayVar(&opts.deny, "deny", nil, "drop model ids matching this regexp (repeatable, added to models.sync.deny)")
+	fs.BoolVar(&opts.addOnly, "add-only", false, "never remove providers or routes")
+	fs.StringVar(&opts.ownedBy, "owned-by", "", "owned_by of added routes (default: models.sync.owned_by)")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print the diff without writing models.yaml")
+	fs.BoolVar(&opts.backup, "backup", true, "backup models.yaml before overwrite")
+	fs.BoolVar(&opts.reload, "reload", false, "signal the running onr (server.pid_file) to reload after writing")
+	fs.StringVar(&opts.format, "format", "text", "output format: text|json")
+	return cmd
+}
+
+type modelsSyncReport struct {
+	ModelsFile string                   `json:"models_file"`
+	Providers  []modelsSyncProviderLine `json:"providers"`
+	Changes    []modelsync.Change       `json:"changes"`
+	Added      int                      `json:"added"`
+	Updated    int                      `json:"updated"`
+	Removed    int                      `json:"removed"`
+	Written    bool                     `json:"written"`
+	Reloaded   bool                     `json:"reloaded"`
+}
+
+type modelsSyncProviderLine struct {
+	Provider string `json:"provider"`
+	Keys     int    `json:"keys"`
+	Models   int    `json:"models"`
+	Error    string `json:"error,omitempty"`
+}
+
+func runModelsSync(opts modelsSyncOptions) error {
+	if opts.out == nil {
+		opts.out = os.Stdout
+	}
+	format := strings.ToLower(strings.TrimSpace(opts.format))
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported --format %q (want text or json)", opts.format)
+	}
+	cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(opts.cfgPath))
+	var sc config.ModelsSyncConfig
+	if cfg != nil {
+		sc = cfg.Models.Sync
+	}
+	keysPath, modelsPath := store.ResolveDataPaths(cfg, opts.keysPath, opts.modelsPath)
+	providersDir := resolveProviderSourcePath(cfg, opts.providersDir)
+
+	rules, err := modelsync.Compile(modelsync.RulesConfig{
+		Allow:   append(append([]string(nil), sc.Allow...), opts.allow...),
+		Deny:    append(append([]string(nil), sc.Deny...), opts.deny...),
+		Aliases: sc.Aliases,
+	})
+	if err != nil {
+		return err
+	}
+	api := firstNonEmpty(opts.api, sc.API, "chat.completions")
+	ownedBy := firstNonEmpty(opts.ownedBy, sc.OwnedBy)
+
+	reg, _, err := loadRegistryFromProviderSource(providersDir)
+	if err != nil {
+		return fmt.Errorf("load providers %s failed: %w", providersDir, err)
+	}
+	ks, err := keystore.Load(strings.TrimSpace(keysPath))
+	if err != nil {
+		return fmt.Errorf("load keys.yaml failed: %w", err)
+	}
+	targets, err := resolveModelsSyncTargets(reg, ks, api, opts, sc.Providers)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G304 -- models path comes from trusted config/flag.
+	raw, err := os.ReadFile(modelsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read models file: %w", err)
+	}
+	current, err := modelsync.ParseRoutes(raw)
+	if err != nil {
+		return err
+	}
+
+	oauth := oauthclient.New(nil, false, "")
+	fetch := func(_ context.Context, provider string, k keystore.Key) ([]string, error) {
+		pf, ok := reg.GetProvider(provider)
+		if !ok {
+			return nil, errors.New("provider not found in registry")
+		}
+		keyCfg, err := providerKeyConfigFromKey(k, "", "")
+		if err != nil {
+			return nil, err
+		}
+		res, err := queryProviderModels(oauth, provider, pf, api, false, keyCfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		return res.IDs, nil
+	}
+	snap := modelsync.Collect(context.Background(), targets, ks.Keys, fetch)
+	plan := modelsync.NewPlan(current, snap, modelsync.PlanOptions{Rules: rules, AddOnly: opts.addOnly, OwnedBy: ownedBy})
+
+	report := modelsSyncReport{ModelsFile: modelsPath, Changes: plan.Changes}
+	report.Added, report.Updated, report.Removed = plan.Counts()
+	if report.Changes == nil {
+		report.Changes = []modelsync.Change{}
+	}
+	failed := 0
+	for _, pr := range snap.Providers {
+		line := modelsSyncProviderLine{Provider: pr.Provider, Keys: pr.Keys, Models: len(pr.IDs)}
+		if err := pr.Err(); err != nil {
+			line.Error = err.Error()
+			failed++
+		}
+		report.Providers = append(report.Providers, line)
+	}
+
+	if !opts.dryRun && !plan.Empty() {
+		out, err := modelsync.Apply(raw, plan)
+		if err != nil {
+			return err
+		}
+		if _, err := modelsync.ParseRoutes(out); err != nil {
+			return fmt.Errorf("validate synced models.yaml: %w", err)
+		}
+		if err := store.WriteAtomic(modelsPath, out, opts.backup); err != nil {
+			return fmt.Errorf("write models file: %w", err)
+		}
+		report.Written = true
+		if opts.reload {
+			if err := onr.SendReload(opts.cfgPath); err != nil {
+				return fmt.Errorf("models.yaml written but reload failed: %w", err)
+			}
+			report.Reloaded = true
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(opts.out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		writeModelsSyncText(opts.out, report, opts.dryRun, opts.cfgPath)
+	}
+	if failed > 0 {
+		return fmt.Errorf("models sync completed with %d provider failure(s)", failed)
+	}
+	return nil
+}
+
+// resolveModelsSyncTargets uses the provider flags, then models.sync.providers,
+// then every provider that has keys and a models block for api.
+func resolveModelsSyncTargets(reg *dslconfig.Registry, ks *keystore.Store, api string, opts modelsSyncOptions, configured []string) ([]string, error) {
+	count := 0
+	for _, set := range []bool{strings.TrimSpace(opts.provider) != "", strings.TrimSpace(opts.providersCSV) != "", opts.allProviders} {
+		if set {
+			count++
+		}
+	}
+	if count > 1 {
+		return nil, errors.New("provider flags are mutually exclusive: use only one of --provider/-p, --providers, --all")
+	}
+	if count == 1 && !opts.allProviders {
+		return resolveTargetProviders(reg, opts.provider, opts.providersCSV, false)
+	}
+	if !opts.allProviders && len(configured) > 0 {
+		return resolveTargetProviders(reg, "", strings.Join(configured, ","), false)
+	}
+	var out []string
+	for _, name := range reg.ListProviderNames() {
+		pf, ok := reg.GetProvider(name)
+		if !ok || !ks.HasProvider(name) {
+			continue
+		}
+		if _, ok := pf.Models.Select(&dslmeta.Meta{API: api}); !ok {
+			continue
+		}
+		out = append(out, name)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no provider has both keys and a DSL models block")
+	}
+	return out, nil
+}
+
+func writeModelsSyncText(w io.Writer, r modelsSyncReport, dryRun bool, cfgPath string) {
+	for _, p := range r.Providers {
+		if p.Error != "" {
+			_, _ = fmt.Fprintf(w, "provider=%s keys=%d models=%d error=%q\n", p.Provider, p.Keys, p.Models, p.Error)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "provider=%s keys=%d models=%d\n", p.Provider, p.Keys, p.Models)
+	}
+	for _, c := range r.Changes {
+		switch c.Op {
+		case modelsync.OpAdd:
+			_, _ = fmt.Fprintf(w, "+ %s [%s]\n", c.Model, strings.Join(c.After, ", "))
+		case modelsync.OpRemove:
+			_, _ = fmt.Fprintf(w, "- %s [%s]\n", c.Model, strings.Join(c.Before, ", "))
+		default:
+			var delta []string
+			for _, p := range c.Added() {
+				delta = append(delta, "+"+p)
+			}
+			for _, p := range c.Removed() {
+				delta = append(delta, "-"+p)
+			}
+			_, _ = fmt.Fprintf(w, "~ %s [%s] -> [%s] (%s)\n", c.Model, strings.Join(c.Before, ", "), strings.Join(c.After, ", "), strings.Join(delta, " "))
+		}
+	}
+	_, _ = fmt.Fprintf(w, "summary added=%d updated=%d removed=%d\n", r.Added, r.Updated, r.Removed)
+	switch {
+	case len(r.Changes) == 0:
+		_, _ = fmt.Fprintf(w, "%s is up to date\n", r.ModelsFile)
+	case dryRun:
+		_, _ = fmt.Fprintln(w, "dry run: models.yaml not written")
+	case r.Written:
+		_, _ = fmt.Fprintf(w, "wrote %s\n", r.ModelsFile)
+		if !r.Reloaded {
+			printUpdateApplyHint(w, cfgPath)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunModelsSync_RewritesModelsYAML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("Authorization") {
+		case "Bearer key-a":
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"gpt-4o-audio-preview"}]}`))
+		case "Bearer key-b":
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"gpt-5"}]}`))
+		default:
+			t.Fatalf("auth=%q", r.Header.Get("Authorization"))
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	providersDir := filepath.Join(dir, "providers")
+	if err := os.MkdirAll(providersDir, 0o750); err != nil {
+		t.Fatalf("mkdir providers: %v", err)
+	}
+	conf := `
+syntax "next-router/0.1";
+
+provider "openai" {
+  defaults {
+    upstream_config {
+      base_url = "` + srv.URL + `";
+    }
+    auth {
+      auth_bearer;
+    }
+    models {
+      models_mode openai;
+    }
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(providersDir, "openai.conf"), []byte(conf), 0o600); err != nil {
+		t.Fatalf("write provider conf: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "onr.conf"), []byte("syntax \"next-router/0.1\";\n\nmodels_mode \"openai\" {}\n"), 0o600); err != nil {
+		t.Fatalf("write onr.conf: %v", err)
+	}
+	keysPath := filepath.Join(dir, "keys.yaml")
+	keysYAML := `
+providers:
+  openai:
+    keys:
+      - name: a
+        value: key-a
+      - name: b
+        value: key-b
+`
+	if err := os.WriteFile(keysPath, []byte(keysYAML), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	modelsPath := filepath.Join(dir, "models.yaml")
+	modelsYAML := `models:
+  # kept: anthropic is not synced
+  claude-haiku-4-5:
+    providers:
+      - anthropic
+  gpt-4-legacy:
+    providers:
+      - openai
+`
+	if err := os.WriteFile(modelsPath, []byte(modelsYAML), 0o600); err != nil {
+		t.Fatalf("write models: %v", err)
+	}
+	cfgPath := filepath.Join(dir, "onr.yaml")
+	cfgYAML := "auth:\n  api_key: \"x\"\nkeys:\n  file: \"" + keysPath + "\"\nmodels:\n  file: \"" + modelsPath + "\"\n  sync:\n    deny: [\"-audio-\"]\n    owned_by: sync\nproviders:\n  dir: \"" + providersDir + "\"\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var dry bytes.Buffer
+	if err := runModelsSync(modelsSyncOptions{cfgPath: cfgPath, dryRun: true, format: "text", out: &dry}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	for _, want := range []string{"provider=openai keys=2 models=3", "+ gpt-4o [openai]", "+ gpt-5 [openai]", "- gpt-4-legacy [openai]", "summary added=2 updated=0 removed=1", "dry run"} {
+		if !strings.Contains(dry.String(), want) {
+			t.Fatalf("dry-run output missing %q:\n%s", want, dry.String())
+		}
+	}
+	if b, _ := os.ReadFile(modelsPath); string(b) != modelsYAML {
+		t.Fatalf("dry run modified models.yaml:\n%s", b)
+	}
+
+	var out bytes.Buffer
+	if err := runModelsSync(modelsSyncOptions{cfgPath: cfgPath, format: "json", out: &out}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	var report modelsSyncReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if !report.Written || report.Added != 2 || report.Removed != 1 {
+		t.Fatalf("report=%+v", report)
+	}
+	b, err := os.ReadFile(modelsPath)
+	if err != nil {
+		t.Fatalf("read models: %v", err)
+	}
+	got := string(b)
+	for _, want := range []string{"# kept: anthropic is not synced", "claude-haiku-4-5:", "gpt-5:", "owned_by: sync"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("models.yaml missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "gpt-4-legacy") || strings.Contains(got, "audio") {
+		t.Fatalf("unexpected route in models.yaml:\n%s", got)
+	}
+
+	var again bytes.Buffer
+	if err := runModelsSync(modelsSyncOptions{cfgPath: cfgPath, format: "text", backup: true, out: &again}); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if !strings.Contains(again.String(), "is up to date") {
+		t.Fatalf("second sync output:\n%s", again.String())
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
 	"github.com/r9s-ai/open-next-router/onr-admin/internal/tui"
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+	"github.com/r9s-ai/open-next-router/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -25,16 +28,24 @@ func newRootCmd() *cobra.Command {
 		Short:         "ONR admin CLI",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			configureAudit(cmd)
+		},
 	}
 	cmd.AddCommand(
+		newAuditCmd(),
 		newTokenCmd(),
 		newOAuthCmd(),
 		newCryptoCmd(),
 		newValidateCmd(),
+		newDSLCmd(),
+		newDumpCmd(),
+		newExplainCmd(),
 		newBalanceCmd(),
 		newModelsCmd(),
 		newPricingCmd(),
 		newUpdateCmd(),
+		newUsageCmd(),
 		newVersionCmd(),
 		newWebCmd(),
 		newTUICmd(),
@@ -42,6 +53,22 @@ func newRootCmd() *cobra.Command {
 	return cmd
 }
 
+// configureAudit enables audit logging for the invoked command using its --config flag when present.
+func configureAudit(cmd *cobra.Command) {
+	var cfg *config.Config
+	if f := cmd.Flags().Lookup("config"); f != nil {
+		cfg, _ = store.LoadConfigIfExists(strings.TrimSpace(f.Value.String()))
+	}
+	source := audit.SourceCLI
+	switch cmd.Name() {
+	case "web":
+		source = audit.SourceWeb
+	case "tui":
+		source = audit.SourceTUI
+	}
+	store.ConfigureAudit(cfg, source)
+}
+
 // newTUICmd returns a non-nil TUI command.
 func newTUICmd() *cobra.Command {
 	opts := tuiOptions{
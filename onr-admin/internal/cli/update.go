@@ -356,6 +356,7 @@ func updateMergedProviderSource(source providersource.Info, names []string, file
 		return 0, unchanged, nil
 	}
 	if err := validateMergedProviderSourceCandidate(source, updated); err != nil {
+		store.AuditRejected("", "providers.update", source.EditablePath, []byte(updated), err)
 		return 0, 0, err
 	}
 	if backup {
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
+	"github.com/spf13/cobra"
+)
+
+type usageReportOptions struct {
+	cfgPath string
+	dir     string
+	groupBy string
+	since   string
+	until   string
+	format  string
+	output  string
+	stderr  io.Writer
+}
+
+// newUsageCmd returns a non-nil usage command.
+func newUsageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Usage ledger reports",
+	}
+	cmd.AddCommand(newUsageReportCmd())
+	return cmd
+}
+
+// newUsageReportCmd returns a non-nil usage report command.
+func newUsageReportCmd() *cobra.Command {
+	opts := usageReportOptions{cfgPath: "onr.yaml", groupBy: "access_key,model", format: "table"}
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Aggregate the usage ledger for chargeback",
+		Long: "Aggregate usage ledger records written by onr (usage_ledger.enabled) into requests,\n" +
+			"errors, latency, token usage and cost per group.\n" +
+			"Group-by fields: " + strings.Join(usageledger.GroupByFields(), ", ") + ".",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.stderr = cmd.ErrOrStderr()
+			return runUsageReport(cmd.OutOrStdout(), opts, time.Now())
+		},
+	}
+	fs := cmd.Flags()
+	fs.StringVar(&opts.cfgPath, "config", "onr.yaml", "config yaml path")
+	fs.StringVar(&opts.dir, "dir", "", "ledger directory (overrides usage_ledger.dir)")
+	fs.StringVar(&opts.groupBy, "group-by", "access_key,model", "comma-separated group-by fields")
+	fs.StringVar(&opts.since, "since", "", "only records at or after this time (RFC3339, YYYY-MM-DD, a duration like 24h, or days like 7d)")
+	fs.StringVar(&opts.until, "until", "", "only records before this time (same formats as --since)")
+	fs.StringVar(&opts.format, "format", "table", "output format: table, csv, json or parquet")
+	fs.StringVarP(&opts.output, "output", "o", "", "write to this file instead of stdout (required for parquet)")
+	return cmd
+}
+
+func runUsageReport(stdout io.Writer, opts usageReportOptions, now time.Time) error {
+	format := strings.ToLower(strings.TrimSpace(opts.format))
+	switch format {
+	case "table", "csv", "json", "parquet":
+	default:
+		return fmt.Errorf("unsupported --format %q (want table, csv, json or parquet)", opts.format)
+	}
+	output := strings.TrimSpace(opts.output)
+	if format == "parquet" && output == "" {
+		return errors.New("--format parquet requires --output")
+	}
+	dir := strings.TrimSpace(opts.dir)
+	if dir == "" {
+		dir = "./usage"
+		if cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(opts.cfgPath)); cfg != nil && strings.TrimSpace(cfg.UsageLedger.Dir) != "" {
+			dir = strings.TrimSpace(cfg.UsageLedger.Dir)
+		}
+	}
+	since, err := parseAuditTime(opts.since, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseAuditTime(opts.until, now)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	records, skipped, err := usageledger.Read(dir, usageledger.Filter{Since: since, Until: until})
+	if err != nil {
+		return fmt.Errorf("read usage ledger %s: %w", dir, err)
+	}
+	if skipped > 0 && opts.stderr != nil {
+		_, _ = fmt.Fprintf(opts.stderr, "warning: skipped %d malformed line(s) in usage ledger %s\n", skipped, dir)
+	}
+	report, err := usageledger.Aggregate(records, strings.Split(opts.groupBy, ","))
+	if err != nil {
+		return err
+	}
+
+	out := stdout
+	var file *os.File
+	if output != "" {
+		file, err = os.Create(output) // #nosec G304 -- admin CLI writes to a user-selected report path.
+		if err != nil {
+			return fmt.Errorf("create %s: %w", output, err)
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+	switch format {
+	case "csv":
+		err = report.WriteCSV(out)
+	case "json":
+		err = writeJSONLine(out, report)
+	case "parquet":
+		err = report.WriteParquet(out)
+	default:
+		err = writeUsageTable(out, report)
+	}
+	if err != nil {
+		return fmt.Errorf("write usage report: %w", err)
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("close %s: %w", output, err)
+		}
+	}
+	return nil
+}
+
+// writeUsageTable prints the report with aligned columns; usage keys that are
+// zero in every group are omitted to keep the table narrow.
+func writeUsageTable(out io.Writer, r usageledger.Report) error {
+	usageKeys := make([]string, 0, len(r.UsageKeys))
+	for _, k := range r.UsageKeys {
+		for _, g := range r.Groups {
+			if g.Usage[k] != 0 {
+				usageKeys = append(usageKeys, k)
+				break
+			}
+		}
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := append(append(append([]string{}, r.GroupBy...), "requests", "errors", "avg_latency_ms"), usageKeys...)
+	_, _ = fmt.Fprintln(tw, strings.Join(append(header, "cost"), "\t"))
+	for _, g := range r.Groups {
+		row := append([]string{}, g.Keys...)
+		avg := int64(0)
+		if g.Requests > 0 {
+			avg = g.LatencyMs / g.Requests
+		}
+		row = append(row, strconv.FormatInt(g.Requests, 10), strconv.FormatInt(g.Errors, 10), strconv.FormatInt(avg, 10))
+		for _, k := range usageKeys {
+			row = append(row, strconv.FormatFloat(g.Usage[k], 'f', -1, 64))
+		}
+		cost := strconv.FormatFloat(g.Cost, 'f', 6, 64)
+		if g.CostUnit != "" {
+			cost += " " + g.CostUnit
+		}
+		_, _ = fmt.Fprintln(tw, strings.Join(append(row, cost), "\t"))
+	}
+	return tw.Flush()
+}
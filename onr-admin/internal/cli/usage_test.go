@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
+)
+
+func TestRunUsageReport(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	ledger, err := usageledger.Open(dir, usageledger.Options{})
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	for _, r := range []usageledger.Record{
+		{Time: now.AddDate(0, 0, -10), AccessKey: "old", Model: "m", Status: 200, Cost: 9},
+		{Time: now.Add(-time.Hour), AccessKey: "team-a", Model: "gpt-4o-mini", Status: 200, LatencyMs: 10, Usage: map[string]float64{"input_tokens": 100}, Cost: 0.2, CostUnit: "USD"},
+		{Time: now.Add(-2 * time.Hour), AccessKey: "team-a", Model: "gpt-4o-mini", Status: 502, LatencyMs: 30},
+	} {
+		if err := ledger.Append(r); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	_ = ledger.Close()
+
+	var out bytes.Buffer
+	opts := usageReportOptions{dir: dir, groupBy: "access_key,model", since: "7d", format: "csv"}
+	if err := runUsageReport(&out, opts, now); err != nil {
+		t.Fatalf("runUsageReport: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "team-a,gpt-4o-mini,2,1,20,100,") {
+		t.Fatalf("unexpected csv:\n%s", out.String())
+	}
+
+	out.Reset()
+	opts.format = "table"
+	if err := runUsageReport(&out, opts, now); err != nil {
+		t.Fatalf("runUsageReport table: %v", err)
+	}
+	if !strings.Contains(out.String(), "input_tokens") || strings.Contains(out.String(), "cache_read_tokens") || !strings.Contains(out.String(), "0.200000 USD") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+
+	// A torn trailing line (crash mid-append) is skipped with a warning.
+	if err := os.WriteFile(filepath.Join(dir, "usage-2026-05-19.jsonl"), []byte(`{"ts":"2026-05-19T10:00:00Z","access_ke`), 0o600); err != nil {
+		t.Fatalf("write torn ledger: %v", err)
+	}
+	var errOut bytes.Buffer
+	out.Reset()
+	opts.format, opts.stderr = "csv", &errOut
+	if err := runUsageReport(&out, opts, now); err != nil {
+		t.Fatalf("runUsageReport with torn line: %v", err)
+	}
+	if !strings.Contains(out.String(), "team-a,gpt-4o-mini,2,1,") || !strings.Contains(errOut.String(), "skipped 1 malformed line(s)") {
+		t.Fatalf("unexpected output with torn line:\n%s\nstderr: %s", out.String(), errOut.String())
+	}
+
+	opts.format = "parquet"
+	if err := runUsageReport(&out, opts, now); err == nil {
+		t.Fatalf("expected parquet without --output to fail")
+	}
+	opts.output = filepath.Join(t.TempDir(), "report.parquet")
+	if err := runUsageReport(&out, opts, now); err != nil {
+		t.Fatalf("runUsageReport parquet: %v", err)
+	}
+	b, err := os.ReadFile(opts.output)
+	if err != nil || !bytes.HasPrefix(b, []byte("PAR1")) {
+		t.Fatalf("unexpected parquet file err=%v", err)
+	}
+
+	opts = usageReportOptions{dir: dir, groupBy: "tenant", format: "csv"}
+	if err := runUsageReport(&out, opts, now); err == nil || !strings.Contains(err.Error(), "unsupported group-by") {
+		t.Fatalf("expected group-by error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"strings"
 
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/oauthflow"
 	adminweb "github.com/r9s-ai/open-next-router/onr-admin/internal/web"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +12,9 @@ type webOptions struct {
 	cfgPath      string
 	providersDir string
 	listen       string
+
+	trustedProxies    []string
+	oauthCallbackPort int
 }
 
 // newWebCmd returns a non-nil web command.
@@ -31,6 +35,12 @@ Environment variables:
   ONR_ADMIN_WEB_CURL_API_BASE_URL
     Default API base URL prefilled in the web UI curl/test request area.
     Default: http://127.0.0.1:3300
+
+  ONR_ADMIN_WEB_TRUSTED_PROXIES
+    Comma-separated IPs/CIDRs used when --trusted-proxy is not set.
+    Only requests from these addresses may name the audit actor via
+    X-Forwarded-User / X-Remote-User / X-Auth-Request-User; other requests
+    are audited as web@<client address>.
 `),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runWebWithOptions(opts)
@@ -40,6 +50,8 @@ Environment variables:
 	fs.StringVarP(&opts.cfgPath, "config", "c", "onr.yaml", "config yaml path")
 	fs.StringVar(&opts.providersDir, "providers-dir", "", "providers dir path")
 	fs.StringVar(&opts.listen, "listen", "", "http listen address (overrides ONR_ADMIN_WEB_LISTEN)")
+	fs.IntVar(&opts.oauthCallbackPort, "oauth-callback-port", oauthflow.DefaultCallbackPort, "redirect listener port for OAuth onboarding without redirect_uri (0 picks an ephemeral port; ports < 1024 are rejected)")
+	fs.StringSliceVar(&opts.trustedProxies, "trusted-proxy", nil, "IP/CIDR of an authenticating reverse proxy allowed to set the audit user (repeatable; overrides ONR_ADMIN_WEB_TRUSTED_PROXIES)")
 	return cmd
 }
 
@@ -48,5 +60,8 @@ func runWebWithOptions(opts webOptions) error {
 		ConfigPath:   strings.TrimSpace(opts.cfgPath),
 		ProvidersDir: strings.TrimSpace(opts.providersDir),
 		Listen:       strings.TrimSpace(opts.listen),
+
+		TrustedProxies:    opts.trustedProxies,
+		OAuthCallbackPort: opts.oauthCallbackPort,
 	})
 }
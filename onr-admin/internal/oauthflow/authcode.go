@@ -0,0 +1,411 @@
+package oauthflow
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCallbackPort is the local port of the auth-code redirect listener.
+const DefaultCallbackPort = 2468
+
+type PKCE struct {
+	Verifier  string
+	Challenge string
+}
+
+// Callback is the query of an OAuth redirect to the callback URL.
+type Callback struct {
+	Code  string
+	State string
+	Err   string
+}
+
+type tokenResp struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthCodeFlow is one pending authorization-code login.
+type AuthCodeFlow struct {
+	Settings    Settings
+	RedirectURI string
+	State       string
+	LoginURL    string
+	pkce        PKCE
+}
+
+// DefaultRedirectURI returns the loopback redirect URI for port.
+func DefaultRedirectURI(port int) string {
+	return fmt.Sprintf("http://localhost:%d/auth/callback", port)
+}
+
+// StartAuthCode prepares state, PKCE and the login URL of an auth-code flow.
+// redirectURI must point at a loopback host.
+func StartAuthCode(s Settings, redirectURI string) (*AuthCodeFlow, error) {
+	redirectURI = strings.TrimSpace(redirectURI)
+	cbURL, err := url.Parse(redirectURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect-uri: %w", err)
+	}
+	if !IsLoopbackHost(cbURL.Hostname()) {
+		return nil, errors.New("redirect-uri host must be localhost/loopback")
+	}
+	f := &AuthCodeFlow{Settings: s, RedirectURI: redirectURI}
+	if s.UsePKCE {
+		f.pkce, err = GeneratePKCE()
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.State, err = RandomURLSafe(32)
+	if err != nil {
+		return nil, err
+	}
+	f.LoginURL, err = BuildAuthURL(AuthURLRequest{
+		BaseURL:       s.AuthURL,
+		ClientID:      s.ClientID,
+		RedirectURI:   redirectURI,
+		RedirectParam: s.RedirectParam,
+		Scope:         s.Scope,
+		State:         f.State,
+		UsePKCE:       s.UsePKCE,
+		Challenge:     f.pkce.Challenge,
+		Params:        s.AuthParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Exchange validates cb against the flow and trades its code for a refresh token.
+func (f *AuthCodeFlow) Exchange(ctx context.Context, cb Callback) (string, error) {
+	if strings.TrimSpace(cb.Err) != "" {
+		return "", fmt.Errorf("oauth callback error: %s", cb.Err)
+	}
+	if strings.TrimSpace(cb.State) != strings.TrimSpace(f.State) {
+		return "", errors.New("oauth state mismatch")
+	}
+	if strings.TrimSpace(cb.Code) == "" {
+		return "", errors.New("oauth callback code is empty")
+	}
+	return ExchangeRefreshToken(ctx, TokenExchangeRequest{
+		TokenURL:     f.Settings.TokenURL,
+		ContentType:  f.Settings.TokenType,
+		ClientID:     f.Settings.ClientID,
+		ClientSecret: f.Settings.ClientSecret,
+		RedirectURI:  f.RedirectURI,
+		Code:         cb.Code,
+		Verifier:     f.pkce.Verifier,
+		UsePKCE:      f.Settings.UsePKCE,
+		TokenBasic:   f.Settings.TokenBasic,
+	})
+}
+
+// ParseCallbackURL parses a redirect URL pasted from the browser address bar.
+func ParseCallbackURL(raw string) (Callback, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Callback{}, fmt.Errorf("invalid callback url: %w", err)
+	}
+	q := u.Query()
+	cb := Callback{
+		Code:  strings.TrimSpace(q.Get("code")),
+		State: strings.TrimSpace(q.Get("state")),
+		Err:   strings.TrimSpace(q.Get("error")),
+	}
+	if cb.Code == "" && cb.Err == "" {
+		return Callback{}, errors.New("callback url has neither code nor error")
+	}
+	return cb, nil
+}
+
+// StartCallbackServer listens on port and forwards the first /auth/callback
+// redirect to cbCh.
+func StartCallbackServer(port int, cbCh chan<- Callback) (*http.Server, error) {
+	ln, err := ListenCallback(port)
+	if err != nil {
+		return nil, err
+	}
+	return ServeCallback(ln, cbCh), nil
+}
+
+// ListenCallback binds the redirect listener. Port 0 picks an ephemeral port;
+// privileged ports are rejected rather than requiring root.
+func ListenCallback(port int) (net.Listener, error) {
+	switch {
+	case port < 0 || port > 65535:
+		return nil, fmt.Errorf("callback port %d is out of range", port)
+	case port > 0 && port < 1024:
+		return nil, fmt.Errorf("callback port %d is privileged: use a port >= 1024, or 0 for an ephemeral port", port)
+	}
+	var lc net.ListenConfig
+	ln, err := lc.Listen(context.Background(), "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("callback port %d unavailable (already in use?): %w", port, err)
+	}
+	return ln, nil
+}
+
+// ListenerPort returns the TCP port ln is bound to.
+func ListenerPort(ln net.Listener) int {
+	if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// CallbackPort returns the explicit port of a loopback redirect URI.
+func CallbackPort(redirectURI string) (int, error) {
+	u, err := url.Parse(strings.TrimSpace(redirectURI))
+	if err != nil {
+		return 0, fmt.Errorf("invalid redirect-uri: %w", err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return 0, fmt.Errorf("redirect-uri %q must include an explicit port", redirectURI)
+	}
+	return port, nil
+}
+
+// ServeCallback serves /auth/callback on ln and forwards the first redirect to cbCh.
+func ServeCallback(ln net.Listener, cbCh chan<- Callback) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		cb := Callback{
+			Code:  strings.TrimSpace(q.Get("code")),
+			State: strings.TrimSpace(q.Get("state")),
+			Err:   strings.TrimSpace(q.Get("error")),
+		}
+		select {
+		case cbCh <- cb:
+		default:
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("OAuth completed. You can close this page."))
+	})
+
+	srv := &http.Server{
+		Addr:         ln.Addr().String(),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() { _ = srv.Serve(ln) }()
+	return srv
+}
+
+// WaitCallback blocks until a callback arrives or ctx is done.
+func WaitCallback(ctx context.Context, cbCh <-chan Callback) (Callback, error) {
+	select {
+	case <-ctx.Done():
+		return Callback{}, fmt.Errorf("wait oauth callback timeout: %w", ctx.Err())
+	case cb := <-cbCh:
+		return cb, nil
+	}
+}
+
+type AuthURLRequest struct {
+	BaseURL       string
+	ClientID      string
+	RedirectURI   string
+	RedirectParam string
+	Scope         string
+	State         string
+	UsePKCE       bool
+	Challenge     string
+	Params        map[string]string
+}
+
+type TokenExchangeRequest struct {
+	TokenURL     string
+	ContentType  string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Code         string
+	Verifier     string
+	UsePKCE      bool
+	TokenBasic   bool
+}
+
+// ExchangeRefreshToken exchanges an authorization code and returns the refresh token.
+func ExchangeRefreshToken(ctx context.Context, in TokenExchangeRequest) (string, error) {
+	tokenURL := strings.TrimSpace(in.TokenURL)
+	if tokenURL == "" {
+		return "", errors.New("token-url is empty")
+	}
+	contentType := strings.ToLower(strings.TrimSpace(in.ContentType))
+	if contentType == "" {
+		contentType = TokenContentTypeForm
+	}
+	if contentType != TokenContentTypeForm && contentType != TokenContentTypeJSON {
+		return "", fmt.Errorf("unsupported token content type %q", in.ContentType)
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+	if contentType == TokenContentTypeJSON {
+		body := map[string]string{
+			"grant_type":   "authorization_code",
+			"client_id":    strings.TrimSpace(in.ClientID),
+			"code":         strings.TrimSpace(in.Code),
+			"redirect_uri": strings.TrimSpace(in.RedirectURI),
+		}
+		if in.UsePKCE {
+			body["code_verifier"] = strings.TrimSpace(in.Verifier)
+		}
+		if strings.TrimSpace(in.ClientSecret) != "" {
+			body["client_secret"] = strings.TrimSpace(in.ClientSecret)
+		}
+		raw, errMarshal := json.Marshal(body)
+		if errMarshal != nil {
+			return "", fmt.Errorf("marshal token request failed: %w", errMarshal)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(string(raw)))
+		if err != nil {
+			return "", fmt.Errorf("create token request failed: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		form := url.Values{}
+		form.Set("grant_type", "authorization_code")
+		form.Set("client_id", strings.TrimSpace(in.ClientID))
+		form.Set("code", strings.TrimSpace(in.Code))
+		form.Set("redirect_uri", strings.TrimSpace(in.RedirectURI))
+		if in.UsePKCE {
+			form.Set("code_verifier", strings.TrimSpace(in.Verifier))
+		}
+		if strings.TrimSpace(in.ClientSecret) != "" {
+			form.Set("client_secret", strings.TrimSpace(in.ClientSecret))
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", fmt.Errorf("create token request failed: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("Accept", "application/json")
+	if in.TokenBasic {
+		if err := setBasicAuth(req, in.ClientID, in.ClientSecret); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read token response failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("token endpoint failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tr tokenResp
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", fmt.Errorf("parse token response failed: %w", err)
+	}
+	token := strings.TrimSpace(tr.RefreshToken)
+	if token == "" {
+		return "", errors.New("refresh_token not found in token response")
+	}
+	return token, nil
+}
+
+// BuildAuthURL returns the authorize URL the user opens in a browser.
+func BuildAuthURL(in AuthURLRequest) (string, error) {
+	b := strings.TrimSpace(in.BaseURL)
+	if b == "" {
+		return "", errors.New("auth-url is empty")
+	}
+	u, err := url.Parse(b)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth-url: %w", err)
+	}
+	q := u.Query()
+	q.Set("client_id", strings.TrimSpace(in.ClientID))
+	q.Set("response_type", "code")
+	redirectParam := strings.TrimSpace(in.RedirectParam)
+	if redirectParam == "" {
+		redirectParam = "redirect_uri"
+	}
+	q.Set(redirectParam, strings.TrimSpace(in.RedirectURI))
+	if strings.TrimSpace(in.Scope) != "" {
+		q.Set("scope", strings.TrimSpace(in.Scope))
+	}
+	q.Set("state", strings.TrimSpace(in.State))
+	if in.UsePKCE {
+		challenge := strings.TrimSpace(in.Challenge)
+		if challenge == "" {
+			return "", errors.New("pkce enabled but code_challenge is empty")
+		}
+		q.Set("code_challenge", challenge)
+		q.Set("code_challenge_method", "S256")
+	}
+	for k, v := range in.Params {
+		key := strings.TrimSpace(k)
+		if key == "" {
+			continue
+		}
+		q.Set(key, strings.TrimSpace(v))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func setBasicAuth(req *http.Request, clientID, clientSecret string) error {
+	clientID = strings.TrimSpace(clientID)
+	clientSecret = strings.TrimSpace(clientSecret)
+	if clientID == "" || clientSecret == "" {
+		return errors.New("token-basic-auth requires both client-id and client-secret")
+	}
+	raw := base64.StdEncoding.EncodeToString([]byte(clientID + ":" + clientSecret))
+	req.Header.Set("Authorization", "Basic "+raw)
+	return nil
+}
+
+func GeneratePKCE() (PKCE, error) {
+	verifier, err := RandomURLSafe(64)
+	if err != nil {
+		return PKCE{}, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	return PKCE{Verifier: verifier, Challenge: challenge}, nil
+}
+
+func RandomURLSafe(byteLen int) (string, error) {
+	buf := make([]byte, byteLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("random generation failed: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func IsLoopbackHost(host string) bool {
+	h := strings.TrimSpace(strings.ToLower(host))
+	if h == "localhost" || h == "::1" || h == "127.0.0.1" {
+		return true
+	}
+	ip := net.ParseIP(h)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,267 @@
+package oauthflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type DeviceCodeResp struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type deviceTokenResp struct {
+	AccessToken      string  `json:"access_token"`
+	RefreshToken     string  `json:"refresh_token"`
+	TokenType        string  `json:"token_type"`
+	ExpiresIn        float64 `json:"expires_in"`
+	Error            string  `json:"error"`
+	ErrorDescription string  `json:"error_description"`
+}
+
+// DeviceCodeFlow is one pending device-code login.
+type DeviceCodeFlow struct {
+	Settings Settings
+	Code     DeviceCodeResp
+	// VerifyURL is the page where the user enters (or confirms) UserCode.
+	VerifyURL string
+	UserCode  string
+	pkce      PKCE
+}
+
+// StartDeviceCode requests a device code for s.
+func StartDeviceCode(ctx context.Context, s Settings) (*DeviceCodeFlow, error) {
+	f := &DeviceCodeFlow{Settings: s}
+	if s.UsePKCE {
+		var err error
+		f.pkce, err = GeneratePKCE()
+		if err != nil {
+			return nil, err
+		}
+	}
+	dc, err := RequestDeviceCode(ctx, s.AuthURL, s.ClientID, s.Scope, s.AuthParams, s.UsePKCE, f.pkce.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	f.Code = dc
+	f.VerifyURL = strings.TrimSpace(firstNonEmpty(dc.VerificationURIComplete, dc.VerificationURI))
+	if f.VerifyURL == "" {
+		return nil, errors.New("device code response missing verification URL")
+	}
+	f.UserCode = strings.TrimSpace(dc.UserCode)
+	return f, nil
+}
+
+// Poll waits until the user approves the device code and returns the refresh token.
+func (f *DeviceCodeFlow) Poll(ctx context.Context) (string, error) {
+	return PollDeviceToken(ctx, DevicePollRequest{
+		Provider:     f.Settings.Name,
+		TokenURL:     f.Settings.TokenURL,
+		ClientID:     f.Settings.ClientID,
+		DeviceCode:   strings.TrimSpace(f.Code.DeviceCode),
+		Verifier:     strings.TrimSpace(f.pkce.Verifier),
+		UsePKCE:      f.Settings.UsePKCE,
+		GrantType:    f.Settings.DeviceGrant,
+		PollInterval: f.Code.Interval,
+		ExpiresIn:    f.Code.ExpiresIn,
+		TokenBasic:   f.Settings.TokenBasic,
+		ClientSecret: f.Settings.ClientSecret,
+		TokenType:    TokenContentTypeForm,
+	})
+}
+
+func RequestDeviceCode(
+	ctx context.Context,
+	deviceCodeURL string,
+	clientID string,
+	scope string,
+	params map[string]string,
+	usePKCE bool,
+	challenge string,
+) (DeviceCodeResp, error) {
+	form := url.Values{}
+	form.Set("client_id", strings.TrimSpace(clientID))
+	if strings.TrimSpace(scope) != "" {
+		form.Set("scope", strings.TrimSpace(scope))
+	}
+	if usePKCE {
+		form.Set("code_challenge", strings.TrimSpace(challenge))
+		form.Set("code_challenge_method", "S256")
+	}
+	for k, v := range params {
+		key := strings.TrimSpace(k)
+		if key == "" {
+			continue
+		}
+		form.Set(key, strings.TrimSpace(v))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSpace(deviceCodeURL), strings.NewReader(form.Encode()))
+	if err != nil {
+		return DeviceCodeResp{}, fmt.Errorf("create device code request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return DeviceCodeResp{}, fmt.Errorf("device code request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return DeviceCodeResp{}, fmt.Errorf("read device code response failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return DeviceCodeResp{}, fmt.Errorf("device code endpoint failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var dc DeviceCodeResp
+	if err := json.Unmarshal(body, &dc); err != nil {
+		return DeviceCodeResp{}, fmt.Errorf("parse device code response failed: %w", err)
+	}
+	if strings.TrimSpace(dc.DeviceCode) == "" {
+		return DeviceCodeResp{}, errors.New("device code response missing device_code")
+	}
+	return dc, nil
+}
+
+type DevicePollRequest struct {
+	Provider     string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	DeviceCode   string
+	Verifier     string
+	UsePKCE      bool
+	GrantType    string
+	PollInterval int
+	ExpiresIn    int
+	TokenBasic   bool
+	TokenType    string
+}
+
+func PollDeviceToken(ctx context.Context, in DevicePollRequest) (string, error) {
+	interval := 5 * time.Second
+	if in.PollInterval > 0 {
+		interval = time.Duration(in.PollInterval) * time.Second
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	deadline := time.Now().Add(15 * time.Minute)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if in.ExpiresIn > 0 {
+		exp := time.Now().Add(time.Duration(in.ExpiresIn) * time.Second)
+		if exp.Before(deadline) {
+			deadline = exp
+		}
+	}
+
+	for {
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("device flow timeout for provider %q", in.Provider)
+		}
+		token, shouldContinue, err := exchangeDeviceTokenOnce(ctx, in)
+		if err != nil {
+			return "", err
+		}
+		if !shouldContinue {
+			if strings.TrimSpace(token) == "" {
+				return "", errors.New("refresh_token not found in token response")
+			}
+			return strings.TrimSpace(token), nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("device flow canceled: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+func exchangeDeviceTokenOnce(ctx context.Context, in DevicePollRequest) (token string, shouldContinue bool, err error) {
+	form := url.Values{}
+	form.Set("grant_type", strings.TrimSpace(in.GrantType))
+	form.Set("client_id", strings.TrimSpace(in.ClientID))
+	form.Set("device_code", strings.TrimSpace(in.DeviceCode))
+	if in.UsePKCE {
+		form.Set("code_verifier", strings.TrimSpace(in.Verifier))
+	}
+	if strings.TrimSpace(in.ClientSecret) != "" {
+		form.Set("client_secret", strings.TrimSpace(in.ClientSecret))
+	}
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSpace(in.TokenURL), strings.NewReader(form.Encode()))
+	if errReq != nil {
+		return "", false, fmt.Errorf("create device token request failed: %w", errReq)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if in.TokenBasic {
+		if err := setBasicAuth(req, in.ClientID, in.ClientSecret); err != nil {
+			return "", false, err
+		}
+	}
+
+	resp, errDo := http.DefaultClient.Do(req)
+	if errDo != nil {
+		return "", false, fmt.Errorf("device token request failed: %w", errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if errRead != nil {
+		return "", false, fmt.Errorf("read device token response failed: %w", errRead)
+	}
+
+	var tr deviceTokenResp
+	_ = json.Unmarshal(body, &tr)
+	errType := strings.TrimSpace(tr.Error)
+	if errType == "" {
+		var obj map[string]any
+		if errUnmarshal := json.Unmarshal(body, &obj); errUnmarshal == nil {
+			if v, ok := obj["error"].(string); ok {
+				errType = strings.TrimSpace(v)
+			}
+			if strings.TrimSpace(tr.ErrorDescription) == "" {
+				if v, ok := obj["error_description"].(string); ok {
+					tr.ErrorDescription = strings.TrimSpace(v)
+				}
+			}
+		}
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && errType == "" {
+		return strings.TrimSpace(tr.RefreshToken), false, nil
+	}
+
+	switch strings.ToLower(errType) {
+	case "authorization_pending", "slow_down":
+		return "", true, nil
+	case "expired_token":
+		return "", false, fmt.Errorf("device code expired for provider %q", in.Provider)
+	case "access_denied":
+		return "", false, fmt.Errorf("authorization denied for provider %q", in.Provider)
+	}
+
+	desc := strings.TrimSpace(tr.ErrorDescription)
+	if errType != "" {
+		if desc != "" {
+			return "", false, fmt.Errorf("device token poll failed: %s - %s", errType, desc)
+		}
+		return "", false, fmt.Errorf("device token poll failed: %s", errType)
+	}
+	return "", false, fmt.Errorf("device token endpoint failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,287 @@
+package oauthflow
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildAuthURL(t *testing.T) {
+	t.Parallel()
+	got, err := BuildAuthURL(AuthURLRequest{
+		BaseURL:       "https://auth.openai.com/oauth/authorize",
+		ClientID:      "cid",
+		RedirectURI:   "http://localhost:1455/auth/callback",
+		RedirectParam: "redirect_uri",
+		Scope:         "openid email profile offline_access",
+		State:         "state1",
+		UsePKCE:       true,
+		Challenge:     "challenge1",
+		Params: map[string]string{
+			"prompt": "login",
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildAuthURL err=%v", err)
+	}
+	if got == "" {
+		t.Fatalf("auth url is empty")
+	}
+}
+
+func TestBuildAuthURL_CustomRedirectParam(t *testing.T) {
+	t.Parallel()
+	got, err := BuildAuthURL(AuthURLRequest{
+		BaseURL:       "https://iflow.cn/oauth",
+		ClientID:      "cid",
+		RedirectURI:   "http://localhost:11451/oauth2callback",
+		RedirectParam: "redirect",
+		State:         "state1",
+		UsePKCE:       false,
+		Params: map[string]string{
+			"loginMethod": "phone",
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildAuthURL err=%v", err)
+	}
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("parse auth url err=%v", err)
+	}
+	q := u.Query()
+	if q.Get("redirect") == "" {
+		t.Fatalf("redirect should exist in query")
+	}
+	if q.Get("redirect_uri") != "" {
+		t.Fatalf("redirect_uri should not exist for custom redirect param")
+	}
+}
+
+func TestResolveProfileAlias(t *testing.T) {
+	t.Parallel()
+	p, err := ResolveProfile("openai-oauth")
+	if err != nil {
+		t.Fatalf("resolve profile err=%v", err)
+	}
+	if p.Name != ProviderOpenAI {
+		t.Fatalf("profile name=%q want=%s", p.Name, ProviderOpenAI)
+	}
+	if p.AuthURL != DefaultOpenAIAuthURL {
+		t.Fatalf("auth_url=%q want=%q", p.AuthURL, DefaultOpenAIAuthURL)
+	}
+}
+
+func TestResolveProfile_QwenKimi(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		provider string
+		name     string
+	}{
+		{provider: "qwen", name: "qwen"},
+		{provider: "kimi", name: "kimi"},
+	} {
+		p, err := ResolveProfile(tc.provider)
+		if err != nil {
+			t.Fatalf("resolve profile provider=%s err=%v", tc.provider, err)
+		}
+		if p.Name != tc.name {
+			t.Fatalf("profile name=%q want=%q", p.Name, tc.name)
+		}
+		if p.Flow != FlowDeviceCode {
+			t.Fatalf("provider=%s flow=%q want=%q", tc.provider, p.Flow, FlowDeviceCode)
+		}
+		if p.AuthURL == "" || p.TokenURL == "" || p.ClientID == "" {
+			t.Fatalf("provider=%s has empty required device flow fields", tc.provider)
+		}
+	}
+}
+
+func TestExchangeRefreshToken_FormWithBasic(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := strings.TrimSpace(r.Header.Get("Authorization")); got == "" {
+			t.Fatalf("missing basic auth header")
+		}
+		if ct := strings.TrimSpace(r.Header.Get("Content-Type")); !strings.Contains(ct, "application/x-www-form-urlencoded") {
+			t.Fatalf("content-type=%q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			t.Fatalf("parse form err=%v", err)
+		}
+		if got := form.Get("grant_type"); got != "authorization_code" {
+			t.Fatalf("grant_type=%q", got)
+		}
+		if got := form.Get("client_secret"); got != "sec" {
+			t.Fatalf("client_secret=%q", got)
+		}
+		if got := form.Get("code_verifier"); got != "verifier1" {
+			t.Fatalf("code_verifier=%q", got)
+		}
+		_, _ = w.Write([]byte(`{"refresh_token":"rt_123"}`))
+	}))
+	defer srv.Close()
+
+	got, err := ExchangeRefreshToken(context.Background(), TokenExchangeRequest{
+		TokenURL:     srv.URL,
+		ContentType:  TokenContentTypeForm,
+		ClientID:     "cid",
+		ClientSecret: "sec",
+		RedirectURI:  "http://localhost:1455/auth/callback",
+		Code:         "code1",
+		Verifier:     "verifier1",
+		UsePKCE:      true,
+		TokenBasic:   true,
+	})
+	if err != nil {
+		t.Fatalf("ExchangeRefreshToken err=%v", err)
+	}
+	if got != "rt_123" {
+		t.Fatalf("refresh_token=%q want=rt_123", got)
+	}
+}
+
+func TestClientSecretEnv(t *testing.T) {
+	t.Parallel()
+	if got := ClientSecretEnv("iflow"); got != "ONR_OAUTH_IFLOW_CLIENT_SECRET" {
+		t.Fatalf("env=%q", got)
+	}
+	if got := ClientSecretEnv("openai-oauth"); got != "ONR_OAUTH_OPENAI_OAUTH_CLIENT_SECRET" {
+		t.Fatalf("env=%q", got)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		host string
+		ok   bool
+	}{
+		{host: "localhost", ok: true},
+		{host: "127.0.0.1", ok: true},
+		{host: "::1", ok: true},
+		{host: "example.com", ok: false},
+	} {
+		if got := IsLoopbackHost(tc.host); got != tc.ok {
+			t.Fatalf("host=%q got=%v want=%v", tc.host, got, tc.ok)
+		}
+	}
+}
+
+func TestGeneratePKCE(t *testing.T) {
+	t.Parallel()
+	p, err := GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE err=%v", err)
+	}
+	if p.Verifier == "" || p.Challenge == "" {
+		t.Fatalf("pkce empty: %+v", p)
+	}
+}
+
+func TestParseCallbackURL(t *testing.T) {
+	t.Parallel()
+	cb, err := ParseCallbackURL("http://localhost:2468/auth/callback?code=c1&state=s1")
+	if err != nil {
+		t.Fatalf("ParseCallbackURL err=%v", err)
+	}
+	if cb.Code != "c1" || cb.State != "s1" {
+		t.Fatalf("callback=%+v", cb)
+	}
+	if _, err := ParseCallbackURL("http://localhost:2468/auth/callback?state=s1"); err == nil {
+		t.Fatalf("expected error for callback without code")
+	}
+}
+
+func TestAuthCodeFlow_ExchangeChecksState(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("code") != "c1" || r.PostForm.Get("code_verifier") == "" {
+			t.Errorf("form=%v", r.PostForm)
+		}
+		_, _ = w.Write([]byte(`{"refresh_token":"rt_1"}`))
+	}))
+	defer srv.Close()
+
+	p, err := ResolveProfile("openai")
+	if err != nil {
+		t.Fatalf("ResolveProfile err=%v", err)
+	}
+	s, err := Resolve(p, Overrides{TokenURL: srv.URL})
+	if err != nil {
+		t.Fatalf("Resolve err=%v", err)
+	}
+	f, err := StartAuthCode(s, DefaultRedirectURI(DefaultCallbackPort))
+	if err != nil {
+		t.Fatalf("StartAuthCode err=%v", err)
+	}
+	if u, _ := url.Parse(f.LoginURL); u.Query().Get("state") != f.State {
+		t.Fatalf("login url missing state: %s", f.LoginURL)
+	}
+	if _, err := f.Exchange(context.Background(), Callback{Code: "c1", State: "other"}); err == nil || !strings.Contains(err.Error(), "state mismatch") {
+		t.Fatalf("expected state mismatch, err=%v", err)
+	}
+	got, err := f.Exchange(context.Background(), Callback{Code: "c1", State: f.State})
+	if err != nil || got != "rt_1" {
+		t.Fatalf("Exchange token=%q err=%v", got, err)
+	}
+	if _, err := StartAuthCode(s, "http://example.com/cb"); err == nil {
+		t.Fatalf("expected non-loopback redirect error")
+	}
+}
+
+func TestDeviceCodeFlow_PollsUntilApproved(t *testing.T) {
+	t.Parallel()
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/device":
+			_, _ = w.Write([]byte(`{"device_code":"dc1","user_code":"ABCD","verification_uri":"https://example.com/activate","interval":1}`))
+		case "/token":
+			_ = r.ParseForm()
+			if r.PostForm.Get("device_code") != "dc1" {
+				t.Errorf("form=%v", r.PostForm)
+			}
+			if polls.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt_dev"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p, err := ResolveProfile("kimi")
+	if err != nil {
+		t.Fatalf("ResolveProfile err=%v", err)
+	}
+	s, err := Resolve(p, Overrides{AuthURL: srv.URL + "/device", TokenURL: srv.URL + "/token"})
+	if err != nil {
+		t.Fatalf("Resolve err=%v", err)
+	}
+	f, err := StartDeviceCode(context.Background(), s)
+	if err != nil {
+		t.Fatalf("StartDeviceCode err=%v", err)
+	}
+	if f.VerifyURL != "https://example.com/activate" || f.UserCode != "ABCD" {
+		t.Fatalf("flow=%+v", f)
+	}
+	got, err := f.Poll(context.Background())
+	if err != nil || got != "rt_dev" {
+		t.Fatalf("Poll token=%q err=%v", got, err)
+	}
+	if polls.Load() != 2 {
+		t.Fatalf("polls=%d want=2", polls.Load())
+	}
+}
@@ -0,0 +1,318 @@
+// Package oauthflow runs the OAuth auth-code and device-code flows that obtain
+// an upstream refresh token for OAuth-backed providers. It is shared by
+// `onr-admin oauth refresh-token` and the onr-admin web onboarding API.
+package oauthflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ProviderOpenAI = "openai"
+
+	DefaultOpenAIAuthURL = "https://auth.openai.com/oauth/authorize"
+	// #nosec G101 -- OAuth endpoint URL, not a credential.
+	DefaultOpenAITokenURL = "https://auth.openai.com/oauth/token"
+	DefaultOpenAIClientID = "app_EMoamEEZ73f0CkXaXp7hrann"
+
+	TokenContentTypeForm = "form"
+	TokenContentTypeJSON = "json"
+
+	FlowAuthCode   = "auth_code"
+	FlowDeviceCode = "device_code"
+
+	defaultDeviceGrant = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Profiles lists the built-in provider profile names accepted by ResolveProfile.
+var Profiles = []string{"openai", "claude", "gemini", "antigravity", "iflow", "qwen", "kimi", "custom"}
+
+type Profile struct {
+	Flow          string
+	Name          string
+	AuthURL       string
+	TokenURL      string
+	ClientID      string
+	ClientSecret  string
+	Scope         string
+	AuthParams    map[string]string
+	RedirectParam string
+	UsePKCE       bool
+	TokenType     string
+	TokenBasic    bool
+	DeviceGrant   string
+}
+
+// ResolveProfile returns the built-in profile for provider; empty means openai.
+func ResolveProfile(provider string) (Profile, error) {
+	p := strings.ToLower(strings.TrimSpace(provider))
+	if p == "" {
+		p = ProviderOpenAI
+	}
+	switch p {
+	case ProviderOpenAI, "openai-oauth", "codex":
+		return Profile{
+			Flow:     FlowAuthCode,
+			Name:     ProviderOpenAI,
+			AuthURL:  DefaultOpenAIAuthURL,
+			TokenURL: DefaultOpenAITokenURL,
+			ClientID: DefaultOpenAIClientID,
+			Scope:    "openid email profile offline_access",
+			AuthParams: map[string]string{
+				"prompt":                     "login",
+				"id_token_add_organizations": "true",
+				"codex_cli_simplified_flow":  "true",
+			},
+			RedirectParam: "redirect_uri",
+			UsePKCE:       true,
+			TokenType:     TokenContentTypeForm,
+		}, nil
+	case "claude", "anthropic":
+		return Profile{
+			Flow:          FlowAuthCode,
+			Name:          "claude",
+			AuthURL:       "https://claude.ai/oauth/authorize",
+			TokenURL:      "https://console.anthropic.com/v1/oauth/token",
+			ClientID:      "9d1c250a-e61b-44d9-88ed-5944d1962f5e",
+			Scope:         "org:create_api_key user:profile user:inference",
+			AuthParams:    map[string]string{"code": "true"},
+			RedirectParam: "redirect_uri",
+			UsePKCE:       true,
+			TokenType:     TokenContentTypeJSON,
+		}, nil
+	case "gemini":
+		return Profile{
+			Flow:     FlowAuthCode,
+			Name:     "gemini",
+			AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+			Scope: strings.Join([]string{
+				"https://www.googleapis.com/auth/cloud-platform",
+				"https://www.googleapis.com/auth/userinfo.email",
+				"https://www.googleapis.com/auth/userinfo.profile",
+			}, " "),
+			AuthParams: map[string]string{
+				"access_type": "offline",
+				"prompt":      "consent",
+			},
+			RedirectParam: "redirect_uri",
+			UsePKCE:       true,
+			TokenType:     TokenContentTypeForm,
+		}, nil
+	case "antigravity":
+		return Profile{
+			Flow:     FlowAuthCode,
+			Name:     "antigravity",
+			AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+			Scope: strings.Join([]string{
+				"https://www.googleapis.com/auth/cloud-platform",
+				"https://www.googleapis.com/auth/userinfo.email",
+				"https://www.googleapis.com/auth/userinfo.profile",
+				"https://www.googleapis.com/auth/cclog",
+				"https://www.googleapis.com/auth/experimentsandconfigs",
+			}, " "),
+			AuthParams: map[string]string{
+				"access_type": "offline",
+				"prompt":      "consent",
+			},
+			RedirectParam: "redirect_uri",
+			UsePKCE:       false,
+			TokenType:     TokenContentTypeForm,
+		}, nil
+	case "iflow":
+		return Profile{
+			Flow:          FlowAuthCode,
+			Name:          "iflow",
+			AuthURL:       "https://iflow.cn/oauth",
+			TokenURL:      "https://iflow.cn/oauth/token",
+			ClientID:      "10009311001",
+			AuthParams:    map[string]string{"loginMethod": "phone", "type": "phone"},
+			RedirectParam: "redirect",
+			UsePKCE:       false,
+			TokenType:     TokenContentTypeForm,
+			TokenBasic:    true,
+		}, nil
+	case "qwen":
+		return Profile{
+			Flow:        FlowDeviceCode,
+			Name:        "qwen",
+			AuthURL:     "https://chat.qwen.ai/api/v1/oauth2/device/code",
+			TokenURL:    "https://chat.qwen.ai/api/v1/oauth2/token",
+			ClientID:    "f0304373b74a44d2b584a3fb70ca9e56",
+			Scope:       "openid profile email model.completion",
+			UsePKCE:     true,
+			TokenType:   TokenContentTypeForm,
+			DeviceGrant: defaultDeviceGrant,
+		}, nil
+	case "kimi":
+		return Profile{
+			Flow:        FlowDeviceCode,
+			Name:        "kimi",
+			AuthURL:     "https://auth.kimi.com/api/oauth/device_authorization",
+			TokenURL:    "https://auth.kimi.com/api/oauth/token",
+			ClientID:    "17e5f671-d194-4dfb-9706-5516cb48c098",
+			UsePKCE:     false,
+			TokenType:   TokenContentTypeForm,
+			DeviceGrant: defaultDeviceGrant,
+		}, nil
+	case "custom":
+		return Profile{
+			Flow:          FlowAuthCode,
+			Name:          "custom",
+			AuthParams:    map[string]string{},
+			RedirectParam: "redirect_uri",
+			UsePKCE:       true,
+			TokenType:     TokenContentTypeForm,
+		}, nil
+	default:
+		return Profile{}, fmt.Errorf(
+			"unsupported provider %q for oauth refresh-token; supported: %s",
+			provider, strings.Join(Profiles, ", "),
+		)
+	}
+}
+
+// Overrides replace profile defaults; empty fields keep the profile value.
+type Overrides struct {
+	AuthURL      string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	// AuthParams are merged over the profile's authorize (or device code) params.
+	AuthParams map[string]string
+	TokenType  string
+	TokenBasic bool
+	NoPKCE     bool
+}
+
+// Settings are the effective endpoints and client parameters of one flow.
+type Settings struct {
+	Flow          string
+	Name          string
+	AuthURL       string
+	TokenURL      string
+	ClientID      string
+	ClientSecret  string
+	Scope         string
+	AuthParams    map[string]string
+	RedirectParam string
+	UsePKCE       bool
+	TokenType     string
+	TokenBasic    bool
+	DeviceGrant   string
+}
+
+// Resolve applies o to p and validates the result. The client secret falls
+// back to ONR_OAUTH_<NAME>_CLIENT_SECRET.
+func Resolve(p Profile, o Overrides) (Settings, error) {
+	s := Settings{
+		Flow:          p.Flow,
+		Name:          p.Name,
+		AuthURL:       strings.TrimSpace(firstNonEmpty(o.AuthURL, p.AuthURL)),
+		TokenURL:      strings.TrimSpace(firstNonEmpty(o.TokenURL, p.TokenURL)),
+		ClientID:      strings.TrimSpace(firstNonEmpty(o.ClientID, p.ClientID)),
+		ClientSecret:  strings.TrimSpace(firstNonEmpty(o.ClientSecret, p.ClientSecret)),
+		Scope:         strings.TrimSpace(firstNonEmpty(o.Scope, p.Scope)),
+		AuthParams:    cloneStringMap(p.AuthParams),
+		RedirectParam: strings.TrimSpace(firstNonEmpty(p.RedirectParam, "redirect_uri")),
+		UsePKCE:       p.UsePKCE && !o.NoPKCE,
+		TokenType:     strings.ToLower(strings.TrimSpace(firstNonEmpty(o.TokenType, p.TokenType))),
+		TokenBasic:    p.TokenBasic || o.TokenBasic,
+		DeviceGrant:   strings.TrimSpace(firstNonEmpty(p.DeviceGrant, defaultDeviceGrant)),
+	}
+	for k, v := range o.AuthParams {
+		s.AuthParams[k] = v
+	}
+	if s.ClientSecret == "" {
+		s.ClientSecret = strings.TrimSpace(os.Getenv(ClientSecretEnv(s.Name)))
+	}
+	if s.Flow == FlowDeviceCode {
+		// Device token polls are always form encoded.
+		s.TokenType = TokenContentTypeForm
+	} else {
+		if s.TokenType == "" {
+			s.TokenType = TokenContentTypeForm
+		}
+		if s.TokenType != TokenContentTypeForm && s.TokenType != TokenContentTypeJSON {
+			return Settings{}, fmt.Errorf("invalid token-content-type %q, expected form|json", s.TokenType)
+		}
+		if s.TokenBasic && s.ClientSecret == "" {
+			return Settings{}, fmt.Errorf(
+				"provider %q requires client secret (token basic auth enabled): use --client-secret or set %s",
+				s.Name,
+				ClientSecretEnv(s.Name),
+			)
+		}
+	}
+	if s.AuthURL == "" {
+		return Settings{}, errors.New("auth-url is empty")
+	}
+	if s.TokenURL == "" {
+		return Settings{}, errors.New("token-url is empty")
+	}
+	if s.ClientID == "" {
+		return Settings{}, errors.New("client-id is empty")
+	}
+	return s, nil
+}
+
+// ParseAuthParams parses repeatable key=value authorize params.
+func ParseAuthParams(values []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, raw := range values {
+		v := strings.TrimSpace(raw)
+		if v == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --auth-param %q: expected key=value", raw)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid --auth-param %q: empty key", raw)
+		}
+		out[key] = strings.TrimSpace(val)
+	}
+	return out, nil
+}
+
+// ClientSecretEnv returns the env var holding the client secret of provider.
+func ClientSecretEnv(provider string) string {
+	p := strings.ToUpper(strings.TrimSpace(provider))
+	var b strings.Builder
+	b.Grow(len(p))
+	for _, r := range p {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return "ONR_OAUTH_" + b.String() + "_CLIENT_SECRET"
+}
+
+func cloneStringMap(in map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+var auditState struct {
+	mu     sync.RWMutex
+	logger *audit.Logger
+	source string
+}
+
+// ConfigureAudit enables the audit log for writes made through WriteAtomic.
+// The audit file comes from cfg.audit.file, falling back to ONR_AUDIT_FILE when cfg is nil.
+// source is one of the audit.Source* values.
+func ConfigureAudit(cfg *config.Config, source string) {
+	path := strings.TrimSpace(os.Getenv("ONR_AUDIT_FILE"))
+	if cfg != nil {
+		path = strings.TrimSpace(cfg.Audit.File)
+	}
+	auditState.mu.Lock()
+	defer auditState.mu.Unlock()
+	auditState.logger = audit.NewLogger(path)
+	auditState.source = source
+}
+
+// AuditFile returns the configured audit file, or "" when auditing is disabled.
+func AuditFile() string {
+	auditState.mu.RLock()
+	defer auditState.mu.RUnlock()
+	return auditState.logger.Path()
+}
+
+// AuditRejected records a change that was not written because validation failed.
+// actor may be empty to use audit.DefaultActor.
+func AuditRejected(actor, action, path string, proposed []byte, validateErr error) {
+	before, _ := readIfExists(path)
+	msg := ""
+	if validateErr != nil {
+		msg = validateErr.Error()
+	}
+	appendAudit(audit.Entry{
+		Actor:      actor,
+		Action:     action,
+		Target:     strings.TrimSpace(path),
+		Diff:       audit.Diff(path, string(before), string(proposed)),
+		Validation: audit.ValidationFailed,
+		Error:      msg,
+	})
+}
+
+// WriteAtomicAs is WriteAtomic with an explicit audit actor (e.g. the web UI user).
+func WriteAtomicAs(actor, path string, data []byte, backup bool) error {
+	return writeAtomicAudited(actor, path, data, backup)
+}
+
+// writeAtomicAudited writes validated content and records the change. Callers validate before writing,
+// so successful writes are recorded with validation=ok.
+func writeAtomicAudited(actor, path string, data []byte, backup bool) error {
+	before, _ := readIfExists(path)
+	if err := writeAtomic(path, data, backup); err != nil {
+		return err
+	}
+	appendAudit(audit.Entry{
+		Actor:      actor,
+		Action:     "write",
+		Target:     strings.TrimSpace(path),
+		Diff:       audit.Diff(path, string(before), string(data)),
+		Validation: audit.ValidationOK,
+	})
+	return nil
+}
+
+func appendAudit(e audit.Entry) {
+	auditState.mu.RLock()
+	l := auditState.logger
+	e.Source = auditState.source
+	auditState.mu.RUnlock()
+	if l == nil {
+		return
+	}
+	if err := l.Append(e); err != nil {
+		// Auditing must not block config changes; surface the problem on stderr.
+		_, _ = os.Stderr.WriteString("onr-admin: write audit log " + l.Path() + ": " + err.Error() + "\n")
+	}
+}
+
+func readIfExists(path string) ([]byte, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, nil
+	}
+	// #nosec G304 -- admin tool reads the file it is about to overwrite.
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
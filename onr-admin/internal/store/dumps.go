@@ -1,7 +1,7 @@
 package store
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
 )
 
 type DumpSummary struct {
@@ -71,7 +73,7 @@ func ListDumpSummaries(opts DumpListOptions) ([]DumpSummary, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(d.Name()), ".log") {
+		if !isDumpFileName(d.Name()) {
 			return nil
 		}
 		info, err := d.Info()
@@ -128,16 +130,17 @@ func FindDumpByRequestID(opts DumpFindOptions) (DumpSummary, bool, error) {
 	}
 
 	if isSafeDumpRequestIDPathPart(rid) {
-		candidate := filepath.Join(dir, rid+".log")
-		sum, found, err := parseDumpSummaryIfExists(candidate)
-		if err != nil {
-			return DumpSummary{}, false, err
-		}
-		if found {
-			if strings.TrimSpace(sum.RequestID) == "" {
-				sum.RequestID = rid
+		for _, ext := range []string{".log", ".jsonl"} {
+			sum, found, err := parseDumpSummaryIfExists(filepath.Join(dir, rid+ext))
+			if err != nil {
+				return DumpSummary{}, false, err
+			}
+			if found {
+				if strings.TrimSpace(sum.RequestID) == "" {
+					sum.RequestID = rid
+				}
+				return sum, true, nil
 			}
-			return sum, true, nil
 		}
 	}
 
@@ -150,7 +153,7 @@ func FindDumpByRequestID(opts DumpFindOptions) (DumpSummary, bool, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(d.Name()), ".log") {
+		if !isDumpFileName(d.Name()) {
 			return nil
 		}
 		info, err := d.Info()
@@ -206,6 +209,12 @@ func parseDumpSummary(path string, info os.FileInfo) (DumpSummary, bool) {
 	return sum, true
 }
 
+// isDumpFileName matches the text (.log) and jsonl (.jsonl) dump extensions.
+func isDumpFileName(name string) bool {
+	n := strings.ToLower(name)
+	return strings.HasSuffix(n, ".log") || strings.HasSuffix(n, ".jsonl")
+}
+
 func isSafeDumpRequestIDPathPart(v string) bool {
 	s := strings.TrimSpace(v)
 	if s == "" || s == "." || s == ".." {
@@ -253,138 +262,39 @@ func parseDumpSummaryFromReader(sum *DumpSummary, r io.Reader) error {
 	if sum == nil {
 		return errors.New("nil summary")
 	}
-
-	br := bufio.NewReader(r)
-	section := ""
-	gotOrigin := false
-	var originBuf strings.Builder
-	originBytes := 0
-
-	for {
-		line, err := br.ReadString('\n')
-		if err != nil && !errors.Is(err, io.EOF) {
-			return err
-		}
-		trimmed := strings.TrimRight(line, "\r\n")
-		t := strings.TrimSpace(trimmed)
-		if strings.HasPrefix(t, "=== ") && strings.HasSuffix(t, " ===") {
-			section = t
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			continue
-		}
-
-		switch section {
-		case "=== META ===":
-			parseDumpMetaLine(sum, trimmed)
-		case "=== ORIGIN REQUEST ===":
-			// Content ends with an empty line (writeBlock always appends a blank line).
-			if t == "" {
-				gotOrigin = true
-				parseDumpOrigin(sum, originBuf.String())
-				originBuf.Reset()
-				originBytes = 0
-				break
-			}
-			// Avoid huge allocations: we only need a small prefix to extract model/stream.
-			if originBytes < 256*1024 {
-				originBuf.WriteString(trimmed)
-				originBuf.WriteByte('\n')
-				originBytes += len(trimmed) + 1
-			}
-		case "=== PROXY RESPONSE ===":
-			if strings.HasPrefix(strings.ToLower(t), "status=") {
-				v := strings.TrimSpace(strings.TrimPrefix(t, "status="))
-				if n, xerr := strconv.Atoi(v); xerr == nil {
-					sum.ProxyStatus = n
-				}
-			}
-		case "=== STREAM ===":
-			if strings.HasPrefix(strings.ToLower(t), "error=") {
-				sum.StreamError = strings.TrimSpace(strings.TrimPrefix(trimmed, "error="))
-			}
-		default:
-			if strings.EqualFold(t, "[truncated]") {
-				sum.HasTruncated = true
-			}
-		}
-
-		if errors.Is(err, io.EOF) {
-			break
-		}
-
-		// Fast path: once we have core fields, we can stop scanning.
-		if sum.RequestID != "" &&
-			sum.Method != "" &&
-			sum.URLPath != "" &&
-			sum.Provider != "" &&
-			gotOrigin &&
-			sum.ProxyStatus != 0 {
-			break
-		}
-	}
-
-	// If the file ended while still in ORIGIN REQUEST, best-effort parse.
-	if section == "=== ORIGIN REQUEST ===" && originBuf.Len() > 0 {
-		parseDumpOrigin(sum, originBuf.String())
-	}
-
-	return nil
-}
-
-func parseDumpMetaLine(sum *DumpSummary, line string) {
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" {
-		return
-	}
-
-	if strings.HasPrefix(trimmed, "time=") {
-		raw := strings.TrimSpace(strings.TrimPrefix(trimmed, "time="))
-		if ts, err := time.Parse(time.RFC3339, raw); err == nil {
-			sum.Time = ts
+	d, err := trafficdump.Parse(r)
+	if err != nil {
+		return err
+	}
+	sum.Time = d.Time
+	sum.RequestID = d.RequestID
+	sum.Method = d.Method
+	sum.URLPath = d.Path
+	sum.ClientIP = d.ClientIP
+	sum.Provider = strings.TrimSpace(d.Header.Get("X-Onr-Provider"))
+	sum.ProxyStatus = d.ProxyStatus
+	if d.Stream != nil {
+		sum.StreamError = d.Stream.Error
+	}
+	if d.OriginRequest != nil {
+		parseDumpOrigin(sum, *d.OriginRequest)
+	}
+	for _, b := range []*trafficdump.Body{d.OriginRequest, d.ProxyResponse} {
+		if b != nil && b.Truncated {
+			sum.HasTruncated = true
 		}
-		return
-	}
-	if strings.HasPrefix(trimmed, "request_id=") {
-		sum.RequestID = strings.TrimSpace(strings.TrimPrefix(trimmed, "request_id="))
-		return
-	}
-	if strings.HasPrefix(trimmed, "method=") {
-		sum.Method = strings.TrimSpace(strings.TrimPrefix(trimmed, "method="))
-		return
-	}
-	if strings.HasPrefix(trimmed, "path=") {
-		sum.URLPath = strings.TrimSpace(strings.TrimPrefix(trimmed, "path="))
-		return
-	}
-	if strings.HasPrefix(trimmed, "client_ip=") {
-		sum.ClientIP = strings.TrimSpace(strings.TrimPrefix(trimmed, "client_ip="))
-		return
 	}
-
-	// headers section lines: "  Key: Value"
-	if strings.HasPrefix(line, "  ") {
-		kv := strings.TrimSpace(strings.TrimPrefix(line, "  "))
-		parts := strings.SplitN(kv, ":", 2)
-		if len(parts) != 2 {
-			return
-		}
-		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-		if strings.EqualFold(key, "X-Onr-Provider") {
-			sum.Provider = val
+	for _, m := range []*trafficdump.Message{d.UpstreamRequest, d.UpstreamResponse} {
+		if m != nil && m.Body.Truncated {
+			sum.HasTruncated = true
 		}
-		return
 	}
+	return nil
 }
 
-func parseDumpOrigin(sum *DumpSummary, raw string) {
-	body := strings.TrimSpace(raw)
-	if body == "" {
-		return
-	}
-	if strings.HasPrefix(body, "[binary body omitted]") {
+// parseDumpOrigin extracts model and stream from a JSON origin request body.
+func parseDumpOrigin(sum *DumpSummary, body trafficdump.Body) {
+	if body.Omitted || body.Binary || len(bytes.TrimSpace(body.Data)) == 0 {
 		return
 	}
 
@@ -394,7 +304,7 @@ func parseDumpOrigin(sum *DumpSummary, raw string) {
 	}
 
 	var v req
-	dec := json.NewDecoder(strings.NewReader(body))
+	dec := json.NewDecoder(bytes.NewReader(body.Data))
 	dec.UseNumber()
 	if err := dec.Decode(&v); err != nil {
 		return
@@ -197,3 +197,28 @@ func TestFindDumpByRequestID_ReturnsNewest(t *testing.T) {
 		t.Fatalf("expected newest=%q got=%q", newPath, sum.Path)
 	}
 }
+
+func TestParseDumpSummary_JSONL(t *testing.T) {
+	tmp := t.TempDir()
+	p := filepath.Join(tmp, "b.jsonl")
+	content := `{"type":"meta","time":"2026-02-09T12:29:48.123+08:00","request_id":"rid-jsonl","method":"POST","path":"/v1/chat/completions","client_ip":"::1","headers":{"X-Onr-Provider":["openai"]}}
+{"type":"origin_request","body":{"base64":"eyJtb2RlbCI6ImdwdC00by1taW5pIiwic3RyZWFtIjpmYWxzZX0=","size":39}}
+{"type":"upstream_response","status":200,"status_line":"200 OK","body":{"base64":"e30=","size":2,"truncated":true}}
+{"type":"proxy_response","status":200,"body":{"base64":"e30=","size":2}}
+{"type":"stream","bytes_copied":2,"error":"context canceled"}
+`
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, found, err := FindDumpByRequestID(DumpFindOptions{Dir: tmp, RequestID: "rid-jsonl"})
+	if err != nil || !found {
+		t.Fatalf("FindDumpByRequestID found=%v err=%v", found, err)
+	}
+	if sum.Path != p || sum.Provider != "openai" || sum.Model != "gpt-4o-mini" || sum.Stream == nil || *sum.Stream {
+		t.Fatalf("summary=%+v", sum)
+	}
+	if sum.ProxyStatus != 200 || sum.StreamError != "context canceled" || !sum.HasTruncated || sum.Time.IsZero() {
+		t.Fatalf("summary=%+v", sum)
+	}
+}
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
@@ -28,8 +30,11 @@ func LoadOrInitKeysDoc(path string) (*yaml.Node, error) { return loadOrInitKeysD
 func LoadOrInitModelsDoc(path string) (*yaml.Node, error) { return loadOrInitModelsDoc(path) }
 func ParseProviders(s string) []string                    { return parseProviders(s) }
 func EncodeYAML(doc *yaml.Node) ([]byte, error)           { return encodeYAML(doc) }
+
+// WriteAtomic writes data via a temp file and rename, and records the change in the audit log
+// when ConfigureAudit enabled it.
 func WriteAtomic(path string, data []byte, backup bool) error {
-	return writeAtomic(path, data, backup)
+	return writeAtomicAudited("", path, data, backup)
 }
 
 func ValueHint(v string) string { return valueHint(v) }
@@ -120,6 +125,17 @@ func UpdateModelRoute(doc *yaml.Node, modelID string, up ModelUpdate) error {
 }
 
 func EncryptKeysDocValues(doc *yaml.Node) (int, error) {
+	return walkKeysDocValueItems(doc, encryptValueFieldIfNeeded)
+}
+
+// RotateKeysDocValues re-encrypts ENC[...] values in keys.yaml with the current ONR_MASTER_KEY.
+// Plaintext values and values already encrypted with the current key are left untouched.
+func RotateKeysDocValues(doc *yaml.Node) (int, error) {
+	return walkKeysDocValueItems(doc, rotateValueFieldIfNeeded)
+}
+
+// walkKeysDocValueItems calls fn for every provider key and access key item and sums the changes.
+func walkKeysDocValueItems(doc *yaml.Node, fn func(item *yaml.Node) (int, error)) (int, error) {
 	changed := 0
 	pm, err := providersMap(doc)
 	if err != nil {
@@ -135,7 +151,7 @@ func EncryptKeysDocValues(doc *yaml.Node) (int, error) {
 			continue
 		}
 		for _, it := range keysNode.Content {
-			c, err := encryptValueFieldIfNeeded(it)
+			c, err := fn(it)
 			if err != nil {
 				return 0, err
 			}
@@ -148,7 +164,7 @@ func EncryptKeysDocValues(doc *yaml.Node) (int, error) {
 		return 0, err
 	}
 	for _, it := range aks.Content {
-		c, err := encryptValueFieldIfNeeded(it)
+		c, err := fn(it)
 		if err != nil {
 			return 0, err
 		}
@@ -166,7 +182,7 @@ func encryptValueFieldIfNeeded(item *yaml.Node) (int, error) {
 		return 0, nil
 	}
 	raw := strings.TrimSpace(v.Value)
-	if raw == "" || strings.HasPrefix(raw, "ENC[") {
+	if raw == "" || strings.HasPrefix(raw, "ENC[") || keystore.IsSecretRef(raw) {
 		return 0, nil
 	}
 	enc, err := keystore.Encrypt(raw)
@@ -178,3 +194,77 @@ func encryptValueFieldIfNeeded(item *yaml.Node) (int, error) {
 	v.Value = enc
 	return 1, nil
 }
+
+func rotateValueFieldIfNeeded(item *yaml.Node) (int, error) {
+	if item == nil || item.Kind != yaml.MappingNode {
+		return 0, nil
+	}
+	v, ok := mappingGet(item, "value")
+	if !ok || v == nil {
+		return 0, nil
+	}
+	out, changed, err := keystore.Rotate(strings.TrimSpace(v.Value))
+	if err != nil {
+		return 0, fmt.Errorf("rotate value failed: %w", err)
+	}
+	if !changed {
+		return 0, nil
+	}
+	v.Kind = yaml.ScalarNode
+	v.Tag = "!!str"
+	v.Value = out
+	return 1, nil
+}
+
+// RotateOAuthTokenFiles re-encrypts access tokens persisted by the OAuth client under dir.
+// It returns the number of rewritten files; a missing dir is not an error.
+func RotateOAuthTokenFiles(dir string, backup bool, dryRun bool) (int, error) {
+	d := strings.TrimSpace(dir)
+	if d == "" {
+		return 0, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(d, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	type rotated struct {
+		path string
+		data []byte
+	}
+	pending := make([]rotated, 0, len(paths))
+	for _, p := range paths {
+		// #nosec G304 -- admin tool reads the configured token persistence directory.
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return 0, err
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return 0, fmt.Errorf("parse oauth token file %s: %w", p, err)
+		}
+		tok, _ := doc["access_token"].(string)
+		out, changed, err := keystore.Rotate(tok)
+		if err != nil {
+			return 0, fmt.Errorf("rotate oauth token file %s: %w", p, err)
+		}
+		if !changed {
+			continue
+		}
+		doc["access_token"] = out
+		nb, err := json.Marshal(doc)
+		if err != nil {
+			return 0, err
+		}
+		pending = append(pending, rotated{path: p, data: nb})
+	}
+	// Decrypt everything before writing anything so a missing old key leaves the directory untouched.
+	if dryRun {
+		return len(pending), nil
+	}
+	for _, r := range pending {
+		if err := writeAtomicAudited("", r.path, r.data, backup); err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), nil
+}
@@ -192,6 +192,8 @@ func encodeYAML(doc *yaml.Node) ([]byte, error) {
 	return []byte(sb.String()), nil
 }
 
+// writeAtomic replaces path through a temp file and rename. New files are
+// created 0600; an existing file keeps its permission bits.
 func writeAtomic(path string, data []byte, backup bool) error {
 	p := strings.TrimSpace(path)
 	if p == "" {
@@ -202,8 +204,13 @@ func writeAtomic(path string, data []byte, backup bool) error {
 		return err
 	}
 
+	perm := os.FileMode(0o600)
+	info, statErr := os.Stat(p)
+	if statErr == nil {
+		perm = info.Mode().Perm()
+	}
 	if backup {
-		if _, err := os.Stat(p); err == nil {
+		if statErr == nil {
 			ts := time.Now().Format("20060102-150405")
 			bpath := p + ".bak." + ts
 			if err := copyFile(p, bpath); err != nil {
@@ -216,6 +223,11 @@ func writeAtomic(path string, data []byte, backup bool) error {
 	if err := os.WriteFile(tmp, data, 0o600); err != nil {
 		return err
 	}
+	// WriteFile's mode is masked by the umask and ignored for an existing temp file.
+	if err := os.Chmod(tmp, perm); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
 	return os.Rename(tmp, p)
 }
 
@@ -245,6 +257,9 @@ func valueHint(v string) string {
 	if strings.HasPrefix(s, "ENC[") {
 		return "(ENC[...])"
 	}
+	if keystore.IsSecretRef(s) {
+		return fmt.Sprintf("(%s)", s)
+	}
 	if len(s) <= 8 {
 		return fmt.Sprintf("%q", s)
 	}
@@ -712,6 +727,9 @@ func listAccessKeysDoc(doc *yaml.Node) ([]keystore.AccessKey, error) {
 		if v, ok := mappingGet(it, "comment"); ok && v != nil {
 			ak.Comment = strings.TrimSpace(v.Value)
 		}
+		if v, ok := mappingGet(it, "client_cert_subject"); ok && v != nil {
+			ak.ClientCertSubject = strings.TrimSpace(v.Value)
+		}
 		out = append(out, ak)
 	}
 	return out, nil
@@ -733,6 +751,9 @@ func appendAccessKeyDoc(doc *yaml.Node, ak keystore.AccessKey) error {
 	if strings.TrimSpace(ak.Comment) != "" {
 		mappingSet(m, "comment", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSpace(ak.Comment)})
 	}
+	if strings.TrimSpace(ak.ClientCertSubject) != "" {
+		mappingSet(m, "client_cert_subject", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSpace(ak.ClientCertSubject)})
+	}
 	seq.Content = append(seq.Content, m)
 	return nil
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
 )
 
 type dumpViewerState int
@@ -357,6 +359,12 @@ func (m dumpViewerModel) readDumpFileCmd(path string) tea.Cmd {
 		if err != nil {
 			return dumpFileMsg{path: p, err: err}
 		}
+		// jsonl dumps are shown in the text layout.
+		if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '{' {
+			if d, perr := trafficdump.Parse(bytes.NewReader(b)); perr == nil {
+				return dumpFileMsg{path: p, content: d.Text()}
+			}
+		}
 		return dumpFileMsg{path: p, content: string(b)}
 	}
 }
@@ -12,6 +12,14 @@ const curlOutputEl = document.getElementById("curlOutput");
 const execOutputEl = document.getElementById("execOutput");
 const requestIdInputEl = document.getElementById("requestIdInput");
 const dumpOutputEl = document.getElementById("dumpOutput");
+const oauthProfileEl = document.getElementById("oauthProfile");
+const oauthProviderEl = document.getElementById("oauthProvider");
+const oauthKeyNameEl = document.getElementById("oauthKeyName");
+const oauthClientSecretEl = document.getElementById("oauthClientSecret");
+const oauthCallbackUrlEl = document.getElementById("oauthCallbackUrl");
+const oauthOutputEl = document.getElementById("oauthOutput");
+let oauthSessionID = "";
+let oauthPollTimer = null;
 const semanticClassByType = {
   keyword: "tok-keyword",
   string: "tok-string",
@@ -37,7 +45,8 @@ const editor = window.CodeMirror ? window.CodeMirror.fromTextArea(contentEl, {
   lineWrapping: false,
   indentUnit: 2,
   tabSize: 2,
-  viewportMargin: Infinity
+  viewportMargin: Infinity,
+  extraKeys: { "Ctrl-Space": showEditorCompletion }
 }) : null;
 
 if (editor) {
@@ -154,6 +163,49 @@ async function fetchEditorHover(provider, content, position) {
   return data;
 }
 
+async function fetchEditorCompletion(provider, content, position) {
+  const res = await fetch("/api/editor/completion", {
+    method: "POST",
+    headers: { "content-type": "application/json" },
+    body: JSON.stringify({ provider, content, position })
+  });
+  const data = await res.json();
+  if (!res.ok || !data.ok) {
+    throw new Error(data.error || "completion failed");
+  }
+  return data;
+}
+
+function showEditorCompletion(cm) {
+  const provider = currentProvider();
+  if (!provider || typeof cm.showHint !== "function") {
+    return;
+  }
+  const hint = (editorInstance, callback) => {
+    const cursor = editorInstance.getCursor();
+    fetchEditorCompletion(provider, editorInstance.getValue(), { line: cursor.line, character: cursor.ch })
+      .then((data) => {
+        const items = Array.isArray(data.items) ? data.items : [];
+        if (items.length === 0) {
+          callback(null);
+          return;
+        }
+        const range = items[0].textEdit ? items[0].textEdit.range : null;
+        callback({
+          list: items.map((item) => ({
+            text: item.textEdit ? item.textEdit.newText : item.label,
+            displayText: item.detail ? `${item.label}  (${item.detail})` : item.label
+          })),
+          from: range ? window.CodeMirror.Pos(range.start.line, range.start.character) : cursor,
+          to: range ? window.CodeMirror.Pos(range.end.line, range.end.character) : cursor
+        });
+      })
+      .catch(() => callback(null));
+  };
+  hint.async = true;
+  cm.showHint({ hint, completeSingle: false });
+}
+
 function renderDiagnostics(diagnostics) {
   const list = Array.isArray(diagnostics) ? diagnostics : [];
   clearDiagnosticMarks();
@@ -525,12 +577,12 @@ function generateCurl() {
   }
 }
 
-async function runRequest() {
+async function runRequest(explain) {
   const ctx = buildTestContext();
   if (!ctx) {
     return;
   }
-  execOutputEl.textContent = "Running request...";
+  execOutputEl.textContent = explain ? "Explaining request..." : "Running request...";
   const res = await fetch("/api/test/request", {
     method: "POST",
     headers: { "content-type": "application/json" },
@@ -539,7 +591,8 @@ async function runRequest() {
       path: ctx.path,
       authorization: ctx.authorization,
       provider: ctx.provider,
-      payload: ctx.payload
+      payload: ctx.payload,
+      explain: explain === true
     })
   });
   const data = await res.json();
@@ -612,6 +665,127 @@ async function loadDumpByRequestID() {
   });
 }
 
+function renderOAuthSession(sess) {
+  const lines = [`session: ${sess.id}`, `profile: ${sess.profile} (${sess.flow})`, `provider: ${sess.provider}`, `status: ${sess.status}`];
+  if (sess.login_url) {
+    lines.push("", "Open to log in:", sess.login_url);
+    if (sess.redirect_uri) {
+      lines.push("", `callback: ${sess.redirect_uri}`, "If the callback page does not load, paste the final redirect URL above and click Submit Callback.");
+    }
+  }
+  if (sess.verify_url) {
+    lines.push("", "Open to approve:", sess.verify_url);
+  }
+  if (sess.user_code) {
+    lines.push(`user code: ${sess.user_code}`);
+  }
+  if (sess.error) {
+    lines.push("", `error: ${sess.error}`);
+  }
+  if (sess.saved) {
+    lines.push("", `saved to ${sess.keys_file}` + (sess.key_name ? ` as ${sess.key_name}` : ""));
+    lines.push(sess.reloaded ? "onr reloaded" : `reload skipped: ${sess.reload_error || "unknown"}`);
+  }
+  oauthOutputEl.textContent = lines.join("\n");
+}
+
+function stopOAuthPolling() {
+  if (oauthPollTimer) {
+    clearInterval(oauthPollTimer);
+    oauthPollTimer = null;
+  }
+}
+
+async function pollOAuthSession() {
+  if (!oauthSessionID) {
+    stopOAuthPolling();
+    return;
+  }
+  const res = await fetch("/api/oauth/session?id=" + encodeURIComponent(oauthSessionID));
+  const data = await res.json();
+  if (!res.ok || !data.ok) {
+    stopOAuthPolling();
+    setStatus(data);
+    return;
+  }
+  renderOAuthSession(data.session);
+  if (data.session.status !== "pending") {
+    stopOAuthPolling();
+  }
+}
+
+async function startOAuth() {
+  stopOAuthPolling();
+  const provider = String(oauthProviderEl.value || "").trim().toLowerCase() || oauthProfileEl.value;
+  oauthOutputEl.textContent = "Starting OAuth...";
+  const res = await fetch("/api/oauth/start", {
+    method: "POST",
+    headers: { "content-type": "application/json" },
+    body: JSON.stringify({
+      profile: oauthProfileEl.value,
+      provider,
+      key_name: String(oauthKeyNameEl.value || "").trim(),
+      client_secret: String(oauthClientSecretEl.value || "").trim()
+    })
+  });
+  const data = await res.json();
+  if (!res.ok || !data.ok) {
+    oauthOutputEl.textContent = JSON.stringify(data, null, 2);
+    return;
+  }
+  oauthSessionID = data.session.id;
+  renderOAuthSession(data.session);
+  const target = data.session.login_url || data.session.verify_url;
+  if (target) {
+    window.open(target, "_blank", "noopener");
+  }
+  oauthPollTimer = setInterval(() => pollOAuthSession().catch((err) => setStatus(String(err))), 2000);
+}
+
+async function submitOAuthCallback() {
+  const callbackURL = String(oauthCallbackUrlEl.value || "").trim();
+  if (!oauthSessionID || !callbackURL) {
+    setStatus("Start an OAuth session and paste the redirect URL first.");
+    return;
+  }
+  const res = await fetch("/api/oauth/callback", {
+    method: "POST",
+    headers: { "content-type": "application/json" },
+    body: JSON.stringify({ session: oauthSessionID, callback_url: callbackURL })
+  });
+  const data = await res.json();
+  if (!res.ok || !data.ok) {
+    setStatus(data);
+    return;
+  }
+  oauthCallbackUrlEl.value = "";
+  renderOAuthSession(data.session);
+}
+
+async function loadOAuthStatus() {
+  const k = String(onrKEl.value || "").trim();
+  if (!k) {
+    setStatus("k is empty: the admin status endpoint needs the ONR master key or an access key.");
+    return;
+  }
+  oauthOutputEl.textContent = "Loading OAuth token status...";
+  const res = await fetch("/api/oauth/status", {
+    method: "POST",
+    headers: { "content-type": "application/json" },
+    body: JSON.stringify({
+      base_url: normalizeBaseURL(onrBaseUrlEl.value),
+      authorization: "Bearer " + k,
+      api: testApiEl.value
+    })
+  });
+  const data = await res.json();
+  if (!res.ok || !data.ok) {
+    oauthOutputEl.textContent = JSON.stringify(data, null, 2);
+    return;
+  }
+  oauthOutputEl.textContent = formatCurlLikeResponse(data, false);
+}
+
 async function copyCurl() {
   const text = String(curlOutputEl.value || "").trim();
   if (!text) {
@@ -703,9 +877,13 @@ document.getElementById("validateBtn").addEventListener("click", validateProvide
 document.getElementById("formatBtn").addEventListener("click", formatProvider);
 document.getElementById("saveBtn").addEventListener("click", saveProvider);
 document.getElementById("genCurlBtn").addEventListener("click", generateCurl);
-document.getElementById("runRequestBtn").addEventListener("click", runRequest);
+document.getElementById("runRequestBtn").addEventListener("click", () => runRequest(false));
+document.getElementById("explainRequestBtn").addEventListener("click", () => runRequest(true));
 document.getElementById("copyCurlBtn").addEventListener("click", copyCurl);
 document.getElementById("loadDumpBtn").addEventListener("click", loadDumpByRequestID);
+document.getElementById("oauthStartBtn").addEventListener("click", () => startOAuth().catch((err) => setStatus(String(err))));
+document.getElementById("oauthCallbackBtn").addEventListener("click", () => submitOAuthCallback().catch((err) => setStatus(String(err))));
+document.getElementById("oauthStatusBtn").addEventListener("click", () => loadOAuthStatus().catch((err) => setStatus(String(err))));
 
 refreshProviders()
   .then(() => runEditorAnalysis())
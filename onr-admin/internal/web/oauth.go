@@ -0,0 +1,419 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/oauthflow"
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+)
+
+const (
+	oauthSessionTimeout = 10 * time.Minute
+	oauthSessionTTL     = 30 * time.Minute
+
+	oauthSessionPending = "pending"
+	oauthSessionDone    = "done"
+	oauthSessionError   = "error"
+)
+
+type oauthStartRequest struct {
+	// Profile is the oauthflow profile (openai, claude, qwen, ...).
+	Profile string `json:"profile"`
+	// Provider is the keys.yaml provider the refresh token is saved under.
+	Provider     string `json:"provider"`
+	KeyName      string `json:"key_name,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
+	// CallbackPort overrides the server's --oauth-callback-port when
+	// RedirectURI is empty.
+	CallbackPort *int `json:"callback_port,omitempty"`
+	// Endpoint overrides, required by the custom profile.
+	AuthURL  string `json:"auth_url,omitempty"`
+	TokenURL string `json:"token_url,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+type oauthCallbackRequest struct {
+	Session     string `json:"session"`
+	CallbackURL string `json:"callback_url"`
+}
+
+type oauthStatusRequest struct {
+	BaseURL       string `json:"base_url"`
+	Authorization string `json:"authorization"`
+	API           string `json:"api,omitempty"`
+}
+
+type oauthSessionView struct {
+	ID       string `json:"id"`
+	Profile  string `json:"profile"`
+	Provider string `json:"provider"`
+	KeyName  string `json:"key_name,omitempty"`
+	Flow     string `json:"flow"`
+	// LoginURL (auth-code) or VerifyURL/UserCode (device-code) is what the user opens.
+	LoginURL string `json:"login_url,omitempty"`
+	// CallbackListening reports the redirect listener of an auth-code flow.
+	// The redirect URL may also be pasted into /api/oauth/callback.
+	CallbackListening bool      `json:"callback_listening,omitempty"`
+	RedirectURI       string    `json:"redirect_uri,omitempty"`
+	VerifyURL         string    `json:"verify_url,omitempty"`
+	UserCode          string    `json:"user_code,omitempty"`
+	Status            string    `json:"status"`
+	Error             string    `json:"error,omitempty"`
+	Saved             bool      `json:"saved,omitempty"`
+	KeysFile          string    `json:"keys_file,omitempty"`
+	Reloaded          bool      `json:"reloaded,omitempty"`
+	ReloadError       string    `json:"reload_error,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type oauthSessionResponse struct {
+	OK      bool              `json:"ok"`
+	Session *oauthSessionView `json:"session,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// oauthSession is one onboarding flow started from the web UI. The refresh
+// token never leaves the server: it is encrypted and appended to keys.yaml.
+type oauthSession struct {
+	view      oauthSessionView
+	callbacks chan oauthflow.Callback
+	cancel    context.CancelFunc
+}
+
+func (s *Server) handleOAuthStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var in oauthStartRequest
+	if err := decodeJSONBody(r, &in); err != nil {
+		writeJSONAny(w, http.StatusBadRequest, oauthSessionResponse{Error: err.Error()})
+		return
+	}
+	view, err := s.startOAuthSession(in, s.auditActor(r))
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, oauthSessionResponse{Error: err.Error()})
+		return
+	}
+	writeJSONAny(w, http.StatusOK, oauthSessionResponse{OK: true, Session: view})
+}
+
+func (s *Server) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var in oauthCallbackRequest
+	if err := decodeJSONBody(r, &in); err != nil {
+		writeJSONAny(w, http.StatusBadRequest, oauthSessionResponse{Error: err.Error()})
+		return
+	}
+	cb, err := oauthflow.ParseCallbackURL(in.CallbackURL)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, oauthSessionResponse{Error: err.Error()})
+		return
+	}
+	s.oauthMu.Lock()
+	sess, ok := s.oauthSessions[strings.TrimSpace(in.Session)]
+	s.oauthMu.Unlock()
+	if !ok || sess.callbacks == nil {
+		writeJSONAny(w, http.StatusNotFound, oauthSessionResponse{Error: "oauth auth-code session not found"})
+		return
+	}
+	select {
+	case sess.callbacks <- cb:
+	default:
+		writeJSONAny(w, http.StatusConflict, oauthSessionResponse{Error: "oauth session already received a callback"})
+		return
+	}
+	writeJSONAny(w, http.StatusOK, oauthSessionResponse{OK: true, Session: s.oauthSessionView(sess)})
+}
+
+func (s *Server) handleOAuthSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	s.oauthMu.Lock()
+	sess, ok := s.oauthSessions[strings.TrimSpace(r.URL.Query().Get("id"))]
+	s.oauthMu.Unlock()
+	if !ok {
+		writeJSONAny(w, http.StatusNotFound, oauthSessionResponse{Error: "oauth session not found"})
+		return
+	}
+	writeJSONAny(w, http.StatusOK, oauthSessionResponse{OK: true, Session: s.oauthSessionView(sess)})
+}
+
+// handleOAuthStatus forwards to the running server's GET /admin/oauth/status.
+func (s *Server) handleOAuthStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var in oauthStatusRequest
+	if err := decodeJSONBody(r, &in); err != nil {
+		writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: err.Error()})
+		return
+	}
+	baseURL, err := normalizeBaseURL(in.BaseURL)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(in.Authorization) == "" {
+		writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: "authorization is empty"})
+		return
+	}
+	target := baseURL + "/admin/oauth/status"
+	if api := strings.TrimSpace(in.API); api != "" {
+		target += "?api=" + url.QueryEscape(api)
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: err.Error()})
+		return
+	}
+	req.Header.Set("Authorization", strings.TrimSpace(in.Authorization))
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadGateway, testResponse{OK: false, Error: err.Error()})
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _, err := readBodyLimit(resp.Body, 2*1024*1024)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadGateway, testResponse{OK: false, Error: err.Error()})
+		return
+	}
+	writeJSONAny(w, http.StatusOK, testResponse{OK: true, Status: resp.StatusCode, Body: string(body)})
+}
+
+// startOAuthSession validates in, starts the flow and returns the pending session.
+func (s *Server) startOAuthSession(in oauthStartRequest, actor string) (*oauthSessionView, error) {
+	provider, err := normalizeProviderName(in.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if !keystore.HasMasterKey() {
+		return nil, errors.New("ONR_MASTER_KEY is not set: refresh tokens are stored encrypted in keys.yaml")
+	}
+	profile, err := oauthflow.ResolveProfile(in.Profile)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := oauthflow.Resolve(profile, oauthflow.Overrides{
+		AuthURL:      in.AuthURL,
+		TokenURL:     in.TokenURL,
+		ClientID:     in.ClientID,
+		ClientSecret: in.ClientSecret,
+		Scope:        in.Scope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	id, err := oauthflow.RandomURLSafe(16)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), oauthSessionTimeout)
+	sess := &oauthSession{
+		view: oauthSessionView{
+			ID:        id,
+			Profile:   settings.Name,
+			Provider:  provider,
+			KeyName:   strings.TrimSpace(in.KeyName),
+			Flow:      settings.Flow,
+			Status:    oauthSessionPending,
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+
+	var wait func(context.Context) (string, error)
+	if settings.Flow == oauthflow.FlowDeviceCode {
+		flow, err := oauthflow.StartDeviceCode(ctx, settings)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		sess.view.VerifyURL = flow.VerifyURL
+		sess.view.UserCode = flow.UserCode
+		wait = flow.Poll
+	} else {
+		ln, redirectURI, err := s.listenOAuthCallback(in)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		flow, err := oauthflow.StartAuthCode(settings, redirectURI)
+		if err != nil {
+			_ = ln.Close()
+			cancel()
+			return nil, err
+		}
+		sess.view.LoginURL = flow.LoginURL
+		sess.view.RedirectURI = redirectURI
+		sess.view.CallbackListening = true
+		sess.callbacks = make(chan oauthflow.Callback, 1)
+		srv := oauthflow.ServeCallback(ln, sess.callbacks)
+		context.AfterFunc(ctx, func() {
+			stopCtx, stop := context.WithTimeout(context.Background(), 3*time.Second)
+			defer stop()
+			_ = srv.Shutdown(stopCtx)
+		})
+		wait = func(ctx context.Context) (string, error) {
+			cb, err := oauthflow.WaitCallback(ctx, sess.callbacks)
+			if err != nil {
+				return "", err
+			}
+			return flow.Exchange(ctx, cb)
+		}
+	}
+
+	s.oauthMu.Lock()
+	s.pruneOAuthSessionsLocked()
+	s.oauthSessions[id] = sess
+	s.oauthMu.Unlock()
+
+	go func() {
+		defer cancel()
+		token, err := wait(ctx)
+		if err == nil {
+			err = s.saveOAuthRefreshToken(actor, provider, sess.view.KeyName, token)
+		}
+		var reloadErr error
+		if err == nil {
+			if s.reload == nil {
+				reloadErr = errors.New("reload not configured: restart onr or run `onr -s reload`")
+			} else {
+				reloadErr = s.reload()
+			}
+		}
+		s.oauthMu.Lock()
+		defer s.oauthMu.Unlock()
+		if err != nil {
+			sess.view.Status = oauthSessionError
+			sess.view.Error = err.Error()
+			return
+		}
+		sess.view.Status = oauthSessionDone
+		sess.view.Saved = true
+		sess.view.KeysFile = s.keysPath
+		sess.view.Reloaded = reloadErr == nil
+		if reloadErr != nil {
+			sess.view.ReloadError = reloadErr.Error()
+		}
+	}()
+	return s.oauthSessionView(sess), nil
+}
+
+// saveOAuthRefreshToken encrypts token and appends it as a provider key.
+func (s *Server) saveOAuthRefreshToken(actor, provider, keyName, token string) error {
+	enc, err := keystore.Encrypt(token)
+	if err != nil {
+		return fmt.Errorf("encrypt refresh token: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := store.LoadOrInitKeysDoc(s.keysPath)
+	if err != nil {
+		return err
+	}
+	if err := store.AppendProviderKey(doc, provider, keystore.Key{Name: keyName, Value: enc}); err != nil {
+		return err
+	}
+	if err := store.ValidateKeysDoc(doc); err != nil {
+		return err
+	}
+	data, err := store.EncodeYAML(doc)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteAtomicAs(actor, s.keysPath, data, true); err != nil {
+		return fmt.Errorf("write keys file: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) oauthSessionView(sess *oauthSession) *oauthSessionView {
+	s.oauthMu.Lock()
+	defer s.oauthMu.Unlock()
+	v := sess.view
+	return &v
+}
+
+// pruneOAuthSessionsLocked drops sessions older than oauthSessionTTL. The
+// caller must hold s.oauthMu.
+func (s *Server) pruneOAuthSessionsLocked() {
+	cutoff := time.Now().Add(-oauthSessionTTL)
+	for id, sess := range s.oauthSessions {
+		if sess.view.CreatedAt.Before(cutoff) {
+			sess.cancel()
+			delete(s.oauthSessions, id)
+		}
+	}
+}
+
+// listenOAuthCallback binds the auth-code redirect listener: the port of an
+// explicit redirect_uri, else callback_port or the server's configured port,
+// where 0 picks an ephemeral port. A privileged or busy port is an error.
+func (s *Server) listenOAuthCallback(in oauthStartRequest) (net.Listener, string, error) {
+	if redirectURI := strings.TrimSpace(in.RedirectURI); redirectURI != "" {
+		port, err := oauthflow.CallbackPort(redirectURI)
+		if err != nil {
+			return nil, "", err
+		}
+		if port == 0 {
+			return nil, "", fmt.Errorf("redirect_uri %q must include a non-zero port", redirectURI)
+		}
+		ln, err := oauthflow.ListenCallback(port)
+		if err != nil {
+			return nil, "", err
+		}
+		return ln, redirectURI, nil
+	}
+	port := s.oauthCallbackPort
+	if in.CallbackPort != nil {
+		port = *in.CallbackPort
+	}
+	ln, err := oauthflow.ListenCallback(port)
+	if err != nil {
+		return nil, "", err
+	}
+	return ln, oauthflow.DefaultRedirectURI(oauthflow.ListenerPort(ln)), nil
+}
+
+func decodeJSONBody(r *http.Request, out any) error {
+	defer func() { _ = r.Body.Close() }()
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+func normalizeBaseURL(raw string) (string, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(raw), "/")
+	if baseURL == "" {
+		return "", errors.New("base_url is empty")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.New("base_url is invalid")
+	}
+	scheme := strings.ToLower(strings.TrimSpace(u.Scheme))
+	if scheme != "http" && scheme != "https" {
+		return "", errors.New("base_url scheme must be http or https")
+	}
+	return baseURL, nil
+}
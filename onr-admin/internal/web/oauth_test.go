@@ -0,0 +1,210 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+)
+
+func TestOAuthDeviceFlowSavesEncryptedKeyAndReloads(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", "12345678901234567890123456789012")
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/device":
+			_, _ = w.Write([]byte(`{"device_code":"dc1","user_code":"WXYZ","verification_uri":"https://example.com/activate","interval":1}`))
+		case "/token":
+			_, _ = w.Write([]byte(`{"access_token":"at","refresh_token":"rt-web"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer idp.Close()
+
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	srv.keysPath = filepath.Join(t.TempDir(), "keys.yaml")
+	reloads := 0
+	srv.reload = func() error {
+		reloads++
+		return nil
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	var started oauthSessionResponse
+	status := postOAuthJSON(t, httpSrv.URL+"/api/oauth/start", oauthStartRequest{
+		Profile:  "kimi",
+		Provider: "kimi",
+		KeyName:  "kimi-oauth",
+		AuthURL:  idp.URL + "/device",
+		TokenURL: idp.URL + "/token",
+	}, &started)
+	if status != http.StatusOK || !started.OK || started.Session == nil {
+		t.Fatalf("start status=%d body=%+v", status, started)
+	}
+	if started.Session.VerifyURL != "https://example.com/activate" || started.Session.UserCode != "WXYZ" {
+		t.Fatalf("session=%+v", started.Session)
+	}
+
+	var sess *oauthSessionView
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var got oauthSessionResponse
+		resp, err := http.Get(httpSrv.URL + "/api/oauth/session?id=" + started.Session.ID)
+		if err != nil {
+			t.Fatalf("get session: %v", err)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode session: %v", err)
+		}
+		_ = resp.Body.Close()
+		if got.Session != nil && got.Session.Status != oauthSessionPending {
+			sess = got.Session
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session still pending: %+v", got.Session)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if sess.Status != oauthSessionDone || !sess.Saved || !sess.Reloaded || reloads != 1 {
+		t.Fatalf("session=%+v reloads=%d", sess, reloads)
+	}
+
+	raw, err := os.ReadFile(srv.keysPath)
+	if err != nil {
+		t.Fatalf("read keys: %v", err)
+	}
+	if strings.Contains(string(raw), "rt-web") || !strings.Contains(string(raw), "ENC[") {
+		t.Fatalf("refresh token should be stored encrypted:\n%s", raw)
+	}
+	ks, err := keystore.Load(srv.keysPath)
+	if err != nil {
+		t.Fatalf("keystore.Load: %v", err)
+	}
+	keys := ks.Keys("kimi")
+	if len(keys) != 1 || keys[0].Name != "kimi-oauth" || keys[0].Value != "rt-web" {
+		t.Fatalf("keys=%+v", keys)
+	}
+}
+
+func TestOAuthStartRequiresMasterKey(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", "")
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	var got oauthSessionResponse
+	status := postOAuthJSON(t, httpSrv.URL+"/api/oauth/start", oauthStartRequest{Profile: "qwen", Provider: "qwen"}, &got)
+	if status != http.StatusBadRequest || !strings.Contains(got.Error, "ONR_MASTER_KEY") {
+		t.Fatalf("status=%d body=%+v", status, got)
+	}
+}
+
+func TestOAuthStatusEndpointForwardsToServer(t *testing.T) {
+	onrSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/oauth/status" || r.URL.Query().Get("api") != "claude.messages" || r.Header.Get("Authorization") != "Bearer admin" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"api":"claude.messages","keys":[]}`))
+	}))
+	defer onrSrv.Close()
+
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	var got testResponse
+	status := postOAuthJSON(t, httpSrv.URL+"/api/oauth/status", oauthStatusRequest{
+		BaseURL:       onrSrv.URL,
+		Authorization: "Bearer admin",
+		API:           "claude.messages",
+	}, &got)
+	if status != http.StatusOK || !got.OK || got.Status != http.StatusOK || !strings.Contains(got.Body, `"keys":[]`) {
+		t.Fatalf("status=%d body=%+v", status, got)
+	}
+}
+
+func postOAuthJSON(t *testing.T, url string, in any, out any) int {
+	t.Helper()
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("post %s: %v", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestOAuthStartCallbackPort(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", "12345678901234567890123456789012")
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = busy.Close() }()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+
+	for name, tc := range map[string]struct {
+		in   oauthStartRequest
+		want string
+	}{
+		"privileged":    {in: oauthStartRequest{CallbackPort: intPtr(80)}, want: "privileged"},
+		"taken":         {in: oauthStartRequest{CallbackPort: intPtr(busyPort)}, want: "unavailable"},
+		"implicit_port": {in: oauthStartRequest{RedirectURI: "http://localhost/auth/callback"}, want: "explicit port"},
+	} {
+		tc.in.Profile, tc.in.Provider = "openai", "openai"
+		var got oauthSessionResponse
+		status := postOAuthJSON(t, httpSrv.URL+"/api/oauth/start", tc.in, &got)
+		if status != http.StatusBadRequest || !strings.Contains(got.Error, tc.want) {
+			t.Fatalf("%s: status=%d body=%+v, want error %q", name, status, got, tc.want)
+		}
+	}
+
+	var started oauthSessionResponse
+	status := postOAuthJSON(t, httpSrv.URL+"/api/oauth/start", oauthStartRequest{Profile: "openai", Provider: "openai", CallbackPort: intPtr(0)}, &started)
+	if status != http.StatusOK || started.Session == nil || !started.Session.CallbackListening {
+		t.Fatalf("ephemeral start status=%d body=%+v", status, started)
+	}
+	u, err := url.Parse(started.Session.RedirectURI)
+	if err != nil || u.Port() == "" || u.Port() == "0" {
+		t.Fatalf("redirect_uri=%q", started.Session.RedirectURI)
+	}
+	if !strings.Contains(started.Session.LoginURL, url.QueryEscape(started.Session.RedirectURI)) {
+		t.Fatalf("login url %q does not carry redirect_uri %q", started.Session.LoginURL, started.Session.RedirectURI)
+	}
+}
+
+func intPtr(v int) *int { return &v }
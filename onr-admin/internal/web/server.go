@@ -8,7 +8,9 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -18,6 +20,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/r9s-ai/open-next-router/onr"
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/oauthflow"
 	"github.com/r9s-ai/open-next-router/onr-admin/internal/providersource"
 	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
@@ -31,6 +35,7 @@ const (
 	defaultAPIBaseURL  = "http://127.0.0.1:3300"
 	envAPIBaseURL      = "ONR_ADMIN_WEB_CURL_API_BASE_URL"
 	envListen          = "ONR_ADMIN_WEB_LISTEN"
+	envTrustedProxies  = "ONR_ADMIN_WEB_TRUSTED_PROXIES"
 	dumpBodyLimitBytes = 2 * 1024 * 1024
 )
 
@@ -40,6 +45,12 @@ type Options struct {
 	ConfigPath   string
 	ProvidersDir string
 	Listen       string
+	// TrustedProxies lists IPs/CIDRs of authenticating reverse proxies whose
+	// X-Forwarded-User style headers name the audit actor.
+	TrustedProxies []string
+	// OAuthCallbackPort is the redirect listener port of OAuth onboarding
+	// sessions without redirect_uri; 0 picks an ephemeral port.
+	OAuthCallbackPort int
 }
 
 type Server struct {
@@ -47,6 +58,18 @@ type Server struct {
 	dumpsDir       string
 	indexHTML      string
 	mu             sync.Mutex
+
+	// keysPath receives refresh tokens from OAuth onboarding; reload, when
+	// set, asks the running onr to pick them up.
+	keysPath      string
+	reload        func() error
+	oauthMu       sync.Mutex
+	oauthSessions map[string]*oauthSession
+
+	// trustedProxies gates the forwarded-user headers used as audit actor.
+	trustedProxies []netip.Prefix
+
+	oauthCallbackPort int
 }
 
 type providerRequest struct {
@@ -71,6 +94,9 @@ type testRequest struct {
 	Authorization string `json:"authorization"`
 	Provider      string `json:"provider"`
 	Payload       string `json:"payload"`
+	// Explain sends the request to the server's /admin/explain endpoint so it is
+	// resolved (provider, key, upstream URL/headers/body) without reaching upstream.
+	Explain bool `json:"explain,omitempty"`
 }
 
 type testResponse struct {
@@ -130,6 +156,15 @@ type editorHoverResponse struct {
 	Error      string         `json:"error,omitempty"`
 }
 
+type editorCompletionResponse struct {
+	OK         bool                     `json:"ok"`
+	Provider   string                   `json:"provider,omitempty"`
+	TargetFile string                   `json:"target_file,omitempty"`
+	URI        string                   `json:"uri,omitempty"`
+	Items      []dsllang.CompletionItem `json:"items"`
+	Error      string                   `json:"error,omitempty"`
+}
+
 type editorFormatResponse struct {
 	OK         bool   `json:"ok"`
 	Provider   string `json:"provider,omitempty"`
@@ -147,13 +182,24 @@ func Run(opts Options) error {
 	if err != nil {
 		return err
 	}
+	srv.trustedProxies, err = parseTrustedProxies(resolveTrustedProxies(opts.TrustedProxies))
+	if err != nil {
+		return err
+	}
+	srv.oauthCallbackPort = opts.OAuthCallbackPort
+	cfgPath := strings.TrimSpace(opts.ConfigPath)
+	srv.keysPath = resolveKeysPath(cfgPath)
+	if cfgPath != "" {
+		srv.reload = func() error { return onr.SendReload(cfgPath) }
+	}
 	listen := resolveListenAddress(opts.Listen)
 	log.Printf(
-		"onr-admin web listening: url=%q providers_source=%q providers_edit_path=%q dumps_dir=%q default_curl_api_base_url=%q",
+		"onr-admin web listening: url=%q providers_source=%q providers_edit_path=%q dumps_dir=%q keys_file=%q default_curl_api_base_url=%q",
 		"http://"+listen,
 		srv.providerSource.SourcePath,
 		srv.providerSource.EditablePath,
 		dumpsDir,
+		srv.keysPath,
 		defaultBaseURL,
 	)
 	return http.ListenAndServe(listen, srv.Handler())
@@ -180,6 +226,10 @@ func newServer(providersDir string, dumpsDir string, defaultBaseURL string) (*Se
 		providerSource: sourceInfo,
 		dumpsDir:       dumpDir,
 		indexHTML:      renderIndexHTML(defaultBaseURL),
+		keysPath:       resolveKeysPath(""),
+		oauthSessions:  map[string]*oauthSession{},
+
+		oauthCallbackPort: oauthflow.DefaultCallbackPort,
 	}, nil
 }
 
@@ -195,9 +245,14 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/api/editor/diagnostics", s.handleEditorDiagnostics)
 	mux.HandleFunc("/api/editor/semantic-tokens", s.handleEditorSemanticTokens)
 	mux.HandleFunc("/api/editor/hover", s.handleEditorHover)
+	mux.HandleFunc("/api/editor/completion", s.handleEditorCompletion)
 	mux.HandleFunc("/api/editor/format", s.handleEditorFormat)
 	mux.HandleFunc("/api/test/request", s.handleTestRequest)
 	mux.HandleFunc("/api/dumps/by-request-id", s.handleDumpByRequestID)
+	mux.HandleFunc("/api/oauth/start", s.handleOAuthStart)
+	mux.HandleFunc("/api/oauth/callback", s.handleOAuthCallback)
+	mux.HandleFunc("/api/oauth/session", s.handleOAuthSession)
+	mux.HandleFunc("/api/oauth/status", s.handleOAuthStatus)
 	return mux
 }
 
@@ -302,12 +357,14 @@ func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	actor := s.auditActor(r)
 	res, target, err := s.validateCandidate(in.Provider, in.Content)
 	if err != nil {
+		auditRejectedProviderSave(s.providerSource, actor, in.Provider, in.Content, err)
 		writeJSON(w, http.StatusBadRequest, providerResponse{OK: false, Error: err.Error()})
 		return
 	}
-	if err := writeProviderContent(s.providerSource, in.Provider, in.Content); err != nil {
+	if err := writeProviderContent(s.providerSource, actor, in.Provider, in.Content); err != nil {
 		writeJSON(w, http.StatusInternalServerError, providerResponse{OK: false, Error: err.Error()})
 		return
 	}
@@ -395,6 +452,30 @@ func (s *Server) handleEditorHover(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleEditorCompletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	in, err := decodeEditorHoverRequest(r)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, editorCompletionResponse{OK: false, Error: err.Error()})
+		return
+	}
+	uri, target, err := s.editorDocumentURI(in.Provider)
+	if err != nil {
+		writeJSONAny(w, http.StatusBadRequest, editorCompletionResponse{OK: false, Error: err.Error()})
+		return
+	}
+	writeJSONAny(w, http.StatusOK, editorCompletionResponse{
+		OK:         true,
+		Provider:   in.Provider,
+		TargetFile: target,
+		URI:        uri,
+		Items:      dsllang.CollectCompletions(in.Content, in.Position),
+	})
+}
+
 func (s *Server) handleEditorFormat(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeMethodNotAllowed(w, http.MethodPost)
@@ -431,14 +512,28 @@ func (s *Server) handleTestRequest(w http.ResponseWriter, r *http.Request) {
 	}
 	ctx := r.Context()
 	client := &http.Client{Timeout: 60 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, in.BaseURL+in.Path, strings.NewReader(in.Payload))
+	target, payload := in.BaseURL+in.Path, in.Payload
+	if in.Explain {
+		envelope, err := json.Marshal(map[string]any{
+			"path":     in.Path,
+			"headers":  map[string]string{"Authorization": in.Authorization},
+			"provider": in.Provider,
+			"body":     json.RawMessage(in.Payload),
+		})
+		if err != nil {
+			writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: err.Error()})
+			return
+		}
+		target, payload = in.BaseURL+"/admin/explain", string(envelope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(payload))
 	if err != nil {
 		writeJSONAny(w, http.StatusBadRequest, testResponse{OK: false, Error: err.Error()})
 		return
 	}
 	req.Header.Set("Authorization", in.Authorization)
 	req.Header.Set("Content-Type", "application/json")
-	if in.Provider != "" {
+	if in.Provider != "" && !in.Explain {
 		req.Header.Set("x-onr-provider", in.Provider)
 	}
 
@@ -801,6 +896,12 @@ func resolveProviderSourcePath(cfgPath, override string) string {
 	return path
 }
 
+func resolveKeysPath(cfgPath string) string {
+	cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(cfgPath))
+	keysPath, _ := store.ResolveDataPaths(cfg, "", "")
+	return keysPath
+}
+
 func resolveDumpsDir(cfgPath string) string {
 	cfg, _ := store.LoadConfigIfExists(strings.TrimSpace(cfgPath))
 	if cfg != nil && strings.TrimSpace(cfg.TrafficDump.Dir) != "" {
@@ -913,30 +1014,109 @@ func readProviderContent(source providersource.Info, provider string) (string, [
 	return target.Path, b, nil
 }
 
-func writeProviderContent(source providersource.Info, provider string, content string) error {
+// auditActor returns the user forwarded by an authenticating reverse proxy
+// when the request comes from a trusted proxy, otherwise the client address.
+// The web UI has no login of its own, so untrusted headers are ignored.
+func (s *Server) auditActor(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if s.isTrustedProxy(host) {
+		for _, h := range []string{"X-Forwarded-User", "X-Remote-User", "X-Auth-Request-User"} {
+			if v := strings.TrimSpace(r.Header.Get(h)); v != "" {
+				return v
+			}
+		}
+	}
+	return "web@" + host
+}
+
+func (s *Server) isTrustedProxy(host string) bool {
+	if len(s.trustedProxies) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range s.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies accepts plain IPs and CIDRs.
+func parseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+func auditRejectedProviderSave(source providersource.Info, actor string, provider string, content string, validateErr error) {
+	name, err := normalizeProviderName(provider)
+	if err != nil {
+		return
+	}
+	path, data, err := providerFileContent(source, name, content)
+	if err != nil {
+		return
+	}
+	store.AuditRejected(actor, "provider.save", path, data, validateErr)
+}
+
+// providerFileContent returns the target file and its full content after saving the provider.
+func providerFileContent(source providersource.Info, provider string, content string) (string, []byte, error) {
 	target, err := providersource.ResolveProviderTarget(source, provider)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	if source.SourceIsFile && target.Path == source.SourcePath {
-		if err := os.MkdirAll(filepath.Dir(source.SourcePath), 0o750); err != nil {
-			return err
-		}
 		// #nosec G304 -- source path is configured by the user.
 		b, err := os.ReadFile(source.SourcePath)
 		if err != nil && !os.IsNotExist(err) {
-			return err
+			return "", nil, err
 		}
 		updated, err := dslconfig.UpsertProviderBlock(source.SourcePath, string(b), provider, content)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
-		return store.WriteAtomic(source.SourcePath, []byte(updated), false)
+		return source.SourcePath, []byte(updated), nil
 	}
-	if err := os.MkdirAll(filepath.Dir(target.Path), 0o750); err != nil {
+	return target.Path, []byte(content), nil
+}
+
+func writeProviderContent(source providersource.Info, actor string, provider string, content string) error {
+	path, data, err := providerFileContent(source, provider, content)
+	if err != nil {
 		return err
 	}
-	return store.WriteAtomic(target.Path, []byte(content), false)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return store.WriteAtomicAs(actor, path, data, false)
 }
 
 func mapSourcePathUnderTemp(tmpRoot string, configRoot string, path string) (string, error) {
@@ -1020,6 +1200,16 @@ func resolveDefaultAPIBaseURL() string {
 	return defaultAPIBaseURL
 }
 
+func resolveTrustedProxies(override []string) []string {
+	if len(override) > 0 {
+		return override
+	}
+	if v := strings.TrimSpace(os.Getenv(envTrustedProxies)); v != "" {
+		return strings.Split(v, ",")
+	}
+	return nil
+}
+
 func resolveListenAddress(override string) string {
 	if v := strings.TrimSpace(override); v != "" {
 		return v
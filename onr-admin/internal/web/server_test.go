@@ -11,7 +11,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/r9s-ai/open-next-router/onr-admin/internal/store"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+	"github.com/r9s-ai/open-next-router/pkg/audit"
 )
 
 const validOpenAIConf = `
@@ -118,6 +120,51 @@ func TestSaveProviderRequiresValidationSuccess(t *testing.T) {
 	}
 }
 
+func TestSaveProviderWritesAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "openai.conf")
+	if err := os.WriteFile(target, []byte(validOpenAIConf), 0o600); err != nil {
+		t.Fatalf("write seed provider conf: %v", err)
+	}
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("ONR_AUDIT_FILE", auditPath)
+	store.ConfigureAudit(nil, audit.SourceWeb)
+	t.Cleanup(func() { store.ConfigureAudit(nil, "") })
+
+	srv, err := NewServer(dir)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	invalid := strings.ReplaceAll(validOpenAIConfUpdated, `provider "openai"`, `provider "other"`)
+	if status, body := postJSON(t, httpSrv.URL+"/api/providers/save", providerRequest{Provider: "openai", Content: invalid}); status != http.StatusBadRequest {
+		t.Fatalf("save invalid status=%d body=%v", status, body)
+	}
+	if status, body := postJSON(t, httpSrv.URL+"/api/providers/save", providerRequest{Provider: "openai", Content: validOpenAIConfUpdated}); status != http.StatusOK {
+		t.Fatalf("save valid status=%d body=%v", status, body)
+	}
+
+	entries, err := audit.Read(auditPath, audit.Filter{})
+	if err != nil {
+		t.Fatalf("read audit: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %#v", entries)
+	}
+	if entries[0].Validation != audit.ValidationFailed || entries[0].Action != "provider.save" || entries[0].Error == "" {
+		t.Fatalf("unexpected rejected entry: %#v", entries[0])
+	}
+	ok := entries[1]
+	if ok.Source != audit.SourceWeb || ok.Target != target || ok.Validation != audit.ValidationOK || !strings.HasPrefix(ok.Actor, "web@") {
+		t.Fatalf("unexpected saved entry: %#v", ok)
+	}
+	if !strings.Contains(ok.Diff, `+    upstream_config { base_url = "https://api.openai.com/v2"; }`) {
+		t.Fatalf("unexpected diff:\n%s", ok.Diff)
+	}
+}
+
 func TestProviderEndpoints(t *testing.T) {
 	dir := t.TempDir()
 	if err := os.MkdirAll(dir, 0o750); err != nil {
@@ -259,6 +306,21 @@ func TestEditorLanguageEndpoints(t *testing.T) {
 		t.Fatalf("expected empty hover, got: %+v", emptyHoverBody)
 	}
 
+	status, completionBody := postEditorCompletionJSON(t, httpSrv.URL+"/api/editor/completion", editorHoverRequest{
+		Provider: "openai",
+		Content:  validOpenAIConf,
+		Position: dsllang.Position{
+			Line:      10,
+			Character: 6,
+		},
+	})
+	if status != http.StatusOK || !completionBody.OK {
+		t.Fatalf("completion status=%d body=%+v", status, completionBody)
+	}
+	if len(completionBody.Items) != 1 || completionBody.Items[0].Label != "upstream" {
+		t.Fatalf("unexpected completion items: %+v", completionBody.Items)
+	}
+
 	status, formatBody := postEditorFormatJSON(t, httpSrv.URL+"/api/editor/format", editorRequest{
 		Provider: "openai",
 		Content:  `provider "openai" { defaults { auth { auth_bearer; } } }`,
@@ -834,6 +896,55 @@ func TestTestRequestEndpoint(t *testing.T) {
 	}
 }
 
+func TestTestRequestEndpoint_Explain(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/explain" {
+			http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		var in struct {
+			Path     string            `json:"path"`
+			Headers  map[string]string `json:"headers"`
+			Provider string            `json:"provider"`
+			Body     map[string]any    `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if in.Path != "/v1/chat/completions" || in.Provider != "openai" || in.Body["model"] != "gpt-4o-mini" ||
+			in.Headers["Authorization"] != r.Header.Get("Authorization") {
+			http.Error(w, "unexpected explain request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"provider":"openai","upstream":{"url":"https://api.openai.com/v1/chat/completions"}}`))
+	}))
+	defer upstream.Close()
+
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	status, body := postTestJSON(t, httpSrv.URL+"/api/test/request", testRequest{
+		BaseURL:       upstream.URL,
+		Path:          "/v1/chat/completions",
+		Authorization: "Bearer onr:v1?k=change-me&p=openai&m=gpt-4o-mini",
+		Provider:      "openai",
+		Payload:       `{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hello"}]}`,
+		Explain:       true,
+	})
+	if status != http.StatusOK || !body.OK || body.Status != http.StatusOK {
+		t.Fatalf("status=%d body=%+v", status, body)
+	}
+	if !strings.Contains(body.Body, `"url":"https://api.openai.com/v1/chat/completions"`) {
+		t.Fatalf("unexpected response body: %+v", body)
+	}
+}
+
 func TestTestRequestEndpoint_InvalidPayload(t *testing.T) {
 	srv, err := NewServer(t.TempDir())
 	if err != nil {
@@ -947,6 +1058,24 @@ func postEditorHoverJSON(t *testing.T, url string, body any) (int, editorHoverRe
 	return resp.StatusCode, out
 }
 
+func postEditorCompletionJSON(t *testing.T, url string, body any) (int, editorCompletionResponse) {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal body: %v", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("post %s: %v", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out editorCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
 func postEditorFormatJSON(t *testing.T, url string, body any) (int, editorFormatResponse) {
 	t.Helper()
 	raw, err := json.Marshal(body)
@@ -964,3 +1093,31 @@ func postEditorFormatJSON(t *testing.T, url string, body any) (int, editorFormat
 	}
 	return resp.StatusCode, out
 }
+
+func TestAuditActorIgnoresSpoofedForwardedUser(t *testing.T) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/providers/save", nil)
+	req.RemoteAddr = "203.0.113.7:51000"
+	req.Header.Set("X-Forwarded-User", "admin")
+	if got := srv.auditActor(req); got != "web@203.0.113.7" {
+		t.Fatalf("untrusted actor=%q", got)
+	}
+
+	srv.trustedProxies, err = parseTrustedProxies([]string{"10.0.0.0/8", "203.0.113.7"})
+	if err != nil {
+		t.Fatalf("parseTrustedProxies: %v", err)
+	}
+	if got := srv.auditActor(req); got != "admin" {
+		t.Fatalf("trusted proxy actor=%q", got)
+	}
+	req.RemoteAddr = "198.51.100.1:51000"
+	if got := srv.auditActor(req); got != "web@198.51.100.1" {
+		t.Fatalf("non-proxy actor=%q", got)
+	}
+	if _, err := parseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("expected invalid trusted proxy error")
+	}
+}
@@ -0,0 +1,154 @@
+package apitypes
+
+import "strings"
+
+// TextVisitor receives one user-visible text value found in a request or response object.
+// Calling set replaces the value in place; visitors that only inspect text ignore it.
+type TextVisitor func(text string, set func(string))
+
+// WalkRequestText visits the prompt text of a client request object for api
+// (chat.completions, completions, responses, claude.messages, embeddings and
+// gemini.*). Non-text content such as images, audio and tool schemas is skipped.
+func WalkRequestText(api string, root map[string]any, visit TextVisitor) {
+	if root == nil || visit == nil {
+		return
+	}
+	switch normalizeTextAPI(api) {
+	case "chat.completions":
+		walkMessagesText(root, "messages", visit)
+	case "completions":
+		walkStringOrList(root, "prompt", visit)
+	case "embeddings":
+		walkStringOrList(root, "input", visit)
+	case "responses":
+		walkStringField(root, "instructions", visit)
+		if items, ok := root["input"].([]any); ok {
+			for _, item := range items {
+				if m, ok := item.(map[string]any); ok {
+					walkContentText(m, "content", visit)
+				}
+			}
+			return
+		}
+		walkStringField(root, "input", visit)
+	case "claude.messages":
+		walkContentText(root, "system", visit)
+		walkMessagesText(root, "messages", visit)
+	case "gemini":
+		if si, ok := root["systemInstruction"].(map[string]any); ok {
+			walkGeminiParts(si, visit)
+		}
+		if contents, ok := root["contents"].([]any); ok {
+			for _, c := range contents {
+				if m, ok := c.(map[string]any); ok {
+					walkGeminiParts(m, visit)
+				}
+			}
+		}
+	}
+}
+
+// WalkResponseText visits the generated text of a non-stream response object for api.
+func WalkResponseText(api string, root map[string]any, visit TextVisitor) {
+	if root == nil || visit == nil {
+		return
+	}
+	switch normalizeTextAPI(api) {
+	case "chat.completions", "completions":
+		choices, _ := root["choices"].([]any)
+		for _, c := range choices {
+			choice, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			walkStringField(choice, "text", visit)
+			if msg, ok := choice["message"].(map[string]any); ok {
+				walkContentText(msg, "content", visit)
+				walkStringField(msg, "reasoning_content", visit)
+			}
+		}
+	case "responses":
+		output, _ := root["output"].([]any)
+		for _, o := range output {
+			if m, ok := o.(map[string]any); ok {
+				walkContentText(m, "content", visit)
+			}
+		}
+	case "claude.messages":
+		walkContentText(root, "content", visit)
+	case "gemini":
+		candidates, _ := root["candidates"].([]any)
+		for _, c := range candidates {
+			cand, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			if content, ok := cand["content"].(map[string]any); ok {
+				walkGeminiParts(content, visit)
+			}
+		}
+	}
+}
+
+func normalizeTextAPI(api string) string {
+	s := strings.ToLower(strings.TrimSpace(api))
+	if strings.HasPrefix(s, "gemini.") {
+		return "gemini"
+	}
+	return s
+}
+
+func walkMessagesText(root map[string]any, key string, visit TextVisitor) {
+	messages, _ := root[key].([]any)
+	for _, item := range messages {
+		if m, ok := item.(map[string]any); ok {
+			walkContentText(m, "content", visit)
+		}
+	}
+}
+
+// walkContentText handles the common "content" shapes: a plain string or a list of
+// typed parts where text lives under "text" (text, input_text, output_text).
+func walkContentText(obj map[string]any, key string, visit TextVisitor) {
+	switch v := obj[key].(type) {
+	case string:
+		visit(v, func(s string) { obj[key] = s })
+	case []any:
+		for _, part := range v {
+			p, ok := part.(map[string]any)
+			if !ok {
+				continue
+			}
+			walkStringField(p, "text", visit)
+		}
+	}
+}
+
+func walkStringOrList(obj map[string]any, key string, visit TextVisitor) {
+	switch v := obj[key].(type) {
+	case string:
+		visit(v, func(s string) { obj[key] = s })
+	case []any:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				idx := i
+				visit(s, func(ns string) { v[idx] = ns })
+			}
+		}
+	}
+}
+
+func walkStringField(obj map[string]any, key string, visit TextVisitor) {
+	if s, ok := obj[key].(string); ok {
+		visit(s, func(ns string) { obj[key] = ns })
+	}
+}
+
+func walkGeminiParts(obj map[string]any, visit TextVisitor) {
+	parts, _ := obj["parts"].([]any)
+	for _, part := range parts {
+		if p, ok := part.(map[string]any); ok {
+			walkStringField(p, "text", visit)
+		}
+	}
+}
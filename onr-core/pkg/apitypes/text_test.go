@@ -0,0 +1,55 @@
+package apitypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkRequestText_Responses(t *testing.T) {
+	root := map[string]any{
+		"instructions": "be brief",
+		"input": []any{
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "input_text", "text": "hello"},
+				map[string]any{"type": "input_image", "image_url": "https://x"},
+			}},
+			map[string]any{"role": "assistant", "content": "earlier answer"},
+		},
+	}
+	var got []string
+	WalkRequestText("responses", root, func(text string, set func(string)) {
+		got = append(got, text)
+		set("<" + text + ">")
+	})
+	if want := []string{"be brief", "hello", "earlier answer"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("visited=%v want %v", got, want)
+	}
+	if root["instructions"] != "<be brief>" {
+		t.Fatalf("set did not update instructions: %v", root["instructions"])
+	}
+	part := root["input"].([]any)[0].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if part["text"] != "<hello>" {
+		t.Fatalf("set did not update part: %v", part["text"])
+	}
+}
+
+func TestWalkRequestText_EmbeddingsList(t *testing.T) {
+	root := map[string]any{"input": []any{"a", 1.0, "b"}}
+	WalkRequestText("embeddings", root, func(text string, set func(string)) { set(text + "!") })
+	if want := []any{"a!", 1.0, "b!"}; !reflect.DeepEqual(root["input"], want) {
+		t.Fatalf("input=%v", root["input"])
+	}
+}
+
+func TestWalkResponseText_ResponsesOutput(t *testing.T) {
+	root := map[string]any{"output": []any{
+		map[string]any{"type": "message", "content": []any{
+			map[string]any{"type": "output_text", "text": "done"},
+		}},
+	}}
+	var got []string
+	WalkResponseText("responses", root, func(text string, _ func(string)) { got = append(got, text) })
+	if len(got) != 1 || got[0] != "done" {
+		t.Fatalf("visited=%v", got)
+	}
+}
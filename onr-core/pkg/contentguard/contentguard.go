@@ -0,0 +1,228 @@
+// Package contentguard executes DSL guard rules (guard_builtin, guard_regex,
+// guard_keywords) against the message text of requests and responses. Text is
+// located with the apitypes walkers for non-stream bodies and with
+// streamtext.ExtractDeltaText for SSE streams. It is HTTP-framework-free.
+package contentguard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/apitypes"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+)
+
+const (
+	ScopeRequest  = dslconfig.GuardScopeRequest
+	ScopeResponse = dslconfig.GuardScopeResponse
+)
+
+// Hit counts the matches of one rule in one scope.
+type Hit struct {
+	Rule   string
+	Action string
+	Scope  string
+	Count  int
+}
+
+// RejectError reports a request blocked by an action=reject rule.
+// Message never contains the matched text, so it is safe for logs and client responses.
+type RejectError struct {
+	Rule string
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("request blocked by guard rule %q", e.Rule)
+}
+
+// ApplyRequest scans the client request root for api. Redact rules rewrite root in place
+// and report changed=true. When a reject rule matches, the hits and a *RejectError are
+// returned and root must not be forwarded.
+func ApplyRequest(cfg *dslconfig.GuardConfig, api string, root map[string]any) ([]Hit, bool, error) {
+	if cfg == nil || root == nil {
+		return nil, false, nil
+	}
+	rules := rulesFor(cfg, ScopeRequest)
+	if len(rules) == 0 {
+		return nil, false, nil
+	}
+	acc := newHitSet(ScopeRequest)
+	var rejected string
+	apitypes.WalkRequestText(api, root, func(text string, _ func(string)) {
+		for _, r := range rules {
+			if n := countMatches(r, text); n > 0 {
+				acc.add(r, n)
+				if r.Action == dslconfig.GuardActionReject && rejected == "" {
+					rejected = r.Name
+				}
+			}
+		}
+	})
+	if rejected != "" {
+		return acc.list(), false, &RejectError{Rule: rejected}
+	}
+	changed := false
+	if acc.hasAction(dslconfig.GuardActionRedact) {
+		apitypes.WalkRequestText(api, root, func(text string, set func(string)) {
+			if out, ok := redactText(rules, text); ok {
+				set(out)
+				changed = true
+			}
+		})
+	}
+	return acc.list(), changed, nil
+}
+
+// ApplyResponse scans a non-stream response root for api. Redact rules rewrite root in place.
+func ApplyResponse(cfg *dslconfig.GuardConfig, api string, root map[string]any) ([]Hit, bool) {
+	if cfg == nil || root == nil {
+		return nil, false
+	}
+	rules := rulesFor(cfg, ScopeResponse)
+	if len(rules) == 0 {
+		return nil, false
+	}
+	acc := newHitSet(ScopeResponse)
+	changed := false
+	apitypes.WalkResponseText(api, root, func(text string, set func(string)) {
+		for _, r := range rules {
+			if n := countMatches(r, text); n > 0 {
+				acc.add(r, n)
+			}
+		}
+		if out, ok := redactText(rules, text); ok {
+			set(out)
+			changed = true
+		}
+	})
+	return acc.list(), changed
+}
+
+// HasResponseRules reports whether cfg has any rule that inspects responses.
+func HasResponseRules(cfg *dslconfig.GuardConfig) bool {
+	return len(rulesFor(cfg, ScopeResponse)) > 0
+}
+
+// FormatHits renders hits for the access log, e.g. "request:email=redact*2,response:api_key=log".
+func FormatHits(hits []Hit) string {
+	parts := make([]string, 0, len(hits))
+	for _, h := range hits {
+		s := h.Scope + ":" + h.Rule + "=" + h.Action
+		if h.Count > 1 {
+			s += fmt.Sprintf("*%d", h.Count)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ",")
+}
+
+func rulesFor(cfg *dslconfig.GuardConfig, scope string) []dslconfig.GuardRule {
+	if cfg == nil {
+		return nil
+	}
+	var out []dslconfig.GuardRule
+	for _, r := range cfg.Rules {
+		if r.Regexp != nil && r.AppliesTo(scope) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func countMatches(r dslconfig.GuardRule, text string) int {
+	if text == "" {
+		return 0
+	}
+	locs := r.Regexp.FindAllStringIndex(text, -1)
+	if !r.Luhn {
+		return len(locs)
+	}
+	n := 0
+	for _, loc := range locs {
+		if luhnValid(text[loc[0]:loc[1]]) {
+			n++
+		}
+	}
+	return n
+}
+
+// redactText replaces matches of action=redact rules with "[REDACTED:<rule>]".
+func redactText(rules []dslconfig.GuardRule, text string) (string, bool) {
+	out := text
+	for _, r := range rules {
+		if r.Action != dslconfig.GuardActionRedact {
+			continue
+		}
+		repl := "[REDACTED:" + r.Name + "]"
+		out = r.Regexp.ReplaceAllStringFunc(out, func(m string) string {
+			if r.Luhn && !luhnValid(m) {
+				return m
+			}
+			return repl
+		})
+	}
+	return out, out != text
+}
+
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+type hitSet struct {
+	scope string
+	order []string
+	hits  map[string]*Hit
+}
+
+func newHitSet(scope string) *hitSet {
+	return &hitSet{scope: scope, hits: map[string]*Hit{}}
+}
+
+func (s *hitSet) add(r dslconfig.GuardRule, n int) {
+	h, ok := s.hits[r.Name]
+	if !ok {
+		h = &Hit{Rule: r.Name, Action: r.Action, Scope: s.scope}
+		s.hits[r.Name] = h
+		s.order = append(s.order, r.Name)
+	}
+	h.Count += n
+}
+
+func (s *hitSet) hasAction(action string) bool {
+	for _, h := range s.hits {
+		if h.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *hitSet) list() []Hit {
+	if len(s.order) == 0 {
+		return nil
+	}
+	out := make([]Hit, 0, len(s.order))
+	for _, name := range s.order {
+		out = append(out, *s.hits[name])
+	}
+	return out
+}
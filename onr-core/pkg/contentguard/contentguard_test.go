@@ -0,0 +1,143 @@
+package contentguard
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+)
+
+func testGuardConfig() *dslconfig.GuardConfig {
+	return &dslconfig.GuardConfig{Rules: []dslconfig.GuardRule{
+		{Name: "email", Action: dslconfig.GuardActionRedact, Scope: dslconfig.GuardScopeBoth,
+			Regexp: regexp.MustCompile(`[a-z]+@example\.com`)},
+		{Name: "card_number", Action: dslconfig.GuardActionReject, Scope: dslconfig.GuardScopeRequest,
+			Regexp: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), Luhn: true},
+		{Name: "codename", Action: dslconfig.GuardActionLog, Scope: dslconfig.GuardScopeResponse,
+			Regexp: regexp.MustCompile(`(?i)falcon`)},
+	}}
+}
+
+func TestApplyRequest_RedactsChatMessages(t *testing.T) {
+	root := map[string]any{
+		"messages": []any{
+			map[string]any{"role": "system", "content": "reply to ops@example.com"},
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "cc bob@example.com and bob@example.com"},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "x@example.com"}},
+			}},
+		},
+	}
+	hits, changed, err := ApplyRequest(testGuardConfig(), "chat.completions", root)
+	if err != nil || !changed {
+		t.Fatalf("changed=%v err=%v", changed, err)
+	}
+	if got := FormatHits(hits); got != "request:email=redact*3" {
+		t.Fatalf("hits=%q", got)
+	}
+	msgs := root["messages"].([]any)
+	if got := msgs[0].(map[string]any)["content"]; got != "reply to [REDACTED:email]" {
+		t.Fatalf("system content=%v", got)
+	}
+	part := msgs[1].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if strings.Contains(part["text"].(string), "@example.com") {
+		t.Fatalf("text part not redacted: %v", part["text"])
+	}
+	img := msgs[1].(map[string]any)["content"].([]any)[1].(map[string]any)["image_url"].(map[string]any)
+	if img["url"] != "x@example.com" {
+		t.Fatalf("non-text parts must be left alone: %v", img["url"])
+	}
+}
+
+func TestApplyRequest_RejectUsesLuhn(t *testing.T) {
+	cfg := testGuardConfig()
+	root := map[string]any{"system": "order 4111 1111 1111 1112", "messages": []any{}}
+	if _, _, err := ApplyRequest(cfg, "claude.messages", root); err != nil {
+		t.Fatalf("invalid Luhn number must not reject: %v", err)
+	}
+	root = map[string]any{"system": "card 4111 1111 1111 1111", "messages": []any{}}
+	hits, _, err := ApplyRequest(cfg, "claude.messages", root)
+	var rerr *RejectError
+	if !errors.As(err, &rerr) || rerr.Rule != "card_number" {
+		t.Fatalf("expected card_number rejection, got %v", err)
+	}
+	if strings.Contains(err.Error(), "4111") {
+		t.Fatalf("error must not leak matched text: %v", err)
+	}
+	if got := FormatHits(hits); got != "request:card_number=reject" {
+		t.Fatalf("hits=%q", got)
+	}
+}
+
+func TestApplyResponse_GeminiCandidates(t *testing.T) {
+	root := map[string]any{"candidates": []any{
+		map[string]any{"content": map[string]any{"parts": []any{
+			map[string]any{"text": "Falcon owner is amy@example.com"},
+		}}},
+	}}
+	hits, changed := ApplyResponse(testGuardConfig(), "gemini.generateContent", root)
+	if !changed {
+		t.Fatalf("expected redaction")
+	}
+	if got := FormatHits(hits); got != "response:email=redact,response:codename=log" {
+		t.Fatalf("hits=%q", got)
+	}
+	text := root["candidates"].([]any)[0].(map[string]any)["content"].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"]
+	if text != "Falcon owner is [REDACTED:email]" {
+		t.Fatalf("text=%v", text)
+	}
+}
+
+func TestStreamScanner_JoinsDeltas(t *testing.T) {
+	s := NewStreamScanner(testGuardConfig(), "chat.completions")
+	if s == nil {
+		t.Fatalf("expected scanner")
+	}
+	chunks := []string{
+		"data: {\"choices\":[{\"delta\":{\"content\":\"write to tom@\"}}]}\n",
+		"\ndata: {\"choices\":[{\"delta\":{\"content\":\"example.com re Fal\"}}]}\n\n",
+		"data: {\"choices\":[{\"delta\":{\"content\":\"con\"}}]}\n\ndata: [DONE]\n\n",
+	}
+	for _, c := range chunks {
+		_, _ = s.Write([]byte(c))
+	}
+	if got := FormatHits(s.Hits()); got != "response:email=log,response:codename=log" {
+		t.Fatalf("hits=%q", got)
+	}
+	if NewStreamScanner(&dslconfig.GuardConfig{Rules: testGuardConfig().Rules[1:2]}, "chat.completions") != nil {
+		t.Fatalf("request-only rules must not create a stream scanner")
+	}
+}
+
+func TestStreamScanner_CRLFEvents(t *testing.T) {
+	s := NewStreamScanner(testGuardConfig(), "chat.completions")
+	s.limit = 64
+	chunks := []string{
+		"data: {\"choices\":[{\"delta\":{\"content\":\"write to tom@\"}}]}\r\n\r",
+		"\ndata: {\"choices\":[{\"delta\":{\"content\":\"example.com re Falcon\"}}]}\r\n\r\n",
+	}
+	for i := 0; i < 50; i++ {
+		chunks = append(chunks, "data: {\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\r\n\r\n")
+	}
+	for _, c := range chunks {
+		_, _ = s.Write([]byte(c))
+	}
+	if len(s.pending) != 0 {
+		t.Fatalf("CRLF events were not split, pending=%d bytes", len(s.pending))
+	}
+	if got := FormatHits(s.Hits()); got != "response:email=log,response:codename=log" {
+		t.Fatalf("hits=%q", got)
+	}
+}
+
+func TestStreamScanner_TrimsAtRuneBoundary(t *testing.T) {
+	s := NewStreamScanner(testGuardConfig(), "chat.completions")
+	s.limit = 4
+	_, _ = s.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"a\u4f60\u597d\"}}]}\n\n"))
+	if got := s.text.String(); got != "a\u4f60" || !utf8.ValidString(got) {
+		t.Fatalf("text=%q", got)
+	}
+}
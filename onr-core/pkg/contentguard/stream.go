@@ -0,0 +1,118 @@
+package contentguard
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/streamtext"
+)
+
+// defaultStreamTextLimit bounds the delta text kept for scanning one stream.
+const defaultStreamTextLimit = 1 << 20
+
+// StreamScanner collects delta text from SSE bytes written to it and scans it once
+// the stream ends. Streams are only inspected: bytes already sent downstream cannot be
+// redacted, so every stream hit is reported as action=log.
+type StreamScanner struct {
+	api   string
+	rules []dslconfig.GuardRule
+	limit int
+
+	pending []byte
+	text    strings.Builder
+}
+
+// NewStreamScanner returns nil when cfg has no response rules.
+func NewStreamScanner(cfg *dslconfig.GuardConfig, api string) *StreamScanner {
+	rules := rulesFor(cfg, ScopeResponse)
+	if len(rules) == 0 {
+		return nil
+	}
+	return &StreamScanner{api: api, rules: rules, limit: defaultStreamTextLimit}
+}
+
+// Write consumes raw SSE bytes; it never fails so it can sit behind io.MultiWriter.
+func (s *StreamScanner) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+	for {
+		end, next := eventBoundary(s.pending)
+		if end < 0 {
+			break
+		}
+		s.consumeEvent(s.pending[:end])
+		s.pending = s.pending[next:]
+	}
+	if len(s.pending) > s.limit {
+		// A single event this large is not chat text; drop it instead of buffering.
+		s.pending = nil
+	}
+	return len(p), nil
+}
+
+// eventBoundary finds the blank line ending the first event, accepting both LF and
+// CRLF line endings. It returns the event end and the start of the next event, or -1.
+func eventBoundary(b []byte) (int, int) {
+	for i := 0; i < len(b); {
+		j := bytes.IndexByte(b[i:], '\n')
+		if j < 0 {
+			return -1, -1
+		}
+		i += j + 1
+		switch {
+		case i < len(b) && b[i] == '\n':
+			return i - 1, i + 1
+		case i+1 < len(b) && b[i] == '\r' && b[i+1] == '\n':
+			return i - 1, i + 2
+		}
+	}
+	return -1, -1
+}
+
+func (s *StreamScanner) consumeEvent(ev []byte) {
+	if s.text.Len() >= s.limit {
+		return
+	}
+	var data [][]byte
+	for _, raw := range bytes.Split(ev, []byte("\n")) {
+		line := bytes.TrimRight(raw, "\r")
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = append(data, bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:"))))
+		}
+	}
+	if len(data) == 0 {
+		return
+	}
+	delta := streamtext.ExtractDeltaText(s.api, bytes.Join(data, []byte("\n")))
+	if delta == "" {
+		return
+	}
+	if room := s.limit - s.text.Len(); len(delta) > room {
+		for room > 0 && !utf8.RuneStart(delta[room]) {
+			room--
+		}
+		delta = delta[:room]
+	}
+	s.text.WriteString(delta)
+}
+
+// Hits flushes any trailing event and scans the collected text.
+func (s *StreamScanner) Hits() []Hit {
+	if len(bytes.TrimSpace(s.pending)) > 0 {
+		s.consumeEvent(s.pending)
+		s.pending = nil
+	}
+	text := s.text.String()
+	acc := newHitSet(ScopeResponse)
+	for _, r := range s.rules {
+		if n := countMatches(r, text); n > 0 {
+			acc.add(r, n)
+		}
+	}
+	hits := acc.list()
+	for i := range hits {
+		hits[i].Action = dslconfig.GuardActionLog
+	}
+	return hits
+}
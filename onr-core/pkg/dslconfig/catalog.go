@@ -0,0 +1,36 @@
+package dslconfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// SupportedMatchAPIs returns the api names accepted by `match api = "..."`, sorted.
+func SupportedMatchAPIs() []string {
+	out := make([]string, 0, len(supportedMatchAPIs))
+	for api := range supportedMatchAPIs {
+		out = append(out, api)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// BuiltinExpressionVariables returns the fixed built-in expression variables
+// such as $request.model, sorted.
+func BuiltinExpressionVariables() []string {
+	out := append([]string(nil), builtinExprVariables...)
+	sort.Strings(out)
+	return out
+}
+
+// PrefixedExpressionVariables returns the variable prefixes that take a
+// caller-chosen suffix, such as $request.header.
+func PrefixedExpressionVariables() []string {
+	return append([]string(nil), prefixedExprVariables...)
+}
+
+// ResolveIncludeTargets expands one include path the same way the loader does:
+// relative to the directory of basePath, with directory and glob expansion.
+func ResolveIncludeTargets(basePath, includePath string) ([]string, error) {
+	return expandIncludeTargets(basePath, strings.TrimSpace(unquoteString(strings.TrimSpace(includePath))))
+}
@@ -0,0 +1,52 @@
+package dslconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCatalogMatchesValidation(t *testing.T) {
+	apis := SupportedMatchAPIs()
+	if len(apis) != len(supportedMatchAPIs) || apis[0] != "audio.speech" {
+		t.Fatalf("SupportedMatchAPIs=%v", apis)
+	}
+	for _, v := range BuiltinExpressionVariables() {
+		if !isBuiltinStringVariable(v) {
+			t.Fatalf("%s is not accepted as a built-in variable", v)
+		}
+	}
+	for _, prefix := range PrefixedExpressionVariables() {
+		if !isPrefixedStringVariable(prefix + "x_y") {
+			t.Fatalf("%s is not accepted as a variable prefix", prefix)
+		}
+	}
+}
+
+func TestResolveIncludeTargets(t *testing.T) {
+	dir := t.TempDir()
+	modes := filepath.Join(dir, "modes")
+	if err := os.MkdirAll(modes, 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"b.conf", "a.conf", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(modes, name), []byte("\n"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	base := filepath.Join(dir, "onr.conf")
+	want := []string{filepath.Join(modes, "a.conf"), filepath.Join(modes, "b.conf")}
+	for _, include := range []string{"modes", `"modes/*.conf"`} {
+		got, err := ResolveIncludeTargets(base, include)
+		if err != nil {
+			t.Fatalf("ResolveIncludeTargets(%s): %v", include, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ResolveIncludeTargets(%s)=%v want %v", include, got, want)
+		}
+	}
+	if _, err := ResolveIncludeTargets(base, "missing.conf"); err == nil {
+		t.Fatalf("expected error for a missing include target")
+	}
+}
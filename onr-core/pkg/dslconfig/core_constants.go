@@ -10,6 +10,17 @@ const (
 	exprRequestMapped    = "$request.model_mapped"
 	exprTaskID           = "$task.id"
 	exprTaskUpstreamID   = "$task.upstream_id"
+	exprRequestAccessKey = "$request.access_key"
+
+	// Prefixed variables carry a caller-chosen suffix: a header name, a body
+	// JSONPath below the request root, or an environment variable name.
+	exprRequestHeaderPrefix = "$request.header."
+	exprRequestBodyPrefix   = "$request.body."
+	exprEnvPrefix           = "$env."
+
+	// DSLEnvPrefix is the only environment name prefix $env.<NAME> may read, so provider
+	// files cannot reach the master key or other process secrets.
+	DSLEnvPrefix = "ONR_DSL_ENV_"
 
 	jsonOpSet           = "json_set"
 	jsonOpReplace       = "json_replace"
@@ -25,3 +36,24 @@ const (
 	jsonOpMapValue      = "json_map_value"
 	jsonOpClamp         = "json_clamp"
 )
+
+// builtinExprVariables lists the fixed built-in expression variables.
+var builtinExprVariables = []string{
+	exprChannelBaseURL,
+	exprChannelKey,
+	exprChannelLocation,
+	exprCredentialProjID,
+	exprOAuthAccessToken,
+	exprRequestModel,
+	exprRequestMapped,
+	exprTaskID,
+	exprTaskUpstreamID,
+	exprRequestAccessKey,
+}
+
+// prefixedExprVariables lists the variable prefixes that take a suffix.
+var prefixedExprVariables = []string{
+	exprRequestHeaderPrefix,
+	exprRequestBodyPrefix,
+	exprEnvPrefix + DSLEnvPrefix,
+}
@@ -12,9 +12,15 @@ const (
 	maxIncludeDepth = 20
 )
 
+// preprocessIncludes expands include directives and then flattens
+// `provider "x" extends "y"` blocks, so callers always parse self-contained providers.
 func preprocessIncludes(rootPath string, content string) (string, error) {
 	visited := map[string]bool{}
-	return preprocessIncludesInner(rootPath, content, visited, 0)
+	expanded, err := preprocessIncludesInner(rootPath, content, visited, 0)
+	if err != nil {
+		return "", err
+	}
+	return resolveProviderExtends(rootPath, expanded, map[string]bool{})
 }
 
 func preprocessIncludesInner(path string, content string, visited map[string]bool, depth int) (string, error) {
@@ -63,6 +69,12 @@ func preprocessIncludesInner(path string, content string, visited map[string]boo
 				if err != nil {
 					return "", err
 				}
+				// Resolve extends relative to the included file so sibling and base/
+				// parents are found next to it rather than next to the root file.
+				expanded, err = resolveProviderExtends(full, expanded, map[string]bool{})
+				if err != nil {
+					return "", err
+				}
 				out.WriteString(expanded)
 				out.WriteString("\n")
 			}
@@ -24,6 +24,8 @@ type ProviderFile struct {
 	Finish   ProviderFinishReason
 	Balance  ProviderBalance
 	Models   ProviderModels
+	Guard    ProviderGuard
+	Vars     ProviderVars
 }
 
 type Registry struct {
@@ -257,7 +259,7 @@ func filterRegistryDirCandidates(candidates []registryDirCandidate, skippedFile
 
 func loadProvidersFromRegistryDirCandidates(next map[string]ProviderFile, loaded, skipped *[]string, skippedReasons map[string]string, candidates []registryDirCandidate, resolvedState modeRegistryState) {
 	for _, candidate := range candidates {
-		pf, hasProvider, err := validateAndBuildProviderFile(candidate.path, candidate.content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance)
+		pf, hasProvider, err := validateAndBuildProviderFile(candidate.path, candidate.content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance, resolvedState.maps)
 		if err != nil {
 			*skipped = append(*skipped, candidate.entryName)
 			skippedReasons[candidate.entryName] = err.Error()
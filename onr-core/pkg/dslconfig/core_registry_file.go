@@ -141,10 +141,27 @@ func parseProvidersFromMergedFile(path string, content string, inherited modeReg
 			if err != nil {
 				return nil, nil, err
 			}
+			guard, err := parseProviderGuardFromContent(path, content, providerName)
+			if err != nil {
+				return nil, nil, err
+			}
+			localMaps, err := parseProviderVarMapsFromContent(path, content, providerName)
+			if err != nil {
+				return nil, nil, err
+			}
+			vars, err := buildProviderVars(path, providerName, content, resolved.maps, localMaps)
+			if err != nil {
+				return nil, nil, err
+			}
 			pf, err := buildMergedProviderFile(path, providerName, metadata, routing, headers, req, response, perr, usage, finish, balance, models, resolved)
 			if err != nil {
 				return nil, nil, err
 			}
+			pf.Guard = guard
+			pf.Vars = vars
+			if err != nil {
+				return nil, nil, err
+			}
 			next[providerName] = pf
 			loaded = append(loaded, providerName)
 		default:
@@ -9,16 +9,35 @@ type Usage struct {
 	CompletionTokens int `json:"completion_tokens,omitempty"`
 	TotalTokens      int `json:"total_tokens,omitempty"`
 
-	InputTokenDetails *ResponseTokenDetails `json:"input_tokens_details,omitempty"`
+	InputTokenDetails  *ResponseTokenDetails `json:"input_tokens_details,omitempty"`
+	OutputTokenDetails *OutputTokenDetails   `json:"output_tokens_details,omitempty"`
 
 	FlatFields map[string]any `json:"-"`
 	DebugFacts []UsageFact    `json:"-"`
 	UsageRoot  map[string]any `json:"-"`
 }
 
+// ResponseTokenDetails breaks input tokens down by cache state and modality.
+// The modality counts are subsets of InputTokens.
 type ResponseTokenDetails struct {
 	CachedTokens     int `json:"cached_tokens,omitempty"`
 	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+
+	TextTokens  int `json:"text_tokens,omitempty"`
+	AudioTokens int `json:"audio_tokens,omitempty"`
+	ImageTokens int `json:"image_tokens,omitempty"`
+	VideoTokens int `json:"video_tokens,omitempty"`
+}
+
+// OutputTokenDetails breaks output tokens down into reasoning and modality
+// counts. All counts are subsets of OutputTokens.
+type OutputTokenDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	TextTokens  int `json:"text_tokens,omitempty"`
+	AudioTokens int `json:"audio_tokens,omitempty"`
+	ImageTokens int `json:"image_tokens,omitempty"`
+	VideoTokens int `json:"video_tokens,omitempty"`
 }
 
 // UsageFact is the canonical usage item produced after extraction.
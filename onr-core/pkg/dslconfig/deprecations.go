@@ -29,6 +29,12 @@ func (w ValidationWarning) String() string {
 
 var deprecatedDirectiveAliasMap = map[string]string{}
 
+// DeprecatedDirectiveWarnings returns warnings for deprecated directive aliases
+// used in one DSL document. Lines and columns are 1-based.
+func DeprecatedDirectiveWarnings(path, content string) []ValidationWarning {
+	return collectDeprecatedDirectiveWarnings(path, content)
+}
+
 func collectDeprecatedDirectiveWarnings(path, content string) []ValidationWarning {
 	s := newScanner(path, content)
 	out := make([]ValidationWarning, 0, 4)
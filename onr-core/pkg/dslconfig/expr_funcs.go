@@ -0,0 +1,253 @@
+package dslconfig
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
+)
+
+// exprFunc is a pure string function usable in DSL expressions. Arguments are
+// evaluated before the call; every function returns a string.
+type exprFunc struct {
+	minArgs int
+	maxArgs int // -1 means variadic
+	// validateArgs adds argument checks beyond arity, such as literal-only options.
+	validateArgs func(args []string) error
+	eval         func(args []string) string
+}
+
+const (
+	nowFormatRFC3339 = "rfc3339"
+	nowFormatUnix    = "unix"
+	nowFormatUnixMS  = "unix_ms"
+)
+
+var exprFuncs = map[string]exprFunc{
+	"coalesce": {minArgs: 1, maxArgs: -1, eval: func(args []string) string {
+		for _, a := range args {
+			if strings.TrimSpace(a) != "" {
+				return a
+			}
+		}
+		return ""
+	}},
+	"if": {minArgs: 3, maxArgs: 3, eval: func(args []string) string {
+		if exprTruthy(args[0]) {
+			return args[1]
+		}
+		return args[2]
+	}},
+	"eq": {minArgs: 2, maxArgs: 2, eval: func(args []string) string {
+		return strconv.FormatBool(args[0] == args[1])
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, eval: func(args []string) string {
+		return strings.ToLower(args[0])
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, eval: func(args []string) string {
+		return strings.ToUpper(args[0])
+	}},
+	"base64": {minArgs: 1, maxArgs: 1, eval: func(args []string) string {
+		return base64.StdEncoding.EncodeToString([]byte(args[0]))
+	}},
+	"sha256": {minArgs: 1, maxArgs: 1, eval: func(args []string) string {
+		sum := sha256.Sum256([]byte(args[0]))
+		return hex.EncodeToString(sum[:])
+	}},
+	"now": {minArgs: 0, maxArgs: 1, validateArgs: validateNowArgs, eval: func(args []string) string {
+		format := nowFormatRFC3339
+		if len(args) == 1 {
+			format = args[0]
+		}
+		return formatNow(time.Now(), format)
+	}},
+}
+
+var (
+	exprFuncCallRe   = regexp.MustCompile(`^([a-z][a-z0-9_]*)\(`)
+	exprEnvNameRe    = regexp.MustCompile(`^` + DSLEnvPrefix + `[A-Za-z0-9_]+$`)
+	exprHeaderNameRe = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`)
+)
+
+// exprTruthy treats empty strings, "false" and "0" as false, like if() conditions.
+func exprTruthy(v string) bool {
+	s := strings.TrimSpace(v)
+	return s != "" && s != "0" && !strings.EqualFold(s, "false")
+}
+
+func formatNow(now time.Time, format string) string {
+	switch format {
+	case nowFormatUnix:
+		return strconv.FormatInt(now.Unix(), 10)
+	case nowFormatUnixMS:
+		return strconv.FormatInt(now.UnixMilli(), 10)
+	default:
+		return now.UTC().Format(time.RFC3339)
+	}
+}
+
+func validateNowArgs(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if !isQuotedStringExpr(args[0]) {
+		return fmt.Errorf("now format must be a string literal")
+	}
+	switch unquoteString(strings.TrimSpace(args[0])) {
+	case nowFormatRFC3339, nowFormatUnix, nowFormatUnixMS:
+		return nil
+	default:
+		return fmt.Errorf("now format must be one of %q, %q, %q", nowFormatRFC3339, nowFormatUnix, nowFormatUnixMS)
+	}
+}
+
+// splitFuncCall splits `name(args...)` when the trailing ')' closes the opening one.
+// concat and template keep their dedicated handling and are not returned here.
+func splitFuncCall(raw string) (string, []string, bool) {
+	m := exprFuncCallRe.FindStringSubmatch(raw)
+	if m == nil || !strings.HasSuffix(raw, ")") {
+		return "", nil, false
+	}
+	name := m[1]
+	if name == "concat" || name == "template" {
+		return "", nil, false
+	}
+	inner := raw[len(name)+1 : len(raw)-1]
+	if !balancedExprParens(inner) {
+		return "", nil, false
+	}
+	return name, splitTopLevelArgs(inner), true
+}
+
+// balancedExprParens reports whether parentheses outside quotes never close below depth 0.
+func balancedExprParens(s string) bool {
+	depth := 0
+	var quote byte
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0 && quote == 0
+}
+
+func evalFuncCall(name string, args []string, meta *dslmeta.Meta) string {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return ""
+	}
+	vals := make([]string, 0, len(args))
+	for _, a := range args {
+		// Function arguments may legitimately be empty (coalesce, if), so they never
+		// trigger the non-empty check used by routing expressions.
+		v, _ := evalStringExprValue(a, meta, false)
+		vals = append(vals, v)
+	}
+	return fn.eval(vals)
+}
+
+func validateFuncCall(name string, args []string) error {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return fmt.Errorf("%s expects %s", name, funcArityText(fn))
+	}
+	if fn.validateArgs != nil {
+		if err := fn.validateArgs(args); err != nil {
+			return err
+		}
+	}
+	for i, arg := range args {
+		if err := ValidateStringExpr(arg); err != nil {
+			return fmt.Errorf("%s argument %d: %w", name, i, err)
+		}
+	}
+	return nil
+}
+
+func funcArityText(fn exprFunc) string {
+	switch {
+	case fn.maxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", fn.minArgs)
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("exactly %d argument(s)", fn.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d argument(s)", fn.minArgs, fn.maxArgs)
+	}
+}
+
+// isPrefixedStringVariable reports whether expr is a well-formed prefixed variable.
+func isPrefixedStringVariable(expr string) bool {
+	switch {
+	case strings.HasPrefix(expr, exprRequestHeaderPrefix):
+		return exprHeaderNameRe.MatchString(strings.TrimPrefix(expr, exprRequestHeaderPrefix))
+	case strings.HasPrefix(expr, exprRequestBodyPrefix):
+		_, err := parseJSONPath("$." + strings.TrimPrefix(expr, exprRequestBodyPrefix))
+		return err == nil
+	case strings.HasPrefix(expr, exprEnvPrefix):
+		return exprEnvNameRe.MatchString(strings.TrimPrefix(expr, exprEnvPrefix))
+	default:
+		return false
+	}
+}
+
+// evalPrefixedStringVariable reads the original downstream request header, the
+// request body root, or an ONR_DSL_ENV_* process environment variable.
+func evalPrefixedStringVariable(expr string, meta *dslmeta.Meta) string {
+	switch {
+	case strings.HasPrefix(expr, exprRequestHeaderPrefix):
+		if meta.RequestHeaders == nil {
+			return ""
+		}
+		return meta.RequestHeaders.Get(strings.TrimPrefix(expr, exprRequestHeaderPrefix))
+	case strings.HasPrefix(expr, exprRequestBodyPrefix):
+		root := meta.RequestRoot()
+		if root == nil {
+			return ""
+		}
+		v, ok := jsonGet(root, "$."+strings.TrimPrefix(expr, exprRequestBodyPrefix))
+		if !ok {
+			return ""
+		}
+		return jsonutil.CoerceScalarString(v)
+	case strings.HasPrefix(expr, exprEnvPrefix):
+		name := strings.TrimPrefix(expr, exprEnvPrefix)
+		if !exprEnvNameRe.MatchString(name) {
+			return ""
+		}
+		return os.Getenv(name)
+	default:
+		return ""
+	}
+}
@@ -1,7 +1,10 @@
 package dslconfig
 
 import (
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
 )
@@ -62,3 +65,79 @@ func TestValidateStringExpr_TemplateRejectsUnknownVariable(t *testing.T) {
 		t.Fatalf("expected unsupported template variable error")
 	}
 }
+
+func TestEvalStringExpr_RequestVariablesAndFunctions(t *testing.T) {
+	t.Setenv("ONR_DSL_ENV_TEST_REGION", "eu")
+	meta := &dslmeta.Meta{
+		OriginModelName: "GPT-4o",
+		AccessKeyName:   "team-a",
+		RequestHeaders:  http.Header{"X-User-Id": []string{"u-42"}},
+		RequestBody:     []byte(`{"metadata":{"tenant":"acme","tier":2},"messages":[{"role":"user","content":"hi"}]}`),
+	}
+	meta.RequestContentType = "application/json"
+
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{`$request.header.x-user-id`, "u-42"},
+		{`$request.header.X-Missing`, ""},
+		{`$request.body.metadata.tenant`, "acme"},
+		{`$request.body.metadata.tier`, "2"},
+		{`$request.body.messages[?(@.role=="user")].content`, "hi"},
+		{`$request.access_key`, "team-a"},
+		{`$env.ONR_DSL_ENV_TEST_REGION`, "eu"},
+		{`coalesce($request.header.x-tenant, $request.body.metadata.tenant, "default")`, "acme"},
+		{`if(eq($request.access_key, "team-a"), "internal", "external")`, "internal"},
+		{`if($request.header.x-debug, "on", "off")`, "off"},
+		{`lower($request.model)`, "gpt-4o"},
+		{`upper(concat("a", "b"))`, "AB"},
+		{`base64("user:pass")`, "dXNlcjpwYXNz"},
+		{`sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`concat("t-", lower($request.header.x-user-id))`, "t-u-42"},
+		{`template("/tenants/${request.body.metadata.tenant}")`, "/tenants/acme"},
+	}
+	for _, tc := range cases {
+		if err := ValidateStringExpr(tc.expr); err != nil {
+			t.Fatalf("ValidateStringExpr(%q): %v", tc.expr, err)
+		}
+		if got := EvalStringExpr(tc.expr, meta); got != tc.want {
+			t.Fatalf("EvalStringExpr(%q)=%q want %q", tc.expr, got, tc.want)
+		}
+	}
+
+	t.Setenv("ONR_MASTER_KEY", "sk-master")
+	if got := EvalStringExpr(`$env.ONR_MASTER_KEY`, meta); strings.Contains(got, "sk-master") {
+		t.Fatalf("$env read a non ONR_DSL_ENV_ variable: %q", got)
+	}
+	if got := EvalStringExpr(`now("unix")`, meta); len(got) < 10 {
+		t.Fatalf("now(unix)=%q", got)
+	}
+	if _, err := time.Parse(time.RFC3339, EvalStringExpr(`now()`, meta)); err != nil {
+		t.Fatalf("now() is not RFC3339: %v", err)
+	}
+}
+
+func TestValidateStringExpr_FunctionErrors(t *testing.T) {
+	cases := map[string]string{
+		`trim("x")`:                      `unknown function "trim"`,
+		`if(eq("a", "b"), "x")`:          "if expects exactly 3 argument(s)",
+		`coalesce()`:                     "coalesce expects at least 1 argument(s)",
+		`now("iso")`:                     "now format must be one of",
+		`now($request.model)`:            "now format must be a string literal",
+		`lower($request.nope)`:           `lower argument 0: unknown variable "$request.nope"`,
+		`$request.header.`:               "unknown variable",
+		`$request.body.messages[x]`:      "unknown variable",
+		`$env.1BAD`:                      "unknown variable",
+		`$env.ONR_MASTER_KEY`:            "$env only reads ONR_DSL_ENV_<NAME> variables",
+		`template("${env.ONR_API_KEY}")`: "unsupported template variable",
+		`template("${env.9}")`:           "unsupported template variable",
+		`eq($request.model, gpt-4o)`:     `unsupported expression "gpt-4o"`,
+	}
+	for expr, want := range cases {
+		err := ValidateStringExpr(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("ValidateStringExpr(%q) err=%v, want %q", expr, err, want)
+		}
+	}
+}
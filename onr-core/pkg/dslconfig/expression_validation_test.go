@@ -170,3 +170,31 @@ provider "template" {
 	}
 	return path
 }
+
+func TestValidateProviderFile_RequestVariablesAndFunctions(t *testing.T) {
+	path := writeProviderConfig(t, `
+request {
+  set_header "x-user" $request.header.x-user-id;
+  set_header "x-tenant" coalesce($request.body.metadata.tenant, "default");
+  set_header "x-route" if(eq(lower($request.access_key), "batch"), "slow", "fast");
+  set_header "x-sig" sha256(concat($env.ONR_DSL_ENV_SIGNING_SALT, $request.model));
+  json_set "$.metadata.received_at" now("unix");
+}
+`)
+	pf, err := ValidateProviderFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProviderFile: %v", err)
+	}
+	if got, want := pf.Headers.Defaults.Request[0].ValueExpr, "$request.header.x-user-id"; got != want {
+		t.Fatalf("set_header value expr=%q want %q", got, want)
+	}
+
+	bad := writeProviderConfig(t, `
+request {
+  set_header "x-user" upper($request.headers.x-user-id);
+}
+`)
+	if _, err := ValidateProviderFile(bad); err == nil || !strings.Contains(err.Error(), `unknown variable "$request.headers.x-user-id"`) {
+		t.Fatalf("ValidateProviderFile err=%v, want unknown variable", err)
+	}
+}
@@ -143,3 +143,30 @@ func loadGlobalBalanceModesFromFile(path string) (balanceModeRegistry, map[strin
 	}
 	return rawModes, paths, content, nil
 }
+
+func loadGlobalVarMapsFromFile(path string) (varMapRegistry, map[string]string, string, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, nil, "", nil
+	}
+	contentBytes, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, "", nil
+		}
+		return nil, nil, "", fmt.Errorf("read global config %q: %w", p, err)
+	}
+	content, err := preprocessIncludes(p, string(contentBytes))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	maps, err := parseGlobalVarMaps(p, content)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	paths := make(map[string]string, len(maps))
+	for name := range maps {
+		paths[name] = p
+	}
+	return maps, paths, content, nil
+}
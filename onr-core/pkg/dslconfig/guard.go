@@ -0,0 +1,125 @@
+package dslconfig
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+const (
+	GuardKindBuiltin  = "builtin"
+	GuardKindRegex    = "regex"
+	GuardKindKeywords = "keywords"
+
+	GuardActionReject = "reject"
+	GuardActionRedact = "redact"
+	GuardActionLog    = "log"
+
+	GuardScopeRequest  = "request"
+	GuardScopeResponse = "response"
+	GuardScopeBoth     = "both"
+
+	GuardBuiltinEmail      = "email"
+	GuardBuiltinCardNumber = "card_number"
+	GuardBuiltinAPIKey     = "api_key"
+)
+
+// guardBuiltinPatterns are the detectors available to guard_builtin.
+var guardBuiltinPatterns = map[string]string{
+	GuardBuiltinEmail:      `[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`,
+	GuardBuiltinCardNumber: `\b(?:\d[ \-]?){12,18}\d\b`,
+	GuardBuiltinAPIKey: `\b(?:sk-(?:proj-|ant-)?[A-Za-z0-9_\-]{20,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_\-]{35}|` +
+		`gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9\-]{10,})\b`,
+}
+
+// GuardRule is one guard detector with the action taken on a hit.
+// Regexp is compiled by the parser; keyword rules compile into a case-insensitive alternation.
+type GuardRule struct {
+	// Name identifies the rule in access logs, dumps and rejection errors.
+	Name string
+	// Kind is one of the GuardKind* constants.
+	Kind string
+	// Pattern is the regex source (regex rules) or the builtin name (builtin rules).
+	Pattern string
+	// Keywords holds the literal keywords of keyword rules.
+	Keywords []string
+	// Action is one of the GuardAction* constants.
+	Action string
+	// Scope is one of the GuardScope* constants.
+	Scope string
+
+	Regexp *regexp.Regexp
+	// Luhn drops matches that fail the Luhn checksum (card_number builtin).
+	Luhn bool
+}
+
+// AppliesTo reports whether the rule runs for scope (request or response).
+func (r GuardRule) AppliesTo(scope string) bool {
+	return r.Scope == GuardScopeBoth || r.Scope == scope
+}
+
+type GuardConfig struct {
+	Rules []GuardRule
+}
+
+type ProviderGuard struct {
+	Defaults GuardConfig
+	Matches  []MatchGuard
+}
+
+type MatchGuard struct {
+	API    string
+	Stream *bool
+
+	Guard GuardConfig
+}
+
+// Select requires a non-nil meta and a valid ProviderGuard receiver.
+// Match rules are appended to the defaults; a match rule replaces a default rule with the same name.
+func (p *ProviderGuard) Select(meta *dslmeta.Meta) (*GuardConfig, bool) {
+	api := strings.TrimSpace(meta.API)
+	if api == "" {
+		return nil, false
+	}
+	out := GuardConfig{Rules: append([]GuardRule(nil), p.Defaults.Rules...)}
+	for _, m := range p.Matches {
+		if m.API != "" && m.API != api {
+			continue
+		}
+		if m.Stream != nil && *m.Stream != meta.IsStream {
+			continue
+		}
+		out.Rules = mergeGuardRules(out.Rules, m.Guard.Rules)
+		break
+	}
+	if len(out.Rules) == 0 {
+		return nil, false
+	}
+	return &out, true
+}
+
+func mergeGuardRules(base []GuardRule, override []GuardRule) []GuardRule {
+	for _, r := range override {
+		replaced := false
+		for i := range base {
+			if base[i].Name == r.Name {
+				base[i] = r
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, r)
+		}
+	}
+	return base
+}
+
+func compileGuardKeywords(keywords []string) (*regexp.Regexp, error) {
+	quoted := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		quoted = append(quoted, regexp.QuoteMeta(kw))
+	}
+	return regexp.Compile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+}
@@ -0,0 +1,110 @@
+package dslconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+func writeGuardProvider(t *testing.T, guardDefaults, guardMatch string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "guarded.conf")
+	content := `syntax "next-router/0.1";
+
+provider "guarded" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.example.com";
+    }
+    guard {
+` + guardDefaults + `
+    }
+  }
+  match api = "chat.completions" stream = false {
+    guard {
+` + guardMatch + `
+    }
+  }
+  match api = "claude.messages" {
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestValidateProviderFile_Guard(t *testing.T) {
+	path := writeGuardProvider(t,
+		`guard_builtin email action=redact on=both;
+      guard_keywords codename "Project Falcon" "Bluebird" action=reject;`,
+		`guard_regex internal_host "\\bcorp\\.example\\b" on=response;
+      guard_builtin email action=log;`)
+	pf, err := ValidateProviderFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProviderFile: %v", err)
+	}
+	if got := len(pf.Guard.Defaults.Rules); got != 2 {
+		t.Fatalf("defaults rules=%d want 2", got)
+	}
+	if got := len(pf.Guard.Matches); got != 2 {
+		t.Fatalf("matches=%d want 2 (one per match block)", got)
+	}
+
+	cfg, ok := pf.Guard.Select(&dslmeta.Meta{API: "chat.completions"})
+	if !ok {
+		t.Fatalf("expected guard selected")
+	}
+	names := make([]string, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		names = append(names, r.Name+"="+r.Action+"/"+r.Scope)
+	}
+	want := "email=log/request,codename=reject/request,internal_host=log/response"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("selected rules=%q want %q", got, want)
+	}
+	if !cfg.Rules[2].Regexp.MatchString("see corp.example now") {
+		t.Fatalf("expected compiled regex to match")
+	}
+	if !cfg.Rules[1].Regexp.MatchString("about project falcon") {
+		t.Fatalf("expected case-insensitive keyword match")
+	}
+
+	// Stream requests skip the stream=false match and keep the defaults.
+	cfg, _ = pf.Guard.Select(&dslmeta.Meta{API: "chat.completions", IsStream: true})
+	if len(cfg.Rules) != 2 || cfg.Rules[0].Action != GuardActionRedact {
+		t.Fatalf("unexpected stream selection: %#v", cfg.Rules)
+	}
+	if len(pf.Guard.Defaults.Rules) != 2 || pf.Guard.Defaults.Rules[0].Action != GuardActionRedact {
+		t.Fatalf("Select must not mutate defaults")
+	}
+}
+
+func TestValidateProviderFile_GuardErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{"unknown builtin", `guard_builtin phone;`, "unknown detector"},
+		{"bad regex", `guard_regex x "(";`, "invalid pattern"},
+		{"reject on response", `guard_builtin email action=reject on=response;`, "only supported on=request"},
+		{"bad action", `guard_builtin email action=block;`, "action expects"},
+		{"no keywords", `guard_keywords x action=log;`, "at least one quoted keyword"},
+		{"duplicate", `guard_builtin email; guard_builtin email;`, "duplicate guard rule"},
+		{"unknown directive", `guard_phone x;`, "unknown guard directive"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeGuardProvider(t, tc.rules, "")
+			_, err := ValidateProviderFile(path)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -45,11 +45,7 @@ func applyJSONOp(meta *dslmeta.Meta, obj map[string]any, op JSONOp) (bool, error
 	case jsonOpReplace:
 		return jsonReplace(obj, op.Path, evalJSONValueExpr(meta, op.ValueExpr))
 	case jsonOpSetIfAbsent:
-		exists, err := jsonPathExists(obj, op.Path)
-		if err != nil || exists {
-			return false, err
-		}
-		return jsonSet(obj, op.Path, evalJSONValueExpr(meta, op.ValueExpr))
+		return jsonSetIfAbsent(obj, op.Path, evalJSONValueExpr(meta, op.ValueExpr))
 	case jsonOpDel:
 		return jsonDel(obj, op.Path)
 	case jsonOpDelIfMissing:
@@ -121,27 +117,11 @@ func lowerPatterns(patterns []string) []string {
 }
 
 func jsonPathExists(root map[string]any, path string) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
-		if !ok {
-			return false, nil
-		}
-		m, ok := next.(map[string]any)
-		if !ok {
-			return false, nil
-		}
-		cur = m
-	}
-	_, ok := cur[parts[len(parts)-1]]
-	return ok, nil
+	return len(existingJSONSlots(root, parts)) > 0, nil
 }
 
 func evalJSONValueExpr(meta *dslmeta.Meta, expr string) any {
@@ -194,23 +174,21 @@ func parseFloatLiteral(raw string) (float64, bool) {
 // matchValue. Missing paths and non-matching/non-string values are left unchanged,
 // so unmapped values pass through (same semantics as model_map fallthrough).
 func jsonMapValue(root map[string]any, path string, matchValue string, val any) (bool, error) {
-	parent, key, ok, err := jsonParentAndKey(root, path)
-	if err != nil || !ok {
+	parts, err := parseJSONPath(path)
+	if err != nil {
 		return false, err
 	}
-	cur, ok := parent[key]
-	if !ok {
-		return false, nil
-	}
-	s, ok := cur.(string)
-	if !ok || s != matchValue {
-		return false, nil
-	}
-	if reflect.DeepEqual(cur, val) {
-		return false, nil
+	changed := false
+	for _, slot := range existingJSONSlots(root, parts) {
+		cur, _ := slot.get()
+		if s, ok := cur.(string); !ok || s != matchValue {
+			continue
+		}
+		if setJSONSlot(slot, val) {
+			changed = true
+		}
 	}
-	parent[key] = val
-	return true, nil
+	return changed, nil
 }
 
 // jsonClamp clamps the numeric value at path to [Min, Max]. Missing paths and
@@ -222,232 +200,165 @@ func jsonClamp(root map[string]any, path string, r *JSONClampRange) (bool, error
 		// means the op was constructed programmatically in an invalid way.
 		return false, fmt.Errorf("json_clamp %s missing clamp range", path)
 	}
-	parent, key, ok, err := jsonParentAndKey(root, path)
-	if err != nil || !ok {
+	parts, err := parseJSONPath(path)
+	if err != nil {
 		return false, err
 	}
-	cur, ok := parent[key]
-	if !ok {
-		return false, nil
-	}
-	v, ok := jsonutil.CoerceFloatOK(cur)
-	if !ok {
-		return false, nil
-	}
-	out := v
-	if out < r.Min {
-		out = r.Min
-	}
-	if out > r.Max {
-		out = r.Max
-	}
-	if reflect.DeepEqual(cur, out) {
-		return false, nil
+	changed := false
+	for _, slot := range existingJSONSlots(root, parts) {
+		cur, _ := slot.get()
+		v, ok := jsonutil.CoerceFloatOK(cur)
+		if !ok {
+			continue
+		}
+		out := v
+		if out < r.Min {
+			out = r.Min
+		}
+		if out > r.Max {
+			out = r.Max
+		}
+		if setJSONSlot(slot, out) {
+			changed = true
+		}
 	}
-	parent[key] = out
-	return true, nil
+	return changed, nil
 }
 
 func jsonSet(root map[string]any, path string, val any) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
+	slots, changed := resolveJSONSlots(root, parts, true)
+	for _, slot := range slots {
+		if setJSONSlot(slot, val) {
+			changed = true
+		}
 	}
-	cur := root
-	changed := false
-	for i := 0; i < len(parts)-1; i++ {
-		k := parts[i]
-		next, ok := cur[k]
-		if ok {
-			if m, ok := next.(map[string]any); ok {
-				cur = m
-				continue
-			}
+	return changed, nil
+}
+
+// jsonSetIfAbsent sets val on every addressed slot that does not exist yet. With
+// selectors, each matched element is checked on its own.
+func jsonSetIfAbsent(root map[string]any, path string, val any) (bool, error) {
+	parts, err := parseJSONPath(path)
+	if err != nil {
+		return false, err
+	}
+	slots, changed := resolveJSONSlots(root, parts, true)
+	for _, slot := range slots {
+		if _, ok := slot.get(); ok {
+			continue
 		}
-		m := map[string]any{}
-		cur[k] = m
-		cur = m
+		slot.set(val)
 		changed = true
 	}
-	last := parts[len(parts)-1]
-	if old, ok := cur[last]; ok && reflect.DeepEqual(old, val) {
-		return changed, nil
-	}
-	cur[last] = val
-	return true, nil
+	return changed, nil
 }
 
 func jsonReplace(root map[string]any, path string, val any) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
-		if !ok {
-			return false, nil
-		}
-		m, ok := next.(map[string]any)
-		if !ok || m == nil {
-			return false, nil
+	changed := false
+	for _, slot := range existingJSONSlots(root, parts) {
+		if setJSONSlot(slot, val) {
+			changed = true
 		}
-		cur = m
 	}
-	last := parts[len(parts)-1]
-	old, ok := cur[last]
-	if !ok {
-		return false, nil
-	}
-	if reflect.DeepEqual(old, val) {
-		return false, nil
-	}
-	cur[last] = val
-	return true, nil
+	return changed, nil
 }
 
 func jsonDel(root map[string]any, path string) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		k := parts[i]
-		next, ok := cur[k]
-		if !ok {
-			return false, nil
-		}
-		m, ok := next.(map[string]any)
-		if !ok {
-			return false, nil
-		}
-		cur = m
-	}
-	last := parts[len(parts)-1]
-	if _, ok := cur[last]; !ok {
-		return false, nil
-	}
-	delete(cur, last)
-	return true, nil
+	return deleteJSONSlots(existingJSONSlots(root, parts)), nil
 }
 
 func jsonRename(root map[string]any, fromPath string, toPath string) (bool, error) {
-	fromParts, err := parseObjectPath(fromPath)
+	fromParts, err := parseJSONPath(fromPath)
 	if err != nil {
 		return false, err
 	}
-	toParts, err := parseObjectPath(toPath)
+	toParts, err := parseJSONPath(toPath)
 	if err != nil {
 		return false, err
 	}
-	if len(fromParts) == 0 || len(toParts) == 0 {
-		return false, nil
+	split, err := jsonRenameSplit(fromParts, toParts)
+	if err != nil {
+		return false, err
 	}
-
-	var val any
-	// get + delete
-	{
-		cur := root
-		for i := 0; i < len(fromParts)-1; i++ {
-			next, ok := cur[fromParts[i]]
-			if !ok {
-				return false, nil
-			}
-			m, ok := next.(map[string]any)
-			if !ok {
-				return false, nil
-			}
-			cur = m
+	if split == 0 {
+		return jsonRenameParts(root, fromParts, toParts), nil
+	}
+	// [*]/filter renames move a field inside each selected element.
+	changed := false
+	for _, slot := range existingJSONSlots(root, fromParts[:split]) {
+		v, _ := slot.get()
+		elem, ok := v.(map[string]any)
+		if !ok || elem == nil {
+			continue
 		}
-		last := fromParts[len(fromParts)-1]
-		v, ok := cur[last]
-		if !ok {
-			return false, nil
+		if jsonRenameParts(elem, fromParts[split:], toParts[split:]) {
+			changed = true
 		}
-		val = v
-		delete(cur, last)
 	}
+	return changed, nil
+}
 
-	// set
-	{
-		cur := root
-		changed := true
-		for i := 0; i < len(toParts)-1; i++ {
-			k := toParts[i]
-			next, ok := cur[k]
-			if ok {
-				if m, ok := next.(map[string]any); ok {
-					cur = m
-					continue
-				}
-			}
-			m := map[string]any{}
-			cur[k] = m
-			cur = m
-			changed = true
-		}
-		last := toParts[len(toParts)-1]
-		if old, ok := cur[last]; ok && reflect.DeepEqual(old, val) {
-			return changed, nil
-		}
-		cur[last] = val
+// jsonRenameParts moves the single value at from to to. The value is left in place
+// when to cannot be addressed (for example an out-of-range index).
+func jsonRenameParts(root map[string]any, from, to []jsonutil.PathPart) bool {
+	src := existingJSONSlots(root, from)
+	if len(src) == 0 {
+		return false
+	}
+	if !jsonPathAddressable(root, to) {
+		return false
+	}
+	val, _ := src[0].get()
+	deleteJSONSlots(src[:1])
+	// Resolve the target after deletion so to is evaluated against the updated document.
+	dst, _ := resolveJSONSlots(root, to, true)
+	for _, slot := range dst {
+		slot.set(val)
 	}
-	return true, nil
+	return true
 }
 
 func jsonWrapInputText(root map[string]any, path string) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
-		if !ok {
-			return false, nil
-		}
-		m, ok := next.(map[string]any)
-		if !ok {
-			return false, nil
-		}
-		cur = m
-	}
-	last := parts[len(parts)-1]
-	val, ok := cur[last]
-	if !ok {
-		return false, nil
-	}
-	if text, ok := val.(string); ok {
-		cur[last] = []any{
-			map[string]any{
-				"role": "user",
-				"content": []any{
-					map[string]any{
-						"type": "input_text",
-						"text": text,
+	changed := false
+	for _, slot := range existingJSONSlots(root, parts) {
+		val, _ := slot.get()
+		if text, ok := val.(string); ok {
+			slot.set([]any{
+				map[string]any{
+					"role": "user",
+					"content": []any{
+						map[string]any{
+							"type": "input_text",
+							"text": text,
+						},
 					},
 				},
-			},
+			})
+			changed = true
+			continue
 		}
-		return true, nil
-	}
-	if val != nil && reflect.TypeOf(val).Kind() == reflect.Slice {
-		return false, nil
+		if val != nil && reflect.TypeOf(val).Kind() == reflect.Slice {
+			continue
+		}
+		return changed, fmt.Errorf("json_wrap_input_text %s expects string or array, got %T", path, val)
 	}
-	return false, fmt.Errorf("json_wrap_input_text %s expects string or array, got %T", path, val)
+	return changed, nil
 }
 
 // jsonFilterValues keeps values that match any of the patterns (allowlist, backward-compatible behavior).
@@ -465,51 +376,40 @@ func jsonKeepValues(root map[string]any, path string, patterns []string) (bool,
 }
 
 func jsonApplyValueFilter(root map[string]any, path string, patterns []string, keepMatching bool, opName string) (bool, error) {
-	parts, err := parseObjectPath(path)
+	parts, err := parseJSONPath(path)
 	if err != nil {
 		return false, err
 	}
-	if len(parts) == 0 {
-		return false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
+	loweredPatterns := lowerPatterns(patterns)
+	changed := false
+	var emptied []jsonSlot
+	for _, slot := range existingJSONSlots(root, parts) {
+		val, _ := slot.get()
+		values, ok := stringSliceValue(val)
 		if !ok {
-			return false, nil
+			return changed, fmt.Errorf("%s %s expects string array, got %T", opName, path, val)
 		}
-		m, ok := next.(map[string]any)
-		if !ok {
-			return false, nil
+		filtered := make([]string, 0, len(values))
+		for _, value := range values {
+			matches := matchesAnyHeaderValuePattern(strings.ToLower(value), loweredPatterns)
+			if matches == keepMatching {
+				filtered = append(filtered, value)
+			}
 		}
-		cur = m
-	}
-	last := parts[len(parts)-1]
-	val, ok := cur[last]
-	if !ok {
-		return false, nil
-	}
-	values, ok := stringSliceValue(val)
-	if !ok {
-		return false, fmt.Errorf("%s %s expects string array, got %T", opName, path, val)
-	}
-	loweredPatterns := lowerPatterns(patterns)
-	filtered := make([]string, 0, len(values))
-	for _, value := range values {
-		matches := matchesAnyHeaderValuePattern(strings.ToLower(value), loweredPatterns)
-		if matches == keepMatching {
-			filtered = append(filtered, value)
+		if len(filtered) == 0 {
+			emptied = append(emptied, slot)
+			continue
 		}
+		if reflect.DeepEqual(values, filtered) {
+			continue
+		}
+		slot.set(filtered)
+		changed = true
 	}
-	if len(filtered) == 0 {
-		delete(cur, last)
-		return true, nil
-	}
-	if reflect.DeepEqual(values, filtered) {
-		return false, nil
+	if deleteJSONSlots(emptied) {
+		changed = true
 	}
-	cur[last] = filtered
-	return true, nil
+	return changed, nil
 }
 
 func stringSliceValue(value any) ([]string, bool) {
@@ -532,43 +432,45 @@ func stringSliceValue(value any) ([]string, bool) {
 }
 
 func jsonDelWithCondition(root map[string]any, path string, fieldName string, patterns []string) (bool, error) {
-	parent, key, ok, err := jsonParentAndKey(root, path)
-	if err != nil || !ok {
+	parts, err := parseJSONPath(path)
+	if err != nil {
 		return false, err
 	}
-	val, ok := parent[key]
-	if !ok {
-		return false, nil
-	}
-	switch typed := val.(type) {
-	case []any:
-		filtered := make([]any, 0, len(typed))
-		for _, item := range typed {
-			obj, ok := item.(map[string]any)
-			if ok && jsonObjectFieldMatches(obj, fieldName, patterns) {
+	changed := false
+	var removed []jsonSlot
+	for _, slot := range existingJSONSlots(root, parts) {
+		val, _ := slot.get()
+		switch typed := val.(type) {
+		case []any:
+			filtered := make([]any, 0, len(typed))
+			for _, item := range typed {
+				obj, ok := item.(map[string]any)
+				if ok && jsonObjectFieldMatches(obj, fieldName, patterns) {
+					continue
+				}
+				filtered = append(filtered, item)
+			}
+			if len(filtered) == len(typed) {
 				continue
 			}
-			filtered = append(filtered, item)
-		}
-		if len(filtered) == len(typed) {
-			return false, nil
-		}
-		if len(filtered) == 0 {
-			delete(parent, key)
-			return true, nil
-		}
-		parent[key] = filtered
-		return true, nil
-	case map[string]any:
-		if !jsonObjectFieldMatches(typed, fieldName, patterns) {
-			return false, nil
+			if len(filtered) == 0 {
+				removed = append(removed, slot)
+				continue
+			}
+			slot.set(filtered)
+			changed = true
+		case map[string]any:
+			if jsonObjectFieldMatches(typed, fieldName, patterns) {
+				removed = append(removed, slot)
+			}
+		default:
+			slog.Debug("json_del_with_condition ignored non-object value", "path", path, "type", fmt.Sprintf("%T", val))
 		}
-		delete(parent, key)
-		return true, nil
-	default:
-		slog.Debug("json_del_with_condition ignored non-object value", "path", path, "type", fmt.Sprintf("%T", val))
-		return false, nil
 	}
+	if deleteJSONSlots(removed) {
+		changed = true
+	}
+	return changed, nil
 }
 
 func jsonObjectFieldMatches(obj map[string]any, fieldName string, patterns []string) bool {
@@ -579,29 +481,8 @@ func jsonObjectFieldMatches(obj map[string]any, fieldName string, patterns []str
 	return matchesAnyHeaderValuePattern(strings.ToLower(fieldValue), lowerPatterns(patterns))
 }
 
-func jsonParentAndKey(root map[string]any, path string) (map[string]any, string, bool, error) {
-	parts, err := parseObjectPath(path)
-	if err != nil {
-		return nil, "", false, err
-	}
-	if len(parts) == 0 {
-		return nil, "", false, nil
-	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
-		if !ok {
-			return nil, "", false, nil
-		}
-		m, ok := next.(map[string]any)
-		if !ok {
-			return nil, "", false, nil
-		}
-		cur = m
-	}
-	return cur, parts[len(parts)-1], true, nil
-}
-
+// parseObjectPath parses a plain object path ($.a.b.c) for rules that index into objects
+// only, such as req_* body rules. JSON ops use parseJSONPath instead.
 func parseObjectPath(path string) ([]string, error) {
 	p := strings.TrimSpace(path)
 	if !strings.HasPrefix(p, "$.") {
@@ -609,7 +490,7 @@ func parseObjectPath(path string) ([]string, error) {
 	}
 	rest := strings.TrimPrefix(p, "$.")
 	if strings.Contains(rest, "[") || strings.Contains(rest, "]") {
-		return nil, fmt.Errorf("json path does not support array indexes in v0.1: %q", path)
+		return nil, fmt.Errorf("json path does not support array indexes here: %q", path)
 	}
 	parts := strings.Split(rest, ".")
 	out := make([]string, 0, len(parts))
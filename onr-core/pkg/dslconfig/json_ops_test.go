@@ -286,9 +286,15 @@ func TestApplyJSONOps_TableDriven(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "array_index_not_supported",
+			name: "array_index_on_non_array_is_noop",
+			in:   map[string]any{"a": 1},
+			ops:  []JSONOp{{Op: "json_set", Path: "$.a[0]", ValueExpr: "true"}},
+			want: map[string]any{"a": 1},
+		},
+		{
+			name:    "invalid_array_selector",
 			in:      map[string]any{"a": 1},
-			ops:     []JSONOp{{Op: "json_set", Path: "$.a[0]", ValueExpr: "true"}},
+			ops:     []JSONOp{{Op: "json_set", Path: "$.a[x]", ValueExpr: "true"}},
 			wantErr: true,
 		},
 		{name: "nil_root", in: nil, ops: []JSONOp{{Op: "json_set", Path: "$.a", ValueExpr: "true"}}, wantErr: true},
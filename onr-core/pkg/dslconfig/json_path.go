@@ -0,0 +1,272 @@
+package dslconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
+)
+
+// JSON op paths reuse the restricted JSONPath subset read by usage_fact:
+//
+//	$.a.b                      object keys
+//	$.messages[0].content      array index (0-based)
+//	$.tools[*].function        every array element
+//	$.messages[?(@.role=="x")] elements whose string field equals "x" (case-insensitive)
+//
+// Selectors never create or extend arrays: a missing array, an out-of-range index or a
+// filter without matches resolves to no targets, so the op is a no-op. Only object keys
+// are created on demand (json_set), and only inside elements that are already objects.
+
+// jsonSlot addresses one value in a decoded JSON document: obj[key] for object members,
+// arr[idx] for array elements. owner is the slot holding arr and is shared by all
+// elements of the same array, so deletions can be compacted once per array.
+type jsonSlot struct {
+	obj   map[string]any
+	key   string
+	arr   []any
+	idx   int
+	owner *jsonSlot
+}
+
+func (s jsonSlot) get() (any, bool) {
+	if s.owner != nil {
+		return s.arr[s.idx], true
+	}
+	v, ok := s.obj[s.key]
+	return v, ok
+}
+
+func (s jsonSlot) set(v any) {
+	if s.owner != nil {
+		s.arr[s.idx] = v
+		return
+	}
+	s.obj[s.key] = v
+}
+
+// jsonRemoved marks array elements scheduled for removal by deleteJSONSlots.
+type jsonRemoved struct{}
+
+// parseJSONPath parses a JSON op path. Every array selector must follow an object key.
+func parseJSONPath(path string) ([]jsonutil.PathPart, error) {
+	p := strings.TrimSpace(path)
+	if !strings.HasPrefix(p, "$.") {
+		return nil, fmt.Errorf("json path must start with '$.': %q", path)
+	}
+	parts, ok := jsonutil.ParsePath(p)
+	if !ok {
+		return nil, fmt.Errorf("invalid json path: %q (supported segments: key, key[n], key[*], key[?(@.field==\"value\")])", path)
+	}
+	for _, part := range parts {
+		if part.Name == "" {
+			return nil, fmt.Errorf("invalid json path: %q (array selector must follow an object key)", path)
+		}
+		if part.HasIndex && !part.MultiMatch() && part.Index < 0 {
+			return nil, fmt.Errorf("invalid json path: %q (array index must be non-negative)", path)
+		}
+	}
+	return parts, nil
+}
+
+// jsonPathMultiMatch reports whether any segment can select several array elements.
+func jsonPathMultiMatch(parts []jsonutil.PathPart) bool {
+	for _, part := range parts {
+		if part.MultiMatch() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveJSONSlots returns the slots addressed by parts. With create=true, missing or
+// non-object intermediate object keys are replaced by empty objects (json_set semantics)
+// and created reports whether that happened. The final slot may not exist yet.
+func resolveJSONSlots(root map[string]any, parts []jsonutil.PathPart, create bool) (slots []jsonSlot, created bool) {
+	if len(parts) == 0 {
+		return nil, false
+	}
+	cur := []map[string]any{root}
+	for i, part := range parts {
+		last := i == len(parts)-1
+		next := make([]map[string]any, 0, len(cur))
+		for _, obj := range cur {
+			member := jsonSlot{obj: obj, key: part.Name}
+			if !part.HasIndex {
+				if last {
+					slots = append(slots, member)
+					continue
+				}
+				v, ok := obj[part.Name]
+				child, isObj := v.(map[string]any)
+				if !ok || !isObj || child == nil {
+					if !create {
+						continue
+					}
+					child = map[string]any{}
+					obj[part.Name] = child
+					created = true
+				}
+				next = append(next, child)
+				continue
+			}
+			arr, ok := obj[part.Name].([]any)
+			if !ok {
+				continue
+			}
+			owner := &member
+			for idx, item := range arr {
+				if !jsonSelectorMatches(part, idx, item) {
+					continue
+				}
+				if last {
+					slots = append(slots, jsonSlot{arr: arr, idx: idx, owner: owner})
+					continue
+				}
+				if child, ok := item.(map[string]any); ok && child != nil {
+					next = append(next, child)
+				}
+			}
+		}
+		cur = next
+	}
+	return slots, created
+}
+
+func jsonSelectorMatches(part jsonutil.PathPart, idx int, item any) bool {
+	switch {
+	case part.Wildcard:
+		return true
+	case part.HasFilter:
+		return part.MatchesFilter(item)
+	default:
+		return idx == part.Index
+	}
+}
+
+// existingJSONSlots resolves parts without creating anything and keeps only slots
+// that currently hold a value.
+func existingJSONSlots(root map[string]any, parts []jsonutil.PathPart) []jsonSlot {
+	slots, _ := resolveJSONSlots(root, parts, false)
+	out := slots[:0]
+	for _, s := range slots {
+		if _, ok := s.get(); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// jsonPathAddressable reports whether resolveJSONSlots with create=true would yield a
+// slot: object keys can always be created, array selectors need existing elements.
+func jsonPathAddressable(root map[string]any, parts []jsonutil.PathPart) bool {
+	lastSel := -1
+	for i, part := range parts {
+		if part.HasIndex {
+			lastSel = i
+		}
+	}
+	if lastSel < 0 {
+		return len(parts) > 0
+	}
+	slots := existingJSONSlots(root, parts[:lastSel+1])
+	if lastSel == len(parts)-1 {
+		return len(slots) > 0
+	}
+	for _, slot := range slots {
+		if v, _ := slot.get(); v != nil {
+			if m, ok := v.(map[string]any); ok && m != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteJSONSlots removes object members and drops array elements from their owning
+// arrays. Arrays keep their remaining order; emptied arrays stay as [].
+func deleteJSONSlots(slots []jsonSlot) bool {
+	changed := false
+	owners := make([]*jsonSlot, 0, 1)
+	for _, s := range slots {
+		if s.owner == nil {
+			if _, ok := s.obj[s.key]; ok {
+				delete(s.obj, s.key)
+				changed = true
+			}
+			continue
+		}
+		s.arr[s.idx] = jsonRemoved{}
+		changed = true
+		seen := false
+		for _, o := range owners {
+			if o == s.owner {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			owners = append(owners, s.owner)
+		}
+	}
+	for _, o := range owners {
+		arr, _ := o.obj[o.key].([]any)
+		kept := make([]any, 0, len(arr))
+		for _, item := range arr {
+			if _, removed := item.(jsonRemoved); !removed {
+				kept = append(kept, item)
+			}
+		}
+		o.obj[o.key] = kept
+	}
+	return changed
+}
+
+// setJSONSlot stores val unless the slot already holds an equal value.
+func setJSONSlot(s jsonSlot, val any) bool {
+	if old, ok := s.get(); ok && reflect.DeepEqual(old, val) {
+		return false
+	}
+	s.set(val)
+	return true
+}
+
+// validateJSONRenamePaths checks that a multi-match rename moves fields inside each
+// selected element: both paths must share the same prefix up to the last [*]/filter.
+func validateJSONRenamePaths(fromPath, toPath string) error {
+	from, err := parseJSONPath(fromPath)
+	if err != nil {
+		return err
+	}
+	to, err := parseJSONPath(toPath)
+	if err != nil {
+		return err
+	}
+	_, err = jsonRenameSplit(from, to)
+	return err
+}
+
+// jsonRenameSplit returns the length of the shared element prefix for multi-match
+// renames, or 0 when from addresses at most one value.
+func jsonRenameSplit(from, to []jsonutil.PathPart) (int, error) {
+	split := 0
+	for i, part := range from {
+		if part.MultiMatch() {
+			split = i + 1
+		}
+	}
+	if split == 0 {
+		if jsonPathMultiMatch(to) {
+			return 0, fmt.Errorf("json_rename to path may only use [*] or filters shared with the from path")
+		}
+		return 0, nil
+	}
+	if split == len(from) || split >= len(to) || !reflect.DeepEqual(from[:split], to[:split]) {
+		return 0, fmt.Errorf("json_rename with [*] or filters must rename a field inside the selected elements (both paths need the same prefix)")
+	}
+	if jsonPathMultiMatch(to[split:]) {
+		return 0, fmt.Errorf("json_rename to path may only use [*] or filters shared with the from path")
+	}
+	return split, nil
+}
@@ -0,0 +1,180 @@
+package dslconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+func decodeJSONObject(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return out
+}
+
+func encodeJSONObject(t *testing.T, v map[string]any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestApplyJSONOps_ArraySelectors(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"messages":[{"role":"system","content":"s"},{"role":"user","content":"u"},{"role":"System","content":"s2"}],` +
+		`"tools":[{"type":"function","function":{"name":"a","strict":true}},{"type":"function","function":{"name":"b"}},"bare"]}`
+
+	cases := []struct {
+		name string
+		ops  []JSONOp
+		want string
+	}{
+		{
+			name: "index_set",
+			ops:  []JSONOp{{Op: jsonOpSet, Path: "$.messages[1].content", ValueExpr: `"hi"`}},
+			want: `{"messages":[{"content":"s","role":"system"},{"content":"hi","role":"user"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "index_out_of_range_is_noop",
+			ops: []JSONOp{
+				{Op: jsonOpSet, Path: "$.messages[9].content", ValueExpr: `"x"`},
+				{Op: jsonOpDel, Path: "$.messages[9]"},
+				{Op: jsonOpSet, Path: "$.missing[0].x", ValueExpr: `"x"`},
+			},
+			want: `{"messages":[{"content":"s","role":"system"},{"content":"u","role":"user"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "wildcard_del_and_set_skip_non_objects",
+			ops: []JSONOp{
+				{Op: jsonOpDel, Path: "$.tools[*].function.strict"},
+				{Op: jsonOpSet, Path: "$.tools[*].function.parameters.type", ValueExpr: `"object"`},
+			},
+			want: `{"messages":[{"content":"s","role":"system"},{"content":"u","role":"user"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","parameters":{"type":"object"}},"type":"function"},{"function":{"name":"b","parameters":{"type":"object"}},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "filter_del_removes_elements",
+			ops:  []JSONOp{{Op: jsonOpDel, Path: `$.messages[?(@.role=="system")]`}},
+			want: `{"messages":[{"content":"u","role":"user"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "filter_rename_inside_elements",
+			ops:  []JSONOp{{Op: jsonOpRename, FromPath: `$.messages[?(@.role=="user")].content`, ToPath: `$.messages[?(@.role=="user")].text`}},
+			want: `{"messages":[{"content":"s","role":"system"},{"role":"user","text":"u"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "index_rename_out_of_array",
+			ops:  []JSONOp{{Op: jsonOpRename, FromPath: "$.messages[0]", ToPath: "$.system"}},
+			want: `{"messages":[{"content":"u","role":"user"},{"content":"s2","role":"System"}],"system":{"content":"s","role":"system"},"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "rename_to_missing_element_keeps_value",
+			ops:  []JSONOp{{Op: jsonOpRename, FromPath: "$.tools", ToPath: "$.messages[7].tools"}},
+			want: `{"messages":[{"content":"s","role":"system"},{"content":"u","role":"user"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b"},"type":"function"},"bare"]}`,
+		},
+		{
+			name: "set_if_absent_per_element",
+			ops:  []JSONOp{{Op: jsonOpSetIfAbsent, Path: "$.tools[*].function.strict", ValueExpr: "false"}},
+			want: `{"messages":[{"content":"s","role":"system"},{"content":"u","role":"user"},{"content":"s2","role":"System"}],"tools":[{"function":{"name":"a","strict":true},"type":"function"},{"function":{"name":"b","strict":false},"type":"function"},"bare"]}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			root := decodeJSONObject(t, doc)
+			got, err := ApplyJSONOps(&dslmeta.Meta{}, root, tc.ops)
+			if err != nil {
+				t.Fatalf("ApplyJSONOps: %v", err)
+			}
+			if s := encodeJSONObject(t, got); s != tc.want {
+				t.Fatalf("got  %s\nwant %s", s, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONOps_ArraySelectorsWithValueOps(t *testing.T) {
+	t.Parallel()
+
+	root := decodeJSONObject(t, `{"messages":[{"role":"user","temperature":3,"tags":["a","b"]},{"role":"user","tags":["b"]}]}`)
+	ops := []JSONOp{
+		{Op: jsonOpClamp, Path: "$.messages[*].temperature", ClampRange: &JSONClampRange{Min: 0, Max: 2}},
+		{Op: jsonOpKeepValues, Path: "$.messages[*].tags", Patterns: []string{"a"}},
+	}
+	got, err := ApplyJSONOps(&dslmeta.Meta{}, root, ops)
+	if err != nil {
+		t.Fatalf("ApplyJSONOps: %v", err)
+	}
+	want := `{"messages":[{"role":"user","tags":["a"],"temperature":2},{"role":"user"}]}`
+	if s := encodeJSONObject(t, got); s != want {
+		t.Fatalf("got  %s\nwant %s", s, want)
+	}
+}
+
+func TestParseJSONPath_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"messages[0]":    "must start with '$.'",
+		"$.messages[x]":  "invalid json path",
+		"$.messages[-1]": "non-negative",
+		"$.a..b":         "invalid json path",
+	}
+	for path, want := range cases {
+		if _, err := parseJSONPath(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseJSONPath(%q) err=%v, want %q", path, err, want)
+		}
+	}
+}
+
+func TestValidateProviderFile_JSONPathSelectors(t *testing.T) {
+	path := writeProviderFile(t, "t.conf", `
+syntax "next-router/0.1";
+
+provider "t" {
+  defaults {
+    upstream_config { base_url = "https://t.example.com"; }
+    request {
+      json_del "$.messages[?(@.role==\"system\")]";
+      json_set "$.tools[*].function.strict" false;
+      json_rename "$.messages[0].content" "$.messages[0].text";
+    }
+    response {
+      json_del "$.choices[*].logprobs";
+    }
+  }
+}
+`)
+	if _, err := ValidateProviderFile(path); err != nil {
+		t.Fatalf("ValidateProviderFile: %v", err)
+	}
+
+	bad := writeProviderFile(t, "bad.conf", `
+syntax "next-router/0.1";
+
+provider "bad" {
+  defaults {
+    upstream_config { base_url = "https://t.example.com"; }
+    request {
+      json_rename "$.tools[*].function.strict" "$.strict";
+    }
+  }
+}
+`)
+	_, err := ValidateProviderFile(bad)
+	var issue *ValidationIssue
+	if err == nil || !strings.Contains(err.Error(), "same prefix") {
+		t.Fatalf("ValidateProviderFile err=%v, want rename prefix error", err)
+	}
+	if !errors.As(err, &issue) || issue.Directive != "json_rename" {
+		t.Fatalf("expected ValidationIssue for json_rename, got %#v", err)
+	}
+}
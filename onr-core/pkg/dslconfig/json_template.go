@@ -0,0 +1,533 @@
+package dslconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
+)
+
+// ReqMapModeTemplate / RespMapModeTemplate are the req_map/resp_map modes set by
+// req_template / resp_template blocks.
+const (
+	ReqMapModeTemplate  = "template"
+	RespMapModeTemplate = "template"
+)
+
+// ErrTemplateRequired is wrapped by JSONTemplate.Render when a `$required` projection
+// does not match the input document.
+var ErrTemplateRequired = errors.New("required template value is missing")
+
+// JSONTemplate is a declarative JSON mapping used by req_template / resp_template.
+//
+// The template is a JSON object describing the output document:
+//
+//   - Literal JSON values are copied as-is.
+//   - A string starting with "$." (or exactly "$") projects a value from the input
+//     document; "@." (or "@") projects from the current $for item.
+//   - An object with "$path" is a projection with options: "$default", "$required",
+//     "$as". "$path" may also be a list of paths; the first one that matches wins.
+//   - An object with "$for" maps an array: "$each" is rendered once per element.
+//   - An object with "$expr" evaluates a DSL string expression (e.g. $request.model).
+//   - An object with "$literal" emits its value verbatim (use it for "$..." strings).
+//
+// Projections that do not match are omitted from the parent object or array.
+type JSONTemplate struct {
+	// Source is the template JSON object as written in the DSL.
+	Source string
+	// SamplePath is an optional sample input, relative to the provider file, rendered
+	// during provider validation.
+	SamplePath string
+
+	root templateNode
+}
+
+type templateNodeKind int
+
+const (
+	templateLiteral templateNodeKind = iota
+	templateObject
+	templateArray
+	templatePath
+	templateFor
+	templateExpr
+)
+
+type templateNode struct {
+	kind templateNodeKind
+
+	literal any
+	keys    []string
+	fields  map[string]templateNode
+	items   []templateNode
+
+	// paths are tried in order for templatePath; paths[0] is the array source for templateFor.
+	paths      []string
+	each       *templateNode
+	expr       string
+	as         string
+	required   bool
+	hasDefault bool
+	def        any
+}
+
+var templateDirectiveKeys = map[string]bool{
+	"$path":     true,
+	"$for":      true,
+	"$expr":     true,
+	"$literal":  true,
+	"$each":     true,
+	"$default":  true,
+	"$required": true,
+	"$as":       true,
+}
+
+var templateAsTypes = map[string]bool{
+	"string":      true,
+	"number":      true,
+	"integer":     true,
+	"boolean":     true,
+	"json":        true,
+	"json_string": true,
+}
+
+// parseJSONTemplateBlock parses `<directive> [sample="file.json"] { <json object body> }`.
+// The body is the inside of a JSON object; DSL comments are allowed and stripped.
+func parseJSONTemplateBlock(s *scanner, directive string) (*JSONTemplate, error) {
+	tpl := &JSONTemplate{}
+	var lb token
+	for {
+		tok := s.nextNonTrivia()
+		if tok.kind == tokLBrace {
+			lb = tok
+			break
+		}
+		if tok.kind != tokIdent || tok.text != "sample" {
+			return nil, s.errAt(tok, "expected '{' or sample=\"<file>\" after "+directive)
+		}
+		if err := consumeEquals(s); err != nil {
+			return nil, err
+		}
+		v := s.nextNonTrivia()
+		if v.kind != tokString || strings.TrimSpace(unquoteString(v.text)) == "" {
+			return nil, s.errAt(v, directive+" sample expects a file path string literal")
+		}
+		tpl.SamplePath = strings.TrimSpace(unquoteString(v.text))
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	depth := 1
+	for depth > 0 {
+		tok := s.next()
+		switch tok.kind {
+		case tokEOF:
+			return nil, s.errAt(lb, "unterminated "+directive+" block")
+		case tokComment:
+			continue
+		case tokLBrace:
+			depth++
+		case tokRBrace:
+			depth--
+		}
+		b.WriteString(tok.text)
+	}
+	tpl.Source = strings.TrimSpace(b.String())
+
+	var doc any
+	if err := json.Unmarshal([]byte(tpl.Source), &doc); err != nil {
+		return nil, s.errAt(lb, fmt.Sprintf("%s is not a valid JSON object: %v", directive, err))
+	}
+	root, err := compileTemplateNode(doc, "$")
+	if err != nil {
+		return nil, s.errAt(lb, fmt.Sprintf("%s: %v", directive, err))
+	}
+	tpl.root = root
+	return tpl, nil
+}
+
+func compileTemplateNode(v any, at string) (templateNode, error) {
+	switch x := v.(type) {
+	case string:
+		if isTemplatePathString(x) {
+			if err := validateTemplatePath(x); err != nil {
+				return templateNode{}, fmt.Errorf("%s: %w", at, err)
+			}
+			return templateNode{kind: templatePath, paths: []string{x}}, nil
+		}
+		return templateNode{kind: templateLiteral, literal: x}, nil
+	case []any:
+		n := templateNode{kind: templateArray, items: make([]templateNode, 0, len(x))}
+		for i, item := range x {
+			child, err := compileTemplateNode(item, fmt.Sprintf("%s[%d]", at, i))
+			if err != nil {
+				return templateNode{}, err
+			}
+			n.items = append(n.items, child)
+		}
+		return n, nil
+	case map[string]any:
+		return compileTemplateObject(x, at)
+	default:
+		return templateNode{kind: templateLiteral, literal: x}, nil
+	}
+}
+
+func compileTemplateObject(obj map[string]any, at string) (templateNode, error) {
+	directive := ""
+	for _, k := range []string{"$path", "$for", "$expr", "$literal"} {
+		if _, ok := obj[k]; !ok {
+			continue
+		}
+		if directive != "" {
+			return templateNode{}, fmt.Errorf("%s: %s cannot be combined with %s", at, k, directive)
+		}
+		directive = k
+	}
+	if directive == "" {
+		n := templateNode{kind: templateObject, fields: make(map[string]templateNode, len(obj))}
+		for k, v := range obj {
+			if strings.HasPrefix(k, "$") {
+				return templateNode{}, fmt.Errorf("%s: unknown template directive %q (wrap literal keys in $literal)", at, k)
+			}
+			child, err := compileTemplateNode(v, at+"."+k)
+			if err != nil {
+				return templateNode{}, err
+			}
+			n.keys = append(n.keys, k)
+			n.fields[k] = child
+		}
+		sort.Strings(n.keys)
+		return n, nil
+	}
+	if directive == "$literal" {
+		if len(obj) != 1 {
+			return templateNode{}, fmt.Errorf("%s: $literal does not take options", at)
+		}
+		return templateNode{kind: templateLiteral, literal: obj["$literal"]}, nil
+	}
+
+	var n templateNode
+	for k := range obj {
+		if !templateDirectiveKeys[k] {
+			return templateNode{}, fmt.Errorf("%s: unknown %s option %q", at, directive, k)
+		}
+	}
+	if _, ok := obj["$each"]; ok != (directive == "$for") {
+		return templateNode{}, fmt.Errorf("%s: $each is required with $for and only valid there", at)
+	}
+	switch directive {
+	case "$path":
+		paths, err := templatePathList(obj["$path"])
+		if err != nil {
+			return templateNode{}, fmt.Errorf("%s: %w", at, err)
+		}
+		n = templateNode{kind: templatePath, paths: paths}
+	case "$for":
+		src, ok := obj["$for"].(string)
+		if !ok || !isTemplatePathString(src) {
+			return templateNode{}, fmt.Errorf("%s: $for expects a \"$.path\" or \"@.path\" string", at)
+		}
+		if err := validateTemplatePath(src); err != nil {
+			return templateNode{}, fmt.Errorf("%s: %w", at, err)
+		}
+		each, err := compileTemplateNode(obj["$each"], at+"[*]")
+		if err != nil {
+			return templateNode{}, err
+		}
+		n = templateNode{kind: templateFor, paths: []string{src}, each: &each}
+	case "$expr":
+		expr, ok := obj["$expr"].(string)
+		if !ok || strings.TrimSpace(expr) == "" {
+			return templateNode{}, fmt.Errorf("%s: $expr expects a non-empty DSL expression string", at)
+		}
+		if err := ValidateStringExpr(expr); err != nil {
+			return templateNode{}, fmt.Errorf("%s: $expr: %w", at, err)
+		}
+		n = templateNode{kind: templateExpr, expr: strings.TrimSpace(expr)}
+	}
+	if raw, ok := obj["$as"]; ok {
+		as, _ := raw.(string)
+		if !templateAsTypes[as] {
+			return templateNode{}, fmt.Errorf("%s: unsupported $as %v (expected string, number, integer, boolean, json or json_string)", at, raw)
+		}
+		n.as = as
+	}
+	if raw, ok := obj["$required"]; ok {
+		required, isBool := raw.(bool)
+		if !isBool {
+			return templateNode{}, fmt.Errorf("%s: $required expects true or false", at)
+		}
+		n.required = required
+	}
+	if def, ok := obj["$default"]; ok {
+		if n.required {
+			return templateNode{}, fmt.Errorf("%s: $default cannot be combined with $required", at)
+		}
+		n.hasDefault = true
+		n.def = def
+	}
+	return n, nil
+}
+
+func templatePathList(v any) ([]string, error) {
+	var raw []any
+	switch x := v.(type) {
+	case string:
+		raw = []any{x}
+	case []any:
+		raw = x
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("$path expects a path string or a non-empty list of paths")
+	}
+	out := make([]string, 0, len(raw))
+	for _, item := range raw {
+		p, ok := item.(string)
+		if !ok || !isTemplatePathString(p) {
+			return nil, fmt.Errorf("$path entries must be \"$.path\" or \"@.path\" strings, got %v", item)
+		}
+		if err := validateTemplatePath(p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func isTemplatePathString(s string) bool {
+	return s == "$" || s == "@" || strings.HasPrefix(s, "$.") || strings.HasPrefix(s, "@.")
+}
+
+func validateTemplatePath(p string) error {
+	if p == "$" || p == "@" {
+		return nil
+	}
+	if _, err := parseJSONPath(templateAbsPath(p)); err != nil {
+		return fmt.Errorf("invalid template path %q: %w", p, err)
+	}
+	return nil
+}
+
+func templateAbsPath(p string) string {
+	if strings.HasPrefix(p, "@.") {
+		return "$." + strings.TrimPrefix(p, "@.")
+	}
+	return p
+}
+
+type templateScope struct {
+	meta *dslmeta.Meta
+	root any
+	item any
+}
+
+// Render builds the output object for the input document. meta may be nil, in which
+// case $expr nodes evaluate with an empty request context.
+func (t *JSONTemplate) Render(meta *dslmeta.Meta, in map[string]any) (map[string]any, error) {
+	if t == nil {
+		return nil, fmt.Errorf("template is nil")
+	}
+	if meta == nil {
+		meta = &dslmeta.Meta{}
+	}
+	out, ok, err := t.root.render(templateScope{meta: meta, root: in, item: in}, "$")
+	if err != nil {
+		return nil, err
+	}
+	obj, isObj := out.(map[string]any)
+	if !ok || !isObj {
+		return map[string]any{}, nil
+	}
+	return obj, nil
+}
+
+// render returns the node value and whether it is present.
+func (n templateNode) render(sc templateScope, at string) (any, bool, error) {
+	switch n.kind {
+	case templateLiteral:
+		return n.literal, true, nil
+	case templateObject:
+		out := make(map[string]any, len(n.keys))
+		for _, k := range n.keys {
+			v, ok, err := n.fields[k].render(sc, at+"."+k)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out[k] = v
+			}
+		}
+		return out, true, nil
+	case templateArray:
+		out := make([]any, 0, len(n.items))
+		for i, item := range n.items {
+			v, ok, err := item.render(sc, fmt.Sprintf("%s[%d]", at, i))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out = append(out, v)
+			}
+		}
+		return out, true, nil
+	case templatePath:
+		for _, p := range n.paths {
+			if v, ok := sc.lookup(p); ok {
+				if v, ok = coerceTemplateValue(v, n.as); ok {
+					return v, true, nil
+				}
+			}
+		}
+		return n.missing(at, strings.Join(n.paths, ", "))
+	case templateFor:
+		src, ok := sc.lookup(n.paths[0])
+		if !ok {
+			return n.missing(at, n.paths[0])
+		}
+		arr, ok := src.([]any)
+		if !ok {
+			return n.missing(at, n.paths[0])
+		}
+		out := make([]any, 0, len(arr))
+		for i, item := range arr {
+			v, ok, err := n.each.render(templateScope{meta: sc.meta, root: sc.root, item: item}, fmt.Sprintf("%s[%d]", at, i))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out = append(out, v)
+			}
+		}
+		return out, true, nil
+	case templateExpr:
+		v, ok := coerceTemplateValue(evalStringExpr(n.expr, sc.meta), n.as)
+		if !ok {
+			return n.missing(at, n.expr)
+		}
+		return v, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (n templateNode) missing(at, source string) (any, bool, error) {
+	if n.hasDefault {
+		return n.def, true, nil
+	}
+	if n.required {
+		return nil, false, fmt.Errorf("%s (%s): %w", at, source, ErrTemplateRequired)
+	}
+	return nil, false, nil
+}
+
+// lookup resolves a template path. Paths addressing several elements (wildcards and
+// filters) yield the list of matched values.
+func (sc templateScope) lookup(p string) (any, bool) {
+	base := sc.root
+	if strings.HasPrefix(p, "@") {
+		base = sc.item
+	}
+	if p == "$" || p == "@" {
+		return base, base != nil
+	}
+	abs := templateAbsPath(p)
+	vals, ok := jsonutil.GetValuesByPath(base, abs)
+	if !ok {
+		return nil, false
+	}
+	parts, _ := jsonutil.ParsePath(abs)
+	if jsonPathMultiMatch(parts) {
+		return vals, true
+	}
+	if len(vals) == 0 {
+		return nil, false
+	}
+	return vals[0], true
+}
+
+// coerceTemplateValue converts v for `$as`; values that cannot be converted count as missing.
+func coerceTemplateValue(v any, as string) (any, bool) {
+	switch as {
+	case "":
+		return v, true
+	case "string":
+		switch x := v.(type) {
+		case string:
+			return x, true
+		case nil, map[string]any, []any:
+			return nil, false
+		default:
+			return jsonutil.CoerceScalarString(x), true
+		}
+	case "number":
+		f, ok := jsonutil.CoerceFloatOK(v)
+		return f, ok
+	case "integer":
+		i, ok := jsonutil.CoerceIntOK(v)
+		return i, ok
+	case "boolean":
+		switch x := v.(type) {
+		case bool:
+			return x, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(x))
+			return b, err == nil
+		}
+		return nil, false
+	case "json":
+		s, ok := v.(string)
+		if !ok {
+			return v, true
+		}
+		var out any
+		if err := json.Unmarshal([]byte(s), &out); err != nil {
+			return nil, false
+		}
+		return out, true
+	case "json_string":
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, false
+		}
+		return string(b), true
+	default:
+		return nil, false
+	}
+}
+
+// validateJSONTemplateSample renders tpl against its sample file (if any), so broken
+// projections are reported when the provider is loaded instead of on live traffic.
+func validateJSONTemplateSample(path, providerName, scope, directive string, tpl *JSONTemplate) error {
+	if tpl == nil || strings.TrimSpace(tpl.SamplePath) == "" {
+		return nil
+	}
+	samplePath := tpl.SamplePath
+	if !filepath.IsAbs(samplePath) {
+		samplePath = filepath.Join(filepath.Dir(path), samplePath)
+	}
+	// #nosec G304 -- sample payloads are referenced by the provider DSL file being validated.
+	b, err := os.ReadFile(samplePath)
+	if err != nil {
+		return validationIssue(fmt.Errorf("provider %q in %q: %s %s sample: %w", providerName, path, scope, directive, err), scope, directive)
+	}
+	var sample map[string]any
+	if err := json.Unmarshal(b, &sample); err != nil {
+		return validationIssue(fmt.Errorf("provider %q in %q: %s %s sample %q is not a JSON object: %w", providerName, path, scope, directive, samplePath, err), scope, directive)
+	}
+	meta := &dslmeta.Meta{OriginModelName: jsonutil.GetStringByPath(sample, "$.model")}
+	if _, err := tpl.Render(meta, sample); err != nil {
+		return validationIssue(fmt.Errorf("provider %q in %q: %s %s sample %q: %w", providerName, path, scope, directive, samplePath, err), scope, directive)
+	}
+	return nil
+}
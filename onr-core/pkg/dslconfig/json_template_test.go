@@ -0,0 +1,204 @@
+package dslconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+func mustParseJSONTemplate(t *testing.T, body string) *JSONTemplate {
+	t.Helper()
+	s := newScanner("test.conf", body)
+	tok := s.nextNonTrivia()
+	if tok.kind != tokIdent {
+		t.Fatalf("expected directive, got %q", tok.text)
+	}
+	tpl, err := parseJSONTemplateBlock(s, tok.text)
+	if err != nil {
+		t.Fatalf("parseJSONTemplateBlock: %v", err)
+	}
+	return tpl
+}
+
+func renderJSONTemplateForTest(t *testing.T, tpl *JSONTemplate, meta *dslmeta.Meta, in string) string {
+	t.Helper()
+	var root map[string]any
+	if err := json.Unmarshal([]byte(in), &root); err != nil {
+		t.Fatalf("unmarshal input: %v", err)
+	}
+	out, err := tpl.Render(meta, root)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("marshal output: %v", err)
+	}
+	return string(b)
+}
+
+func TestJSONTemplate_RenderCohereChat(t *testing.T) {
+	tpl := mustParseJSONTemplate(t, `req_template {
+  # Cohere v1 chat
+  "model": {"$expr": "$request.model_mapped"},
+  "message": "$.messages[1].content",
+  "chat_history": {
+    "$for": "$.messages",
+    "$each": {"role": {"$path": "@.role", "$as": "string"}, "message": "@.content"}
+  },
+  "temperature": {"$path": "$.temperature", "$default": 0.3},
+  "max_tokens": {"$path": ["$.max_completion_tokens", "$.max_tokens"], "$as": "integer"},
+  "stop_sequences": "$.stop",
+  "stream": false,
+  "tags": ["onr", "$.user"],
+  "raw": {"$literal": "$.not_a_path"}
+}`)
+	meta := &dslmeta.Meta{OriginModelName: "command-r", DSLModelMapped: "command-r-plus"}
+	got := renderJSONTemplateForTest(t, tpl, meta, `{
+  "model": "command-r",
+  "messages": [{"role": "system", "content": "be brief"}, {"role": "user", "content": "hi"}],
+  "max_tokens": "128"
+}`)
+	want := `{"chat_history":[{"message":"be brief","role":"system"},{"message":"hi","role":"user"}],"max_tokens":128,"message":"hi","model":"command-r-plus","raw":"$.not_a_path","stream":false,"tags":["onr"],"temperature":0.3}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONTemplate_WildcardProjectionAndJSONCoercion(t *testing.T) {
+	tpl := mustParseJSONTemplate(t, `resp_template {
+  "texts": "$.output[*].text",
+  "args": {"$path": "$.call.arguments", "$as": "json"},
+  "echo": {"$path": "$.call", "$as": "json_string"}
+}`)
+	got := renderJSONTemplateForTest(t, tpl, nil, `{"output":[{"text":"a"},{"text":"b"}],"call":{"arguments":"{\"x\":1}"}}`)
+	want := `{"args":{"x":1},"echo":"{\"arguments\":\"{\\\"x\\\":1}\"}","texts":["a","b"]}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONTemplate_RequiredMissing(t *testing.T) {
+	tpl := mustParseJSONTemplate(t, `req_template { "query": {"$path": "$.input", "$required": true} }`)
+	_, err := tpl.Render(nil, map[string]any{"prompt": "x"})
+	if !errors.Is(err, ErrTemplateRequired) || !strings.Contains(err.Error(), "$.query") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestJSONTemplate_ParseErrors(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want string
+	}{
+		"invalid_json":       {body: `req_template { "a": }`, want: "not a valid JSON object"},
+		"unknown_directive":  {body: `req_template { "a": {"$pth": "$.x"} }`, want: `unknown template directive "$pth"`},
+		"unknown_option":     {body: `req_template { "a": {"$path": "$.x", "$defualt": 1} }`, want: `unknown $path option "$defualt"`},
+		"for_without_each":   {body: `req_template { "a": {"$for": "$.x"} }`, want: "$each is required"},
+		"bad_as":             {body: `req_template { "a": {"$path": "$.x", "$as": "float"} }`, want: "unsupported $as"},
+		"bad_expr":           {body: `req_template { "a": {"$expr": "$nope.value"} }`, want: "$expr"},
+		"required_default":   {body: `req_template { "a": {"$path": "$.x", "$required": true, "$default": 1} }`, want: "$default cannot be combined"},
+		"combined":           {body: `req_template { "a": {"$path": "$.x", "$expr": "\"v\""} }`, want: "cannot be combined"},
+		"bad_sample_option":  {body: `req_template file="x.json" { }`, want: `expected '{' or sample=`},
+		"unterminated_block": {body: `req_template { "a": 1`, want: "unterminated req_template block"},
+		"negative_index":     {body: `req_template { "a": "$.messages[-1].content" }`, want: "invalid template path"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := newScanner("test.conf", tc.body)
+			tok := s.nextNonTrivia()
+			_, err := parseJSONTemplateBlock(s, tok.text)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err=%v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+const testTemplateProvider = `
+syntax "next-router/0.1";
+
+provider "cohere" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.cohere.example";
+    }
+    auth {
+      auth_bearer;
+    }
+  }
+
+  match api = "chat.completions" stream = false {
+    request {
+      req_template sample="samples/chat_request.json" {
+        "model": "$.model",
+        "message": {"$path": "$.messages[0].content", "$required": true}
+      }
+      after_req_map {
+        json_set "$.source" "onr";
+      }
+    }
+    upstream {
+      set_path "/v1/chat";
+    }
+    response {
+      resp_template sample="samples/chat_response.json" {
+        "object": "chat.completion",
+        "choices": [{"index": 0, "message": {"role": "assistant", "content": {"$path": "$.text", "$required": true}}}]
+      }
+    }
+  }
+}
+`
+
+func TestJSONTemplate_ProviderSampleValidation(t *testing.T) {
+	files := map[string]string{
+		"cohere.conf":                testTemplateProvider,
+		"samples/chat_request.json":  `{"model":"command-r","messages":[{"role":"user","content":"hi"}]}`,
+		"samples/chat_response.json": `{"text":"hello"}`,
+	}
+	dir := writeExtendsFixture(t, files)
+	if _, err := ValidateProvidersDir(dir); err != nil {
+		t.Fatalf("ValidateProvidersDir: %v", err)
+	}
+	reg := NewRegistry()
+	if _, err := reg.ReloadFromDir(dir); err != nil {
+		t.Fatalf("ReloadFromDir: %v", err)
+	}
+	pf, ok := reg.GetProvider("cohere")
+	if !ok {
+		t.Fatalf("cohere not loaded")
+	}
+	meta := &dslmeta.Meta{API: "chat.completions"}
+	req, ok := pf.Request.Select(meta)
+	if !ok || req.ReqMapMode != ReqMapModeTemplate || req.ReqTemplate == nil || len(req.AfterReqMapJSONOps) != 1 {
+		t.Fatalf("request transform=%#v", req)
+	}
+	resp, ok := pf.Response.Select(meta)
+	if !ok || resp.Op != "resp_map" || resp.Mode != RespMapModeTemplate || resp.Template == nil {
+		t.Fatalf("response directive=%#v", resp)
+	}
+	if !reflect.DeepEqual(resp.Template.SamplePath, "samples/chat_response.json") {
+		t.Fatalf("sample path=%q", resp.Template.SamplePath)
+	}
+
+	files["samples/chat_response.json"] = `{"message":"hello"}`
+	dir = writeExtendsFixture(t, files)
+	_, err := ValidateProvidersDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "resp_template sample") || !strings.Contains(err.Error(), "$.text") {
+		t.Fatalf("expected resp_template sample error, got %v", err)
+	}
+
+	delete(files, "samples/chat_request.json")
+	files["samples/chat_response.json"] = `{"text":"hello"}`
+	dir = writeExtendsFixture(t, files)
+	_, err = ValidateProvidersDir(filepath.Clean(dir))
+	if err == nil || !strings.Contains(err.Error(), "req_template sample") {
+		t.Fatalf("expected missing req_template sample error, got %v", err)
+	}
+}
@@ -11,6 +11,8 @@ type modeRegistryState struct {
 	modelsPaths       map[string]string
 	balance           balanceModeRegistry
 	balancePaths      map[string]string
+	maps              varMapRegistry
+	mapsPaths         map[string]string
 }
 
 func newModeRegistryState() modeRegistryState {
@@ -23,6 +25,8 @@ func newModeRegistryState() modeRegistryState {
 		modelsPaths:       map[string]string{},
 		balance:           balanceModeRegistry{},
 		balancePaths:      map[string]string{},
+		maps:              varMapRegistry{},
+		mapsPaths:         map[string]string{},
 	}
 }
 
@@ -44,6 +48,10 @@ func loadGlobalModeRegistryState(path string) (modeRegistryState, string, error)
 	if err != nil {
 		return modeRegistryState{}, "", err
 	}
+	maps, mapsPaths, _, err := loadGlobalVarMapsFromFile(path)
+	if err != nil {
+		return modeRegistryState{}, "", err
+	}
 	if usage != nil {
 		state.usage = usage
 	}
@@ -68,6 +76,12 @@ func loadGlobalModeRegistryState(path string) (modeRegistryState, string, error)
 	if balancePaths != nil {
 		state.balancePaths = balancePaths
 	}
+	if maps != nil {
+		state.maps = maps
+	}
+	if mapsPaths != nil {
+		state.mapsPaths = mapsPaths
+	}
 	return state, globalContent, nil
 }
 
@@ -90,6 +104,10 @@ func parseLocalModeRegistryState(path string, content string) (modeRegistryState
 	if err != nil {
 		return modeRegistryState{}, err
 	}
+	state.maps, err = parseGlobalVarMaps(path, content)
+	if err != nil {
+		return modeRegistryState{}, err
+	}
 	return state, nil
 }
 
@@ -119,6 +137,12 @@ func (s modeRegistryState) clone() modeRegistryState {
 	for name, path := range s.balancePaths {
 		cloned.balancePaths[name] = path
 	}
+	for name, m := range s.maps {
+		cloned.maps[name] = m
+	}
+	for name, path := range s.mapsPaths {
+		cloned.mapsPaths[name] = path
+	}
 	return cloned
 }
 
@@ -151,6 +175,13 @@ func (s *modeRegistryState) merge(path string, local modeRegistryState) error {
 		s.balance[name] = cfg
 		s.balancePaths[name] = path
 	}
+	for name, m := range local.maps {
+		if prev, ok := s.mapsPaths[name]; ok {
+			return fmt.Errorf("duplicate map variable $%s in %q (already in %q)", name, path, prev)
+		}
+		s.maps[name] = m
+		s.mapsPaths[name] = path
+	}
 	return nil
 }
 
@@ -180,5 +211,7 @@ func (s modeRegistryState) resolve() (modeRegistryState, error) {
 	resolved.modelsPaths = s.modelsPaths
 	resolved.balance = balance
 	resolved.balancePaths = s.balancePaths
+	resolved.maps = s.maps
+	resolved.mapsPaths = s.mapsPaths
 	return resolved, nil
 }
@@ -0,0 +1,294 @@
+package dslconfig
+
+import (
+	"regexp"
+	"strings"
+)
+
+// parseProviderGuardFromContent collects guard blocks in a separate pass, like metadata,
+// so the phase parsers keep skipping the block as an unknown directive.
+func parseProviderGuardFromContent(path string, content string, providerName string) (ProviderGuard, error) {
+	s := newScanner(path, content)
+	want := normalizeProviderName(providerName)
+	for {
+		tok := s.nextNonTrivia()
+		if tok.kind == tokEOF {
+			return ProviderGuard{}, nil
+		}
+		if tok.kind != tokIdent || tok.text != providerKeyword {
+			continue
+		}
+		nameTok := s.nextNonTrivia()
+		if nameTok.kind != tokString {
+			return ProviderGuard{}, s.errAt(nameTok, "expected provider name string literal")
+		}
+		lb := s.nextNonTrivia()
+		if lb.kind != tokLBrace {
+			return ProviderGuard{}, s.errAt(lb, "expected '{' after provider name")
+		}
+		if normalizeProviderName(unquoteString(nameTok.text)) != want {
+			if err := skipBalancedBraces(s); err != nil {
+				return ProviderGuard{}, err
+			}
+			continue
+		}
+		return parseProviderGuardBody(s)
+	}
+}
+
+func parseProviderGuardBody(s *scanner) (ProviderGuard, error) {
+	var g ProviderGuard
+	for {
+		tok := s.nextNonTrivia()
+		switch tok.kind {
+		case tokEOF:
+			return ProviderGuard{}, s.errAt(tok, "unexpected EOF in provider block")
+		case tokRBrace:
+			return g, nil
+		case tokIdent:
+			switch tok.text {
+			case "defaults":
+				lb := s.nextNonTrivia()
+				if lb.kind != tokLBrace {
+					return ProviderGuard{}, s.errAt(lb, "expected '{' after defaults")
+				}
+				if err := parseGuardContainer(s, "defaults block", &g.Defaults); err != nil {
+					return ProviderGuard{}, err
+				}
+			case "match":
+				m := parseGuardMatchHeader(s)
+				if err := parseGuardContainer(s, "match block", &m.Guard); err != nil {
+					return ProviderGuard{}, err
+				}
+				g.Matches = append(g.Matches, m)
+			default:
+				if err := skipStmtOrBlock(s); err != nil {
+					return ProviderGuard{}, err
+				}
+			}
+		default:
+			// ignore
+		}
+	}
+}
+
+// parseGuardMatchHeader reads the match header up to '{'. Header errors are reported by the main parser.
+func parseGuardMatchHeader(s *scanner) MatchGuard {
+	var m MatchGuard
+	for {
+		tok := s.nextNonTrivia()
+		if tok.kind == tokEOF || tok.kind == tokLBrace {
+			return m
+		}
+		if tok.kind != tokIdent {
+			continue
+		}
+		op := s.nextNonTrivia()
+		if op.kind != tokOther || (op.text != "=" && op.text != "!=") {
+			continue
+		}
+		valTok := s.nextNonTrivia()
+		switch tok.text {
+		case "api":
+			if valTok.kind == tokString {
+				m.API = strings.TrimSpace(unquoteString(valTok.text))
+			}
+		case "stream":
+			if valTok.kind == tokIdent && (valTok.text == "true" || valTok.text == "false") {
+				v := valTok.text == "true"
+				m.Stream = &v
+			}
+		}
+	}
+}
+
+// parseGuardContainer scans a defaults/match body for guard blocks and skips everything else.
+func parseGuardContainer(s *scanner, blockName string, cfg *GuardConfig) error {
+	for {
+		tok := s.nextNonTrivia()
+		switch tok.kind {
+		case tokEOF:
+			return s.errAt(tok, "unexpected EOF in "+blockName)
+		case tokRBrace:
+			return nil
+		case tokIdent:
+			if tok.text == "guard" {
+				if err := parseGuardPhase(s, cfg); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := skipStmtOrBlock(s); err != nil {
+				return err
+			}
+		default:
+			// ignore
+		}
+	}
+}
+
+func parseGuardPhase(s *scanner, cfg *GuardConfig) error {
+	lb := s.nextNonTrivia()
+	if lb.kind != tokLBrace {
+		return s.errAt(lb, "expected '{' after guard")
+	}
+	for {
+		tok := s.nextNonTrivia()
+		switch tok.kind {
+		case tokEOF:
+			return s.errAt(tok, "unexpected EOF in guard block")
+		case tokRBrace:
+			return nil
+		case tokIdent:
+			var (
+				rule GuardRule
+				err  error
+			)
+			switch tok.text {
+			case "guard_builtin":
+				rule, err = parseGuardBuiltinStmt(s)
+			case "guard_regex":
+				rule, err = parseGuardRegexStmt(s)
+			case "guard_keywords":
+				rule, err = parseGuardKeywordsStmt(s)
+			default:
+				return s.errAt(tok, "unknown guard directive "+tok.text)
+			}
+			if err != nil {
+				return err
+			}
+			for _, existing := range cfg.Rules {
+				if existing.Name == rule.Name {
+					return s.errAt(tok, "duplicate guard rule "+rule.Name)
+				}
+			}
+			cfg.Rules = append(cfg.Rules, rule)
+		default:
+			return s.errAt(tok, "unexpected token in guard block")
+		}
+	}
+}
+
+func parseGuardBuiltinStmt(s *scanner) (GuardRule, error) {
+	const directive = "guard_builtin"
+	words, err := collectStmtWords(s, directive)
+	if err != nil {
+		return GuardRule{}, err
+	}
+	if len(words) == 0 || words[0].quoted {
+		return GuardRule{}, s.errAt(token{pos: s.lastPos}, directive+" expects: guard_builtin <email|card_number|api_key> [action=...] [on=...];")
+	}
+	name := strings.TrimSpace(words[0].text)
+	pattern, ok := guardBuiltinPatterns[name]
+	if !ok {
+		return GuardRule{}, s.errAt(token{pos: words[0].pos}, directive+" unknown detector "+name+" (expected email, card_number or api_key)")
+	}
+	rule := GuardRule{
+		Name:    name,
+		Kind:    GuardKindBuiltin,
+		Pattern: name,
+		Regexp:  regexp.MustCompile(pattern),
+		Luhn:    name == GuardBuiltinCardNumber,
+	}
+	if err := parseGuardOptions(s, directive, words[1:], &rule); err != nil {
+		return GuardRule{}, err
+	}
+	return rule, nil
+}
+
+func parseGuardRegexStmt(s *scanner) (GuardRule, error) {
+	const directive = "guard_regex"
+	words, err := collectStmtWords(s, directive)
+	if err != nil {
+		return GuardRule{}, err
+	}
+	if len(words) < 2 || words[0].quoted || !words[1].quoted {
+		return GuardRule{}, s.errAt(token{pos: s.lastPos}, directive+" expects: guard_regex <name> \"<pattern>\" [action=...] [on=...];")
+	}
+	rule := GuardRule{Name: strings.TrimSpace(words[0].text), Kind: GuardKindRegex, Pattern: words[1].text}
+	if rule.Pattern == "" {
+		return GuardRule{}, s.errAt(token{pos: words[1].pos}, directive+" requires a non-empty pattern")
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return GuardRule{}, s.errAt(token{pos: words[1].pos}, directive+" invalid pattern: "+err.Error())
+	}
+	rule.Regexp = re
+	if err := parseGuardOptions(s, directive, words[2:], &rule); err != nil {
+		return GuardRule{}, err
+	}
+	return rule, nil
+}
+
+func parseGuardKeywordsStmt(s *scanner) (GuardRule, error) {
+	const directive = "guard_keywords"
+	words, err := collectStmtWords(s, directive)
+	if err != nil {
+		return GuardRule{}, err
+	}
+	if len(words) < 2 || words[0].quoted {
+		return GuardRule{}, s.errAt(token{pos: s.lastPos}, directive+" expects: guard_keywords <name> \"<keyword>\"... [action=...] [on=...];")
+	}
+	rule := GuardRule{Name: strings.TrimSpace(words[0].text), Kind: GuardKindKeywords}
+	rest := words[1:]
+	for len(rest) > 0 && rest[0].quoted {
+		if strings.TrimSpace(rest[0].text) == "" {
+			return GuardRule{}, s.errAt(token{pos: rest[0].pos}, directive+" keywords must be non-empty")
+		}
+		rule.Keywords = append(rule.Keywords, rest[0].text)
+		rest = rest[1:]
+	}
+	if len(rule.Keywords) == 0 {
+		return GuardRule{}, s.errAt(token{pos: words[1].pos}, directive+" requires at least one quoted keyword")
+	}
+	re, err := compileGuardKeywords(rule.Keywords)
+	if err != nil {
+		return GuardRule{}, s.errAt(token{pos: words[1].pos}, directive+" invalid keywords: "+err.Error())
+	}
+	rule.Regexp = re
+	if err := parseGuardOptions(s, directive, rest, &rule); err != nil {
+		return GuardRule{}, err
+	}
+	return rule, nil
+}
+
+// parseGuardOptions applies action= and on= and fills their defaults (action=log, on=request).
+func parseGuardOptions(s *scanner, directive string, words []stmtWord, rule *GuardRule) error {
+	for _, w := range words {
+		key, value, ok := splitStmtKV(w)
+		if !ok {
+			return s.errAt(token{pos: w.pos}, directive+" only accepts action=reject|redact|log and on=request|response|both")
+		}
+		switch key {
+		case "action":
+			switch value {
+			case GuardActionReject, GuardActionRedact, GuardActionLog:
+				rule.Action = value
+			default:
+				return s.errAt(token{pos: w.pos}, directive+" action expects reject, redact or log")
+			}
+		case "on":
+			switch value {
+			case GuardScopeRequest, GuardScopeResponse, GuardScopeBoth:
+				rule.Scope = value
+			default:
+				return s.errAt(token{pos: w.pos}, directive+" on expects request, response or both")
+			}
+		default:
+			return s.errAt(token{pos: w.pos}, directive+" unknown option "+key)
+		}
+	}
+	if rule.Action == "" {
+		rule.Action = GuardActionLog
+	}
+	if rule.Scope == "" {
+		rule.Scope = GuardScopeRequest
+	}
+	if rule.Name == "" {
+		return s.errAt(token{pos: s.lastPos}, directive+" requires a rule name")
+	}
+	if rule.Action == GuardActionReject && rule.Scope != GuardScopeRequest {
+		return s.errAt(token{pos: s.lastPos}, directive+" action=reject is only supported on=request")
+	}
+	return nil
+}
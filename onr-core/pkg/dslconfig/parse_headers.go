@@ -172,6 +172,7 @@ func requestPhaseHandlers() map[string]requestPhaseHandler {
 		"json_clamp":              requestTransformHandler(parseJSONClampStmt),
 		"after_req_map":           requestTransformHandler(parseAfterReqMapBlock),
 		"req_map":                 requestTransformHandler(parseRequestMapStmt),
+		"req_template":            requestTransformHandler(parseRequestTemplateBlock),
 		"req_required":            requestTransformHandler(parseReqRequiredStmt),
 		"req_forbid":              requestTransformHandler(parseReqForbidStmt),
 		"req_type":                requestTransformHandler(parseReqTypeStmt),
@@ -222,6 +223,17 @@ func parseRequestMapStmt(s *scanner, transform *RequestTransform) error {
 		return err
 	}
 	transform.ReqMapMode = mode
+	transform.ReqTemplate = nil
+	return nil
+}
+
+func parseRequestTemplateBlock(s *scanner, transform *RequestTransform) error {
+	tpl, err := parseJSONTemplateBlock(s, "req_template")
+	if err != nil {
+		return err
+	}
+	transform.ReqMapMode = ReqMapModeTemplate
+	transform.ReqTemplate = tpl
 	return nil
 }
 
@@ -109,6 +109,7 @@ func parseDefaultsBlock(s *scanner, routing *ProviderRouting, headers *ProviderH
 	}
 	return parsePhaseBlock(s, "defaults block", phaseHandlers{
 		upstreamConfig: func() error { return parseUpstreamConfigBlock(s, routing) },
+		upstream:       func() error { return parseDefaultsUpstreamPhase(s, routing) },
 		auth:           func() error { return parseAuthPhase(s, &headers.Defaults) },
 		request:        func() error { return parseRequestPhaseWithTransform(s, &headers.Defaults, &req.Defaults) },
 		response:       func() error { return parseResponsePhase(s, &response.Defaults) },
@@ -237,7 +238,7 @@ func parseMatchBlock(s *scanner) (RoutingMatch, MatchHeaders, MatchRequestTransf
 
 func parseMatchBody(s *scanner, m *RoutingMatch, h *MatchHeaders, req *MatchRequestTransform, r *MatchResponse, e *MatchError, u *MatchUsage, fr *MatchFinishReason) error {
 	return parsePhaseBlock(s, "match block", phaseHandlers{
-		upstream:  func() error { return parseUpstreamPhase(s, m) },
+		upstream:  func() error { return parseUpstreamPhase(s, m, false) },
 		auth:      func() error { return parseAuthPhase(s, &h.Headers) },
 		request:   func() error { return parseRequestPhaseWithTransform(s, &h.Headers, &req.Transform) },
 		response:  func() error { return parseResponsePhase(s, &r.Response) },
@@ -852,7 +853,18 @@ func parseUsageFactBoolValue(s *scanner, tok token) (bool, error) {
 	return false, s.errAt(tok, "fallback expects true or false")
 }
 
-func parseUpstreamPhase(s *scanner, m *RoutingMatch) error {
+// parseDefaultsUpstreamPhase parses `defaults { upstream { ... } }`, which only accepts
+// timeout directives: path and query rewrites stay per match.
+func parseDefaultsUpstreamPhase(s *scanner, routing *ProviderRouting) error {
+	var m RoutingMatch
+	if err := parseUpstreamPhase(s, &m, true); err != nil {
+		return err
+	}
+	routing.Timeouts = m.Timeouts
+	return nil
+}
+
+func parseUpstreamPhase(s *scanner, m *RoutingMatch, defaults bool) error {
 	lb := s.nextNonTrivia()
 	if lb.kind != tokLBrace {
 		return s.errAt(lb, "expected '{' after upstream")
@@ -865,6 +877,18 @@ func parseUpstreamPhase(s *scanner, m *RoutingMatch) error {
 		case tokRBrace:
 			return nil
 		case tokIdent:
+			if out, ok := upstreamTimeoutField(&m.Timeouts, tok.text); ok {
+				if err := parseUpstreamTimeoutStmt(s, tok.text, out); err != nil {
+					return err
+				}
+				continue
+			}
+			if defaults {
+				switch tok.text {
+				case "set_path", "set_query", "del_query":
+					return s.errAt(tok, tok.text+" is only allowed in match upstream blocks; defaults upstream accepts timeout directives only")
+				}
+			}
 			switch tok.text {
 			case "set_path":
 				if err := parseDirectiveSetPathStmt(s, m); err != nil {
@@ -891,6 +915,39 @@ func parseUpstreamPhase(s *scanner, m *RoutingMatch) error {
 	}
 }
 
+func upstreamTimeoutField(t *UpstreamTimeouts, directive string) (*int, bool) {
+	switch directive {
+	case "timeout_ms":
+		return &t.TimeoutMs, true
+	case "connect_timeout_ms":
+		return &t.ConnectTimeoutMs, true
+	case "first_byte_timeout_ms":
+		return &t.FirstByteTimeoutMs, true
+	case "stream_idle_timeout_ms":
+		return &t.StreamIdleTimeoutMs, true
+	default:
+		return nil, false
+	}
+}
+
+func parseUpstreamTimeoutStmt(s *scanner, directive string, out *int) error {
+	// <directive> <positive-int>;
+	tok := s.nextNonTrivia()
+	if tok.kind == tokOther && tok.text == "=" {
+		return s.errAt(tok, directive+" does not use '='; use: "+directive+" <int>;")
+	}
+	expr, err := consumeExprUntilSemicolonWithFirst(s, tok)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.Trim(expr, `"`)))
+	if err != nil || n <= 0 {
+		return s.errAt(tok, directive+" expects a positive integer (milliseconds)")
+	}
+	*out = n
+	return nil
+}
+
 func parseDirectiveSetPathStmt(s *scanner, m *RoutingMatch) error {
 	// set_path <expr>;
 	first := s.nextNonTrivia()
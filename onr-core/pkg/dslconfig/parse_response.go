@@ -14,6 +14,7 @@ func parseResponsePhase(s *scanner, resp *ResponseDirective) error {
 	handlers := map[string]func(*scanner, *ResponseDirective) error{
 		"resp_passthrough":   parseRespPassthrough,
 		"resp_map":           parseRespMap,
+		"resp_template":      parseRespTemplate,
 		"sse_parse":          parseSSEParse,
 		"sse_collect":        parseSSECollect,
 		jsonOpSet:            func(s *scanner, r *ResponseDirective) error { return parseRespJSONSetStmt(s, r, jsonOpSet) },
@@ -56,6 +57,7 @@ func parseRespPassthrough(s *scanner, resp *ResponseDirective) error {
 	}
 	resp.Op = "resp_passthrough"
 	resp.Mode = ""
+	resp.Template = nil
 	return nil
 }
 
@@ -66,6 +68,18 @@ func parseRespMap(s *scanner, resp *ResponseDirective) error {
 	}
 	resp.Op = "resp_map"
 	resp.Mode = mode
+	resp.Template = nil
+	return nil
+}
+
+func parseRespTemplate(s *scanner, resp *ResponseDirective) error {
+	tpl, err := parseJSONTemplateBlock(s, "resp_template")
+	if err != nil {
+		return err
+	}
+	resp.Op = "resp_map"
+	resp.Mode = RespMapModeTemplate
+	resp.Template = tpl
 	return nil
 }
 
@@ -76,6 +90,7 @@ func parseSSEParse(s *scanner, resp *ResponseDirective) error {
 	}
 	resp.Op = "sse_parse"
 	resp.Mode = mode
+	resp.Template = nil
 	return nil
 }
 
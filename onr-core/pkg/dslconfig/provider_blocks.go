@@ -9,7 +9,9 @@ import (
 )
 
 type ProviderBlock struct {
-	Name    string
+	Name string
+	// Extends is the parent provider name of `provider "x" extends "y" { ... }`, if any.
+	Extends string
 	Start   int
 	End     int
 	Content string
@@ -110,7 +112,10 @@ func scanProviderBlock(s *scanner, providerTok token, content string) (ProviderB
 	if nameTok.kind != tokString {
 		return ProviderBlock{}, s.errAt(nameTok, "expected string literal after provider")
 	}
-	lb := s.nextNonTrivia()
+	extends, lb, err := scanProviderExtends(s)
+	if err != nil {
+		return ProviderBlock{}, err
+	}
 	if lb.kind != tokLBrace {
 		return ProviderBlock{}, s.errAt(lb, "expected '{' after provider name")
 	}
@@ -134,6 +139,7 @@ func scanProviderBlock(s *scanner, providerTok token, content string) (ProviderB
 	name := NormalizeProviderName(unquoteString(nameTok.text))
 	return ProviderBlock{
 		Name:    name,
+		Extends: extends,
 		Start:   providerTok.pos,
 		End:     end,
 		Content: content[providerTok.pos:end],
@@ -0,0 +1,529 @@
+package dslconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// providerBaseDir is the providers subdirectory searched for parent providers that are
+// not loadable on their own (for example a shared "openai-compatible" definition without
+// base_url). Directory loaders never scan subdirectories, so bases there are only used
+// through extends.
+const providerBaseDir = "base"
+
+// Provider inheritance is resolved on source text right after include expansion, so
+// every loader, validator and bundler sees a flattened provider block:
+//
+//	provider "groq" extends "openai-compatible" { ... }
+//
+// Merge rules (child over parent):
+//   - defaults and metadata: merged per directive, recursively through phases
+//     (upstream_config, auth, request, ...). A child directive replaces the inherited
+//     directive with the same key in place (see itemKey); other inherited directives are
+//     kept and new ones are appended.
+//   - match: a child match with the same header (api/stream conditions) replaces the
+//     parent match in place; other child matches are placed before the inherited ones
+//     so they take precedence (first match wins).
+//   - other blocks (map, ...): a child block replaces the parent block.
+//
+// The flattened block keeps child items on their source lines (see extendsRenderer), so
+// diagnostics keep pointing at the child file.
+
+// scanProviderExtends consumes an optional `extends "<name>"` clause after the provider
+// name and returns the parent name together with the next significant token.
+func scanProviderExtends(s *scanner) (string, token, error) {
+	tok := s.nextNonTrivia()
+	if tok.kind != tokIdent || tok.text != "extends" {
+		return "", tok, nil
+	}
+	parentTok := s.nextNonTrivia()
+	if parentTok.kind != tokString {
+		return "", token{}, s.errAt(parentTok, "expected parent provider name string literal after extends")
+	}
+	parent := normalizeProviderName(unquoteString(parentTok.text))
+	if err := validateProviderName(parent); err != nil {
+		return "", token{}, s.errAt(parentTok, fmt.Sprintf("invalid parent provider name: %v", err))
+	}
+	return parent, s.nextNonTrivia(), nil
+}
+
+// resolveProviderExtends replaces every provider block declaring extends with its
+// flattened equivalent. Content without extends is returned unchanged.
+func resolveProviderExtends(path string, content string, chain map[string]bool) (string, error) {
+	blocks, err := ListProviderBlocks(path, content)
+	if err != nil {
+		return "", err
+	}
+	hasExtends := false
+	local := make(map[string]ProviderBlock, len(blocks))
+	for _, block := range blocks {
+		if block.Extends != "" {
+			hasExtends = true
+		}
+		if _, ok := local[block.Name]; !ok {
+			local[block.Name] = block
+		}
+	}
+	if !hasExtends {
+		return content, nil
+	}
+	var out strings.Builder
+	cursor := 0
+	for _, block := range blocks {
+		if block.Extends == "" {
+			continue
+		}
+		flat, err := flattenProviderBlock(path, block, local, chain)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(content[cursor:block.Start])
+		out.WriteString(flat)
+		cursor = block.End
+	}
+	out.WriteString(content[cursor:])
+	return out.String(), nil
+}
+
+func flattenProviderBlock(path string, block ProviderBlock, local map[string]ProviderBlock, chain map[string]bool) (string, error) {
+	if block.Extends == "" {
+		return block.Content, nil
+	}
+	if chain[block.Name] {
+		return "", fmt.Errorf("provider %q in %q: extends cycle detected", block.Name, path)
+	}
+	chain[block.Name] = true
+	defer delete(chain, block.Name)
+
+	parent, err := loadParentProviderBlock(path, block, local, chain)
+	if err != nil {
+		return "", err
+	}
+	parentItems, err := splitProviderBlockItems(path, parent, false)
+	if err != nil {
+		return "", err
+	}
+	childItems, err := splitProviderBlockItems(path, block.Content, true)
+	if err != nil {
+		return "", err
+	}
+	merged, err := mergeProviderItems(path, block.Content, parentItems, childItems)
+	if err != nil {
+		return "", err
+	}
+	return renderProviderBlock(block, merged), nil
+}
+
+// loadParentProviderBlock finds the parent in the same (include-expanded) content first,
+// then in <dir>/<parent>.conf and <dir>/base/<parent>.conf, and returns it flattened.
+func loadParentProviderBlock(path string, block ProviderBlock, local map[string]ProviderBlock, chain map[string]bool) (string, error) {
+	if parent, ok := local[block.Extends]; ok && parent.Name != block.Name {
+		return flattenProviderBlock(path, parent, local, chain)
+	}
+	dir := filepath.Dir(path)
+	candidates := []string{
+		filepath.Join(dir, block.Extends+providerConfExt),
+		filepath.Join(dir, providerBaseDir, block.Extends+providerConfExt),
+	}
+	for _, candidate := range candidates {
+		if filepath.Clean(candidate) == filepath.Clean(path) {
+			continue
+		}
+		// #nosec G304 -- parent providers are resolved next to the provider file being loaded.
+		b, err := os.ReadFile(candidate)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", fmt.Errorf("read parent provider file %q (from %q): %w", candidate, path, err)
+		}
+		expanded, err := preprocessIncludesInner(candidate, string(b), map[string]bool{}, 0)
+		if err != nil {
+			return "", err
+		}
+		blocks, err := ListProviderBlocks(candidate, expanded)
+		if err != nil {
+			return "", err
+		}
+		parentLocal := make(map[string]ProviderBlock, len(blocks))
+		for _, pb := range blocks {
+			if _, ok := parentLocal[pb.Name]; !ok {
+				parentLocal[pb.Name] = pb
+			}
+		}
+		parent, ok := parentLocal[block.Extends]
+		if !ok {
+			return "", fmt.Errorf("provider %q in %q: parent file %q does not declare provider %q", block.Name, path, candidate, block.Extends)
+		}
+		return flattenProviderBlock(candidate, parent, parentLocal, chain)
+	}
+	return "", fmt.Errorf("provider %q in %q extends unknown provider %q (looked in the same file, %s)", block.Name, path, block.Extends, strings.Join(candidates, ", "))
+}
+
+// providerItem is one statement or block inside a provider body or a directive block.
+type providerItem struct {
+	name string
+	// key identifies the item for overriding, see itemKey.
+	key  string
+	text string
+	// pos is the byte offset of text inside the child provider block being flattened, or
+	// -1 for items inherited from the parent.
+	pos int
+	// open is the offset of '{' within text (blocks only).
+	open int
+	body string
+	// bodyPos is the byte offset of body inside the child provider block, or -1.
+	bodyPos int
+	// isBlock is false for `name ...;` statements.
+	isBlock bool
+	// children holds the per-directive merge result of a block present in both parent and
+	// child; the block is then re-rendered from its children instead of text.
+	children []providerItem
+	merged   bool
+}
+
+// directiveBlocks are blocks whose bodies are directive lists and therefore merged per
+// directive. Other blocks (req_template, resp_template, ...) are replaced as a whole.
+var directiveBlocks = map[string]bool{
+	"defaults":        true,
+	"upstream_config": true,
+	"upstream":        true,
+	"auth":            true,
+	"request":         true,
+	"after_req_map":   true,
+	"response":        true,
+	"error":           true,
+	"metrics":         true,
+	"balance":         true,
+	"models":          true,
+	"guard":           true,
+	"metadata":        true,
+}
+
+// exclusiveDirectives are alternatives of which a phase keeps at most one: a child
+// `auth_header_key` replaces an inherited `auth_bearer`.
+var exclusiveDirectives = map[string]string{
+	"auth_bearer":        "auth_*",
+	"auth_header_key":    "auth_*",
+	"auth_oauth_bearer":  "auth_*",
+	"auth_sigv4_bedrock": "auth_*",
+	"req_map":            "req_map|req_template",
+	"req_template":       "req_map|req_template",
+	"resp_passthrough":   "resp_passthrough|resp_map|resp_template",
+	"resp_map":           "resp_passthrough|resp_map|resp_template",
+	"resp_template":      "resp_passthrough|resp_map|resp_template",
+}
+
+// repeatableDirectives may appear several times in one phase. The value is the number of
+// leading arguments that identify one occurrence (a header name, a JSON path, ...);
+// 0 means the whole statement is its identity.
+var repeatableDirectives = map[string]int{
+	"set_header":              1,
+	"del_header":              1,
+	"pass_header":             1,
+	"filter_header_values":    1,
+	"keep_header_values":      1,
+	"set_query":               1,
+	"del_query":               1,
+	"oauth_form":              1,
+	"model_map":               1,
+	"json_set":                1,
+	"json_replace":            1,
+	"json_set_if_absent":      1,
+	"json_del":                1,
+	"json_del_if_missing":     1,
+	"json_del_with_condition": 1,
+	"json_rename":             1,
+	"json_wrap_input_text":    1,
+	"json_set_header_values":  1,
+	"json_filter_values":      1,
+	"json_keep_values":        1,
+	"json_clamp":              1,
+	"json_map_value":          2,
+	"req_enum":                2,
+	"req_forbid":              2,
+	"req_len":                 2,
+	"req_range":               2,
+	"req_required":            2,
+	"req_type":                2,
+	"usage_fact":              2,
+	"guard_builtin":           1,
+	"guard_keywords":          1,
+	"guard_regex":             1,
+	"usage_root":              0,
+	"error_when":              0,
+	"id_path":                 0,
+	"sse_json_del_if":         0,
+}
+
+// itemKey returns the override identity of an item: the normalized header for blocks
+// (`match api = "chat.completions" stream = true`), the directive name for single-valued
+// statements and the name plus its identifying arguments for repeatable ones.
+func itemKey(name string, tokens []string, args []string, isBlock bool) string {
+	if group, ok := exclusiveDirectives[name]; ok {
+		return group
+	}
+	if isBlock {
+		return strings.Join(tokens, " ")
+	}
+	n, ok := repeatableDirectives[name]
+	if !ok {
+		return name
+	}
+	if n == 0 || n > len(args) {
+		n = len(args)
+	}
+	return strings.Join(append([]string{name}, args[:n]...), " ")
+}
+
+// splitProviderBlockItems returns the items of a `provider "x" [extends "y"] { ... }` block.
+// Positions are recorded only for the child block (fromChild), so its items can be
+// rendered back on their original lines.
+func splitProviderBlockItems(path string, providerBlock string, fromChild bool) ([]providerItem, error) {
+	open := strings.Index(providerBlock, "{")
+	end := strings.LastIndex(providerBlock, "}")
+	if open < 0 || end <= open {
+		return nil, fmt.Errorf("%s: malformed provider block", path)
+	}
+	return splitBodyItems(path, providerBlock, open+1, end, fromChild)
+}
+
+// splitBodyItems splits src[start:end] into items. Offsets refer to src.
+func splitBodyItems(path string, src string, start, end int, fromChild bool) ([]providerItem, error) {
+	s := newScanner(path, src[:end])
+	s.i = start
+	offset := func(pos int) int {
+		if fromChild {
+			return pos
+		}
+		return -1
+	}
+	items := make([]providerItem, 0)
+	for {
+		first := s.nextNonTrivia()
+		if first.kind == tokEOF {
+			return items, nil
+		}
+		if first.kind != tokIdent {
+			return nil, s.errAt(first, "expected directive")
+		}
+		tokens := []string{first.text}
+		args := make([]string, 0)
+		// args are whitespace-separated words, so an unquoted `$.a.b` stays one argument.
+		inWord := false
+		for {
+			tok := s.next()
+			switch tok.kind {
+			case tokEOF:
+				return nil, s.errAt(tok, "unexpected EOF in "+first.text)
+			case tokWhitespace, tokComment:
+				inWord = false
+				continue
+			case tokSemicolon:
+				items = append(items, providerItem{
+					name: first.text,
+					key:  itemKey(first.text, tokens, args, false),
+					text: src[first.pos : tok.pos+1],
+					pos:  offset(first.pos),
+				})
+			case tokLBrace:
+				if err := skipBalancedBraces(s); err != nil {
+					return nil, err
+				}
+				closePos := s.i - 1
+				items = append(items, providerItem{
+					name:    first.text,
+					key:     itemKey(first.text, tokens, args, true),
+					text:    src[first.pos : closePos+1],
+					pos:     offset(first.pos),
+					open:    tok.pos - first.pos,
+					body:    src[tok.pos+1 : closePos],
+					bodyPos: offset(tok.pos + 1),
+					isBlock: true,
+				})
+			case tokRBrace:
+				return nil, s.errAt(tok, "unexpected '}'")
+			default:
+				tokens = append(tokens, tok.text)
+				if inWord {
+					args[len(args)-1] += tok.text
+				} else {
+					args = append(args, tok.text)
+				}
+				inWord = true
+				continue
+			}
+			break
+		}
+	}
+}
+
+// bodyItems splits the body of a block item; child bodies are scanned in place in the
+// child block source (childSrc) so their items keep child offsets.
+func bodyItems(path string, childSrc string, item providerItem) ([]providerItem, error) {
+	if item.bodyPos < 0 {
+		return splitBodyItems(path, item.body, 0, len(item.body), false)
+	}
+	return splitBodyItems(path, childSrc, item.bodyPos, item.bodyPos+len(item.body), true)
+}
+
+func mergeProviderItems(path string, childSrc string, parent, child []providerItem) ([]providerItem, error) {
+	out := make([]providerItem, 0, len(parent)+len(child))
+	out = append(out, parent...)
+	newMatches := make([]providerItem, 0)
+	for _, item := range child {
+		i := indexItem(out, item.key)
+		switch {
+		case i >= 0 && item.name == "match":
+			out[i] = item
+		case i >= 0:
+			merged, err := mergeItem(path, childSrc, out[i], item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = merged
+		case item.name == "match":
+			newMatches = append(newMatches, item)
+		default:
+			out = append(out, item)
+		}
+	}
+	if len(newMatches) > 0 {
+		firstMatch := len(out)
+		for i, item := range out {
+			if item.name == "match" {
+				firstMatch = i
+				break
+			}
+		}
+		withMatches := make([]providerItem, 0, len(out)+len(newMatches))
+		withMatches = append(withMatches, out[:firstMatch]...)
+		withMatches = append(withMatches, newMatches...)
+		withMatches = append(withMatches, out[firstMatch:]...)
+		out = withMatches
+	}
+	return out, nil
+}
+
+func indexItem(items []providerItem, key string) int {
+	for i, item := range items {
+		if item.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeItem overrides an inherited item. Directive blocks present on both sides are
+// merged per directive: a child directive replaces the inherited one with the same key
+// in place, other inherited directives are kept and new ones are appended.
+func mergeItem(path string, childSrc string, parent, child providerItem) (providerItem, error) {
+	if !child.isBlock || !parent.isBlock || !directiveBlocks[child.name] {
+		return child, nil
+	}
+	parentItems, err := bodyItems(path, childSrc, parent)
+	if err != nil {
+		return providerItem{}, err
+	}
+	childItems, err := bodyItems(path, childSrc, child)
+	if err != nil {
+		return providerItem{}, err
+	}
+	items := append([]providerItem(nil), parentItems...)
+	for _, item := range childItems {
+		i := indexItem(items, item.key)
+		if i < 0 {
+			items = append(items, item)
+			continue
+		}
+		merged, err := mergeItem(path, childSrc, items[i], item)
+		if err != nil {
+			return providerItem{}, err
+		}
+		items[i] = merged
+	}
+	child.children = items
+	child.merged = true
+	return child, nil
+}
+
+// extendsRenderer writes a flattened provider block that keeps every child item on the
+// line (and column) it has in the source, so diagnostics still point into the child file.
+// Inherited items are collapsed onto single lines between them; a child item that can no
+// longer reach its line (reordered matches) is collapsed as well, so the block never grows
+// past its original line count.
+type extendsRenderer struct {
+	src  string
+	b    strings.Builder
+	line int
+}
+
+func renderProviderBlock(block ProviderBlock, items []providerItem) string {
+	r := &extendsRenderer{src: block.Content}
+	fmt.Fprintf(&r.b, "provider %q {", block.Name)
+	for _, item := range items {
+		r.item(item)
+	}
+	r.place("}", len(block.Content)-1)
+	return r.b.String()
+}
+
+func (r *extendsRenderer) item(item providerItem) {
+	if !item.merged {
+		r.place(item.text, item.pos)
+		return
+	}
+	r.place(item.text[:item.open+1], item.pos)
+	for _, child := range item.children {
+		r.item(child)
+	}
+	closePos := -1
+	if item.pos >= 0 {
+		closePos = item.pos + len(item.text) - 1
+	}
+	r.place("}", closePos)
+}
+
+func (r *extendsRenderer) place(text string, pos int) {
+	if pos >= 0 {
+		if target := strings.Count(r.src[:pos], "\n"); target > r.line {
+			r.b.WriteString(strings.Repeat("\n", target-r.line))
+			r.line = target
+			col := pos - strings.LastIndex(r.src[:pos], "\n") - 1
+			r.b.WriteString(strings.Repeat(" ", col))
+			r.b.WriteString(text)
+			r.line += strings.Count(text, "\n")
+			return
+		}
+	}
+	collapsed := collapseDSLText(text)
+	r.b.WriteString(" ")
+	r.b.WriteString(collapsed)
+	r.line += strings.Count(collapsed, "\n")
+}
+
+// collapseDSLText puts text on one line: newlines become spaces and comments are dropped.
+// Newlines inside string literals are kept.
+func collapseDSLText(text string) string {
+	s := newScanner("", text)
+	var b strings.Builder
+	for {
+		tok := s.next()
+		switch tok.kind {
+		case tokEOF:
+			return b.String()
+		case tokComment:
+			b.WriteString(" ")
+		case tokWhitespace:
+			if strings.ContainsAny(tok.text, "\r\n") {
+				b.WriteString(" ")
+			} else {
+				b.WriteString(tok.text)
+			}
+		default:
+			b.WriteString(tok.text)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package dslconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOpenAICompatibleBase = `
+syntax "next-router/0.1";
+
+provider "openai-compatible" {
+  defaults {
+    auth {
+      auth_bearer;
+    }
+    response {
+      resp_passthrough;
+    }
+  }
+
+  match api = "chat.completions" {
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+  match api = "embeddings" {
+    upstream {
+      set_path "/v1/embeddings";
+    }
+  }
+}
+`
+
+func writeExtendsFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestProviderExtends_BaseDirParent(t *testing.T) {
+	dir := writeExtendsFixture(t, map[string]string{
+		"base/openai-compatible.conf": testOpenAICompatibleBase,
+		"groq.conf": `
+syntax "next-router/0.1";
+
+provider "groq" extends "openai-compatible" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.groq.com/openai";
+    }
+    auth {
+      auth_header_key "x-api-key";
+    }
+  }
+
+  match api = "embeddings" {
+    upstream {
+      set_path "/openai/v1/embeddings";
+    }
+  }
+  match api = "chat.completions" stream = true {
+    upstream {
+      set_path "/v1/chat/stream";
+    }
+  }
+}
+`,
+	})
+
+	res, err := ValidateProvidersDir(dir)
+	if err != nil {
+		t.Fatalf("ValidateProvidersDir: %v", err)
+	}
+	if strings.Join(res.LoadedProviders, ",") != "groq" {
+		t.Fatalf("loaded=%v, base providers must not be loaded on their own", res.LoadedProviders)
+	}
+
+	reg := NewRegistry()
+	if _, err := reg.ReloadFromDir(dir); err != nil {
+		t.Fatalf("ReloadFromDir: %v", err)
+	}
+	pf, ok := reg.GetProvider("groq")
+	if !ok {
+		t.Fatalf("groq not loaded")
+	}
+	if pf.Routing.BaseURLExpr != `"https://api.groq.com/openai"` {
+		t.Fatalf("base_url=%q", pf.Routing.BaseURLExpr)
+	}
+	got := make([]string, 0, len(pf.Routing.Matches))
+	for _, m := range pf.Routing.Matches {
+		got = append(got, m.API+"="+m.SetPath)
+	}
+	want := `chat.completions="/v1/chat/stream",chat.completions="/v1/chat/completions",embeddings="/openai/v1/embeddings"`
+	if strings.Join(got, ",") != want {
+		t.Fatalf("matches=%s\nwant     %s", strings.Join(got, ","), want)
+	}
+	if pf.Response.Defaults.Op != "resp_passthrough" {
+		t.Fatalf("inherited response op=%q", pf.Response.Defaults.Op)
+	}
+	if len(pf.Headers.Defaults.Auth) != 1 || pf.Headers.Defaults.Auth[0].NameExpr != `"x-api-key"` {
+		t.Fatalf("child auth_header_key must replace the inherited auth_bearer: %#v", pf.Headers.Defaults.Auth)
+	}
+}
+
+func TestProviderExtends_MergedFileAndBundle(t *testing.T) {
+	dir := writeExtendsFixture(t, map[string]string{
+		"providers.conf": `
+syntax "next-router/0.1";
+
+provider "openai" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.openai.com";
+    }
+    auth {
+      auth_bearer;
+    }
+  }
+  match api = "chat.completions" {
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+}
+
+provider "deepseek" extends "openai" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.deepseek.com";
+    }
+  }
+}
+`,
+	})
+	path := filepath.Join(dir, "providers.conf")
+	res, err := ValidateProvidersFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProvidersFile: %v", err)
+	}
+	if strings.Join(res.LoadedProviders, ",") != "deepseek,openai" {
+		t.Fatalf("loaded=%v", res.LoadedProviders)
+	}
+
+	bundled, err := BundleProvidersPath(path)
+	if err != nil {
+		t.Fatalf("BundleProvidersPath: %v", err)
+	}
+	if strings.Contains(bundled, "extends") {
+		t.Fatalf("bundle must be flattened:\n%s", bundled)
+	}
+	block, ok, err := ExtractProviderBlockOptional(path, bundled, "deepseek")
+	if err != nil || !ok {
+		t.Fatalf("deepseek block missing: ok=%v err=%v", ok, err)
+	}
+	for _, want := range []string{`base_url = "https://api.deepseek.com";`, "auth_bearer;", `set_path "/v1/chat/completions";`} {
+		if !strings.Contains(block, want) {
+			t.Fatalf("flattened block missing %q:\n%s", want, block)
+		}
+	}
+}
+
+func TestProviderExtends_Errors(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		want    string
+	}{
+		"unknown_parent": {
+			content: `provider "a" extends "missing" { defaults { upstream_config { base_url = "https://a.example.com"; } } }`,
+			want:    `extends unknown provider "missing"`,
+		},
+		"cycle": {
+			content: `provider "a" extends "b" { }
+provider "b" extends "a" { }`,
+			want: "extends cycle detected",
+		},
+		"bad_clause": {
+			content: `provider "a" extends b { }`,
+			want:    "expected parent provider name string literal after extends",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := writeExtendsFixture(t, map[string]string{"providers.conf": tc.content})
+			_, err := ValidateProvidersFile(filepath.Join(dir, "providers.conf"))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err=%v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestProviderExtends_MergesPhasesPerDirective(t *testing.T) {
+	dir := writeExtendsFixture(t, map[string]string{
+		"providers.conf": `
+provider "parent" {
+  defaults {
+    upstream_config {
+      base_url = "https://parent.example.com";
+    }
+    auth {
+      auth_bearer;
+    }
+    request {
+      set_header "X-Team" "parent";
+      set_header "X-Keep" "yes";
+      json_del "$.user";
+    }
+  }
+  match api = "chat.completions" {
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+}
+
+provider "child" extends "parent" {
+  defaults {
+    request {
+      set_header "X-Team" "child";
+      set_header "X-New" "1";
+    }
+  }
+}
+`,
+	})
+	reg := NewRegistry()
+	if _, err := reg.ReloadFromFile(filepath.Join(dir, "providers.conf")); err != nil {
+		t.Fatalf("ReloadFromFile: %v", err)
+	}
+	pf, ok := reg.GetProvider("child")
+	if !ok {
+		t.Fatalf("child not loaded")
+	}
+	got := make([]string, 0)
+	for _, op := range pf.Headers.Defaults.Request {
+		got = append(got, op.NameExpr+"="+op.ValueExpr)
+	}
+	want := `"X-Team"="child","X-Keep"="yes","X-New"="1"`
+	if strings.Join(got, ",") != want {
+		t.Fatalf("request headers=%s want=%s", strings.Join(got, ","), want)
+	}
+	if len(pf.Headers.Defaults.Auth) != 1 {
+		t.Fatalf("inherited auth phase lost: %#v", pf.Headers.Defaults.Auth)
+	}
+	if len(pf.Request.Defaults.JSONOps) != 1 || pf.Request.Defaults.JSONOps[0].Path != "$.user" {
+		t.Fatalf("inherited json_del lost: %#v", pf.Request.Defaults.JSONOps)
+	}
+}
+
+func TestProviderExtends_DiagnosticsKeepChildLines(t *testing.T) {
+	dir := writeExtendsFixture(t, map[string]string{
+		"base/openai-compatible.conf": testOpenAICompatibleBase,
+		"groq.conf": `syntax "next-router/0.1";
+
+provider "groq" extends "openai-compatible" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.groq.com/openai";
+    }
+  }
+
+  match api = "embeddings" {
+    upstream {
+      timeout_ms abc;
+    }
+  }
+}
+`,
+	})
+	_, err := ValidateProvidersFile(filepath.Join(dir, "groq.conf"))
+	if err == nil || !strings.Contains(err.Error(), "groq.conf:12:18:") {
+		t.Fatalf("err=%v, want position groq.conf:12:18", err)
+	}
+}
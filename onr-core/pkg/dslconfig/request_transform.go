@@ -21,6 +21,8 @@ type RequestTransform struct {
 	// ReqMapMode selects a built-in request mapping mode (non-streaming JSON transform),
 	// e.g. openai chat.completions -> openai responses.
 	ReqMapMode string
+	// ReqTemplate is set by req_template (ReqMapMode "template").
+	ReqTemplate *JSONTemplate
 }
 
 type ProviderRequestTransform struct {
@@ -112,6 +114,7 @@ func mergeRequestTransform(base, override RequestTransform) RequestTransform {
 	}
 	if reqMapMode := normalizedReqMapMode(override.ReqMapMode); reqMapMode != "" {
 		out.ReqMapMode = reqMapMode
+		out.ReqTemplate = override.ReqTemplate
 	}
 	return out
 }
@@ -152,7 +152,7 @@ provider "templated" {
   }
 }
 `
-	pf, hasProvider, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	pf, hasProvider, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("validateAndBuildProviderFile: %v", err)
 	}
@@ -198,7 +198,7 @@ provider "vertex" {
   }
 }
 `
-	pf, hasProvider, err := validateAndBuildProviderFile("vertex.conf", conf, nil, nil, nil, nil)
+	pf, hasProvider, err := validateAndBuildProviderFile("vertex.conf", conf, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("validateAndBuildProviderFile: %v", err)
 	}
@@ -240,7 +240,7 @@ provider "vertex" {
   }
 }
 `
-	pf, hasProvider, err := validateAndBuildProviderFile("vertex.conf", conf, nil, nil, nil, nil)
+	pf, hasProvider, err := validateAndBuildProviderFile("vertex.conf", conf, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("validateAndBuildProviderFile: %v", err)
 	}
@@ -328,7 +328,7 @@ provider "templated" {
   }
 }
 `
-	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err == nil {
 		t.Fatalf("expected validation error")
 	}
@@ -355,7 +355,7 @@ provider "templated" {
   }
 }
 `
-	pf, hasProvider, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	pf, hasProvider, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("validateAndBuildProviderFile: %v", err)
 	}
@@ -394,7 +394,7 @@ provider "templated" {
   }
 }
 `
-	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err == nil {
 		t.Fatalf("expected validation error")
 	}
@@ -421,7 +421,7 @@ provider "templated" {
   }
 }
 `
-	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err == nil {
 		t.Fatalf("expected validation error")
 	}
@@ -448,7 +448,7 @@ provider "templated" {
   }
 }
 `
-	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil)
+	_, _, err := validateAndBuildProviderFile("templated.conf", conf, nil, nil, nil, nil, nil)
 	if err == nil {
 		t.Fatalf("expected validation error")
 	}
@@ -744,13 +744,13 @@ provider "t" {
   defaults {
     upstream_config { base_url = "https://t.example.com"; }
     request {
-      json_wrap_input_text "$.input[0]";
+      json_wrap_input_text "$.input[first]";
     }
   }
 }
 `)
 	_, err := ValidateProviderFile(path)
-	if err == nil || !strings.Contains(err.Error(), "array indexes") {
-		t.Fatalf("ValidateProviderFile err=%v, want array index validation error", err)
+	if err == nil || !strings.Contains(err.Error(), "invalid json path") {
+		t.Fatalf("ValidateProviderFile err=%v, want json path validation error", err)
 	}
 }
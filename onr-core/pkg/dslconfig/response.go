@@ -9,6 +9,8 @@ import (
 type ResponseDirective struct {
 	Op   string
 	Mode string
+	// Template is set by resp_template (Op "resp_map", Mode "template").
+	Template *JSONTemplate
 
 	// SSECollectMode collects an upstream SSE response into the same upstream
 	// protocol's non-stream JSON object before optional resp_map/JSONOps.
@@ -137,6 +139,7 @@ func mergeResponseDirective(base ResponseDirective, override ResponseDirective)
 	if strings.TrimSpace(override.Op) != "" {
 		out.Op = override.Op
 		out.Mode = override.Mode
+		out.Template = override.Template
 	}
 	if strings.TrimSpace(override.Mode) != "" {
 		out.Mode = override.Mode
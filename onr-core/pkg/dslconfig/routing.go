@@ -3,6 +3,7 @@ package dslconfig
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
@@ -11,7 +12,9 @@ import (
 type ProviderRouting struct {
 	BaseURLExpr string
 	Transport   string
-	Matches     []RoutingMatch
+	// Timeouts come from `defaults { upstream { ... } }`; match timeouts override them per field.
+	Timeouts UpstreamTimeouts
+	Matches  []RoutingMatch
 }
 
 type RoutingMatch struct {
@@ -21,6 +24,42 @@ type RoutingMatch struct {
 	SetPath    string
 	QueryPairs map[string]string
 	QueryDels  []string
+
+	Timeouts UpstreamTimeouts
+}
+
+// UpstreamTimeouts holds the upstream timeout directives in milliseconds; zero means unset.
+type UpstreamTimeouts struct {
+	// TimeoutMs bounds the whole upstream call, including reading a streamed body.
+	TimeoutMs int
+	// ConnectTimeoutMs bounds obtaining a connection (DNS, dial and TLS handshake).
+	ConnectTimeoutMs int
+	// FirstByteTimeoutMs bounds the wait for the first response byte after the request is written.
+	FirstByteTimeoutMs int
+	// StreamIdleTimeoutMs bounds the gap between two reads of a streamed response body.
+	StreamIdleTimeoutMs int
+}
+
+// IsZero reports whether no timeout is set.
+func (t UpstreamTimeouts) IsZero() bool {
+	return t == UpstreamTimeouts{}
+}
+
+// Merge returns t with every field that is set in override replaced.
+func (t UpstreamTimeouts) Merge(override UpstreamTimeouts) UpstreamTimeouts {
+	if override.TimeoutMs > 0 {
+		t.TimeoutMs = override.TimeoutMs
+	}
+	if override.ConnectTimeoutMs > 0 {
+		t.ConnectTimeoutMs = override.ConnectTimeoutMs
+	}
+	if override.FirstByteTimeoutMs > 0 {
+		t.FirstByteTimeoutMs = override.FirstByteTimeoutMs
+	}
+	if override.StreamIdleTimeoutMs > 0 {
+		t.StreamIdleTimeoutMs = override.StreamIdleTimeoutMs
+	}
+	return t
 }
 
 // Apply requires a non-nil meta and a valid ProviderRouting receiver.
@@ -99,6 +138,36 @@ func (p *ProviderRouting) HasMatch(meta *dslmeta.Meta) bool {
 	return ok
 }
 
+// MatchIndex requires a non-nil meta and returns the index of the first match block
+// selected for meta.API/meta.IsStream. Match blocks share one index across all phases
+// (routing, headers, request, response, metrics), in provider file order.
+func (p *ProviderRouting) MatchIndex(meta *dslmeta.Meta) (int, bool) {
+	api := strings.TrimSpace(meta.API)
+	if api == "" {
+		return -1, false
+	}
+	for i, m := range p.Matches {
+		if m.API != "" && m.API != api {
+			continue
+		}
+		if m.Stream != nil && *m.Stream != meta.IsStream {
+			continue
+		}
+		return i, true
+	}
+	return -1, false
+}
+
+// EffectiveTimeouts requires a non-nil meta and returns the defaults timeouts overridden
+// by the selected match block's timeouts.
+func (p *ProviderRouting) EffectiveTimeouts(meta *dslmeta.Meta) UpstreamTimeouts {
+	out := p.Timeouts
+	if idx, ok := p.MatchIndex(meta); ok {
+		out = out.Merge(p.Matches[idx].Timeouts)
+	}
+	return out
+}
+
 // ReferencesVariable reports whether routing expressions contain a DSL variable such as "channel.location".
 func (p *ProviderRouting) ReferencesVariable(variable string) bool {
 	if referencesVariable(p.BaseURLExpr, variable) {
@@ -179,6 +248,9 @@ func evalStringExprValue(expr string, meta *dslmeta.Meta, requireNonEmptyVariabl
 		}
 		return b.String(), nil
 	}
+	if name, args, ok := splitFuncCall(raw); ok {
+		return evalFuncCall(name, args, meta), nil
+	}
 	if isQuotedStringExpr(raw) {
 		return unquoteString(raw), nil
 	}
@@ -229,7 +301,8 @@ func evalBuiltinStringVariable(expr string, meta *dslmeta.Meta) string {
 	if meta == nil {
 		return ""
 	}
-	switch strings.TrimSpace(expr) {
+	expr = strings.TrimSpace(expr)
+	switch expr {
 	case exprChannelBaseURL:
 		return meta.BaseURL
 	case exprChannelKey:
@@ -251,8 +324,13 @@ func evalBuiltinStringVariable(expr string, meta *dslmeta.Meta) string {
 		return meta.Task.ID
 	case exprTaskUpstreamID:
 		return meta.Task.UpstreamID
+	case exprRequestAccessKey:
+		return meta.AccessKeyName
 	default:
-		return ""
+		if isUserVariable(expr) {
+			return evalUserVariable(expr, meta)
+		}
+		return evalPrefixedStringVariable(expr, meta)
 	}
 }
 
@@ -270,13 +348,14 @@ func normalizeTemplateVariable(name string) (string, bool) {
 	return "", false
 }
 
+// isBuiltinStringVariable also accepts user variables ($name); whether they are defined
+// by a map block is checked per provider in buildProviderVars.
 func isBuiltinStringVariable(expr string) bool {
-	switch strings.TrimSpace(expr) {
-	case exprChannelBaseURL, exprChannelKey, exprChannelLocation, exprCredentialProjID, exprOAuthAccessToken, exprRequestModel, exprRequestMapped, exprTaskID, exprTaskUpstreamID:
+	raw := strings.TrimSpace(expr)
+	if slices.Contains(builtinExprVariables, raw) {
 		return true
-	default:
-		return false
 	}
+	return isPrefixedStringVariable(raw) || isUserVariable(raw)
 }
 
 func isQuotedStringExpr(expr string) bool {
@@ -1,6 +1,10 @@
 package dslconfig
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
 
 func TestProviderRoutingReferencesVariable(t *testing.T) {
 	routing := ProviderRouting{
@@ -20,3 +24,55 @@ func TestProviderRoutingReferencesVariable(t *testing.T) {
 		t.Fatalf("routing should not reference oauth.access_token")
 	}
 }
+
+func TestParseUpstreamTimeouts(t *testing.T) {
+	const conf = `syntax "next-router/0.1";
+provider "demo" {
+  defaults {
+    upstream_config { base_url = "https://api.example.com"; }
+    upstream {
+      timeout_ms 30000;
+      connect_timeout_ms 2000;
+    }
+  }
+  match api = "chat.completions" stream = true {
+    upstream {
+      set_path "/v1/chat/completions";
+      timeout_ms 90000;
+      first_byte_timeout_ms 20000;
+      stream_idle_timeout_ms 15000;
+    }
+  }
+  match api = "embeddings" {
+    upstream { set_path "/v1/embeddings"; }
+  }
+}`
+	routing, _, _, _, _, _, _, _, _, err := parseProviderConfig("demo.conf", conf)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	stream := routing.EffectiveTimeouts(&dslmeta.Meta{API: "chat.completions", IsStream: true})
+	want := UpstreamTimeouts{TimeoutMs: 90000, ConnectTimeoutMs: 2000, FirstByteTimeoutMs: 20000, StreamIdleTimeoutMs: 15000}
+	if stream != want {
+		t.Fatalf("stream timeouts=%+v want %+v", stream, want)
+	}
+	embeddings := routing.EffectiveTimeouts(&dslmeta.Meta{API: "embeddings"})
+	if embeddings != (UpstreamTimeouts{TimeoutMs: 30000, ConnectTimeoutMs: 2000}) {
+		t.Fatalf("embeddings timeouts=%+v", embeddings)
+	}
+}
+
+func TestParseUpstreamTimeoutsErrors(t *testing.T) {
+	cases := map[string]string{
+		"defaults set_path": `provider "demo" { defaults { upstream { set_path "/x"; } } }`,
+		"zero":              `provider "demo" { match api = "embeddings" { upstream { timeout_ms 0; } } }`,
+		"not int":           `provider "demo" { match api = "embeddings" { upstream { stream_idle_timeout_ms 1s; } } }`,
+	}
+	for name, conf := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, _, _, _, _, _, _, _, err := parseProviderConfig("demo.conf", conf); err == nil {
+				t.Fatalf("expected parse error")
+			}
+		})
+	}
+}
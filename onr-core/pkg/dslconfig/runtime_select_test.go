@@ -383,3 +383,30 @@ func TestProviderRoutingHasMatchHelpers(t *testing.T) {
 		t.Fatalf("HasMatch should be false for empty api")
 	}
 }
+
+func TestProviderRoutingMatchIndex(t *testing.T) {
+	streamTrue := true
+	p := ProviderRouting{
+		Matches: []RoutingMatch{
+			{API: "chat.completions", Stream: &streamTrue},
+			{API: "chat.completions"},
+			{},
+		},
+	}
+	cases := []struct {
+		meta *dslmeta.Meta
+		want int
+		ok   bool
+	}{
+		{&dslmeta.Meta{API: "chat.completions", IsStream: true}, 0, true},
+		{&dslmeta.Meta{API: "chat.completions"}, 1, true},
+		{&dslmeta.Meta{API: "embeddings"}, 2, true},
+		{&dslmeta.Meta{}, -1, false},
+	}
+	for _, tc := range cases {
+		got, ok := p.MatchIndex(tc.meta)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("MatchIndex(%s stream=%v)=%d,%v want %d,%v", tc.meta.API, tc.meta.IsStream, got, ok, tc.want, tc.ok)
+		}
+	}
+}
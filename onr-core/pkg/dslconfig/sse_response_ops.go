@@ -263,24 +263,15 @@ func applySSEJSONDelIf(obj map[string]any, rules []SSEJSONDelIfRule) {
 }
 
 func jsonGet(root map[string]any, path string) (any, bool) {
-	parts, err := parseObjectPath(path)
-	if err != nil || len(parts) == 0 {
+	parts, err := parseJSONPath(path)
+	if err != nil {
 		return nil, false
 	}
-	cur := root
-	for i := 0; i < len(parts)-1; i++ {
-		next, ok := cur[parts[i]]
-		if !ok {
-			return nil, false
-		}
-		m, ok := next.(map[string]any)
-		if !ok || m == nil {
-			return nil, false
-		}
-		cur = m
+	slots := existingJSONSlots(root, parts)
+	if len(slots) == 0 {
+		return nil, false
 	}
-	v, ok := cur[parts[len(parts)-1]]
-	return v, ok
+	return slots[0].get()
 }
 
 func applyJSONOpsToObject(meta *dslmeta.Meta, obj map[string]any, ops []JSONOp, event string, counts []int) error {
@@ -308,15 +299,8 @@ func applyJSONOpsToObject(meta *dslmeta.Meta, obj map[string]any, ops []JSONOp,
 			}
 			changed = opChanged
 		case jsonOpSetIfAbsent:
-			exists, err := jsonPathExists(obj, op.Path)
-			if err != nil {
-				return err
-			}
-			if exists {
-				continue
-			}
 			val := evalJSONValueExpr(meta, op.ValueExpr)
-			opChanged, err := jsonSet(obj, op.Path, val)
+			opChanged, err := jsonSetIfAbsent(obj, op.Path, val)
 			if err != nil {
 				return err
 			}
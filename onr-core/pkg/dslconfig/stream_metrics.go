@@ -198,7 +198,10 @@ func isAllZeroUsage(u *Usage) bool {
 	if u.InputTokens != 0 || u.OutputTokens != 0 || u.TotalTokens != 0 {
 		return false
 	}
-	if u.InputTokenDetails != nil && (u.InputTokenDetails.CachedTokens != 0 || u.InputTokenDetails.CacheWriteTokens != 0) {
+	if u.InputTokenDetails != nil && *u.InputTokenDetails != (ResponseTokenDetails{}) {
+		return false
+	}
+	if u.OutputTokenDetails != nil && *u.OutputTokenDetails != (OutputTokenDetails{}) {
 		return false
 	}
 	if hasNonZeroUsageFlatFields(u.FlatFields) {
@@ -239,12 +242,24 @@ func mergeUsagePreferNonZero(dst *Usage, src *Usage) {
 		if dst.InputTokenDetails == nil {
 			dst.InputTokenDetails = &ResponseTokenDetails{}
 		}
-		if src.InputTokenDetails.CachedTokens > 0 {
-			dst.InputTokenDetails.CachedTokens = src.InputTokenDetails.CachedTokens
-		}
-		if src.InputTokenDetails.CacheWriteTokens > 0 {
-			dst.InputTokenDetails.CacheWriteTokens = src.InputTokenDetails.CacheWriteTokens
+		d, s := dst.InputTokenDetails, src.InputTokenDetails
+		preferNonZero(&d.CachedTokens, s.CachedTokens)
+		preferNonZero(&d.CacheWriteTokens, s.CacheWriteTokens)
+		preferNonZero(&d.TextTokens, s.TextTokens)
+		preferNonZero(&d.AudioTokens, s.AudioTokens)
+		preferNonZero(&d.ImageTokens, s.ImageTokens)
+		preferNonZero(&d.VideoTokens, s.VideoTokens)
+	}
+	if src.OutputTokenDetails != nil {
+		if dst.OutputTokenDetails == nil {
+			dst.OutputTokenDetails = &OutputTokenDetails{}
 		}
+		d, s := dst.OutputTokenDetails, src.OutputTokenDetails
+		preferNonZero(&d.ReasoningTokens, s.ReasoningTokens)
+		preferNonZero(&d.TextTokens, s.TextTokens)
+		preferNonZero(&d.AudioTokens, s.AudioTokens)
+		preferNonZero(&d.ImageTokens, s.ImageTokens)
+		preferNonZero(&d.VideoTokens, s.VideoTokens)
 	}
 	mergeUsageFlatFieldsPreferNonZero(dst, src)
 	mergeUsageDebugFactsPreferNonZero(dst, src)
@@ -255,6 +270,12 @@ func mergeUsagePreferNonZero(dst *Usage, src *Usage) {
 	normalizeUsageFields(dst)
 }
 
+func preferNonZero(dst *int, src int) {
+	if src > 0 {
+		*dst = src
+	}
+}
+
 func (a *StreamMetricsAggregator) recordFreshInputTokens(u *Usage) {
 	if !a.inputIncludesCache || u == nil {
 		return
@@ -201,6 +201,9 @@ func TestStreamMetricsAggregator_GeminiUsage(t *testing.T) {
 	if u.InputTokens != 1 || u.OutputTokens != 5 || u.TotalTokens != 6 {
 		t.Fatalf("unexpected usage: %+v", *u)
 	}
+	if u.OutputTokenDetails == nil || u.OutputTokenDetails.ReasoningTokens != 3 {
+		t.Fatalf("output details=%+v want reasoning=3", u.OutputTokenDetails)
+	}
 	if fr != "STOP" {
 		t.Fatalf("unexpected finish_reason: %q", fr)
 	}
@@ -70,9 +70,12 @@ type usageFactEval struct {
 var defaultUsageDimensionRegistry = NewUsageDimensionRegistry(
 	UsageDimension{Dimension: "input", Unit: "token"},
 	UsageDimension{Dimension: "output", Unit: "token"},
+	UsageDimension{Dimension: "output.reasoning", Unit: "token"},
+	UsageDimension{Dimension: "input.text", Unit: "token"},
 	UsageDimension{Dimension: "input.image", Unit: "token"},
 	UsageDimension{Dimension: "input.video", Unit: "token"},
 	UsageDimension{Dimension: "input.audio", Unit: "token"},
+	UsageDimension{Dimension: "output.text", Unit: "token"},
 	UsageDimension{Dimension: "output.image", Unit: "token"},
 	UsageDimension{Dimension: "output.audio", Unit: "token"},
 	UsageDimension{Dimension: "output.video", Unit: "token"},
@@ -470,39 +473,59 @@ func matchesUsageFactFilter(v any, typ, status string) bool {
 
 func projectUsageFromFacts(facts []usageFactEval, usageRootConfigured bool) (*Usage, int) {
 	usage := &Usage{}
-	var cachedTokens int
-	var cacheWriteTokens int
+	var in ResponseTokenDetails
+	var out OutputTokenDetails
 	for _, fact := range facts {
 		if !fact.matched {
 			continue
 		}
 		key := normalizeUsageFactKey(fact.cfg.Dimension, fact.cfg.Unit)
-		switch key {
-		case usageFactKey{Dimension: "input", Unit: "token"}:
-			v := int(math.Round(fact.quantity))
+		if key.Unit != "token" {
+			continue
+		}
+		v := int(math.Round(fact.quantity))
+		switch key.Dimension {
+		case "input":
 			usage.InputTokens += v
 			usage.PromptTokens += v
-		case usageFactKey{Dimension: "output", Unit: "token"}:
-			v := int(math.Round(fact.quantity))
+		case "output":
 			usage.OutputTokens += v
 			usage.CompletionTokens += v
-		case usageFactKey{Dimension: "cache_read", Unit: "token"}:
-			cachedTokens += int(math.Round(fact.quantity))
-		case usageFactKey{Dimension: "cache_write", Unit: "token"}:
-			cacheWriteTokens += int(math.Round(fact.quantity))
+		case "cache_read":
+			in.CachedTokens += v
+		case "cache_write":
+			in.CacheWriteTokens += v
+		case "input.text":
+			in.TextTokens += v
+		case "input.audio":
+			in.AudioTokens += v
+		case "input.image":
+			in.ImageTokens += v
+		case "input.video":
+			in.VideoTokens += v
+		case "output.reasoning":
+			out.ReasoningTokens += v
+		case "output.text":
+			out.TextTokens += v
+		case "output.audio":
+			out.AudioTokens += v
+		case "output.image":
+			out.ImageTokens += v
+		case "output.video":
+			out.VideoTokens += v
 		}
 	}
 
 	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
-	if cachedTokens > 0 || cacheWriteTokens > 0 {
-		usage.InputTokenDetails = &ResponseTokenDetails{
-			CachedTokens:     cachedTokens,
-			CacheWriteTokens: cacheWriteTokens,
-		}
+	if in != (ResponseTokenDetails{}) {
+		usage.InputTokenDetails = &in
+	}
+	if out != (OutputTokenDetails{}) {
+		usage.OutputTokenDetails = &out
 	}
 	usage.FlatFields = buildUsageFlatFields(facts)
 	usage.DebugFacts = buildUsageDebugFacts(facts, usageRootConfigured)
-	return usage, cachedTokens
+	return usage, in.CachedTokens
 }
 
 func extractCustomUsageWithEvent(event string, reqRoot, respRoot, derivedRoot map[string]any, cfg UsageExtractConfig) (*Usage, int) {
@@ -668,6 +691,8 @@ func isLegacyUsageFactKey(key usageFactKey) bool {
 		return true
 	case usageFactKey{Dimension: "cache_write", Unit: "token"}:
 		return true
+	case usageFactKey{Dimension: "output.reasoning", Unit: "token"}:
+		return true
 	default:
 		return false
 	}
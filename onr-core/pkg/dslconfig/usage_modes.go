@@ -248,7 +248,7 @@ func FindProviderNameOptional(path string, content string) (string, bool, error)
 	return findProviderNameOptional(path, content)
 }
 
-func validateAndBuildProviderFile(path string, content string, usageModes usageModeRegistry, finishReasonModes finishReasonModeRegistry, modelsModes modelsModeRegistry, balanceModes balanceModeRegistry) (ProviderFile, bool, error) {
+func validateAndBuildProviderFile(path string, content string, usageModes usageModeRegistry, finishReasonModes finishReasonModeRegistry, modelsModes modelsModeRegistry, balanceModes balanceModeRegistry, globalMaps varMapRegistry) (ProviderFile, bool, error) {
 	providerName, hasProvider, err := findProviderNameOptional(path, content)
 	if err != nil {
 		return ProviderFile{}, false, err
@@ -272,6 +272,18 @@ func validateAndBuildProviderFile(path string, content string, usageModes usageM
 	if err != nil {
 		return ProviderFile{}, false, err
 	}
+	guard, err := parseProviderGuardFromContent(path, content, providerName)
+	if err != nil {
+		return ProviderFile{}, false, err
+	}
+	localMaps, err := parseProviderVarMapsFromContent(path, content, providerName)
+	if err != nil {
+		return ProviderFile{}, false, err
+	}
+	vars, err := buildProviderVars(path, providerName, content, globalMaps, localMaps)
+	if err != nil {
+		return ProviderFile{}, false, err
+	}
 	if err := validateProviderBaseURL(path, providerName, routing); err != nil {
 		return ProviderFile{}, false, err
 	}
@@ -321,6 +333,8 @@ func validateAndBuildProviderFile(path string, content string, usageModes usageM
 		Finish:   resolvedFinish,
 		Balance:  resolvedBalance,
 		Models:   resolvedModels,
+		Guard:    guard,
+		Vars:     vars,
 	}, true, nil
 }
 
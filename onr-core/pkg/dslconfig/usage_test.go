@@ -114,6 +114,58 @@ func TestExtractUsage_OpenAIResponsesCacheWrite(t *testing.T) {
 	}
 }
 
+func TestExtractUsage_OpenAIChatCompletionsReasoningAndModalities(t *testing.T) {
+	meta := &dslmeta.Meta{API: "chat.completions", IsStream: false}
+	cfg, _ := mustLoadProviderMatchConfigs(t, "openai.conf", meta.API, meta.IsStream)
+
+	resp := []byte(`{
+	  "usage": {
+	    "prompt_tokens": 120,
+	    "completion_tokens": 80,
+	    "prompt_tokens_details": {"cached_tokens": 0, "audio_tokens": 30},
+	    "completion_tokens_details": {"reasoning_tokens": 64, "audio_tokens": 10}
+	  }
+	}`)
+
+	u, _, err := ExtractUsage(meta, cfg, resp)
+	if err != nil {
+		t.Fatalf("ExtractUsage: %v", err)
+	}
+	if u.InputTokens != 120 || u.OutputTokens != 80 || u.TotalTokens != 200 {
+		t.Fatalf("unexpected usage: %+v", *u)
+	}
+	if u.InputTokenDetails == nil || u.InputTokenDetails.AudioTokens != 30 || u.InputTokenDetails.CachedTokens != 0 {
+		t.Fatalf("input details=%+v", u.InputTokenDetails)
+	}
+	if u.OutputTokenDetails == nil || u.OutputTokenDetails.ReasoningTokens != 64 || u.OutputTokenDetails.AudioTokens != 10 {
+		t.Fatalf("output details=%+v", u.OutputTokenDetails)
+	}
+	if _, ok := u.FlatFields["output_reasoning_tokens"]; ok {
+		t.Fatalf("reasoning should not be a flat field: %+v", u.FlatFields)
+	}
+}
+
+func TestExtractUsage_OpenAIResponsesReasoningTokens(t *testing.T) {
+	meta := &dslmeta.Meta{API: "responses", IsStream: false}
+	cfg, _ := mustLoadProviderMatchConfigs(t, "openai.conf", meta.API, meta.IsStream)
+
+	resp := []byte(`{
+	  "usage": {
+	    "input_tokens": 13,
+	    "output_tokens": 700,
+	    "output_tokens_details": {"reasoning_tokens": 640}
+	  }
+	}`)
+
+	u, _, err := ExtractUsage(meta, cfg, resp)
+	if err != nil {
+		t.Fatalf("ExtractUsage: %v", err)
+	}
+	if u.OutputTokens != 700 || u.OutputTokenDetails == nil || u.OutputTokenDetails.ReasoningTokens != 640 {
+		t.Fatalf("unexpected usage: %+v details=%+v", *u, u.OutputTokenDetails)
+	}
+}
+
 func TestExtractUsage_OpenAIResponsesOptionalCacheFields(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -255,6 +307,9 @@ func TestExtractUsage_OpenAI_ImagesGenerations(t *testing.T) {
 	if got, want := u.FlatFields["output_image_tokens"], 4096; got != want {
 		t.Fatalf("output_image_tokens=%v want=%v", got, want)
 	}
+	if u.InputTokenDetails == nil || u.InputTokenDetails.TextTokens != 104 || u.OutputTokenDetails == nil || u.OutputTokenDetails.ImageTokens != 4096 {
+		t.Fatalf("details input=%+v output=%+v", u.InputTokenDetails, u.OutputTokenDetails)
+	}
 	found := false
 	foundOutputImage := false
 	for _, fact := range u.DebugFacts {
@@ -883,6 +938,12 @@ func TestExtractUsage_Gemini_NonStream_MultimodalBuiltin(t *testing.T) {
 	if got, want := u.FlatFields["input_audio_tokens"], 76; got != want {
 		t.Fatalf("input_audio_tokens=%v want=%v", got, want)
 	}
+	if got, want := *u.InputTokenDetails, (ResponseTokenDetails{TextTokens: 5, ImageTokens: 12, VideoTokens: 34, AudioTokens: 76}); got != want {
+		t.Fatalf("input details=%+v want=%+v", got, want)
+	}
+	if u.OutputTokenDetails == nil || u.OutputTokenDetails.ReasoningTokens != 553 {
+		t.Fatalf("output details=%+v want reasoning=553", u.OutputTokenDetails)
+	}
 }
 
 func TestExtractUsage_Gemini_NonStream_SnakeCaseUsageIgnored(t *testing.T) {
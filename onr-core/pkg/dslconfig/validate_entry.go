@@ -77,7 +77,7 @@ func ValidateProviderFile(path string) (ProviderFile, error) {
 	if err != nil {
 		return ProviderFile{}, err
 	}
-	pf, hasProvider, err := validateAndBuildProviderFile(p, content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance)
+	pf, hasProvider, err := validateAndBuildProviderFile(p, content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance, resolvedState.maps)
 	if err != nil {
 		return ProviderFile{}, err
 	}
@@ -142,7 +142,7 @@ func ValidateProvidersDir(providersDir string) (LoadResult, error) {
 	loaded := make([]string, 0)
 	seen := map[string]string{}
 	for _, candidate := range candidates {
-		pf, hasProvider, err := validateAndBuildProviderFile(candidate.path, candidate.content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance)
+		pf, hasProvider, err := validateAndBuildProviderFile(candidate.path, candidate.content, resolvedState.usage, resolvedState.finishReason, resolvedState.models, resolvedState.balance, resolvedState.maps)
 		if err != nil {
 			return LoadResult{}, err
 		}
@@ -213,6 +213,18 @@ func validateOAuthConfig(path, providerName, scope string, cfg OAuthConfig) erro
 }
 
 func validateResponseDirective(path, providerName, scope string, d ResponseDirective) error {
+	if strings.TrimSpace(d.Op) == "resp_map" && strings.EqualFold(strings.TrimSpace(d.Mode), RespMapModeTemplate) {
+		if d.Template == nil {
+			return validationIssue(
+				fmt.Errorf("provider %q in %q: %s resp_map template requires a resp_template { ... } block", providerName, path, scope),
+				scope,
+				"resp_map",
+			)
+		}
+		if err := validateJSONTemplateSample(path, providerName, scope, "resp_template", d.Template); err != nil {
+			return err
+		}
+	}
 	if mode := strings.TrimSpace(d.SSECollectMode); mode != "" {
 		if !ssecollect.SupportsMode(mode) {
 			return validationIssue(
@@ -235,19 +247,26 @@ func validateResponseDirective(path, providerName, scope string, d ResponseDirec
 		if strings.TrimSpace(r.Equals) == "" {
 			return fmt.Errorf("provider %q in %q: %s equals must be non-empty", providerName, path, rs)
 		}
-		if _, err := parseObjectPath(r.CondPath); err != nil {
-			return fmt.Errorf("provider %q in %q: %s invalid cond path: %w", providerName, path, rs, err)
+		if _, err := parseJSONPath(r.CondPath); err != nil {
+			return validationIssue(fmt.Errorf("provider %q in %q: %s invalid cond path: %w", providerName, path, rs, err), rs, "sse_json_del_if")
 		}
-		if _, err := parseObjectPath(r.DelPath); err != nil {
-			return fmt.Errorf("provider %q in %q: %s invalid del path: %w", providerName, path, rs, err)
+		if _, err := parseJSONPath(r.DelPath); err != nil {
+			return validationIssue(fmt.Errorf("provider %q in %q: %s invalid del path: %w", providerName, path, rs, err), rs, "sse_json_del_if")
 		}
 	}
 	for i, op := range d.JSONOps {
 		opScope := fmt.Sprintf("%s.json_op[%d]", scope, i)
+		invalidPath := func(kind string, err error) error {
+			return validationIssue(
+				fmt.Errorf("provider %q in %q: %s invalid %s: %w", providerName, path, opScope, kind, err),
+				opScope,
+				op.Op,
+			)
+		}
 		switch strings.ToLower(strings.TrimSpace(op.Op)) {
 		case jsonOpSet, jsonOpReplace, jsonOpSetIfAbsent, jsonOpDel:
-			if _, err := parseObjectPath(op.Path); err != nil {
-				return fmt.Errorf("provider %q in %q: %s invalid json path: %w", providerName, path, opScope, err)
+			if _, err := parseJSONPath(op.Path); err != nil {
+				return invalidPath("json path", err)
 			}
 			if op.Op == jsonOpSet || op.Op == jsonOpReplace || op.Op == jsonOpSetIfAbsent {
 				if err := validateJSONValueExpr(op.ValueExpr); err != nil {
@@ -255,11 +274,14 @@ func validateResponseDirective(path, providerName, scope string, d ResponseDirec
 				}
 			}
 		case jsonOpRename:
-			if _, err := parseObjectPath(op.FromPath); err != nil {
-				return fmt.Errorf("provider %q in %q: %s invalid from path: %w", providerName, path, opScope, err)
+			if _, err := parseJSONPath(op.FromPath); err != nil {
+				return invalidPath("from path", err)
+			}
+			if _, err := parseJSONPath(op.ToPath); err != nil {
+				return invalidPath("to path", err)
 			}
-			if _, err := parseObjectPath(op.ToPath); err != nil {
-				return fmt.Errorf("provider %q in %q: %s invalid to path: %w", providerName, path, opScope, err)
+			if err := validateJSONRenamePaths(op.FromPath, op.ToPath); err != nil {
+				return invalidPath("rename paths", err)
 			}
 		default:
 			return fmt.Errorf("provider %q in %q: %s unsupported json op %q", providerName, path, opScope, op.Op)
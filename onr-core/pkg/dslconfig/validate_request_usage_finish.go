@@ -49,6 +49,18 @@ func validateRequestTransform(path, providerName, scope string, t RequestTransfo
 		return t, nil
 	}
 	switch mode {
+	case ReqMapModeTemplate:
+		if t.ReqTemplate == nil {
+			return RequestTransform{}, validationIssue(
+				fmt.Errorf("provider %q in %q: %s req_map template requires a req_template { ... } block", providerName, path, scope),
+				scope,
+				"req_map",
+			)
+		}
+		if err := validateJSONTemplateSample(path, providerName, scope, "req_template", t.ReqTemplate); err != nil {
+			return RequestTransform{}, err
+		}
+		return t, nil
 	case "openai_chat_to_openai_responses":
 		return t, nil
 	case "openai_chat_to_anthropic_messages":
@@ -100,14 +112,18 @@ func validateRequestJSONOps(path, providerName, scope string, ops []JSONOp) erro
 // includes the op index for error messages.
 func validateRequestJSONOp(path, providerName, opScope string, op JSONOp) error {
 	invalidPath := func(kind string, err error) error {
-		return fmt.Errorf("provider %q in %q: %s invalid %s: %w", providerName, path, opScope, kind, err)
+		return validationIssue(
+			fmt.Errorf("provider %q in %q: %s invalid %s: %w", providerName, path, opScope, kind, err),
+			opScope,
+			op.Op,
+		)
 	}
 	requireErr := func(msg string) error {
 		return fmt.Errorf("provider %q in %q: %s %s", providerName, path, opScope, msg)
 	}
 	switch strings.ToLower(strings.TrimSpace(op.Op)) {
 	case jsonOpDelWithCond:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if strings.TrimSpace(op.FieldName) == "" {
@@ -117,53 +133,56 @@ func validateRequestJSONOp(path, providerName, opScope string, op JSONOp) error
 			return requireErr("json_del_with_condition requires at least one pattern")
 		}
 	case jsonOpDel, jsonOpWrapInputText:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 	case jsonOpSet, jsonOpReplace, jsonOpSetIfAbsent:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if err := validateJSONValueExpr(op.ValueExpr); err != nil {
 			return invalidPath("value expression", err)
 		}
 	case jsonOpDelIfMissing:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
-		if _, err := parseObjectPath(op.FromPath); err != nil {
+		if _, err := parseJSONPath(op.FromPath); err != nil {
 			return invalidPath("required path", err)
 		}
 	case jsonOpSetHeaderVals:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if strings.TrimSpace(op.HeaderName) == "" {
 			return requireErr("json_set_header_values requires header name")
 		}
 	case jsonOpFilterValues, jsonOpKeepValues:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if len(op.Patterns) == 0 {
 			return requireErr(op.Op + " requires at least one pattern")
 		}
 	case jsonOpRename:
-		if _, err := parseObjectPath(op.FromPath); err != nil {
+		if _, err := parseJSONPath(op.FromPath); err != nil {
 			return invalidPath("from path", err)
 		}
-		if _, err := parseObjectPath(op.ToPath); err != nil {
+		if _, err := parseJSONPath(op.ToPath); err != nil {
 			return invalidPath("to path", err)
 		}
+		if err := validateJSONRenamePaths(op.FromPath, op.ToPath); err != nil {
+			return invalidPath("rename paths", err)
+		}
 	case jsonOpMapValue:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if err := validateJSONValueExpr(op.ValueExpr); err != nil {
 			return invalidPath("value expression", err)
 		}
 	case jsonOpClamp:
-		if _, err := parseObjectPath(op.Path); err != nil {
+		if _, err := parseJSONPath(op.Path); err != nil {
 			return invalidPath("json path", err)
 		}
 		if op.ClampRange == nil || op.ClampRange.Max < op.ClampRange.Min {
@@ -262,6 +262,15 @@ func ValidateStringExpr(expr string) error {
 		_, err := validateTemplateExpr(raw)
 		return err
 	}
+	if name, args, ok := splitFuncCall(raw); ok {
+		return validateFuncCall(name, args)
+	}
+	if strings.HasPrefix(raw, exprEnvPrefix) {
+		return fmt.Errorf("unknown variable %q: $env only reads %s<NAME> variables", raw, DSLEnvPrefix)
+	}
+	if strings.HasPrefix(raw, "$") {
+		return fmt.Errorf("unknown variable %q", raw)
+	}
 	return fmt.Errorf("unsupported expression %q", raw)
 }
 
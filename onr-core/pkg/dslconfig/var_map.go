@@ -0,0 +1,459 @@
+package dslconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+// VarMap is one nginx-style `map <source> $<name> { ... }` block. The output variable
+// $<name> can be used wherever a string expression is accepted.
+//
+// Lookup order follows nginx: exact keys first, then regex keys in declaration order,
+// then default. Without a default an unmatched source yields "".
+type VarMap struct {
+	Name       string
+	SourceExpr string
+	// Exact maps a literal source value to a value expression.
+	Exact   map[string]string
+	Regexps []VarMapRegexp
+	// DefaultExpr is only meaningful when HasDefault is true.
+	DefaultExpr string
+	HasDefault  bool
+}
+
+// VarMapRegexp is a `~pattern` (case-sensitive) or `~*pattern` (case-insensitive) key.
+type VarMapRegexp struct {
+	Pattern         string
+	CaseInsensitive bool
+	ValueExpr       string
+	Regexp          *regexp.Regexp
+}
+
+// ProviderVars holds the map blocks visible to one provider: global (top-level) maps
+// plus the provider's own maps, which shadow global ones with the same name.
+// It implements dslmeta.VarResolver.
+type ProviderVars struct {
+	Maps map[string]VarMap
+}
+
+type varMapRegistry map[string]VarMap
+
+var (
+	userVarNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	userVarExprRe = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*$`)
+	templateVarRe = regexp.MustCompile(`(^|[^\\])\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}`)
+)
+
+// isUserVariable reports whether expr is a user variable reference such as $deployment.
+// Built-in variables always contain a dot, so the two never collide.
+func isUserVariable(expr string) bool {
+	return userVarExprRe.MatchString(strings.TrimSpace(expr))
+}
+
+// ResolveVar evaluates the map named name for the current request.
+func (v ProviderVars) ResolveVar(name string, meta *dslmeta.Meta) (string, bool) {
+	m, ok := v.Maps[name]
+	if !ok {
+		return "", false
+	}
+	source, _ := evalStringExprValue(m.SourceExpr, meta, false)
+	valueExpr, ok := m.lookup(source)
+	if !ok {
+		return "", true
+	}
+	out, _ := evalStringExprValue(valueExpr, meta, false)
+	return out, true
+}
+
+func (m VarMap) lookup(source string) (string, bool) {
+	if v, ok := m.Exact[source]; ok {
+		return v, true
+	}
+	for _, re := range m.Regexps {
+		if re.Regexp != nil && re.Regexp.MatchString(source) {
+			return re.ValueExpr, true
+		}
+	}
+	if m.HasDefault {
+		return m.DefaultExpr, true
+	}
+	return "", false
+}
+
+func evalUserVariable(expr string, meta *dslmeta.Meta) string {
+	if meta == nil || meta.Vars == nil {
+		return ""
+	}
+	v, _ := meta.Vars.ResolveVar(strings.TrimPrefix(strings.TrimSpace(expr), "$"), meta)
+	return v
+}
+
+// parseGlobalVarMaps collects top-level map blocks. Like usage_mode, a map declared
+// outside provider blocks is visible to every provider.
+func parseGlobalVarMaps(path string, content string) (varMapRegistry, error) {
+	s := newScanner(path, content)
+	maps := varMapRegistry{}
+	for {
+		tok := s.nextNonTrivia()
+		switch tok.kind {
+		case tokEOF:
+			return maps, nil
+		case tokIdent:
+			if tok.text != "map" {
+				if err := skipStmtOrBlock(s); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			m, err := parseVarMapBlock(s, tok)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := maps[m.Name]; exists {
+				return nil, s.errAt(tok, fmt.Sprintf("duplicate map variable $%s", m.Name))
+			}
+			maps[m.Name] = m
+		}
+	}
+}
+
+// parseProviderVarMapsFromContent collects provider-level map blocks in a separate pass,
+// like metadata and guard, so the phase parsers keep skipping them.
+func parseProviderVarMapsFromContent(path string, content string, providerName string) (varMapRegistry, error) {
+	s := newScanner(path, content)
+	want := normalizeProviderName(providerName)
+	for {
+		tok := s.nextNonTrivia()
+		if tok.kind == tokEOF {
+			return varMapRegistry{}, nil
+		}
+		if tok.kind != tokIdent || tok.text != providerKeyword {
+			continue
+		}
+		nameTok := s.nextNonTrivia()
+		if nameTok.kind != tokString {
+			return nil, s.errAt(nameTok, "expected provider name string literal")
+		}
+		lb := s.nextNonTrivia()
+		if lb.kind != tokLBrace {
+			return nil, s.errAt(lb, "expected '{' after provider name")
+		}
+		if normalizeProviderName(unquoteString(nameTok.text)) != want {
+			if err := skipBalancedBraces(s); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return parseProviderVarMapsBody(s)
+	}
+}
+
+func parseProviderVarMapsBody(s *scanner) (varMapRegistry, error) {
+	maps := varMapRegistry{}
+	for {
+		tok := s.nextNonTrivia()
+		switch tok.kind {
+		case tokEOF:
+			return nil, s.errAt(tok, "unexpected EOF in provider block")
+		case tokRBrace:
+			return maps, nil
+		case tokIdent:
+			if tok.text != "map" {
+				if err := skipStmtOrBlock(s); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			m, err := parseVarMapBlock(s, tok)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := maps[m.Name]; exists {
+				return nil, s.errAt(tok, fmt.Sprintf("duplicate map variable $%s", m.Name))
+			}
+			maps[m.Name] = m
+		}
+	}
+}
+
+// parseVarMapBlock parses `map <source-expr> $<name> { entries }` after the map keyword.
+func parseVarMapBlock(s *scanner, mapTok token) (VarMap, error) {
+	header := make([]token, 0, 4)
+	for {
+		tok := s.nextNonTrivia()
+		if tok.kind == tokEOF {
+			return VarMap{}, s.errAt(tok, "unexpected EOF in map header")
+		}
+		if tok.kind == tokSemicolon || tok.kind == tokRBrace {
+			return VarMap{}, s.errAt(tok, "expected '{' after map variable")
+		}
+		if tok.kind == tokLBrace {
+			break
+		}
+		header = append(header, tok)
+	}
+	n := len(header)
+	if n < 3 || header[n-2].text != "$" || header[n-1].kind != tokIdent || header[n-2].pos+1 != header[n-1].pos {
+		return VarMap{}, s.errAt(mapTok, "map expects: map <source> $<variable> { ... }")
+	}
+	name := header[n-1].text
+	if !userVarNameRe.MatchString(name) {
+		return VarMap{}, s.errAt(header[n-1], fmt.Sprintf("invalid map variable name $%s (use letters, digits and '_')", name))
+	}
+	source := strings.TrimSpace(s.input[header[0].pos:header[n-2].pos])
+	if err := ValidateStringExpr(source); err != nil {
+		return VarMap{}, s.errAt(header[0], fmt.Sprintf("map $%s source: %v", name, err))
+	}
+	m := VarMap{Name: name, SourceExpr: source, Exact: map[string]string{}}
+	for {
+		tok := s.nextNonTrivia()
+		switch {
+		case tok.kind == tokEOF:
+			return VarMap{}, s.errAt(tok, "unexpected EOF in map block")
+		case tok.kind == tokRBrace:
+			return m, nil
+		case tok.kind == tokString:
+			key := unquoteString(tok.text)
+			value, err := parseVarMapValue(s, tok, name)
+			if err != nil {
+				return VarMap{}, err
+			}
+			if _, exists := m.Exact[key]; exists {
+				return VarMap{}, s.errAt(tok, fmt.Sprintf("map $%s has duplicate key %q", name, key))
+			}
+			m.Exact[key] = value
+		case tok.kind == tokIdent && tok.text == "default":
+			value, err := parseVarMapValue(s, tok, name)
+			if err != nil {
+				return VarMap{}, err
+			}
+			if m.HasDefault {
+				return VarMap{}, s.errAt(tok, fmt.Sprintf("map $%s has duplicate default", name))
+			}
+			m.DefaultExpr = value
+			m.HasDefault = true
+		case tok.kind == tokOther && tok.text == "~":
+			re, err := scanVarMapRegexp(s, tok, name)
+			if err != nil {
+				return VarMap{}, err
+			}
+			value, err := parseVarMapValue(s, tok, name)
+			if err != nil {
+				return VarMap{}, err
+			}
+			re.ValueExpr = value
+			m.Regexps = append(m.Regexps, re)
+		default:
+			return VarMap{}, s.errAt(tok, fmt.Sprintf("map $%s entry must start with a string literal, ~regex or default", name))
+		}
+	}
+}
+
+func parseVarMapValue(s *scanner, keyTok token, name string) (string, error) {
+	value, err := consumeExprUntilSemicolon(s)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", s.errAt(keyTok, fmt.Sprintf("map $%s entry requires a value", name))
+	}
+	if err := ValidateStringExpr(value); err != nil {
+		return "", s.errAt(keyTok, fmt.Sprintf("map $%s value: %v", name, err))
+	}
+	return value, nil
+}
+
+// scanVarMapRegexp reads the pattern after '~': `~*` makes it case-insensitive, and the
+// pattern is either a string literal or raw text up to the next whitespace.
+func scanVarMapRegexp(s *scanner, tildeTok token, name string) (VarMapRegexp, error) {
+	var re VarMapRegexp
+	if s.i < len(s.input) && s.input[s.i] == '*' {
+		re.CaseInsensitive = true
+		s.i++
+	}
+	if s.i < len(s.input) && (s.input[s.i] == '"' || s.input[s.i] == '\'') {
+		strTok := s.next()
+		if strTok.kind != tokString {
+			return VarMapRegexp{}, s.errAt(tildeTok, fmt.Sprintf("map $%s has an unterminated regex literal", name))
+		}
+		re.Pattern = unquoteString(strTok.text)
+	} else {
+		start := s.i
+		for s.i < len(s.input) && !strings.ContainsRune(" \t\r\n;", rune(s.input[s.i])) {
+			s.i++
+		}
+		re.Pattern = s.input[start:s.i]
+	}
+	if re.Pattern == "" {
+		return VarMapRegexp{}, s.errAt(tildeTok, fmt.Sprintf("map $%s has an empty regex key", name))
+	}
+	pattern := re.Pattern
+	if re.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return VarMapRegexp{}, s.errAt(tildeTok, fmt.Sprintf("map $%s has invalid regex %q: %v", name, re.Pattern, err))
+	}
+	re.Regexp = compiled
+	return re, nil
+}
+
+// buildProviderVars merges global and provider-level maps and checks that every user
+// variable referenced by the maps or by the provider block is defined and acyclic.
+func buildProviderVars(path, providerName, content string, global, local varMapRegistry) (ProviderVars, error) {
+	merged := make(map[string]VarMap, len(global)+len(local))
+	for name, m := range global {
+		merged[name] = m
+	}
+	for name, m := range local {
+		merged[name] = m
+	}
+	if err := validateVarMapGraph(path, providerName, merged); err != nil {
+		return ProviderVars{}, err
+	}
+	if err := validateProviderUserVarRefs(path, providerName, content, merged); err != nil {
+		return ProviderVars{}, err
+	}
+	if len(merged) == 0 {
+		return ProviderVars{}, nil
+	}
+	return ProviderVars{Maps: merged}, nil
+}
+
+func validateVarMapGraph(path, providerName string, maps map[string]VarMap) error {
+	names := make([]string, 0, len(maps))
+	for name := range maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	deps := make(map[string][]string, len(maps))
+	for _, name := range names {
+		m := maps[name]
+		exprs := []string{m.SourceExpr}
+		for _, v := range m.Exact {
+			exprs = append(exprs, v)
+		}
+		for _, re := range m.Regexps {
+			exprs = append(exprs, re.ValueExpr)
+		}
+		if m.HasDefault {
+			exprs = append(exprs, m.DefaultExpr)
+		}
+		for _, expr := range exprs {
+			for _, ref := range userVarRefs(expr) {
+				if _, ok := maps[ref]; !ok {
+					return fmt.Errorf("provider %q in %q: map $%s references undefined variable $%s", providerName, path, name, ref)
+				}
+				deps[name] = append(deps[name], ref)
+			}
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(maps))
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("provider %q in %q: map variable cycle: $%s", providerName, path, strings.Join(append(chain, name), " -> $"))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userVarRefs returns user variables referenced by an expression, including
+// ${name} placeholders inside template strings.
+func userVarRefs(expr string) []string {
+	var refs []string
+	s := newScanner("", expr)
+	var rs userVarRefScanner
+	for {
+		tok := s.next()
+		if tok.kind == tokEOF {
+			return refs
+		}
+		refs = rs.appendRefs(refs, tok)
+	}
+}
+
+// userVarRefScanner finds user variable references in a token stream: `$name` and
+// ${name} placeholders in the template string of `template("...")`, the only position
+// the evaluator expands them. Other string literals may contain `${...}` verbatim.
+type userVarRefScanner struct {
+	prev token
+	// sig holds the last two significant tokens (sig[1] is the latest).
+	sig [2]token
+}
+
+func (rs *userVarRefScanner) appendRefs(refs []string, tok token) []string {
+	switch tok.kind {
+	case tokWhitespace, tokComment:
+		rs.prev = tok
+		return refs
+	case tokIdent:
+		if rs.prev.kind == tokOther && rs.prev.text == "$" && rs.prev.pos+1 == tok.pos && userVarNameRe.MatchString(tok.text) {
+			refs = append(refs, tok.text)
+		}
+	case tokString:
+		if rs.sig[0].kind == tokIdent && rs.sig[0].text == "template" && rs.sig[1].kind == tokOther && rs.sig[1].text == "(" {
+			for _, m := range templateVarRe.FindAllStringSubmatch(tok.text, -1) {
+				refs = append(refs, m[2])
+			}
+		}
+	}
+	rs.prev = tok
+	rs.sig[0], rs.sig[1] = rs.sig[1], tok
+	return refs
+}
+
+// validateProviderUserVarRefs scans the provider block for $name and template ${name}
+// references and reports the first undefined one with its position.
+func validateProviderUserVarRefs(path, providerName, content string, maps map[string]VarMap) error {
+	blocks, err := ListProviderBlocks(path, content)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if block.Name != normalizeProviderName(providerName) {
+			continue
+		}
+		s := newScanner(path, content)
+		s.i = block.Start
+		var rs userVarRefScanner
+		for {
+			tok := s.next()
+			if tok.kind == tokEOF || tok.pos >= block.End {
+				return nil
+			}
+			for _, ref := range rs.appendRefs(nil, tok) {
+				if _, ok := maps[ref]; !ok {
+					return s.errAt(tok, fmt.Sprintf("provider %q: undefined variable $%s (declare it with a map block)", providerName, ref))
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package dslconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+const testVarMapProvider = `
+syntax "next-router/0.1";
+
+map $request.model $tier {
+  "gpt-4o-mini" "small";
+  default "large";
+}
+
+provider "azure" {
+  map $request.model $deployment {
+    "gpt-4o" "prod-4o";
+    ~"^gpt-4o-" "prod-4o-family";
+    ~*"^O1" "reasoning";
+    default "fallback";
+  }
+
+  defaults {
+    upstream_config {
+      base_url = "https://example.openai.azure.com";
+    }
+    auth {
+      auth_header_key "api-key";
+    }
+    request {
+      set_header "x-tier" $tier;
+    }
+  }
+
+  match api = "chat.completions" {
+    upstream {
+      set_path template("/openai/deployments/${deployment}/chat/completions");
+    }
+  }
+}
+`
+
+func loadVarMapProvider(t *testing.T, content string) ProviderFile {
+	t.Helper()
+	dir := writeExtendsFixture(t, map[string]string{"providers.conf": content})
+	reg := NewRegistry()
+	if _, err := reg.ReloadFromFile(filepath.Join(dir, "providers.conf")); err != nil {
+		t.Fatalf("ReloadFromFile: %v", err)
+	}
+	pf, ok := reg.GetProvider("azure")
+	if !ok {
+		t.Fatalf("azure not loaded")
+	}
+	return pf
+}
+
+func TestVarMap_ResolvesExactRegexAndDefault(t *testing.T) {
+	pf := loadVarMapProvider(t, testVarMapProvider)
+	if len(pf.Vars.Maps) != 2 {
+		t.Fatalf("maps=%v, want global tier and local deployment", pf.Vars.Maps)
+	}
+	cases := []struct {
+		model string
+		path  string
+		tier  string
+	}{
+		{model: "gpt-4o", path: "/openai/deployments/prod-4o/chat/completions", tier: "large"},
+		{model: "gpt-4o-mini", path: "/openai/deployments/prod-4o-family/chat/completions", tier: "small"},
+		{model: "o1-preview", path: "/openai/deployments/reasoning/chat/completions", tier: "large"},
+		{model: "claude", path: "/openai/deployments/fallback/chat/completions", tier: "large"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.model, func(t *testing.T) {
+			m := &dslmeta.Meta{API: "chat.completions", RequestURLPath: "/v1/chat/completions", OriginModelName: tc.model, Vars: pf.Vars}
+			if err := pf.Routing.Apply(m); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if m.RequestURLPath != tc.path {
+				t.Fatalf("path=%q want %q", m.RequestURLPath, tc.path)
+			}
+			if got := EvalStringExpr("$tier", m); got != tc.tier {
+				t.Fatalf("tier=%q want %q", got, tc.tier)
+			}
+		})
+	}
+}
+
+func TestVarMap_NoDefaultYieldsEmpty(t *testing.T) {
+	content := strings.Replace(testVarMapProvider, `    default "fallback";
+`, "", 1)
+	pf := loadVarMapProvider(t, content)
+	m := &dslmeta.Meta{OriginModelName: "claude", Vars: pf.Vars}
+	if got := EvalStringExpr("$deployment", m); got != "" {
+		t.Fatalf("deployment=%q want empty", got)
+	}
+}
+
+func TestVarMap_LocalShadowsGlobal(t *testing.T) {
+	content := strings.Replace(testVarMapProvider, `  map $request.model $deployment {`, `  map $request.model $tier {
+    default "local";
+  }
+  map $request.model $deployment {`, 1)
+	pf := loadVarMapProvider(t, content)
+	m := &dslmeta.Meta{OriginModelName: "gpt-4o-mini", Vars: pf.Vars}
+	if got := EvalStringExpr("$tier", m); got != "local" {
+		t.Fatalf("tier=%q want local", got)
+	}
+}
+
+func TestVarMap_Errors(t *testing.T) {
+	cases := map[string]struct {
+		from string
+		to   string
+		want string
+	}{
+		"undefined_variable": {
+			from: `set_header "x-tier" $tier;`,
+			to:   `set_header "x-tier" $region;`,
+			want: "undefined variable $region",
+		},
+		"undefined_template_variable": {
+			from: "${deployment}",
+			to:   "${deploy}",
+			want: "undefined variable $deploy",
+		},
+		"cycle": {
+			from: `default "fallback";`,
+			to:   `default $deployment;`,
+			want: "cycle",
+		},
+		"duplicate_key": {
+			from: `"gpt-4o" "prod-4o";`,
+			to:   `"gpt-4o" "prod-4o"; "gpt-4o" "other";`,
+			want: "duplicate",
+		},
+		"bad_regex": {
+			from: `~"^gpt-4o-"`,
+			to:   `~"^gpt-4o-("`,
+			want: "regex",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			content := strings.Replace(testVarMapProvider, tc.from, tc.to, 1)
+			if content == testVarMapProvider {
+				t.Fatalf("fixture replacement %q not applied", tc.from)
+			}
+			dir := writeExtendsFixture(t, map[string]string{"providers.conf": content})
+			_, err := ValidateProvidersFile(filepath.Join(dir, "providers.conf"))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err=%v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVarMap_PlainLiteralPlaceholdersAreNotReferences(t *testing.T) {
+	content := strings.Replace(testVarMapProvider, `set_header "x-tier" $tier;`, `set_header "x-tier" $tier;
+      set_header "x-note" "${not_a_map}";`, 1)
+	pf := loadVarMapProvider(t, content)
+	got := ""
+	for _, op := range pf.Headers.Defaults.Request {
+		if op.NameExpr == `"x-note"` {
+			got = EvalStringExpr(op.ValueExpr, &dslmeta.Meta{Vars: pf.Vars})
+		}
+	}
+	if got != "${not_a_map}" {
+		t.Fatalf("x-note=%q, plain literals must keep ${...} verbatim", got)
+	}
+}
@@ -1,5 +1,7 @@
 package dsllang
 
+import dslconfig "github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+
 // CollectDiagnostics returns DSL syntax and semantic diagnostics for text.
 func CollectDiagnostics(uri, text string) []Diagnostic {
 	return collectDiagnostics(uri, text)
@@ -65,6 +67,42 @@ func CollectHover(text string, pos Position) (*Hover, bool) {
 	return collectHover(text, pos)
 }
 
+// CollectCompletions returns completion candidates for a text position: the
+// directives allowed in the current block, built-in and named modes, enum
+// arguments, match api names and expression variables.
+func CollectCompletions(text string, pos Position) []CompletionItem {
+	return collectCompletions(text, pos)
+}
+
+// FindDefinition resolves the include path or named mode reference at pos.
+// Include targets are resolved relative to uri, which must be a file URI or an
+// absolute path.
+func FindDefinition(uri, text string, pos Position) []Location {
+	return findDefinition(uri, text, pos)
+}
+
+// FindReferences returns the uses of the named mode at pos within text.
+func FindReferences(uri, text string, pos Position, includeDeclaration bool) []Location {
+	return findReferences(uri, text, pos, includeDeclaration)
+}
+
+// CollectCodeActions returns quick fixes for diagnostics reported on text.
+func CollectCodeActions(uri, text string, diagnostics []Diagnostic) []CodeAction {
+	return collectCodeActions(uri, text, diagnostics)
+}
+
+// LintRules returns the rules checked by Lint.
+func LintRules() []LintRule {
+	return lintRulesList()
+}
+
+// Lint checks docs as one config set; named modes declared in one document may be
+// used by another. providers are the loaded providers (extends flattened) used
+// for match and usage rules; they are located in docs by provider name.
+func Lint(docs []LintDocument, providers []dslconfig.ProviderFile) []LintIssue {
+	return lint(docs, providers)
+}
+
 // CollectSemanticTokenLegend returns the token legend for CollectSemanticTokens.
 func CollectSemanticTokenLegend() SemanticTokenLegend {
 	return SemanticTokenLegend{
@@ -0,0 +1,357 @@
+package dsllang
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslspec"
+)
+
+const codeActionQuickFix = "quickfix"
+
+// maxSuggestionDistance bounds the edit distance of "did you mean" replacements.
+const maxSuggestionDistance = 3
+
+var (
+	misplacedDirectiveRe  = regexp.MustCompile(`^directive (\S+) is not allowed in \S+ block; allowed in: ([^;]+);`)
+	unknownDirectiveRe    = regexp.MustCompile(`^unknown directive in (\S+) block: (\S+)$`)
+	unknownTopDirectiveRe = regexp.MustCompile(`^unknown top-level directive: (\S+)$`)
+	unsupportedModeRe     = regexp.MustCompile(`^unsupported (\S+) mode ("(?:[^"\\]|\\.)*")$`)
+)
+
+func collectCodeActions(uri, text string, diagnostics []Diagnostic) []CodeAction {
+	toks := lex(text)
+	out := make([]CodeAction, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		var actions []CodeAction
+		msg := d.Message
+		switch {
+		case strings.HasPrefix(msg, "expected ';' after "):
+			if i := tokenIndexAt(toks, d.Range.Start); i > 0 {
+				at := tokenEnd(toks[i-1])
+				actions = append(actions, quickFix(uri, "Insert missing ';'", TextEdit{Range: Range{Start: at, End: at}, NewText: ";"}))
+			}
+		case strings.HasPrefix(msg, "missing closing '}'"):
+			end := endPosition(text)
+			insert := "}\n"
+			if !strings.HasSuffix(text, "\n") {
+				insert = "\n" + insert
+			}
+			actions = append(actions, quickFix(uri, "Insert missing '}'", TextEdit{Range: Range{Start: end, End: end}, NewText: insert}))
+		default:
+			if m := misplacedDirectiveRe.FindStringSubmatch(msg); m != nil {
+				actions = moveDirectiveActions(uri, text, toks, d.Range.Start, m[1], strings.Split(m[2], ", "))
+			} else if m := unknownDirectiveRe.FindStringSubmatch(msg); m != nil {
+				actions = replaceTokenActions(uri, toks, d.Range.Start, m[2], dslspec.DirectivesByBlock(m[1]))
+			} else if m := unknownTopDirectiveRe.FindStringSubmatch(msg); m != nil {
+				actions = replaceTokenActions(uri, toks, d.Range.Start, m[1], dslspec.DirectivesByBlock("top"))
+			} else if m := unsupportedModeRe.FindStringSubmatch(msg); m != nil {
+				if mode, err := strconv.Unquote(m[2]); err == nil {
+					actions = replaceTokenActions(uri, toks, d.Range.Start, mode, modeCandidates(text, m[1], d.Range.Start))
+				}
+			}
+		}
+		for i := range actions {
+			actions[i].Diagnostics = []Diagnostic{d}
+			actions[i].IsPreferred = i == 0
+		}
+		out = append(out, actions...)
+	}
+	return out
+}
+
+func quickFix(uri, title string, edits ...TextEdit) CodeAction {
+	return CodeAction{
+		Title: title,
+		Kind:  codeActionQuickFix,
+		Edit:  &WorkspaceEdit{Changes: map[string][]TextEdit{uri: edits}},
+	}
+}
+
+func tokenIndexAt(toks []token, pos Position) int {
+	for i, tok := range toks {
+		if tok.line == pos.Line && tok.col == pos.Character {
+			return i
+		}
+	}
+	return -1
+}
+
+func tokenEnd(tok token) Position {
+	return Position{Line: tok.line, Character: tok.col + len(tok.text)}
+}
+
+// replaceTokenActions offers the candidates closest to got as replacements for
+// the token at pos. String tokens keep their quotes.
+func replaceTokenActions(uri string, toks []token, pos Position, got string, candidates []string) []CodeAction {
+	i := tokenIndexAt(toks, pos)
+	if i < 0 {
+		return nil
+	}
+	rng := Range{Start: pos, End: tokenEnd(toks[i])}
+	if toks[i].kind == tokString && len(toks[i].text) >= 2 {
+		rng.Start.Character++
+		rng.End.Character--
+	}
+	out := make([]CodeAction, 0, 3)
+	for _, c := range closestCandidates(got, candidates, 3) {
+		out = append(out, quickFix(uri, "Replace with '"+c+"'", TextEdit{Range: rng, NewText: c}))
+	}
+	return out
+}
+
+func modeCandidates(text, directive string, pos Position) []string {
+	block := CurrentBlock(text, pos)
+	out := dslspec.ModesByDirectiveInBlock(directive, block)
+	if registry := dslspec.DirectiveModeRegistryBlockInBlock(directive, block); registry != "" {
+		out = append(out, CollectNamedModeBlocks(text, registry)...)
+	}
+	return out
+}
+
+func closestCandidates(got string, candidates []string, limit int) []string {
+	type scored struct {
+		value string
+		dist  int
+	}
+	seen := map[string]struct{}{}
+	ranked := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		if _, ok := seen[c]; ok || c == got {
+			continue
+		}
+		seen[c] = struct{}{}
+		if dist := editDistance(strings.ToLower(got), strings.ToLower(c)); dist <= maxSuggestionDistance {
+			ranked = append(ranked, scored{value: c, dist: dist})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].dist != ranked[j].dist {
+			return ranked[i].dist < ranked[j].dist
+		}
+		return ranked[i].value < ranked[j].value
+	})
+	out := make([]string, 0, limit)
+	for _, r := range ranked {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, r.value)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// moveDirectiveActions moves a misplaced statement into a target block that is
+// either a child of its current block or a sibling of it. An existing
+// `target { ... }` block is reused; otherwise a new one is created.
+func moveDirectiveActions(uri, text string, toks []token, pos Position, directive string, allowed []string) []CodeAction {
+	start := tokenIndexAt(toks, pos)
+	if start < 0 {
+		return nil
+	}
+	end := statementEnd(toks, start)
+	if end < 0 {
+		return nil
+	}
+	stmtRange := Range{Start: pos, End: tokenEnd(toks[end])}
+	stmtText := textInRange(text, stmtRange)
+	indent := leadingIndent(lineAt(text, pos.Line))
+	stack := CurrentBlockStack(text, pos)
+	if len(stack) == 0 {
+		return nil
+	}
+	current := stack[len(stack)-1]
+	parent := "top"
+	if len(stack) > 1 {
+		parent = stack[len(stack)-2]
+	}
+
+	out := make([]CodeAction, 0, len(allowed))
+	for _, target := range allowed {
+		target = strings.TrimSpace(target)
+		title := "Move " + directive + " into " + target + " { ... }"
+		switch {
+		case blockAllowsChildBlock(current, target) && !blockDirectiveNeedsHeader(current, target):
+			if closing := siblingBlockClose(toks, start, target); closing >= 0 {
+				out = append(out, quickFix(uri, title,
+					deleteStatementEdit(text, stmtRange),
+					insertBeforeCloseEdit(text, toks[closing], stmtText),
+				))
+				continue
+			}
+			wrapped := target + " {\n" + indent + "  " + stmtText + "\n" + indent + "}"
+			out = append(out, quickFix(uri, title, TextEdit{Range: stmtRange, NewText: wrapped}))
+		case blockAllowsChildBlock(parent, target) && !blockDirectiveNeedsHeader(parent, target):
+			open := enclosingOpen(toks, start)
+			if open < 0 {
+				continue
+			}
+			if closing := siblingBlockClose(toks, open, target); closing >= 0 {
+				out = append(out, quickFix(uri, title,
+					deleteStatementEdit(text, stmtRange),
+					insertBeforeCloseEdit(text, toks[closing], stmtText),
+				))
+				continue
+			}
+			currentClose := statementEnd(toks, open)
+			if currentClose < 0 {
+				continue
+			}
+			at := tokenEnd(toks[currentClose])
+			outer := leadingIndent(lineAt(text, toks[currentClose].line))
+			block := "\n" + outer + target + " {\n" + outer + "  " + stmtText + "\n" + outer + "}"
+			out = append(out, quickFix(uri, title,
+				deleteStatementEdit(text, stmtRange),
+				TextEdit{Range: Range{Start: at, End: at}, NewText: block},
+			))
+		}
+	}
+	return out
+}
+
+// statementEnd returns the index of the ';' or closing '}' that ends the
+// statement starting at toks[start].
+func statementEnd(toks []token, start int) int {
+	depth := 0
+	for i := start; i < len(toks); i++ {
+		switch toks[i].kind {
+		case tokSemicolon:
+			if depth == 0 {
+				return i
+			}
+		case tokLBrace:
+			depth++
+		case tokRBrace:
+			depth--
+			if depth == 0 {
+				return i
+			}
+			if depth < 0 {
+				return -1
+			}
+		case tokEOF:
+			return -1
+		}
+	}
+	return -1
+}
+
+// enclosingOpen returns the index of the '{' that opens the block containing
+// toks[idx], or -1 at top level.
+func enclosingOpen(toks []token, idx int) int {
+	depth := 0
+	for i := idx - 1; i >= 0; i-- {
+		switch toks[i].kind {
+		case tokRBrace:
+			depth++
+		case tokLBrace:
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// siblingBlockClose finds a `name { ... }` block in the same parent block as
+// toks[idx] and returns the index of its closing brace, or -1.
+func siblingBlockClose(toks []token, idx int, name string) int {
+	open := enclosingOpen(toks, idx)
+	if open < 0 {
+		return -1
+	}
+	depth := 0
+	for i := open + 1; i < len(toks); i++ {
+		switch toks[i].kind {
+		case tokLBrace:
+			depth++
+		case tokRBrace:
+			if depth == 0 {
+				return -1
+			}
+			depth--
+		case tokIdent:
+			if depth == 0 && toks[i].text == name && isStatementStart(toks, i) && i+1 < len(toks) && toks[i+1].kind == tokLBrace {
+				return statementEnd(toks, i)
+			}
+		case tokEOF:
+			return -1
+		}
+	}
+	return -1
+}
+
+// deleteStatementEdit removes the statement, including its whole line when
+// nothing else is on it.
+func deleteStatementEdit(text string, rng Range) TextEdit {
+	line := lineAt(text, rng.Start.Line)
+	if rng.Start.Line == rng.End.Line &&
+		strings.TrimSpace(line[:rng.Start.Character]) == "" &&
+		strings.TrimSpace(line[rng.End.Character:]) == "" {
+		return TextEdit{Range: Range{
+			Start: Position{Line: rng.Start.Line},
+			End:   Position{Line: rng.Start.Line + 1},
+		}}
+	}
+	return TextEdit{Range: rng}
+}
+
+func insertBeforeCloseEdit(text string, closing token, stmtText string) TextEdit {
+	line := lineAt(text, closing.line)
+	at := Position{Line: closing.line, Character: closing.col}
+	if strings.TrimSpace(line[:closing.col]) == "" {
+		at.Character = 0
+		return TextEdit{Range: Range{Start: at, End: at}, NewText: leadingIndent(line) + "  " + stmtText + "\n"}
+	}
+	return TextEdit{Range: Range{Start: at, End: at}, NewText: stmtText + " "}
+}
+
+func leadingIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func textInRange(text string, rng Range) string {
+	start, end := offsetAt(text, rng.Start), offsetAt(text, rng.End)
+	if start > end {
+		return ""
+	}
+	return text[start:end]
+}
+
+// offsetAt converts a zero-based position to a byte offset in text.
+func offsetAt(text string, pos Position) int {
+	line := 0
+	for i := 0; i < len(text); i++ {
+		if line == pos.Line {
+			return min(i+pos.Character, len(text))
+		}
+		if text[i] == '\n' {
+			line++
+		}
+	}
+	return len(text)
+}
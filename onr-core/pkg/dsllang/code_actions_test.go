@@ -0,0 +1,99 @@
+package dsllang_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+)
+
+const codeActionURI = "file:///tmp/x.conf"
+
+// applyEdits applies non-overlapping edits to text.
+func applyEdits(t *testing.T, text string, edits []dsllang.TextEdit) string {
+	t.Helper()
+	lineStarts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(p dsllang.Position) int {
+		if p.Line >= len(lineStarts) {
+			return len(text)
+		}
+		return min(lineStarts[p.Line]+p.Character, len(text))
+	}
+	sorted := append([]dsllang.TextEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return offset(sorted[i].Range.Start) > offset(sorted[j].Range.Start) })
+	for _, e := range sorted {
+		text = text[:offset(e.Range.Start)] + e.NewText + text[offset(e.Range.End):]
+	}
+	return text
+}
+
+func firstFix(t *testing.T, text, contains string) dsllang.CodeAction {
+	t.Helper()
+	diags := dsllang.AnalyzeSyntax(text)
+	diags = append(diags, dsllang.AnalyzeSemanticModes(text)...)
+	for _, action := range dsllang.CollectCodeActions(codeActionURI, text, diags) {
+		if strings.Contains(action.Title, contains) {
+			return action
+		}
+	}
+	t.Fatalf("no code action containing %q for diagnostics %+v", contains, diags)
+	return dsllang.CodeAction{}
+}
+
+func TestCodeActionInsertSemicolon(t *testing.T) {
+	text := "provider \"x\" {\n  defaults {\n    auth {\n      auth_bearer\n    }\n  }\n}\n"
+	action := firstFix(t, text, "';'")
+	if action.Kind != "quickfix" || !action.IsPreferred || len(action.Diagnostics) != 1 {
+		t.Fatalf("unexpected action: %+v", action)
+	}
+	got := applyEdits(t, text, action.Edit.Changes[codeActionURI])
+	if !strings.Contains(got, "auth_bearer;\n") || len(dsllang.AnalyzeSyntax(got)) != 0 {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+}
+
+func TestCodeActionMoveDirectiveIntoBlock(t *testing.T) {
+	text := "provider \"x\" {\n  defaults {\n    response {\n      req_map openai_chat_to_anthropic_messages;\n    }\n  }\n}\n"
+	action := firstFix(t, text, "Move req_map")
+	got := applyEdits(t, text, action.Edit.Changes[codeActionURI])
+	if len(dsllang.AnalyzeSyntax(got)) != 0 || !strings.Contains(got, "request {\n") {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+
+	text = "provider \"x\" {\n  defaults {\n    request {\n      json_del \"$.user\";\n    }\n    response {\n      req_map openai_chat_to_anthropic_messages;\n    }\n  }\n}\n"
+	action = firstFix(t, text, "Move req_map")
+	got = applyEdits(t, text, action.Edit.Changes[codeActionURI])
+	want := "    request {\n      json_del \"$.user\";\n      req_map openai_chat_to_anthropic_messages;\n    }\n    response {\n    }\n"
+	if !strings.Contains(got, want) || len(dsllang.AnalyzeSyntax(got)) != 0 {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+}
+
+func TestCodeActionReplaceTypos(t *testing.T) {
+	text := "provider \"x\" {\n  defaults {\n    request {\n      json_dell \"$.user\";\n    }\n  }\n}\n"
+	action := firstFix(t, text, "Replace with 'json_del'")
+	if got := applyEdits(t, text, action.Edit.Changes[codeActionURI]); len(dsllang.AnalyzeSyntax(got)) != 0 {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+
+	text = "provider \"x\" {\n  defaults {\n    request {\n      req_map \"openai_chat_to_anthropic_mesages\";\n    }\n  }\n}\n"
+	action = firstFix(t, text, "Replace with 'openai_chat_to_anthropic_messages'")
+	got := applyEdits(t, text, action.Edit.Changes[codeActionURI])
+	if !strings.Contains(got, `req_map "openai_chat_to_anthropic_messages";`) || len(dsllang.AnalyzeSemanticModes(got)) != 0 {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+}
+
+func TestCodeActionInsertClosingBrace(t *testing.T) {
+	text := "provider \"x\" {\n  defaults {\n  }\n"
+	action := firstFix(t, text, "'}'")
+	if got := applyEdits(t, text, action.Edit.Changes[codeActionURI]); len(dsllang.AnalyzeSyntax(got)) != 0 {
+		t.Fatalf("unexpected fix:\n%s", got)
+	}
+}
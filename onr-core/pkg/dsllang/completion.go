@@ -0,0 +1,231 @@
+package dsllang
+
+import (
+	"sort"
+	"strings"
+
+	dslconfig "github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslspec"
+)
+
+// completionContext is the statement being typed at the completion position.
+type completionContext struct {
+	block  string
+	prefix string
+	// stmt holds the statement tokens before the word being typed.
+	stmt []token
+	// quoted is true when the word being typed is inside an open string literal.
+	quoted bool
+	rng    Range
+}
+
+func collectCompletions(text string, pos Position) []CompletionItem {
+	line := lineAt(text, pos.Line)
+	ch := pos.Character
+	if ch < 0 {
+		ch = 0
+	}
+	if ch > len(line) {
+		ch = len(line)
+	}
+	start := ch
+	for start > 0 && isCompletionWordChar(line[start-1]) {
+		start--
+	}
+	ctx := completionContext{
+		block:  CurrentBlock(text, Position{Line: pos.Line, Character: start}),
+		prefix: line[start:ch],
+		rng: Range{
+			Start: Position{Line: pos.Line, Character: start},
+			End:   Position{Line: pos.Line, Character: ch},
+		},
+	}
+	if strings.HasPrefix(ctx.prefix, "$") {
+		return variableCompletions(text, ctx)
+	}
+	ctx.stmt, ctx.quoted = statementBefore(lex(text), Position{Line: pos.Line, Character: start})
+	if len(ctx.stmt) == 0 {
+		if ctx.quoted {
+			return nil
+		}
+		return directiveCompletions(ctx)
+	}
+	return argumentCompletions(text, ctx)
+}
+
+func isCompletionWordChar(b byte) bool {
+	return isWordChar(b) || b == '-' || b == '$'
+}
+
+// statementBefore returns the tokens of the statement that ends at pos. A string
+// token left open at pos is dropped and reported as quoted.
+func statementBefore(toks []token, pos Position) ([]token, bool) {
+	end := 0
+	for end < len(toks) && toks[end].kind != tokEOF && !tokenAfterPosition(toks[end], pos) {
+		if toks[end].line == pos.Line && toks[end].col == pos.Character {
+			break
+		}
+		end++
+	}
+	quoted := false
+	if end > 0 {
+		last := toks[end-1]
+		if last.kind == tokString && last.line == pos.Line && last.col == pos.Character-1 {
+			quoted = true
+			end--
+		}
+	}
+	start := end
+	for start > 0 {
+		switch toks[start-1].kind {
+		case tokLBrace, tokRBrace, tokSemicolon:
+			return toks[start:end], quoted
+		}
+		start--
+	}
+	return toks[:end], quoted
+}
+
+func directiveCompletions(ctx completionContext) []CompletionItem {
+	out := make([]CompletionItem, 0, 16)
+	for _, name := range dslspec.DirectivesByBlock(ctx.block) {
+		if !strings.HasPrefix(name, ctx.prefix) {
+			continue
+		}
+		detail := "directive"
+		if blockAllowsChildBlock(ctx.block, name) {
+			detail = "block"
+		}
+		item := newCompletionItem(name, CompletionKindKeyword, detail, name, ctx.rng)
+		if doc, ok := dslspec.DirectiveHoverInBlock(name, ctx.block); ok {
+			item.Documentation = &MarkupContent{Kind: "markdown", Value: doc}
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func argumentCompletions(text string, ctx completionContext) []CompletionItem {
+	directive := ctx.stmt[0]
+	if directive.kind != tokIdent {
+		return nil
+	}
+	args := ctx.stmt[1:]
+	if directive.text == "match" {
+		n := len(args)
+		if n >= 2 && args[n-1].kind == tokOther && args[n-1].text == "=" && args[n-2].text == "api" {
+			return valueCompletions(dslconfig.SupportedMatchAPIs(), CompletionKindValue, "match api", ctx, true)
+		}
+		return nil
+	}
+
+	argIndex := 0
+	for _, arg := range args {
+		if arg.kind == tokIdent || arg.kind == tokString {
+			argIndex++
+		}
+	}
+	out := make([]CompletionItem, 0, 8)
+	if argIndex == 0 {
+		out = append(out, valueCompletions(dslspec.ModesByDirectiveInBlock(directive.text, ctx.block), CompletionKindEnumMember, "built-in mode", ctx, false)...)
+		if registry := dslspec.DirectiveModeRegistryBlockInBlock(directive.text, ctx.block); registry != "" {
+			out = append(out, valueCompletions(CollectNamedModeBlocks(text, registry), CompletionKindModule, registry, ctx, false)...)
+		}
+	}
+	out = append(out, valueCompletions(dslspec.DirectiveArgEnumValuesInBlock(directive.text, ctx.block, argIndex), CompletionKindEnumMember, directive.text, ctx, false)...)
+	return dedupeCompletions(out)
+}
+
+// valueCompletions returns values matching the typed prefix. Values that are not
+// plain identifiers, and all values when alwaysQuote is set, are inserted as
+// string literals unless a string is already open.
+func valueCompletions(values []string, kind int, detail string, ctx completionContext, alwaysQuote bool) []CompletionItem {
+	out := make([]CompletionItem, 0, len(values))
+	for _, v := range values {
+		if !strings.HasPrefix(v, ctx.prefix) {
+			continue
+		}
+		insert := v
+		if !ctx.quoted && (alwaysQuote || !isPlainIdent(v)) {
+			insert = `"` + v + `"`
+		}
+		out = append(out, newCompletionItem(v, kind, detail, insert, ctx.rng))
+	}
+	return out
+}
+
+func variableCompletions(text string, ctx completionContext) []CompletionItem {
+	out := make([]CompletionItem, 0, 16)
+	add := func(values []string, kind int, detail string) {
+		for _, v := range values {
+			if strings.HasPrefix(v, ctx.prefix) {
+				out = append(out, newCompletionItem(v, kind, detail, v, ctx.rng))
+			}
+		}
+	}
+	add(dslconfig.BuiltinExpressionVariables(), CompletionKindVariable, "built-in variable")
+	add(dslconfig.PrefixedExpressionVariables(), CompletionKindVariable, "variable prefix")
+	add(collectMapVariables(text), CompletionKindVariable, "map variable")
+	return dedupeCompletions(out)
+}
+
+// collectMapVariables returns the $name variables declared by map blocks.
+func collectMapVariables(text string) []string {
+	toks := lex(text)
+	out := make([]string, 0, 4)
+	for i := 0; i < len(toks); i++ {
+		if toks[i].kind != tokIdent || toks[i].text != "map" || !isStatementStart(toks, i) {
+			continue
+		}
+		name := ""
+		for j := i + 1; j+1 < len(toks); j++ {
+			tok := toks[j]
+			if tok.kind == tokLBrace || tok.kind == tokRBrace || tok.kind == tokSemicolon || tok.kind == tokEOF {
+				break
+			}
+			next := toks[j+1]
+			if tok.kind == tokOther && tok.text == "$" && next.kind == tokIdent && next.line == tok.line && next.col == tok.col+1 && !strings.Contains(next.text, ".") {
+				name = "$" + next.text
+			}
+		}
+		if name != "" {
+			out = append(out, name)
+		}
+	}
+	return DedupeSortedStrings(out)
+}
+
+func newCompletionItem(label string, kind int, detail, insert string, rng Range) CompletionItem {
+	return CompletionItem{
+		Label:    label,
+		Kind:     kind,
+		Detail:   detail,
+		TextEdit: &TextEdit{Range: rng, NewText: insert},
+	}
+}
+
+func dedupeCompletions(in []CompletionItem) []CompletionItem {
+	seen := map[string]struct{}{}
+	out := in[:0]
+	for _, item := range in {
+		if _, ok := seen[item.Label]; ok {
+			continue
+		}
+		seen[item.Label] = struct{}{}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	return out
+}
+
+func isPlainIdent(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentStart(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package dsllang_test
+
+import (
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+)
+
+func completionLabels(items []dsllang.CompletionItem) map[string]dsllang.CompletionItem {
+	out := make(map[string]dsllang.CompletionItem, len(items))
+	for _, item := range items {
+		out[item.Label] = item
+	}
+	return out
+}
+
+func TestCollectCompletionsDirectivesForBlock(t *testing.T) {
+	text := "provider \"x\" {\n  defaults {\n    request {\n      json_\n    }\n  }\n}\n"
+	items := completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 3, Character: 11}))
+	if _, ok := items["json_set"]; !ok {
+		t.Fatalf("expected json_set in request completions, got %v", items)
+	}
+	if _, ok := items["req_map"]; ok {
+		t.Fatalf("prefix filter not applied: %v", items)
+	}
+	item := items["json_del"]
+	if item.Documentation == nil || item.TextEdit == nil || item.TextEdit.Range.Start.Character != 6 || item.TextEdit.NewText != "json_del" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+
+	items = completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 2, Character: 4}))
+	if items["request"].Detail != "block" || items["upstream"].Label == "" {
+		t.Fatalf("expected defaults child blocks, got %v", items)
+	}
+}
+
+func TestCollectCompletionsModesAndEnums(t *testing.T) {
+	text := "usage_mode \"my_usage\" {\n  usage_extract custom;\n}\n" +
+		"provider \"x\" {\n  defaults {\n    metrics {\n      usage_extract ;\n    }\n    balance {\n      method ;\n    }\n  }\n}\n"
+	items := completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 6, Character: 20}))
+	if items["custom"].Kind != dsllang.CompletionKindEnumMember {
+		t.Fatalf("expected built-in mode custom, got %v", items)
+	}
+	if items["my_usage"].Detail != "usage_mode" {
+		t.Fatalf("expected named usage_mode, got %v", items)
+	}
+
+	items = completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 9, Character: 13}))
+	if _, ok := items["GET"]; !ok || len(items) != 2 {
+		t.Fatalf("expected method enum values, got %v", items)
+	}
+}
+
+func TestCollectCompletionsMatchAPI(t *testing.T) {
+	text := "provider \"x\" {\n  match api = chat\n}\n"
+	items := completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 1, Character: 18}))
+	item, ok := items["chat.completions"]
+	if !ok || len(items) != 1 || item.TextEdit.NewText != `"chat.completions"` {
+		t.Fatalf("unexpected api completions: %v", items)
+	}
+
+	text = "provider \"x\" {\n  match api = \"gemini.\n}\n"
+	items = completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 1, Character: 22}))
+	item, ok = items["gemini.generateContent"]
+	if !ok || item.TextEdit.NewText != "gemini.generateContent" || item.TextEdit.Range.Start.Character != 15 {
+		t.Fatalf("unexpected quoted api completion: %v", items)
+	}
+}
+
+func TestCollectCompletionsVariables(t *testing.T) {
+	text := "map $request.access_key $tier {\n  default \"standard\";\n}\n" +
+		"provider \"x\" {\n  defaults {\n    request {\n      set_header \"x-tier\" $\n      set_header \"x-model\" $request.mo\n    }\n  }\n}\n"
+	items := completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 6, Character: 27}))
+	for _, want := range []string{"$tier", "$channel.key", "$request.header."} {
+		if _, ok := items[want]; !ok {
+			t.Fatalf("missing %s in %v", want, items)
+		}
+	}
+	if _, ok := items["$request.access_key"]; !ok {
+		t.Fatalf("missing built-in $request.access_key in %v", items)
+	}
+
+	items = completionLabels(dsllang.CollectCompletions(text, dsllang.Position{Line: 7, Character: 38}))
+	if len(items) != 2 || items["$request.model_mapped"].TextEdit.Range.Start.Character != 27 {
+		t.Fatalf("unexpected variable completions: %v", items)
+	}
+}
@@ -329,6 +329,11 @@ func initDirectiveSpecsFromMetadata() {
 		defer delete(visiting, block)
 
 		names := dslspec.DirectivesByBlock(block)
+		if len(names) == 0 {
+			// Blocks without known children (e.g. req_template JSON bodies) are opaque.
+			cache[block] = nil
+			return nil
+		}
 		specs := make(map[string]directiveSpec, len(names))
 		for _, name := range names {
 			spec := directiveSpec{name: name}
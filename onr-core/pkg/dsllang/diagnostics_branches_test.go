@@ -90,3 +90,18 @@ func TestDiagnostics_MetadataBlock_NoFalseBlockDiagnostic(t *testing.T) {
 		}
 	}
 }
+
+func TestDiagnostics_GuardBlock_NoFalseDiagnostic(t *testing.T) {
+	text := "syntax \"next-router/0.1\";\n\nprovider \"openai\" {\n  defaults {\n    guard {\n      guard_builtin email action=redact on=both;\n      guard_keywords codename \"Falcon\" action=reject;\n    }\n  }\n  match api = \"chat.completions\" {\n    guard {\n      guard_regex host \"corp\\\\.example\" on=response;\n    }\n  }\n}\n"
+	if diags := dsllang.AnalyzeSyntax(text); len(diags) != 0 {
+		t.Fatalf("unexpected guard diagnostics: %+v", diags)
+	}
+}
+
+func TestDiagnostics_MapBlocksAccepted(t *testing.T) {
+	text := "map $request.model $tier {\n  \"gpt-4o-mini\" \"small\";\n  default \"large\";\n}\n" +
+		"provider \"x\" {\n  map $request.model $deployment {\n    ~*\"^o1\" \"reasoning\";\n    default \"fallback\";\n  }\n  match api = \"chat.completions\" {\n    upstream {\n      set_path template(\"/deployments/${deployment}\");\n    }\n  }\n}\n"
+	if diags := dsllang.AnalyzeSyntax(text); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for map blocks, got: %+v", diags)
+	}
+}
@@ -129,3 +129,23 @@ func TestSemanticDiagnosticsMultipleModeErrors(t *testing.T) {
 		t.Fatalf("expected both req_map and resp_map semantic diagnostics, got: %+v", diags)
 	}
 }
+
+func TestSemanticDiagnosticsJSONPathSelectors(t *testing.T) {
+	base := "provider \"x\" {\n  defaults {\n    upstream_config {\n      base_url = \"https://example.com\";\n    }\n    request {\n      json_del \"$.messages[?(@.role==\\\"system\\\")]\";\n      %s\n    }\n  }\n}\n"
+	valid := strings.Replace(base, "%s", "json_set \"$.tools[*].function.strict\" false;", 1)
+	if diags := dsllang.CollectDiagnostics("file:///tmp/x.conf", valid); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for array selectors, got: %+v", diags)
+	}
+
+	invalid := strings.Replace(base, "%s", "json_set \"$.tools[first].type\" \"function\";", 1)
+	diags := dsllang.CollectDiagnostics("file:///tmp/x.conf", invalid)
+	for _, d := range diags {
+		if strings.Contains(d.Message, "invalid json path") {
+			if d.Range.Start.Line != 7 {
+				t.Fatalf("expected diagnostic on json_set line, got: %+v", d)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected invalid json path diagnostic, got: %+v", diags)
+}
@@ -0,0 +1,428 @@
+package dsllang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dslconfig "github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslspec"
+)
+
+// Lint rule IDs reported in LintIssue.Rule.
+const (
+	LintRuleShadowedMatch       = "shadowed-match"
+	LintRuleNoEffect            = "no-effect"
+	LintRuleUnusedMode          = "unused-mode"
+	LintRuleDeprecatedDirective = "deprecated-directive"
+	LintRuleMissingUsage        = "missing-usage"
+)
+
+// LintRule describes one lint rule.
+type LintRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+var lintRules = []LintRule{
+	{ID: LintRuleShadowedMatch, Description: "match block that can never be selected because an earlier match already covers its api/stream conditions"},
+	{ID: LintRuleNoEffect, Description: "directive or block that does not change the request or response"},
+	{ID: LintRuleUnusedMode, Description: "named usage_mode/finish_reason_mode/models_mode/balance_mode that nothing references"},
+	{ID: LintRuleDeprecatedDirective, Description: "deprecated directive alias"},
+	{ID: LintRuleMissingUsage, Description: "match for a token-billed API without usage extraction"},
+}
+
+// LintDocument is one DSL file to lint. URI is a file URI or a path.
+type LintDocument struct {
+	URI  string
+	Text string
+}
+
+// LintIssue is one lint finding. Severity follows Diagnostic (2 = warning).
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	URI      string `json:"uri"`
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// billableMatchAPIs are APIs priced by tokens, so a match without usage
+// extraction cannot be billed.
+var billableMatchAPIs = map[string]struct{}{
+	"completions":                  {},
+	"chat.completions":             {},
+	"responses":                    {},
+	"claude.messages":              {},
+	"embeddings":                   {},
+	"gemini.generateContent":       {},
+	"gemini.streamGenerateContent": {},
+}
+
+// overridingDirectives maps directives that write one keyed target to the
+// target family; a later write to the same family and key replaces them.
+var overridingDirectives = map[string]string{
+	"set_header": "header",
+	"del_header": "header",
+	"json_set":   "json",
+	"json_del":   "json",
+	"set_query":  "query",
+	"del_query":  "query",
+	"set_path":   "path",
+}
+
+// lintNode is a statement or block in a lexical outline of one document.
+type lintNode struct {
+	toks     []token
+	block    bool
+	children []*lintNode
+	parent   *lintNode
+}
+
+func (n *lintNode) name() string {
+	if len(n.toks) == 0 || n.toks[0].kind != tokIdent {
+		return ""
+	}
+	return n.toks[0].text
+}
+
+// parentBlock returns the metadata block name that contains n.
+func (n *lintNode) parentBlock() string {
+	if n.parent == nil || n.parent.parent == nil {
+		return "top"
+	}
+	return n.parent.name()
+}
+
+func lintRulesList() []LintRule {
+	return append([]LintRule(nil), lintRules...)
+}
+
+func lint(docs []LintDocument, providers []dslconfig.ProviderFile) []LintIssue {
+	out := make([]LintIssue, 0, 8)
+	roots := make([]*lintNode, len(docs))
+	for i, doc := range docs {
+		roots[i] = buildLintTree(lex(doc.Text))
+		out = append(out, lintNoEffect(doc.URI, roots[i])...)
+		out = append(out, lintDeprecated(doc)...)
+	}
+	out = append(out, lintUnusedModes(docs)...)
+	for _, pf := range providers {
+		out = append(out, lintProvider(docs, roots, pf)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		if a.Range.Start.Character != b.Range.Start.Character {
+			return a.Range.Start.Character < b.Range.Start.Character
+		}
+		return a.Rule < b.Rule
+	})
+	return out
+}
+
+func newLintIssue(rule, uri string, tok token, msg string) LintIssue {
+	return LintIssue{
+		Rule:     rule,
+		URI:      uri,
+		Range:    Range{Start: Position{Line: tok.line, Character: tok.col}, End: tokenEnd(tok)},
+		Severity: 2,
+		Message:  msg,
+	}
+}
+
+// buildLintTree groups tokens into statements and blocks. Unbalanced input is
+// tolerated; syntax errors are reported by diagnostics, not by lint.
+func buildLintTree(toks []token) *lintNode {
+	root := &lintNode{block: true}
+	cur := root
+	var stmt []token
+	flush := func(block bool) *lintNode {
+		n := &lintNode{toks: stmt, block: block, parent: cur}
+		cur.children = append(cur.children, n)
+		stmt = nil
+		return n
+	}
+	for _, tok := range toks {
+		switch tok.kind {
+		case tokEOF:
+			if len(stmt) > 0 {
+				flush(false)
+			}
+			return root
+		case tokSemicolon:
+			flush(false)
+		case tokLBrace:
+			cur = flush(true)
+		case tokRBrace:
+			if len(stmt) > 0 {
+				flush(false)
+			}
+			if cur.parent != nil {
+				cur = cur.parent
+			}
+		default:
+			stmt = append(stmt, tok)
+		}
+	}
+	return root
+}
+
+func lintNoEffect(uri string, n *lintNode) []LintIssue {
+	out := make([]LintIssue, 0, 4)
+	lastWrite := map[string]int{}
+	for i, child := range n.children {
+		name := child.name()
+		if child.block {
+			if len(child.children) == 0 && isPhaseBlock(child) && !replacesInheritedPhase(child) {
+				out = append(out, newLintIssue(LintRuleNoEffect, uri, child.toks[0], "empty "+name+" block has no effect"))
+			}
+			out = append(out, lintNoEffect(uri, child)...)
+			continue
+		}
+		if name == "model_map" && len(child.toks) >= 3 && lintArg(child.toks[1]) == lintArg(child.toks[2]) {
+			out = append(out, newLintIssue(LintRuleNoEffect, uri, child.toks[0], fmt.Sprintf("model_map maps %q to itself", lintArg(child.toks[1]))))
+		}
+		if key, ok := overrideKey(n, child); ok {
+			lastWrite[key] = i
+		}
+	}
+	for i, child := range n.children {
+		key, ok := overrideKey(n, child)
+		if !ok {
+			continue
+		}
+		if last := lastWrite[key]; last > i {
+			later := n.children[last]
+			out = append(out, newLintIssue(LintRuleNoEffect, uri, child.toks[0],
+				fmt.Sprintf("%s is overridden by %s on line %d and has no effect", strings.Join(lintTexts(child.toks), " "), later.name(), later.toks[0].line+1)))
+		}
+	}
+	return out
+}
+
+// overrideKey returns the target written by a statement in block n, such as
+// "header:x-api-key", when a later write to the same target replaces it.
+func overrideKey(n, stmt *lintNode) (string, bool) {
+	family, ok := overridingDirectives[stmt.name()]
+	if !ok || stmt.block || n.isOpaque() {
+		return "", false
+	}
+	if family == "path" {
+		return family, true
+	}
+	if len(stmt.toks) < 2 {
+		return "", false
+	}
+	key := lintArg(stmt.toks[1])
+	if family == "header" {
+		key = strings.ToLower(key)
+	}
+	return family + ":" + key, true
+}
+
+// isOpaque reports whether n is a block whose body is not DSL statements, such
+// as req_template JSON.
+func (n *lintNode) isOpaque() bool {
+	return n.parent != nil && len(dslspec.DirectivesByBlock(n.name())) == 0
+}
+
+// isPhaseBlock reports whether n is a plain (header-less) DSL block.
+func isPhaseBlock(n *lintNode) bool {
+	name := n.name()
+	parent := n.parentBlock()
+	return name != "" && len(n.toks) == 1 && blockAllowsChildBlock(parent, name) && !blockDirectiveNeedsHeader(parent, name) &&
+		len(dslspec.DirectivesByBlock(name)) > 0
+}
+
+// replacesInheritedPhase reports whether an empty defaults phase clears the
+// phase inherited through `provider "x" extends "y"`.
+func replacesInheritedPhase(n *lintNode) bool {
+	defaults := n.parent
+	if defaults == nil || defaults.name() != "defaults" || defaults.parent == nil {
+		return false
+	}
+	provider := defaults.parent
+	for _, tok := range provider.toks {
+		if tok.kind == tokIdent && tok.text == "extends" {
+			return true
+		}
+	}
+	return false
+}
+
+func lintArg(tok token) string {
+	return normalizeModeToken(tok)
+}
+
+func lintTexts(toks []token) []string {
+	out := make([]string, 0, len(toks))
+	for _, tok := range toks {
+		out = append(out, tok.text)
+	}
+	return out
+}
+
+func lintDeprecated(doc LintDocument) []LintIssue {
+	path := doc.URI
+	if p, ok := filePathFromURI(doc.URI); ok {
+		path = p
+	}
+	warnings := dslconfig.DeprecatedDirectiveWarnings(path, doc.Text)
+	out := make([]LintIssue, 0, len(warnings))
+	for _, w := range warnings {
+		tok := token{kind: tokIdent, text: w.Directive, line: max(w.Line-1, 0), col: max(w.Column-1, 0)}
+		out = append(out, newLintIssue(LintRuleDeprecatedDirective, doc.URI, tok, w.Message))
+	}
+	return out
+}
+
+func lintUnusedModes(docs []LintDocument) []LintIssue {
+	type declared struct {
+		uri string
+		sym modeSymbol
+	}
+	decls := make([]declared, 0, 8)
+	used := map[string]struct{}{}
+	for _, doc := range docs {
+		for _, sym := range collectModeSymbols(doc.Text) {
+			key := sym.registry + ":" + sym.name
+			if sym.decl {
+				decls = append(decls, declared{uri: doc.URI, sym: sym})
+				continue
+			}
+			used[key] = struct{}{}
+		}
+	}
+	out := make([]LintIssue, 0, 4)
+	for _, d := range decls {
+		if _, ok := used[d.sym.registry+":"+d.sym.name]; ok {
+			continue
+		}
+		out = append(out, newLintIssue(LintRuleUnusedMode, d.uri, d.sym.tok, fmt.Sprintf("%s %q is never referenced", d.sym.registry, d.sym.name)))
+	}
+	return out
+}
+
+// lintProvider checks the loaded provider, with extends flattened, and reports
+// findings at the matching lexical match block, or at the provider header for
+// inherited matches.
+func lintProvider(docs []LintDocument, roots []*lintNode, pf dslconfig.ProviderFile) []LintIssue {
+	uri, provider := findLintProvider(docs, roots, pf.Name)
+	if provider == nil {
+		return nil
+	}
+	locate := func(m dslconfig.RoutingMatch) (token, bool) {
+		for _, child := range provider.children {
+			if child.block && child.name() == "match" {
+				api, stream := lintMatchHeader(child.toks)
+				if api == m.API && sameStream(stream, m.Stream) {
+					return child.toks[0], true
+				}
+			}
+		}
+		return provider.toks[0], false
+	}
+
+	out := make([]LintIssue, 0, 4)
+	matches := pf.Routing.Matches
+	for j, m := range matches {
+		tok, own := locate(m)
+		label := describeMatch(m)
+		if !own {
+			label = "inherited " + label
+		}
+		shadowed := false
+		for i := 0; i < j; i++ {
+			if matchCovers(matches[i], m) {
+				out = append(out, newLintIssue(LintRuleShadowedMatch, uri, tok,
+					fmt.Sprintf("%s is never selected: %s above matches first", label, describeMatch(matches[i]))))
+				shadowed = true
+				break
+			}
+		}
+		if shadowed {
+			continue
+		}
+		if _, ok := billableMatchAPIs[m.API]; !ok {
+			continue
+		}
+		streams := []bool{false, true}
+		if m.Stream != nil {
+			streams = []bool{*m.Stream}
+		}
+		for _, stream := range streams {
+			if _, ok := pf.Usage.Select(&dslmeta.Meta{API: m.API, IsStream: stream}); ok {
+				continue
+			}
+			out = append(out, newLintIssue(LintRuleMissingUsage, uri, tok,
+				fmt.Sprintf("%s has no usage_extract (stream=%t); token usage will not be recorded", label, stream)))
+			break
+		}
+	}
+	return out
+}
+
+func findLintProvider(docs []LintDocument, roots []*lintNode, name string) (string, *lintNode) {
+	for i, root := range roots {
+		for _, child := range root.children {
+			if child.block && child.name() == "provider" && len(child.toks) >= 2 && lintArg(child.toks[1]) == name {
+				return docs[i].URI, child
+			}
+		}
+	}
+	return "", nil
+}
+
+func lintMatchHeader(toks []token) (string, *bool) {
+	api := ""
+	var stream *bool
+	for i := 1; i+2 < len(toks); i++ {
+		if toks[i+1].text != "=" {
+			continue
+		}
+		switch toks[i].text {
+		case "api":
+			api = lintArg(toks[i+2])
+		case "stream":
+			v := toks[i+2].text == "true"
+			stream = &v
+		}
+	}
+	return api, stream
+}
+
+func sameStream(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// matchCovers reports whether every request selected by m is already selected by earlier.
+func matchCovers(earlier, m dslconfig.RoutingMatch) bool {
+	if earlier.API != "" && earlier.API != m.API {
+		return false
+	}
+	if earlier.Stream == nil {
+		return true
+	}
+	return m.Stream != nil && *m.Stream == *earlier.Stream
+}
+
+func describeMatch(m dslconfig.RoutingMatch) string {
+	out := "match"
+	if m.API != "" {
+		out += fmt.Sprintf(" api = %q", m.API)
+	}
+	if m.Stream != nil {
+		out += fmt.Sprintf(" stream = %t", *m.Stream)
+	}
+	return out
+}
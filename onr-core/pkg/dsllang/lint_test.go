@@ -0,0 +1,135 @@
+package dsllang_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dslconfig "github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+)
+
+const lintProviderText = `syntax "next-router/0.1";
+
+usage_mode "demo_usage" {
+  usage_fact input token path="$.usage.prompt_tokens";
+}
+
+usage_mode "demo_unused" {
+  usage_fact input token path="$.usage.input_tokens";
+}
+
+provider "demo" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.example.com";
+    }
+    request {
+      model_map "gpt-4o" "gpt-4o";
+    }
+  }
+
+  match api = "chat.completions" {
+    metrics {
+      usage_extract demo_usage;
+    }
+    upstream {
+      set_path "/v1/a";
+      set_path "/v1/b";
+    }
+  }
+
+  match api = "chat.completions" stream = true {
+    upstream {
+      set_path "/v1/stream";
+    }
+  }
+
+  match api = "embeddings" {
+    response {
+    }
+  }
+}
+`
+
+func lintFixture(t *testing.T) ([]dsllang.LintDocument, []dslconfig.ProviderFile) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "demo.conf")
+	if err := os.WriteFile(path, []byte(lintProviderText), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	pf, err := dslconfig.ValidateProviderFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProviderFile: %v", err)
+	}
+	return []dsllang.LintDocument{{URI: "demo.conf", Text: lintProviderText}}, []dslconfig.ProviderFile{pf}
+}
+
+func TestLint_ReportsEachRule(t *testing.T) {
+	docs, providers := lintFixture(t)
+	issues := dsllang.Lint(docs, providers)
+
+	want := map[string]string{
+		dsllang.LintRuleUnusedMode:    `usage_mode "demo_unused" is never referenced`,
+		dsllang.LintRuleShadowedMatch: `match api = "chat.completions" stream = true is never selected`,
+		dsllang.LintRuleMissingUsage:  `match api = "embeddings" has no usage_extract`,
+	}
+	got := map[string][]string{}
+	for _, issue := range issues {
+		got[issue.Rule] = append(got[issue.Rule], issue.Message)
+		if issue.URI != "demo.conf" || issue.Severity != 2 {
+			t.Fatalf("unexpected issue location/severity: %+v", issue)
+		}
+	}
+	for rule, fragment := range want {
+		if len(got[rule]) != 1 || !strings.Contains(got[rule][0], fragment) {
+			t.Fatalf("rule %s: want one message containing %q, got %q", rule, fragment, got[rule])
+		}
+	}
+
+	noEffect := strings.Join(got[dsllang.LintRuleNoEffect], "\n")
+	for _, fragment := range []string{"model_map", "set_path", "empty response block"} {
+		if !strings.Contains(noEffect, fragment) {
+			t.Fatalf("no-effect messages missing %q:\n%s", fragment, noEffect)
+		}
+	}
+}
+
+func TestLint_CleanProviderHasNoIssues(t *testing.T) {
+	text := `syntax "next-router/0.1";
+
+provider "clean" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.example.com";
+    }
+  }
+
+  match api = "images.generations" {
+    upstream {
+      set_path "/v1/images/generations";
+    }
+  }
+}
+`
+	path := filepath.Join(t.TempDir(), "clean.conf")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	pf, err := dslconfig.ValidateProviderFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProviderFile: %v", err)
+	}
+	if issues := dsllang.Lint([]dsllang.LintDocument{{URI: path, Text: text}}, []dslconfig.ProviderFile{pf}); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestLintRules_HaveDescriptions(t *testing.T) {
+	for _, r := range dsllang.LintRules() {
+		if r.ID == "" || r.Description == "" {
+			t.Fatalf("incomplete rule: %+v", r)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package dsllang
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	dslconfig "github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslspec"
+)
+
+// modeSymbol is a named mode preset declaration (`usage_mode "name" { ... }`)
+// or a reference to one (`usage_extract name;`).
+type modeSymbol struct {
+	registry string
+	name     string
+	decl     bool
+	tok      token
+}
+
+func findDefinition(uri, text string, pos Position) []Location {
+	if locs, ok := includeDefinition(uri, text, pos); ok {
+		return locs
+	}
+	symbols := collectModeSymbols(text)
+	sym, ok := modeSymbolAt(symbols, pos)
+	if !ok {
+		return nil
+	}
+	out := make([]Location, 0, 1)
+	for _, s := range symbols {
+		if s.decl && s.registry == sym.registry && s.name == sym.name {
+			out = append(out, Location{URI: uri, Range: modeSymbolRange(s)})
+		}
+	}
+	return out
+}
+
+func findReferences(uri, text string, pos Position, includeDeclaration bool) []Location {
+	symbols := collectModeSymbols(text)
+	sym, ok := modeSymbolAt(symbols, pos)
+	if !ok {
+		return nil
+	}
+	out := make([]Location, 0, 4)
+	for _, s := range symbols {
+		if s.registry != sym.registry || s.name != sym.name {
+			continue
+		}
+		if s.decl && !includeDeclaration {
+			continue
+		}
+		out = append(out, Location{URI: uri, Range: modeSymbolRange(s)})
+	}
+	return out
+}
+
+// includeDefinition resolves the include statement at pos to the files it
+// expands to. The second result is false when pos is not on an include path.
+func includeDefinition(uri, text string, pos Position) ([]Location, bool) {
+	toks := lex(text)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		if tok.kind != tokIdent || tok.text != "include" || tok.line != pos.Line || !isStatementStart(toks, i) {
+			continue
+		}
+		pathStart := tok.col + len(tok.text)
+		if pos.Character < pathStart {
+			return nil, false
+		}
+		line := lineAt(text, tok.line)
+		pathEnd := len(line)
+		for j := i + 1; j < len(toks) && toks[j].line == tok.line; j++ {
+			if toks[j].kind == tokSemicolon {
+				pathEnd = toks[j].col
+				break
+			}
+		}
+		if pos.Character > pathEnd {
+			return nil, false
+		}
+		basePath, ok := filePathFromURI(uri)
+		if !ok {
+			return nil, true
+		}
+		targets, err := dslconfig.ResolveIncludeTargets(basePath, line[pathStart:pathEnd])
+		if err != nil {
+			return nil, true
+		}
+		out := make([]Location, 0, len(targets))
+		for _, target := range targets {
+			out = append(out, Location{URI: fileURI(target)})
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func collectModeSymbols(text string) []modeSymbol {
+	toks := lex(text)
+	out := make([]modeSymbol, 0, 8)
+	stack := make([]string, 0, 8)
+	pending := ""
+	lockedPending := false
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		block := "top"
+		if len(stack) > 0 {
+			block = stack[len(stack)-1]
+		}
+		switch tok.kind {
+		case tokIdent:
+			if !isStatementStart(toks, i) {
+				continue
+			}
+			registry := dslspec.DirectiveModeRegistryBlockInBlock(tok.text, block)
+			decl := block == "top" && isModeRegistryBlock(tok.text)
+			if decl {
+				registry = tok.text
+			}
+			if registry != "" {
+				if modeTok, ok := nextModeToken(toks, i+1); ok {
+					if name := normalizeModeToken(modeTok); name != "" {
+						out = append(out, modeSymbol{registry: registry, name: name, decl: decl, tok: modeTok})
+					}
+				}
+			}
+			if blockAllowsChildBlock(block, tok.text) {
+				pending = tok.text
+				lockedPending = blockDirectiveNeedsHeader(block, tok.text)
+			}
+		case tokLBrace:
+			name := pending
+			if name == "" {
+				name = "unknown"
+			}
+			stack = append(stack, name)
+			pending = ""
+			lockedPending = false
+		case tokRBrace:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			pending = ""
+			lockedPending = false
+		case tokSemicolon:
+			if !lockedPending {
+				pending = ""
+			}
+		}
+	}
+	return out
+}
+
+func isModeRegistryBlock(name string) bool {
+	for _, d := range dslspec.DirectiveMetadataList() {
+		if strings.TrimSpace(d.ModeRegistryBlock) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func modeSymbolAt(symbols []modeSymbol, pos Position) (modeSymbol, bool) {
+	for _, s := range symbols {
+		if s.tok.line == pos.Line && pos.Character >= s.tok.col && pos.Character <= s.tok.col+len(s.tok.text) {
+			return s, true
+		}
+	}
+	return modeSymbol{}, false
+}
+
+// modeSymbolRange covers the mode name without surrounding quotes.
+func modeSymbolRange(s modeSymbol) Range {
+	col := s.tok.col
+	if s.tok.kind == tokString {
+		col++
+	}
+	return Range{
+		Start: Position{Line: s.tok.line, Character: col},
+		End:   Position{Line: s.tok.line, Character: col + len(s.name)},
+	}
+}
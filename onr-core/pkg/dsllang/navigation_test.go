@@ -0,0 +1,76 @@
+package dsllang_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dsllang"
+)
+
+const navigationText = `usage_mode "shared_usage" {
+  usage_extract custom;
+}
+
+usage_mode "child_usage" {
+  usage_extract shared_usage;
+}
+
+provider "x" {
+  defaults {
+    metrics {
+      usage_extract "shared_usage";
+      finish_reason_extract custom;
+    }
+  }
+}
+`
+
+func TestFindDefinitionNamedMode(t *testing.T) {
+	locs := dsllang.FindDefinition("file:///tmp/x.conf", navigationText, dsllang.Position{Line: 11, Character: 24})
+	if len(locs) != 1 {
+		t.Fatalf("expected one definition, got %+v", locs)
+	}
+	want := dsllang.Range{Start: dsllang.Position{Line: 0, Character: 12}, End: dsllang.Position{Line: 0, Character: 24}}
+	if locs[0].URI != "file:///tmp/x.conf" || locs[0].Range != want {
+		t.Fatalf("unexpected definition: %+v", locs[0])
+	}
+	if locs := dsllang.FindDefinition("file:///tmp/x.conf", navigationText, dsllang.Position{Line: 12, Character: 29}); len(locs) != 0 {
+		t.Fatalf("built-in mode should have no definition, got %+v", locs)
+	}
+}
+
+func TestFindReferencesNamedMode(t *testing.T) {
+	locs := dsllang.FindReferences("file:///tmp/x.conf", navigationText, dsllang.Position{Line: 0, Character: 14}, false)
+	if len(locs) != 2 || locs[0].Range.Start.Line != 5 || locs[1].Range.Start.Line != 11 {
+		t.Fatalf("unexpected references: %+v", locs)
+	}
+	if locs[1].Range.Start.Character != 21 {
+		t.Fatalf("reference range should exclude quotes: %+v", locs[1])
+	}
+	locs = dsllang.FindReferences("file:///tmp/x.conf", navigationText, dsllang.Position{Line: 5, Character: 20}, true)
+	if len(locs) != 3 || locs[0].Range.Start.Line != 0 {
+		t.Fatalf("expected declaration plus references, got %+v", locs)
+	}
+}
+
+func TestFindDefinitionInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "modes"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"a.conf", "b.conf"} {
+		if err := os.WriteFile(filepath.Join(dir, "modes", name), []byte("\n"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	text := "include modes/*.conf;\ninclude providers;\n"
+	locs := dsllang.FindDefinition(filepath.Join(dir, "onr.conf"), text, dsllang.Position{Line: 0, Character: 10})
+	if len(locs) != 2 || !strings.HasPrefix(locs[0].URI, "file://") || !strings.HasSuffix(locs[1].URI, "/modes/b.conf") {
+		t.Fatalf("unexpected include targets: %+v", locs)
+	}
+	if locs := dsllang.FindDefinition(filepath.Join(dir, "onr.conf"), text, dsllang.Position{Line: 1, Character: 10}); len(locs) != 0 {
+		t.Fatalf("missing include target should resolve to nothing, got %+v", locs)
+	}
+}
@@ -228,6 +228,10 @@ func writeContextualValidationFile(tempRoot, uri, content string) (string, func(
 
 	switch role {
 	case "provider":
+		// Sibling and base/ providers are needed to resolve `extends`.
+		if err := copyDirIfExists(filepath.Join(cfgRoot, "providers"), filepath.Join(tempRoot, "providers")); err != nil {
+			return "", nil, false, err
+		}
 		dst := filepath.Join(tempRoot, "providers", filepath.Base(actualPath))
 		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 			return "", nil, false, fmt.Errorf("create temp providers dir: %w", err)
@@ -189,6 +189,9 @@ func classifyIdentifierType(word, block string, statementStart, modePending bool
 	if w == "true" || w == "false" || w == "syntax" {
 		return semanticTypeKeyword
 	}
+	if w == "extends" && block == "top" && !statementStart {
+		return semanticTypeKeyword
+	}
 	if statementStart && blockAllowsChildBlock(block, w) {
 		return semanticTypeKeyword
 	}
@@ -41,6 +41,20 @@ func TestSemanticTokensFull_ClassifiesSingleQuotedProviderName(t *testing.T) {
 	}
 }
 
+func TestSemanticTokensFull_ClassifiesProviderExtends(t *testing.T) {
+	text := `provider "groq" extends "openai-compatible" { }`
+	legend := dsllang.CollectSemanticTokenLegend()
+	res := dsllang.CollectSemanticTokens(text)
+	toks := decodeSemanticTokenTypes(res.Data, legend)
+
+	if got := toks[semanticTokenKey{line: 0, start: len(`provider "groq" `)}]; got != "keyword" {
+		t.Fatalf("extends token type=%q want keyword; tokens=%+v", got, toks)
+	}
+	if got := toks[semanticTokenKey{line: 0, start: len(`provider "groq" extends `)}]; got != "namespace" {
+		t.Fatalf("parent name token type=%q want namespace; tokens=%+v", got, toks)
+	}
+}
+
 type semanticTokenKey struct {
 	line  int
 	start int
@@ -34,6 +34,53 @@ type Hover struct {
 	Block    string        `json:"block,omitempty"`
 }
 
+// CompletionItemKind values follow the LSP CompletionItemKind enumeration.
+const (
+	CompletionKindFunction   = 3
+	CompletionKindVariable   = 6
+	CompletionKindModule     = 9
+	CompletionKindValue      = 12
+	CompletionKindKeyword    = 14
+	CompletionKindFile       = 17
+	CompletionKindEnumMember = 20
+)
+
+// CompletionItem describes one completion candidate.
+type CompletionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind,omitempty"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *MarkupContent `json:"documentation,omitempty"`
+	// TextEdit replaces the partially typed word at the request position.
+	TextEdit *TextEdit `json:"textEdit,omitempty"`
+}
+
+// Location is a range inside a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextEdit replaces Range with NewText.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit groups text edits by document URI.
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+// CodeAction is a quick fix for one diagnostic.
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
 type formattingOptions struct {
 	TabSize      int  `json:"tabSize"`
 	InsertSpaces bool `json:"insertSpaces"`
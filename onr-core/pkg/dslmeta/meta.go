@@ -8,6 +8,12 @@ import (
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestcanon"
 )
 
+// VarResolver resolves user-defined DSL variables, such as the output of map blocks.
+// Names are passed without the leading '$'.
+type VarResolver interface {
+	ResolveVar(name string, m *Meta) (string, bool)
+}
+
 type TaskMeta struct {
 	ID         string
 	UpstreamID string
@@ -67,9 +73,15 @@ type Meta struct {
 	// DSLModelMapped is the mapped model name after applying model_map.
 	DSLModelMapped string
 
+	// AccessKeyName is the name of the ONR access key that authenticated the request.
+	AccessKeyName string
+
 	// Task exposes a narrow runtime task context for long-running operation routes.
 	Task TaskMeta
 
+	// Vars resolves the provider's user-defined variables. Nil means none are defined.
+	Vars VarResolver
+
 	// RequestURLPath is the request path (and query), e.g. "/v1/chat/completions?x=1".
 	// DSL routing directives can rewrite it via set_path/set_query/del_query.
 	RequestURLPath string
@@ -117,7 +129,9 @@ func Clone(src *Meta) *Meta {
 		UpstreamTransport:   src.UpstreamTransport,
 		OriginModelName:     src.OriginModelName,
 		DSLModelMapped:      src.DSLModelMapped,
+		AccessKeyName:       src.AccessKeyName,
 		Task:                src.Task,
+		Vars:                src.Vars,
 		RequestURLPath:      src.RequestURLPath,
 		RequestContentType:  src.RequestContentType,
 		RequestBody:         src.RequestBody,
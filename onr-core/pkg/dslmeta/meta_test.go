@@ -98,6 +98,8 @@ func TestCloneCopiesPublicFields(t *testing.T) {
 		ChannelLocation:     "us-central1",
 		OriginModelName:     "gpt-4o",
 		DSLModelMapped:      "gpt-4o-mini",
+		AccessKeyName:       "team-a",
+		Vars:                staticVars{"deployment": "prod"},
 		RequestURLPath:      "/v1/chat/completions",
 		RequestContentType:  "application/json",
 		RequestBody:         body,
@@ -115,7 +117,8 @@ func TestCloneCopiesPublicFields(t *testing.T) {
 		got.OAuthCacheKey != src.OAuthCacheKey || got.CredentialFile != src.CredentialFile ||
 		got.CredentialJSON != src.CredentialJSON || got.CredentialProjectID != src.CredentialProjectID ||
 		got.ChannelLocation != src.ChannelLocation || got.OriginModelName != src.OriginModelName ||
-		got.DSLModelMapped != src.DSLModelMapped || got.RequestURLPath != src.RequestURLPath ||
+		got.DSLModelMapped != src.DSLModelMapped || got.AccessKeyName != src.AccessKeyName ||
+		got.Vars == nil || got.RequestURLPath != src.RequestURLPath ||
 		got.RequestContentType != src.RequestContentType || !got.StartTime.Equal(start) {
 		t.Fatalf("Clone fields differ: %#v", got)
 	}
@@ -141,3 +144,10 @@ func TestCloneCopiesHeaderAndDerivedUsageContainers(t *testing.T) {
 		t.Fatalf("source derived usage mutated: %v", src.DerivedUsage)
 	}
 }
+
+type staticVars map[string]string
+
+func (v staticVars) ResolveVar(name string, _ *Meta) (string, bool) {
+	val, ok := v[name]
+	return val, ok
+}
@@ -32,7 +32,8 @@ type DirectiveArg struct {
 var directiveMetadata = []DirectiveMetadata{
 	{Name: "syntax", Block: "top", Hover: "`syntax \"next-router/0.1\";`\n\nDeclares DSL syntax version for this file."},
 	{Name: "include", Block: "top", Hover: "`include path.conf;`\n\nIncludes another DSL fragment file before parsing. Supports unquoted nginx-style paths like `providers;` and `providers/*.conf;`."},
-	{Name: "provider", Block: "top", Hover: "`provider \"name\" { ... }`\n\nDefines one provider DSL block. File name should match provider name.", IsBlock: true, BlockHeader: true},
+	{Name: "provider", Block: "top", Hover: "`provider \"name\" [extends \"parent\"] { ... }`\n\nDefines one provider DSL block. File name should match provider name. With `extends`, defaults phases and matches are inherited from the parent provider (same file, `<name>.conf` or `base/<name>.conf`) and may be overridden.", IsBlock: true, BlockHeader: true},
+	{Name: "map", Block: "top", Hover: "`map <source-expr> $name { \"key\" <value>; ~\"regex\" <value>; default <value>; }`\n\nDeclares a global user variable. `$name` is computed lazily from the source expression: exact keys first, then `~` (case-sensitive) / `~*` (case-insensitive) regexes in order, then `default` (empty when nothing matches).", IsBlock: true, BlockHeader: true},
 	{Name: "usage_mode", Block: "top", Hover: "`usage_mode \"name\" { ... }`\n\nDefines one reusable global usage extraction preset.", IsBlock: true, BlockHeader: true},
 	{Name: "finish_reason_mode", Block: "top", Hover: "`finish_reason_mode \"name\" { ... }`\n\nDefines one reusable global finish reason extraction preset.", IsBlock: true, BlockHeader: true},
 	{Name: "models_mode", Block: "top", Hover: "`models_mode \"name\" { ... }`\n\nDefines one reusable global models query preset.", IsBlock: true, BlockHeader: true},
@@ -40,12 +41,15 @@ var directiveMetadata = []DirectiveMetadata{
 
 	{Name: "defaults", Block: "provider", Hover: "`defaults { ... }`\n\nDefault phases shared by all `match` rules unless overridden.", IsBlock: true},
 	{Name: "match", Block: "provider", Hover: "`match api = \"...\" [stream = true|false] { ... }`\n\nRoute rule. First match wins.", IsBlock: true, BlockHeader: true},
+	{Name: "map", Block: "provider", Hover: "`map <source-expr> $name { ... }`\n\nDeclares a provider-scoped user variable. Shadows a global `map` with the same name.", IsBlock: true, BlockHeader: true},
+	{Name: "default", Block: "map", Hover: "`default <value>;`\n\nValue of the map variable when no exact or regex key matches the source."},
 	{Name: "metadata", Block: "provider", Hover: "`metadata { provider_family <family>; signal_profile <profile>; }`\n\nDeclares provider identity and capacity signal profile metadata.", IsBlock: true},
 
 	{Name: "provider_family", Block: "metadata", Hover: "`provider_family <family>;`\n\nProvider family used for operations, debug output, and later capacity-signal grouping."},
 	{Name: "signal_profile", Block: "metadata", Hover: "`signal_profile <profile>;`\n\nSignal profile used by later provider capacity signal adaptors."},
 
 	{Name: "upstream_config", Block: "defaults", Hover: "`upstream_config { base_url = \"...\"; }`\n\nProvider-level upstream base URL config.", IsBlock: true},
+	{Name: "upstream", Block: "defaults", Hover: "`upstream { ... }`\n\nProvider-wide upstream timeout directives; match `upstream` timeouts override them.", IsBlock: true},
 	{Name: "auth", Block: "defaults", Hover: "`auth { ... }`\n\nAuthentication directives for upstream requests.", IsBlock: true},
 	{Name: "request", Block: "defaults", Hover: "`request { ... }`\n\nRequest rewrite/transform directives.", IsBlock: true},
 	{Name: "response", Block: "defaults", Hover: "`response { ... }`\n\nDownstream response mapping/transformation directives.", IsBlock: true},
@@ -53,13 +57,15 @@ var directiveMetadata = []DirectiveMetadata{
 	{Name: "metrics", Block: "defaults", Hover: "`metrics { ... }`\n\nToken usage and finish reason extraction rules.", IsBlock: true},
 	{Name: "balance", Block: "defaults", Hover: "`balance { ... }`\n\nBalance query and extraction directives.", IsBlock: true},
 	{Name: "models", Block: "defaults", Hover: "`models { ... }`\n\nProvider models list query and mapping directives.", IsBlock: true},
+	{Name: "guard", Block: "defaults", Hover: "`guard { ... }`\n\nPrompt/response content guardrails (reject, redact or log matched text).", IsBlock: true},
 
-	{Name: "upstream", Block: "match", Hover: "`upstream { ... }`\n\nUpstream path/query routing directives.", IsBlock: true},
+	{Name: "upstream", Block: "match", Hover: "`upstream { ... }`\n\nUpstream path/query routing and timeout directives.", IsBlock: true},
 	{Name: "auth", Block: "match", Hover: "`auth { ... }`\n\nAuthentication directives for upstream requests.", IsBlock: true},
 	{Name: "request", Block: "match", Hover: "`request { ... }`\n\nRequest rewrite/transform directives.", IsBlock: true},
 	{Name: "response", Block: "match", Hover: "`response { ... }`\n\nDownstream response mapping/transformation directives.", IsBlock: true},
 	{Name: "error", Block: "match", Hover: "`error { error_map <mode>; }`\n\nNormalize upstream error payloads.", IsBlock: true},
 	{Name: "metrics", Block: "match", Hover: "`metrics { ... }`\n\nToken usage and finish reason extraction rules.", IsBlock: true},
+	{Name: "guard", Block: "match", Hover: "`guard { ... }`\n\nPrompt/response content guardrails. Rules are appended to defaults; a rule with the same name replaces the default one.", IsBlock: true},
 
 	{Name: "usage_extract", Block: "usage_mode", Hover: "`usage_extract <mode>;`\n\nSelects `custom` or inherits another reusable `usage_mode` preset.", Modes: []string{"custom"}, ModeRegistryBlock: "usage_mode"},
 	{Name: "usage_root", Block: "usage_mode", Hover: "`usage_root path=\"$.usage\" [event=\"a|b\"] [event_optional=true] [exclude=\"field_a|field_b\"];`\n\nExtracts and merges the upstream usage JSON object before `usage_fact` rules run. When a mode has `usage_root`, `usage_fact` without `source` reads from that merged usage object. `exclude` removes top-level keys from the extracted usage object before merging."},
@@ -104,6 +110,10 @@ var directiveMetadata = []DirectiveMetadata{
 	{Name: "set_path", Block: "upstream", Hover: "`set_path <expr>;`\n\nSets upstream request path."},
 	{Name: "set_query", Block: "upstream", Hover: "`set_query <name> <expr>;`\n\nSets/upserts upstream query parameter."},
 	{Name: "del_query", Block: "upstream", Hover: "`del_query <name>;`\n\nDeletes upstream query parameter."},
+	{Name: "timeout_ms", Block: "upstream", Hover: "`timeout_ms <int>;`\n\nTotal upstream call timeout in milliseconds, including reading a streamed body. Overrides the server write timeout."},
+	{Name: "connect_timeout_ms", Block: "upstream", Hover: "`connect_timeout_ms <int>;`\n\nTimeout in milliseconds for obtaining an upstream connection (DNS, dial, TLS)."},
+	{Name: "first_byte_timeout_ms", Block: "upstream", Hover: "`first_byte_timeout_ms <int>;`\n\nTimeout in milliseconds between writing the request and the first upstream response byte."},
+	{Name: "stream_idle_timeout_ms", Block: "upstream", Hover: "`stream_idle_timeout_ms <int>;`\n\nAborts a streamed response when no bytes arrive for this many milliseconds; logged with finish_reason `stream_idle_timeout`."},
 
 	{Name: "auth_bearer", Block: "auth", Hover: "`auth_bearer;`\n\nSets `Authorization: Bearer <channel.key>`."},
 	{Name: "auth_header_key", Block: "auth", Hover: "`auth_header_key <Header-Name>;`\n\nSets `<Header-Name>: <channel.key>`."},
@@ -133,11 +143,11 @@ var directiveMetadata = []DirectiveMetadata{
 	{Name: "del_header", Block: "request", Hover: "`del_header <Header-Name>;`\n\nDeletes one upstream request header."},
 	{Name: "model_map", Block: "request", Hover: "`model_map <from> <expr>;`\n\nMaps input model name to upstream model expression."},
 	{Name: "model_map_default", Block: "request", Hover: "`model_map_default <expr>;`\n\nFallback mapped model expression when no rule matches."},
-	{Name: "json_set", Block: "request", Hover: "`json_set <jsonpath> <expr>;`\n\nSets one request JSON field value."},
+	{Name: "json_set", Block: "request", Hover: "`json_set <jsonpath> <expr>;`\n\nSets one request JSON field value. Paths accept `[n]`, `[*]` and `[?(@.field==\"v\")]` selectors; selectors never create array elements."},
 	{Name: "json_replace", Block: "request", Hover: "`json_replace <jsonpath> <expr>;`\n\nReplaces one request JSON field only when the path already exists."},
 	{Name: "json_set_if_absent", Block: "request", Hover: "`json_set_if_absent <jsonpath> <expr>;`\n\nSets JSON field only when target field is absent."},
-	{Name: "json_del", Block: "request", Hover: "`json_del <jsonpath>;`\n\nDeletes one request JSON field."},
-	{Name: "json_rename", Block: "request", Hover: "`json_rename <from-jsonpath> <to-jsonpath>;`\n\nRenames/moves one request JSON field."},
+	{Name: "json_del", Block: "request", Hover: "`json_del <jsonpath>;`\n\nDeletes one request JSON field. A selector as the last segment (`$.messages[0]`, `$.messages[?(@.role==\"system\")]`) removes the matched array elements."},
+	{Name: "json_rename", Block: "request", Hover: "`json_rename <from-jsonpath> <to-jsonpath>;`\n\nRenames/moves one request JSON field. With `[*]` or a filter, both paths must share the prefix up to the last selector."},
 	{Name: "json_wrap_input_text", Block: "request", Hover: "`json_wrap_input_text <jsonpath>;`\n\nWraps a string field as an OpenAI Responses `input` message list. Missing paths and already-array values are left unchanged."},
 	{Name: "json_set_header_values", Block: "request", Hover: "`json_set_header_values <jsonpath> <Header-Name> [separator=\"<sep>\"];`\n\nSets one request JSON array field from downstream header values."},
 	{Name: "json_filter_values", Block: "request", Hover: "`json_filter_values <jsonpath> <pattern>...;`\n\nRemoves values from a request JSON string array that match any of the patterns; non-matching values are kept."},
@@ -148,6 +158,7 @@ var directiveMetadata = []DirectiveMetadata{
 	{Name: "json_clamp", Block: "request", Hover: "`json_clamp <jsonpath> min=<f> max=<f>;`\n\nClamps the numeric value at path to `[min, max]`; values inside the range pass through unchanged. Missing/non-numeric fields are left unchanged."},
 	{Name: "after_req_map", Block: "request", Hover: "`after_req_map { ... }`\n\nRuns nested request JSON operations after req_map. If no req_map is configured, runs after normal request JSON operations.", IsBlock: true},
 	{Name: "req_map", Block: "request", Hover: "`req_map <mode>;`\n\nMap request JSON between API schemas.", Modes: []string{"openai_chat_to_openai_responses", "openai_chat_to_anthropic_messages", "openai_chat_to_gemini_generate_content", "openai_images_to_gemini_generate_content", "openai_images_to_minimax_image", "anthropic_to_openai_chat", "gemini_to_openai_chat"}},
+	{Name: "req_template", Block: "request", Hover: "`req_template [sample=\"file.json\"] { <json object body> }`\n\nMaps the request with a declarative JSON template (`req_map template`). Strings `\"$.path\"` / `\"@.path\"` project values; objects with `$path` (`$default`, `$required`, `$as`), `$for` + `$each`, `$expr` and `$literal` control the mapping. `sample` is rendered during validation.", IsBlock: true, BlockHeader: true},
 	{Name: "req_required", Block: "request", Hover: "`req_required <body|header|query> <path-or-name> [allow_null=true|false];`\n\nRejects the request with HTTP 400 when the target is missing. JSON null counts as missing unless allow_null=true (body source only). Runs after model_map and before request JSON operations."},
 	{Name: "req_forbid", Block: "request", Hover: "`req_forbid <body|header|query> <path-or-name>;`\n\nRejects the request with HTTP 400 when the target is present."},
 	{Name: "req_type", Block: "request", Hover: "`req_type body <jsonpath> <null|bool|number|integer|string|array|object>;`\n\nRejects the request with HTTP 400 when the body field exists but is not of the given JSON type. Missing fields pass; body source only."},
@@ -169,12 +180,13 @@ var directiveMetadata = []DirectiveMetadata{
 
 	{Name: "resp_passthrough", Block: "response", Hover: "`resp_passthrough;`\n\nPasses upstream response through without schema mapping."},
 	{Name: "resp_map", Block: "response", Hover: "`resp_map <mode>;`\n\nMap non-stream response JSON.", Modes: []string{"openai_responses_to_openai_chat", "anthropic_to_openai_chat", "gemini_to_openai_chat", "gemini_to_openai_images", "minimax_image_to_openai_images", "openai_to_anthropic_messages", "openai_to_gemini_chat", "openai_to_gemini_generate_content"}},
+	{Name: "resp_template", Block: "response", Hover: "`resp_template [sample=\"file.json\"] { <json object body> }`\n\nMaps the non-stream response with a declarative JSON template (`resp_map template`), using the same syntax as `req_template`.", IsBlock: true, BlockHeader: true},
 	{Name: "sse_parse", Block: "response", Hover: "`sse_parse <mode>;`\n\nMap streaming SSE events/chunks.", Modes: []string{"openai_responses_to_openai_chat_chunks", "anthropic_to_openai_chunks", "openai_to_anthropic_chunks", "openai_to_gemini_chunks", "gemini_to_openai_chat_chunks"}},
 	{Name: "sse_collect", Block: "response", Hover: "`sse_collect <mode>;`\n\nCollects upstream SSE into the same protocol's non-stream JSON before optional `resp_map`/JSON ops.", Modes: []string{"openai_responses", "anthropic_messages", "gemini_generate_content"}},
-	{Name: "json_set", Block: "response", Hover: "`json_set <jsonpath> <expr> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nSets one downstream response JSON field value (best-effort)."},
+	{Name: "json_set", Block: "response", Hover: "`json_set <jsonpath> <expr> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nSets one downstream response JSON field value (best-effort). Paths accept `[n]`, `[*]` and `[?(@.field==\"v\")]` selectors."},
 	{Name: "json_replace", Block: "response", Hover: "`json_replace <jsonpath> <expr> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nReplaces one downstream response JSON field only when the path already exists."},
 	{Name: "json_set_if_absent", Block: "response", Hover: "`json_set_if_absent <jsonpath> <expr> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nSets response JSON field only when absent (best-effort)."},
-	{Name: "json_del", Block: "response", Hover: "`json_del <jsonpath> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nDeletes one downstream response JSON field (best-effort)."},
+	{Name: "json_del", Block: "response", Hover: "`json_del <jsonpath> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nDeletes one downstream response JSON field (best-effort). Paths accept `[n]`, `[*]` and `[?(@.field==\"v\")]` selectors."},
 	{Name: "json_rename", Block: "response", Hover: "`json_rename <from-jsonpath> <to-jsonpath> [event=\"a|b\"] [event_optional=true] [max_count=n];`\n\nRenames/moves one downstream response JSON field (best-effort)."},
 	{Name: "sse_json_del_if", Block: "response", Hover: "`sse_json_del_if <cond-jsonpath> <equals-string> <del-jsonpath>;`\n\nFor SSE JSON event payloads, conditionally delete one field."},
 	{Name: "resp_body_extract", Block: "response", Hover: "`resp_body_extract path=\"$.data.audio\" decode=hex|base64;`\n\nNon-stream: decodes the string at path from the upstream JSON response into the raw downstream body (e.g. hex-encoded audio -> binary audio). Usage/error extraction reads the original JSON before the body transform."},
@@ -220,6 +232,10 @@ var directiveMetadata = []DirectiveMetadata{
 	{Name: "id_allow_regex", Block: "models", Hover: "`id_allow_regex \"<regex>\";`\n\nFilter extracted model ids by regex allowlist."},
 	{Name: "set_header", Block: "models", Hover: "`set_header <Header-Name> <expr>;`\n\nSets header for models query request."},
 	{Name: "del_header", Block: "models", Hover: "`del_header <Header-Name>;`\n\nDeletes header for models query request."},
+
+	{Name: "guard_builtin", Block: "guard", Hover: "`guard_builtin <email|card_number|api_key> [action=reject|redact|log] [on=request|response|both];`\n\nBuilt-in detector. `card_number` also requires a valid Luhn checksum. Defaults: action=log, on=request.", Args: []DirectiveArg{{Name: "detector", Kind: "enum", Enum: []string{"email", "card_number", "api_key"}}}},
+	{Name: "guard_regex", Block: "guard", Hover: "`guard_regex <name> \"<regex>\" [action=reject|redact|log] [on=request|response|both];`\n\nRegex detector (Go RE2 syntax). Redaction replaces matches with `[REDACTED:<name>]`."},
+	{Name: "guard_keywords", Block: "guard", Hover: "`guard_keywords <name> \"<keyword>\"... [action=reject|redact|log] [on=request|response|both];`\n\nCase-insensitive keyword detector. `action=reject` is request-only; streamed responses are scanned after the stream and only logged."},
 }
 
 // DirectiveHover returns hover markdown for a directive name.
@@ -93,6 +93,7 @@ func parserDirectiveSources() []parserDirectiveSource {
 		{block: "request", fn: "parseRequestPhaseWithTransform", kind: parserDirectiveHandlerMap},
 		{block: "after_req_map", fn: "parseRequestJSONOpsOnlyBlock", kind: parserDirectiveSwitch},
 		{block: "upstream", fn: "parseUpstreamPhase", kind: parserDirectiveSwitch},
+		{block: "upstream", fn: "upstreamTimeoutField", kind: parserDirectiveSwitch},
 		{block: "response", fn: "parseResponsePhase", kind: parserDirectiveSwitch},
 		{block: "error", fn: "parseErrorPhase", kind: parserDirectiveSwitch},
 		{block: "metrics", fn: "parseMetricsPhase", kind: parserDirectiveSwitch},
@@ -256,6 +256,51 @@ func VisitValuesByPath(root any, path string, visit func(any)) bool {
 	return visitPathValues(root, compiled.parts, visit)
 }
 
+// PathPart is one segment of the restricted JSONPath subset: an object key,
+// optionally followed by an array selector ([n], [*] or [?(@.field=="VALUE")]).
+type PathPart struct {
+	Name        string
+	Index       int
+	HasIndex    bool
+	Wildcard    bool
+	HasFilter   bool
+	FilterField string
+	FilterValue string
+}
+
+// MultiMatch reports whether the selector can address more than one array element.
+func (p PathPart) MultiMatch() bool {
+	return p.Wildcard || p.HasFilter
+}
+
+// MatchesFilter reports whether item satisfies the part's filter selector. Filters
+// compare trimmed string values case-insensitively, like the read-side helpers.
+func (p PathPart) MatchesFilter(item any) bool {
+	return matchesFilter(item, p.FilterField, p.FilterValue)
+}
+
+// ParsePath splits path into segments using the same restricted JSONPath subset
+// accepted by GetValuesByPath. It reports false when the path is not supported.
+func ParsePath(path string) ([]PathPart, bool) {
+	compiled, ok := lookupCompiledPath(path)
+	if !ok {
+		return nil, false
+	}
+	out := make([]PathPart, 0, len(compiled.parts))
+	for _, part := range compiled.parts {
+		out = append(out, PathPart{
+			Name:        part.name,
+			Index:       part.idx,
+			HasIndex:    part.hasIdx,
+			Wildcard:    part.isStar,
+			HasFilter:   part.hasFilter,
+			FilterField: part.filterField,
+			FilterValue: part.filterValue,
+		})
+	}
+	return out, true
+}
+
 func getStringByParts(cur any, parts []compiledPathPart) string {
 	for i, part := range parts {
 		name, idx, hasIdx, isStar := part.name, part.idx, part.hasIdx, part.isStar
@@ -266,3 +266,29 @@ func TestGetStringByPath_SupportsFilter(t *testing.T) {
 		t.Fatalf("filtered string got %q, want wav", got)
 	}
 }
+
+func TestParsePath(t *testing.T) {
+	parts, ok := ParsePath(`$.messages[?(@.role=="system")].content`)
+	if !ok || len(parts) != 2 {
+		t.Fatalf("ParsePath got (%#v, %v)", parts, ok)
+	}
+	if p := parts[0]; p.Name != "messages" || !p.HasFilter || !p.MultiMatch() || p.FilterField != "role" || p.FilterValue != "system" {
+		t.Fatalf("unexpected filter part: %#v", p)
+	}
+	if !parts[0].MatchesFilter(map[string]any{"role": "System"}) {
+		t.Fatalf("expected case-insensitive filter match")
+	}
+	if parts[1].Name != "content" || parts[1].HasIndex {
+		t.Fatalf("unexpected key part: %#v", parts[1])
+	}
+
+	parts, ok = ParsePath("$.tools[2]")
+	if !ok || !parts[0].HasIndex || parts[0].Index != 2 || parts[0].MultiMatch() {
+		t.Fatalf("unexpected index part: %#v", parts)
+	}
+	for _, bad := range []string{"tools", "$.tools[x]", "$.tools[0", "$.a..b"} {
+		if _, ok := ParsePath(bad); ok {
+			t.Fatalf("ParsePath(%q) should fail", bad)
+		}
+	}
+}
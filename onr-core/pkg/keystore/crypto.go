@@ -0,0 +1,234 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	masterKeyEnv         = "ONR_MASTER_KEY"
+	previousMasterKeyEnv = "ONR_MASTER_KEYS_PREVIOUS"
+)
+
+var (
+	encValuePattern   = regexp.MustCompile(`^ENC\[v1:aesgcm:([A-Za-z0-9+/=]+)\]$`)
+	encV2ValuePattern = regexp.MustCompile(`^ENC\[v2:aesgcm:([A-Za-z0-9_-]+):([A-Za-z0-9+/=]+)\]$`)
+)
+
+// MasterKey is an AES-256 master key with the key ID stamped into ENC[v2:...] values.
+type MasterKey struct {
+	ID  string
+	Key []byte
+}
+
+// IsEncrypted reports whether raw is an ENC[v1:...] or ENC[v2:...] value.
+func IsEncrypted(raw string) bool {
+	s := strings.TrimSpace(raw)
+	return encValuePattern.MatchString(s) || encV2ValuePattern.MatchString(s)
+}
+
+// EncryptedKeyID returns the key ID of an ENC[v2:...] value.
+// It returns "", false for ENC[v1:...] values and non-encrypted input.
+func EncryptedKeyID(raw string) (string, bool) {
+	m := encV2ValuePattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// MasterKeyID returns the stable key ID derived from key material (first 8 hex chars of SHA-256).
+func MasterKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// HasMasterKey reports whether ONR_MASTER_KEY is set.
+func HasMasterKey() bool {
+	return strings.TrimSpace(os.Getenv(masterKeyEnv)) != ""
+}
+
+// LoadMasterKeys returns the primary key from ONR_MASTER_KEY followed by the decrypt-only
+// keys from ONR_MASTER_KEYS_PREVIOUS (comma-separated).
+func LoadMasterKeys() ([]MasterKey, error) {
+	primary, err := loadMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	out := []MasterKey{{ID: MasterKeyID(primary), Key: primary}}
+	seen := map[string]bool{out[0].ID: true}
+	for _, part := range strings.Split(os.Getenv(previousMasterKeyEnv), ",") {
+		raw := strings.TrimSpace(part)
+		if raw == "" {
+			continue
+		}
+		k, err := parseMasterKey(raw, previousMasterKeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		id := MasterKeyID(k)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, MasterKey{ID: id, Key: k})
+	}
+	return out, nil
+}
+
+func decryptIfNeeded(raw string) (string, error) {
+	if !IsEncrypted(raw) {
+		return raw, nil
+	}
+	return Decrypt(raw)
+}
+
+// Decrypt decrypts an ENC[v1:aesgcm:...] or ENC[v2:aesgcm:<kid>:...] value into plaintext.
+// v2 values select the master key by ID; v1 values try every configured master key.
+func Decrypt(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if m := encV2ValuePattern.FindStringSubmatch(s); m != nil {
+		keys, err := LoadMasterKeys()
+		if err != nil {
+			return "", err
+		}
+		for _, k := range keys {
+			if k.ID == m[1] {
+				return openAESGCM(k.Key, m[2], []byte(k.ID))
+			}
+		}
+		return "", fmt.Errorf("no master key with id %q (set %s or %s)", m[1], masterKeyEnv, previousMasterKeyEnv)
+	}
+	m := encValuePattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", errors.New("ciphertext must be in ENC[v1:aesgcm:...] or ENC[v2:aesgcm:<kid>:...] format")
+	}
+	keys, err := LoadMasterKeys()
+	if err != nil {
+		return "", err
+	}
+	var lastErr error
+	for _, k := range keys {
+		pt, err := openAESGCM(k.Key, m[1], nil)
+		if err == nil {
+			return pt, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// Encrypt encrypts plain with the primary master key into ENC[v2:aesgcm:<kid>:...].
+func Encrypt(plain string) (string, error) {
+	key, err := loadMasterKey()
+	if err != nil {
+		return "", err
+	}
+	return encryptWithKey(MasterKey{ID: MasterKeyID(key), Key: key}, plain)
+}
+
+// Rotate re-encrypts raw with the primary master key.
+// It returns raw unchanged and false when raw is not encrypted or is already a v2 value of the primary key.
+func Rotate(raw string) (string, bool, error) {
+	s := strings.TrimSpace(raw)
+	if !IsEncrypted(s) {
+		return raw, false, nil
+	}
+	primary, err := loadMasterKey()
+	if err != nil {
+		return "", false, err
+	}
+	pk := MasterKey{ID: MasterKeyID(primary), Key: primary}
+	if id, ok := EncryptedKeyID(s); ok && id == pk.ID {
+		return raw, false, nil
+	}
+	pt, err := Decrypt(s)
+	if err != nil {
+		return "", false, err
+	}
+	out, err := encryptWithKey(pk, pt)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func encryptWithKey(k MasterKey, plain string) (string, error) {
+	gcm, err := newGCM(k.Key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	// The key ID is bound as additional data so it cannot be swapped without detection.
+	ct := gcm.Seal(nil, nonce, []byte(plain), []byte(k.ID))
+	buf := make([]byte, 0, len(nonce)+len(ct))
+	buf = append(buf, nonce...)
+	buf = append(buf, ct...)
+	return "ENC[v2:aesgcm:" + k.ID + ":" + base64.StdEncoding.EncodeToString(buf) + "]", nil
+}
+
+func openAESGCM(key []byte, b64 string, aad []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 ciphertext: %w", err)
+	}
+	if len(data) < 12 {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce := data[:12]
+	ct := data[12:]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	pt, err := gcm.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return "", fmt.Errorf("decrypt failed: %w", err)
+	}
+	return string(pt), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func loadMasterKey() ([]byte, error) {
+	raw := strings.TrimSpace(os.Getenv(masterKeyEnv))
+	if raw == "" {
+		return nil, errors.New("ONR_MASTER_KEY is required to decrypt ENC[...] values")
+	}
+	return parseMasterKey(raw, masterKeyEnv)
+}
+
+func parseMasterKey(raw string, envName string) ([]byte, error) {
+	// Accept either raw 32-byte string or base64.
+	if len(raw) == 32 {
+		return []byte(raw), nil
+	}
+	b, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be 32 bytes or base64-encoded 32 bytes", envName)
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%s must be 32 bytes (AES-256)", envName)
+	}
+	return b, nil
+}
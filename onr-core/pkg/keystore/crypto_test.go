@@ -0,0 +1,122 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	testOldMasterKey = "12345678901234567890123456789012"
+	testNewMasterKey = "abcdefghijklmnopqrstuvwxyzABCDEF"
+)
+
+// encryptV1ForTest builds a legacy ENC[v1:aesgcm:...] value without a key ID.
+func encryptV1ForTest(t *testing.T, key string, plain string) string {
+	t.Helper()
+	gcm, err := newGCM([]byte(key))
+	if err != nil {
+		t.Fatalf("newGCM: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		t.Fatalf("nonce: %v", err)
+	}
+	ct := gcm.Seal(nil, nonce, []byte(plain), nil)
+	return "ENC[v1:aesgcm:" + base64.StdEncoding.EncodeToString(append(nonce, ct...)) + "]"
+}
+
+func TestDecrypt_V1Legacy(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", testOldMasterKey)
+	enc := encryptV1ForTest(t, testOldMasterKey, "legacy")
+	got, err := Decrypt(enc)
+	if err != nil || got != "legacy" {
+		t.Fatalf("Decrypt got=%q err=%v", got, err)
+	}
+	if _, ok := EncryptedKeyID(enc); ok {
+		t.Fatalf("v1 value should not carry a key id")
+	}
+}
+
+func TestDecrypt_PreviousMasterKeys(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", testOldMasterKey)
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "")
+	encV2, err := Encrypt("old-v2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	encV1 := encryptV1ForTest(t, testOldMasterKey, "old-v1")
+
+	t.Setenv("ONR_MASTER_KEY", testNewMasterKey)
+	if _, err := Decrypt(encV2); err == nil || !strings.Contains(err.Error(), "no master key with id") {
+		t.Fatalf("expected missing key id error, got %v", err)
+	}
+	if _, err := Decrypt(encV1); err == nil {
+		t.Fatalf("expected v1 decrypt failure with wrong key")
+	}
+
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", " , "+base64.StdEncoding.EncodeToString([]byte(testOldMasterKey)))
+	if got, err := Decrypt(encV2); err != nil || got != "old-v2" {
+		t.Fatalf("v2 got=%q err=%v", got, err)
+	}
+	if got, err := Decrypt(encV1); err != nil || got != "old-v1" {
+		t.Fatalf("v1 got=%q err=%v", got, err)
+	}
+
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "short")
+	if _, err := LoadMasterKeys(); err == nil || !strings.Contains(err.Error(), "ONR_MASTER_KEYS_PREVIOUS") {
+		t.Fatalf("expected previous key parse error, got %v", err)
+	}
+}
+
+func TestDecrypt_V2KeyIDTampered(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", testOldMasterKey)
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", testNewMasterKey)
+	enc, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	oldID := MasterKeyID([]byte(testOldMasterKey))
+	newID := MasterKeyID([]byte(testNewMasterKey))
+	tampered := strings.Replace(enc, ":"+oldID+":", ":"+newID+":", 1)
+	if _, err := Decrypt(tampered); err == nil {
+		t.Fatalf("expected decrypt failure for tampered key id")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", testOldMasterKey)
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "")
+	encV2, err := Encrypt("s1")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	encV1 := encryptV1ForTest(t, testOldMasterKey, "s2")
+
+	if out, changed, err := Rotate(encV2); err != nil || changed || out != encV2 {
+		t.Fatalf("current key should not rotate: changed=%v err=%v", changed, err)
+	}
+	if out, changed, err := Rotate("plain"); err != nil || changed || out != "plain" {
+		t.Fatalf("plaintext should not rotate: changed=%v err=%v", changed, err)
+	}
+
+	t.Setenv("ONR_MASTER_KEY", testNewMasterKey)
+	t.Setenv("ONR_MASTER_KEYS_PREVIOUS", testOldMasterKey)
+	newID := MasterKeyID([]byte(testNewMasterKey))
+	for plain, enc := range map[string]string{"s1": encV2, "s2": encV1} {
+		out, changed, err := Rotate(enc)
+		if err != nil || !changed {
+			t.Fatalf("Rotate(%s) changed=%v err=%v", plain, changed, err)
+		}
+		if id, ok := EncryptedKeyID(out); !ok || id != newID {
+			t.Fatalf("rotated key id=%q want %q", id, newID)
+		}
+		t.Setenv("ONR_MASTER_KEYS_PREVIOUS", "")
+		if got, err := Decrypt(out); err != nil || got != plain {
+			t.Fatalf("decrypt rotated got=%q err=%v", got, err)
+		}
+		t.Setenv("ONR_MASTER_KEYS_PREVIOUS", testOldMasterKey)
+	}
+}
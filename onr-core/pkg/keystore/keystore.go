@@ -1,16 +1,11 @@
 package keystore
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"crypto/subtle"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -42,6 +37,9 @@ type AccessKey struct {
 	Value    string `yaml:"value"`
 	Disabled bool   `yaml:"disabled"`
 	Comment  string `yaml:"comment"`
+	// ClientCertSubject maps a verified mTLS client certificate to this access key.
+	// It matches either the certificate subject CN or the full RFC 2253 subject DN.
+	ClientCertSubject string `yaml:"client_cert_subject"`
 }
 
 type fileFormat struct {
@@ -52,8 +50,6 @@ type fileFormat struct {
 	AccessKeys []AccessKey `yaml:"access_keys"`
 }
 
-var encValuePattern = regexp.MustCompile(`^ENC\[v1:aesgcm:([A-Za-z0-9+/=]+)\]$`)
-
 func Load(path string) (*Store, error) {
 	// #nosec G304 -- path is provided by trusted config.
 	b, err := os.ReadFile(path)
@@ -68,6 +64,7 @@ func Load(path string) (*Store, error) {
 		byProv:  map[string][]Key{},
 		nextIdx: map[string]int{},
 	}
+	baseDir := filepath.Dir(path)
 	for prov, v := range ff.Providers {
 		p := normalizeProvider(prov)
 		if p == "" {
@@ -93,7 +90,7 @@ func Load(path string) (*Store, error) {
 			}
 
 			if raw != "" {
-				val, err := decryptIfNeeded(raw)
+				val, err := resolveValue(raw, baseDir)
 				if err != nil {
 					return nil, fmt.Errorf("invalid key value for provider=%s name=%q: %w", p, k.Name, err)
 				}
@@ -115,19 +112,24 @@ func Load(path string) (*Store, error) {
 		}
 		ak.Name = strings.TrimSpace(ak.Name)
 		ak.Comment = strings.TrimSpace(ak.Comment)
+		ak.ClientCertSubject = strings.TrimSpace(ak.ClientCertSubject)
 
 		raw := strings.TrimSpace(ak.Value)
 		if envVal := strings.TrimSpace(os.Getenv(envVarForAccessKey(ak.Name, i))); envVal != "" {
 			raw = envVal
 		}
-		if raw == "" {
+		if raw == "" && ak.ClientCertSubject == "" {
 			continue
 		}
-		val, err := decryptIfNeeded(raw)
-		if err != nil {
-			return nil, fmt.Errorf("invalid access_keys value name=%q: %w", ak.Name, err)
+		if raw != "" {
+			val, err := resolveValue(raw, baseDir)
+			if err != nil {
+				return nil, fmt.Errorf("invalid access_keys value name=%q: %w", ak.Name, err)
+			}
+			ak.Value = val
+		} else {
+			ak.Value = ""
 		}
-		ak.Value = val
 		aks = append(aks, ak)
 	}
 	out.accessKeys = aks
@@ -141,6 +143,30 @@ func Load(path string) (*Store, error) {
 // NextKey returns the next provider key in round-robin order.
 // It returns nil, false when the store is nil or the provider has no keys.
 func (s *Store) NextKey(provider string) (*Key, bool) {
+	return s.NextKeyFunc(provider, nil)
+}
+
+// PeekKey returns the key NextKey would return without advancing the round-robin cursor.
+// It returns nil, false when the store is nil or the provider has no keys.
+func (s *Store) PeekKey(provider string) (*Key, bool) {
+	return s.PeekKeyFunc(provider, nil)
+}
+
+// NextKeyFunc is NextKey restricted to the keys for which usable returns true;
+// i is the key's index in keys.yaml. When no key is usable it falls back to
+// plain round-robin so a provider is never left without keys. A nil usable
+// accepts every key. usable must not call back into the Store.
+func (s *Store) NextKeyFunc(provider string, usable func(i int, k Key) bool) (*Key, bool) {
+	return s.selectKey(provider, usable, true)
+}
+
+// PeekKeyFunc returns the key NextKeyFunc would return without advancing the
+// round-robin cursor.
+func (s *Store) PeekKeyFunc(provider string, usable func(i int, k Key) bool) (*Key, bool) {
+	return s.selectKey(provider, usable, false)
+}
+
+func (s *Store) selectKey(provider string, usable func(i int, k Key) bool, advance bool) (*Key, bool) {
 	if s == nil {
 		return nil, false
 	}
@@ -151,9 +177,20 @@ func (s *Store) NextKey(provider string) (*Key, bool) {
 	if len(keys) == 0 {
 		return nil, false
 	}
-	i := s.nextIdx[p] % len(keys)
-	s.nextIdx[p] = (i + 1) % len(keys)
-	return &keys[i], true
+	start := s.nextIdx[p] % len(keys)
+	pick := start
+	if usable != nil {
+		for j := range len(keys) {
+			if i := (start + j) % len(keys); usable(i, keys[i]) {
+				pick = i
+				break
+			}
+		}
+	}
+	if advance {
+		s.nextIdx[p] = (pick + 1) % len(keys)
+	}
+	return &keys[pick], true
 }
 
 // MatchAccessKey requires a non-nil Store receiver.
@@ -174,6 +211,29 @@ func (s *Store) MatchAccessKey(value string) (*AccessKey, bool) {
 	return nil, false
 }
 
+// MatchClientCertSubject requires a non-nil Store receiver.
+// It matches access keys whose client_cert_subject equals the certificate CN or full subject DN.
+func (s *Store) MatchClientCertSubject(commonName string, subjectDN string) (*AccessKey, bool) {
+	cn := strings.TrimSpace(commonName)
+	dn := strings.TrimSpace(subjectDN)
+	if cn == "" && dn == "" {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.accessKeys {
+		ak := &s.accessKeys[i]
+		want := ak.ClientCertSubject
+		if want == "" {
+			continue
+		}
+		if (cn != "" && want == cn) || (dn != "" && want == dn) {
+			return ak, true
+		}
+	}
+	return nil, false
+}
+
 // AccessKeys requires a non-nil Store receiver.
 // It returns a copy of the configured access keys.
 func (s *Store) AccessKeys() []AccessKey {
@@ -233,88 +293,30 @@ func sanitizeEnvToken(s string) string {
 	return b.String()
 }
 
-func decryptIfNeeded(raw string) (string, error) {
-	m := encValuePattern.FindStringSubmatch(raw)
-	if m == nil {
-		return raw, nil
-	}
-	return Decrypt(raw)
-}
-
-// Decrypt decrypts an ENC[v1:aesgcm:...] value into plaintext.
-func Decrypt(raw string) (string, error) {
-	m := encValuePattern.FindStringSubmatch(strings.TrimSpace(raw))
-	if m == nil {
-		return "", errors.New("ciphertext must be in ENC[v1:aesgcm:...] format")
-	}
-	key, err := loadMasterKey()
-	if err != nil {
-		return "", err
-	}
-	data, err := base64.StdEncoding.DecodeString(m[1])
-	if err != nil {
-		return "", fmt.Errorf("invalid base64 ciphertext: %w", err)
-	}
-	if len(data) < 12 {
-		return "", errors.New("ciphertext too short")
-	}
-	nonce := data[:12]
-	ct := data[12:]
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-	pt, err := gcm.Open(nil, nonce, ct, nil)
-	if err != nil {
-		return "", fmt.Errorf("decrypt failed: %w", err)
-	}
-	return string(pt), nil
-}
-
-func Encrypt(plain string) (string, error) {
-	key, err := loadMasterKey()
+// resolveValue resolves ref+ secret references first, then decrypts ENC[...] values,
+// so a mounted secret may itself hold ciphertext. Relative ref+file paths resolve
+// against baseDir.
+func resolveValue(raw, baseDir string) (string, error) {
+	v, err := resolveSecretRef(raw, baseDir)
 	if err != nil {
 		return "", err
 	}
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-	ct := gcm.Seal(nil, nonce, []byte(plain), nil)
-	buf := make([]byte, 0, len(nonce)+len(ct))
-	buf = append(buf, nonce...)
-	buf = append(buf, ct...)
-	return "ENC[v1:aesgcm:" + base64.StdEncoding.EncodeToString(buf) + "]", nil
+	return decryptIfNeeded(v)
 }
 
-func loadMasterKey() ([]byte, error) {
-	raw := strings.TrimSpace(os.Getenv("ONR_MASTER_KEY"))
-	if raw == "" {
-		return nil, errors.New("ONR_MASTER_KEY is required to decrypt ENC[...] values")
-	}
-	// Accept either raw 32-byte string or base64.
-	if len(raw) == 32 {
-		return []byte(raw), nil
-	}
-	b, err := base64.StdEncoding.DecodeString(raw)
-	if err != nil {
-		return nil, errors.New("ONR_MASTER_KEY must be 32 bytes or base64-encoded 32 bytes")
+// Keys returns a copy of the provider's keys in file order, or nil when the
+// store is nil or the provider has no keys.
+func (s *Store) Keys(provider string) []Key {
+	if s == nil {
+		return nil
 	}
-	if len(b) != 32 {
-		return nil, errors.New("ONR_MASTER_KEY must be 32 bytes (AES-256)")
+	p := normalizeProvider(provider)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.byProv[p]) == 0 {
+		return nil
 	}
-	return b, nil
+	out := make([]Key, len(s.byProv[p]))
+	copy(out, s.byProv[p])
+	return out
 }
@@ -70,10 +70,16 @@ access_keys:
 		t.Fatalf("unexpected provider")
 	}
 
+	if pk, ok := st.PeekKey("openai"); !ok || pk == nil || pk.Value != "v1" {
+		t.Fatalf("peek #1: %#v %v", pk, ok)
+	}
 	k1, ok := st.NextKey("openai")
 	if !ok || k1 == nil || k1.Value != "v1" {
 		t.Fatalf("next #1: %#v %v", k1, ok)
 	}
+	if pk, ok := st.PeekKey("openai"); !ok || pk == nil || pk.Value != "v2" {
+		t.Fatalf("peek #2: %#v %v", pk, ok)
+	}
 	k2, ok := st.NextKey("openai")
 	if !ok || k2 == nil || k2.Value != "v2" {
 		t.Fatalf("next #2: %#v %v", k2, ok)
@@ -85,6 +91,9 @@ access_keys:
 	if _, ok := st.NextKey("none"); ok {
 		t.Fatalf("unexpected key for none")
 	}
+	if _, ok := st.PeekKey("none"); ok {
+		t.Fatalf("unexpected peek key for none")
+	}
 
 	aks := st.AccessKeys()
 	if len(aks) != 1 || aks[0].Name != "client-a" {
@@ -97,6 +106,38 @@ access_keys:
 	}
 }
 
+func TestStore_NextKeyFuncSkipsUnusableKeys(t *testing.T) {
+	st := &Store{
+		byProv: map[string][]Key{
+			"openai": {{Name: "k1", Value: "v1"}, {Name: "k2", Value: "v2"}, {Name: "k3", Value: "v3"}},
+		},
+		nextIdx: map[string]int{},
+	}
+	notK2 := func(_ int, k Key) bool { return k.Name != "k2" }
+	var got []string
+	for range 4 {
+		k, ok := st.NextKeyFunc("openai", notK2)
+		if !ok {
+			t.Fatalf("no key")
+		}
+		got = append(got, k.Name)
+	}
+	if strings.Join(got, ",") != "k1,k3,k1,k3" {
+		t.Fatalf("rotation=%v", got)
+	}
+	if pk, ok := st.PeekKeyFunc("openai", notK2); !ok || pk.Name != "k1" {
+		t.Fatalf("peek=%#v %v", pk, ok)
+	}
+	// Nothing usable: plain round-robin.
+	none := func(int, Key) bool { return false }
+	if k, ok := st.NextKeyFunc("openai", none); !ok || k.Name != "k1" {
+		t.Fatalf("fallback=%#v %v", k, ok)
+	}
+	if k, ok := st.NextKeyFunc("openai", none); !ok || k.Name != "k2" {
+		t.Fatalf("fallback #2=%#v %v", k, ok)
+	}
+}
+
 func TestLoad_CredentialFileKeyWithoutValue(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "keys.yaml")
@@ -164,7 +205,7 @@ func TestEncryptDecrypt(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Encrypt err=%v", err)
 	}
-	if !strings.HasPrefix(enc, "ENC[v1:aesgcm:") {
+	if !strings.HasPrefix(enc, "ENC[v2:aesgcm:"+MasterKeyID([]byte("12345678901234567890123456789012"))+":") {
 		t.Fatalf("unexpected encrypted format: %q", enc)
 	}
 	got, err := Decrypt(enc)
@@ -268,3 +309,39 @@ access_keys:
 		t.Fatalf("expected access key match")
 	}
 }
+
+func TestLoad_ClientCertSubjectAccessKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(path, []byte(`
+access_keys:
+  - name: "svc-a"
+    client_cert_subject: " svc-a.internal "
+  - name: "svc-b"
+    value: "ak-b"
+    client_cert_subject: "CN=svc-b,O=Example"
+  - name: "no-identity"
+    value: ""
+`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	if got := len(st.AccessKeys()); got != 2 {
+		t.Fatalf("access keys=%d want 2", got)
+	}
+	if ak, ok := st.MatchClientCertSubject("svc-a.internal", "CN=svc-a.internal"); !ok || ak.Name != "svc-a" {
+		t.Fatalf("expected CN match, got %#v", ak)
+	}
+	if ak, ok := st.MatchClientCertSubject("svc-b", "CN=svc-b,O=Example"); !ok || ak.Name != "svc-b" {
+		t.Fatalf("expected DN match, got %#v", ak)
+	}
+	if _, ok := st.MatchClientCertSubject("other", "CN=other"); ok {
+		t.Fatalf("unexpected match")
+	}
+	if _, ok := st.MatchAccessKey(""); ok {
+		t.Fatalf("empty value must not match cert-only access key")
+	}
+}
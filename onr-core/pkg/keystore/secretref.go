@@ -0,0 +1,333 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretRefPrefix marks a keys.yaml value that must be resolved by a SecretResolver,
+// e.g. "ref+file:///run/secrets/openai" or "ref+vault://secret/data/openai#api_key".
+const secretRefPrefix = "ref+"
+
+const defaultSecretResolveTimeout = 10 * time.Second
+
+// DefaultSecretCacheTTL bounds how long an exec/vault secret stays cached for
+// implicit re-resolution (repeated Loads outside an explicit reload).
+const DefaultSecretCacheTTL = 5 * time.Minute
+
+// SecretResolver resolves the reference part of a "ref+<scheme>:<ref>" value into plaintext.
+// The ref passed to Resolve is the full reference after "ref+", including the scheme.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]SecretResolver{
+		"file":  SecretResolverFunc(resolveFileRef),
+		"exec":  SecretResolverFunc(resolveExecRef),
+		"vault": &VaultResolver{},
+	}
+
+	secretCache = &secretRefCache{values: map[string]secretCacheEntry{}, ttl: DefaultSecretCacheTTL}
+)
+
+type secretBaseDirKey struct{}
+
+// withSecretBaseDir tells resolvers which directory relative references
+// resolve against (the keys.yaml directory).
+func withSecretBaseDir(ctx context.Context, dir string) context.Context {
+	if dir == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, secretBaseDirKey{}, dir)
+}
+
+func secretBaseDir(ctx context.Context) string {
+	dir, _ := ctx.Value(secretBaseDirKey{}).(string)
+	return dir
+}
+
+// RegisterSecretResolver installs r for the given scheme, replacing any existing resolver.
+// A nil resolver removes the scheme.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	s := strings.ToLower(strings.TrimSpace(scheme))
+	if s == "" {
+		return
+	}
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	if r == nil {
+		delete(resolvers, s)
+		return
+	}
+	resolvers[s] = r
+}
+
+// ResetSecretCache drops all cached secret reference values so the next Load
+// re-resolves them. Explicit reloads (SIGHUP / onr -s reload) call it.
+func ResetSecretCache() {
+	secretCache.reset()
+}
+
+// SetSecretCacheTTL changes how long resolved secrets are cached. A
+// non-positive ttl disables caching.
+func SetSecretCacheTTL(ttl time.Duration) {
+	secretCache.setTTL(ttl)
+}
+
+// IsSecretRef reports whether raw is a "ref+<scheme>:..." secret reference.
+func IsSecretRef(raw string) bool {
+	return strings.HasPrefix(strings.TrimSpace(raw), secretRefPrefix)
+}
+
+// ResolveSecretRef resolves a "ref+<scheme>:..." value using the registered resolvers.
+// Values without the ref+ prefix are returned unchanged. Relative ref+file paths
+// resolve against the process working directory; Load resolves them against the
+// keys.yaml directory instead. Non-file results are cached for the secret cache TTL.
+func ResolveSecretRef(raw string) (string, error) {
+	return resolveSecretRef(raw, "")
+}
+
+func resolveSecretRef(raw, baseDir string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if !strings.HasPrefix(s, secretRefPrefix) {
+		return raw, nil
+	}
+	ref := strings.TrimPrefix(s, secretRefPrefix)
+	scheme, _, ok := strings.Cut(ref, ":")
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if !ok || scheme == "" {
+		return "", fmt.Errorf("invalid secret reference %q: expected ref+<scheme>:<ref>", redactSecretRef(s))
+	}
+	// File reads are cheap, so they are never cached and a rotated secret file
+	// takes effect on the next reload.
+	cacheable := scheme != "file"
+	if cacheable {
+		if v, ok := secretCache.get(s); ok {
+			return v, nil
+		}
+	}
+	resolversMu.RLock()
+	r := resolvers[scheme]
+	resolversMu.RUnlock()
+	if r == nil {
+		return "", fmt.Errorf("unsupported secret reference scheme %q", scheme)
+	}
+	v, err := r.Resolve(withSecretBaseDir(context.Background(), baseDir), ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", redactSecretRef(s), err)
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", fmt.Errorf("resolve %s: empty secret", redactSecretRef(s))
+	}
+	if cacheable {
+		secretCache.set(s, v)
+	}
+	return v, nil
+}
+
+// redactSecretRef drops query strings so inline tokens never end up in error messages.
+func redactSecretRef(s string) string {
+	if i := strings.IndexByte(s, '?'); i >= 0 {
+		return s[:i] + "?..."
+	}
+	return s
+}
+
+type secretCacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+type secretRefCache struct {
+	mu     sync.Mutex
+	values map[string]secretCacheEntry
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func (c *secretRefCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *secretRefCache) get(k string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.values[k]
+	if !ok {
+		return "", false
+	}
+	if !c.clock().Before(e.expires) {
+		delete(c.values, k)
+		return "", false
+	}
+	return e.value, true
+}
+
+func (c *secretRefCache) set(k, v string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return
+	}
+	c.values[k] = secretCacheEntry{value: v, expires: c.clock().Add(c.ttl)}
+}
+
+func (c *secretRefCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	c.values = map[string]secretCacheEntry{}
+}
+
+func (c *secretRefCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = map[string]secretCacheEntry{}
+}
+
+// resolveFileRef reads "file:///abs/path" or "file:relative/path" and returns its trimmed content.
+// Relative paths resolve against the keys.yaml directory when Load passes one.
+func resolveFileRef(ctx context.Context, ref string) (string, error) {
+	p := strings.TrimPrefix(ref, "file:")
+	p = strings.TrimPrefix(p, "//")
+	if strings.TrimSpace(p) == "" {
+		return "", errors.New("file path is empty")
+	}
+	if dir := secretBaseDir(ctx); dir != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	// #nosec G304 -- secret file paths come from trusted keys.yaml.
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// resolveExecRef runs "exec:<command> [args...]" without a shell and returns its trimmed stdout.
+func resolveExecRef(ctx context.Context, ref string) (string, error) {
+	cmdline := strings.TrimPrefix(ref, "exec:")
+	cmdline = strings.TrimPrefix(cmdline, "//")
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return "", errors.New("exec command is empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultSecretResolveTimeout)
+	defer cancel()
+	// #nosec G204 -- exec references come from trusted keys.yaml.
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// VaultResolver reads secrets from a HashiCorp Vault compatible HTTP API (e.g. a vault agent sidecar).
+//
+// Reference format: "vault://<mount>/<path>#<field>". Both KV v2 (data.data.<field>) and
+// KV v1 (data.<field>) response shapes are accepted. Addr defaults to VAULT_ADDR and
+// Token defaults to VAULT_TOKEN; an empty token is allowed for agents that inject auth.
+type VaultResolver struct {
+	Addr   string
+	Token  string
+	Client *http.Client
+}
+
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	rest := strings.TrimPrefix(ref, "vault:")
+	rest = strings.TrimPrefix(rest, "//")
+	secretPath, field, _ := strings.Cut(rest, "#")
+	secretPath = strings.Trim(strings.TrimSpace(secretPath), "/")
+	field = strings.TrimSpace(field)
+	if secretPath == "" || field == "" {
+		return "", errors.New("vault reference must be vault://<path>#<field>")
+	}
+
+	addr := strings.TrimSpace(v.Addr)
+	if addr == "" {
+		addr = strings.TrimSpace(os.Getenv("VAULT_ADDR"))
+	}
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is required for ref+vault values")
+	}
+	token := strings.TrimSpace(v.Token)
+	if token == "" {
+		token = strings.TrimSpace(os.Getenv("VAULT_TOKEN"))
+	}
+	u, err := url.JoinPath(addr, "v1", secretPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultSecretResolveTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+	data := body.Data
+	if inner, ok := data["data"].(map[string]any); ok {
+		data = inner
+	}
+	val, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret has no field %q", field)
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("vault field %q is not a string", field)
+	}
+	return s, nil
+}
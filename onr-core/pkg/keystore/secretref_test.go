@@ -0,0 +1,227 @@
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_SecretRefFile(t *testing.T) {
+	ResetSecretCache()
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "openai")
+	if err := os.WriteFile(secretPath, []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	akPath := filepath.Join(dir, "client-a")
+	if err := os.WriteFile(akPath, []byte("ak-from-file"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	path := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(path, []byte(`
+providers:
+  openai:
+    keys:
+      - name: "main"
+        value: "ref+file://`+secretPath+`"
+access_keys:
+  - name: "client-a"
+    value: "ref+file:`+akPath+`"
+`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	k, ok := st.NextKey("openai")
+	if !ok || k.Value != "sk-from-file" {
+		t.Fatalf("unexpected key: %#v", k)
+	}
+	if _, ok := st.MatchAccessKey("ak-from-file"); !ok {
+		t.Fatalf("expected access key match")
+	}
+}
+
+func TestLoad_SecretRefEncryptedFile(t *testing.T) {
+	ResetSecretCache()
+	t.Setenv("ONR_MASTER_KEY", "12345678901234567890123456789012")
+	enc, err := Encrypt("sk-decrypted")
+	if err != nil {
+		t.Fatalf("Encrypt err=%v", err)
+	}
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "openai")
+	if err := os.WriteFile(secretPath, []byte(enc), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	path := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(path, []byte(`
+providers:
+  openai:
+    keys:
+      - name: "main"
+        value: "ref+file://`+secretPath+`"
+`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	if k, _ := st.NextKey("openai"); k == nil || k.Value != "sk-decrypted" {
+		t.Fatalf("unexpected key: %#v", k)
+	}
+}
+
+func TestResolveSecretRef_CacheTTLAndReset(t *testing.T) {
+	ResetSecretCache()
+	now := time.Unix(1_700_000_000, 0)
+	secretCache.now = func() time.Time { return now }
+	t.Cleanup(func() { secretCache.now = nil })
+	calls := 0
+	RegisterSecretResolver("counting", SecretResolverFunc(func(_ context.Context, _ string) (string, error) {
+		calls++
+		return fmt.Sprintf("v%d", calls), nil
+	}))
+	t.Cleanup(func() { RegisterSecretResolver("counting", nil) })
+
+	ref := "ref+counting:x"
+	if got, err := ResolveSecretRef(ref); err != nil || got != "v1" {
+		t.Fatalf("got=%q err=%v", got, err)
+	}
+	if got, _ := ResolveSecretRef(ref); got != "v1" || calls != 1 {
+		t.Fatalf("expected cached value, got=%q calls=%d", got, calls)
+	}
+	now = now.Add(DefaultSecretCacheTTL)
+	if got, _ := ResolveSecretRef(ref); got != "v2" {
+		t.Fatalf("expected value re-resolved after TTL, got=%q", got)
+	}
+	ResetSecretCache()
+	if got, _ := ResolveSecretRef(ref); got != "v3" {
+		t.Fatalf("expected value re-resolved after reset, got=%q", got)
+	}
+}
+
+func TestResolveSecretRef_FileIsNotCached(t *testing.T) {
+	ResetSecretCache()
+	secretPath := filepath.Join(t.TempDir(), "s")
+	if err := os.WriteFile(secretPath, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ref := "ref+file://" + secretPath
+	if got, err := ResolveSecretRef(ref); err != nil || got != "v1" {
+		t.Fatalf("got=%q err=%v", got, err)
+	}
+	if err := os.WriteFile(secretPath, []byte("v2"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got, _ := ResolveSecretRef(ref); got != "v2" {
+		t.Fatalf("expected rotated file value, got=%q", got)
+	}
+}
+
+func TestLoad_SecretRefFileRelativeToKeysDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "secrets"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secrets", "openai"), []byte("sk-relative"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	path := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(path, []byte("providers:\n  openai:\n    keys:\n      - name: main\n        value: \"ref+file:secrets/openai\"\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	st, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	if k, _ := st.NextKey("openai"); k == nil || k.Value != "sk-relative" {
+		t.Fatalf("unexpected key: %#v", k)
+	}
+}
+
+func TestResolveSecretRef_Exec(t *testing.T) {
+	ResetSecretCache()
+	got, err := ResolveSecretRef("ref+exec:echo sk-from-exec")
+	if err != nil {
+		t.Fatalf("ResolveSecretRef err=%v", err)
+	}
+	if got != "sk-from-exec" {
+		t.Fatalf("got=%q", got)
+	}
+	if _, err := ResolveSecretRef("ref+exec:false"); err == nil {
+		t.Fatalf("expected failing command error")
+	}
+}
+
+func TestResolveSecretRef_Errors(t *testing.T) {
+	ResetSecretCache()
+	if got, err := ResolveSecretRef("plain"); err != nil || got != "plain" {
+		t.Fatalf("plain value got=%q err=%v", got, err)
+	}
+	if _, err := ResolveSecretRef("ref+nothing"); err == nil {
+		t.Fatalf("expected missing scheme error")
+	}
+	if _, err := ResolveSecretRef("ref+unknown:x"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected unsupported scheme error, got %v", err)
+	}
+	if _, err := ResolveSecretRef("ref+file:///does/not/exist"); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+	ResetSecretCache()
+	RegisterSecretResolver("test", SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+		return "resolved:" + ref, nil
+	}))
+	t.Cleanup(func() { RegisterSecretResolver("test", nil) })
+	got, err := ResolveSecretRef("ref+test:abc")
+	if err != nil || got != "resolved:test:abc" {
+		t.Fatalf("got=%q err=%v", got, err)
+	}
+}
+
+func TestVaultResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/openai":
+			_, _ = w.Write([]byte(`{"data":{"data":{"api_key":"sk-v2"}}}`))
+		case "/v1/kv/openai":
+			_, _ = w.Write([]byte(`{"data":{"api_key":"sk-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	v := &VaultResolver{Addr: srv.URL, Token: "tok", Client: srv.Client()}
+	if got, err := v.Resolve(context.Background(), "vault://secret/data/openai#api_key"); err != nil || got != "sk-v2" {
+		t.Fatalf("kv2 got=%q err=%v", got, err)
+	}
+	if got, err := v.Resolve(context.Background(), "vault://kv/openai#api_key"); err != nil || got != "sk-v1" {
+		t.Fatalf("kv1 got=%q err=%v", got, err)
+	}
+	if _, err := v.Resolve(context.Background(), "vault://kv/openai#missing"); err == nil {
+		t.Fatalf("expected missing field error")
+	}
+	if _, err := v.Resolve(context.Background(), "vault://kv/openai"); err == nil {
+		t.Fatalf("expected missing field selector error")
+	}
+	if _, err := v.Resolve(context.Background(), "vault://kv/none#api_key"); err == nil {
+		t.Fatalf("expected status error")
+	}
+}
@@ -90,6 +90,22 @@ func (r *Router) NextProvider(modelID string) (string, bool) {
 	}
 }
 
+// PeekProvider requires a non-nil Router receiver. It returns the provider NextProvider
+// would return without advancing the round-robin cursor.
+func (r *Router) PeekProvider(modelID string) (string, bool) {
+	id := normalizeModelID(modelID)
+	if id == "" {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt, ok := r.routes[id]
+	if !ok || len(rt.Providers) == 0 {
+		return "", false
+	}
+	return rt.Providers[r.nextIdx[id]%len(rt.Providers)], true
+}
+
 func (r *Router) ToOpenAIList() map[string]any {
 	return r.ToOpenAIListAt(0)
 }
@@ -29,6 +29,9 @@ func TestRouter_Basic(t *testing.T) {
 	if !ok || p1 != "openai" {
 		t.Fatalf("next provider #1: %q %v", p1, ok)
 	}
+	if pp, ok := r.PeekProvider("gpt-4o-mini"); !ok || pp != "azure" {
+		t.Fatalf("peek provider: %q %v", pp, ok)
+	}
 	p2, ok := r.NextProvider("gpt-4o-mini")
 	if !ok || p2 != "azure" {
 		t.Fatalf("next provider #2: %q %v", p2, ok)
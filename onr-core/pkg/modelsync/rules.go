@@ -0,0 +1,129 @@
+package modelsync
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Alias rewrites upstream model ids before they are routed, e.g. to strip a
+// "models/" prefix or to map a vendor-qualified id onto a shared name.
+type Alias struct {
+	// Provider limits the alias to one provider; empty applies to all.
+	Provider string `yaml:"provider"`
+	// Match is a regular expression matched against the upstream id.
+	Match string `yaml:"match"`
+	// Replace is the whole routed id; it may reference Match groups ($1, ${name}).
+	Replace string `yaml:"replace"`
+}
+
+// RulesConfig selects which upstream ids become models.yaml routes.
+type RulesConfig struct {
+	// Allow keeps only ids matching at least one expression; empty keeps all.
+	Allow []string
+	// Deny drops ids matching any expression; it wins over Allow.
+	Deny []string
+	// Aliases are tried in order; the first match rewrites the id.
+	Aliases []Alias
+}
+
+type alias struct {
+	provider string
+	re       *regexp.Regexp
+	replace  string
+}
+
+// Rules is a compiled RulesConfig. A nil Rules keeps every id unchanged.
+type Rules struct {
+	allow   []*regexp.Regexp
+	deny    []*regexp.Regexp
+	aliases []alias
+}
+
+// Compile validates cfg and returns non-nil rules.
+func Compile(cfg RulesConfig) (*Rules, error) {
+	r := &Rules{}
+	var err error
+	if r.allow, err = compileAll("allow", cfg.Allow); err != nil {
+		return nil, err
+	}
+	if r.deny, err = compileAll("deny", cfg.Deny); err != nil {
+		return nil, err
+	}
+	for i, a := range cfg.Aliases {
+		match := strings.TrimSpace(a.Match)
+		if match == "" {
+			return nil, fmt.Errorf("alias %d: match is empty", i)
+		}
+		re, err := regexp.Compile(match)
+		if err != nil {
+			return nil, fmt.Errorf("alias %d: invalid match %q: %w", i, match, err)
+		}
+		if strings.TrimSpace(a.Replace) == "" {
+			return nil, fmt.Errorf("alias %d: replace is empty", i)
+		}
+		r.aliases = append(r.aliases, alias{
+			provider: strings.ToLower(strings.TrimSpace(a.Provider)),
+			re:       re,
+			replace:  strings.TrimSpace(a.Replace),
+		})
+	}
+	return r, nil
+}
+
+func compileAll(kind string, exprs []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, e := range exprs {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", kind, e, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// Route maps an upstream id of provider to its models.yaml id. Aliases apply
+// first; allow/deny then match the aliased id. ok is false for dropped ids.
+func (r *Rules) Route(provider, upstreamID string) (id string, ok bool) {
+	id = strings.TrimSpace(upstreamID)
+	if id == "" {
+		return "", false
+	}
+	if r == nil {
+		return id, true
+	}
+	p := strings.ToLower(strings.TrimSpace(provider))
+	for _, a := range r.aliases {
+		if a.provider != "" && a.provider != p {
+			continue
+		}
+		if loc := a.re.FindStringSubmatchIndex(id); loc != nil {
+			id = strings.TrimSpace(string(a.re.ExpandString(nil, a.replace, id, loc)))
+			break
+		}
+	}
+	if id == "" {
+		return "", false
+	}
+	if len(r.allow) > 0 && !matchAny(r.allow, id) {
+		return "", false
+	}
+	if matchAny(r.deny, id) {
+		return "", false
+	}
+	return id, true
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+// Package modelsync keeps models.yaml routes in line with the model lists that
+// providers report through the DSL models block.
+package modelsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+)
+
+// FetchFunc returns the upstream model ids visible to one provider key.
+type FetchFunc func(ctx context.Context, provider string, key keystore.Key) ([]string, error)
+
+// ProviderResult is the union of the ids reported by every key of a provider.
+type ProviderResult struct {
+	Provider string
+	IDs      []string
+	// Keys is the number of keys queried; Errors holds one entry per failed key.
+	Keys   int
+	Errors []error
+}
+
+// Complete reports whether every key answered, i.e. whether the provider's
+// list is authoritative enough to remove routes.
+func (r ProviderResult) Complete() bool {
+	return r.Keys > 0 && len(r.Errors) == 0
+}
+
+// Err joins the per-key errors.
+func (r ProviderResult) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// Snapshot is the upstream view of a sync run.
+type Snapshot struct {
+	Providers []ProviderResult
+}
+
+// Collect queries every key of each provider with fetch. keys returns the keys
+// of a provider; a provider without keys is reported with an error.
+func Collect(ctx context.Context, providers []string, keys func(provider string) []keystore.Key, fetch FetchFunc) Snapshot {
+	var snap Snapshot
+	for _, p := range providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		res := ProviderResult{Provider: p}
+		seen := map[string]struct{}{}
+		for i, k := range keys(p) {
+			res.Keys++
+			ids, err := fetch(ctx, p, k)
+			if err != nil {
+				name := strings.TrimSpace(k.Name)
+				if name == "" {
+					name = fmt.Sprintf("#%d", i)
+				}
+				res.Errors = append(res.Errors, fmt.Errorf("key %s: %w", name, err))
+				continue
+			}
+			for _, id := range ids {
+				id = strings.TrimSpace(id)
+				if _, ok := seen[id]; ok || id == "" {
+					continue
+				}
+				seen[id] = struct{}{}
+				res.IDs = append(res.IDs, id)
+			}
+		}
+		if res.Keys == 0 {
+			res.Errors = append(res.Errors, errors.New("no keys configured"))
+		}
+		sort.Strings(res.IDs)
+		snap.Providers = append(snap.Providers, res)
+	}
+	return snap
+}
+
+// Change operations.
+const (
+	OpAdd    = "add"
+	OpUpdate = "update"
+	OpRemove = "remove"
+)
+
+// Change is one models.yaml route that a sync adds, updates or removes.
+type Change struct {
+	Model string `json:"model"`
+	Op    string `json:"op"`
+	// Before and After are the route providers; Before is empty for adds and
+	// After is empty for removals.
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// Added returns the providers in After but not in Before.
+func (c Change) Added() []string { return subtract(c.After, c.Before) }
+
+// Removed returns the providers in Before but not in After.
+func (c Change) Removed() []string { return subtract(c.Before, c.After) }
+
+// PlanOptions tunes how a snapshot is merged into the current routes.
+type PlanOptions struct {
+	Rules *Rules
+	// AddOnly never removes a provider from a route (nor a route).
+	AddOnly bool
+	// OwnedBy is set on added routes.
+	OwnedBy string
+}
+
+// Plan is the result of merging a snapshot into the current routes.
+type Plan struct {
+	// Changes is sorted by model id.
+	Changes []Change
+	// Routes is the full route set after the changes.
+	Routes map[string]models.Route
+	// OwnedBy is copied from PlanOptions for Apply.
+	OwnedBy string
+}
+
+// NewPlan merges snap into current. Providers that answered on every key are
+// authoritative: they are added to the routes of the ids they reported and
+// removed from the others (unless AddOnly). Providers with failed keys only add.
+// Routes that lose their last provider are removed; providers that were not
+// queried are left untouched.
+func NewPlan(current map[string]models.Route, snap Snapshot, opts PlanOptions) *Plan {
+	want := map[string]map[string]struct{}{} // model -> providers
+	authoritative := map[string]struct{}{}
+	for _, pr := range snap.Providers {
+		if pr.Complete() {
+			authoritative[pr.Provider] = struct{}{}
+		}
+		for _, upstreamID := range pr.IDs {
+			id, ok := opts.Rules.Route(pr.Provider, upstreamID)
+			if !ok {
+				continue
+			}
+			if want[id] == nil {
+				want[id] = map[string]struct{}{}
+			}
+			want[id][pr.Provider] = struct{}{}
+		}
+	}
+
+	plan := &Plan{Routes: map[string]models.Route{}, OwnedBy: strings.TrimSpace(opts.OwnedBy)}
+	for id, rt := range current {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		before := normalizeProviders(rt.Providers)
+		after := make([]string, 0, len(before))
+		for _, p := range before {
+			_, managed := authoritative[p]
+			_, listed := want[id][p]
+			if listed || !managed || opts.AddOnly {
+				after = append(after, p)
+			}
+		}
+		after = append(after, sortedMissing(want[id], after)...)
+		rt.Providers = after
+		switch {
+		case len(after) == 0 && len(before) > 0:
+			plan.Changes = append(plan.Changes, Change{Model: id, Op: OpRemove, Before: before})
+			continue
+		case !slices.Equal(before, after):
+			plan.Changes = append(plan.Changes, Change{Model: id, Op: OpUpdate, Before: before, After: after})
+		}
+		plan.Routes[id] = rt
+	}
+	for id, provs := range want {
+		if _, ok := plan.Routes[id]; ok {
+			continue
+		}
+		if _, removed := current[id]; removed {
+			continue
+		}
+		after := sortedMissing(provs, nil)
+		plan.Routes[id] = models.Route{Providers: after, Strategy: models.StrategyRoundRobin, OwnedBy: plan.OwnedBy}
+		plan.Changes = append(plan.Changes, Change{Model: id, Op: OpAdd, After: after})
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool { return plan.Changes[i].Model < plan.Changes[j].Model })
+	return plan
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return p == nil || len(p.Changes) == 0
+}
+
+// Counts returns the number of added, updated and removed routes.
+func (p *Plan) Counts() (added, updated, removed int) {
+	if p == nil {
+		return 0, 0, 0
+	}
+	for _, c := range p.Changes {
+		switch c.Op {
+		case OpAdd:
+			added++
+		case OpUpdate:
+			updated++
+		case OpRemove:
+			removed++
+		}
+	}
+	return added, updated, removed
+}
+
+func normalizeProviders(in []string) []string {
+	out := make([]string, 0, len(in))
+	seen := map[string]struct{}{}
+	for _, p := range in {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if _, ok := seen[p]; ok || p == "" {
+			continue
+		}
+		seen[p] = struct{}{}
+		out = append(out, p)
+	}
+	return out
+}
+
+// sortedMissing returns the members of set not in have, sorted.
+func sortedMissing(set map[string]struct{}, have []string) []string {
+	var out []string
+	for p := range set {
+		if !slices.Contains(have, p) {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func subtract(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package modelsync
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+)
+
+func TestRulesRoute(t *testing.T) {
+	r, err := Compile(RulesConfig{
+		Allow: []string{`^(gpt|gemini)-`},
+		Deny:  []string{`-audio-`},
+		Aliases: []Alias{
+			{Provider: "gemini", Match: `^models/(.+)$`, Replace: "$1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	cases := []struct {
+		provider, in, want string
+		ok                 bool
+	}{
+		{"openai", "gpt-4o", "gpt-4o", true},
+		{"openai", "gpt-4o-audio-preview", "", false},
+		{"openai", "dall-e-3", "", false},
+		{"gemini", "models/gemini-2.5-flash", "gemini-2.5-flash", true},
+		{"vertex", "models/gemini-2.5-flash", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := r.Route(tc.provider, tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("Route(%q,%q)=%q,%v want %q,%v", tc.provider, tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	if _, err := Compile(RulesConfig{Deny: []string{"("}}); err == nil {
+		t.Fatalf("expected invalid deny pattern error")
+	}
+	if _, err := Compile(RulesConfig{Aliases: []Alias{{Match: "x"}}}); err == nil {
+		t.Fatalf("expected empty replace error")
+	}
+}
+
+func TestCollectUnionsKeysAndRecordsErrors(t *testing.T) {
+	keys := map[string][]keystore.Key{
+		"openai":    {{Name: "k1", Value: "a"}, {Name: "k2", Value: "b"}},
+		"anthropic": {{Name: "k1", Value: "c"}},
+	}
+	fetch := func(_ context.Context, provider string, k keystore.Key) ([]string, error) {
+		switch k.Value {
+		case "a":
+			return []string{"gpt-4o", "gpt-4.1"}, nil
+		case "b":
+			return []string{"gpt-4o", "o3"}, nil
+		}
+		return nil, errors.New("status=401")
+	}
+	snap := Collect(context.Background(), []string{"openai", "anthropic", "gemini"}, func(p string) []keystore.Key { return keys[p] }, fetch)
+	if len(snap.Providers) != 3 {
+		t.Fatalf("providers=%+v", snap.Providers)
+	}
+	openai, anthropic, gemini := snap.Providers[0], snap.Providers[1], snap.Providers[2]
+	if !reflect.DeepEqual(openai.IDs, []string{"gpt-4.1", "gpt-4o", "o3"}) || !openai.Complete() {
+		t.Fatalf("openai=%+v", openai)
+	}
+	if anthropic.Complete() || !strings.Contains(anthropic.Err().Error(), "key k1: status=401") {
+		t.Fatalf("anthropic=%+v", anthropic)
+	}
+	if gemini.Keys != 0 || gemini.Complete() || gemini.Err() == nil {
+		t.Fatalf("gemini=%+v", gemini)
+	}
+}
+
+func TestNewPlanMergesAuthoritativeProviders(t *testing.T) {
+	current := map[string]models.Route{
+		"gpt-4o":       {Providers: []string{"openai", "r9s"}, Strategy: models.StrategyRoundRobin, OwnedBy: "team"},
+		"gpt-4-legacy": {Providers: []string{"openai"}},
+		"claude-3":     {Providers: []string{"anthropic"}},
+		"o3":           {Providers: []string{"r9s"}},
+	}
+	snap := Snapshot{Providers: []ProviderResult{
+		{Provider: "openai", IDs: []string{"gpt-4o", "o3", "gpt-5"}, Keys: 1},
+		// Failed provider: only adds.
+		{Provider: "anthropic", IDs: []string{"claude-4"}, Keys: 2, Errors: []error{errors.New("boom")}},
+	}}
+
+	plan := NewPlan(current, snap, PlanOptions{OwnedBy: "sync"})
+	want := []Change{
+		{Model: "claude-4", Op: OpAdd, After: []string{"anthropic"}},
+		{Model: "gpt-4-legacy", Op: OpRemove, Before: []string{"openai"}},
+		{Model: "gpt-5", Op: OpAdd, After: []string{"openai"}},
+		{Model: "o3", Op: OpUpdate, Before: []string{"r9s"}, After: []string{"r9s", "openai"}},
+	}
+	if !reflect.DeepEqual(plan.Changes, want) {
+		t.Fatalf("changes=%+v", plan.Changes)
+	}
+	if _, ok := plan.Routes["gpt-4-legacy"]; ok {
+		t.Fatalf("removed route still present")
+	}
+	if got := plan.Routes["gpt-4o"]; got.OwnedBy != "team" || !reflect.DeepEqual(got.Providers, []string{"openai", "r9s"}) {
+		t.Fatalf("gpt-4o=%+v", got)
+	}
+	if got := plan.Routes["gpt-5"]; got.OwnedBy != "sync" {
+		t.Fatalf("gpt-5=%+v", got)
+	}
+	if added, updated, removed := plan.Counts(); added != 2 || updated != 1 || removed != 1 {
+		t.Fatalf("counts=%d/%d/%d", added, updated, removed)
+	}
+
+	addOnly := NewPlan(current, snap, PlanOptions{AddOnly: true})
+	for _, c := range addOnly.Changes {
+		if c.Op == OpRemove || len(c.Removed()) > 0 {
+			t.Fatalf("add-only plan removes: %+v", c)
+		}
+	}
+}
+
+func TestApplyPreservesCommentsAndOrder(t *testing.T) {
+	raw := []byte(`# routing table
+models:
+  # manual route
+  gpt-4o:
+    providers:
+      - openai
+    strategy: round_robin
+    owned_by: team
+  gpt-4-legacy:
+    providers: [openai]
+  o3:
+    providers:
+      - r9s
+`)
+	plan := &Plan{Changes: []Change{
+		{Model: "gpt-4-legacy", Op: OpRemove, Before: []string{"openai"}},
+		{Model: "gpt-5", Op: OpAdd, After: []string{"openai"}},
+		{Model: "o3", Op: OpUpdate, Before: []string{"r9s"}, After: []string{"r9s", "openai"}},
+	}, OwnedBy: "sync"}
+
+	out, err := Apply(raw, plan)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	s := string(out)
+	for _, want := range []string{"# routing table", "# manual route", "owned_by: team"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("missing %q in:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "gpt-4-legacy") {
+		t.Fatalf("removed route kept:\n%s", s)
+	}
+	if strings.Index(s, "gpt-4o:") > strings.Index(s, "o3:") || strings.Index(s, "o3:") > strings.Index(s, "gpt-5:") {
+		t.Fatalf("unexpected order:\n%s", s)
+	}
+
+	routes, err := ParseRoutes(out)
+	if err != nil {
+		t.Fatalf("ParseRoutes: %v", err)
+	}
+	if got := routes["o3"].Providers; !reflect.DeepEqual(got, []string{"r9s", "openai"}) {
+		t.Fatalf("o3 providers=%v", got)
+	}
+	if got := routes["gpt-5"]; got.OwnedBy != "sync" || got.Strategy != models.StrategyRoundRobin {
+		t.Fatalf("gpt-5=%+v", got)
+	}
+
+	fresh, err := Apply(nil, &Plan{Changes: []Change{{Model: "m1", Op: OpAdd, After: []string{"p1"}}}})
+	if err != nil {
+		t.Fatalf("Apply(nil): %v", err)
+	}
+	if routes, err := ParseRoutes(fresh); err != nil || len(routes["m1"].Providers) != 1 {
+		t.Fatalf("fresh=%s err=%v", fresh, err)
+	}
+}
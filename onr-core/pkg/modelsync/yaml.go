@@ -0,0 +1,135 @@
+package modelsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseRoutes returns the routes of the models.yaml content raw.
+func ParseRoutes(raw []byte) (map[string]models.Route, error) {
+	var f models.File
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse models yaml: %w", err)
+	}
+	if f.Models == nil {
+		f.Models = map[string]models.Route{}
+	}
+	return f.Models, nil
+}
+
+// Apply rewrites the models.yaml content raw with the plan's changes. It edits
+// the YAML tree in place so comments, key order and untouched routes survive;
+// added routes are appended in model id order. Empty raw starts a new file.
+func Apply(raw []byte, plan *Plan) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("parse models yaml: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("models yaml root is not a mapping")
+	}
+	root := doc.Content[0]
+	mm := mappingGet(root, "models")
+	if mm == nil || (mm.Kind == yaml.ScalarNode && mm.Tag == "!!null") {
+		mm = &yaml.Node{Kind: yaml.MappingNode}
+		mappingSet(root, "models", mm)
+	}
+	if mm.Kind != yaml.MappingNode {
+		return nil, errors.New("models is not a mapping")
+	}
+
+	if plan != nil {
+		for _, c := range plan.Changes {
+			if err := applyChange(mm, c, plan.OwnedBy); err != nil {
+				return nil, fmt.Errorf("model %s: %w", c.Model, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		_ = enc.Close()
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func applyChange(mm *yaml.Node, c Change, ownedBy string) error {
+	switch c.Op {
+	case OpRemove:
+		for i := 0; i+1 < len(mm.Content); i += 2 {
+			if strings.TrimSpace(mm.Content[i].Value) == c.Model {
+				mm.Content = append(mm.Content[:i], mm.Content[i+2:]...)
+				return nil
+			}
+		}
+		return nil
+	case OpUpdate:
+		rt := mappingGet(mm, c.Model)
+		if rt == nil || rt.Kind != yaml.MappingNode {
+			return errors.New("route is not a mapping")
+		}
+		mappingSet(rt, "providers", providersNode(c.After))
+		return nil
+	case OpAdd:
+		rt := &yaml.Node{Kind: yaml.MappingNode}
+		mappingSet(rt, "providers", providersNode(c.After))
+		mappingSet(rt, "strategy", strNode(string(models.StrategyRoundRobin)))
+		if ownedBy != "" {
+			mappingSet(rt, "owned_by", strNode(ownedBy))
+		}
+		mappingSet(mm, c.Model, rt)
+		return nil
+	}
+	return fmt.Errorf("unknown change op %q", c.Op)
+}
+
+func providersNode(providers []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, p := range providers {
+		seq.Content = append(seq.Content, strNode(p))
+	}
+	return seq
+}
+
+func strNode(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+func mappingGet(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.TrimSpace(m.Content[i].Value) == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingSet(m *yaml.Node, key string, val *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.TrimSpace(m.Content[i].Value) == key {
+			// Keep comments attached to the old value.
+			val.HeadComment = m.Content[i+1].HeadComment
+			val.LineComment = m.Content[i+1].LineComment
+			val.FootComment = m.Content[i+1].FootComment
+			m.Content[i+1] = val
+			return
+		}
+	}
+	m.Content = append(m.Content, strNode(key), val)
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
 )
 
 const (
@@ -37,6 +38,15 @@ type Token struct {
 	ExpiresAt   time.Time
 }
 
+// TokenStatus describes the cached token and the last refresh attempt of one cache key.
+type TokenStatus struct {
+	Cached        bool
+	ExpiresAt     time.Time
+	LastRefreshAt time.Time
+	LastError     string
+	LastErrorAt   time.Time
+}
+
 type ServiceAccountCredentialInfo struct {
 	ProjectID   string
 	ClientEmail string
@@ -75,6 +85,7 @@ type Client struct {
 
 	mu       sync.Mutex
 	cache    map[string]Token
+	status   map[string]TokenStatus
 	inFlight map[string]*flight
 }
 
@@ -94,6 +105,7 @@ func New(httpClient *http.Client, persistEnabled bool, persistDir string) *Clien
 		persistEnabled: persistEnabled,
 		persistDir:     strings.TrimSpace(persistDir),
 		cache:          map[string]Token{},
+		status:         map[string]TokenStatus{},
 		inFlight:       map[string]*flight{},
 	}
 }
@@ -108,6 +120,42 @@ func (c *Client) Invalidate(cacheKey string) {
 	c.mu.Unlock()
 }
 
+// Status reports the token state of cacheKey. ok is false when the key was
+// never requested through this client.
+func (c *Client) Status(cacheKey string) (TokenStatus, bool) {
+	key := strings.TrimSpace(cacheKey)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.status[key]
+	if !ok {
+		return TokenStatus{}, false
+	}
+	if tok, cached := c.cache[key]; cached && strings.TrimSpace(tok.AccessToken) != "" {
+		st.Cached = true
+		st.ExpiresAt = tok.ExpiresAt
+	} else {
+		st.Cached = false
+	}
+	return st, true
+}
+
+func (c *Client) recordStatus(cacheKey string, tok Token, err error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.status[cacheKey]
+	if err != nil {
+		st.LastError = err.Error()
+		st.LastErrorAt = now
+	} else {
+		st.ExpiresAt = tok.ExpiresAt
+		st.LastRefreshAt = now
+		st.LastError = ""
+		st.LastErrorAt = time.Time{}
+	}
+	c.status[cacheKey] = st
+}
+
 func (c *Client) GetToken(ctx context.Context, in AcquireInput) (Token, error) {
 	key := strings.TrimSpace(in.CacheKey)
 	if key == "" {
@@ -137,6 +185,7 @@ func (c *Client) GetToken(ctx context.Context, in AcquireInput) (Token, error) {
 			c.mu.Lock()
 			c.cache[key] = tok
 			c.mu.Unlock()
+			c.recordStatus(key, tok, nil)
 			return tok, nil
 		}
 	}
@@ -149,6 +198,7 @@ func (c *Client) GetToken(ctx context.Context, in AcquireInput) (Token, error) {
 	defer c.endFlight(key, f)
 
 	token, err := c.requestToken(ctx, in)
+	c.recordStatus(key, token, err)
 	if err != nil {
 		f.err = err
 		return Token{}, err
@@ -503,6 +553,13 @@ func (c *Client) loadPersistedValid(cacheKey string, skew time.Duration) (Token,
 	if strings.TrimSpace(p.AccessToken) == "" || p.ExpiresAt <= 0 {
 		return Token{}, false
 	}
+	if keystore.IsEncrypted(p.AccessToken) {
+		plain, err := keystore.Decrypt(p.AccessToken)
+		if err != nil {
+			return Token{}, false
+		}
+		p.AccessToken = plain
+	}
 	tok := Token{
 		AccessToken: p.AccessToken,
 		TokenType:   p.TokenType,
@@ -527,6 +584,15 @@ func (c *Client) savePersisted(cacheKey string, tok Token) error {
 		TokenType:   tok.TokenType,
 		ExpiresAt:   tok.ExpiresAt.Unix(),
 	}
+	// Persisted tokens are encrypted at rest when a master key is configured;
+	// `onr-admin crypto rotate` re-encrypts them together with keys.yaml.
+	if keystore.HasMasterKey() {
+		enc, err := keystore.Encrypt(p.AccessToken)
+		if err != nil {
+			return err
+		}
+		p.AccessToken = enc
+	}
 	raw, err := json.Marshal(p)
 	if err != nil {
 		return err
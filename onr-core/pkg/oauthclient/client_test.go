@@ -12,6 +12,7 @@ import (
 	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -82,6 +83,50 @@ func TestClient_GetToken_CacheAndPersist(t *testing.T) {
 	}
 }
 
+func TestClient_PersistEncryptedWithMasterKey(t *testing.T) {
+	t.Setenv("ONR_MASTER_KEY", "12345678901234567890123456789012")
+
+	var tokenCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "tok-secret",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := filepath.Join(t.TempDir(), "oauth")
+	in := AcquireInput{
+		CacheKey:      "k1",
+		TokenURL:      srv.URL,
+		TokenPath:     "$.access_token",
+		ExpiresInPath: "$.expires_in",
+	}
+	if _, err := New(srv.Client(), true, dir).GetToken(context.Background(), in); err != nil {
+		t.Fatalf("get token err=%v", err)
+	}
+
+	c := New(srv.Client(), true, dir)
+	raw, err := os.ReadFile(c.persistPath("k1"))
+	if err != nil {
+		t.Fatalf("read persisted: %v", err)
+	}
+	if strings.Contains(string(raw), "tok-secret") || !strings.Contains(string(raw), "ENC[v2:aesgcm:") {
+		t.Fatalf("persisted token should be encrypted: %s", raw)
+	}
+	tok, err := c.GetToken(context.Background(), in)
+	if err != nil {
+		t.Fatalf("get persisted token err=%v", err)
+	}
+	if tok.AccessToken != "tok-secret" {
+		t.Fatalf("access token=%q", tok.AccessToken)
+	}
+	if got := tokenCalls.Load(); got != 1 {
+		t.Fatalf("token endpoint calls=%d want=1", got)
+	}
+}
+
 func TestClient_Invalidate(t *testing.T) {
 	t.Parallel()
 
@@ -125,6 +170,50 @@ func TestClient_Invalidate(t *testing.T) {
 	}
 }
 
+func TestClient_StatusTracksLastRefreshError(t *testing.T) {
+	t.Parallel()
+
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "tok",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.Client(), false, "")
+	if _, ok := c.Status("k3"); ok {
+		t.Fatalf("status should be absent before first request")
+	}
+	in := AcquireInput{CacheKey: "k3", TokenURL: srv.URL}
+	if _, err := c.GetToken(context.Background(), in); err == nil {
+		t.Fatalf("expected token error")
+	}
+	st, ok := c.Status("k3")
+	if !ok || st.Cached || !strings.Contains(st.LastError, "status=400") || st.LastErrorAt.IsZero() {
+		t.Fatalf("status after failure=%+v ok=%v", st, ok)
+	}
+
+	fail.Store(false)
+	if _, err := c.GetToken(context.Background(), in); err != nil {
+		t.Fatalf("get token err=%v", err)
+	}
+	st, _ = c.Status("k3")
+	if !st.Cached || st.LastError != "" || st.LastRefreshAt.IsZero() || time.Until(st.ExpiresAt) < 59*time.Minute {
+		t.Fatalf("status after refresh=%+v", st)
+	}
+	c.Invalidate("k3")
+	if st, _ = c.Status("k3"); st.Cached {
+		t.Fatalf("status after invalidate=%+v", st)
+	}
+}
+
 func TestParseServiceAccountCredential(t *testing.T) {
 	t.Parallel()
 
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
 )
 
+var contextTierKeyRe = regexp.MustCompile(`^context_over_(\d+)k$`)
+
 const (
 	DefaultCatalogURL = "https://models.dev/api.json"
 	defaultUserAgent  = "open-next-router-onr-admin/pricing-sync"
@@ -29,9 +33,10 @@ type Provider struct {
 }
 
 type Model struct {
-	ID   string
-	Name string
-	Cost map[string]float64
+	ID    string
+	Name  string
+	Cost  map[string]float64
+	Tiers []PriceTier
 }
 
 type FetchResult struct {
@@ -44,6 +49,7 @@ type ModelPrice struct {
 	Provider string
 	Model    string
 	Cost     map[string]float64
+	Tiers    []PriceTier
 }
 
 type apiProvider struct {
@@ -119,9 +125,10 @@ func FetchCatalog(ctx context.Context, client *http.Client, url string) (*FetchR
 				continue
 			}
 			models[modelID] = Model{
-				ID:   modelID,
-				Name: strings.TrimSpace(m.Name),
-				Cost: normalizeCostMap(m.Cost),
+				ID:    modelID,
+				Name:  strings.TrimSpace(m.Name),
+				Cost:  normalizeCostMap(m.Cost),
+				Tiers: extractContextTiers(m.Cost),
 			}
 		}
 		out.Providers[id] = Provider{
@@ -199,6 +206,7 @@ func (c Catalog) ExtractPrices(providerInput string, modelInputs []string) ([]Mo
 			Provider: providerID,
 			Model:    modelID,
 			Cost:     cloneFloatMap(m.Cost),
+			Tiers:    clonePriceTiers(m.Tiers),
 		})
 	}
 	return out, providerID, nil
@@ -223,6 +231,33 @@ func normalizeCostMap(in map[string]any) map[string]float64 {
 	return out
 }
 
+// extractContextTiers converts models.dev `context_over_<N>k` cost objects into
+// price tiers, sorted by threshold.
+func extractContextTiers(in map[string]any) []PriceTier {
+	var out []PriceTier
+	for k, v := range in {
+		m := contextTierKeyRe.FindStringSubmatch(strings.TrimSpace(k))
+		if m == nil {
+			continue
+		}
+		nested, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		thousands, err := strconv.Atoi(m[1])
+		if err != nil || thousands <= 0 {
+			continue
+		}
+		cost := normalizeCostMap(nested)
+		if len(cost) == 0 {
+			continue
+		}
+		out = append(out, PriceTier{AboveInputTokens: thousands * 1000, Cost: cost})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AboveInputTokens < out[j].AboveInputTokens })
+	return out
+}
+
 func cloneFloatMap(in map[string]float64) map[string]float64 {
 	out := make(map[string]float64, len(in))
 	for k, v := range in {
@@ -36,7 +36,7 @@ func TestFetchCatalogAndExtractPrices(t *testing.T) {
     "models": {
       "gemini-2.5-flash": {
         "id": "gemini-2.5-flash",
-        "cost": {"input": 0.3, "output": 2.5}
+        "cost": {"input": 0.3, "output": 2.5, "context_over_200k": {"input": 0.6, "output": 5}}
       }
     }
   }
@@ -79,6 +79,9 @@ func TestFetchCatalogAndExtractPrices(t *testing.T) {
 	if prices[0].Cost["output"] != 2.5 {
 		t.Fatalf("output cost=%v want=2.5", prices[0].Cost["output"])
 	}
+	if len(prices[0].Tiers) != 1 || prices[0].Tiers[0].AboveInputTokens != 200000 || prices[0].Tiers[0].Cost["input"] != 0.6 {
+		t.Fatalf("tiers=%+v want one 200k tier with input 0.6", prices[0].Tiers)
+	}
 }
 
 func TestFetchCatalog_NilClientFallsBackToDefault(t *testing.T) {
@@ -25,6 +25,17 @@ type PriceItem struct {
 	Model    string             `yaml:"model"`
 	Provider string             `yaml:"provider"`
 	Cost     map[string]float64 `yaml:"cost"`
+	// Tiers replace cost rates once a request's input tokens exceed a threshold.
+	Tiers []PriceTier `yaml:"tiers,omitempty"`
+	// Discounts scale all rates by service tier (e.g. batch: 0.5, flex: 0.5).
+	Discounts map[string]float64 `yaml:"discounts,omitempty"`
+}
+
+// PriceTier is a context-length tier: its cost rates override the base rates
+// when input tokens (including cached tokens) exceed AboveInputTokens.
+type PriceTier struct {
+	AboveInputTokens int                `yaml:"above_input_tokens"`
+	Cost             map[string]float64 `yaml:"cost"`
 }
 
 func BuildPriceFile(fetch *FetchResult, providerIDs []string, prices []ModelPrice) PriceFile {
@@ -49,6 +60,7 @@ func BuildPriceFile(fetch *FetchResult, providerIDs []string, prices []ModelPric
 			Model:    strings.TrimSpace(p.Model),
 			Provider: strings.TrimSpace(p.Provider),
 			Cost:     cloneFloatMap(p.Cost),
+			Tiers:    clonePriceTiers(p.Tiers),
 		})
 	}
 	sort.Slice(entries, func(i, j int) bool {
@@ -77,3 +89,14 @@ func BuildPriceFile(fetch *FetchResult, providerIDs []string, prices []ModelPric
 		Entries: entries,
 	}
 }
+
+func clonePriceTiers(in []PriceTier) []PriceTier {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]PriceTier, 0, len(in))
+	for _, t := range in {
+		out = append(out, PriceTier{AboveInputTokens: t.AboveInputTokens, Cost: cloneFloatMap(t.Cost)})
+	}
+	return out
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
@@ -14,16 +16,39 @@ import (
 const (
 	rateInput      = "input"
 	rateOutput     = "output"
+	rateReasoning  = "reasoning"
 	rateCacheRead  = "cache_read"
 	rateCacheWrite = "cache_write"
 )
 
+// modalityRates are optional per-modality token rates. Priced modality tokens
+// leave the base input/output rate, like reasoning tokens do.
+var modalityRates = []struct {
+	rate     string
+	usageKey string
+	output   bool
+}{
+	{rate: "input_audio", usageKey: "input_audio_tokens"},
+	{rate: "input_image", usageKey: "input_image_tokens"},
+	{rate: "input_video", usageKey: "input_video_tokens"},
+	{rate: "output_audio", usageKey: "output_audio_tokens", output: true},
+	{rate: "output_image", usageKey: "output_image_tokens", output: true},
+	{rate: "output_video", usageKey: "output_video_tokens", output: true},
+}
+
+const (
+	lineItemUnitToken = "token"
+	lineItemUnitUnit  = "unit"
+	rateUnitPerUnit   = "usd_per_unit"
+)
+
 var standardUsageCostKeyOrder = []string{
 	"input_tokens",
 	"output_tokens",
 	"total_tokens",
 	"cache_read_tokens",
 	"cache_write_tokens",
+	"reasoning_tokens",
 }
 
 var standardUsageCostKeys = newStringSet(standardUsageCostKeyOrder)
@@ -39,14 +64,28 @@ type ScopeOverride struct {
 	Models     map[string]ModelOverride `yaml:"models"`
 }
 
+// ModelOverride merges cost and discount keys into the base price; non-empty
+// tiers replace the base tiers. Cost keys set by an override without its own
+// tiers win over inherited tier rates, so a negotiated rate also applies above
+// the base tier thresholds.
 type ModelOverride struct {
-	Cost map[string]float64 `yaml:"cost"`
+	Cost      map[string]float64 `yaml:"cost"`
+	Tiers     []PriceTier        `yaml:"tiers"`
+	Discounts map[string]float64 `yaml:"discounts"`
+}
+
+type modelPrice struct {
+	cost      map[string]float64
+	tiers     []PriceTier
+	discounts map[string]float64
+	// pinned holds override cost keys that tier rates must not replace.
+	pinned map[string]bool
 }
 
 type Resolver struct {
 	unit string
 
-	base map[string]map[string]map[string]float64
+	base map[string]map[string]modelPrice
 
 	providerOverrides map[string]ScopeOverride
 	channelOverrides  map[string]map[string]ScopeOverride
@@ -62,23 +101,67 @@ type CostResult struct {
 	RateUnit string
 
 	Multiplier float64
-
-	InputTokens         int
-	OutputTokens        int
-	CacheReadTokens     int
-	CacheWriteTokens    int
-	BillableInputTokens int
+	// Tier is the AboveInputTokens threshold of the applied context tier, or 0.
+	Tier int
+	// ServiceTier and Discount describe the applied service-tier discount;
+	// Discount is 1 when none applies.
+	ServiceTier string
+	Discount    float64
+
+	InputTokens          int
+	OutputTokens         int
+	CacheReadTokens      int
+	CacheWriteTokens     int
+	ReasoningTokens      int
+	BillableInputTokens  int
+	BillableOutputTokens int
 
 	InputRate      float64
 	OutputRate     float64
+	ReasoningRate  float64
 	CacheReadRate  float64
 	CacheWriteRate float64
 
 	InputCost      float64
 	OutputCost     float64
+	ReasoningCost  float64
 	CacheReadCost  float64
 	CacheWriteCost float64
-	TotalCost      float64
+	// ModalityCost sums the per-modality token items (input_audio, ...).
+	ModalityCost float64
+	ExtraCost    float64
+	TotalCost    float64
+
+	LineItems CostLineItems
+}
+
+// ComputeOptions carries request attributes that affect pricing.
+type ComputeOptions struct {
+	// ServiceTier selects a discount from the price entry, e.g. "batch" or "flex".
+	ServiceTier string
+}
+
+// CostLineItem is one priced component of a request. Token items are priced
+// per RateUnit (per 1M tokens by default); other usage fields per unit.
+type CostLineItem struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Rate     float64 `json:"rate"`
+	RateUnit string  `json:"rate_unit"`
+	Cost     float64 `json:"cost"`
+}
+
+// CostLineItems renders compactly in text access logs as
+// name:quantity@rate=cost entries separated by ';'.
+type CostLineItems []CostLineItem
+
+func (items CostLineItems) String() string {
+	parts := make([]string, 0, len(items))
+	for _, it := range items {
+		parts = append(parts, it.Name+":"+formatFloat(it.Quantity)+"@"+formatFloat(it.Rate)+"="+formatFloat(it.Cost))
+	}
+	return strings.Join(parts, ";")
 }
 
 func LoadResolver(pricePath, overridesPath string) (*Resolver, error) {
@@ -101,7 +184,7 @@ func LoadResolver(pricePath, overridesPath string) (*Resolver, error) {
 		unit = defaultPriceUnit
 	}
 
-	base := map[string]map[string]map[string]float64{}
+	base := map[string]map[string]modelPrice{}
 	for _, entry := range priceDoc.Entries {
 		provider := strings.ToLower(strings.TrimSpace(entry.Provider))
 		model := strings.TrimSpace(entry.Model)
@@ -109,9 +192,13 @@ func LoadResolver(pricePath, overridesPath string) (*Resolver, error) {
 			continue
 		}
 		if _, ok := base[provider]; !ok {
-			base[provider] = map[string]map[string]float64{}
+			base[provider] = map[string]modelPrice{}
+		}
+		base[provider][model] = modelPrice{
+			cost:      cloneFloatMap(entry.Cost),
+			tiers:     clonePriceTiers(entry.Tiers),
+			discounts: normalizeDiscounts(entry.Discounts),
 		}
-		base[provider][model] = cloneFloatMap(entry.Cost)
 	}
 	if len(base) == 0 {
 		return nil, nil
@@ -139,6 +226,11 @@ func LoadResolver(pricePath, overridesPath string) (*Resolver, error) {
 
 // Compute requires a non-nil Resolver receiver.
 func (r *Resolver) Compute(provider, key, model string, usage map[string]any) (*CostResult, bool) {
+	return r.ComputeWithOptions(provider, key, model, usage, ComputeOptions{})
+}
+
+// ComputeWithOptions requires a non-nil Resolver receiver.
+func (r *Resolver) ComputeWithOptions(provider, key, model string, usage map[string]any, opts ComputeOptions) (*CostResult, bool) {
 	if usage == nil {
 		return nil, false
 	}
@@ -148,31 +240,58 @@ func (r *Resolver) Compute(provider, key, model string, usage map[string]any) (*
 	if provider == "" || model == "" {
 		return nil, false
 	}
-	effectiveRates := map[string]float64{}
+	price := modelPrice{cost: map[string]float64{}, discounts: map[string]float64{}}
 	multiplier := 1.0
 
 	if models, ok := r.base[provider]; ok {
-		if baseRates, ok := models[model]; ok {
-			effectiveRates = cloneFloatMap(baseRates)
+		if base, ok := models[model]; ok {
+			price = modelPrice{
+				cost:      cloneFloatMap(base.cost),
+				tiers:     base.tiers,
+				discounts: cloneFloatMap(base.discounts),
+			}
 		}
 	}
 
 	if ov, ok := r.providerOverrides[provider]; ok {
-		applyModelOverride(effectiveRates, model, ov)
+		applyModelOverride(&price, model, ov)
 		if ov.Multiplier != nil {
 			multiplier *= *ov.Multiplier
 		}
 	}
 	if channels, ok := r.channelOverrides[provider]; ok && key != "" {
 		if ov, ok := channels[key]; ok {
-			applyModelOverride(effectiveRates, model, ov)
+			applyModelOverride(&price, model, ov)
 			if ov.Multiplier != nil {
 				multiplier *= *ov.Multiplier
 			}
 		}
 	}
+
+	inputTokens := intFromAny(usage["input_tokens"])
+	outputTokens := intFromAny(usage["output_tokens"])
+	cacheReadTokens := intFromAny(usage["cache_read_tokens"])
+	cacheWriteTokens := intFromAny(usage["cache_write_tokens"])
+	reasoningTokens := intFromAny(usage["reasoning_tokens"])
+
+	effectiveRates := price.cost
+	tier := selectPriceTier(price.tiers, inputTokens)
+	if tier != nil {
+		for k, v := range tier.Cost {
+			if k = strings.TrimSpace(k); !price.pinned[k] {
+				effectiveRates[k] = v
+			}
+		}
+	}
+	serviceTier := strings.ToLower(strings.TrimSpace(opts.ServiceTier))
+	discount := 1.0
+	if d, ok := price.discounts[serviceTier]; ok && serviceTier != "" {
+		discount = d
+	} else {
+		serviceTier = ""
+	}
 	for k, v := range effectiveRates {
-		effectiveRates[k] = v * multiplier
+		effectiveRates[k] = v * multiplier * discount
 	}
 	if len(effectiveRates) == 0 {
 		return nil, false
@@ -180,6 +299,7 @@ func (r *Resolver) Compute(provider, key, model string, usage map[string]any) (*
 
 	inputRate := effectiveRates[rateInput]
 	outputRate := effectiveRates[rateOutput]
+	reasoningRate := effectiveRates[rateReasoning]
 	cacheReadRate := effectiveRates[rateCacheRead]
 	cacheWriteRate := effectiveRates[rateCacheWrite]
 	if cacheReadRate == 0 {
@@ -188,30 +308,88 @@ func (r *Resolver) Compute(provider, key, model string, usage map[string]any) (*
 	if cacheWriteRate == 0 {
 		cacheWriteRate = inputRate
 	}
-	extraCostTotal, hasExtraRate := computeExtraUsageCost(usage, effectiveRates)
-	if inputRate == 0 && outputRate == 0 && cacheReadRate == 0 && cacheWriteRate == 0 && !hasExtraRate {
+	extraItems := computeExtraUsageItems(usage, effectiveRates)
+	hasModalityRate := false
+	for _, m := range modalityRates {
+		if effectiveRates[m.rate] != 0 {
+			hasModalityRate = true
+			break
+		}
+	}
+	if inputRate == 0 && outputRate == 0 && reasoningRate == 0 && cacheReadRate == 0 && cacheWriteRate == 0 && !hasModalityRate && len(extraItems) == 0 {
 		return nil, false
 	}
 
-	inputTokens := intFromAny(usage["input_tokens"])
-	outputTokens := intFromAny(usage["output_tokens"])
-	cacheReadTokens := intFromAny(usage["cache_read_tokens"])
-	cacheWriteTokens := intFromAny(usage["cache_write_tokens"])
 	billableInput := inputTokens - cacheReadTokens - cacheWriteTokens
 	if billableInput < 0 {
 		billableInput = 0
 	}
+	// Reasoning tokens are part of output tokens; they move to their own rate
+	// only when the entry prices them separately.
+	billableOutput := outputTokens
+	if reasoningRate == 0 {
+		reasoningTokens = 0
+	} else {
+		reasoningTokens = min(reasoningTokens, outputTokens)
+		billableOutput -= reasoningTokens
+	}
+
+	// Modality tokens (audio/image/video) move to their own rate only when the
+	// entry prices them, taking from the side's remaining billable tokens.
+	type modalityTokens struct {
+		name   string
+		tokens int
+		rate   float64
+	}
+	var modalities []modalityTokens
+	for _, m := range modalityRates {
+		rate := effectiveRates[m.rate]
+		tokens := intFromAny(usage[m.usageKey])
+		if rate == 0 || tokens <= 0 {
+			continue
+		}
+		if m.output {
+			tokens = min(tokens, billableOutput)
+			billableOutput -= tokens
+		} else {
+			tokens = min(tokens, billableInput)
+			billableInput -= tokens
+		}
+		modalities = append(modalities, modalityTokens{name: m.rate, tokens: tokens, rate: rate})
+	}
 
-	inputCost := usdByRatePerMillion(billableInput, inputRate)
-	outputCost := usdByRatePerMillion(outputTokens, outputRate)
-	cacheReadCost := usdByRatePerMillion(cacheReadTokens, cacheReadRate)
-	cacheWriteCost := usdByRatePerMillion(cacheWriteTokens, cacheWriteRate)
-	total := inputCost + outputCost + cacheReadCost + cacheWriteCost + extraCostTotal
+	items := make(CostLineItems, 0, 5+len(modalities)+len(extraItems))
+	addTokens := func(name string, tokens int, rate float64) float64 {
+		cost := usdByRatePerMillion(tokens, rate)
+		if tokens > 0 && rate != 0 {
+			items = append(items, CostLineItem{Name: name, Quantity: float64(tokens), Unit: lineItemUnitToken, Rate: rate, RateUnit: r.unit, Cost: cost})
+		}
+		return cost
+	}
+	inputCost := addTokens(rateInput, billableInput, inputRate)
+	cacheReadCost := addTokens(rateCacheRead, cacheReadTokens, cacheReadRate)
+	cacheWriteCost := addTokens(rateCacheWrite, cacheWriteTokens, cacheWriteRate)
+	outputCost := addTokens(rateOutput, billableOutput, outputRate)
+	reasoningCost := addTokens(rateReasoning, reasoningTokens, reasoningRate)
+	modalityCost := 0.0
+	for _, m := range modalities {
+		modalityCost += addTokens(m.name, m.tokens, m.rate)
+	}
+	extraCost := 0.0
+	for _, it := range extraItems {
+		extraCost += it.Cost
+	}
+	items = append(items, extraItems...)
+	total := inputCost + outputCost + reasoningCost + cacheReadCost + cacheWriteCost + modalityCost + extraCost
 
 	channel := provider
 	if key != "" {
 		channel = provider + "/" + key
 	}
+	tierThreshold := 0
+	if tier != nil {
+		tierThreshold = tier.AboveInputTokens
+	}
 
 	return &CostResult{
 		Provider: provider,
@@ -221,33 +399,58 @@ func (r *Resolver) Compute(provider, key, model string, usage map[string]any) (*
 		Unit:     "usd",
 		RateUnit: r.unit,
 
-		Multiplier: multiplier,
+		Multiplier:  multiplier,
+		Tier:        tierThreshold,
+		ServiceTier: serviceTier,
+		Discount:    discount,
 
-		InputTokens:         inputTokens,
-		OutputTokens:        outputTokens,
-		CacheReadTokens:     cacheReadTokens,
-		CacheWriteTokens:    cacheWriteTokens,
-		BillableInputTokens: billableInput,
+		InputTokens:          inputTokens,
+		OutputTokens:         outputTokens,
+		CacheReadTokens:      cacheReadTokens,
+		CacheWriteTokens:     cacheWriteTokens,
+		ReasoningTokens:      reasoningTokens,
+		BillableInputTokens:  billableInput,
+		BillableOutputTokens: billableOutput,
 
 		InputRate:      inputRate,
 		OutputRate:     outputRate,
+		ReasoningRate:  reasoningRate,
 		CacheReadRate:  cacheReadRate,
 		CacheWriteRate: cacheWriteRate,
 
 		InputCost:      inputCost,
 		OutputCost:     outputCost,
+		ReasoningCost:  reasoningCost,
 		CacheReadCost:  cacheReadCost,
 		CacheWriteCost: cacheWriteCost,
+		ModalityCost:   modalityCost,
+		ExtraCost:      extraCost,
 		TotalCost:      total,
+
+		LineItems: items,
 	}, true
 }
 
-func computeExtraUsageCost(usage map[string]any, rates map[string]float64) (float64, bool) {
+// selectPriceTier returns the tier with the highest threshold below
+// inputTokens, or nil.
+func selectPriceTier(tiers []PriceTier, inputTokens int) *PriceTier {
+	var out *PriceTier
+	for i := range tiers {
+		t := &tiers[i]
+		if inputTokens > t.AboveInputTokens && (out == nil || t.AboveInputTokens > out.AboveInputTokens) {
+			out = t
+		}
+	}
+	return out
+}
+
+// computeExtraUsageItems prices non-standard usage fields (image counts, audio
+// seconds, tool calls) per unit, sorted by field name.
+func computeExtraUsageItems(usage map[string]any, rates map[string]float64) CostLineItems {
 	if len(usage) == 0 || len(rates) == 0 {
-		return 0, false
+		return nil
 	}
-	total := 0.0
-	var matched bool
+	var out CostLineItems
 	for key, value := range usage {
 		name := strings.TrimSpace(key)
 		if name == "" {
@@ -264,10 +467,10 @@ func computeExtraUsageCost(usage map[string]any, rates map[string]float64) (floa
 		if !ok || quantity <= 0 {
 			continue
 		}
-		total += quantity * rate
-		matched = true
+		out = append(out, CostLineItem{Name: name, Quantity: quantity, Unit: lineItemUnitUnit, Rate: rate, RateUnit: rateUnitPerUnit, Cost: quantity * rate})
 	}
-	return total, matched
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
 }
 
 func loadPriceFile(path string) (*PriceFile, error) {
@@ -342,7 +545,11 @@ func normalizeScopeOverride(in ScopeOverride) ScopeOverride {
 		if m == "" {
 			continue
 		}
-		models[m] = ModelOverride{Cost: cloneFloatMap(ov.Cost)}
+		models[m] = ModelOverride{
+			Cost:      cloneFloatMap(ov.Cost),
+			Tiers:     clonePriceTiers(ov.Tiers),
+			Discounts: normalizeDiscounts(ov.Discounts),
+		}
 	}
 	return ScopeOverride{
 		Multiplier: in.Multiplier,
@@ -350,16 +557,41 @@ func normalizeScopeOverride(in ScopeOverride) ScopeOverride {
 	}
 }
 
-func applyModelOverride(rates map[string]float64, model string, scope ScopeOverride) {
-	if rates == nil || len(scope.Models) == 0 {
+func normalizeDiscounts(in map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(in))
+	for k, v := range in {
+		if tier := strings.ToLower(strings.TrimSpace(k)); tier != "" {
+			out[tier] = v
+		}
+	}
+	return out
+}
+
+func applyModelOverride(price *modelPrice, model string, scope ScopeOverride) {
+	if price == nil || len(scope.Models) == 0 {
 		return
 	}
 	ov, ok := scope.Models[strings.TrimSpace(model)]
 	if !ok {
 		return
 	}
+	if len(ov.Tiers) > 0 {
+		// The override's own tiers apply on top of its cost, as in a base entry.
+		price.tiers = ov.Tiers
+		price.pinned = nil
+	}
 	for k, v := range ov.Cost {
-		rates[strings.TrimSpace(k)] = v
+		k = strings.TrimSpace(k)
+		price.cost[k] = v
+		if len(ov.Tiers) == 0 {
+			if price.pinned == nil {
+				price.pinned = map[string]bool{}
+			}
+			price.pinned[k] = true
+		}
+	}
+	for k, v := range ov.Discounts {
+		price.discounts[k] = v
 	}
 }
 
@@ -387,6 +619,10 @@ func intFromAny(v any) int {
 	}
 }
 
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 func newStringSet(keys []string) map[string]struct{} {
 	out := make(map[string]struct{}, len(keys))
 	for _, key := range keys {
@@ -1,6 +1,7 @@
 package pricing
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
@@ -176,3 +177,248 @@ providers:
 		t.Fatalf("model=%q want=%q", got, want)
 	}
 }
+
+func TestResolverComputeChannelOverrideBeatsBaseTier(t *testing.T) {
+	dir := t.TempDir()
+	pricePath := filepath.Join(dir, "price.yaml")
+	overridesPath := filepath.Join(dir, "price_overrides.yaml")
+	priceYAML := `
+version: v1
+unit: usd_per_1m_tokens
+entries:
+  - provider: google
+    model: gemini-2.5-pro
+    cost:
+      input: 1.25
+      output: 10
+    tiers:
+      - above_input_tokens: 200000
+        cost:
+          input: 2.5
+          output: 15
+`
+	overridesYAML := `
+version: v1
+channels:
+  "google/key1":
+    models:
+      gemini-2.5-pro:
+        cost:
+          input: 0.5
+  "google/key2":
+    models:
+      gemini-2.5-pro:
+        cost:
+          input: 0.5
+        tiers:
+          - above_input_tokens: 100000
+            cost:
+              input: 0.75
+`
+	if err := os.WriteFile(pricePath, []byte(priceYAML), 0o600); err != nil {
+		t.Fatalf("write price: %v", err)
+	}
+	if err := os.WriteFile(overridesPath, []byte(overridesYAML), 0o600); err != nil {
+		t.Fatalf("write overrides: %v", err)
+	}
+	r, err := LoadResolver(pricePath, overridesPath)
+	if err != nil || r == nil {
+		t.Fatalf("LoadResolver: r=%v err=%v", r, err)
+	}
+
+	usage := map[string]any{"input_tokens": 300000, "output_tokens": 1000}
+	cases := []struct {
+		key               string
+		tier              int
+		input, outputRate float64
+	}{
+		// The negotiated input rate holds above the base tier; output still follows the tier.
+		{key: "key1", tier: 200000, input: 0.5, outputRate: 15},
+		// An override with its own tiers prices like a base entry.
+		{key: "key2", tier: 100000, input: 0.75, outputRate: 10},
+		{key: "", tier: 200000, input: 2.5, outputRate: 15},
+	}
+	for _, tc := range cases {
+		c, ok := r.Compute("google", tc.key, "gemini-2.5-pro", usage)
+		if !ok || c == nil {
+			t.Fatalf("Compute(%q) failed", tc.key)
+		}
+		if c.Tier != tc.tier || c.InputRate != tc.input || c.OutputRate != tc.outputRate {
+			t.Fatalf("key=%q tier=%d input_rate=%v output_rate=%v, want %d/%v/%v", tc.key, c.Tier, c.InputRate, c.OutputRate, tc.tier, tc.input, tc.outputRate)
+		}
+	}
+}
+
+func TestResolverComputeTierReasoningAndLineItems(t *testing.T) {
+	dir := t.TempDir()
+	pricePath := filepath.Join(dir, "price.yaml")
+	priceYAML := `
+version: v1
+unit: usd_per_1m_tokens
+entries:
+  - provider: google
+    model: gemini-2.5-pro
+    cost:
+      input: 1
+      output: 10
+      reasoning: 20
+      server_tool_web_search_calls: 0.03
+    tiers:
+      - above_input_tokens: 1000
+        cost:
+          input: 2
+      - above_input_tokens: 100000
+        cost:
+          input: 4
+`
+	if err := os.WriteFile(pricePath, []byte(priceYAML), 0o600); err != nil {
+		t.Fatalf("write price: %v", err)
+	}
+	r, err := LoadResolver(pricePath, "")
+	if err != nil || r == nil {
+		t.Fatalf("LoadResolver: r=%v err=%v", r, err)
+	}
+
+	usage := map[string]any{
+		"input_tokens":                 2000,
+		"output_tokens":                500,
+		"reasoning_tokens":             300,
+		"server_tool_web_search_calls": 2,
+	}
+	c, ok := r.Compute("google", "", "gemini-2.5-pro", usage)
+	if !ok || c == nil {
+		t.Fatalf("Compute failed")
+	}
+	if c.Tier != 1000 || c.InputRate != 2 || c.OutputRate != 10 {
+		t.Fatalf("tier=%d input_rate=%v output_rate=%v want 1000/2/10", c.Tier, c.InputRate, c.OutputRate)
+	}
+	if c.BillableOutputTokens != 200 || c.ReasoningTokens != 300 {
+		t.Fatalf("billable_output=%d reasoning=%d want 200/300", c.BillableOutputTokens, c.ReasoningTokens)
+	}
+	// 2000*2/1M + 200*10/1M + 300*20/1M + 2*0.03
+	if want := 0.004 + 0.002 + 0.006 + 0.06; math.Abs(c.TotalCost-want) > 1e-9 {
+		t.Fatalf("total=%v want=%v", c.TotalCost, want)
+	}
+	names := make([]string, 0, len(c.LineItems))
+	sum := 0.0
+	for _, it := range c.LineItems {
+		names = append(names, it.Name)
+		sum += it.Cost
+	}
+	if got, want := fmt.Sprint(names), "[input output reasoning server_tool_web_search_calls]"; got != want {
+		t.Fatalf("line items=%s want=%s", got, want)
+	}
+	if math.Abs(sum-c.TotalCost) > 1e-12 {
+		t.Fatalf("line items sum=%v total=%v", sum, c.TotalCost)
+	}
+	if got, want := c.LineItems.String(), "input:2000@2=0.004;output:200@10=0.002;reasoning:300@20=0.006;server_tool_web_search_calls:2@0.03=0.06"; got != want {
+		t.Fatalf("line items string=%q want=%q", got, want)
+	}
+
+	small, ok := r.Compute("google", "", "gemini-2.5-pro", map[string]any{"input_tokens": 1000})
+	if !ok || small.Tier != 0 || small.InputRate != 1 {
+		t.Fatalf("expected base tier at threshold, got %+v", small)
+	}
+}
+
+func TestResolverComputeModalityRates(t *testing.T) {
+	dir := t.TempDir()
+	pricePath := filepath.Join(dir, "price.yaml")
+	priceYAML := `
+version: v1
+unit: usd_per_1m_tokens
+entries:
+  - provider: openai
+    model: gpt-4o-audio
+    cost:
+      input: 2
+      output: 10
+      input_audio: 40
+      output_audio: 80
+`
+	if err := os.WriteFile(pricePath, []byte(priceYAML), 0o600); err != nil {
+		t.Fatalf("write price: %v", err)
+	}
+	r, err := LoadResolver(pricePath, "")
+	if err != nil || r == nil {
+		t.Fatalf("LoadResolver: r=%v err=%v", r, err)
+	}
+
+	c, ok := r.Compute("openai", "", "gpt-4o-audio", map[string]any{
+		"input_tokens":        1000,
+		"output_tokens":       500,
+		"input_audio_tokens":  400,
+		"output_audio_tokens": 900,
+		"input_image_tokens":  50,
+	})
+	if !ok || c == nil {
+		t.Fatalf("Compute failed")
+	}
+	// Unpriced image tokens stay at the input rate; output audio is capped at output tokens.
+	if c.BillableInputTokens != 600 || c.BillableOutputTokens != 0 {
+		t.Fatalf("billable input=%d output=%d want 600/0", c.BillableInputTokens, c.BillableOutputTokens)
+	}
+	if got, want := c.LineItems.String(), "input:600@2=0.0012;input_audio:400@40=0.016;output_audio:500@80=0.04"; got != want {
+		t.Fatalf("line items=%q want=%q", got, want)
+	}
+	if math.Abs(c.ModalityCost-0.056) > 1e-12 || math.Abs(c.TotalCost-0.0572) > 1e-12 {
+		t.Fatalf("modality=%v total=%v", c.ModalityCost, c.TotalCost)
+	}
+}
+
+func TestResolverComputeServiceTierDiscount(t *testing.T) {
+	dir := t.TempDir()
+	pricePath := filepath.Join(dir, "price.yaml")
+	overridesPath := filepath.Join(dir, "price_overrides.yaml")
+	priceYAML := `
+version: v1
+unit: usd_per_1m_tokens
+entries:
+  - provider: openai
+    model: gpt-4.1
+    cost:
+      input: 2
+      output: 8
+    discounts:
+      Batch: 0.5
+`
+	overridesYAML := `
+version: v1
+providers:
+  openai:
+    models:
+      gpt-4.1:
+        discounts:
+          flex: 0.25
+`
+	if err := os.WriteFile(pricePath, []byte(priceYAML), 0o600); err != nil {
+		t.Fatalf("write price: %v", err)
+	}
+	if err := os.WriteFile(overridesPath, []byte(overridesYAML), 0o600); err != nil {
+		t.Fatalf("write overrides: %v", err)
+	}
+	r, err := LoadResolver(pricePath, overridesPath)
+	if err != nil || r == nil {
+		t.Fatalf("LoadResolver: r=%v err=%v", r, err)
+	}
+	usage := map[string]any{"input_tokens": 1_000_000, "output_tokens": 1_000_000}
+
+	for _, tc := range []struct {
+		tier     string
+		wantTier string
+		total    float64
+	}{
+		{tier: "", wantTier: "", total: 10},
+		{tier: "batch", wantTier: "batch", total: 5},
+		{tier: "flex", wantTier: "flex", total: 2.5},
+		{tier: "priority", wantTier: "", total: 10},
+	} {
+		c, ok := r.ComputeWithOptions("openai", "", "gpt-4.1", usage, ComputeOptions{ServiceTier: tc.tier})
+		if !ok || c == nil {
+			t.Fatalf("tier %q: Compute failed", tc.tier)
+		}
+		if c.ServiceTier != tc.wantTier || math.Abs(c.TotalCost-tc.total) > 1e-9 {
+			t.Fatalf("tier %q: service_tier=%q total=%v want %q/%v", tc.tier, c.ServiceTier, c.TotalCost, tc.wantTier, tc.total)
+		}
+	}
+}
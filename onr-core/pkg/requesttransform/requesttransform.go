@@ -2,6 +2,7 @@ package requesttransform
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -112,7 +113,14 @@ func Apply(meta *dslmeta.Meta, contentType string, body []byte, value map[string
 		return result, nil
 	}
 
-	mappedBody, mappedRoot, err := ApplyReqMap(reqMapMode, result.Body, out, opts)
+	var mappedBody []byte
+	var mappedRoot map[string]any
+	var err error
+	if strings.EqualFold(reqMapMode, dslconfig.ReqMapModeTemplate) {
+		mappedBody, mappedRoot, err = applyReqTemplate(meta, t.ReqTemplate, result.Body, out, opts)
+	} else {
+		mappedBody, mappedRoot, err = ApplyReqMap(reqMapMode, result.Body, out, opts)
+	}
 	if err != nil {
 		return Result{}, err
 	}
@@ -136,6 +144,35 @@ func Apply(meta *dslmeta.Meta, contentType string, body []byte, value map[string
 	return result, nil
 }
 
+// applyReqTemplate renders a req_template against the request object root. Missing
+// `$required` values are reported as ValidationError so callers answer 400.
+func applyReqTemplate(meta *dslmeta.Meta, tpl *dslconfig.JSONTemplate, raw []byte, out map[string]any, opts ApplyOptions) ([]byte, map[string]any, error) {
+	if tpl == nil {
+		return nil, nil, fmt.Errorf("req_map template requires a req_template block")
+	}
+	ce := strings.ToLower(strings.TrimSpace(opts.ContentEncoding))
+	if ce != "" && ce != contentEncodingIdentity {
+		return nil, nil, fmt.Errorf("cannot transform encoded client request (Content-Encoding=%q)", opts.ContentEncoding)
+	}
+	if out == nil {
+		if err := json.Unmarshal(raw, &out); err != nil || out == nil {
+			return nil, nil, &ValidationError{Message: "req_template expects a JSON object request body"}
+		}
+	}
+	dst, err := tpl.Render(meta, out)
+	if err != nil {
+		if errors.Is(err, dslconfig.ErrTemplateRequired) {
+			return nil, nil, &ValidationError{Message: err.Error()}
+		}
+		return nil, nil, err
+	}
+	body, err := json.Marshal(dst)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, dst, nil
+}
+
 // ApplyReqMap remaps a request object root to another object-root request schema.
 // out should be the already-parsed request object root when available; otherwise raw is reparsed.
 func ApplyReqMap(mode string, raw []byte, out map[string]any, opts ApplyOptions) ([]byte, map[string]any, error) {
@@ -0,0 +1,291 @@
+package trafficdump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is one line of a jsonl dump. Type is the section name (meta,
+// origin_request, upstream_request, upstream_response, proxy_response, stream,
+// guard); only the fields of that section are set.
+type Record struct {
+	Type string `json:"type"`
+
+	// meta
+	Time      string `json:"time,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	Path      string `json:"path,omitempty"`
+
+	// meta and upstream_request
+	Method string `json:"method,omitempty"`
+	// upstream_request
+	URL string `json:"url,omitempty"`
+
+	// upstream_response and proxy_response
+	Status     int    `json:"status,omitempty"`
+	StatusLine string `json:"status_line,omitempty"`
+
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    *RecordBody         `json:"body,omitempty"`
+
+	// stream
+	BytesCopied             *int64 `json:"bytes_copied,omitempty"`
+	Error                   string `json:"error,omitempty"`
+	IgnoredClientDisconnect bool   `json:"ignored_client_disconnect,omitempty"`
+
+	// guard
+	Phase string `json:"phase,omitempty"`
+	Hits  string `json:"hits,omitempty"`
+}
+
+// RecordBody is a captured payload of a jsonl dump.
+type RecordBody struct {
+	// Base64 is the captured payload (at most traffic_dump.max_bytes).
+	Base64 string `json:"base64,omitempty"`
+	// Size is the number of captured bytes.
+	Size int `json:"size"`
+	// Binary reports that the payload was classified as non-text.
+	Binary    bool `json:"binary,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
+	// Omitted is set instead of Base64 when the payload was not captured.
+	Omitted string `json:"omitted,omitempty"`
+}
+
+func newRecordBody(content []byte, binary bool, truncated bool) *RecordBody {
+	return &RecordBody{
+		Base64:    base64.StdEncoding.EncodeToString(content),
+		Size:      len(content),
+		Binary:    binary,
+		Truncated: truncated,
+	}
+}
+
+func (r *Recorder) writeRecord(rec Record) {
+	b, err := json.Marshal(rec)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	if err != nil {
+		r.setErrLocked(err)
+		return
+	}
+	if _, err := r.writeLocked(append(b, '\n')); err != nil {
+		r.setErrLocked(err)
+	}
+}
+
+func maskHeaders(h map[string][]string, on bool) map[string][]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(h))
+	for k, vals := range h {
+		masked := make([]string, 0, len(vals))
+		for _, v := range vals {
+			masked = append(masked, maskIfNeeded(k, v, on))
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+func statusCodeOf(statusLine string) int {
+	code, _, _ := strings.Cut(strings.TrimSpace(statusLine), " ")
+	n, _ := strconv.Atoi(code)
+	return n
+}
+
+// isJSONL reports whether raw starts with a JSON record rather than a text section.
+func isJSONL(raw []byte) bool {
+	t := bytes.TrimLeft(raw, " \t\r\n")
+	return len(t) > 0 && t[0] == '{'
+}
+
+func parseJSONL(raw []byte) (*Dump, error) {
+	d := &Dump{}
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := d.applyRecord(rec); err != nil {
+			return nil, fmt.Errorf("line %d (%s): %w", line, rec.Type, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Dump) applyRecord(rec Record) error {
+	body, err := rec.Body.decode()
+	if err != nil {
+		return err
+	}
+	switch rec.Type {
+	case sectionMeta:
+		if rec.Time != "" {
+			t, err := time.Parse(time.RFC3339Nano, rec.Time)
+			if err != nil {
+				return fmt.Errorf("invalid time %q", rec.Time)
+			}
+			d.Time = t
+		}
+		d.RequestID = rec.RequestID
+		d.Method = rec.Method
+		d.Path = rec.Path
+		d.ClientIP = rec.ClientIP
+		d.Header = http.Header(rec.Headers)
+		if d.Header == nil {
+			d.Header = http.Header{}
+		}
+	case sectionOriginRequest:
+		d.OriginRequest = &body
+	case sectionUpstreamRequest:
+		d.UpstreamRequest = &Message{Line: strings.TrimSpace(rec.Method + " " + rec.URL), Header: recordHeader(rec.Headers), Body: body}
+	case sectionUpstreamResp:
+		line := rec.StatusLine
+		if line == "" && rec.Status != 0 {
+			line = strconv.Itoa(rec.Status)
+		}
+		d.UpstreamResponse = &Message{Line: line, Header: recordHeader(rec.Headers), Body: body}
+	case sectionProxyResponse:
+		d.ProxyStatus = rec.Status
+		d.ProxyResponse = &body
+	case sectionStream:
+		s := &StreamSummary{Error: rec.Error, IgnoredClientDisconnect: rec.IgnoredClientDisconnect}
+		if rec.BytesCopied != nil {
+			s.BytesCopied = *rec.BytesCopied
+		}
+		d.Stream = s
+	case sectionGuard:
+		d.Guard = append(d.Guard, GuardHit{Phase: rec.Phase, Hits: rec.Hits})
+	}
+	return nil
+}
+
+func recordHeader(h map[string][]string) http.Header {
+	if h == nil {
+		return http.Header{}
+	}
+	return http.Header(h)
+}
+
+func (b *RecordBody) decode() (Body, error) {
+	if b == nil {
+		return Body{}, nil
+	}
+	if b.Omitted != "" {
+		return Body{Data: []byte(b.Omitted), Omitted: true}, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(b.Base64)
+	if err != nil {
+		return Body{}, fmt.Errorf("decode body: %w", err)
+	}
+	if len(data) == 0 {
+		data = nil
+	}
+	return Body{Data: data, Binary: b.Binary, Truncated: b.Truncated}, nil
+}
+
+// Text renders d in the text dump layout, e.g. to display a jsonl dump.
+// Sections appear in request order and headers are sorted.
+func (d *Dump) Text() string {
+	var sb strings.Builder
+	if d.RequestID != "" || d.Method != "" {
+		sb.WriteString(headerMeta + "\n")
+		if !d.Time.IsZero() {
+			sb.WriteString("time=" + d.Time.Format(time.RFC3339) + "\n")
+		}
+		sb.WriteString("request_id=" + d.RequestID + "\n")
+		sb.WriteString("method=" + d.Method + "\n")
+		sb.WriteString("path=" + d.Path + "\n")
+		sb.WriteString("client_ip=" + d.ClientIP + "\n")
+		sb.WriteString("headers:\n")
+		writeTextHeaders(&sb, d.Header)
+		sb.WriteString("\n")
+	}
+	if d.OriginRequest != nil {
+		sb.WriteString(headerOriginRequest + "\n")
+		writeTextBody(&sb, *d.OriginRequest)
+	}
+	for _, m := range []struct {
+		header string
+		msg    *Message
+	}{{headerUpstreamRequest, d.UpstreamRequest}, {headerUpstreamResponse, d.UpstreamResponse}} {
+		if m.msg == nil {
+			continue
+		}
+		sb.WriteString(m.header + "\n" + m.msg.Line + "\n")
+		writeTextHeaders(&sb, m.msg.Header)
+		sb.WriteString("\n\n")
+		writeTextBody(&sb, m.msg.Body)
+	}
+	if d.ProxyResponse != nil {
+		sb.WriteString(headerProxyResponse + "\n" + "status=" + strconv.Itoa(d.ProxyStatus) + "\n\n\n")
+		writeTextBody(&sb, *d.ProxyResponse)
+	}
+	if s := d.Stream; s != nil {
+		sb.WriteString(headerStream + "\n" + "bytes_copied=" + strconv.FormatInt(s.BytesCopied, 10) + "\n")
+		if s.Error != "" {
+			sb.WriteString("error=" + s.Error + "\n")
+		}
+		if s.IgnoredClientDisconnect {
+			sb.WriteString("ignored_client_disconnect=true\n")
+		}
+		sb.WriteString("\n")
+	}
+	for _, g := range d.Guard {
+		sb.WriteString(headerGuard + "\n" + "phase=" + g.Phase + "\n" + "hits=" + g.Hits + "\n\n")
+	}
+	return sb.String()
+}
+
+func writeTextHeaders(sb *strings.Builder, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			sb.WriteString("  " + k + ": " + v + "\n")
+		}
+	}
+}
+
+// writeTextBody mirrors Recorder.writeBlock without its title line.
+func writeTextBody(sb *strings.Builder, b Body) {
+	if b.Binary {
+		sb.WriteString("[base64]\n" + base64.StdEncoding.EncodeToString(b.Data) + "\n")
+	} else {
+		sb.Write(b.Data)
+		if len(b.Data) == 0 || b.Data[len(b.Data)-1] != '\n' {
+			sb.WriteString("\n")
+		}
+	}
+	if b.Truncated {
+		sb.WriteString("[truncated]\n")
+	}
+	sb.WriteString("\n")
+}
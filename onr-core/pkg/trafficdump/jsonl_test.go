@@ -0,0 +1,101 @@
+package trafficdump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecorderJSONL_TypedRecordsRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmp := t.TempDir()
+	cfg := Config{Enabled: true, Dir: tmp, FilePath: "{{.request_id}}.jsonl", MaxBytes: 1 << 20, MaskSecrets: true, Format: FormatJSONL}
+
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{}`))
+	gc.Request.Header.Set("Authorization", "Bearer secret")
+
+	rec, err := StartWithRequestID(gc, cfg, "rid_jsonl")
+	if err != nil {
+		t.Fatalf("StartWithRequestID: %v", err)
+	}
+	AppendOriginRequest(gc, []byte(`{"model":"m"}`), false, false)
+	AppendUpstreamRequest(gc, "POST", "https://up.test/v1/chat?key=abc", map[string][]string{
+		"Authorization": {"Bearer up"},
+	}, []byte(`{"model":"m2"}`), false, true)
+	AppendUpstreamResponse(gc, "502 Bad Gateway", nil, []byte{0xff, 0x00}, true, false)
+	AppendProxyResponse(gc, []byte(`{"error":"x"}`), false, false, 502)
+	AppendStreamSummary(gc, 7, "", true)
+	AppendGuardHits(gc, "request", "request:email=log")
+	rec.Close()
+
+	path := filepath.Join(tmp, "rid_jsonl.jsonl")
+	// #nosec G304 -- test reads a file path constructed from t.TempDir().
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if bytes.Contains(raw, []byte("secret")) || bytes.Contains(raw, []byte("Bearer up")) || bytes.Contains(raw, []byte("key=abc")) {
+		t.Fatalf("secrets not masked:\n%s", raw)
+	}
+	var types []string
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		types = append(types, r.Type)
+	}
+	if got := strings.Join(types, ","); got != "meta,origin_request,upstream_request,upstream_response,proxy_response,stream,guard" {
+		t.Fatalf("record types=%s", got)
+	}
+
+	d, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if d.RequestID != "rid_jsonl" || d.Header.Get("Authorization") != "[REDACTED]" || d.Time.IsZero() {
+		t.Fatalf("meta=%+v", d)
+	}
+	if string(d.OriginRequest.Data) != `{"model":"m"}` {
+		t.Fatalf("origin=%q", d.OriginRequest.Data)
+	}
+	if m, u := d.UpstreamRequest.RequestLine(); m != "POST" || !strings.HasPrefix(u, "https://up.test/v1/chat?key=") || !d.UpstreamRequest.Body.Truncated {
+		t.Fatalf("upstream request=%+v", d.UpstreamRequest)
+	}
+	if d.UpstreamResponse.StatusCode() != 502 || !d.UpstreamResponse.Body.Binary || !bytes.Equal(d.UpstreamResponse.Body.Data, []byte{0xff, 0x00}) {
+		t.Fatalf("upstream response=%+v", d.UpstreamResponse)
+	}
+	if d.ProxyStatus != 502 || d.Stream.BytesCopied != 7 || !d.Stream.IgnoredClientDisconnect || len(d.Guard) != 1 {
+		t.Fatalf("dump=%+v stream=%+v", d, d.Stream)
+	}
+
+	text := d.Text()
+	back, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Parse(Text()): %v", err)
+	}
+	if back.RequestID != d.RequestID || back.ProxyStatus != 502 || !bytes.Equal(back.UpstreamResponse.Body.Data, []byte{0xff, 0x00}) {
+		t.Fatalf("text rendering does not round-trip:\n%s", text)
+	}
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	if f, err := NormalizeFormat(""); err != nil || f != FormatText {
+		t.Fatalf("empty: %q %v", f, err)
+	}
+	if f, err := NormalizeFormat(" JSONL "); err != nil || f != FormatJSONL {
+		t.Fatalf("jsonl: %q %v", f, err)
+	}
+	if _, err := NormalizeFormat("har"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,326 @@
+package trafficdump
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// section header lines written by the Recorder.
+const (
+	headerMeta             = "=== META ==="
+	headerOriginRequest    = "=== ORIGIN REQUEST ==="
+	headerUpstreamRequest  = "=== UPSTREAM REQUEST ==="
+	headerUpstreamResponse = "=== UPSTREAM RESPONSE ==="
+	headerProxyResponse    = "=== PROXY RESPONSE ==="
+	headerStream           = "=== STREAM ==="
+	headerGuard            = "=== GUARD ==="
+)
+
+var sectionByHeader = map[string]string{
+	headerMeta:             sectionMeta,
+	headerOriginRequest:    sectionOriginRequest,
+	headerUpstreamRequest:  sectionUpstreamRequest,
+	headerUpstreamResponse: sectionUpstreamResp,
+	headerProxyResponse:    sectionProxyResponse,
+	headerStream:           sectionStream,
+	headerGuard:            sectionGuard,
+}
+
+// Body is a captured payload.
+type Body struct {
+	Data []byte
+	// Binary reports that the payload was written as base64; Data is decoded.
+	Binary bool
+	// Truncated reports that the payload hit traffic_dump.max_bytes.
+	Truncated bool
+	// Omitted reports a "[binary body omitted]" summary; Data holds the summary line.
+	Omitted bool
+}
+
+// Message is a captured upstream request or response.
+type Message struct {
+	// Line is the request line ("POST https://...") or the status line ("200 OK").
+	Line   string
+	Header http.Header
+	Body   Body
+}
+
+// RequestLine splits Line into method and URL.
+func (m *Message) RequestLine() (method, url string) {
+	method, url, _ = strings.Cut(strings.TrimSpace(m.Line), " ")
+	return method, strings.TrimSpace(url)
+}
+
+// StatusCode returns the leading status code of Line, or 0.
+func (m *Message) StatusCode() int {
+	return statusCodeOf(m.Line)
+}
+
+// StreamSummary is the STREAM section.
+type StreamSummary struct {
+	BytesCopied             int64
+	Error                   string
+	IgnoredClientDisconnect bool
+}
+
+// GuardHit is one GUARD section.
+type GuardHit struct {
+	Phase string
+	Hits  string
+}
+
+// Dump is a parsed traffic dump file. Sections missing from the file (disabled
+// by traffic_dump.sections or never reached) are nil. When a section occurs
+// more than once (e.g. upstream retries), the last one wins.
+type Dump struct {
+	Time      time.Time
+	RequestID string
+	Method    string
+	// Path is the origin request URI including the (masked) query.
+	Path     string
+	ClientIP string
+	Header   http.Header
+
+	OriginRequest    *Body
+	UpstreamRequest  *Message
+	UpstreamResponse *Message
+	ProxyStatus      int
+	ProxyResponse    *Body
+	Stream           *StreamSummary
+	Guard            []GuardHit
+}
+
+// ParseFile parses the dump file at path.
+func ParseFile(path string) (*Dump, error) {
+	// #nosec G304 -- dump paths come from the operator (CLI args / configured dump dir).
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return Parse(f)
+}
+
+// Parse reads a dump written by Recorder in either format (detected from the
+// first byte). Unknown lines outside of text sections are ignored so that
+// files with extra trailing notes still parse.
+func Parse(r io.Reader) (*Dump, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if isJSONL(raw) {
+		return parseJSONL(raw)
+	}
+	d := &Dump{}
+	for _, sec := range splitSections(string(raw)) {
+		if err := d.parseSection(sec.name, sec.text); err != nil {
+			return nil, fmt.Errorf("parse %s section: %w", sec.name, err)
+		}
+	}
+	return d, nil
+}
+
+type rawSection struct {
+	name string
+	text string
+}
+
+// splitSections cuts raw at section header lines; text excludes the header line.
+func splitSections(raw string) []rawSection {
+	var out []rawSection
+	cur := -1
+	start := 0
+	pos := 0
+	for pos <= len(raw) {
+		end := strings.IndexByte(raw[pos:], '\n')
+		line := raw[pos:]
+		next := len(raw) + 1
+		if end >= 0 {
+			line = raw[pos : pos+end]
+			next = pos + end + 1
+		}
+		if name, ok := sectionByHeader[strings.TrimRight(line, "\r")]; ok {
+			if cur >= 0 {
+				out[cur].text = raw[start:pos]
+			}
+			out = append(out, rawSection{name: name})
+			cur = len(out) - 1
+			start = min(next, len(raw))
+		}
+		pos = next
+	}
+	if cur >= 0 {
+		out[cur].text = raw[start:]
+	}
+	return out
+}
+
+func (d *Dump) parseSection(name, text string) error {
+	switch name {
+	case sectionMeta:
+		return d.parseMeta(text)
+	case sectionOriginRequest:
+		b := parseBlock(text)
+		d.OriginRequest = &b
+	case sectionUpstreamRequest:
+		m := parseMessage(text)
+		d.UpstreamRequest = &m
+	case sectionUpstreamResp:
+		m := parseMessage(text)
+		d.UpstreamResponse = &m
+	case sectionProxyResponse:
+		line, rest, _ := strings.Cut(text, "\n")
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "status="); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid status %q", v)
+			}
+			d.ProxyStatus = n
+		}
+		// status line, blank line, then an untitled block.
+		rest = strings.TrimPrefix(rest, "\n")
+		b := parseBlock(strings.TrimPrefix(rest, "\n"))
+		d.ProxyResponse = &b
+	case sectionStream:
+		s := &StreamSummary{}
+		for _, line := range strings.Split(text, "\n") {
+			k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "bytes_copied":
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid bytes_copied %q", v)
+				}
+				s.BytesCopied = n
+			case "error":
+				s.Error = v
+			case "ignored_client_disconnect":
+				s.IgnoredClientDisconnect = v == "true"
+			}
+		}
+		d.Stream = s
+	case sectionGuard:
+		var g GuardHit
+		for _, line := range strings.Split(text, "\n") {
+			if v, ok := strings.CutPrefix(line, "phase="); ok {
+				g.Phase = v
+			} else if v, ok := strings.CutPrefix(line, "hits="); ok {
+				g.Hits = v
+			}
+		}
+		d.Guard = append(d.Guard, g)
+	}
+	return nil
+}
+
+func (d *Dump) parseMeta(text string) error {
+	d.Header = http.Header{}
+	inHeaders := false
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if inHeaders {
+			if k, v, ok := parseHeaderLine(line); ok {
+				d.Header.Add(k, v)
+				continue
+			}
+			inHeaders = false
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			if strings.TrimSpace(line) == "headers:" {
+				inHeaders = true
+			}
+			continue
+		}
+		switch k {
+		case "time":
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("invalid time %q", v)
+			}
+			d.Time = t
+		case "request_id":
+			d.RequestID = strings.TrimSpace(v)
+		case "method":
+			d.Method = strings.TrimSpace(v)
+		case "path":
+			d.Path = strings.TrimSpace(v)
+		case "client_ip":
+			d.ClientIP = strings.TrimSpace(v)
+		}
+	}
+	return sc.Err()
+}
+
+// parseHeaderLine parses an indented "  Key: value" line.
+func parseHeaderLine(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "  ") {
+		return "", "", false
+	}
+	k, v, ok := strings.Cut(strings.TrimPrefix(line, "  "), ": ")
+	if !ok || strings.TrimSpace(k) == "" {
+		return "", "", false
+	}
+	return k, v, true
+}
+
+// parseMessage parses "<line>\n  K: V...\n\n" followed by an untitled block.
+func parseMessage(text string) Message {
+	m := Message{Header: http.Header{}}
+	line, rest, _ := strings.Cut(text, "\n")
+	m.Line = strings.TrimSpace(line)
+	for rest != "" {
+		hl, tail, _ := strings.Cut(rest, "\n")
+		k, v, ok := parseHeaderLine(hl)
+		if !ok {
+			break
+		}
+		m.Header.Add(k, v)
+		rest = tail
+	}
+	// blank line after headers, then the empty block title line.
+	rest = strings.TrimPrefix(rest, "\n")
+	rest = strings.TrimPrefix(rest, "\n")
+	m.Body = parseBlock(rest)
+	return m
+}
+
+// parseBlock reverses Recorder.writeBlock without its title line.
+func parseBlock(text string) Body {
+	var b Body
+	s := strings.TrimSuffix(text, "\n")
+	if t, ok := strings.CutSuffix(s, "[truncated]\n"); ok {
+		b.Truncated = true
+		s = t
+	}
+	if enc, ok := strings.CutPrefix(s, "[base64]\n"); ok {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+		if err == nil {
+			b.Binary = true
+			b.Data = data
+			return b
+		}
+	}
+	if strings.HasPrefix(s, "[binary body omitted]") {
+		b.Omitted = true
+		s = strings.TrimSuffix(s, "\n")
+	}
+	// writeBlock terminates text bodies with a newline; an empty body is just that.
+	if s != "\n" {
+		b.Data = []byte(s)
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package trafficdump
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParse_RoundTripsRecorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmp := t.TempDir()
+	cfg := Config{Enabled: true, Dir: tmp, FilePath: "{{.request_id}}.log", MaxBytes: 1 << 20, MaskSecrets: true}
+
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest("POST", "/v1/chat/completions?key=abc", strings.NewReader(`{}`))
+	gc.Request.Header.Set("Authorization", "Bearer secret")
+	gc.Request.Header.Set("Content-Type", "application/json")
+
+	rec, err := StartWithRequestID(gc, cfg, "rid_parse")
+	if err != nil {
+		t.Fatalf("StartWithRequestID: %v", err)
+	}
+	AppendOriginRequest(gc, []byte(`{"model":"m"}`), false, false)
+	AppendUpstreamRequest(gc, "POST", "https://up.test/v1/chat", map[string][]string{
+		"Content-Type": {"application/json"},
+	}, []byte(`{"model":"m2"}`), false, true)
+	AppendUpstreamResponse(gc, "200 OK", map[string][]string{
+		"Content-Type": {"text/event-stream"},
+	}, []byte("data: {\"a\":1}\n\ndata: [DONE]\n\n"), false, false)
+	AppendProxyResponse(gc, []byte{0x00, 0x01}, true, false, 201)
+	AppendStreamSummary(gc, 42, "boom", false)
+	AppendGuardHits(gc, "request", "request:email=log")
+	rec.Close()
+
+	d, err := ParseFile(filepath.Join(tmp, "rid_parse.log"))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if d.RequestID != "rid_parse" || d.Method != "POST" || d.Time.IsZero() {
+		t.Fatalf("meta=%+v", d)
+	}
+	if !strings.Contains(d.Path, "key=%5BREDACTED%5D") && !strings.Contains(d.Path, "key=[REDACTED]") {
+		t.Fatalf("path=%q", d.Path)
+	}
+	if d.Header.Get("Authorization") != "[REDACTED]" || d.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("headers=%v", d.Header)
+	}
+	if d.OriginRequest == nil || string(d.OriginRequest.Data) != "{\"model\":\"m\"}\n" {
+		t.Fatalf("origin=%+v", d.OriginRequest)
+	}
+	if d.UpstreamRequest == nil || d.UpstreamRequest.Line != "POST https://up.test/v1/chat" ||
+		!d.UpstreamRequest.Body.Truncated || string(bytes.TrimSpace(d.UpstreamRequest.Body.Data)) != `{"model":"m2"}` {
+		t.Fatalf("upstream request=%+v", d.UpstreamRequest)
+	}
+	if d.UpstreamResponse == nil || d.UpstreamResponse.StatusCode() != 200 ||
+		d.UpstreamResponse.Header.Get("Content-Type") != "text/event-stream" ||
+		string(d.UpstreamResponse.Body.Data) != "data: {\"a\":1}\n\ndata: [DONE]\n\n" {
+		t.Fatalf("upstream response=%+v", d.UpstreamResponse)
+	}
+	if d.ProxyStatus != 201 || d.ProxyResponse == nil || !d.ProxyResponse.Binary || !bytes.Equal(d.ProxyResponse.Data, []byte{0x00, 0x01}) {
+		t.Fatalf("proxy response status=%d body=%+v", d.ProxyStatus, d.ProxyResponse)
+	}
+	if d.Stream == nil || d.Stream.BytesCopied != 42 || d.Stream.Error != "boom" {
+		t.Fatalf("stream=%+v", d.Stream)
+	}
+	if len(d.Guard) != 1 || d.Guard[0].Phase != "request" {
+		t.Fatalf("guard=%+v", d.Guard)
+	}
+}
+
+func TestParse_OmittedAndEmptyBodies(t *testing.T) {
+	raw := "=== ORIGIN REQUEST ===\n[binary body omitted] content_type=multipart/form-data captured_bytes=3\n\n" +
+		"=== PROXY RESPONSE ===\nstatus=204\n\n\n\n\n"
+	d, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.OriginRequest == nil || !d.OriginRequest.Omitted {
+		t.Fatalf("origin=%+v", d.OriginRequest)
+	}
+	if d.ProxyStatus != 204 || d.ProxyResponse == nil || len(d.ProxyResponse.Data) != 0 {
+		t.Fatalf("proxy=%d %+v", d.ProxyStatus, d.ProxyResponse)
+	}
+}
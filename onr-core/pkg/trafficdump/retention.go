@@ -0,0 +1,252 @@
+package trafficdump
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SampleRule keeps a fraction of the dumps of matching requests.
+type SampleRule struct {
+	// API matches the resolved API (e.g. chat.completions); empty matches any.
+	API string
+	// Status matches the client status: comma-separated codes or classes
+	// such as "5xx", "429" or "4xx,5xx"; empty matches any.
+	Status string
+	// Rate is the kept fraction in [0, 1].
+	Rate float64
+}
+
+type sampleRule struct {
+	api     string
+	codes   map[int]struct{}
+	classes map[int]struct{}
+	rate    float64
+}
+
+// Sampler decides whether a finished request keeps its dump. The first
+// matching rule wins; requests matching no rule use the default rate.
+// A nil Sampler keeps everything.
+type Sampler struct {
+	rules       []sampleRule
+	defaultRate float64
+	random      func() float64
+}
+
+// NewSampler validates rules and returns a non-nil sampler.
+func NewSampler(rules []SampleRule, defaultRate float64) (*Sampler, error) {
+	if defaultRate < 0 || defaultRate > 1 {
+		return nil, fmt.Errorf("traffic dump sampling default rate %v out of range [0,1]", defaultRate)
+	}
+	s := &Sampler{defaultRate: defaultRate, random: rand.Float64}
+	for i, r := range rules {
+		if r.Rate < 0 || r.Rate > 1 {
+			return nil, fmt.Errorf("traffic dump sampling rule %d: rate %v out of range [0,1]", i, r.Rate)
+		}
+		rule := sampleRule{api: strings.TrimSpace(r.API), rate: r.Rate}
+		for _, part := range strings.Split(r.Status, ",") {
+			p := strings.ToLower(strings.TrimSpace(part))
+			if p == "" {
+				continue
+			}
+			if len(p) == 3 && strings.HasSuffix(p, "xx") && p[0] >= '1' && p[0] <= '5' {
+				if rule.classes == nil {
+					rule.classes = map[int]struct{}{}
+				}
+				rule.classes[int(p[0]-'0')] = struct{}{}
+				continue
+			}
+			code, err := strconv.Atoi(p)
+			if err != nil || code < 100 || code > 599 {
+				return nil, fmt.Errorf("traffic dump sampling rule %d: invalid status %q (want e.g. 5xx or 429)", i, part)
+			}
+			if rule.codes == nil {
+				rule.codes = map[int]struct{}{}
+			}
+			rule.codes[code] = struct{}{}
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+// Rate returns the kept fraction for a request.
+func (s *Sampler) Rate(api string, status int) float64 {
+	if s == nil {
+		return 1
+	}
+	for _, r := range s.rules {
+		if r.matches(api, status) {
+			return r.rate
+		}
+	}
+	return s.defaultRate
+}
+
+// Drops reports whether no dump of api is kept whatever its status, so the
+// recording can stop as soon as the API is known.
+func (s *Sampler) Drops(api string) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if r.api != "" && !strings.EqualFold(r.api, strings.TrimSpace(api)) {
+			continue
+		}
+		if r.rate > 0 {
+			return false
+		}
+		if r.codes == nil && r.classes == nil {
+			return true
+		}
+	}
+	return s.defaultRate <= 0
+}
+
+// Keep reports whether the dump of a request with api and status is kept.
+func (s *Sampler) Keep(api string, status int) bool {
+	rate := s.Rate(api, status)
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	return s.random() < rate
+}
+
+func (r sampleRule) matches(api string, status int) bool {
+	if r.api != "" && !strings.EqualFold(r.api, strings.TrimSpace(api)) {
+		return false
+	}
+	if r.codes == nil && r.classes == nil {
+		return true
+	}
+	if _, ok := r.codes[status]; ok {
+		return true
+	}
+	_, ok := r.classes[status/100]
+	return ok
+}
+
+// RetentionOptions bounds the dump directory; zero values disable a limit.
+type RetentionOptions struct {
+	// MaxTotalBytes removes the oldest dumps until the total size fits.
+	MaxTotalBytes int64
+	// MaxAge removes dumps last modified longer ago than this.
+	MaxAge time.Duration
+}
+
+// Enabled reports whether any limit is set.
+func (o RetentionOptions) Enabled() bool {
+	return o.MaxTotalBytes > 0 || o.MaxAge > 0
+}
+
+// PruneResult summarizes one Prune pass.
+type PruneResult struct {
+	Removed      int
+	RemovedBytes int64
+	// Files and Bytes are what remains.
+	Files int
+	Bytes int64
+}
+
+type dumpFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Prune enforces opts on dir. Only files that start like a dump (a text
+// section header or a jsonl record) are considered, so a misconfigured dir
+// never loses unrelated files. Missing dirs are not an error.
+func Prune(dir string, opts RetentionOptions, now time.Time) (PruneResult, error) {
+	var res PruneResult
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return res, errors.New("traffic dump dir is empty")
+	}
+	var files []dumpFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !looksLikeDumpFile(path) {
+			return nil
+		}
+		files = append(files, dumpFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.Before(files[j].modTime) // oldest first
+		}
+		return files[i].path < files[j].path
+	})
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	var errs []error
+	for _, f := range files {
+		expired := opts.MaxAge > 0 && now.Sub(f.modTime) > opts.MaxAge
+		oversize := opts.MaxTotalBytes > 0 && total > opts.MaxTotalBytes
+		if !expired && !oversize {
+			res.Files++
+			res.Bytes += f.size
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			res.Files++
+			res.Bytes += f.size
+			continue
+		}
+		total -= f.size
+		res.Removed++
+		res.RemovedBytes += f.size
+	}
+	return res, errors.Join(errs...)
+}
+
+func looksLikeDumpFile(path string) bool {
+	// #nosec G304 -- path is walked from the configured dump dir.
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, 16)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false
+	}
+	head = head[:n]
+	return bytes.HasPrefix(head, []byte("=== ")) || bytes.HasPrefix(head, []byte(`{"type":"`))
+}
@@ -0,0 +1,214 @@
+package trafficdump
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSampler_FirstMatchingRuleWins(t *testing.T) {
+	s, err := NewSampler([]SampleRule{
+		{Status: "5xx", Rate: 1},
+		{API: "embeddings", Rate: 0},
+		{Status: "429, 2xx", Rate: 0.01},
+	}, 0.5)
+	if err != nil {
+		t.Fatalf("NewSampler: %v", err)
+	}
+	cases := []struct {
+		api    string
+		status int
+		want   float64
+	}{
+		{"chat.completions", 503, 1},
+		{"embeddings", 500, 1},
+		{"embeddings", 200, 0},
+		{"chat.completions", 200, 0.01},
+		{"chat.completions", 429, 0.01},
+		{"chat.completions", 404, 0.5},
+	}
+	for _, tc := range cases {
+		if got := s.Rate(tc.api, tc.status); got != tc.want {
+			t.Fatalf("Rate(%s,%d)=%v want %v", tc.api, tc.status, got, tc.want)
+		}
+	}
+
+	s.random = func() float64 { return 0.005 }
+	if !s.Keep("chat.completions", 200) {
+		t.Fatalf("expected 1%% rule to keep a draw of 0.005")
+	}
+	s.random = func() float64 { return 0.5 }
+	if s.Keep("chat.completions", 200) || s.Keep("embeddings", 200) || !s.Keep("x", 500) {
+		t.Fatalf("unexpected keep decisions")
+	}
+	var nilSampler *Sampler
+	if !nilSampler.Keep("x", 200) || nilSampler.Drops("x") {
+		t.Fatalf("nil sampler must keep everything")
+	}
+}
+
+func TestSampler_Drops(t *testing.T) {
+	s, err := NewSampler([]SampleRule{
+		{API: "embeddings", Rate: 0},
+		{API: "responses", Status: "5xx", Rate: 1},
+		{API: "audio.speech", Status: "5xx", Rate: 0},
+		{Status: "2xx", Rate: 0},
+	}, 0)
+	if err != nil {
+		t.Fatalf("NewSampler: %v", err)
+	}
+	for api, want := range map[string]bool{
+		"embeddings":       true,
+		"responses":        false,
+		"audio.speech":     true,
+		"chat.completions": true,
+	} {
+		if got := s.Drops(api); got != want {
+			t.Fatalf("Drops(%s)=%v want %v", api, got, want)
+		}
+	}
+	if s, _ := NewSampler([]SampleRule{{API: "embeddings", Rate: 0}}, 0.01); s.Drops("chat.completions") {
+		t.Fatalf("a positive default rate must not drop other APIs")
+	}
+}
+
+func TestNewSampler_Invalid(t *testing.T) {
+	for _, rules := range [][]SampleRule{
+		{{Status: "6xx", Rate: 1}},
+		{{Status: "abc", Rate: 1}},
+		{{Rate: 1.5}},
+	} {
+		if _, err := NewSampler(rules, 1); err == nil {
+			t.Fatalf("expected error for %+v", rules)
+		}
+	}
+	if _, err := NewSampler(nil, -0.1); err == nil {
+		t.Fatalf("expected error for negative default rate")
+	}
+}
+
+func writeDumpFile(t *testing.T, path string, size int, mod time.Time) {
+	t.Helper()
+	content := "=== META ===\n" + strings.Repeat("x", size-len("=== META ===\n"))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrune_AgeThenSizeOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeDumpFile(t, filepath.Join(dir, "expired.log"), 100, now.Add(-48*time.Hour))
+	writeDumpFile(t, filepath.Join(dir, "a", "old.log"), 100, now.Add(-3*time.Hour))
+	writeDumpFile(t, filepath.Join(dir, "mid.jsonl"), 100, now.Add(-2*time.Hour))
+	writeDumpFile(t, filepath.Join(dir, "new.log"), 100, now.Add(-time.Hour))
+	// Not a dump: never removed even though it is the oldest file.
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(other, now.Add(-100*time.Hour), now.Add(-100*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Prune(dir, RetentionOptions{MaxAge: 24 * time.Hour, MaxTotalBytes: 250}, now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if res.Removed != 2 || res.RemovedBytes != 200 || res.Files != 2 || res.Bytes != 200 {
+		t.Fatalf("result=%+v", res)
+	}
+	for _, gone := range []string{"expired.log", filepath.Join("a", "old.log")} {
+		if _, err := os.Stat(filepath.Join(dir, gone)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, err=%v", gone, err)
+		}
+	}
+	for _, kept := range []string{"mid.jsonl", "new.log", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+			t.Fatalf("expected %s kept: %v", kept, err)
+		}
+	}
+
+	if res, err := Prune(filepath.Join(dir, "missing"), RetentionOptions{MaxAge: time.Hour}, now); err != nil || res.Removed != 0 {
+		t.Fatalf("missing dir: res=%+v err=%v", res, err)
+	}
+}
+
+func TestRecorderDiscard_RemovesFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmp := t.TempDir()
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest("GET", "/v1/models", nil)
+	rec, err := StartWithRequestID(gc, Config{Enabled: true, Dir: tmp, FilePath: "{{.request_id}}.log", MaxBytes: 1024}, "rid_discard")
+	if err != nil {
+		t.Fatalf("StartWithRequestID: %v", err)
+	}
+	rec.Discard()
+	if _, err := os.Stat(filepath.Join(tmp, "rid_discard.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected dump removed, err=%v", err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Err=%v", err)
+	}
+}
+
+func TestRecorderDeferred_WritesOnlyKeptDumps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmp := t.TempDir()
+	cfg := Config{Enabled: true, Dir: filepath.Join(tmp, "dumps"), FilePath: "{{.request_id}}.log", MaxBytes: 1024, Deferred: true}
+	start := func(rid string) (*gin.Context, *Recorder) {
+		t.Helper()
+		gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+		gc.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		rec, err := StartWithRequestID(gc, cfg, rid)
+		if err != nil {
+			t.Fatalf("StartWithRequestID: %v", err)
+		}
+		return gc, rec
+	}
+
+	gc, rec := start("rid_dropped")
+	AppendOriginRequest(gc, []byte(`{"model":"m"}`), false, false)
+	Drop(gc)
+	AppendProxyResponse(gc, []byte(`{"ok":true}`), false, false, 200)
+	rec.Discard()
+	if FromContext(gc) != nil || rec.Err() != nil {
+		t.Fatalf("expected detached recorder without error, err=%v", rec.Err())
+	}
+	if _, err := os.Stat(cfg.Dir); !os.IsNotExist(err) {
+		t.Fatalf("discarded deferred dump touched disk, stat err=%v", err)
+	}
+
+	gc, rec = start("rid_kept")
+	AppendOriginRequest(gc, []byte(`{"model":"m"}`), false, false)
+	path := filepath.Join(cfg.Dir, "rid_kept.log")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("deferred dump written before Close, stat err=%v", err)
+	}
+	rec.Close()
+	raw, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(raw), "request_id=rid_kept") || !strings.Contains(string(raw), `{"model":"m"}`) {
+		t.Fatalf("kept dump=%q err=%v", raw, err)
+	}
+
+	gc, rec = start("rid_spilled")
+	AppendOriginRequest(gc, []byte(strings.Repeat("x", deferredBufferMin)), false, false)
+	if _, err := os.Stat(filepath.Join(cfg.Dir, "rid_spilled.log")); err != nil {
+		t.Fatalf("expected oversized deferred dump to spill to disk: %v", err)
+	}
+	rec.Discard()
+	if _, err := os.Stat(filepath.Join(cfg.Dir, "rid_spilled.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected spilled dump removed, stat err=%v", err)
+	}
+}
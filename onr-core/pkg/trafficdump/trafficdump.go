@@ -29,6 +29,7 @@ const (
 	sectionUpstreamResp    = "upstream_response"
 	sectionProxyResponse   = "proxy_response"
 	sectionStream          = "stream"
+	sectionGuard           = "guard"
 )
 
 var imageB64FieldRegex = regexp.MustCompile(`"(b64_json|image|mask)"\s*:\s*"[^"]*"`)
@@ -40,8 +41,17 @@ var allTrafficDumpSections = map[string]struct{}{
 	sectionUpstreamResp:    {},
 	sectionProxyResponse:   {},
 	sectionStream:          {},
+	sectionGuard:           {},
 }
 
+// Dump file formats.
+const (
+	// FormatText writes human-readable "=== SECTION ===" blocks (default).
+	FormatText = "text"
+	// FormatJSONL writes one typed JSON Record per section with base64 bodies.
+	FormatJSONL = "jsonl"
+)
+
 type Config struct {
 	Enabled     bool
 	Dir         string
@@ -49,18 +59,43 @@ type Config struct {
 	MaxBytes    int
 	MaskSecrets bool
 	Sections    []string
+	// Format is FormatText (default) or FormatJSONL.
+	Format string
+	// Deferred keeps the dump in memory until Close, spilling it to the file
+	// once it outgrows MaxBytes, so Discard of an unsampled request usually
+	// costs no file I/O.
+	Deferred bool
 }
 
+// deferredBufferMin is the smallest in-memory buffer of a deferred dump.
+const deferredBufferMin = 64 << 10
+
 type Recorder struct {
 	mu              sync.Mutex
 	f               *os.File
+	buf             *bytes.Buffer
+	spillAt         int
+	path            string
 	maxBytes        int
 	mask            bool
+	jsonl           bool
 	enabledSections map[string]struct{}
 	closed          bool
 	err             error
 }
 
+// NormalizeFormat returns the canonical dump format; empty means text.
+func NormalizeFormat(format string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(format)); v {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSONL:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid traffic dump format %q (want text or jsonl)", format)
+	}
+}
+
 func Enabled(cfg Config) bool { return cfg.Enabled }
 
 func RequestID(c *gin.Context) string {
@@ -130,6 +165,10 @@ func startWithRequestIDAndHeaderKey(c *gin.Context, cfg Config, requestID string
 	if err != nil {
 		return nil, err
 	}
+	format, err := NormalizeFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
 
 	rid := strings.TrimSpace(requestID)
 	headerKey = requestid.ResolveHeaderKey(headerKey)
@@ -152,29 +191,33 @@ func startWithRequestIDAndHeaderKey(c *gin.Context, cfg Config, requestID string
 		return nil, err
 	}
 
-	dir := strings.TrimSpace(cfg.Dir)
-	if err := os.MkdirAll(dir, 0o750); err != nil {
-		return nil, err
-	}
-	path := filepath.Join(dir, buf.String())
-	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
-		return nil, err
-	}
-	// #nosec G304 -- path is derived from configured dump dir and template.
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
-	if err != nil {
-		return nil, err
-	}
-
+	path := filepath.Join(strings.TrimSpace(cfg.Dir), buf.String())
 	r := &Recorder{
-		f:               f,
+		path:            path,
 		maxBytes:        cfg.MaxBytes,
 		mask:            cfg.MaskSecrets,
+		jsonl:           format == FormatJSONL,
 		enabledSections: enabledSections,
 	}
+	if cfg.Deferred {
+		r.buf = &bytes.Buffer{}
+		r.spillAt = max(cfg.MaxBytes, deferredBufferMin)
+	} else if r.f, err = openDumpFile(path); err != nil {
+		return nil, err
+	}
 	c.Set(ctxKeyRecorder, r)
 
-	if r.sectionEnabled(sectionMeta) {
+	if r.sectionEnabled(sectionMeta) && r.jsonl {
+		r.writeRecord(Record{
+			Type:      sectionMeta,
+			Time:      time.Now().Format(time.RFC3339Nano),
+			RequestID: rid,
+			Method:    c.Request.Method,
+			Path:      maskURLIfNeeded(c.Request.URL.String(), r.mask),
+			ClientIP:  c.ClientIP(),
+			Headers:   maskHeaders(c.Request.Header, r.mask),
+		})
+	} else if r.sectionEnabled(sectionMeta) {
 		r.writeLine("=== META ===")
 		r.writeLine(fmt.Sprintf("time=%s", time.Now().Format(time.RFC3339)))
 		r.writeLine(fmt.Sprintf("request_id=%s", rid))
@@ -204,18 +247,99 @@ func FromContext(c *gin.Context) *Recorder {
 	return rec
 }
 
+func openDumpFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	// #nosec G304 -- path is derived from configured dump dir and template.
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+}
+
+// Close writes out a deferred dump and closes the file.
 func (r *Recorder) Close() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.closed {
 		return
 	}
+	if r.buf != nil {
+		if err := r.spillLocked(); err != nil {
+			r.setErrLocked(err)
+			return
+		}
+	}
 	r.closed = true
 	if err := r.f.Close(); err != nil {
 		r.setErrLocked(err)
 	}
 }
 
+// Discard closes the recorder and removes its file, e.g. when the request is
+// not sampled. A deferred dump that never spilled is just dropped.
+// It requires a non-nil Recorder receiver.
+func (r *Recorder) Discard() {
+	r.mu.Lock()
+	if !r.closed && r.buf != nil {
+		r.buf = nil
+		r.closed = true
+	}
+	opened := r.f != nil
+	r.mu.Unlock()
+	if !opened {
+		return
+	}
+	r.Close()
+	if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.mu.Lock()
+		r.setErrLocked(err)
+		r.mu.Unlock()
+	}
+}
+
+// Drop discards the recorder attached to c, if any, and detaches it so the
+// remaining sections of the request are not recorded.
+// It requires a non-nil Gin context from the request handling path.
+func Drop(c *gin.Context) {
+	if r := FromContext(c); r != nil {
+		r.Discard()
+		c.Set(ctxKeyRecorder, (*Recorder)(nil))
+	}
+}
+
+// spillLocked moves a deferred dump from memory to its file; later writes go
+// to the file. On failure the recorder is closed.
+func (r *Recorder) spillLocked() error {
+	f, err := openDumpFile(r.path)
+	if err == nil {
+		r.f = f
+		_, err = f.Write(r.buf.Bytes())
+	}
+	r.buf = nil
+	if err != nil {
+		r.closed = true
+		if r.f != nil {
+			_ = r.f.Close()
+		}
+	}
+	return err
+}
+
+// writeLocked writes to the in-memory buffer of a deferred dump or the file.
+func (r *Recorder) writeLocked(b []byte) (int, error) {
+	if r.buf == nil {
+		return r.f.Write(b)
+	}
+	n, _ := r.buf.Write(b)
+	if r.buf.Len() > r.spillAt {
+		return n, r.spillLocked()
+	}
+	return n, nil
+}
+
+func (r *Recorder) writeStringLocked(s string) (int, error) {
+	return r.writeLocked([]byte(s))
+}
+
 // MaxBytes requires a non-nil Recorder receiver.
 func (r *Recorder) MaxBytes() int {
 	r.mu.Lock()
@@ -236,11 +360,11 @@ func (r *Recorder) writeLine(s string) {
 	if r.closed {
 		return
 	}
-	if _, err := r.f.WriteString(s); err != nil {
+	if _, err := r.writeStringLocked(s); err != nil {
 		r.setErrLocked(err)
 		return
 	}
-	if _, err := r.f.WriteString("\n"); err != nil {
+	if _, err := r.writeStringLocked("\n"); err != nil {
 		r.setErrLocked(err)
 	}
 }
@@ -251,47 +375,47 @@ func (r *Recorder) writeBlock(title string, content []byte, binary bool, truncat
 	if r.closed {
 		return
 	}
-	if _, err := r.f.WriteString(title); err != nil {
+	if _, err := r.writeStringLocked(title); err != nil {
 		r.setErrLocked(err)
 		return
 	}
-	if _, err := r.f.WriteString("\n"); err != nil {
+	if _, err := r.writeStringLocked("\n"); err != nil {
 		r.setErrLocked(err)
 		return
 	}
 	if binary {
-		if _, err := r.f.WriteString("[base64]\n"); err != nil {
+		if _, err := r.writeStringLocked("[base64]\n"); err != nil {
 			r.setErrLocked(err)
 			return
 		}
 		enc := base64.StdEncoding.EncodeToString(content)
-		if _, err := r.f.WriteString(enc); err != nil {
+		if _, err := r.writeStringLocked(enc); err != nil {
 			r.setErrLocked(err)
 			return
 		}
-		if _, err := r.f.WriteString("\n"); err != nil {
+		if _, err := r.writeStringLocked("\n"); err != nil {
 			r.setErrLocked(err)
 			return
 		}
 	} else {
-		if _, err := r.f.Write(content); err != nil {
+		if _, err := r.writeLocked(content); err != nil {
 			r.setErrLocked(err)
 			return
 		}
 		if len(content) == 0 || content[len(content)-1] != '\n' {
-			if _, err := r.f.WriteString("\n"); err != nil {
+			if _, err := r.writeStringLocked("\n"); err != nil {
 				r.setErrLocked(err)
 				return
 			}
 		}
 	}
 	if truncated {
-		if _, err := r.f.WriteString("[truncated]\n"); err != nil {
+		if _, err := r.writeStringLocked("[truncated]\n"); err != nil {
 			r.setErrLocked(err)
 			return
 		}
 	}
-	if _, err := r.f.WriteString("\n"); err != nil {
+	if _, err := r.writeStringLocked("\n"); err != nil {
 		r.setErrLocked(err)
 	}
 }
@@ -393,6 +517,17 @@ func maskURLIfNeeded(rawURL string, on bool) string {
 	return u.String()
 }
 
+// MaskHeaderValue redacts val when header key looks like a credential
+// (authorization, api keys, cookies, tokens), as traffic dumps do with mask_secrets.
+func MaskHeaderValue(key, val string) string {
+	return maskIfNeeded(key, val, true)
+}
+
+// MaskURL redacts credential-like query parameters (key, api_key, *token*, *secret*).
+func MaskURL(rawURL string) string {
+	return maskURLIfNeeded(rawURL, true)
+}
+
 func isBinaryByContentType(ct string) bool {
 	ct = strings.ToLower(strings.TrimSpace(ct))
 	return !strings.Contains(ct, "json") && !strings.HasPrefix(ct, "text/")
@@ -466,12 +601,20 @@ func AppendOriginRequest(c *gin.Context, body []byte, binary bool, truncated boo
 		}
 		if omitBinaryBodyForDump(path, ct) && (binary || isBinaryByContentType(ct)) {
 			summary := fmt.Sprintf("[binary body omitted] content_type=%s content_length=%d captured_bytes=%d", ct, c.Request.ContentLength, len(body))
+			if r.jsonl {
+				r.writeRecord(Record{Type: sectionOriginRequest, Body: &RecordBody{Omitted: summary, Size: len(body)}})
+				return
+			}
 			r.writeBlock("=== ORIGIN REQUEST ===", []byte(summary+"\n"), false, false)
 			return
 		}
 		if isImageEditPath(path) && !binary {
 			body = redactImageBase64Fields(body)
 		}
+		if r.jsonl {
+			r.writeRecord(Record{Type: sectionOriginRequest, Body: newRecordBody(body, binary, truncated)})
+			return
+		}
 		r.writeBlock("=== ORIGIN REQUEST ===", body, binary, truncated)
 	}
 }
@@ -489,31 +632,12 @@ func AppendUpstreamRequest(
 		if !r.sectionEnabled(sectionUpstreamRequest) {
 			return
 		}
-		r.writeLine("=== UPSTREAM REQUEST ===")
-		r.writeLine(fmt.Sprintf("%s %s", method, maskURLIfNeeded(url, r.mask)))
-		ct := ""
-		for k, vals := range headers {
-			for _, v := range vals {
-				r.writeLine(fmt.Sprintf("  %s: %s", k, maskIfNeeded(k, v, r.mask)))
-			}
-			if strings.EqualFold(k, "Content-Type") && len(vals) > 0 {
-				ct = vals[0]
-			}
-		}
-		r.writeLine("")
-		path := ""
-		if c != nil && c.Request != nil && c.Request.URL != nil {
-			path = c.Request.URL.Path
-		}
-		if omitBinaryBodyForDump(path, ct) && (binary || isBinaryByContentType(ct)) {
-			summary := fmt.Sprintf("[binary body omitted] content_type=%s captured_bytes=%d", ct, len(body))
-			r.writeBlock("", []byte(summary+"\n"), false, false)
-			return
-		}
-		if isImageEditPath(path) && !binary {
-			body = redactImageBase64Fields(body)
+		rec := Record{Type: sectionUpstreamRequest, Method: method, URL: maskURLIfNeeded(url, r.mask)}
+		if !r.jsonl {
+			r.writeLine("=== UPSTREAM REQUEST ===")
+			r.writeLine(fmt.Sprintf("%s %s", method, rec.URL))
 		}
-		r.writeBlock("", body, binary, truncated)
+		r.appendMessage(c, rec, headers, body, binary, truncated)
 	}
 }
 
@@ -529,32 +653,56 @@ func AppendUpstreamResponse(
 		if !r.sectionEnabled(sectionUpstreamResp) {
 			return
 		}
-		r.writeLine("=== UPSTREAM RESPONSE ===")
-		r.writeLine(statusLine)
-		ct := ""
-		for k, vals := range headers {
-			for _, v := range vals {
-				r.writeLine(fmt.Sprintf("  %s: %s", k, maskIfNeeded(k, v, r.mask)))
-			}
-			if strings.EqualFold(k, "Content-Type") && len(vals) > 0 {
-				ct = vals[0]
-			}
+		rec := Record{Type: sectionUpstreamResp, StatusLine: statusLine, Status: statusCodeOf(statusLine)}
+		if !r.jsonl {
+			r.writeLine("=== UPSTREAM RESPONSE ===")
+			r.writeLine(statusLine)
 		}
-		r.writeLine("")
-		path := ""
-		if c != nil && c.Request != nil && c.Request.URL != nil {
-			path = c.Request.URL.Path
+		r.appendMessage(c, rec, headers, body, binary, truncated)
+	}
+}
+
+// appendMessage writes the headers and body of an upstream request/response
+// after its first line (text) or as rec (jsonl).
+func (r *Recorder) appendMessage(c *gin.Context, rec Record, headers map[string][]string, body []byte, binary bool, truncated bool) {
+	ct := ""
+	for k, vals := range headers {
+		if strings.EqualFold(k, "Content-Type") && len(vals) > 0 {
+			ct = vals[0]
 		}
-		if omitBinaryBodyForDump(path, ct) && (binary || isBinaryByContentType(ct)) {
-			summary := fmt.Sprintf("[binary body omitted] content_type=%s captured_bytes=%d", ct, len(body))
-			r.writeBlock("", []byte(summary+"\n"), false, false)
-			return
+	}
+	path := ""
+	if c != nil && c.Request != nil && c.Request.URL != nil {
+		path = c.Request.URL.Path
+	}
+	var summary string
+	if omitBinaryBodyForDump(path, ct) && (binary || isBinaryByContentType(ct)) {
+		summary = fmt.Sprintf("[binary body omitted] content_type=%s captured_bytes=%d", ct, len(body))
+	} else if isImageEditPath(path) && !binary {
+		body = redactImageBase64Fields(body)
+	}
+
+	if r.jsonl {
+		rec.Headers = maskHeaders(headers, r.mask)
+		if summary != "" {
+			rec.Body = &RecordBody{Omitted: summary, Size: len(body)}
+		} else {
+			rec.Body = newRecordBody(body, binary, truncated)
 		}
-		if isImageEditPath(path) && !binary {
-			body = redactImageBase64Fields(body)
+		r.writeRecord(rec)
+		return
+	}
+	for k, vals := range headers {
+		for _, v := range vals {
+			r.writeLine(fmt.Sprintf("  %s: %s", k, maskIfNeeded(k, v, r.mask)))
 		}
-		r.writeBlock("", body, binary, truncated)
 	}
+	r.writeLine("")
+	if summary != "" {
+		r.writeBlock("", []byte(summary+"\n"), false, false)
+		return
+	}
+	r.writeBlock("", body, binary, truncated)
 }
 
 func AppendProxyResponse(c *gin.Context, body []byte, binary bool, truncated bool, statusCode int) {
@@ -562,9 +710,11 @@ func AppendProxyResponse(c *gin.Context, body []byte, binary bool, truncated boo
 		if !r.sectionEnabled(sectionProxyResponse) {
 			return
 		}
-		r.writeLine("=== PROXY RESPONSE ===")
-		r.writeLine(fmt.Sprintf("status=%d", statusCode))
-		r.writeLine("")
+		if !r.jsonl {
+			r.writeLine("=== PROXY RESPONSE ===")
+			r.writeLine(fmt.Sprintf("status=%d", statusCode))
+			r.writeLine("")
+		}
 		ct := ""
 		path := ""
 		if c != nil && c.Writer != nil {
@@ -575,12 +725,20 @@ func AppendProxyResponse(c *gin.Context, body []byte, binary bool, truncated boo
 		}
 		if omitBinaryBodyForDump(path, ct) && (binary || isBinaryByContentType(ct)) {
 			summary := fmt.Sprintf("[binary body omitted] content_type=%s captured_bytes=%d", ct, len(body))
+			if r.jsonl {
+				r.writeRecord(Record{Type: sectionProxyResponse, Status: statusCode, Body: &RecordBody{Omitted: summary, Size: len(body)}})
+				return
+			}
 			r.writeBlock("", []byte(summary+"\n"), false, false)
 			return
 		}
 		if isImageEditPath(path) && !binary {
 			body = redactImageBase64Fields(body)
 		}
+		if r.jsonl {
+			r.writeRecord(Record{Type: sectionProxyResponse, Status: statusCode, Body: newRecordBody(body, binary, truncated)})
+			return
+		}
 		r.writeBlock("", body, binary, truncated)
 	}
 }
@@ -592,6 +750,15 @@ func AppendStreamSummary(c *gin.Context, bytesCopied int64, errMsg string, ignor
 		if !r.sectionEnabled(sectionStream) {
 			return
 		}
+		if r.jsonl {
+			r.writeRecord(Record{
+				Type:                    sectionStream,
+				BytesCopied:             &bytesCopied,
+				Error:                   strings.TrimSpace(errMsg),
+				IgnoredClientDisconnect: ignoredClientDisconnect,
+			})
+			return
+		}
 		r.writeLine("=== STREAM ===")
 		r.writeLine(fmt.Sprintf("bytes_copied=%d", bytesCopied))
 		if strings.TrimSpace(errMsg) != "" {
@@ -604,6 +771,24 @@ func AppendStreamSummary(c *gin.Context, bytesCopied int64, errMsg string, ignor
 	}
 }
 
+// AppendGuardHits appends the DSL guard rules that matched in one phase (request or response).
+// hits is the access-log rendering, so matched text never reaches the dump from here.
+func AppendGuardHits(c *gin.Context, phase string, hits string) {
+	if r := FromContext(c); r != nil {
+		if !r.sectionEnabled(sectionGuard) || strings.TrimSpace(hits) == "" {
+			return
+		}
+		if r.jsonl {
+			r.writeRecord(Record{Type: sectionGuard, Phase: phase, Hits: hits})
+			return
+		}
+		r.writeLine("=== GUARD ===")
+		r.writeLine(fmt.Sprintf("phase=%s", phase))
+		r.writeLine(fmt.Sprintf("hits=%s", hits))
+		r.writeLine("")
+	}
+}
+
 func LimitBytes(b []byte, max int) (out []byte, truncated bool) {
 	if max <= 0 {
 		return nil, false
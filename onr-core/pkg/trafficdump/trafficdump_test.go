@@ -296,6 +296,7 @@ func TestRecorderAppendSections_BinaryAndStreamSummary(t *testing.T) {
 
 	AppendProxyResponse(gc, []byte{0x20, 0x21, 0x22}, true, false, 200)
 	AppendStreamSummary(gc, 123, "context canceled", true)
+	AppendGuardHits(gc, "response", "response:email=log")
 
 	rec.Close()
 
@@ -328,6 +329,9 @@ func TestRecorderAppendSections_BinaryAndStreamSummary(t *testing.T) {
 	if !strings.Contains(s, "bytes_copied=123") || !strings.Contains(s, "ignored_client_disconnect=true") {
 		t.Fatalf("expected stream summary fields:\n%s", s)
 	}
+	if !strings.Contains(s, "=== GUARD ===\nphase=response\nhits=response:email=log") {
+		t.Fatalf("expected guard section:\n%s", s)
+	}
 }
 
 func TestRequestID_PrefersHeaderWhenPresent(t *testing.T) {
@@ -0,0 +1,248 @@
+package usageestimate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ChatTemplateChatML   = "chatml"
+	ChatTemplateLlama3   = "llama3"
+	ChatTemplateDeepSeek = "deepseek"
+	ChatTemplateGLM      = "glm"
+	ChatTemplateNone     = "none"
+)
+
+// ChatTemplate approximates a model family's chat template: the fixed
+// framing tokens wrapped around each turn. It does not try to reproduce the
+// Jinja templates byte for byte, only their token overhead.
+type ChatTemplate struct {
+	Family string
+	// Begin is emitted once before the first input turn.
+	Begin string
+	// Turn wraps every message; {role} and {content} are substituted.
+	Turn string
+	// Roles maps normalized roles (system/user/assistant/tool) to the role
+	// string the template emits. Unmapped roles are used as is.
+	Roles map[string]string
+	// ToolsPrefix introduces the tool definitions, rendered as a system turn.
+	ToolsPrefix string
+	// ToolCall wraps each assistant tool call; {name} and {arguments} are substituted.
+	ToolCall string
+	// GenerationPrompt is appended to the input to open the assistant turn.
+	GenerationPrompt string
+	// OutputSuffix is the end-of-turn marker the model emits after its output.
+	OutputSuffix string
+}
+
+var chatTemplates = map[string]ChatTemplate{
+	ChatTemplateChatML: {
+		Family:           ChatTemplateChatML,
+		Turn:             "<|im_start|>{role}\n{content}<|im_end|>\n",
+		ToolsPrefix:      "# Tools\n\nYou may call one or more functions to assist with the user query.\n\n<tools>\n",
+		ToolCall:         "<tool_call>\n{\"name\": \"{name}\", \"arguments\": {arguments}}\n</tool_call>",
+		GenerationPrompt: "<|im_start|>assistant\n",
+		OutputSuffix:     "<|im_end|>",
+	},
+	ChatTemplateLlama3: {
+		Family:           ChatTemplateLlama3,
+		Begin:            "<|begin_of_text|>",
+		Turn:             "<|start_header_id|>{role}<|end_header_id|>\n\n{content}<|eot_id|>",
+		Roles:            map[string]string{"tool": "ipython"},
+		ToolsPrefix:      "You have access to the following functions:\n\n",
+		ToolCall:         "{\"name\": \"{name}\", \"parameters\": {arguments}}",
+		GenerationPrompt: "<|start_header_id|>assistant<|end_header_id|>\n\n",
+		OutputSuffix:     "<|eot_id|>",
+	},
+	ChatTemplateDeepSeek: {
+		Family:           ChatTemplateDeepSeek,
+		Begin:            "<｜begin▁of▁sentence｜>",
+		Turn:             "{role}{content}",
+		Roles:            map[string]string{"system": "", "user": "<｜User｜>", "assistant": "<｜Assistant｜>", "tool": "<｜tool▁output▁begin｜>"},
+		ToolsPrefix:      "## Tools\n\nYou have access to the following tools:\n\n",
+		ToolCall:         "<｜tool▁call▁begin｜>function<｜tool▁sep｜>{name}\n```json\n{arguments}\n```<｜tool▁call▁end｜>",
+		GenerationPrompt: "<｜Assistant｜>",
+		OutputSuffix:     "<｜end▁of▁sentence｜>",
+	},
+	ChatTemplateGLM: {
+		Family:           ChatTemplateGLM,
+		Begin:            "[gMASK]<sop>",
+		Turn:             "<|{role}|>\n{content}",
+		Roles:            map[string]string{"tool": "observation"},
+		ToolsPrefix:      "# Tools\n\nYou may call one or more functions to assist with the user query.\n\n<tools>\n",
+		ToolCall:         "<tool_call>{name}\n{arguments}</tool_call>",
+		GenerationPrompt: "<|assistant|>",
+		OutputSuffix:     "<|user|>",
+	},
+	ChatTemplateNone: {
+		Family: ChatTemplateNone,
+		Turn:   "{content}",
+	},
+}
+
+// ChatTemplateFamilies returns the supported chat template family names.
+func ChatTemplateFamilies() []string {
+	out := make([]string, 0, len(chatTemplates))
+	for name := range chatTemplates {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ChatTemplateForModel returns the template for family, inferring it from
+// the model name when family is empty. Unknown models fall back to chatml,
+// which most open-weights chat models (Qwen, Yi, Mistral fine-tunes) use.
+func ChatTemplateForModel(family, model string) (ChatTemplate, error) {
+	family = strings.ToLower(strings.TrimSpace(family))
+	if family == "" {
+		family = inferChatTemplateFamily(model)
+	}
+	tpl, ok := chatTemplates[family]
+	if !ok {
+		return ChatTemplate{}, fmt.Errorf("unknown chat template %q", family)
+	}
+	return tpl, nil
+}
+
+func inferChatTemplateFamily(model string) string {
+	m := strings.ToLower(model)
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	switch {
+	case strings.Contains(m, "llama-3"), strings.Contains(m, "llama3"):
+		return ChatTemplateLlama3
+	case strings.Contains(m, "deepseek"):
+		return ChatTemplateDeepSeek
+	case strings.Contains(m, "glm"):
+		return ChatTemplateGLM
+	default:
+		return ChatTemplateChatML
+	}
+}
+
+func (tpl ChatTemplate) role(role string) string {
+	switch role {
+	case "developer":
+		role = "system"
+	case "model":
+		role = "assistant"
+	case "function":
+		role = "tool"
+	}
+	if mapped, ok := tpl.Roles[role]; ok {
+		return mapped
+	}
+	return role
+}
+
+func (tpl ChatTemplate) turn(role, content string) string {
+	return strings.NewReplacer("{role}", tpl.role(role), "{content}", content).Replace(tpl.Turn)
+}
+
+func (tpl ChatTemplate) toolCall(name string, arguments any) string {
+	if tpl.ToolCall == "" {
+		return name + " " + templateArguments(arguments)
+	}
+	return strings.NewReplacer("{name}", name, "{arguments}", templateArguments(arguments)).Replace(tpl.ToolCall)
+}
+
+// Render builds the prompt text the model sees for ectx.
+func (tpl ChatTemplate) Render(ectx *EstimateContext) string {
+	var b strings.Builder
+	if ectx.Direction == EstimateOutput {
+		for _, msg := range ectx.Messages {
+			b.WriteString(messageTemplateContent(tpl, msg))
+		}
+		for _, text := range ectx.Texts {
+			b.WriteString(text.Text)
+		}
+		if b.Len() > 0 {
+			b.WriteString(tpl.OutputSuffix)
+		}
+		return b.String()
+	}
+
+	b.WriteString(tpl.Begin)
+	if tools := tpl.renderTools(ectx.Tools); tools != "" {
+		b.WriteString(tpl.turn("system", tools))
+	}
+	for _, msg := range ectx.Messages {
+		b.WriteString(tpl.turn(msg.Role, messageTemplateContent(tpl, msg)))
+	}
+	for _, text := range ectx.Texts {
+		b.WriteString(tpl.turn("system", text.Text))
+	}
+	b.WriteString(tpl.GenerationPrompt)
+	return b.String()
+}
+
+func (tpl ChatTemplate) renderTools(tools []EstimateTool) string {
+	if len(tools) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(tpl.ToolsPrefix)
+	for _, tool := range tools {
+		def := map[string]any{"name": tool.Name}
+		if tool.Description != "" {
+			def["description"] = tool.Description
+		}
+		if tool.Definition != "" {
+			def["definition"] = tool.Definition
+		}
+		def["parameters"] = tool.Parameters
+		raw, err := json.Marshal(map[string]any{"type": "function", "function": def})
+		if err != nil {
+			continue
+		}
+		b.Write(raw)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func messageTemplateContent(tpl ChatTemplate, msg EstimateMessage) string {
+	parts := make([]string, 0, len(msg.Content)+len(msg.ToolCalls))
+	for _, item := range msg.Content {
+		switch item.Type {
+		case "tool_use", "server_tool_use":
+			parts = append(parts, tpl.toolCall(item.Name, item.Arguments))
+		default:
+			if item.Text != "" {
+				parts = append(parts, item.Text)
+			}
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		parts = append(parts, tpl.toolCall(call.Name, call.Arguments))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func templateArguments(arguments any) string {
+	switch v := arguments.(type) {
+	case nil:
+		return "{}"
+	case string:
+		return v
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}"
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "{}"
+		}
+		return string(raw)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(raw)
+	}
+}
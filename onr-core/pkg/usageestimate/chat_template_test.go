@@ -0,0 +1,89 @@
+package usageestimate
+
+import "testing"
+
+func TestChatTemplateForModel_InfersFamily(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		model string
+		want  string
+	}{
+		{model: "Qwen/Qwen2.5-72B-Instruct", want: ChatTemplateChatML},
+		{model: "meta-llama/Meta-Llama-3.1-70B-Instruct", want: ChatTemplateLlama3},
+		{model: "deepseek-ai/DeepSeek-V3", want: ChatTemplateDeepSeek},
+		{model: "THUDM/glm-4-9b-chat", want: ChatTemplateGLM},
+		{model: "some-unknown-model", want: ChatTemplateChatML},
+	}
+	for _, tc := range cases {
+		tpl, err := ChatTemplateForModel("", tc.model)
+		if err != nil {
+			t.Fatalf("ChatTemplateForModel(%q): %v", tc.model, err)
+		}
+		if tpl.Family != tc.want {
+			t.Fatalf("model=%q family=%q want=%q", tc.model, tpl.Family, tc.want)
+		}
+	}
+	if _, err := ChatTemplateForModel("jinja", "x"); err == nil {
+		t.Fatalf("expected unknown family error")
+	}
+}
+
+func TestChatTemplateRender_ChatML(t *testing.T) {
+	t.Parallel()
+
+	tpl, _ := ChatTemplateForModel(ChatTemplateChatML, "")
+	ectx := NewEstimateContext("qwen", apiChatCompletions, EstimateInput)
+	ectx.Messages = []EstimateMessage{
+		{Role: "developer", Content: []EstimateMessagesContent{{Type: "text", Text: "be brief"}}},
+		{Role: "user", Content: []EstimateMessagesContent{{Type: "text", Text: "hello"}}},
+	}
+	want := "<|im_start|>system\nbe brief<|im_end|>\n<|im_start|>user\nhello<|im_end|>\n<|im_start|>assistant\n"
+	if got := tpl.Render(ectx); got != want {
+		t.Fatalf("Render=%q want=%q", got, want)
+	}
+
+	ectx.Direction = EstimateOutput
+	ectx.Messages = []EstimateMessage{{Role: "assistant", Content: []EstimateMessagesContent{{Type: "text", Text: "hi"}}}}
+	if got := tpl.Render(ectx); got != "hi<|im_end|>" {
+		t.Fatalf("output Render=%q", got)
+	}
+}
+
+func TestEstimate_UsesConfiguredOpenSourceTokenizer(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestTokenizer(t, testByteLevelBPE)
+	cfg := &Config{Tokenizers: []TokenizerConfig{{Model: "qwen/*", Path: dir}}}
+	ApplyDefaults(cfg)
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	out := Estimate(cfg, Input{
+		API:          apiChatCompletions,
+		Model:        "Qwen/Qwen2.5-7B-Instruct",
+		RequestBody:  []byte(`{"model":"Qwen/Qwen2.5-7B-Instruct","messages":[{"role":"user","content":"hello world"}]}`),
+		ResponseBody: []byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`),
+	})
+	if out.Usage == nil || out.Stage != StageEstimateBoth {
+		t.Fatalf("out=%+v", out)
+	}
+	m, err := cfg.OpenSourceModel("Qwen/Qwen2.5-7B-Instruct")
+	if err != nil || m == nil {
+		t.Fatalf("OpenSourceModel: m=%v err=%v", m, err)
+	}
+	tok := m.Tokenizer
+	wantInput := tok.Count("<|im_start|>user\nhello world<|im_end|>\n<|im_start|>assistant\n")
+	if out.Usage.InputTokens != wantInput {
+		t.Fatalf("input=%d want=%d", out.Usage.InputTokens, wantInput)
+	}
+	// "hello" + <|im_end|>.
+	if out.Usage.OutputTokens != 2 {
+		t.Fatalf("output=%d want=2", out.Usage.OutputTokens)
+	}
+
+	if m, err := cfg.OpenSourceModel("claude-sonnet-4"); m != nil || err != nil {
+		t.Fatalf("unmatched model should fall back to closed-source estimation")
+	}
+}
@@ -16,10 +16,19 @@ type cliOptions struct {
 	direction string
 	body      string
 	bodyFile  string
+
+	tokenizer    string
+	chatTemplate string
+	compare      bool
+	dumps        []string
+	// apiSet reports an explicit --api, which overrides the API inferred from dumps.
+	apiSet bool
 }
 
 // RunCLI estimates token count for a request or response body.
-// Body input can come from --body, --body-file, or stdin.
+// Body input can come from --body, --body-file, or stdin. With --compare,
+// positional traffic dump files are estimated and compared against the usage
+// the upstream reported.
 func RunCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 	opts, err := parseCLIOptions(args, stderr)
 	if err != nil {
@@ -29,6 +38,19 @@ func RunCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return 2
 	}
 
+	cfg := &Config{}
+	ApplyDefaults(cfg)
+	if opts.tokenizer != "" {
+		cfg.Tokenizers = []TokenizerConfig{{Model: "*", Path: opts.tokenizer, ChatTemplate: opts.chatTemplate}}
+		if err := Validate(cfg); err != nil {
+			_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+			return 2
+		}
+	}
+	if opts.compare {
+		return runCompare(opts, cfg, opts.dumps, stdout, stderr)
+	}
+
 	bodyBytes, source, err := readCLIBody(opts, stdin)
 	if err != nil {
 		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
@@ -36,7 +58,16 @@ func RunCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 	}
 
 	body := parseCLIBodyValue(bodyBytes)
-	tokens, err := EstimateToken(opts.model, opts.api, body, parseEstimateDirection(opts.direction))
+	openSource, err := cfg.OpenSourceModel(opts.model)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	if opts.tokenizer != "" && openSource == nil {
+		_, _ = fmt.Fprintf(stderr, "error: load tokenizer %s failed\n", opts.tokenizer)
+		return 1
+	}
+	tokens, err := EstimateToken(opts.model, opts.api, body, parseEstimateDirection(opts.direction), WithOpenSourceModel(openSource))
 	if err != nil {
 		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
 		return 1
@@ -47,6 +78,10 @@ func RunCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 	_, _ = fmt.Fprintf(stdout, "api=%s\n", opts.api)
 	_, _ = fmt.Fprintf(stdout, "direction=%s\n", parseEstimateDirection(opts.direction))
 	_, _ = fmt.Fprintf(stdout, "source=%s\n", source)
+	if openSource != nil {
+		_, _ = fmt.Fprintf(stdout, "tokenizer=%s\n", opts.tokenizer)
+		_, _ = fmt.Fprintf(stdout, "chat_template=%s\n", openSource.Template.Family)
+	}
 	return 0
 }
 
@@ -60,9 +95,33 @@ func parseCLIOptions(args []string, stderr io.Writer) (cliOptions, error) {
 	fs.StringVar(&opts.direction, "direction", string(EstimateInput), "estimate direction: input or output")
 	fs.StringVar(&opts.body, "body", "", "request or response body JSON/text")
 	fs.StringVar(&opts.bodyFile, "body-file", "", "path to request or response body file")
+	fs.StringVar(&opts.tokenizer, "tokenizer", "", "HuggingFace tokenizer.json file or directory (open-weights models)")
+	fs.StringVar(&opts.chatTemplate, "chat-template", "", "chat template family for --tokenizer: "+strings.Join(ChatTemplateFamilies(), ", ")+" (default: inferred from model)")
+	fs.BoolVar(&opts.compare, "compare", false, "compare estimates against upstream usage recorded in the traffic dump files given as arguments")
 	if err := fs.Parse(args); err != nil {
 		return opts, err
 	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "api" {
+			opts.apiSet = true
+		}
+	})
+	if opts.chatTemplate != "" && opts.tokenizer == "" {
+		return opts, errors.New("--chat-template requires --tokenizer")
+	}
+	if opts.compare {
+		opts.dumps = fs.Args()
+		if len(opts.dumps) == 0 {
+			return opts, errors.New("--compare requires at least one traffic dump file")
+		}
+		if opts.body != "" || opts.bodyFile != "" {
+			return opts, errors.New("--compare cannot be used with --body or --body-file")
+		}
+		return opts, nil
+	}
+	if fs.NArg() != 0 {
+		return opts, fmt.Errorf("unexpected positional arguments: %s", strings.Join(fs.Args(), " "))
+	}
 	if strings.TrimSpace(opts.model) == "" {
 		return opts, errors.New("model is required")
 	}
@@ -0,0 +1,256 @@
+package usageestimate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+)
+
+// recordedUsage is the usage an upstream reported in a traffic dump.
+type recordedUsage struct {
+	input, output int
+}
+
+type compareTotals struct {
+	files, compared           int
+	inputErrPct, outputErrPct float64
+	inputN, outputN           int
+}
+
+// runCompare estimates every dump in paths the way the proxy would (usage
+// missing, so both sides are estimated) and prints it next to the usage the
+// upstream actually reported.
+func runCompare(opts cliOptions, cfg *Config, paths []string, stdout, stderr io.Writer) int {
+	var totals compareTotals
+	for _, path := range paths {
+		totals.files++
+		d, err := trafficdump.ParseFile(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "error: %s: %v\n", path, err)
+			continue
+		}
+		line, ok := compareDump(opts, cfg, path, d, &totals)
+		_, _ = fmt.Fprintln(stdout, line)
+		if ok {
+			totals.compared++
+		}
+	}
+	_, _ = fmt.Fprintf(stdout, "files=%d compared=%d input_mean_abs_err=%s output_mean_abs_err=%s\n",
+		totals.files, totals.compared, meanPct(totals.inputErrPct, totals.inputN), meanPct(totals.outputErrPct, totals.outputN))
+	if totals.compared == 0 {
+		return 1
+	}
+	return 0
+}
+
+func compareDump(opts cliOptions, cfg *Config, path string, d *trafficdump.Dump, totals *compareTotals) (string, bool) {
+	reqBody := dumpBody(d.OriginRequest)
+	if len(reqBody) == 0 && d.UpstreamRequest != nil {
+		reqBody = d.UpstreamRequest.Body.Data
+	}
+	api := strings.TrimSpace(opts.api)
+	if !opts.apiSet {
+		if inferred := apiFromDumpPath(d.Path); inferred != "" {
+			api = inferred
+		}
+	}
+	model := strings.TrimSpace(opts.model)
+	if model == "" {
+		model = modelFromDump(d.Path, reqBody)
+	}
+	if model == "" {
+		return fmt.Sprintf("file=%s skipped=missing_model", path), false
+	}
+
+	respBody := dumpBody(d.ProxyResponse)
+	if len(respBody) == 0 && d.UpstreamResponse != nil {
+		respBody = d.UpstreamResponse.Body.Data
+	}
+	var recorded recordedUsage
+	ok := false
+	if d.UpstreamResponse != nil {
+		recorded, ok = recordedUsageFromBody(d.UpstreamResponse.Body.Data)
+	}
+	if !ok {
+		recorded, ok = recordedUsageFromBody(dumpBody(d.ProxyResponse))
+	}
+	if !ok {
+		return fmt.Sprintf("file=%s api=%s model=%s skipped=missing_upstream_usage", path, api, model), false
+	}
+
+	in := Input{API: api, Model: model, RequestBody: reqBody}
+	if isSSEBody(respBody) {
+		in.StreamTail = respBody
+	} else {
+		in.ResponseBody = respBody
+	}
+	estimateCfg := *cfg
+	estimateCfg.APIs = []string{api}
+	est := Estimate(&estimateCfg, in).Usage
+	if est == nil {
+		return fmt.Sprintf("file=%s api=%s model=%s skipped=estimate_failed", path, api, model), false
+	}
+
+	inputErr, inputOK := errPct(est.InputTokens, recorded.input)
+	outputErr, outputOK := errPct(est.OutputTokens, recorded.output)
+	if inputOK {
+		totals.inputErrPct += math.Abs(inputErr)
+		totals.inputN++
+	}
+	if outputOK {
+		totals.outputErrPct += math.Abs(outputErr)
+		totals.outputN++
+	}
+	return fmt.Sprintf("file=%s api=%s model=%s input_est=%d input_upstream=%d input_diff=%d input_err=%s output_est=%d output_upstream=%d output_diff=%d output_err=%s",
+		path, api, model,
+		est.InputTokens, recorded.input, est.InputTokens-recorded.input, formatPct(inputErr, inputOK),
+		est.OutputTokens, recorded.output, est.OutputTokens-recorded.output, formatPct(outputErr, outputOK)), true
+}
+
+func dumpBody(b *trafficdump.Body) []byte {
+	if b == nil || b.Omitted {
+		return nil
+	}
+	return b.Data
+}
+
+// apiFromDumpPath maps the origin request URI to an API name.
+func apiFromDumpPath(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return apiChatCompletions
+	case strings.HasSuffix(path, "/responses"):
+		return apiResponses
+	case strings.HasSuffix(path, "/messages"):
+		return apiMessages
+	case strings.HasSuffix(path, "/embeddings"):
+		return apiEmbeddings
+	case strings.HasSuffix(path, ":streamGenerateContent"):
+		return "gemini.streamGenerateContent"
+	case strings.HasSuffix(path, ":generateContent"):
+		return "gemini.generateContent"
+	default:
+		return ""
+	}
+}
+
+func modelFromDump(path string, reqBody []byte) string {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(reqBody, &req) == nil && strings.TrimSpace(req.Model) != "" {
+		return strings.TrimSpace(req.Model)
+	}
+	// Gemini carries the model in the URI: /v1beta/models/{model}:generateContent.
+	path, _, _ = strings.Cut(path, "?")
+	if _, rest, ok := strings.Cut(path, "/models/"); ok {
+		model, _, _ := strings.Cut(rest, ":")
+		return model
+	}
+	return ""
+}
+
+func isSSEBody(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return bytes.HasPrefix(body, []byte("data:")) || bytes.HasPrefix(body, []byte("event:"))
+}
+
+// recordedUsageFromBody reads usage from a JSON or SSE response body. For
+// streams each field keeps its last non-zero value, since Anthropic reports
+// input tokens in message_start and output tokens in message_delta.
+func recordedUsageFromBody(body []byte) (recordedUsage, bool) {
+	var out recordedUsage
+	found := false
+	visit := func(raw []byte) {
+		var obj map[string]any
+		if json.Unmarshal(raw, &obj) != nil {
+			return
+		}
+		in, outTokens, ok := usageFromObject(obj)
+		if !ok {
+			return
+		}
+		found = true
+		if in > 0 {
+			out.input = in
+		}
+		if outTokens > 0 {
+			out.output = outTokens
+		}
+	}
+	if !isSSEBody(body) {
+		visit(bytes.TrimSpace(body))
+		return out, found
+	}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			visit(bytes.TrimSpace(data))
+		}
+	}
+	return out, found
+}
+
+func usageFromObject(obj map[string]any) (int, int, bool) {
+	if meta, ok := obj["usageMetadata"].(map[string]any); ok {
+		in, _ := intFromAny(meta["promptTokenCount"])
+		candidates, _ := intFromAny(meta["candidatesTokenCount"])
+		thoughts, _ := intFromAny(meta["thoughtsTokenCount"])
+		return in, candidates + thoughts, true
+	}
+	usage, ok := obj["usage"].(map[string]any)
+	if !ok {
+		for _, key := range []string{"response", "message"} {
+			if nested, isMap := obj[key].(map[string]any); isMap {
+				if usage, ok = nested["usage"].(map[string]any); ok {
+					break
+				}
+			}
+		}
+	}
+	if !ok {
+		return 0, 0, false
+	}
+	in := firstUsageInt(usage, "prompt_tokens", "input_tokens")
+	// Anthropic reports cached input separately from input_tokens.
+	if _, ok := usage["prompt_tokens"]; !ok {
+		in += firstUsageInt(usage, "cache_read_input_tokens") + firstUsageInt(usage, "cache_creation_input_tokens")
+	}
+	return in, firstUsageInt(usage, "completion_tokens", "output_tokens"), true
+}
+
+func firstUsageInt(usage map[string]any, keys ...string) int {
+	for _, key := range keys {
+		if n, ok := intFromAny(usage[key]); ok {
+			return n
+		}
+	}
+	return 0
+}
+
+func errPct(est, upstream int) (float64, bool) {
+	if upstream <= 0 {
+		return 0, false
+	}
+	return float64(est-upstream) / float64(upstream) * 100, true
+}
+
+func formatPct(v float64, ok bool) string {
+	if !ok {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", v)
+}
+
+func meanPct(sum float64, n int) string {
+	if n == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", sum/float64(n))
+}
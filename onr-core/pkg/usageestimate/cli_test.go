@@ -113,6 +113,69 @@ func TestRunCLI_RejectsBodyAndBodyFileTogether(t *testing.T) {
 	}
 }
 
+func TestRunCLI_Tokenizer(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	code := RunCLI([]string{
+		"--model", "Qwen/Qwen2.5-7B-Instruct",
+		"--api", apiChatCompletions,
+		"--tokenizer", writeTestTokenizer(t, testByteLevelBPE),
+		"--body", "hello world",
+	}, strings.NewReader(""), &stdout, &stderr)
+
+	if code != 0 {
+		t.Fatalf("code=%d stderr=%q", code, stderr.String())
+	}
+	assertCLIOutputContains(t, stdout.String(), "tokens=2\n", "chat_template=chatml")
+}
+
+func TestRunCLI_CompareDumps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dump := "=== META ===\npath=/v1/chat/completions\n\n" +
+		"=== ORIGIN REQUEST ===\n" + `{"model":"Qwen/Qwen2.5-7B-Instruct","messages":[{"role":"user","content":"hello world"}]}` + "\n\n" +
+		"=== UPSTREAM RESPONSE ===\n200 OK\n\n\n" +
+		`{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":9,"completion_tokens":3}}` + "\n\n"
+	path := filepath.Join(dir, "rid.log")
+	if err := os.WriteFile(path, []byte(dump), 0o600); err != nil {
+		t.Fatalf("write dump: %v", err)
+	}
+	noUsage := filepath.Join(dir, "no_usage.log")
+	if err := os.WriteFile(noUsage, []byte("=== META ===\npath=/v1/chat/completions\n\n=== ORIGIN REQUEST ===\n{\"model\":\"m\"}\n\n"), 0o600); err != nil {
+		t.Fatalf("write dump: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := RunCLI([]string{
+		"--compare",
+		"--tokenizer", writeTestTokenizer(t, testByteLevelBPE),
+		path, noUsage,
+	}, strings.NewReader(""), &stdout, &stderr)
+
+	if code != 0 {
+		t.Fatalf("code=%d stdout=%q stderr=%q", code, stdout.String(), stderr.String())
+	}
+	assertCLIOutputContains(t, stdout.String(),
+		"file="+path+" api=chat.completions model=Qwen/Qwen2.5-7B-Instruct",
+		"input_upstream=9",
+		"output_est=2 output_upstream=3 output_diff=-1 output_err=-33.3%",
+		"file="+noUsage+" api=chat.completions model=m skipped=missing_upstream_usage",
+		"files=2 compared=1",
+	)
+}
+
+func TestRunCLI_CompareRequiresDumps(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	code := RunCLI([]string{"--compare"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "at least one traffic dump") {
+		t.Fatalf("code=%d stderr=%q", code, stderr.String())
+	}
+}
+
 func assertCLIOutputContains(t *testing.T, output string, parts ...string) {
 	t.Helper()
 	for _, part := range parts {
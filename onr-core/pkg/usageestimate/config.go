@@ -2,6 +2,10 @@ package usageestimate
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -27,6 +31,29 @@ type Config struct {
 	// APIs defines which API types participate in estimation (e.g. "chat.completions").
 	// If empty, defaults will be applied.
 	APIs []string `yaml:"apis"`
+
+	// TokenizerDir is the base directory for open-weights tokenizers. Relative
+	// tokenizer paths resolve against it, and a model without an explicit
+	// mapping uses <tokenizer_dir>/<model>/tokenizer.json when present.
+	TokenizerDir string `yaml:"tokenizer_dir"`
+
+	// Tokenizers maps model globs to HuggingFace tokenizer.json files. The
+	// first matching entry wins.
+	Tokenizers []TokenizerConfig `yaml:"tokenizers"`
+}
+
+// TokenizerConfig maps a model glob to an open-weights tokenizer.
+type TokenizerConfig struct {
+	// Model is a case-insensitive glob; "*" matches any run of characters,
+	// including "/" (e.g. "Qwen/Qwen2.5-*", "*deepseek-v3*").
+	Model string `yaml:"model"`
+
+	// Path is a tokenizer.json file or a directory containing one.
+	Path string `yaml:"path"`
+
+	// ChatTemplate selects the template family (chatml, llama3, deepseek, glm, none).
+	// Empty infers it from the model name.
+	ChatTemplate string `yaml:"chat_template"`
 }
 
 func ApplyDefaults(cfg *Config) {
@@ -82,9 +109,92 @@ func Validate(cfg *Config) error {
 	if cfg.MaxStreamCollectBytes < 0 {
 		return errors.New("usage_estimation.max_stream_collect_bytes must be non-negative")
 	}
+	for i, tc := range cfg.Tokenizers {
+		if strings.TrimSpace(tc.Model) == "" {
+			return fmt.Errorf("usage_estimation.tokenizers[%d].model is required", i)
+		}
+		if strings.TrimSpace(tc.Path) == "" {
+			return fmt.Errorf("usage_estimation.tokenizers[%d].path is required", i)
+		}
+		if _, err := ChatTemplateForModel(tc.ChatTemplate, tc.Model); err != nil {
+			return fmt.Errorf("usage_estimation.tokenizers[%d].chat_template: %w", i, err)
+		}
+		if _, err := os.Stat(hfTokenizerFilePath(cfg.tokenizerPath(tc.Path))); err != nil {
+			return fmt.Errorf("usage_estimation.tokenizers[%d].path: %w", i, err)
+		}
+	}
 	return nil
 }
 
+func (c *Config) tokenizerPath(path string) string {
+	path = strings.TrimSpace(path)
+	dir := strings.TrimSpace(c.TokenizerDir)
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// OpenSourceModel returns the open-weights tokenizer configured for model, or
+// nil when none applies; callers then fall back to the closed-source profiles.
+// Tokenizers are loaded lazily and cached. A non-nil error reports a tokenizer
+// that failed to load; it is returned once per failed load, so callers can log
+// it without flooding.
+func (c *Config) OpenSourceModel(model string) (*OpenSourceModel, error) {
+	if c == nil {
+		return nil, nil
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil, nil
+	}
+	for _, tc := range c.Tokenizers {
+		if !matchModelGlob(tc.Model, model) {
+			continue
+		}
+		tpl, err := ChatTemplateForModel(tc.ChatTemplate, model)
+		if err != nil {
+			return nil, err
+		}
+		return loadOpenSourceModel(c.tokenizerPath(tc.Path), tpl)
+	}
+	dir := strings.TrimSpace(c.TokenizerDir)
+	if dir == "" || strings.Contains(model, "..") {
+		return nil, nil
+	}
+	tpl, _ := ChatTemplateForModel("", model)
+	m, err := loadOpenSourceModel(filepath.Join(dir, filepath.FromSlash(model), "tokenizer.json"), tpl)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Most models have no tokenizer under tokenizer_dir.
+		return nil, nil
+	}
+	return m, err
+}
+
+// matchModelGlob reports whether model matches pattern case-insensitively,
+// where "*" matches any (possibly empty) run of characters.
+func matchModelGlob(pattern, model string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	model = strings.ToLower(model)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == model
+	}
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(model, part)
+		if i < 0 {
+			return false
+		}
+		model = model[i+len(part):]
+	}
+	return len(model) >= len(last) && strings.HasSuffix(model, last)
+}
+
 // IsAPIEnabled requires a non-nil Config receiver.
 func (c *Config) IsAPIEnabled(api string) bool {
 	if !c.Enabled {
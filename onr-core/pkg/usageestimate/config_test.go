@@ -17,6 +17,10 @@ func TestValidate_TableDriven(t *testing.T) {
 		{name: "max_request_negative", cfg: Config{MaxRequestBytes: -1}, wantErr: true},
 		{name: "max_response_negative", cfg: Config{MaxResponseBytes: -1}, wantErr: true},
 		{name: "max_stream_negative", cfg: Config{MaxStreamCollectBytes: -1}, wantErr: true},
+		{name: "tokenizer_missing_model", cfg: Config{Tokenizers: []TokenizerConfig{{Path: "x"}}}, wantErr: true},
+		{name: "tokenizer_missing_path", cfg: Config{Tokenizers: []TokenizerConfig{{Model: "qwen*"}}}, wantErr: true},
+		{name: "tokenizer_missing_file", cfg: Config{Tokenizers: []TokenizerConfig{{Model: "qwen*", Path: "/nonexistent/tokenizer.json"}}}, wantErr: true},
+		{name: "tokenizer_bad_template", cfg: Config{Tokenizers: []TokenizerConfig{{Model: "qwen*", Path: ".", ChatTemplate: "jinja"}}}, wantErr: true},
 	}
 
 	for _, tc := range cases {
@@ -60,3 +64,26 @@ func TestIsAPIEnabled_TableDriven(t *testing.T) {
 		t.Fatalf("expected disabled config to disable api")
 	}
 }
+
+func TestMatchModelGlob(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		model   string
+		want    bool
+	}{
+		{pattern: "Qwen/Qwen2.5-*", model: "qwen/qwen2.5-72b-instruct", want: true},
+		{pattern: "*deepseek-v3*", model: "deepseek-ai/DeepSeek-V3-0324", want: true},
+		{pattern: "*", model: "anything/at/all", want: true},
+		{pattern: "glm-4", model: "glm-4", want: true},
+		{pattern: "glm-4", model: "glm-4-plus", want: false},
+		{pattern: "llama-*-instruct", model: "llama-3-70b", want: false},
+		{pattern: "a*a", model: "a", want: false},
+	}
+	for _, tc := range cases {
+		if got := matchModelGlob(tc.pattern, tc.model); got != tc.want {
+			t.Fatalf("matchModelGlob(%q, %q)=%v want=%v", tc.pattern, tc.model, got, tc.want)
+		}
+	}
+}
@@ -90,6 +90,9 @@ type EstimateContext struct {
 	// the encoder and NewCloseSourceTokenizer reuses it. When nil, token
 	// counting falls back to the profile's text-length counter.
 	TokenEncoder *tiktoken.Tiktoken
+	// OpenSource is optional. When set, GetTokenizers counts with the model's
+	// own tokenizer.json instead of the closed-source profiles.
+	OpenSource *OpenSourceModel
 
 	Messages      []EstimateMessage
 	ToolChoice    map[string]string
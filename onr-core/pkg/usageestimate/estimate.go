@@ -41,6 +41,9 @@ type Input struct {
 type Output struct {
 	Usage *dslconfig.Usage
 	Stage string
+	// TokenizerErr reports an open-weights tokenizer that failed to load; the
+	// estimate then used the closed-source profiles.
+	TokenizerErr error
 }
 
 type parsedRequestBody struct {
@@ -52,14 +55,25 @@ type parsedRequestBody struct {
 
 type Opt func(c *EstimateContext)
 
-func EstimateToken(model string, api string, body any, estimateDirection EstimateDirection) (int, error) {
-	return estimateTokenWithEncoder(model, api, body, estimateDirection, nil)
+// WithOpenSourceModel counts with an open-weights tokenizer instead of the
+// closed-source profiles. A nil model is ignored.
+func WithOpenSourceModel(m *OpenSourceModel) Opt {
+	return func(c *EstimateContext) {
+		c.OpenSource = m
+	}
+}
+
+func EstimateToken(model string, api string, body any, estimateDirection EstimateDirection, opts ...Opt) (int, error) {
+	return estimateTokenWithEncoder(model, api, body, estimateDirection, nil, opts...)
 }
 
-func estimateTokenWithEncoder(model string, api string, body any, estimateDirection EstimateDirection, tokenEncoder *tiktoken.Tiktoken) (int, error) {
+func estimateTokenWithEncoder(model string, api string, body any, estimateDirection EstimateDirection, tokenEncoder *tiktoken.Tiktoken, opts ...Opt) (int, error) {
 
 	ectx := NewEstimateContext(model, api, estimateDirection)
 	ectx.TokenEncoder = tokenEncoder
+	for _, opt := range opts {
+		opt(ectx)
+	}
 	tokenizer, err := GetTokenizers(ectx)
 	if err != nil {
 		return 0, err
@@ -134,13 +148,15 @@ func Estimate(cfg *Config, in Input) Output {
 	// States 1 and 3 estimate from an empty/all-zero base. State 2 reaches here
 	// only when one scalar token field is missing; keep existing upstream fields
 	// and estimate only the missing side.
+	model, tokenizerErr := cfg.OpenSourceModel(in.Model)
+	openSource := WithOpenSourceModel(model)
 	if outUsage.InputTokens == 0 {
 		inputTokens := 0
 		parsed := parseRequestBody(in.RequestBody, in.RequestRoot, cfg.MaxRequestBytes)
 		if parsed.root == nil {
-			inputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, parsed.raw, EstimateInput, in.TokenEncoder)
+			inputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, parsed.raw, EstimateInput, in.TokenEncoder, openSource)
 		} else {
-			inputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, parsed.root, EstimateInput, in.TokenEncoder)
+			inputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, parsed.root, EstimateInput, in.TokenEncoder, openSource)
 		}
 
 		if inputTokens >= 0 {
@@ -156,7 +172,7 @@ func Estimate(cfg *Config, in Input) Output {
 		if len(in.StreamTail) > 0 {
 			outputBody = in.StreamTail
 		}
-		outputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, outputBody, EstimateOutput, in.TokenEncoder)
+		outputTokens, _ = estimateTokenWithEncoder(in.Model, in.API, outputBody, EstimateOutput, in.TokenEncoder, openSource)
 		if outputTokens >= 0 {
 			estimateCompletionsSuccessed = true
 			outUsage.OutputTokens = outputTokens
@@ -164,7 +180,7 @@ func Estimate(cfg *Config, in Input) Output {
 		}
 	}
 	if !estimateCompletionsSuccessed && !estimatePromptSuccessed {
-		return Output{Usage: u, Stage: stage}
+		return Output{Usage: u, Stage: stage, TokenizerErr: tokenizerErr}
 	}
 
 	outUsage.TotalTokens = outUsage.InputTokens + outUsage.OutputTokens
@@ -178,7 +194,7 @@ func Estimate(cfg *Config, in Input) Output {
 		outStage = StageEstimateBoth
 	}
 
-	return Output{Usage: outUsage, Stage: outStage}
+	return Output{Usage: outUsage, Stage: outStage, TokenizerErr: tokenizerErr}
 
 }
 
@@ -0,0 +1,901 @@
+package usageestimate
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dlclark/regexp2"
+)
+
+const (
+	hfModelBPE     = "BPE"
+	hfModelUnigram = "Unigram"
+
+	// hfWordCacheLimit bounds the per-tokenizer pre-token count cache.
+	hfWordCacheLimit = 1 << 16
+	// hfUnigramUnkPenalty matches the HuggingFace unigram unknown-piece penalty.
+	hfUnigramUnkPenalty = 10.0
+)
+
+// gpt2PreTokenizePattern is the ByteLevel pre-tokenizer regex (use_regex=true).
+const gpt2PreTokenizePattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+
+// HFTokenizer counts tokens with a HuggingFace tokenizer.json vocabulary.
+// It supports BPE (byte-level and SentencePiece-style) and Unigram models with
+// the common normalizers and pre-tokenizers; Unicode normalization forms are
+// treated as no-ops, which is close enough for estimation.
+type HFTokenizer struct {
+	model       string
+	added       []string
+	normalizers []hfNormalizer
+	pretokens   []hfPreTokenizer
+
+	vocab        map[string]int
+	merges       map[string]int
+	byteFallback bool
+	ignoreMerges bool
+	subwordPfx   string
+	wordSuffix   string
+
+	pieces      map[string]float64
+	maxPieceLen int
+	unkScore    float64
+
+	mu    sync.Mutex
+	words map[string]int
+}
+
+type hfTokenizerFile struct {
+	AddedTokens []struct {
+		Content string `json:"content"`
+	} `json:"added_tokens"`
+	Normalizer   *hfComponent `json:"normalizer"`
+	PreTokenizer *hfComponent `json:"pre_tokenizer"`
+	Model        hfModelSpec  `json:"model"`
+}
+
+type hfComponent struct {
+	Type string `json:"type"`
+
+	Normalizers   []*hfComponent `json:"normalizers"`
+	Pretokenizers []*hfComponent `json:"pretokenizers"`
+
+	Pattern  *hfPattern `json:"pattern"`
+	Content  string     `json:"content"`
+	Behavior string     `json:"behavior"`
+	Invert   bool       `json:"invert"`
+	Prepend  string     `json:"prepend"`
+
+	Left  bool `json:"left"`
+	Right bool `json:"right"`
+
+	AddPrefixSpace *bool  `json:"add_prefix_space"`
+	UseRegex       *bool  `json:"use_regex"`
+	Replacement    string `json:"replacement"`
+	PrependScheme  string `json:"prepend_scheme"`
+	Split          *bool  `json:"split"`
+
+	IndividualDigits bool  `json:"individual_digits"`
+	Lowercase        *bool `json:"lowercase"`
+}
+
+type hfPattern struct {
+	String *string `json:"String"`
+	Regex  *string `json:"Regex"`
+}
+
+type hfModelSpec struct {
+	Type                    string          `json:"type"`
+	Vocab                   json.RawMessage `json:"vocab"`
+	Merges                  json.RawMessage `json:"merges"`
+	ByteFallback            bool            `json:"byte_fallback"`
+	IgnoreMerges            bool            `json:"ignore_merges"`
+	ContinuingSubwordPrefix *string         `json:"continuing_subword_prefix"`
+	EndOfWordSuffix         *string         `json:"end_of_word_suffix"`
+}
+
+type hfNormalizer func(string) string
+
+// hfPreTokenizer splits pieces further; first reports the leading segment of
+// the text (before any added token), which Metaspace "first" relies on.
+type hfPreTokenizer func(pieces []string, first bool) []string
+
+// LoadHFTokenizer loads path, either a tokenizer.json file or a directory
+// containing one.
+func LoadHFTokenizer(path string) (*HFTokenizer, error) {
+	path = hfTokenizerFilePath(path)
+	// #nosec G304 -- tokenizer paths come from trusted config / CLI flags.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tokenizer: %w", err)
+	}
+	t, err := parseHFTokenizer(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse tokenizer %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// hfTokenizerRecheckInterval throttles the stat that detects a changed
+// tokenizer file, keeping it off most estimates.
+var hfTokenizerRecheckInterval = 5 * time.Second
+
+type hfTokenizerCacheEntry struct {
+	checked time.Time
+	modTime time.Time
+	size    int64
+	tok     *HFTokenizer
+}
+
+// hfTokenizerLoad is an in-flight parse that concurrent callers wait on.
+type hfTokenizerLoad struct {
+	done chan struct{}
+	tok  *HFTokenizer
+	err  error
+}
+
+var (
+	hfTokenizerCacheMu sync.Mutex
+	hfTokenizerCache   = map[string]hfTokenizerCacheEntry{}
+	hfTokenizerLoads   = map[string]*hfTokenizerLoad{}
+)
+
+// loadOpenSourceModel returns the cached tokenizer for path, reloading it when
+// the file changes. A file is parsed once however many callers ask for it, and
+// other paths are not blocked meanwhile. Load failures are cached too so a
+// broken file is not re-parsed on every request; the error is returned only by
+// the load that hit it and later calls return nil, nil until the file changes.
+func loadOpenSourceModel(path string, tpl ChatTemplate) (*OpenSourceModel, error) {
+	path = strings.TrimSpace(path)
+	now := time.Now()
+	hfTokenizerCacheMu.Lock()
+	entry, ok := hfTokenizerCache[path]
+	hfTokenizerCacheMu.Unlock()
+	if ok && now.Sub(entry.checked) < hfTokenizerRecheckInterval {
+		return openSourceModelFor(entry.tok, tpl), nil
+	}
+
+	file := hfTokenizerFilePath(path)
+	st, err := os.Stat(file)
+	if err != nil {
+		if !ok {
+			return nil, fmt.Errorf("read tokenizer: %w", err)
+		}
+		// Keep serving what was loaded while the file is briefly missing (e.g. mid-deploy).
+		hfTokenizerCacheMu.Lock()
+		entry.checked = now
+		hfTokenizerCache[path] = entry
+		hfTokenizerCacheMu.Unlock()
+		return openSourceModelFor(entry.tok, tpl), nil
+	}
+
+	hfTokenizerCacheMu.Lock()
+	// Re-read the entry: another caller may have finished loading this version.
+	if entry, ok := hfTokenizerCache[path]; ok && entry.modTime.Equal(st.ModTime()) && entry.size == st.Size() {
+		entry.checked = now
+		hfTokenizerCache[path] = entry
+		hfTokenizerCacheMu.Unlock()
+		return openSourceModelFor(entry.tok, tpl), nil
+	}
+	load, running := hfTokenizerLoads[path]
+	if !running {
+		load = &hfTokenizerLoad{done: make(chan struct{})}
+		hfTokenizerLoads[path] = load
+	}
+	hfTokenizerCacheMu.Unlock()
+	if running {
+		<-load.done
+		return openSourceModelFor(load.tok, tpl), nil
+	}
+
+	load.tok, load.err = LoadHFTokenizer(file)
+	hfTokenizerCacheMu.Lock()
+	hfTokenizerCache[path] = hfTokenizerCacheEntry{checked: now, modTime: st.ModTime(), size: st.Size(), tok: load.tok}
+	delete(hfTokenizerLoads, path)
+	hfTokenizerCacheMu.Unlock()
+	close(load.done)
+	return openSourceModelFor(load.tok, tpl), load.err
+}
+
+func openSourceModelFor(tok *HFTokenizer, tpl ChatTemplate) *OpenSourceModel {
+	if tok == nil {
+		return nil
+	}
+	return &OpenSourceModel{Tokenizer: tok, Template: tpl}
+}
+
+func hfTokenizerFilePath(path string) string {
+	path = strings.TrimSpace(path)
+	if st, err := os.Stat(path); err == nil && st.IsDir() {
+		return filepath.Join(path, "tokenizer.json")
+	}
+	return path
+}
+
+func parseHFTokenizer(raw []byte) (*HFTokenizer, error) {
+	var f hfTokenizerFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	t := &HFTokenizer{words: map[string]int{}}
+	for _, a := range f.AddedTokens {
+		if a.Content != "" {
+			t.added = append(t.added, a.Content)
+		}
+	}
+	// Longest first, so "<|im_start|>" wins over a shorter prefix token.
+	sort.SliceStable(t.added, func(i, j int) bool { return len(t.added[i]) > len(t.added[j]) })
+
+	var err error
+	if t.normalizers, err = buildHFNormalizers(f.Normalizer); err != nil {
+		return nil, err
+	}
+	if t.pretokens, err = buildHFPreTokenizers(f.PreTokenizer); err != nil {
+		return nil, err
+	}
+	if err := t.loadModel(f.Model); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *HFTokenizer) loadModel(m hfModelSpec) error {
+	typ := m.Type
+	if typ == "" && len(m.Merges) > 0 {
+		typ = hfModelBPE
+	}
+	t.model = typ
+	t.byteFallback = m.ByteFallback
+	switch typ {
+	case hfModelBPE:
+		if err := json.Unmarshal(m.Vocab, &t.vocab); err != nil {
+			return fmt.Errorf("bpe vocab: %w", err)
+		}
+		merges, err := parseHFMerges(m.Merges)
+		if err != nil {
+			return err
+		}
+		t.merges = make(map[string]int, len(merges))
+		for rank, pair := range merges {
+			key := bpePairKey(pair[0], pair[1])
+			if _, ok := t.merges[key]; !ok {
+				t.merges[key] = rank
+			}
+		}
+		t.ignoreMerges = m.IgnoreMerges
+		if m.ContinuingSubwordPrefix != nil {
+			t.subwordPfx = *m.ContinuingSubwordPrefix
+		}
+		if m.EndOfWordSuffix != nil {
+			t.wordSuffix = *m.EndOfWordSuffix
+		}
+	case hfModelUnigram:
+		var vocab [][]any
+		if err := json.Unmarshal(m.Vocab, &vocab); err != nil {
+			return fmt.Errorf("unigram vocab: %w", err)
+		}
+		t.pieces = make(map[string]float64, len(vocab))
+		minScore := math.Inf(1)
+		for _, entry := range vocab {
+			if len(entry) != 2 {
+				continue
+			}
+			piece, _ := entry[0].(string)
+			score, _ := entry[1].(float64)
+			if piece == "" {
+				continue
+			}
+			t.pieces[piece] = score
+			t.maxPieceLen = max(t.maxPieceLen, len(piece))
+			minScore = min(minScore, score)
+		}
+		if len(t.pieces) == 0 {
+			return errors.New("unigram vocab is empty")
+		}
+		t.unkScore = minScore - hfUnigramUnkPenalty
+	default:
+		return fmt.Errorf("unsupported tokenizer model type %q", typ)
+	}
+	return nil
+}
+
+// parseHFMerges accepts both the legacy ["a b"] and the current [["a","b"]] forms.
+func parseHFMerges(raw json.RawMessage) ([][2]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var legacy []string
+	if err := json.Unmarshal(raw, &legacy); err == nil {
+		out := make([][2]string, 0, len(legacy))
+		for _, m := range legacy {
+			a, b, ok := strings.Cut(m, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid merge %q", m)
+			}
+			out = append(out, [2]string{a, b})
+		}
+		return out, nil
+	}
+	var pairs [][]string
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		return nil, fmt.Errorf("bpe merges: %w", err)
+	}
+	out := make([][2]string, 0, len(pairs))
+	for _, p := range pairs {
+		if len(p) != 2 {
+			return nil, fmt.Errorf("invalid merge %v", p)
+		}
+		out = append(out, [2]string{p[0], p[1]})
+	}
+	return out, nil
+}
+
+func buildHFNormalizers(c *hfComponent) ([]hfNormalizer, error) {
+	if c == nil {
+		return nil, nil
+	}
+	switch c.Type {
+	case "Sequence":
+		var out []hfNormalizer
+		for _, sub := range c.Normalizers {
+			n, err := buildHFNormalizers(sub)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, n...)
+		}
+		return out, nil
+	case "Prepend":
+		prefix := c.Prepend
+		return []hfNormalizer{func(s string) string {
+			if s == "" {
+				return s
+			}
+			return prefix + s
+		}}, nil
+	case "Replace":
+		replace, err := hfReplaceFunc(c.Pattern, c.Content)
+		if err != nil {
+			return nil, err
+		}
+		return []hfNormalizer{replace}, nil
+	case "Lowercase":
+		return []hfNormalizer{strings.ToLower}, nil
+	case "BertNormalizer":
+		if c.Lowercase != nil && !*c.Lowercase {
+			return nil, nil
+		}
+		return []hfNormalizer{strings.ToLower}, nil
+	case "Strip":
+		left, right := c.Left, c.Right
+		return []hfNormalizer{func(s string) string {
+			if left {
+				s = strings.TrimLeftFunc(s, unicode.IsSpace)
+			}
+			if right {
+				s = strings.TrimRightFunc(s, unicode.IsSpace)
+			}
+			return s
+		}}, nil
+	case "NFC", "NFD", "NFKC", "NFKD", "Precompiled", "StripAccents":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported normalizer %q", c.Type)
+	}
+}
+
+func hfReplaceFunc(p *hfPattern, content string) (hfNormalizer, error) {
+	switch {
+	case p != nil && p.String != nil:
+		old := *p.String
+		return func(s string) string { return strings.ReplaceAll(s, old, content) }, nil
+	case p != nil && p.Regex != nil:
+		re, err := regexp2.Compile(*p.Regex, regexp2.None)
+		if err != nil {
+			return nil, fmt.Errorf("replace pattern: %w", err)
+		}
+		return func(s string) string {
+			out, err := re.ReplaceFunc(s, func(regexp2.Match) string { return content }, -1, -1)
+			if err != nil {
+				return s
+			}
+			return out
+		}, nil
+	default:
+		return nil, errors.New("replace normalizer without pattern")
+	}
+}
+
+func buildHFPreTokenizers(c *hfComponent) ([]hfPreTokenizer, error) {
+	if c == nil {
+		return nil, nil
+	}
+	switch c.Type {
+	case "Sequence":
+		var out []hfPreTokenizer
+		for _, sub := range c.Pretokenizers {
+			p, err := buildHFPreTokenizers(sub)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p...)
+		}
+		return out, nil
+	case "ByteLevel":
+		var out []hfPreTokenizer
+		if c.AddPrefixSpace != nil && *c.AddPrefixSpace {
+			out = append(out, func(pieces []string, _ bool) []string {
+				if len(pieces) > 0 && !strings.HasPrefix(pieces[0], " ") {
+					pieces[0] = " " + pieces[0]
+				}
+				return pieces
+			})
+		}
+		if c.UseRegex == nil || *c.UseRegex {
+			out = append(out, hfRegexSplitter(regexp2.MustCompile(gpt2PreTokenizePattern, regexp2.None), "Isolated", false))
+		}
+		return append(out, func(pieces []string, _ bool) []string {
+			for i, p := range pieces {
+				pieces[i] = byteLevelEncode(p)
+			}
+			return pieces
+		}), nil
+	case "Split":
+		behavior := c.Behavior
+		if behavior == "" {
+			behavior = "Isolated"
+		}
+		switch {
+		case c.Pattern != nil && c.Pattern.Regex != nil:
+			re, err := regexp2.Compile(*c.Pattern.Regex, regexp2.None)
+			if err != nil {
+				return nil, fmt.Errorf("split pattern: %w", err)
+			}
+			return []hfPreTokenizer{hfRegexSplitter(re, behavior, c.Invert)}, nil
+		case c.Pattern != nil && c.Pattern.String != nil:
+			re := regexp2.MustCompile(regexp2.Escape(*c.Pattern.String), regexp2.None)
+			return []hfPreTokenizer{hfRegexSplitter(re, behavior, c.Invert)}, nil
+		default:
+			return nil, errors.New("split pre-tokenizer without pattern")
+		}
+	case "Metaspace":
+		return []hfPreTokenizer{hfMetaspace(c)}, nil
+	case "Digits":
+		pattern := `\p{N}+`
+		if c.IndividualDigits {
+			pattern = `\p{N}`
+		}
+		return []hfPreTokenizer{hfRegexSplitter(regexp2.MustCompile(pattern, regexp2.None), "Isolated", false)}, nil
+	case "Whitespace":
+		return []hfPreTokenizer{hfRegexSplitter(regexp2.MustCompile(`\w+|[^\w\s]+`, regexp2.None), "Removed", true)}, nil
+	case "WhitespaceSplit":
+		return []hfPreTokenizer{hfWhitespaceSplit}, nil
+	case "Punctuation":
+		return []hfPreTokenizer{hfRegexSplitter(regexp2.MustCompile(`\p{P}`, regexp2.None), "Isolated", false)}, nil
+	case "BertPreTokenizer":
+		return []hfPreTokenizer{
+			hfWhitespaceSplit,
+			hfRegexSplitter(regexp2.MustCompile(`\p{P}`, regexp2.None), "Isolated", false),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported pre_tokenizer %q", c.Type)
+	}
+}
+
+func hfWhitespaceSplit(pieces []string, _ bool) []string {
+	out := make([]string, 0, len(pieces))
+	for _, p := range pieces {
+		out = append(out, strings.Fields(p)...)
+	}
+	return out
+}
+
+func hfMetaspace(c *hfComponent) hfPreTokenizer {
+	rep := c.Replacement
+	if rep == "" {
+		rep = "▁"
+	}
+	scheme := strings.ToLower(c.PrependScheme)
+	if scheme == "" {
+		scheme = "always"
+		if c.AddPrefixSpace != nil && !*c.AddPrefixSpace {
+			scheme = "never"
+		}
+	}
+	split := c.Split == nil || *c.Split
+	return func(pieces []string, first bool) []string {
+		out := make([]string, 0, len(pieces))
+		for i, p := range pieces {
+			p = strings.ReplaceAll(p, " ", rep)
+			if (scheme == "always" || (scheme == "first" && first && i == 0)) && !strings.HasPrefix(p, rep) {
+				p = rep + p
+			}
+			if !split {
+				out = append(out, p)
+				continue
+			}
+			// Split before every replacement char, keeping it on the next word.
+			for p != "" {
+				cut := strings.Index(p[1:], rep)
+				if cut < 0 {
+					out = append(out, p)
+					break
+				}
+				out = append(out, p[:cut+1])
+				p = p[cut+1:]
+			}
+		}
+		return out
+	}
+}
+
+type hfSegment struct {
+	text  string
+	delim bool
+}
+
+func hfRegexSplitter(re *regexp2.Regexp, behavior string, invert bool) hfPreTokenizer {
+	return func(pieces []string, _ bool) []string {
+		out := make([]string, 0, len(pieces))
+		for _, p := range pieces {
+			out = appendSplitBehavior(out, splitByRegex(re, p, invert), behavior)
+		}
+		return out
+	}
+}
+
+func splitByRegex(re *regexp2.Regexp, s string, invert bool) []hfSegment {
+	runes := []rune(s)
+	var out []hfSegment
+	last := 0
+	m, _ := re.FindRunesMatch(runes)
+	for m != nil {
+		if m.Length > 0 {
+			if m.Index > last {
+				out = append(out, hfSegment{text: string(runes[last:m.Index]), delim: invert})
+			}
+			out = append(out, hfSegment{text: string(runes[m.Index : m.Index+m.Length]), delim: !invert})
+			last = m.Index + m.Length
+		}
+		m, _ = re.FindNextMatch(m)
+	}
+	if last < len(runes) {
+		out = append(out, hfSegment{text: string(runes[last:]), delim: invert})
+	}
+	return out
+}
+
+func appendSplitBehavior(out []string, segs []hfSegment, behavior string) []string {
+	pending := ""
+	prevDelim := false
+	for i, seg := range segs {
+		switch behavior {
+		case "Removed":
+			if !seg.delim {
+				out = append(out, seg.text)
+			}
+		case "MergedWithPrevious":
+			if seg.delim && i > 0 && len(out) > 0 && !prevDelim {
+				out[len(out)-1] += seg.text
+			} else {
+				out = append(out, seg.text)
+			}
+		case "MergedWithNext":
+			if seg.delim {
+				pending += seg.text
+				continue
+			}
+			out = append(out, pending+seg.text)
+			pending = ""
+		case "Contiguous":
+			if seg.delim && prevDelim && len(out) > 0 {
+				out[len(out)-1] += seg.text
+			} else {
+				out = append(out, seg.text)
+			}
+		default: // Isolated
+			out = append(out, seg.text)
+		}
+		prevDelim = seg.delim
+	}
+	if pending != "" {
+		out = append(out, pending)
+	}
+	return out
+}
+
+var byteLevelAlphabet = buildByteLevelAlphabet()
+
+// buildByteLevelAlphabet is GPT-2's bytes_to_unicode table.
+func buildByteLevelAlphabet() [256]rune {
+	var table [256]rune
+	n := 0
+	for b := 0; b < 256; b++ {
+		switch {
+		case b >= '!' && b <= '~', b >= 0xA1 && b <= 0xAC, b >= 0xAE && b <= 0xFF:
+			table[b] = rune(b)
+		default:
+			table[b] = rune(256 + n)
+			n++
+		}
+	}
+	return table
+}
+
+func byteLevelEncode(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(byteLevelAlphabet[s[i]])
+	}
+	return b.String()
+}
+
+// Count returns the number of tokens text encodes to, without BOS/EOS.
+// Added tokens (special and not) appearing in text count as one token each.
+func (t *HFTokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	total := 0
+	first := true
+	for _, seg := range t.splitAddedTokens(text) {
+		if seg.delim {
+			total++
+			continue
+		}
+		total += t.countSegment(seg.text, first)
+		first = false
+	}
+	return total
+}
+
+// splitAddedTokens splits text around added tokens, marking them as delim.
+func (t *HFTokenizer) splitAddedTokens(text string) []hfSegment {
+	var present []string
+	for _, tok := range t.added {
+		if strings.Contains(text, tok) {
+			present = append(present, tok)
+		}
+	}
+	if len(present) == 0 {
+		return []hfSegment{{text: text}}
+	}
+	next := make([]int, len(present))
+	for i, tok := range present {
+		next[i] = strings.Index(text, tok)
+	}
+	var out []hfSegment
+	pos := 0
+	for {
+		best := -1
+		for i, tok := range present {
+			if next[i] >= 0 && next[i] < pos {
+				if idx := strings.Index(text[pos:], tok); idx >= 0 {
+					next[i] = pos + idx
+				} else {
+					next[i] = -1
+				}
+			}
+			// present is longest first, so ties keep the longer token.
+			if next[i] >= 0 && (best < 0 || next[i] < next[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		if next[best] > pos {
+			out = append(out, hfSegment{text: text[pos:next[best]]})
+		}
+		out = append(out, hfSegment{text: present[best], delim: true})
+		pos = next[best] + len(present[best])
+	}
+	if pos < len(text) {
+		out = append(out, hfSegment{text: text[pos:]})
+	}
+	return out
+}
+
+func (t *HFTokenizer) countSegment(text string, first bool) int {
+	for _, n := range t.normalizers {
+		text = n(text)
+	}
+	if text == "" {
+		return 0
+	}
+	pieces := []string{text}
+	for _, p := range t.pretokens {
+		pieces = p(pieces, first)
+	}
+	total := 0
+	for _, p := range pieces {
+		if p != "" {
+			total += t.countWord(p)
+		}
+	}
+	return total
+}
+
+func (t *HFTokenizer) countWord(word string) int {
+	t.mu.Lock()
+	n, ok := t.words[word]
+	t.mu.Unlock()
+	if ok {
+		return n
+	}
+	if t.model == hfModelUnigram {
+		n = t.countUnigram(word)
+	} else {
+		n = t.countBPE(word)
+	}
+	t.mu.Lock()
+	if len(t.words) >= hfWordCacheLimit {
+		t.words = map[string]int{}
+	}
+	t.words[word] = n
+	t.mu.Unlock()
+	return n
+}
+
+type bpeSymbol struct {
+	text       string
+	prev, next int
+	alive      bool
+}
+
+type bpeCandidate struct {
+	rank, pos   int
+	left, right string
+}
+
+func bpePairKey(a, b string) string {
+	return a + "\x00" + b
+}
+
+// countBPE applies merges by rank (leftmost first on ties) and counts the
+// remaining symbols; symbols missing from the vocab fall back to bytes or unk.
+func (t *HFTokenizer) countBPE(word string) int {
+	if t.ignoreMerges {
+		if _, ok := t.vocab[word+t.wordSuffix]; ok {
+			return 1
+		}
+	}
+	syms := make([]bpeSymbol, 0, utf8.RuneCountInString(word))
+	for i, r := range word {
+		s := string(r)
+		if i > 0 {
+			s = t.subwordPfx + s
+		}
+		syms = append(syms, bpeSymbol{text: s, prev: len(syms) - 1, next: len(syms) + 1, alive: true})
+	}
+	if len(syms) == 0 {
+		return 0
+	}
+	syms[len(syms)-1].next = -1
+	syms[len(syms)-1].text += t.wordSuffix
+
+	h := &bpeHeap{}
+	push := func(left int) {
+		if left < 0 || syms[left].next < 0 {
+			return
+		}
+		right := syms[left].next
+		if rank, ok := t.merges[bpePairKey(syms[left].text, syms[right].text)]; ok {
+			heap.Push(h, bpeCandidate{rank: rank, pos: left, left: syms[left].text, right: syms[right].text})
+		}
+	}
+	for i := range syms {
+		push(i)
+	}
+	for h.Len() > 0 {
+		c := heap.Pop(h).(bpeCandidate)
+		l := &syms[c.pos]
+		if !l.alive || l.next < 0 || l.text != c.left || syms[l.next].text != c.right {
+			continue
+		}
+		r := &syms[l.next]
+		l.text += t.trimSubwordPrefix(r.text)
+		r.alive = false
+		l.next = r.next
+		if l.next >= 0 {
+			syms[l.next].prev = c.pos
+		}
+		push(l.prev)
+		push(c.pos)
+	}
+
+	count := 0
+	for _, s := range syms {
+		if !s.alive {
+			continue
+		}
+		if _, ok := t.vocab[s.text]; ok || !t.byteFallback {
+			count++
+			continue
+		}
+		count += len(t.trimSubwordPrefix(strings.TrimSuffix(s.text, t.wordSuffix)))
+	}
+	return count
+}
+
+func (t *HFTokenizer) trimSubwordPrefix(s string) string {
+	if t.subwordPfx == "" {
+		return s
+	}
+	return strings.TrimPrefix(s, t.subwordPfx)
+}
+
+// countUnigram runs Viterbi over the piece scores and counts the best path.
+func (t *HFTokenizer) countUnigram(word string) int {
+	n := len(word)
+	best := make([]float64, n+1)
+	tokens := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+	}
+	for start := 0; start < n; start++ {
+		if math.IsInf(best[start], -1) || !utf8.RuneStart(word[start]) {
+			continue
+		}
+		_, runeLen := utf8.DecodeRuneInString(word[start:])
+		matched := false
+		for end := start + 1; end <= n && end-start <= t.maxPieceLen; end++ {
+			if end < n && !utf8.RuneStart(word[end]) {
+				continue
+			}
+			score, ok := t.pieces[word[start:end]]
+			if !ok {
+				continue
+			}
+			if end-start == runeLen {
+				matched = true
+			}
+			if s := best[start] + score; s > best[end] {
+				best[end] = s
+				tokens[end] = tokens[start] + 1
+			}
+		}
+		if !matched {
+			end := start + runeLen
+			unkTokens := 1
+			if t.byteFallback {
+				unkTokens = runeLen
+			}
+			if s := best[start] + t.unkScore; s > best[end] {
+				best[end] = s
+				tokens[end] = tokens[start] + unkTokens
+			}
+		}
+	}
+	return tokens[n]
+}
+
+type bpeHeap []bpeCandidate
+
+func (h bpeHeap) Len() int { return len(h) }
+func (h bpeHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].pos < h[j].pos
+}
+func (h bpeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *bpeHeap) Push(x any)   { *h = append(*h, x.(bpeCandidate)) }
+func (h *bpeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
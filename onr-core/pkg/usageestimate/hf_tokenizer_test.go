@@ -0,0 +1,156 @@
+package usageestimate
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testByteLevelBPE is a tiny GPT-2 style tokenizer: "hello" and " world"
+// merge into single tokens, and "<|im_start|>"/"<|im_end|>" are added tokens.
+const testByteLevelBPE = `{
+  "added_tokens": [
+    {"id": 100, "content": "<|im_start|>", "special": true},
+    {"id": 101, "content": "<|im_end|>", "special": true}
+  ],
+  "normalizer": null,
+  "pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+  "model": {
+    "type": "BPE",
+    "vocab": {"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "w": 5, "r": 6, "d": 7,
+      "he": 8, "ll": 9, "hell": 10, "hello": 11, "Ġw": 12, "or": 13, "Ġwor": 14, "ld": 15, "Ġworld": 16},
+    "merges": [["h", "e"], ["l", "l"], ["he", "ll"], ["hell", "o"], ["Ġ", "w"], ["o", "r"], ["Ġw", "or"], ["l", "d"], ["Ġwor", "ld"]]
+  }
+}`
+
+const testUnigram = `{
+  "added_tokens": [{"id": 0, "content": "<unk>", "special": true}],
+  "normalizer": {"type": "Sequence", "normalizers": [{"type": "NFKC"}]},
+  "pre_tokenizer": {"type": "Metaspace", "replacement": "▁", "prepend_scheme": "always", "split": true},
+  "model": {
+    "type": "Unigram",
+    "unk_id": 0,
+    "vocab": [["<unk>", 0.0], ["▁hello", -1.0], ["▁", -2.0], ["▁he", -3.0], ["llo", -3.0],
+      ["h", -5.0], ["e", -5.0], ["l", -5.0], ["o", -5.0]]
+  }
+}`
+
+func writeTestTokenizer(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tokenizer.json"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write tokenizer: %v", err)
+	}
+	return dir
+}
+
+func TestHFTokenizer_ByteLevelBPE(t *testing.T) {
+	t.Parallel()
+
+	tok, err := LoadHFTokenizer(writeTestTokenizer(t, testByteLevelBPE))
+	if err != nil {
+		t.Fatalf("LoadHFTokenizer: %v", err)
+	}
+	cases := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "hello", want: 1},
+		{text: "hello world", want: 2},
+		{text: "hello world world", want: 3},
+		{text: "<|im_start|>hello<|im_end|>", want: 3},
+		// "wo" has no merge, so it stays two symbols.
+		{text: "wo", want: 2},
+	}
+	for _, tc := range cases {
+		if got := tok.Count(tc.text); got != tc.want {
+			t.Fatalf("Count(%q)=%d want=%d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestHFTokenizer_Unigram(t *testing.T) {
+	t.Parallel()
+
+	tok, err := LoadHFTokenizer(filepath.Join(writeTestTokenizer(t, testUnigram), "tokenizer.json"))
+	if err != nil {
+		t.Fatalf("LoadHFTokenizer: %v", err)
+	}
+	cases := []struct {
+		text string
+		want int
+	}{
+		{text: "hello", want: 1},
+		{text: "hello hello", want: 2},
+		// "▁he" + unknown "x".
+		{text: "hex", want: 2},
+	}
+	for _, tc := range cases {
+		if got := tok.Count(tc.text); got != tc.want {
+			t.Fatalf("Count(%q)=%d want=%d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestLoadHFTokenizer_RejectsUnsupportedComponents(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadHFTokenizer(writeTestTokenizer(t, `{"pre_tokenizer":{"type":"Bogus"},"model":{"type":"BPE","vocab":{},"merges":[]}}`))
+	if err == nil {
+		t.Fatalf("expected unsupported pre_tokenizer error")
+	}
+	_, err = LoadHFTokenizer(writeTestTokenizer(t, `{"model":{"type":"WordPiece","vocab":{}}}`))
+	if err == nil {
+		t.Fatalf("expected unsupported model error")
+	}
+}
+
+func TestLoadOpenSourceModel_CachesAndReportsErrorsOnce(t *testing.T) {
+	defer func(d time.Duration) { hfTokenizerRecheckInterval = d }(hfTokenizerRecheckInterval)
+	hfTokenizerRecheckInterval = time.Hour
+
+	dir := writeTestTokenizer(t, `{"model":{"type":"WordPiece","vocab":{}}}`)
+	if m, err := loadOpenSourceModel(dir, ChatTemplate{}); m != nil || err == nil {
+		t.Fatalf("expected load error, got m=%v err=%v", m, err)
+	}
+	if m, err := loadOpenSourceModel(dir, ChatTemplate{}); m != nil || err != nil {
+		t.Fatalf("expected cached failure to fall back silently, got m=%v err=%v", m, err)
+	}
+
+	// Within the recheck interval the file is not stat'ed, so a fix is not seen yet.
+	file := filepath.Join(dir, "tokenizer.json")
+	if err := os.WriteFile(file, []byte(testByteLevelBPE), 0o600); err != nil {
+		t.Fatalf("write tokenizer: %v", err)
+	}
+	if m, _ := loadOpenSourceModel(dir, ChatTemplate{}); m != nil {
+		t.Fatalf("expected the cached failure until the next recheck")
+	}
+
+	hfTokenizerRecheckInterval = 0
+	var wg sync.WaitGroup
+	toks := make([]*HFTokenizer, 8)
+	for i := range toks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := loadOpenSourceModel(dir, ChatTemplate{})
+			if err != nil || m == nil {
+				t.Errorf("load #%d: m=%v err=%v", i, m, err)
+				return
+			}
+			toks[i] = m.Tokenizer
+		}(i)
+	}
+	wg.Wait()
+	for i, tok := range toks {
+		if tok != toks[0] {
+			t.Fatalf("load #%d parsed its own tokenizer", i)
+		}
+	}
+	if got := toks[0].Count("hello world"); got != 2 {
+		t.Fatalf("Count=%d want=2", got)
+	}
+}
@@ -21,11 +21,8 @@ type Tokenizer interface {
 }
 
 func GetTokenizers(ectx *EstimateContext) (Tokenizer, error) {
-	switch {
-	// case ectx.Model == "glm-5.2":
-	// 	return NewOpenSourceTokenizer(ectx)
-	case strings.HasPrefix(ectx.Model, "claude"):
-		return NewCloseSourceTokenizer(ectx)
+	if ectx.OpenSource != nil && ectx.OpenSource.Tokenizer != nil {
+		return NewOpenSourceTokenizer(ectx), nil
 	}
 	return NewCloseSourceTokenizer(ectx)
 }
@@ -248,20 +245,37 @@ func (ct *CloseSourceTokenizer) ApplyChatTemplate() string {
 	return strings.TrimSuffix(ct.textBuilder.String(), " ")
 }
 
+// OpenSourceModel pairs a loaded tokenizer.json with the chat template of
+// the model family it belongs to.
+type OpenSourceModel struct {
+	Tokenizer *HFTokenizer
+	Template  ChatTemplate
+}
+
+// OpenSourceTokenizer counts tokens exactly with the model's own vocabulary,
+// so no profile multipliers or overhead weights are applied; the chat
+// template supplies the framing tokens instead.
 type OpenSourceTokenizer struct {
-	ectx *EstimateContext
+	ectx  *EstimateContext
+	model *OpenSourceModel
 }
 
 func NewOpenSourceTokenizer(ectx *EstimateContext) *OpenSourceTokenizer {
-
-	return &OpenSourceTokenizer{ectx: ectx}
+	return &OpenSourceTokenizer{ectx: ectx, model: ectx.OpenSource}
 }
+
 func (ot *OpenSourceTokenizer) CountToken(prompt string) int {
-	return 0
-}
-func (ot *OpenSourceTokenizer) CountTokenByText(prompt string) int {
-	return 0
+	return ot.model.Tokenizer.Count(prompt)
 }
+
 func (ot *OpenSourceTokenizer) ApplyChatTemplate() string {
-	return ""
+	switch normalizeAPI(ot.ectx.API) {
+	case apiMessages, apiChatCompletions, apiResponses, apiGeminiGenerateContent, apiGeminiStreamGenerateContent:
+		return ot.model.Template.Render(ot.ectx)
+	}
+	parts := make([]string, 0, len(ot.ectx.Texts))
+	for _, text := range ot.ectx.Texts {
+		parts = append(parts, text.Text)
+	}
+	return strings.Join(parts, "\n")
 }
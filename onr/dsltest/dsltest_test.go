@@ -0,0 +1,272 @@
+package dsltest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProviderConf = `syntax "next-router/0.1";
+
+provider "demo" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.demo.test";
+    }
+    auth {
+      auth_bearer;
+    }
+    response {
+      resp_passthrough;
+    }
+  }
+
+  match api = "chat.completions" stream = false {
+    metrics {
+      usage_extract openai_chat_completions;
+      finish_reason_extract openai_chat_completions;
+    }
+    request {
+      json_set "$.model" "demo-large";
+    }
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+
+  match api = "chat.completions" stream = true {
+    metrics {
+      usage_extract openai_chat_completions;
+      finish_reason_extract openai_chat_completions;
+    }
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+}
+`
+
+const testSuite = `# demo provider goldens
+cases:
+  - name: chat_basic
+    api: chat.completions
+    key: sk-demo
+    request:
+      body:
+        model: gpt-4o-mini
+        messages:
+          - role: user
+            content: hi
+    response:
+      body:
+        id: chatcmpl-1
+        object: chat.completion
+        choices:
+          - index: 0
+            message: {role: assistant, content: hello}
+            finish_reason: stop
+        usage: {prompt_tokens: 3, completion_tokens: 1, total_tokens: 4}
+    upstream:
+      method: POST
+      url: https://api.demo.test/v1/chat/completions
+      headers:
+        Authorization: Bearer sk-demo
+      body:
+        model: demo-large
+        messages:
+          - role: user
+            content: hi
+    expect:
+      status: 200
+      ignore_fields: [id]
+      body:
+        object: chat.completion
+        choices:
+          - index: 0
+            message: {role: assistant, content: hello}
+            finish_reason: stop
+        usage: {prompt_tokens: 3, completion_tokens: 1, total_tokens: 4}
+      usage:
+        input_tokens: 3
+        output_tokens: 1
+        total_tokens: 4
+      finish_reason: stop
+  - name: chat_stream
+    api: chat.completions
+    stream: true
+    request:
+      body:
+        model: demo-large
+        stream: true
+        messages: [{role: user, content: hi}]
+    response:
+      sse: |
+        data: {"choices":[{"index":0,"delta":{"content":"he"}}]}
+
+        data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}
+
+        data: [DONE]
+    expect:
+      status: 200
+      finish_reason: stop
+`
+
+// writeFixture lays out root/{onr.conf,modes/,providers/demo.conf,providers/demo.test.yaml}
+// reusing the shipped mode definitions.
+func writeFixture(t *testing.T, suite string) string {
+	t.Helper()
+	root := t.TempDir()
+	copyFile(t, filepath.Join("..", "..", "config", "onr.conf"), filepath.Join(root, "onr.conf"))
+	modes, err := filepath.Glob(filepath.Join("..", "..", "config", "modes", "*.conf"))
+	if err != nil || len(modes) == 0 {
+		t.Fatalf("glob modes: %v", err)
+	}
+	for _, m := range modes {
+		copyFile(t, m, filepath.Join(root, "modes", filepath.Base(m)))
+	}
+	dir := filepath.Join(root, "providers")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "demo.conf"), []byte(testProviderConf), 0o600); err != nil {
+		t.Fatalf("write conf: %v", err)
+	}
+	path := filepath.Join(dir, "demo"+FileSuffix)
+	if err := os.WriteFile(path, []byte(suite), 0o600); err != nil {
+		t.Fatalf("write suite: %v", err)
+	}
+	return path
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	b, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read %s: %v", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(dst, b, 0o600); err != nil {
+		t.Fatalf("write %s: %v", dst, err)
+	}
+}
+
+func TestRunFile_Pass(t *testing.T) {
+	path := writeFixture(t, testSuite)
+	res, err := RunFile(path, Options{})
+	if err != nil {
+		t.Fatalf("RunFile: %v", err)
+	}
+	if len(res.Cases) != 2 {
+		t.Fatalf("cases=%d", len(res.Cases))
+	}
+	for _, c := range res.Cases {
+		if !c.Passed() {
+			t.Fatalf("case %s failed: %v", c.Name, c.Failures)
+		}
+	}
+}
+
+func TestRunFile_ReportsMismatches(t *testing.T) {
+	suite := strings.Replace(testSuite, "model: demo-large\n        messages:\n          - role: user", "model: wrong\n        messages:\n          - role: user", 1)
+	suite = strings.Replace(suite, "finish_reason: stop\n  - name", "finish_reason: length\n  - name", 1)
+	path := writeFixture(t, suite)
+	res, err := RunFile(path, Options{})
+	if err != nil {
+		t.Fatalf("RunFile: %v", err)
+	}
+	if res.Failed() != 1 {
+		t.Fatalf("failed=%d", res.Failed())
+	}
+	joined := strings.Join(res.Cases[0].Failures, "\n")
+	for _, want := range []string{"upstream.body", "expect.finish_reason"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing %q in failures:\n%s", want, joined)
+		}
+	}
+}
+
+func TestRunFile_UpdateRewritesGoldens(t *testing.T) {
+	const bare = `# keep me
+cases:
+  - name: chat_basic
+    api: chat.completions
+    request:
+      body: {model: gpt-4o-mini, messages: [{role: user, content: hi}]}
+    response:
+      body:
+        id: chatcmpl-1
+        choices: [{index: 0, message: {role: assistant, content: hello}, finish_reason: stop}]
+        usage: {prompt_tokens: 3, completion_tokens: 1, total_tokens: 4}
+    expect:
+      ignore_fields: [id]
+  - name: stream
+    api: chat.completions
+    stream: true
+    request:
+      body: {model: demo-large, stream: true, messages: [{role: user, content: hi}]}
+    response:
+      sse: |
+        data: {"id":"x","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+        data: [DONE]
+`
+	path := writeFixture(t, bare)
+	res, err := RunFile(path, Options{Update: true})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !res.Updated {
+		t.Fatalf("expected Updated")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	got := string(b)
+	for _, want := range []string{"# keep me", "url: https://api.demo.test/v1/chat/completions", "Authorization: Bearer test-key", "finish_reason: stop", "ignore_fields:", "sse: |"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in updated file:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "chatcmpl-1\n      usage") || strings.Contains(got, "User-Agent") {
+		t.Fatalf("unexpected content in updated file:\n%s", got)
+	}
+
+	res, err = RunFile(path, Options{})
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if res.Failed() != 0 {
+		t.Fatalf("rerun failures: %+v", res.Cases)
+	}
+}
+
+func TestLoadSuite_Errors(t *testing.T) {
+	cases := map[string]string{
+		"name is required":    "cases:\n  - api: chat.completions\n",
+		"api is required":     "cases:\n  - name: a\n",
+		"duplicate case name": "cases:\n  - name: a\n    api: x\n  - name: a\n    api: x\n",
+		"only one of body":    "cases:\n  - name: a\n    api: x\n    response:\n      body: {}\n      sse: x\n",
+		"mutually exclusive":  "cases:\n  - name: a\n    api: x\n    request:\n      body: {}\n      body_raw: x\n",
+	}
+	for want, content := range cases {
+		path := filepath.Join(t.TempDir(), "demo"+FileSuffix)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadSuite(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("want error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestRunFile_UnknownProvider(t *testing.T) {
+	path := writeFixture(t, "provider: missing\ncases: []\n")
+	if _, err := RunFile(path, Options{}); err == nil || !strings.Contains(err.Error(), `provider "missing" not found`) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package dsltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// noiseHeaders are set by the HTTP stack rather than the DSL and are left out of
+// generated upstream goldens.
+var noiseHeaders = map[string]bool{
+	"Accept-Encoding": true,
+	"Content-Length":  true,
+	"User-Agent":      true,
+}
+
+func compareCase(c Case, a caseActual) []string {
+	var fails []string
+	failf := func(format string, args ...any) {
+		fails = append(fails, fmt.Sprintf(format, args...))
+	}
+
+	exp := c.Expect
+	if exp != nil && exp.Error != "" {
+		if a.Err == nil {
+			failf("error: want %q, got success", exp.Error)
+		} else if !strings.Contains(a.Err.Error(), exp.Error) {
+			failf("error: want substring %q, got %q", exp.Error, a.Err.Error())
+		}
+	} else if a.Err != nil {
+		failf("unexpected error: %v", a.Err)
+	}
+
+	if up := c.Upstream; up != nil {
+		if a.Upstream == nil {
+			failf("upstream: no request was sent")
+		} else {
+			got := a.Upstream
+			if up.Method != "" && !strings.EqualFold(up.Method, got.Method) {
+				failf("upstream.method: want %q, got %q", up.Method, got.Method)
+			}
+			if up.URL != "" && up.URL != got.URL {
+				failf("upstream.url: want %q, got %q", up.URL, got.URL)
+			}
+			for _, k := range sortedKeys(up.Headers) {
+				if v := strings.Join(got.Header.Values(k), ", "); v != up.Headers[k] {
+					failf("upstream.headers[%s]: want %q, got %q", k, up.Headers[k], v)
+				}
+			}
+			if msg := compareBody(up.Body, up.BodyRaw, got.Body, nil); msg != "" {
+				failf("upstream.body: %s", msg)
+			}
+		}
+	}
+
+	if exp == nil || a.Err != nil {
+		return fails
+	}
+	if exp.Status != 0 && exp.Status != a.Status {
+		failf("expect.status: want %d, got %d", exp.Status, a.Status)
+	}
+	if exp.SSE != "" {
+		want := normalizeSSE(exp.SSE, exp.IgnoreFields)
+		if got := normalizeSSE(string(a.Body), exp.IgnoreFields); want != got {
+			failf("expect.sse: want\n%s\ngot\n%s", want, got)
+		}
+	}
+	if msg := compareBody(exp.Body, exp.BodyRaw, a.Body, exp.IgnoreFields); msg != "" {
+		failf("expect.body: %s", msg)
+	}
+	if exp.Usage != nil {
+		want, _ := normalizeJSONValue(exp.Usage)
+		got, _ := normalizeJSONValue(a.Usage)
+		wantM, _ := want.(map[string]any)
+		gotM, _ := got.(map[string]any)
+		for _, k := range sortedKeys(wantM) {
+			if !reflect.DeepEqual(wantM[k], gotM[k]) {
+				failf("expect.usage.%s: want %s, got %s", k, compactJSON(wantM[k]), compactJSON(gotM[k]))
+			}
+		}
+	}
+	if exp.FinishReason != "" && exp.FinishReason != a.FinishReason {
+		failf("expect.finish_reason: want %q, got %q", exp.FinishReason, a.FinishReason)
+	}
+	return fails
+}
+
+// compareBody compares a golden body (JSON document or raw text) with the actual bytes.
+// It returns an empty string when the golden is unset or matches.
+func compareBody(wantJSON any, wantRaw string, got []byte, ignore []string) string {
+	switch {
+	case wantRaw != "":
+		if wantRaw != string(got) {
+			return fmt.Sprintf("want %q, got %q", wantRaw, string(got))
+		}
+	case wantJSON != nil:
+		want, err := normalizeJSONValue(wantJSON)
+		if err != nil {
+			return err.Error()
+		}
+		var gotV any
+		if err := json.Unmarshal(got, &gotV); err != nil {
+			return fmt.Sprintf("actual body is not JSON: %q", string(got))
+		}
+		want = stripFields(want, ignore)
+		gotV = stripFields(gotV, ignore)
+		if !reflect.DeepEqual(want, gotV) {
+			return fmt.Sprintf("want %s, got %s", compactJSON(want), compactJSON(gotV))
+		}
+	}
+	return ""
+}
+
+// normalizeJSONValue round-trips v through encoding/json so YAML-decoded and
+// JSON-decoded values compare equal.
+func normalizeJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode golden: %w", err)
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func stripFields(v any, fields []string) any {
+	if len(fields) == 0 {
+		return v
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		for _, f := range fields {
+			delete(t, f)
+		}
+		for k, child := range t {
+			t[k] = stripFields(child, fields)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = stripFields(child, fields)
+		}
+	}
+	return v
+}
+
+// normalizeSSE trims blank-line noise and canonicalizes JSON data payloads (minus
+// ignored fields) so goldens are insensitive to key order and whitespace.
+func normalizeSSE(s string, ignore []string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if payload, ok := strings.CutPrefix(line, "data:"); ok {
+			payload = strings.TrimSpace(payload)
+			var v any
+			if err := json.Unmarshal([]byte(payload), &v); err == nil {
+				payload = compactJSON(stripFields(v, ignore))
+			}
+			line = "data: " + payload
+		}
+		out = append(out, line)
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// goldenUpstream builds the upstream golden from what the fake upstream received.
+func goldenUpstream(got *capturedRequest) *UpstreamExpect {
+	if got == nil {
+		return nil
+	}
+	up := &UpstreamExpect{Method: got.Method, URL: got.URL}
+	for k, vs := range got.Header {
+		ck := http.CanonicalHeaderKey(k)
+		if noiseHeaders[ck] {
+			continue
+		}
+		if up.Headers == nil {
+			up.Headers = map[string]string{}
+		}
+		up.Headers[ck] = strings.Join(vs, ", ")
+	}
+	up.Body, up.BodyRaw = goldenBody(got.Body, nil)
+	return up
+}
+
+// goldenExpect builds the client golden, keeping the previous ignore_fields.
+func goldenExpect(prev *ClientExpect, a caseActual) *ClientExpect {
+	exp := &ClientExpect{}
+	if prev != nil {
+		exp.IgnoreFields = prev.IgnoreFields
+	}
+	if a.Err != nil {
+		exp.Error = a.Err.Error()
+		return exp
+	}
+	exp.Status = a.Status
+	if strings.HasPrefix(strings.ToLower(a.ContentType), "text/event-stream") {
+		exp.SSE = normalizeSSE(string(a.Body), exp.IgnoreFields) + "\n"
+	} else {
+		exp.Body, exp.BodyRaw = goldenBody(a.Body, exp.IgnoreFields)
+	}
+	if len(a.Usage) > 0 {
+		u, err := normalizeJSONValue(a.Usage)
+		if m, ok := u.(map[string]any); err == nil && ok {
+			exp.Usage = m
+		}
+	}
+	exp.FinishReason = a.FinishReason
+	return exp
+}
+
+func goldenBody(b []byte, ignore []string) (any, string) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, ""
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, string(b)
+	}
+	return stripFields(v, ignore), ""
+}
+
+// updateGoldens rewrites the upstream/expect nodes of each case in place, leaving
+// comments and the remaining fields of the file untouched.
+func updateGoldens(path string, suite Suite, actuals []caseActual) error {
+	// #nosec G304 -- golden case files are selected by the operator.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("parse %q: %w", path, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return fmt.Errorf("%s: empty document", path)
+	}
+	cases := mappingValue(doc.Content[0], "cases")
+	if cases == nil || cases.Kind != yaml.SequenceNode || len(cases.Content) != len(suite.Cases) {
+		return fmt.Errorf("%s: cases must be a sequence", path)
+	}
+	for i, node := range cases.Content {
+		if err := setMappingValue(node, "upstream", goldenUpstream(actuals[i].Upstream)); err != nil {
+			return fmt.Errorf("%s: case %q: %w", path, suite.Cases[i].Name, err)
+		}
+		if err := setMappingValue(node, "expect", goldenExpect(suite.Cases[i].Expect, actuals[i])); err != nil {
+			return fmt.Errorf("%s: case %q: %w", path, suite.Cases[i].Name, err)
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encode %q: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	// #nosec G306 -- golden files are regular project files.
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write %q: %w", path, err)
+	}
+	return nil
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces (or appends) key in mapping node m; a nil value removes it.
+func setMappingValue(m *yaml.Node, key string, v any) error {
+	if m.Kind != yaml.MappingNode {
+		return fmt.Errorf("case must be a mapping")
+	}
+	isNil := v == nil || reflect.ValueOf(v).IsNil()
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != key {
+			continue
+		}
+		if isNil {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return nil
+		}
+		return m.Content[i+1].Encode(v)
+	}
+	if isNil {
+		return nil
+	}
+	var val yaml.Node
+	if err := val.Encode(v); err != nil {
+		return err
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &val)
+	return nil
+}
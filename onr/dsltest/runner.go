@@ -0,0 +1,254 @@
+package dsltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+)
+
+const defaultTestKey = "test-key"
+
+// Options controls one harness run.
+type Options struct {
+	// ProvidersDir overrides the providers directory; by default the directory
+	// containing the *.test.yaml file is loaded.
+	ProvidersDir string
+	// Update rewrites the upstream/expect goldens from the actual results.
+	Update bool
+}
+
+// CaseResult is the outcome of one case. Failures lists golden mismatches.
+type CaseResult struct {
+	Name     string
+	Failures []string
+}
+
+// Passed reports whether the case matched its goldens.
+func (r CaseResult) Passed() bool { return len(r.Failures) == 0 }
+
+// FileResult is the outcome of one *.test.yaml file.
+type FileResult struct {
+	Path    string
+	Cases   []CaseResult
+	Updated bool
+}
+
+// Failed returns the number of failed cases.
+func (r FileResult) Failed() int {
+	n := 0
+	for _, c := range r.Cases {
+		if !c.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// RunFile runs every case of one *.test.yaml file.
+func RunFile(path string, opts Options) (FileResult, error) {
+	suite, err := LoadSuite(path)
+	if err != nil {
+		return FileResult{}, err
+	}
+	dir := strings.TrimSpace(opts.ProvidersDir)
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	reg := dslconfig.NewRegistry()
+	loaded, err := reg.ReloadFromDir(dir)
+	if err != nil {
+		return FileResult{}, fmt.Errorf("load providers dir %q: %w", dir, err)
+	}
+	if _, ok := reg.GetProvider(suite.Provider); !ok {
+		if reason := loaded.SkippedReasons[suite.Provider+".conf"]; reason != "" {
+			return FileResult{}, fmt.Errorf("%s: provider %q skipped: %s", path, suite.Provider, reason)
+		}
+		return FileResult{}, fmt.Errorf("%s: provider %q not found in %q", path, suite.Provider, dir)
+	}
+
+	res := FileResult{Path: path}
+	actuals := make([]caseActual, 0, len(suite.Cases))
+	for _, c := range suite.Cases {
+		actual, err := runCase(reg, suite.Provider, c)
+		if err != nil {
+			return FileResult{}, fmt.Errorf("%s: case %q: %w", path, c.Name, err)
+		}
+		actuals = append(actuals, actual)
+		cr := CaseResult{Name: c.Name}
+		if !opts.Update {
+			cr.Failures = compareCase(c, actual)
+		}
+		res.Cases = append(res.Cases, cr)
+	}
+	if opts.Update {
+		if err := updateGoldens(path, suite, actuals); err != nil {
+			return FileResult{}, err
+		}
+		res.Updated = true
+	}
+	return res, nil
+}
+
+// capturedRequest is what the fake upstream received.
+type capturedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+type caseActual struct {
+	Upstream     *capturedRequest
+	Status       int
+	ContentType  string
+	Body         []byte
+	Usage        map[string]any
+	FinishReason string
+	Err          error
+}
+
+// fakeUpstream answers every outbound request in-process with the case's canned response.
+type fakeUpstream struct {
+	resp UpstreamResponse
+
+	mu       sync.Mutex
+	captured *capturedRequest
+}
+
+func (f *fakeUpstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		_ = req.Body.Close()
+	}
+	f.mu.Lock()
+	f.captured = &capturedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body}
+	f.mu.Unlock()
+
+	status := f.resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := http.Header{}
+	var out []byte
+	switch {
+	case f.resp.SSE != "":
+		header.Set("Content-Type", "text/event-stream")
+		out = []byte(f.resp.SSE)
+	case f.resp.BodyRaw != "":
+		out = []byte(f.resp.BodyRaw)
+	case f.resp.Body != nil:
+		b, err := json.Marshal(f.resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("encode response.body: %w", err)
+		}
+		header.Set("Content-Type", "application/json")
+		out = b
+	}
+	for k, v := range f.resp.Headers {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(out)),
+		ContentLength: int64(len(out)),
+		Request:       req,
+	}, nil
+}
+
+func runCase(reg *dslconfig.Registry, provider string, c Case) (caseActual, error) {
+	gin.SetMode(gin.TestMode)
+	upstream := &fakeUpstream{resp: c.Response}
+	logger, err := logx.NewSystemLoggerWithOptions(logx.SystemLoggerOptions{Writer: io.Discard, Level: "error"})
+	if err != nil {
+		return caseActual{}, err
+	}
+	client := &proxy.Client{
+		HTTP:         &http.Client{Transport: upstream, Timeout: 10 * time.Second},
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		Registry:     reg,
+		SystemLogger: logger,
+	}
+
+	body, err := clientRequestBody(c.Request)
+	if err != nil {
+		return caseActual{}, err
+	}
+	method := strings.ToUpper(strings.TrimSpace(c.Request.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	path := strings.TrimSpace(c.Request.Path)
+	if path == "" {
+		path = "/v1/" + strings.ReplaceAll(strings.TrimSpace(c.API), ".", "/")
+	}
+	rec := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(rec)
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if c.Request.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.Request.Headers {
+		req.Header.Set(k, v)
+	}
+	gc.Request = req
+
+	key := strings.TrimSpace(c.Key)
+	if key == "" {
+		key = defaultTestKey
+	}
+	res, perr := client.ProxyJSON(gc, provider, proxy.ProviderKey{Name: "dsltest", Value: key}, strings.TrimSpace(c.API), c.Stream)
+
+	upstream.mu.Lock()
+	actual := caseActual{Upstream: upstream.captured, Err: perr}
+	upstream.mu.Unlock()
+	if perr != nil {
+		return actual, nil
+	}
+	actual.Status = rec.Code
+	actual.ContentType = rec.Header().Get("Content-Type")
+	actual.Body = rec.Body.Bytes()
+	if res != nil {
+		actual.Usage = res.Usage
+		actual.FinishReason = res.FinishReason
+	}
+	return actual, nil
+}
+
+func clientRequestBody(r ClientRequest) ([]byte, error) {
+	if r.BodyRaw != "" {
+		return []byte(r.BodyRaw), nil
+	}
+	if r.Body == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("encode request.body: %w", err)
+	}
+	return b, nil
+}
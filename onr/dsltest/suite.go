@@ -0,0 +1,165 @@
+// Package dsltest runs provider DSL golden cases (*.test.yaml) through the real proxy
+// pipeline against an in-process fake upstream.
+package dsltest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileSuffix is the file name suffix of provider golden case files, placed next to
+// the provider file (e.g. providers/openai.conf + providers/openai.test.yaml).
+const FileSuffix = ".test.yaml"
+
+// Suite is one *.test.yaml file.
+type Suite struct {
+	// Provider defaults to the file name without FileSuffix.
+	Provider string `yaml:"provider,omitempty"`
+	Cases    []Case `yaml:"cases"`
+}
+
+// Case is one golden case: a client request, a canned upstream response and the
+// expected upstream request / client response.
+type Case struct {
+	Name   string `yaml:"name"`
+	API    string `yaml:"api"`
+	Stream bool   `yaml:"stream,omitempty"`
+	// Key is the upstream key value ($channel.key); defaults to "test-key".
+	Key string `yaml:"key,omitempty"`
+
+	Request  ClientRequest    `yaml:"request"`
+	Response UpstreamResponse `yaml:"response"`
+
+	// Upstream and Expect are the goldens; `onr-admin dsl test --update` rewrites them.
+	Upstream *UpstreamExpect `yaml:"upstream,omitempty"`
+	Expect   *ClientExpect   `yaml:"expect,omitempty"`
+}
+
+// ClientRequest is the downstream request sent to ONR.
+type ClientRequest struct {
+	// Method defaults to POST.
+	Method string `yaml:"method,omitempty"`
+	// Path defaults to /v1/<api with dots replaced by slashes>, e.g. /v1/chat/completions.
+	Path    string            `yaml:"path,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Body is a JSON document written as YAML; BodyRaw is sent verbatim.
+	Body    any    `yaml:"body,omitempty"`
+	BodyRaw string `yaml:"body_raw,omitempty"`
+}
+
+// UpstreamResponse is the canned response returned by the fake upstream.
+type UpstreamResponse struct {
+	// Status defaults to 200.
+	Status  int               `yaml:"status,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    any               `yaml:"body,omitempty"`
+	BodyRaw string            `yaml:"body_raw,omitempty"`
+	// SSE is sent as text/event-stream.
+	SSE string `yaml:"sse,omitempty"`
+}
+
+// UpstreamExpect is the expected upstream request after DSL transforms. Headers are
+// matched as a subset (case-insensitive names).
+type UpstreamExpect struct {
+	Method  string            `yaml:"method,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    any               `yaml:"body,omitempty"`
+	BodyRaw string            `yaml:"body_raw,omitempty"`
+}
+
+// ClientExpect is the expected client-visible result.
+type ClientExpect struct {
+	Status  int    `yaml:"status,omitempty"`
+	Body    any    `yaml:"body,omitempty"`
+	BodyRaw string `yaml:"body_raw,omitempty"`
+	SSE     string `yaml:"sse,omitempty"`
+	// Usage is matched as a subset of the extracted usage fields.
+	Usage        map[string]any `yaml:"usage,omitempty"`
+	FinishReason string         `yaml:"finish_reason,omitempty"`
+	// Error is matched as a substring of the proxy error, for cases ONR rejects.
+	Error string `yaml:"error,omitempty"`
+	// IgnoreFields are JSON object keys (at any depth) removed from client bodies and
+	// SSE data payloads before comparison, e.g. generated "id" / "created".
+	IgnoreFields []string `yaml:"ignore_fields,omitempty"`
+}
+
+// LoadSuite reads and validates one *.test.yaml file.
+func LoadSuite(path string) (Suite, error) {
+	// #nosec G304 -- golden case files are selected by the operator.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Suite{}, fmt.Errorf("read %q: %w", path, err)
+	}
+	var s Suite
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return Suite{}, fmt.Errorf("parse %q: %w", path, err)
+	}
+	if strings.TrimSpace(s.Provider) == "" {
+		s.Provider = strings.TrimSuffix(filepath.Base(path), FileSuffix)
+	}
+	s.Provider = strings.ToLower(strings.TrimSpace(s.Provider))
+	seen := map[string]bool{}
+	for i, c := range s.Cases {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			return Suite{}, fmt.Errorf("%s: cases[%d]: name is required", path, i)
+		}
+		if seen[name] {
+			return Suite{}, fmt.Errorf("%s: duplicate case name %q", path, name)
+		}
+		seen[name] = true
+		if strings.TrimSpace(c.API) == "" {
+			return Suite{}, fmt.Errorf("%s: case %q: api is required", path, name)
+		}
+		if c.Request.Body != nil && c.Request.BodyRaw != "" {
+			return Suite{}, fmt.Errorf("%s: case %q: request.body and request.body_raw are mutually exclusive", path, name)
+		}
+		r := c.Response
+		n := 0
+		for _, set := range []bool{r.Body != nil, r.BodyRaw != "", r.SSE != ""} {
+			if set {
+				n++
+			}
+		}
+		if n > 1 {
+			return Suite{}, fmt.Errorf("%s: case %q: response accepts only one of body, body_raw or sse", path, name)
+		}
+	}
+	return s, nil
+}
+
+// Discover expands files and directories into *.test.yaml paths. Directories are
+// scanned recursively.
+func Discover(paths []string) ([]string, error) {
+	var out []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			out = append(out, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), FileSuffix) {
+				out = append(out, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
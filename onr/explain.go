@@ -0,0 +1,11 @@
+package onr
+
+import "github.com/r9s-ai/open-next-router/onr/internal/onrserver"
+
+// Explain resolves one explain request (see onrserver.ExplainRequest: method, path,
+// headers, provider, body) against the config at cfgPath without sending anything
+// upstream. It returns the status and JSON body the POST /admin/explain endpoint
+// would return: the resolved upstream call on success, otherwise the client error.
+func Explain(cfgPath string, request []byte) (int, []byte, error) {
+	return onrserver.Explain(cfgPath, request)
+}
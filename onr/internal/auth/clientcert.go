@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	//nolint:gosec // context key identifier, not credential material
+	ctxAccessKeyName = "onr.access_key"
+	//nolint:gosec // context key identifier, not credential material
+	ctxClientCertAuthenticated = "onr.client_cert_authenticated"
+	ctxClientCertSubject       = "onr.client_cert_subject"
+)
+
+// ClientCertMatcher maps a verified client certificate to an access key name.
+type ClientCertMatcher func(cert *x509.Certificate) (name string, ok bool)
+
+// ClientCertMiddleware authenticates requests that present a verified mTLS client certificate
+// mapped to an access key. Requests without a matching certificate fall through to Middleware.
+func ClientCertMiddleware(match ClientCertMatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cert := verifiedClientCert(c)
+		if cert == nil {
+			c.Next()
+			return
+		}
+		c.Set(ctxClientCertSubject, cert.Subject.String())
+		if match != nil {
+			if name, ok := match(cert); ok {
+				c.Set(ctxClientCertAuthenticated, true)
+				c.Set(ctxAccessKeyName, name)
+			}
+		}
+		c.Next()
+	}
+}
+
+// verifiedClientCert returns the leaf of the first verified chain, or nil when the
+// connection is not TLS or the client certificate was not verified against the CA bundle.
+func verifiedClientCert(c *gin.Context) *x509.Certificate {
+	if c.Request == nil || c.Request.TLS == nil {
+		return nil
+	}
+	for _, chain := range c.Request.TLS.VerifiedChains {
+		if len(chain) > 0 && chain[0] != nil {
+			return chain[0]
+		}
+	}
+	return nil
+}
+
+// AccessKeyName requires a non-nil Gin context from the auth middleware path.
+// It returns the matched access key name, or "" for master-key/BYOK requests.
+func AccessKeyName(c *gin.Context) string {
+	return strings.TrimSpace(c.GetString(ctxAccessKeyName))
+}
+
+// ClientCertSubject requires a non-nil Gin context from the auth middleware path.
+func ClientCertSubject(c *gin.Context) string {
+	return strings.TrimSpace(c.GetString(ctxClientCertSubject))
+}
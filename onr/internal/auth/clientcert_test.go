@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientCertMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	match := func(cert *x509.Certificate) (string, bool) {
+		if cert.Subject.CommonName == "svc-a" {
+			return "client-a", true
+		}
+		return "", false
+	}
+	r := gin.New()
+	r.Use(ClientCertMiddleware(match))
+	r.Use(Middleware("master", nil))
+	r.GET("/ok", func(c *gin.Context) {
+		c.String(http.StatusOK, AccessKeyName(c)+"|"+ClientCertSubject(c))
+	})
+
+	withCert := func(cn string, verified bool) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, withCert("svc-a", true))
+	if w.Code != http.StatusOK || w.Body.String() != "client-a|CN=svc-a" {
+		t.Fatalf("mapped cert: code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, withCert("svc-a", false))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unverified cert should not authenticate, code=%d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, withCert("unknown", true))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unmapped cert should not authenticate, code=%d", w.Code)
+	}
+
+	req := withCert("unknown", true)
+	req.Header.Set("Authorization", "Bearer master")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "|CN=unknown" {
+		t.Fatalf("bearer fallback: code=%d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestMiddleware_SetsAccessKeyName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	match := func(accessKey string) (string, bool) {
+		if accessKey == "ak-1" {
+			return "client1", true
+		}
+		return "", false
+	}
+	r := gin.New()
+	r.Use(Middleware("master", match))
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, AccessKeyName(c)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set("Authorization", "Bearer ak-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "client1" {
+		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -21,16 +21,11 @@ func Middleware(masterKey string, matchAccessKey AccessKeyMatcher, tokenOpts ...
 		allowBYOKWithoutK = tokenOpts[0].AllowBYOKWithoutK
 	}
 	return func(c *gin.Context) {
-		got := ""
-		if v := strings.TrimSpace(c.GetHeader("Authorization")); strings.HasPrefix(v, "Bearer ") {
-			got = strings.TrimSpace(strings.TrimPrefix(v, "Bearer "))
-		}
-		if got == "" {
-			got = strings.TrimSpace(c.GetHeader("x-api-key"))
-		}
-		if got == "" {
-			got = strings.TrimSpace(c.GetHeader("x-goog-api-key"))
+		if c.GetBool(ctxClientCertAuthenticated) {
+			c.Next()
+			return
 		}
+		got := requestAPIKey(c)
 
 		// Legacy: exact match master key.
 		if expected != "" && subtle.ConstantTimeCompare([]byte(got), []byte(expected)) == 1 {
@@ -38,7 +33,8 @@ func Middleware(masterKey string, matchAccessKey AccessKeyMatcher, tokenOpts ...
 			return
 		}
 		if matchAccessKey != nil {
-			if _, ok := matchAccessKey(got); ok {
+			if name, ok := matchAccessKey(got); ok {
+				setAccessKeyName(c, name)
 				c.Next()
 				return
 			}
@@ -56,7 +52,11 @@ func Middleware(masterKey string, matchAccessKey AccessKeyMatcher, tokenOpts ...
 						ok = true
 					}
 					if !ok && matchAccessKey != nil {
-						_, ok = matchAccessKey(accessKey)
+						var name string
+						name, ok = matchAccessKey(accessKey)
+						if ok {
+							setAccessKeyName(c, name)
+						}
 					}
 				} else if allowBYOKWithoutK && claims.Mode == TokenModeBYOK && strings.TrimSpace(claims.UpstreamKey) != "" {
 					ok = true
@@ -79,15 +79,65 @@ func Middleware(masterKey string, matchAccessKey AccessKeyMatcher, tokenOpts ...
 		}
 
 		{
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			abortUnauthorized(c)
+			return
+		}
+	}
+}
+
+// MasterKeyMiddleware admits only the configured master key. It guards admin endpoints
+// that expose data across every provider and access key, so access keys, token keys and
+// client certificates are rejected. Without a master key the endpoints are disabled.
+func MasterKeyMiddleware(masterKey string) gin.HandlerFunc {
+	expected := strings.TrimSpace(masterKey)
+	return func(c *gin.Context) {
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": gin.H{
-					"message": "unauthorized",
+					"message": "admin endpoints require auth.api_key (master key)",
 					"type":    "invalid_request_error",
-					"code":    "invalid_api_key",
+					"code":    "admin_disabled",
 				},
 			})
 			return
 		}
+		if subtle.ConstantTimeCompare([]byte(requestAPIKey(c)), []byte(expected)) != 1 {
+			abortUnauthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestAPIKey returns the credential from Authorization: Bearer, x-api-key or
+// x-goog-api-key, in that order.
+func requestAPIKey(c *gin.Context) string {
+	got := ""
+	if v := strings.TrimSpace(c.GetHeader("Authorization")); strings.HasPrefix(v, "Bearer ") {
+		got = strings.TrimSpace(strings.TrimPrefix(v, "Bearer "))
+	}
+	if got == "" {
+		got = strings.TrimSpace(c.GetHeader("x-api-key"))
+	}
+	if got == "" {
+		got = strings.TrimSpace(c.GetHeader("x-goog-api-key"))
+	}
+	return got
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": gin.H{
+			"message": "unauthorized",
+			"type":    "invalid_request_error",
+			"code":    "invalid_api_key",
+		},
+	})
+}
+
+func setAccessKeyName(c *gin.Context, name string) {
+	if n := strings.TrimSpace(name); n != "" {
+		c.Set(ctxAccessKeyName, n)
 	}
 }
 
@@ -129,3 +129,29 @@ func TestMiddleware_TokenKey_BYOKWithoutK_Enabled(t *testing.T) {
 		t.Fatalf("code=%d body=%s", w.Code, w.Body.String())
 	}
 }
+
+func TestMasterKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		master string
+		key    string
+		want   int
+	}{
+		{master: "master", key: "master", want: http.StatusOK},
+		{master: "master", key: "ak-1", want: http.StatusUnauthorized},
+		{master: "", key: "", want: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(MasterKeyMiddleware(tc.master))
+		r.GET("/admin", func(c *gin.Context) { c.String(200, "ok") })
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("x-api-key", tc.key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("master=%q key=%q code=%d want=%d", tc.master, tc.key, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Access log encodings.
+const (
+	AccessLogEncodingText   = "text"
+	AccessLogEncodingJSON   = "json"
+	AccessLogEncodingLogfmt = "logfmt"
+)
+
+// AccessLogEntry is one request as seen by the access log middleware.
+type AccessLogEntry struct {
+	Time     time.Time
+	Status   int
+	Latency  time.Duration
+	ClientIP string
+	Method   string
+	Path     string
+	// Fields are the collected onr.* context fields keyed by log name.
+	Fields map[string]any
+}
+
+// AccessLogEncoder renders an entry as a single line without a trailing newline.
+type AccessLogEncoder interface {
+	Encode(e AccessLogEntry) []byte
+}
+
+// NormalizeAccessLogEncoding returns the canonical encoding name; empty means text.
+func NormalizeAccessLogEncoding(encoding string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(encoding)); v {
+	case "", AccessLogEncodingText:
+		return AccessLogEncodingText, nil
+	case AccessLogEncodingJSON, AccessLogEncodingLogfmt:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid access log encoding %q (want text, json or logfmt)", encoding)
+	}
+}
+
+// NewAccessLogEncoder returns a non-nil encoder. The text encoding renders
+// formatter when set (access_log_format / preset) and the default request line
+// otherwise; color only applies to text.
+func NewAccessLogEncoder(encoding string, formatter *AccessLogFormatter, color bool) (AccessLogEncoder, error) {
+	enc, err := NormalizeAccessLogEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	switch enc {
+	case AccessLogEncodingJSON:
+		return jsonAccessLogEncoder{}, nil
+	case AccessLogEncodingLogfmt:
+		return logfmtAccessLogEncoder{}, nil
+	default:
+		return textAccessLogEncoder{formatter: formatter, color: color}, nil
+	}
+}
+
+type textAccessLogEncoder struct {
+	formatter *AccessLogFormatter
+	color     bool
+}
+
+func (t textAccessLogEncoder) Encode(e AccessLogEntry) []byte {
+	if t.formatter != nil {
+		return []byte(t.formatter.Format(e.Time, e.Status, e.Latency, e.ClientIP, e.Method, e.Path, e.Fields, t.color))
+	}
+	return []byte(FormatRequestLineWithColor(e.Time, e.Status, e.Latency, e.ClientIP, e.Method, e.Path, e.Fields, t.color))
+}
+
+// accessLogHeadKeys are written first by the structured encoders.
+var accessLogHeadKeys = []string{"ts", "status", "latency_ms", "client_ip", "method", "path"}
+
+// structuredAccessLogPairs returns the head fields followed by every collected
+// context field in access log order. Nil values are skipped.
+func structuredAccessLogPairs(e AccessLogEntry) ([]string, []any) {
+	head := map[string]any{
+		"ts":         e.Time.UTC().Format(time.RFC3339Nano),
+		"status":     e.Status,
+		"latency_ms": e.Latency.Milliseconds(),
+		"client_ip":  strings.TrimSpace(e.ClientIP),
+		"method":     strings.TrimSpace(e.Method),
+		"path":       e.Path,
+	}
+	if v, ok := e.Fields["latency_ms"]; ok && v != nil {
+		head["latency_ms"] = v
+	}
+	keys := make([]string, 0, len(head)+len(e.Fields))
+	values := make([]any, 0, len(head)+len(e.Fields))
+	for _, k := range accessLogHeadKeys {
+		keys = append(keys, k)
+		values = append(values, head[k])
+	}
+	for _, k := range orderedAccessFieldKeys(e.Fields) {
+		if _, isHead := head[k]; isHead {
+			continue
+		}
+		v := e.Fields[k]
+		if v == nil {
+			continue
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	return keys, values
+}
+
+type jsonAccessLogEncoder struct{}
+
+func (jsonAccessLogEncoder) Encode(e AccessLogEntry) []byte {
+	keys, values := structuredAccessLogPairs(e)
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		raw, err := json.Marshal(values[i])
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprintf("%v", values[i]))
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		b.Write(kb)
+		b.WriteByte(':')
+		b.Write(raw)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+type logfmtAccessLogEncoder struct{}
+
+func (logfmtAccessLogEncoder) Encode(e AccessLogEntry) []byte {
+	keys, values := structuredAccessLogPairs(e)
+	var b strings.Builder
+	for i, k := range keys {
+		v := logfmtValue(values[i])
+		if v == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+	return []byte(b.String())
+}
+
+// logfmtValue formats v and quotes it when it contains spaces, quotes, '=' or
+// control characters. Empty strings are omitted by the caller.
+func logfmtValue(v any) string {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = strings.TrimSpace(t)
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(t), 'f', -1, 32)
+	default:
+		s = strings.TrimSpace(fmt.Sprintf("%v", v))
+		if s == "<nil>" {
+			s = ""
+		}
+	}
+	if s == "" {
+		return ""
+	}
+	if strings.ContainsAny(s, " =\"\\") || strings.IndexFunc(s, func(r rune) bool { return r < 0x20 }) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package logx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAccessLogEntry() AccessLogEntry {
+	return AccessLogEntry{
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Status:   200,
+		Latency:  1500 * time.Millisecond,
+		ClientIP: "127.0.0.1",
+		Method:   "POST",
+		Path:     "/v1/chat/completions",
+		Fields: map[string]any{
+			"request_id":     "rid-1",
+			"provider":       "openai",
+			"model":          "gpt-4o-mini",
+			"stream":         false,
+			"latency_ms":     int64(1234),
+			"input_tokens":   12,
+			"cost_total":     0.0025,
+			"finish_reason":  "stop",
+			"web_search":     2,
+			"appname":        "my app",
+			"cost_line_item": nil,
+		},
+	}
+}
+
+func TestJSONAccessLogEncoder(t *testing.T) {
+	enc, err := NewAccessLogEncoder("JSON", nil, true)
+	if err != nil {
+		t.Fatalf("NewAccessLogEncoder: %v", err)
+	}
+	line := string(enc.Encode(testAccessLogEntry()))
+	var got map[string]any
+	if err := json.Unmarshal([]byte(line), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", line, err)
+	}
+	want := map[string]any{
+		"ts":            "2026-01-02T03:04:05Z",
+		"status":        float64(200),
+		"latency_ms":    float64(1234),
+		"method":        "POST",
+		"path":          "/v1/chat/completions",
+		"request_id":    "rid-1",
+		"stream":        false,
+		"input_tokens":  float64(12),
+		"cost_total":    0.0025,
+		"web_search":    float64(2),
+		"finish_reason": "stop",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("field %s=%#v, want %#v (line=%s)", k, got[k], v, line)
+		}
+	}
+	if _, ok := got["cost_line_item"]; ok {
+		t.Fatalf("nil field should be omitted: %s", line)
+	}
+	if !strings.HasPrefix(line, `{"ts":`) || strings.Index(line, `"model"`) > strings.Index(line, `"input_tokens"`) {
+		t.Fatalf("unexpected field order: %s", line)
+	}
+}
+
+func TestLogfmtAccessLogEncoder(t *testing.T) {
+	enc, err := NewAccessLogEncoder("logfmt", nil, false)
+	if err != nil {
+		t.Fatalf("NewAccessLogEncoder: %v", err)
+	}
+	line := string(enc.Encode(testAccessLogEntry()))
+	for _, want := range []string{
+		"ts=2026-01-02T03:04:05Z status=200 latency_ms=1234 client_ip=127.0.0.1 method=POST path=/v1/chat/completions",
+		`appname="my app"`,
+		"cost_total=0.0025",
+		"stream=false",
+	} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("logfmt line missing %q: %s", want, line)
+		}
+	}
+}
+
+func TestNewAccessLogEncoder_TextAndInvalid(t *testing.T) {
+	f, err := CompileAccessLogFormat("$method $path $model")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	enc, err := NewAccessLogEncoder("", f, false)
+	if err != nil {
+		t.Fatalf("NewAccessLogEncoder: %v", err)
+	}
+	if got := string(enc.Encode(testAccessLogEntry())); got != "POST /v1/chat/completions gpt-4o-mini" {
+		t.Fatalf("unexpected text line: %q", got)
+	}
+	if _, err := NewAccessLogEncoder("xml", nil, false); err == nil {
+		t.Fatalf("expected invalid encoding error")
+	}
+}
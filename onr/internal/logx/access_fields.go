@@ -13,19 +13,26 @@ var standardUsageContextFieldSpecs = []AccessLogContextFieldSpec{
 	{CtxKey: "onr.usage_total_tokens", LogKey: "total_tokens"},
 	{CtxKey: "onr.usage_cache_read_tokens", LogKey: "cache_read_tokens"},
 	{CtxKey: "onr.usage_cache_write_tokens", LogKey: "cache_write_tokens"},
+	{CtxKey: "onr.usage_reasoning_tokens", LogKey: "reasoning_tokens"},
 }
 
 var costContextFieldSpecs = []AccessLogContextFieldSpec{
 	{CtxKey: "onr.cost_total", LogKey: "cost_total"},
 	{CtxKey: "onr.cost_input", LogKey: "cost_input"},
 	{CtxKey: "onr.cost_output", LogKey: "cost_output"},
+	{CtxKey: "onr.cost_reasoning", LogKey: "cost_reasoning"},
 	{CtxKey: "onr.cost_cache_read", LogKey: "cost_cache_read"},
 	{CtxKey: "onr.cost_cache_write", LogKey: "cost_cache_write"},
+	{CtxKey: "onr.cost_modality", LogKey: "cost_modality"},
+	{CtxKey: "onr.cost_extra", LogKey: "cost_extra"},
 	{CtxKey: "onr.billable_input_tokens", LogKey: "billable_input_tokens"},
 	{CtxKey: "onr.cost_multiplier", LogKey: "cost_multiplier"},
 	{CtxKey: "onr.cost_model", LogKey: "cost_model"},
 	{CtxKey: "onr.cost_channel", LogKey: "cost_channel"},
 	{CtxKey: "onr.cost_unit", LogKey: "cost_unit"},
+	{CtxKey: "onr.cost_tier", LogKey: "cost_tier"},
+	{CtxKey: "onr.cost_service_tier", LogKey: "cost_service_tier"},
+	{CtxKey: "onr.cost_line_items", LogKey: "cost_line_items"},
 }
 
 var trailingTokenFieldOrder = []string{
@@ -34,11 +41,15 @@ var trailingTokenFieldOrder = []string{
 	"total_tokens",
 	"cache_read_tokens",
 	"cache_write_tokens",
+	"reasoning_tokens",
 	"billable_input_tokens",
 	"cost_input",
 	"cost_output",
+	"cost_reasoning",
 	"cost_cache_read",
 	"cost_cache_write",
+	"cost_modality",
+	"cost_extra",
 	"cost_total",
 }
 
@@ -48,6 +59,7 @@ var leadingAccessFieldOrder = []string{
 	"model",
 	"request_id",
 	"appname",
+	"access_key",
 	"latency_ms",
 	"provider",
 	"provider_source",
@@ -56,8 +68,11 @@ var leadingAccessFieldOrder = []string{
 	"cost_model",
 	"cost_multiplier",
 	"cost_unit",
+	"cost_tier",
+	"cost_service_tier",
 	"upstream_status",
 	"finish_reason",
+	"guard",
 	"ttft_ms",
 	"tps",
 }
@@ -65,6 +80,7 @@ var leadingAccessFieldOrder = []string{
 var standardUsageFieldOrder = logKeysOfSpecs(standardUsageContextFieldSpecs)
 
 var baseAccessLogContextFieldSpecs = []AccessLogContextFieldSpec{
+	{CtxKey: "onr.access_key", LogKey: "access_key"},
 	{CtxKey: "onr.provider", LogKey: "provider"},
 	{CtxKey: "onr.provider_source", LogKey: "provider_source"},
 	{CtxKey: "onr.api", LogKey: "api"},
@@ -73,6 +89,7 @@ var baseAccessLogContextFieldSpecs = []AccessLogContextFieldSpec{
 	{CtxKey: "onr.usage_stage", LogKey: "usage_stage"},
 	{CtxKey: "onr.upstream_status", LogKey: "upstream_status"},
 	{CtxKey: "onr.finish_reason", LogKey: "finish_reason"},
+	{CtxKey: "onr.guard_hits", LogKey: "guard"},
 	{CtxKey: "onr.ttft_ms", LogKey: "ttft_ms"},
 	{CtxKey: "onr.tps", LogKey: "tps"},
 }
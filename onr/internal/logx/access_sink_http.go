@@ -0,0 +1,229 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHTTPSinkBatchSize     = 100
+	defaultHTTPSinkBufferSize    = 10000
+	defaultHTTPSinkFlushInterval = time.Second
+	defaultHTTPSinkTimeout       = 5 * time.Second
+)
+
+// AccessLogHTTPSinkOptions configures an HTTP batch sink.
+type AccessLogHTTPSinkOptions struct {
+	URL     string
+	Headers map[string]string
+	// BatchSize is the max lines per POST (default 100).
+	BatchSize int
+	// FlushInterval sends a partial batch after this long (default 1s).
+	FlushInterval time.Duration
+	// BufferSize is the number of queued lines (default 10000).
+	BufferSize int
+	// BlockTimeout is how long Write waits for buffer space before dropping
+	// the line. Zero drops immediately so requests never wait on the sink.
+	BlockTimeout time.Duration
+	// Timeout bounds each POST (default 5s).
+	Timeout time.Duration
+	// Client overrides the HTTP client in tests.
+	Client *http.Client
+}
+
+// AccessLogSinkStats are the cumulative counters of a buffered sink.
+type AccessLogSinkStats struct {
+	Sent    uint64 `json:"sent"`
+	Dropped uint64 `json:"dropped"`
+	Failed  uint64 `json:"failed"`
+}
+
+// AccessLogHTTPSink buffers lines and POSTs them as newline-delimited batches
+// (Content-Type: application/x-ndjson). Lines are dropped, not retried, when
+// the buffer is full or a POST fails; both are counted in Stats.
+type AccessLogHTTPSink struct {
+	url       string
+	headers   map[string]string
+	batchSize int
+	interval  time.Duration
+	block     time.Duration
+	timeout   time.Duration
+	client    *http.Client
+
+	queue chan []byte
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	closeOnce sync.Once
+	closeMu   sync.RWMutex
+	closed    bool
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// NewAccessLogHTTPSink returns a non-nil sink and starts its sender goroutine.
+func NewAccessLogHTTPSink(opts AccessLogHTTPSinkOptions) (*AccessLogHTTPSink, error) {
+	u := strings.TrimSpace(opts.URL)
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return nil, fmt.Errorf("invalid access log http url %q", opts.URL)
+	}
+	s := &AccessLogHTTPSink{
+		url:       u,
+		headers:   opts.Headers,
+		batchSize: opts.BatchSize,
+		interval:  opts.FlushInterval,
+		block:     opts.BlockTimeout,
+		timeout:   opts.Timeout,
+		client:    opts.Client,
+		done:      make(chan struct{}),
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultHTTPSinkBatchSize
+	}
+	if s.interval <= 0 {
+		s.interval = defaultHTTPSinkFlushInterval
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultHTTPSinkTimeout
+	}
+	if s.client == nil {
+		s.client = &http.Client{}
+	}
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultHTTPSinkBufferSize
+	}
+	s.queue = make(chan []byte, bufferSize)
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// Write queues one line. It only fails after Close; a full buffer drops the
+// line (after BlockTimeout) and counts it instead of returning an error.
+func (s *AccessLogHTTPSink) Write(p []byte) (int, error) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return 0, errors.New("access log http sink is closed")
+	}
+	line := bytes.TrimRight(p, "\n")
+	line = append(make([]byte, 0, len(line)+1), line...)
+	select {
+	case s.queue <- line:
+		return len(p), nil
+	default:
+	}
+	if s.block > 0 {
+		t := time.NewTimer(s.block)
+		defer t.Stop()
+		select {
+		case s.queue <- line:
+			return len(p), nil
+		case <-t.C:
+		}
+	}
+	s.dropped.Add(1)
+	return len(p), nil
+}
+
+// Stats returns the cumulative sent/dropped/failed line counts.
+func (s *AccessLogHTTPSink) Stats() AccessLogSinkStats {
+	return AccessLogSinkStats{Sent: s.sent.Load(), Dropped: s.dropped.Load(), Failed: s.failed.Load()}
+}
+
+// Close flushes queued lines and stops the sender.
+func (s *AccessLogHTTPSink) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeMu.Lock()
+		s.closed = true
+		s.closeMu.Unlock()
+		close(s.done)
+		s.wg.Wait()
+	})
+	return nil
+}
+
+func (s *AccessLogHTTPSink) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.batchSize)
+	var reportedDropped uint64
+	flush := func() {
+		if len(batch) > 0 {
+			s.post(batch)
+			batch = batch[:0]
+		}
+		if d := s.dropped.Load(); d != reportedDropped {
+			log.Printf("[ONR] WARN | access_log | access log http sink buffer full, lines dropped | url=%s dropped_total=%d", s.url, d)
+			reportedDropped = d
+		}
+	}
+	for {
+		select {
+		case line := <-s.queue:
+			batch = append(batch, line)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			for {
+				select {
+				case line := <-s.queue:
+					batch = append(batch, line)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *AccessLogHTTPSink) post(batch [][]byte) {
+	n := uint64(len(batch))
+	body := append(bytes.Join(batch, []byte{'\n'}), '\n')
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		s.failed.Add(n)
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		total := s.failed.Add(n)
+		log.Printf("[ONR] WARN | access_log | access log http sink post failed | url=%s lines=%d failed_total=%d error=%v", s.url, n, total, err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		total := s.failed.Add(n)
+		log.Printf("[ONR] WARN | access_log | access log http sink post failed | url=%s lines=%d failed_total=%d status=%d", s.url, n, total, resp.StatusCode)
+		return
+	}
+	s.sent.Add(n)
+}
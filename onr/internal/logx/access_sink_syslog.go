@@ -0,0 +1,169 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogFacilities maps facility names to RFC 5424 facility codes.
+var syslogFacilities = map[string]int{
+	"user":   1,
+	"daemon": 3,
+	"local0": 16,
+	"local1": 17,
+	"local2": 18,
+	"local3": 19,
+	"local4": 20,
+	"local5": 21,
+	"local6": 22,
+	"local7": 23,
+}
+
+const syslogSeverityInfo = 6
+
+// AccessLogSyslogOptions configures a syslog sink.
+type AccessLogSyslogOptions struct {
+	// Network is udp (default) or tcp.
+	Network string
+	// Address is host:port of the syslog receiver.
+	Address string
+	// Tag is the RFC 5424 APP-NAME (default "onr").
+	Tag string
+	// Facility is user, daemon or local0..local7 (default local0).
+	Facility string
+	// DialTimeout bounds tcp connects (default 5s).
+	DialTimeout time.Duration
+}
+
+// AccessLogSyslogSink sends each line as one RFC 5424 message at severity
+// info. TCP uses octet-counting framing and redials once after a write error.
+type AccessLogSyslogSink struct {
+	network  string
+	address  string
+	tag      string
+	priority int
+	hostname string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewAccessLogSyslogSink returns a non-nil sink and dials the receiver.
+func NewAccessLogSyslogSink(opts AccessLogSyslogOptions) (*AccessLogSyslogSink, error) {
+	network := strings.ToLower(strings.TrimSpace(opts.Network))
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("invalid syslog network %q (want udp or tcp)", opts.Network)
+	}
+	addr := strings.TrimSpace(opts.Address)
+	if addr == "" {
+		return nil, errors.New("syslog address is empty")
+	}
+	facility, err := ParseSyslogFacility(opts.Facility)
+	if err != nil {
+		return nil, err
+	}
+	tag := strings.TrimSpace(opts.Tag)
+	if tag == "" {
+		tag = "onr"
+	}
+	host, _ := os.Hostname()
+	if strings.TrimSpace(host) == "" {
+		host = "-"
+	}
+	timeout := opts.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	s := &AccessLogSyslogSink{
+		network:  network,
+		address:  addr,
+		tag:      tag,
+		priority: facility*8 + syslogSeverityInfo,
+		hostname: host,
+		timeout:  timeout,
+	}
+	if err := s.dialLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseSyslogFacility returns the facility code; empty means local0.
+func ParseSyslogFacility(name string) (int, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if n == "" {
+		n = "local0"
+	}
+	code, ok := syslogFacilities[n]
+	if !ok {
+		return 0, fmt.Errorf("invalid syslog facility %q", name)
+	}
+	return code, nil
+}
+
+func (s *AccessLogSyslogSink) dialLocked() error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return fmt.Errorf("dial syslog %s://%s: %w", s.network, s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Write sends p (one access log line) as a syslog message.
+func (s *AccessLogSyslogSink) Write(p []byte) (int, error) {
+	msg := s.format(time.Now(), strings.TrimRight(string(p), "\n"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.dialLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := s.conn.Write(msg); err != nil {
+		if s.network != "tcp" {
+			return 0, err
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+		if derr := s.dialLocked(); derr != nil {
+			return 0, err
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *AccessLogSyslogSink) format(ts time.Time, line string) []byte {
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := "<" + strconv.Itoa(s.priority) + ">1 " + ts.UTC().Format(time.RFC3339Nano) + " " +
+		s.hostname + " " + s.tag + " " + strconv.Itoa(os.Getpid()) + " access - " + line
+	if s.network == "tcp" {
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+	return []byte(msg)
+}
+
+// Close closes the connection.
+func (s *AccessLogSyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,148 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access log sink types selectable per output.
+const (
+	AccessLogSinkStdout = "stdout"
+	AccessLogSinkFile   = "file"
+	AccessLogSinkSyslog = "syslog"
+	AccessLogSinkHTTP   = "http"
+)
+
+// accessLogErrorReportInterval limits write-failure warnings per output.
+const accessLogErrorReportInterval = 30 * time.Second
+
+// AccessLogOutput pairs an encoder with a sink. Each Sink.Write call receives
+// exactly one encoded line including the trailing newline.
+type AccessLogOutput struct {
+	// Name identifies the output in warnings, e.g. "http:https://logs.example.com".
+	Name    string
+	Encoder AccessLogEncoder
+	Sink    io.WriteCloser
+}
+
+type accessLogOutputState struct {
+	AccessLogOutput
+
+	mu           sync.Mutex
+	failed       uint64
+	lastReported time.Time
+}
+
+// AccessLogOutputs fans access log entries out to several outputs.
+type AccessLogOutputs struct {
+	outputs []*accessLogOutputState
+}
+
+// NewAccessLogOutputs returns a non-nil fan-out over outs.
+func NewAccessLogOutputs(outs ...AccessLogOutput) *AccessLogOutputs {
+	o := &AccessLogOutputs{outputs: make([]*accessLogOutputState, 0, len(outs))}
+	for _, out := range outs {
+		o.outputs = append(o.outputs, &accessLogOutputState{AccessLogOutput: out})
+	}
+	return o
+}
+
+// Log encodes e once per output and writes it. Write failures never block the
+// request; they are counted and reported at most every 30s per output.
+func (o *AccessLogOutputs) Log(e AccessLogEntry) {
+	if o == nil {
+		return
+	}
+	for _, out := range o.outputs {
+		line := append(out.Encoder.Encode(e), '\n')
+		out.mu.Lock()
+		if _, err := out.Sink.Write(line); err != nil {
+			out.failed++
+			if now := time.Now(); now.Sub(out.lastReported) >= accessLogErrorReportInterval {
+				out.lastReported = now
+				log.Printf("[ONR] WARN | access_log | access log write failed | output=%s failed_total=%d error=%v", out.Name, out.failed, err)
+			}
+		}
+		out.mu.Unlock()
+	}
+}
+
+// AccessLogOutputStats are the counters of one output. Buffered is true when the
+// sink queues lines (http), so Sent and Dropped are tracked; Failed also counts
+// failed sink writes of unbuffered outputs.
+type AccessLogOutputStats struct {
+	Name     string `json:"name"`
+	Buffered bool   `json:"buffered"`
+	AccessLogSinkStats
+}
+
+// accessLogStatsSink is implemented by buffered sinks such as AccessLogHTTPSink.
+type accessLogStatsSink interface {
+	Stats() AccessLogSinkStats
+}
+
+// Stats returns the counters of every output in configuration order.
+func (o *AccessLogOutputs) Stats() []AccessLogOutputStats {
+	if o == nil {
+		return nil
+	}
+	out := make([]AccessLogOutputStats, 0, len(o.outputs))
+	for _, st := range o.outputs {
+		s := AccessLogOutputStats{Name: st.Name}
+		if ss, ok := st.Sink.(accessLogStatsSink); ok {
+			s.Buffered = true
+			s.AccessLogSinkStats = ss.Stats()
+		}
+		st.mu.Lock()
+		s.Failed += st.failed
+		st.mu.Unlock()
+		out = append(out, s)
+	}
+	return out
+}
+
+// Close closes every sink and returns the joined errors.
+func (o *AccessLogOutputs) Close() error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	for _, out := range o.outputs {
+		if err := out.Sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", out.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewAccessLogStdoutSink returns a sink writing to stdout; Close is a no-op.
+func NewAccessLogStdoutSink() io.WriteCloser {
+	return nopWriteCloser{Writer: os.Stdout}
+}
+
+// OpenAccessLogFile opens path for appending, creating its directory.
+func OpenAccessLogFile(path string) (*os.File, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, errors.New("access log file path is empty")
+	}
+	dir := filepath.Dir(p)
+	if strings.TrimSpace(dir) != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+	// #nosec G304 -- access log paths come from trusted config/env.
+	return os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+}
@@ -0,0 +1,177 @@
+package logx
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type bufferSink struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferSink) Close() error { b.closed = true; return nil }
+
+func TestAccessLogOutputs_FansOutPerEncoding(t *testing.T) {
+	jsonEnc, _ := NewAccessLogEncoder(AccessLogEncodingJSON, nil, false)
+	textEnc, _ := NewAccessLogEncoder(AccessLogEncodingText, nil, false)
+	a, b := &bufferSink{}, &bufferSink{}
+	outs := NewAccessLogOutputs(
+		AccessLogOutput{Name: "a", Encoder: jsonEnc, Sink: a},
+		AccessLogOutput{Name: "b", Encoder: textEnc, Sink: b},
+	)
+	outs.Log(testAccessLogEntry())
+	if err := outs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !strings.HasPrefix(a.String(), `{"ts":`) || !strings.HasSuffix(a.String(), "}\n") {
+		t.Fatalf("unexpected json output: %q", a.String())
+	}
+	if !strings.HasPrefix(b.String(), "[ONR] ") || !a.closed || !b.closed {
+		t.Fatalf("unexpected text output or close state: %q", b.String())
+	}
+}
+
+func TestAccessLogSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	s, err := NewAccessLogSyslogSink(AccessLogSyslogOptions{Address: pc.LocalAddr().String(), Facility: "local1", Tag: "onr-test"})
+	if err != nil {
+		t.Fatalf("NewAccessLogSyslogSink: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	if _, err := s.Write([]byte("status=200 path=/v1/models\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+	// local1 (17) * 8 + info (6) = 142
+	if !strings.HasPrefix(msg, "<142>1 ") || !strings.Contains(msg, " onr-test ") || !strings.HasSuffix(msg, " access - status=200 path=/v1/models") {
+		t.Fatalf("unexpected syslog message: %q", msg)
+	}
+
+	if _, err := NewAccessLogSyslogSink(AccessLogSyslogOptions{Address: "127.0.0.1:1", Facility: "mail"}); err == nil {
+		t.Fatalf("expected invalid facility error")
+	}
+}
+
+func TestAccessLogHTTPSink_BatchesAndDrops(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		bodies  []string
+		started = make(chan struct{}, 4)
+		release = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Header.Get("Content-Type")+"|"+r.Header.Get("X-Token")+"|"+string(b))
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	s, err := NewAccessLogHTTPSink(AccessLogHTTPSinkOptions{
+		URL:           srv.URL,
+		Headers:       map[string]string{"X-Token": "t"},
+		BatchSize:     1,
+		BufferSize:    1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewAccessLogHTTPSink: %v", err)
+	}
+	_, _ = s.Write([]byte("line-1\n"))
+	<-started // sender is now blocked posting line-1
+	_, _ = s.Write([]byte("line-2\n"))
+	_, _ = s.Write([]byte("line-3\n"))
+	close(release)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	st := s.Stats()
+	if st.Sent != 2 || st.Dropped != 1 || st.Failed != 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != "application/x-ndjson|t|line-1\n" || bodies[1] != "application/x-ndjson|t|line-2\n" {
+		t.Fatalf("unexpected bodies: %q", bodies)
+	}
+	if _, err := s.Write([]byte("late\n")); err == nil {
+		t.Fatalf("expected write after close to fail")
+	}
+}
+
+func TestAccessLogHTTPSink_CountsFailedPosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s, err := NewAccessLogHTTPSink(AccessLogHTTPSinkOptions{URL: srv.URL, BatchSize: 10, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewAccessLogHTTPSink: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = s.Write([]byte("x\n"))
+	}
+	_ = s.Close()
+	if st := s.Stats(); st.Failed != 3 || st.Sent != 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+func (failingSink) Close() error              { return nil }
+
+func TestAccessLogOutputs_Stats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	httpSink, err := NewAccessLogHTTPSink(AccessLogHTTPSinkOptions{URL: srv.URL, BatchSize: 10, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewAccessLogHTTPSink: %v", err)
+	}
+	enc, _ := NewAccessLogEncoder(AccessLogEncodingJSON, nil, false)
+	outs := NewAccessLogOutputs(
+		AccessLogOutput{Name: "file", Encoder: enc, Sink: failingSink{}},
+		AccessLogOutput{Name: "http", Encoder: enc, Sink: httpSink},
+	)
+	outs.Log(testAccessLogEntry())
+	outs.Log(testAccessLogEntry())
+	if err := outs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got := outs.Stats()
+	want := []AccessLogOutputStats{
+		{Name: "file", AccessLogSinkStats: AccessLogSinkStats{Failed: 2}},
+		{Name: "http", Buffered: true, AccessLogSinkStats: AccessLogSinkStats{Sent: 2}},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("Stats()=%+v want %+v", got, want)
+	}
+	if (*AccessLogOutputs)(nil).Stats() != nil {
+		t.Fatalf("nil outputs should report no stats")
+	}
+}
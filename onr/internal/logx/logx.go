@@ -90,24 +90,6 @@ func formatFields(fields map[string]any) string {
 		return ""
 	}
 
-	keys := make([]string, 0, len(fields))
-	extraKeys := make([]string, 0, len(fields))
-	for k := range fields {
-		if _, ok := tokenFieldKeys[k]; ok {
-			continue
-		}
-		if _, ok := leadingAccessFieldKeys[k]; ok {
-			continue
-		}
-		if _, ok := fixedAccessFieldKeys[k]; ok {
-			keys = append(keys, k)
-			continue
-		}
-		extraKeys = append(extraKeys, k)
-	}
-	sort.Strings(keys)
-	sort.Strings(extraKeys)
-
 	parts := make([]string, 0, len(fields))
 	appendIfPresent := func(k string) {
 		v, ok := fields[k]
@@ -153,19 +135,46 @@ func formatFields(fields map[string]any) string {
 		}
 	}
 
-	for _, k := range leadingAccessFieldOrder {
-		appendIfPresent(k)
-	}
-	for _, k := range keys {
+	for _, k := range orderedAccessFieldKeys(fields) {
 		appendIfPresent(k)
 	}
-	for _, k := range extraKeys {
-		appendIfPresent(k)
+	return strings.Join(parts, " ")
+}
+
+// orderedAccessFieldKeys returns the keys present in fields in access log
+// order: leading fields, other known fields and extra usage fields (sorted),
+// then token usage and cost fields at the end for readability.
+func orderedAccessFieldKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	extraKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		if _, ok := tokenFieldKeys[k]; ok {
+			continue
+		}
+		if _, ok := leadingAccessFieldKeys[k]; ok {
+			continue
+		}
+		if _, ok := fixedAccessFieldKeys[k]; ok {
+			keys = append(keys, k)
+			continue
+		}
+		extraKeys = append(extraKeys, k)
 	}
+	sort.Strings(keys)
+	sort.Strings(extraKeys)
 
-	// Keep token usage fields at the end for readability.
+	out := make([]string, 0, len(fields))
+	for _, k := range leadingAccessFieldOrder {
+		if _, ok := fields[k]; ok {
+			out = append(out, k)
+		}
+	}
+	out = append(out, keys...)
+	out = append(out, extraKeys...)
 	for _, k := range trailingTokenFieldOrder {
-		appendIfPresent(k)
+		if _, ok := fields[k]; ok {
+			out = append(out, k)
+		}
 	}
-	return strings.Join(parts, " ")
+	return out
 }
@@ -23,17 +23,23 @@ const (
 )
 
 const (
-	SystemCategoryStartup   = "startup"
-	SystemCategoryServer    = "server"
-	SystemCategoryReload    = "reload"
-	SystemCategoryProviders = "providers"
+	SystemCategoryStartup     = "startup"
+	SystemCategoryServer      = "server"
+	SystemCategoryReload      = "reload"
+	SystemCategoryProviders   = "providers"
+	SystemCategoryTrafficDump = "traffic_dump"
+	SystemCategoryModelsSync  = "models_sync"
+	SystemCategoryBalance     = "balance"
 )
 
 var allowedSystemCategories = map[string]struct{}{
-	SystemCategoryStartup:   {},
-	SystemCategoryServer:    {},
-	SystemCategoryReload:    {},
-	SystemCategoryProviders: {},
+	SystemCategoryStartup:     {},
+	SystemCategoryServer:      {},
+	SystemCategoryReload:      {},
+	SystemCategoryProviders:   {},
+	SystemCategoryTrafficDump: {},
+	SystemCategoryModelsSync:  {},
+	SystemCategoryBalance:     {},
 }
 
 type SystemLoggerOptions struct {
@@ -0,0 +1,140 @@
+package onrserver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+// openAccessLogOutputs builds the multi-sink access log. It returns nil when
+// the single logger from openAccessLogger covers the config: access log
+// disabled, or no access_log_outputs with the text encoding. Without outputs,
+// a json/logfmt encoding applies to access_log_path (or stdout).
+func openAccessLogOutputs(cfg *config.Config, formatter *logx.AccessLogFormatter) (*logx.AccessLogOutputs, error) {
+	if !cfg.Logging.AccessLog {
+		return nil, nil
+	}
+	defaultEncoding, err := logx.NormalizeAccessLogEncoding(cfg.Logging.AccessLogEncoding)
+	if err != nil {
+		return nil, err
+	}
+	outputs := cfg.Logging.AccessLogOutputs
+	if len(outputs) == 0 {
+		if defaultEncoding == logx.AccessLogEncodingText {
+			return nil, nil
+		}
+		legacy := config.AccessLogOutputConfig{Type: logx.AccessLogSinkStdout}
+		if p := strings.TrimSpace(cfg.Logging.AccessLogPath); p != "" {
+			legacy = config.AccessLogOutputConfig{Type: logx.AccessLogSinkFile, Path: p, Rotate: cfg.Logging.AccessLogRotate}
+		}
+		outputs = []config.AccessLogOutputConfig{legacy}
+	}
+
+	built := make([]logx.AccessLogOutput, 0, len(outputs))
+	closeBuilt := func() {
+		_ = logx.NewAccessLogOutputs(built...).Close()
+	}
+	for i, oc := range outputs {
+		encoding := defaultEncoding
+		if strings.TrimSpace(oc.Encoding) != "" {
+			if encoding, err = logx.NormalizeAccessLogEncoding(oc.Encoding); err != nil {
+				closeBuilt()
+				return nil, err
+			}
+		}
+		typ := strings.ToLower(strings.TrimSpace(oc.Type))
+		enc, err := logx.NewAccessLogEncoder(encoding, formatter, typ == logx.AccessLogSinkStdout)
+		if err != nil {
+			closeBuilt()
+			return nil, err
+		}
+		name, sink, err := openAccessLogSink(typ, oc)
+		if err != nil {
+			closeBuilt()
+			return nil, fmt.Errorf("logging.access_log_outputs[%d]: %w", i, err)
+		}
+		built = append(built, logx.AccessLogOutput{Name: name, Encoder: enc, Sink: sink})
+	}
+	return logx.NewAccessLogOutputs(built...), nil
+}
+
+// openAccessLogSink returns the output name and sink for one configured output.
+func openAccessLogSink(typ string, oc config.AccessLogOutputConfig) (string, io.WriteCloser, error) {
+	switch typ {
+	case logx.AccessLogSinkStdout:
+		return typ, logx.NewAccessLogStdoutSink(), nil
+	case logx.AccessLogSinkFile:
+		name := typ + ":" + strings.TrimSpace(oc.Path)
+		if oc.Rotate.Enabled {
+			w, err := logx.NewAccessRotateWriter(logx.AccessLogRotateOptions{
+				Path:       oc.Path,
+				MaxSizeMB:  oc.Rotate.MaxSizeMB,
+				MaxBackups: oc.Rotate.MaxBackups,
+				MaxAgeDays: oc.Rotate.MaxAgeDays,
+				Compress:   oc.Rotate.Compress,
+			})
+			return name, w, err
+		}
+		f, err := logx.OpenAccessLogFile(oc.Path)
+		if err != nil {
+			return name, nil, err
+		}
+		return name, f, nil
+	case logx.AccessLogSinkSyslog:
+		s, err := logx.NewAccessLogSyslogSink(logx.AccessLogSyslogOptions{
+			Network:  oc.Network,
+			Address:  oc.Address,
+			Tag:      oc.Tag,
+			Facility: oc.Facility,
+		})
+		if err != nil {
+			return typ, nil, err
+		}
+		return typ + ":" + strings.TrimSpace(oc.Address), s, nil
+	case logx.AccessLogSinkHTTP:
+		s, err := logx.NewAccessLogHTTPSink(logx.AccessLogHTTPSinkOptions{
+			URL:           oc.URL,
+			Headers:       oc.Headers,
+			BatchSize:     oc.BatchSize,
+			FlushInterval: time.Duration(oc.FlushIntervalMs) * time.Millisecond,
+			BufferSize:    oc.BufferSize,
+			BlockTimeout:  time.Duration(oc.BlockTimeoutMs) * time.Millisecond,
+			Timeout:       time.Duration(oc.TimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return typ, nil, err
+		}
+		return typ + ":" + strings.TrimSpace(oc.URL), s, nil
+	default:
+		return typ, nil, fmt.Errorf("unsupported access log output type %q", typ)
+	}
+}
+
+// writeAccessLogMetrics writes per-output access log counters in Prometheus text
+// format. Sent and dropped lines are only tracked by buffered (http) outputs.
+func writeAccessLogMetrics(w io.Writer, stats []logx.AccessLogOutputStats) {
+	if len(stats) == 0 {
+		return
+	}
+	counter := func(name, help string, value func(s logx.AccessLogOutputStats) (uint64, bool)) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range stats {
+			if v, ok := value(s); ok {
+				_, _ = fmt.Fprintf(w, "%s{output=\"%s\"} %d\n", name, metricLabelEscaper.Replace(s.Name), v)
+			}
+		}
+	}
+	counter("onr_access_log_sent_lines_total", "Access log lines delivered by a buffered output.", func(s logx.AccessLogOutputStats) (uint64, bool) {
+		return s.Sent, s.Buffered
+	})
+	counter("onr_access_log_dropped_lines_total", "Access log lines dropped because the output buffer was full.", func(s logx.AccessLogOutputStats) (uint64, bool) {
+		return s.Dropped, s.Buffered
+	})
+	counter("onr_access_log_failed_lines_total", "Access log lines lost to failed writes or POSTs.", func(s logx.AccessLogOutputStats) (uint64, bool) {
+		return s.Failed, true
+	})
+}
@@ -0,0 +1,427 @@
+package onrserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/balancequery"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+const balanceQueryTimeout = 30 * time.Second
+
+// Balance states of a provider key. The zero state means no successful query yet.
+const (
+	balanceStateOK        = "ok"
+	balanceStateLow       = "low"
+	balanceStateExhausted = "exhausted"
+)
+
+// balanceEntry is the last known balance of one provider key.
+type balanceEntry struct {
+	Provider  string     `json:"provider"`
+	Key       string     `json:"key"`
+	Unit      string     `json:"unit,omitempty"`
+	Balance   *float64   `json:"balance,omitempty"`
+	Used      *float64   `json:"used,omitempty"`
+	State     string     `json:"state,omitempty"`
+	Benched   bool       `json:"benched"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt time.Time  `json:"checked_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// balanceEvent is the webhook payload sent when a key changes state.
+type balanceEvent struct {
+	// Event is balance.low, balance.exhausted or balance.recovered.
+	Event     string    `json:"event"`
+	Provider  string    `json:"provider"`
+	Key       string    `json:"key"`
+	Unit      string    `json:"unit"`
+	Balance   float64   `json:"balance"`
+	Threshold *float64  `json:"threshold,omitempty"`
+	Previous  string    `json:"previous_state,omitempty"`
+	State     string    `json:"state"`
+	Benched   bool      `json:"benched"`
+	At        time.Time `json:"at"`
+}
+
+type balanceQueryFunc func(ctx context.Context, provider string, k keystore.Key) (balancequery.Result, error)
+
+// balanceMonitor polls the DSL balance block of every provider key. Results
+// survive key reloads because keys are tracked by provider and key label.
+type balanceMonitor struct {
+	cfg    config.BalanceMonitorConfig
+	st     *state
+	reg    *dslconfig.Registry
+	query  balanceQueryFunc
+	hook   *http.Client
+	logger *logx.SystemLogger
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]*balanceEntry
+}
+
+func newBalanceMonitor(cfg config.BalanceMonitorConfig, st *state, reg *dslconfig.Registry, pclient *proxy.Client, logger *logx.SystemLogger) *balanceMonitor {
+	m := &balanceMonitor{
+		cfg:     cfg,
+		st:      st,
+		reg:     reg,
+		hook:    &http.Client{Timeout: time.Duration(cfg.Webhook.TimeoutMs) * time.Millisecond},
+		logger:  logger,
+		now:     time.Now,
+		entries: map[string]*balanceEntry{},
+	}
+	if pclient != nil {
+		m.query = func(ctx context.Context, provider string, k keystore.Key) (balancequery.Result, error) {
+			return pclient.QueryBalance(ctx, provider, proxy.ProviderKey{
+				Name:               k.Name,
+				Value:              k.Value,
+				BaseURLOverride:    k.BaseURLOverride,
+				CredentialFile:     k.CredentialFile,
+				Location:           k.Location,
+				AWSAccessKeyID:     k.AWSAccessKeyID,
+				AWSSecretAccessKey: k.AWSSecretAccessKey,
+				AWSSessionToken:    k.AWSSessionToken,
+				AWSRegion:          k.AWSRegion,
+			}, cfg.API)
+		}
+	}
+	return m
+}
+
+// installBalanceMonitor starts the periodic balance_monitor job and attaches
+// it to st. It returns nil when balance_monitor.enabled is false.
+func installBalanceMonitor(cfg *config.Config, st *state, reg *dslconfig.Registry, pclient *proxy.Client, logger *logx.SystemLogger) io.Closer {
+	if !cfg.BalanceMonitor.Enabled {
+		return nil
+	}
+	m := newBalanceMonitor(cfg.BalanceMonitor, st, reg, pclient, logger)
+	st.SetBalanceMonitor(m)
+	interval := time.Duration(cfg.BalanceMonitor.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.poll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return closerFunc(func() error {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+		return nil
+	})
+}
+
+// poll queries every key of the monitored providers once, updates the
+// entries and sends a webhook for every state change.
+func (m *balanceMonitor) poll(ctx context.Context) {
+	keys := m.st.Keys()
+	seen := map[string]struct{}{}
+	var events []balanceEvent
+	for _, provider := range m.targets(keys) {
+		for i, k := range keys.Keys(provider) {
+			if ctx.Err() != nil {
+				return
+			}
+			label := balanceKeyLabel(i, k)
+			qctx, cancel := context.WithTimeout(ctx, balanceQueryTimeout)
+			res, err := m.query(qctx, provider, k)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			seen[balanceEntryID(provider, label)] = struct{}{}
+			if ev, ok := m.record(provider, label, res, err); ok {
+				events = append(events, ev)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	for id := range m.entries {
+		if _, ok := seen[id]; !ok {
+			delete(m.entries, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, ev := range events {
+		m.logger.Warn(logx.SystemCategoryBalance, "provider key balance "+ev.State, map[string]any{
+			"provider": ev.Provider,
+			"key":      ev.Key,
+			"balance":  ev.Balance,
+			"unit":     ev.Unit,
+			"previous": ev.Previous,
+			"benched":  ev.Benched,
+		})
+		if err := m.sendWebhook(ctx, ev); err != nil {
+			m.logger.Warn(logx.SystemCategoryBalance, "balance webhook failed", map[string]any{
+				"event":    ev.Event,
+				"provider": ev.Provider,
+				"key":      ev.Key,
+				"error":    err.Error(),
+			})
+		}
+	}
+}
+
+// record stores one query result and returns the state change event, if any.
+// A failed query keeps the previous balance, state and bench.
+func (m *balanceMonitor) record(provider, label string, res balancequery.Result, qerr error) (balanceEvent, bool) {
+	now := m.now().UTC()
+	id := balanceEntryID(provider, label)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entries[id]
+	if e == nil {
+		e = &balanceEntry{Provider: provider, Key: label}
+		m.entries[id] = e
+	}
+	e.CheckedAt = now
+	if qerr != nil {
+		e.Error = qerr.Error()
+		m.logger.Warn(logx.SystemCategoryBalance, "balance query failed", map[string]any{
+			"provider": provider,
+			"key":      label,
+			"error":    e.Error,
+		})
+		return balanceEvent{}, false
+	}
+	balance := res.Balance
+	e.Error = ""
+	e.Unit = res.Unit
+	e.Balance = &balance
+	e.Used = res.Used
+	e.UpdatedAt = &now
+
+	low, exhausted := m.cfg.ThresholdsFor(provider)
+	state, threshold := balanceStateOK, low
+	switch {
+	case balance <= exhausted:
+		state, threshold = balanceStateExhausted, &exhausted
+	case low != nil && balance <= *low:
+		state = balanceStateLow
+	case low == nil:
+		threshold = &exhausted
+	}
+	prev := e.State
+	e.State = state
+	e.Benched = m.cfg.AutoBench && state == balanceStateExhausted
+	if state == prev || (prev == "" && state == balanceStateOK) {
+		return balanceEvent{}, false
+	}
+	event := "balance." + state
+	if state == balanceStateOK {
+		event = "balance.recovered"
+	}
+	th := *threshold
+	return balanceEvent{
+		Event:     event,
+		Provider:  provider,
+		Key:       label,
+		Unit:      e.Unit,
+		Balance:   balance,
+		Threshold: &th,
+		Previous:  prev,
+		State:     state,
+		Benched:   e.Benched,
+		At:        now,
+	}, true
+}
+
+// targets returns balance_monitor.providers, or every provider with keys and
+// a balance block for balance_monitor.api.
+func (m *balanceMonitor) targets(keys *keystore.Store) []string {
+	var out []string
+	if len(m.cfg.Providers) > 0 {
+		for _, p := range m.cfg.Providers {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+	for _, name := range m.reg.ListProviderNames() {
+		pf, ok := m.reg.GetProvider(name)
+		if !ok || !keys.HasProvider(name) {
+			continue
+		}
+		if _, ok := pf.Balance.Select(&dslmeta.Meta{API: m.cfg.API}); ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (m *balanceMonitor) sendWebhook(ctx context.Context, ev balanceEvent) error {
+	url := strings.TrimSpace(m.cfg.Webhook.URL)
+	if url == "" {
+		return nil
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range m.cfg.Webhook.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := m.hook.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook status=%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// usableKey returns the keystore filter that skips benched keys of provider,
+// or nil when nothing is benched. It is nil-safe.
+func (m *balanceMonitor) usableKey(provider string) func(i int, k keystore.Key) bool {
+	if m == nil || !m.cfg.AutoBench {
+		return nil
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	benched := map[string]struct{}{}
+	for _, e := range m.entries {
+		if e.Benched && e.Provider == provider {
+			benched[e.Key] = struct{}{}
+		}
+	}
+	if len(benched) == 0 {
+		return nil
+	}
+	return func(i int, k keystore.Key) bool {
+		_, ok := benched[balanceKeyLabel(i, k)]
+		return !ok
+	}
+}
+
+// Entries returns a copy of the entries sorted by provider and key.
+func (m *balanceMonitor) Entries() []balanceEntry {
+	m.mu.RLock()
+	out := make([]balanceEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		out = append(out, *e)
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// WriteMetrics writes the entries as Prometheus text-format gauges.
+func (m *balanceMonitor) WriteMetrics(w io.Writer) {
+	entries := m.Entries()
+	gauge := func(name, help string, value func(e balanceEntry) (float64, bool)) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, e := range entries {
+			if v, ok := value(e); ok {
+				_, _ = fmt.Fprintf(w, "%s{provider=\"%s\",key=\"%s\",unit=\"%s\"} %s\n", name,
+					metricLabelEscaper.Replace(e.Provider), metricLabelEscaper.Replace(e.Key), metricLabelEscaper.Replace(e.Unit),
+					strconv.FormatFloat(v, 'g', -1, 64))
+			}
+		}
+	}
+	gauge("onr_balance", "Remaining upstream balance of a provider key.", func(e balanceEntry) (float64, bool) {
+		if e.Balance == nil {
+			return 0, false
+		}
+		return *e.Balance, true
+	})
+	gauge("onr_balance_used", "Used upstream balance of a provider key, when the DSL balance block reports it.", func(e balanceEntry) (float64, bool) {
+		if e.Used == nil {
+			return 0, false
+		}
+		return *e.Used, true
+	})
+	gauge("onr_balance_state", "Balance state of a provider key: 0 ok, 1 low, 2 exhausted.", func(e balanceEntry) (float64, bool) {
+		switch e.State {
+		case balanceStateOK:
+			return 0, true
+		case balanceStateLow:
+			return 1, true
+		case balanceStateExhausted:
+			return 2, true
+		}
+		return 0, false
+	})
+	gauge("onr_balance_benched", "1 when routing skips the provider key because its balance is exhausted.", func(e balanceEntry) (float64, bool) {
+		return boolGauge(e.Benched), true
+	})
+	gauge("onr_balance_query_up", "1 when the last balance query of the provider key succeeded.", func(e balanceEntry) (float64, bool) {
+		return boolGauge(e.Error == ""), true
+	})
+	gauge("onr_balance_updated_timestamp_seconds", "Unix time of the last successful balance query.", func(e balanceEntry) (float64, bool) {
+		if e.UpdatedAt == nil {
+			return 0, false
+		}
+		return float64(e.UpdatedAt.Unix()), true
+	})
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// balanceKeyLabel names a provider key by its keys.yaml name, or by its
+// 1-based position when it has none.
+func balanceKeyLabel(i int, k keystore.Key) string {
+	if name := strings.TrimSpace(k.Name); name != "" {
+		return name
+	}
+	return "#" + strconv.Itoa(i+1)
+}
+
+func balanceEntryID(provider, label string) string {
+	return provider + "\x00" + label
+}
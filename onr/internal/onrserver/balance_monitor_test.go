@@ -0,0 +1,155 @@
+package onrserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/balancequery"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+func TestBalanceMonitor_ThresholdEventsAndAutoBench(t *testing.T) {
+	var (
+		hookMu sync.Mutex
+		events []balanceEvent
+	)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "t1" {
+			t.Errorf("webhook header X-Token=%q", r.Header.Get("X-Token"))
+		}
+		var ev balanceEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode webhook: %v", err)
+		}
+		hookMu.Lock()
+		events = append(events, ev)
+		hookMu.Unlock()
+	}))
+	defer hook.Close()
+
+	keysPath := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(keysPath, []byte("providers:\n  openai:\n    keys:\n      - name: k1\n        value: v1\n      - name: k2\n        value: v2\n"), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	ks, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("keystore.Load: %v", err)
+	}
+	st := &state{keys: ks}
+
+	low := 10.0
+	cfg := config.BalanceMonitorConfig{
+		Providers: []string{"OpenAI"},
+		Low:       &low,
+		AutoBench: true,
+		Webhook:   config.BalanceWebhookConfig{URL: hook.URL, Headers: map[string]string{"X-Token": "t1"}, TimeoutMs: 1000},
+	}
+	logger, _ := newTestSystemLogger(t)
+	m := newBalanceMonitor(cfg, st, dslconfig.NewRegistry(), nil, logger)
+	balances := map[string]float64{}
+	m.query = func(_ context.Context, provider string, k keystore.Key) (balancequery.Result, error) {
+		return balancequery.Result{Provider: provider, Unit: "USD", Balance: balances[k.Name]}, nil
+	}
+	takeEvents := func() []string {
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		var out []string
+		for _, ev := range events {
+			out = append(out, ev.Key+":"+ev.Event)
+		}
+		events = nil
+		return out
+	}
+	nextKeys := func() string {
+		var out []string
+		for range 2 {
+			k, _ := st.Keys().NextKeyFunc("openai", m.usableKey("openai"))
+			out = append(out, k.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	balances["k1"], balances["k2"] = 50, 5
+	m.poll(context.Background())
+	if got := takeEvents(); strings.Join(got, " ") != "k2:balance.low" {
+		t.Fatalf("poll #1 events=%v", got)
+	}
+
+	balances["k2"] = 0
+	m.poll(context.Background())
+	if got := takeEvents(); strings.Join(got, " ") != "k2:balance.exhausted" {
+		t.Fatalf("poll #2 events=%v", got)
+	}
+	if got := nextKeys(); got != "k1,k1" {
+		t.Fatalf("benched key still routed: %s", got)
+	}
+	var metrics bytes.Buffer
+	m.WriteMetrics(&metrics)
+	for _, want := range []string{
+		`onr_balance{provider="openai",key="k1",unit="USD"} 50`,
+		`onr_balance_state{provider="openai",key="k2",unit="USD"} 2`,
+		`onr_balance_benched{provider="openai",key="k2",unit="USD"} 1`,
+		"# TYPE onr_balance_query_up gauge",
+	} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Fatalf("metrics missing %q:\n%s", want, metrics.String())
+		}
+	}
+
+	balances["k2"] = 20
+	m.poll(context.Background())
+	if got := takeEvents(); strings.Join(got, " ") != "k2:balance.recovered" {
+		t.Fatalf("poll #3 events=%v", got)
+	}
+	if got := nextKeys(); got != "k1,k2" && got != "k2,k1" {
+		t.Fatalf("recovered key not routed: %s", got)
+	}
+	entries := m.Entries()
+	if len(entries) != 2 || entries[1].Key != "k2" || entries[1].State != balanceStateOK || entries[1].Benched {
+		t.Fatalf("entries=%+v", entries)
+	}
+}
+
+func TestBalanceEndpoints_RequireMasterKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keysPath := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(keysPath, []byte("providers:\n  openai:\n    keys:\n      - name: k1\n        value: v1\naccess_keys:\n  - name: tenant\n    value: ak-tenant\n"), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	ks, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("keystore.Load: %v", err)
+	}
+	st := &state{keys: ks}
+	logger, _ := newTestSystemLogger(t)
+	reg := dslconfig.NewRegistry()
+	st.SetBalanceMonitor(newBalanceMonitor(config.BalanceMonitorConfig{}, st, reg, nil, logger))
+	cfg := &config.Config{}
+	cfg.Auth.APIKey = "master"
+	r := NewRouter(cfg, st, reg, &proxy.Client{Registry: reg}, nil, false, "X-Onr-Request-Id", nil)
+
+	for _, path := range []string{"/admin/balances", "/admin/metrics"} {
+		for key, want := range map[string]int{"ak-tenant": http.StatusUnauthorized, "master": http.StatusOK} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != want {
+				t.Fatalf("%s key=%s status=%d want=%d body=%s", path, key, w.Code, want, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package onrserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+// ExplainRequest is the body of POST /admin/explain and the file format of
+// `onr-admin explain --request`: the client request to resolve without sending it.
+type ExplainRequest struct {
+	// Method defaults to POST.
+	Method string `json:"method,omitempty"`
+	// Path is the client request path, e.g. /v1/chat/completions.
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Provider is a shortcut for the x-onr-provider header.
+	Provider string `json:"provider,omitempty"`
+	// Body is the client JSON body; a JSON string is sent verbatim as raw text.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// newExplainEngine builds a side engine with the same proxy routes as the main
+// router. It has no access log or traffic dump unless passed in extra, and only
+// authenticates requests that carry a credential so token-key routing
+// (onr:v1?...) is explained too.
+func newExplainEngine(cfg *config.Config, st *state, pclient *proxy.Client, requestIDHeaderKey string, extra ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(requestIDMiddleware(requestIDHeaderKey))
+	r.Use(gin.Recovery())
+	r.Use(extra...)
+	g := r.Group("/")
+	authMW := newAuthMiddleware(cfg, st)
+	g.Use(func(c *gin.Context) {
+		if !hasClientCredential(c.Request.Header) {
+			c.Next()
+			return
+		}
+		authMW(c)
+	})
+	registerProxyRoutes(g, cfg, st, pclient, requestIDHeaderKey)
+	return r
+}
+
+func hasClientCredential(h http.Header) bool {
+	return strings.TrimSpace(h.Get("Authorization")) != "" ||
+		strings.TrimSpace(h.Get("x-api-key")) != "" ||
+		strings.TrimSpace(h.Get("x-goog-api-key")) != ""
+}
+
+func makeExplainHandler(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := ioReadAllLimit(c.Request.Body, 16<<20)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": gin.H{"message": err.Error(), "type": openAIInvalidRequestType, "code": "invalid_json"}})
+			return
+		}
+		status, out, err := runExplain(c.Request.Context(), engine, body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": gin.H{"message": err.Error(), "type": openAIInvalidRequestType, "code": "invalid_explain_request"}})
+			return
+		}
+		c.Data(status, "application/json; charset=utf-8", out)
+	}
+}
+
+// runExplain dispatches the decoded ExplainRequest through engine in explain mode and
+// returns the client-visible status and body: an Explanation on success, otherwise the
+// error the real request would get.
+func runExplain(ctx context.Context, engine *gin.Engine, raw []byte) (int, []byte, error) {
+	var in ExplainRequest
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return 0, nil, fmt.Errorf("decode explain request: %w", err)
+	}
+	path := strings.TrimSpace(in.Path)
+	if !strings.HasPrefix(path, "/") {
+		return 0, nil, errors.New("explain request path must start with '/', e.g. /v1/chat/completions")
+	}
+	method := strings.ToUpper(strings.TrimSpace(in.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	var body []byte
+	if b := bytes.TrimSpace(in.Body); len(b) > 0 {
+		var text string
+		if b[0] == '"' && json.Unmarshal(b, &text) == nil {
+			body = []byte(text)
+		} else {
+			body = b
+		}
+	}
+	req, err := http.NewRequestWithContext(proxy.WithExplain(ctx), method, path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("build explain request: %w", err)
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+	if p := strings.TrimSpace(in.Provider); p != "" {
+		req.Header.Set("x-onr-provider", p)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes(), nil
+}
+
+// Explain loads the config at cfgPath (providers, keys, models) and resolves one
+// ExplainRequest offline, without starting the server or sending anything upstream.
+func Explain(cfgPath string, request []byte) (int, []byte, error) {
+	cfg, st, pclient, err := loadOffline(cfgPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	engine := newExplainEngine(cfg, st, pclient, "X-Onr-Request-Id")
+	return runExplain(context.Background(), engine, request)
+}
+
+// loadOffline loads the config at cfgPath with its providers, keys and models
+// for in-process use by the admin CLI (explain, dump replay).
+func loadOffline(cfgPath string) (*config.Config, *state, *proxy.Client, error) {
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	reg := dslconfig.NewRegistry()
+	providersPath, _ := config.ResolveProviderDSLSource(cfg)
+	if _, err := reg.ReloadFromPath(providersPath); err != nil {
+		return nil, nil, nil, fmt.Errorf("load providers %q: %w", providersPath, err)
+	}
+	keys, err := keystore.Load(cfg.Keys.File)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load keys file %q: %w", cfg.Keys.File, err)
+	}
+	mr, err := models.Load(cfg.Models.File)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load models file %q: %w", cfg.Models.File, err)
+	}
+	logger, err := logx.NewSystemLoggerWithOptions(logx.SystemLoggerOptions{Writer: io.Discard, Level: cfg.Logging.Level})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("init system log: %w", err)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	pclient := &proxy.Client{
+		HTTP:            &http.Client{},
+		ReadTimeout:     time.Duration(cfg.Server.ReadTimeoutMs) * time.Millisecond,
+		WriteTimeout:    time.Duration(cfg.Server.WriteTimeoutMs) * time.Millisecond,
+		Registry:        reg,
+		UsageEst:        &cfg.UsageEstimation,
+		ProxyByProvider: cfg.UpstreamProxies.ByProvider,
+		SystemLogger:    logger,
+	}
+	return cfg, &state{keys: keys, modelRouter: mr}, pclient, nil
+}
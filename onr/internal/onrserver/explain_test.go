@@ -0,0 +1,236 @@
+package onrserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+const explainTestProviderConf = `syntax "next-router/0.1";
+
+provider "demo" {
+  defaults {
+    upstream_config {
+      base_url = "https://api.demo.test";
+    }
+    auth {
+      auth_bearer;
+    }
+  }
+
+  match api = "embeddings" {
+    upstream {
+      set_path "/v1/embeddings";
+    }
+  }
+
+  match api = "chat.completions" {
+    request {
+      model_map "gpt-4o-mini" "demo-small";
+      json_del "$.user";
+    }
+    upstream {
+      set_path "/v1/chat/completions";
+      set_query api-version "2024-01-01";
+    }
+  }
+}
+`
+
+const explainTestKeys = `providers:
+  demo:
+    keys:
+      - name: k1
+        value: sk-one
+      - name: k2
+        value: sk-two
+access_keys:
+  - name: tenant
+    value: ak-tenant
+`
+
+func writeExplainFixture(t *testing.T) (providersDir, keysPath, modelsPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	providersDir = filepath.Join(dir, "providers")
+	if err := os.MkdirAll(providersDir, 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(providersDir, "demo.conf"): explainTestProviderConf,
+		filepath.Join(dir, "keys.yaml"):          explainTestKeys,
+		filepath.Join(dir, "models.yaml"):        "models:\n  gpt-4o-mini:\n    providers: [demo]\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return providersDir, filepath.Join(dir, "keys.yaml"), filepath.Join(dir, "models.yaml")
+}
+
+func newExplainTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	providersDir, keysPath, modelsPath := writeExplainFixture(t)
+	reg := dslconfig.NewRegistry()
+	if _, err := reg.ReloadFromDir(providersDir); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	keys, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	mr, err := models.Load(modelsPath)
+	if err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Auth.APIKey = "master"
+	pclient := &proxy.Client{Registry: reg, HTTP: &http.Client{Transport: failingTransport{t: t}}}
+	return NewRouter(cfg, &state{keys: keys, modelRouter: mr}, reg, pclient, nil, false, "X-Onr-Request-Id", nil)
+}
+
+// failingTransport fails the test if explain ever reaches the network.
+type failingTransport struct{ t *testing.T }
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.t.Errorf("unexpected upstream request: %s %s", req.Method, req.URL)
+	return nil, http.ErrHandlerTimeout
+}
+
+func postExplain(t *testing.T, r *gin.Engine, body string) (int, map[string]any) {
+	return postExplainAs(t, r, "master", body)
+}
+
+func postExplainAs(t *testing.T, r *gin.Engine, apiKey string, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/explain", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, out
+}
+
+func TestAdminExplain_RequiresMasterKey(t *testing.T) {
+	r := newExplainTestRouter(t)
+	const in = `{"path":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[]}}`
+	for _, key := range []string{"ak-tenant", "wrong"} {
+		status, out := postExplainAs(t, r, key, in)
+		if status != http.StatusUnauthorized {
+			t.Fatalf("key=%s status=%d body=%v, want 401", key, status, out)
+		}
+	}
+}
+
+func TestAdminExplain_ResolvesUpstreamCall(t *testing.T) {
+	r := newExplainTestRouter(t)
+	const in = `{"path":"/v1/chat/completions","body":{"model":"gpt-4o-mini","user":"u1","messages":[{"role":"user","content":"hi"}]}}`
+
+	for i := 0; i < 2; i++ {
+		status, out := postExplain(t, r, in)
+		if status != http.StatusOK {
+			t.Fatalf("status=%d body=%v", status, out)
+		}
+		if out["provider"] != "demo" || out["provider_source"] != "model" || out["key"] != "k1" {
+			t.Fatalf("unexpected selection (call %d): %v", i, out)
+		}
+		if out["mapped_model"] != "demo-small" {
+			t.Fatalf("mapped_model=%v", out["mapped_model"])
+		}
+		match, _ := out["match"].(map[string]any)
+		if match["index"] != float64(1) || match["api"] != "chat.completions" {
+			t.Fatalf("match=%v", match)
+		}
+		up, _ := out["upstream"].(map[string]any)
+		if up["url"] != "https://api.demo.test/v1/chat/completions?api-version=2024-01-01" {
+			t.Fatalf("url=%v", up["url"])
+		}
+		headers, _ := up["headers"].(map[string]any)
+		if got := headers["Authorization"]; !strings.Contains(strings.Join(toStrings(got), ","), "[REDACTED]") {
+			t.Fatalf("authorization not masked: %v", got)
+		}
+		body, _ := up["body"].(map[string]any)
+		if body["model"] != "demo-small" || body["user"] != nil {
+			t.Fatalf("body=%v", body)
+		}
+		raw, _ := json.Marshal(out["directives"])
+		for _, want := range []string{`"directive":"set_path"`, `"args":"gpt-4o-mini \"demo-small\"","directive":"model_map","phase":"request","source":"match[1]"`, `"directive":"json_del"`, `"source":"defaults"`} {
+			if !bytes.Contains(raw, []byte(want)) {
+				t.Fatalf("missing %s in directives %s", want, raw)
+			}
+		}
+	}
+}
+
+func TestAdminExplain_ReturnsClientError(t *testing.T) {
+	r := newExplainTestRouter(t)
+	status, out := postExplain(t, r, `{"path":"/v1/chat/completions","body":{"model":"unknown-model"}}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status=%d", status)
+	}
+	errObj, _ := out["error"].(map[string]any)
+	if errObj["code"] != "provider_not_selected" {
+		t.Fatalf("error=%v", out)
+	}
+
+	status, out = postExplain(t, r, `{"path":"v1/chat"}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status=%d", status)
+	}
+	errObj, _ = out["error"].(map[string]any)
+	if errObj["code"] != "invalid_explain_request" {
+		t.Fatalf("error=%v", out)
+	}
+}
+
+func TestAdminExplain_InnerCredentialIsAuthenticated(t *testing.T) {
+	r := newExplainTestRouter(t)
+	status, _ := postExplain(t, r, `{"path":"/v1/embeddings","provider":"demo","headers":{"Authorization":"Bearer wrong"},"body":{"model":"x","input":"hi"}}`)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status=%d", status)
+	}
+}
+
+func TestExplain_Offline(t *testing.T) {
+	providersDir, keysPath, modelsPath := writeExplainFixture(t)
+	cfgPath := filepath.Join(t.TempDir(), "onr.yaml")
+	cfg := "providers:\n  dir: " + providersDir + "\nkeys:\n  file: " + keysPath + "\nmodels:\n  file: " + modelsPath + "\n"
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	status, body, err := Explain(cfgPath, []byte(`{"path":"/v1/embeddings","provider":"demo","body":{"model":"x","input":"hi"}}`))
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if status != http.StatusOK || !bytes.Contains(body, []byte(`"url":"https://api.demo.test/v1/embeddings"`)) {
+		t.Fatalf("status=%d body=%s", status, body)
+	}
+}
+
+func toStrings(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		s, _ := item.(string)
+		out = append(out, s)
+	}
+	return out
+}
@@ -47,7 +47,7 @@ func makeGeminiHandler(cfg *config.Config, st *state, pclient *proxy.Client, req
 			}
 		}
 
-		c.Set("onr.api", api)
+		setRequestAPI(c, api)
 		c.Set("onr.model", model)
 		c.Set("onr.stream", stream)
 
@@ -70,7 +70,7 @@ func makeGeminiHandler(cfg *config.Config, st *state, pclient *proxy.Client, req
 		// restore body for downstream proxy layer
 		c.Request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 
-		provider, source := selectProvider(st, auth.TokenProvider(c), c.GetHeader("x-onr-provider"), model)
+		provider, source := selectProvider(st, auth.TokenProvider(c), c.GetHeader("x-onr-provider"), model, proxy.IsExplain(c.Request.Context()))
 		c.Set("onr.provider", provider)
 		c.Set("onr.provider_source", source)
 		if provider == "" {
@@ -96,8 +96,7 @@ func makeGeminiHandler(cfg *config.Config, st *state, pclient *proxy.Client, req
 			kname = "byok"
 			kval = uk
 		} else {
-			keys := st.Keys()
-			k, ok := keys.NextKey(provider)
+			k, ok := nextUpstreamKey(c, st, provider)
 			if !ok {
 				writeOpenAIError(c, requestIDHeaderKey, "missing_upstream_key", "no upstream key for provider: "+provider)
 				return
@@ -10,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/apitransform"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestcanon"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestid"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestvalidate"
@@ -26,12 +28,13 @@ const (
 	ctxKeyRequestRoot        = "onr.request_root"
 	ctxKeyRequestModel       = "onr.request_model"
 	ctxKeyRequestContentType = "onr.request_content_type"
+	ctxKeyTrafficDumpSampler = "onr.traffic_dump_sampler"
 )
 
 func makeHandler(cfg *config.Config, st *state, pclient *proxy.Client, api string, requestIDHeaderKey string) gin.HandlerFunc {
 	requestIDHeaderKey = requestid.ResolveHeaderKey(requestIDHeaderKey)
 	return func(c *gin.Context) {
-		c.Set("onr.api", api)
+		setRequestAPI(c, api)
 		bodyBytes, stream, model, err := inspectRequestBody(c, api)
 		if err != nil {
 			writeOpenAIError(c, requestIDHeaderKey, "invalid_json", err.Error())
@@ -54,7 +57,7 @@ func makeHandler(cfg *config.Config, st *state, pclient *proxy.Client, api strin
 		// restore body for downstream proxy layer
 		c.Request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 
-		provider, source := selectProvider(st, auth.TokenProvider(c), c.GetHeader("x-onr-provider"), model)
+		provider, source := selectProvider(st, auth.TokenProvider(c), c.GetHeader("x-onr-provider"), model, proxy.IsExplain(c.Request.Context()))
 		c.Set("onr.provider", provider)
 		c.Set("onr.provider_source", source)
 		c.Set("onr.model", model)
@@ -82,8 +85,7 @@ func makeHandler(cfg *config.Config, st *state, pclient *proxy.Client, api strin
 			kname = "byok"
 			kval = uk
 		} else {
-			keys := st.Keys()
-			k, ok := keys.NextKey(provider)
+			k, ok := nextUpstreamKey(c, st, provider)
 			if !ok {
 				writeOpenAIError(c, requestIDHeaderKey, "missing_upstream_key", "no upstream key for provider: "+provider)
 				return
@@ -139,7 +141,9 @@ func inspectRequestBody(c *gin.Context, api string) ([]byte, bool, string, error
 	return bodyBytes, info.Stream, strings.TrimSpace(info.Model), nil
 }
 
-func selectProvider(st *state, tokenProvider string, headerProvider string, model string) (provider string, source string) {
+// selectProvider picks the provider by token, header, then models.yaml routing.
+// peek reports the model route without advancing its round-robin cursor (explain mode).
+func selectProvider(st *state, tokenProvider string, headerProvider string, model string, peek bool) (provider string, source string) {
 	if p := strings.ToLower(strings.TrimSpace(tokenProvider)); p != "" {
 		return p, "token"
 	}
@@ -148,7 +152,11 @@ func selectProvider(st *state, tokenProvider string, headerProvider string, mode
 	}
 	if m := strings.TrimSpace(model); m != "" {
 		if mr := st.ModelRouter(); mr != nil {
-			if p, ok := mr.NextProvider(m); ok && p != "" {
+			next := mr.NextProvider
+			if peek {
+				next = mr.PeekProvider
+			}
+			if p, ok := next(m); ok && p != "" {
 				return p, "model"
 			}
 		}
@@ -156,6 +164,17 @@ func selectProvider(st *state, tokenProvider string, headerProvider string, mode
 	return "", ""
 }
 
+// nextUpstreamKey returns the provider key for this request, skipping keys
+// benched by the balance monitor. Explain (dry-run) requests peek so they do
+// not advance the keys round-robin.
+func nextUpstreamKey(c *gin.Context, st *state, provider string) (*keystore.Key, bool) {
+	usable := st.BalanceMonitor().usableKey(provider)
+	if proxy.IsExplain(c.Request.Context()) {
+		return st.Keys().PeekKeyFunc(provider, usable)
+	}
+	return st.Keys().NextKeyFunc(provider, usable)
+}
+
 func peekJSONBody(c *gin.Context) ([]byte, bool, string, error) {
 	b, err := ioReadAllLimit(c.Request.Body, 16<<20) // 16MB
 	if err != nil {
@@ -208,21 +227,34 @@ func writeOpenAIErrorWithStatus(c *gin.Context, requestIDHeaderKey string, statu
 
 // writeProxyError maps a ProxyJSON error to a downstream response.
 // Request validation failures get a stable code and the failing param path;
+// guard rejections get the guardrail_rejected code and the rule name as param;
 // request mapping rejections keep the mapping builtin's own code and param;
-// upstream response failures keep their own status/type/code so an upstream
-// fault is not reported to the client as an invalid request; all other proxy
-// errors fall back to a 400 with the generic proxy_error code.
+// DSL upstream timeouts become a 504 whose code is the timeout kind (also logged
+// as finish_reason); upstream response failures keep their own status/type/code
+// so an upstream fault is not reported to the client as an invalid request; all
+// other proxy errors fall back to a 400 with the generic proxy_error code.
 func writeProxyError(c *gin.Context, requestIDHeaderKey string, err error) {
 	var verr *requestvalidate.RequestValidationError
 	if errors.As(err, &verr) {
 		writeOpenAIErrorWithParam(c, requestIDHeaderKey, "request_validation_failed", err.Error(), verr.PathOrName)
 		return
 	}
+	var gerr *contentguard.RejectError
+	if errors.As(err, &gerr) {
+		writeOpenAIErrorWithParam(c, requestIDHeaderKey, "guardrail_rejected", err.Error(), gerr.Rule)
+		return
+	}
 	var merr *apitransform.RequestMappingError
 	if errors.As(err, &merr) {
 		writeOpenAIErrorWithParam(c, requestIDHeaderKey, merr.Code, merr.Message, merr.Param)
 		return
 	}
+	var terr *proxy.UpstreamTimeoutError
+	if errors.As(err, &terr) {
+		c.Set("onr.finish_reason", terr.Kind)
+		writeOpenAIErrorWithStatus(c, requestIDHeaderKey, http.StatusGatewayTimeout, "server_error", terr.Kind, err.Error())
+		return
+	}
 	var uerr *apitransform.UpstreamResponseError
 	if errors.As(err, &uerr) {
 		writeOpenAIErrorWithStatus(c, requestIDHeaderKey, uerr.StatusCode, uerr.Type, uerr.Code, uerr.Message)
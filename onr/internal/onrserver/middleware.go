@@ -31,3 +31,24 @@ func requestLoggerWithColor(l *log.Logger, color bool, requestIDHeaderKey string
 		l.Println(logx.FormatRequestLineWithColor(ts, status, latency, c.ClientIP(), c.Request.Method, c.Request.URL.Path, fields, color))
 	}
 }
+
+// accessLogOutputsMiddleware requires non-nil outputs created during server startup.
+func accessLogOutputsMiddleware(outs *logx.AccessLogOutputs, requestIDHeaderKey string, appnameInferEnabled bool, appnameInferUnknown string) gin.HandlerFunc {
+	requestIDHeaderKey = requestid.ResolveHeaderKey(requestIDHeaderKey)
+	collector := accesslog.NewCollector(requestIDHeaderKey, appnameInferEnabled, appnameInferUnknown)
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		latency := time.Since(start)
+		outs.Log(logx.AccessLogEntry{
+			Time:     time.Now(),
+			Status:   c.Writer.Status(),
+			Latency:  latency,
+			ClientIP: c.ClientIP(),
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Fields:   collector.Collect(c, latency),
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package onrserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/modelsync"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+const modelsSyncKeyTimeout = 30 * time.Second
+
+// modelsSyncer rewrites models.yaml from the upstream model lists and swaps
+// the model router, like `onr-admin models sync` followed by a reload.
+type modelsSyncer struct {
+	cfg     *config.Config
+	st      *state
+	reg     *dslconfig.Registry
+	pclient *proxy.Client
+	auditor *reloadAuditor
+	mu      *sync.Mutex
+	logger  *logx.SystemLogger
+}
+
+// installModelsSync starts the periodic models.sync job. It returns nil when
+// models.sync.enabled is false. mu is the reload mutex shared with SIGHUP and
+// provider auto reloads.
+func installModelsSync(cfg *config.Config, st *state, reg *dslconfig.Registry, pclient *proxy.Client, auditor *reloadAuditor, mu *sync.Mutex, logger *logx.SystemLogger) io.Closer {
+	if !cfg.Models.Sync.Enabled {
+		return nil
+	}
+	s := &modelsSyncer{cfg: cfg, st: st, reg: reg, pclient: pclient, auditor: auditor, mu: mu, logger: logger}
+	interval := time.Duration(cfg.Models.Sync.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.run(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn(logx.SystemCategoryModelsSync, "models sync failed", map[string]any{
+					"models_file": cfg.Models.File,
+					"error":       err.Error(),
+				})
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return closerFunc(func() error {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+		return nil
+	})
+}
+
+// run performs one sync pass.
+func (s *modelsSyncer) run(ctx context.Context) error {
+	sc := s.cfg.Models.Sync
+	rules, err := config.NewModelsSyncRules(s.cfg)
+	if err != nil {
+		return err
+	}
+	keys := s.st.Keys()
+	targets := s.targets(keys)
+	if len(targets) == 0 {
+		return errors.New("no provider has both keys and a DSL models block")
+	}
+	fetch := func(ctx context.Context, provider string, k keystore.Key) ([]string, error) {
+		kctx, cancel := context.WithTimeout(ctx, modelsSyncKeyTimeout)
+		defer cancel()
+		return s.pclient.QueryModels(kctx, provider, proxy.ProviderKey{
+			Name:               k.Name,
+			Value:              k.Value,
+			BaseURLOverride:    k.BaseURLOverride,
+			CredentialFile:     k.CredentialFile,
+			Location:           k.Location,
+			AWSAccessKeyID:     k.AWSAccessKeyID,
+			AWSSecretAccessKey: k.AWSSecretAccessKey,
+			AWSSessionToken:    k.AWSSessionToken,
+			AWSRegion:          k.AWSRegion,
+		}, sc.API)
+	}
+	snap := modelsync.Collect(ctx, targets, keys.Keys, fetch)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, pr := range snap.Providers {
+		if err := pr.Err(); err != nil {
+			s.logger.Warn(logx.SystemCategoryModelsSync, "models sync provider query failed", map[string]any{
+				"provider": pr.Provider,
+				"keys":     pr.Keys,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	path := strings.TrimSpace(s.cfg.Models.File)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// #nosec G304 -- models file comes from trusted config.
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read models file: %w", err)
+	}
+	current, err := modelsync.ParseRoutes(raw)
+	if err != nil {
+		return err
+	}
+	plan := modelsync.NewPlan(current, snap, modelsync.PlanOptions{Rules: rules, AddOnly: sc.AddOnly, OwnedBy: sc.OwnedBy})
+	if plan.Empty() {
+		return nil
+	}
+	out, err := modelsync.Apply(raw, plan)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, out); err != nil {
+		return fmt.Errorf("write models file: %w", err)
+	}
+	mr, err := models.Load(path)
+	if err == nil {
+		s.st.SetModelRouter(mr)
+	}
+	s.auditor.Record(s.cfg, "models_sync", false, err)
+	if err != nil {
+		return fmt.Errorf("reload models file %q: %w", path, err)
+	}
+	added, updated, removed := plan.Counts()
+	s.logger.Info(logx.SystemCategoryModelsSync, "models.yaml synchronized", map[string]any{
+		"models_file": path,
+		"added":       added,
+		"updated":     updated,
+		"removed":     removed,
+		"models":      len(plan.Routes),
+	})
+	return nil
+}
+
+// targets returns models.sync.providers, or every provider with keys and a
+// models block for models.sync.api.
+func (s *modelsSyncer) targets(keys *keystore.Store) []string {
+	if len(s.cfg.Models.Sync.Providers) > 0 {
+		return s.cfg.Models.Sync.Providers
+	}
+	var out []string
+	for _, name := range s.reg.ListProviderNames() {
+		pf, ok := s.reg.GetProvider(name)
+		if !ok || !keys.HasProvider(name) {
+			continue
+		}
+		if _, ok := pf.Models.Select(&dslmeta.Meta{API: s.cfg.Models.Sync.API}); ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package onrserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+func TestModelsSyncer_RunRewritesModelsAndSwapsRouter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer sk-demo" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"id":"demo-large"},{"id":"demo-small"}]}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	providersDir := filepath.Join(dir, "providers")
+	if err := os.MkdirAll(providersDir, 0o750); err != nil {
+		t.Fatalf("mkdir providers: %v", err)
+	}
+	conf := `
+syntax "next-router/0.1";
+
+provider "demo" {
+  defaults {
+    upstream_config { base_url = "` + srv.URL + `"; }
+    auth { auth_bearer; }
+    models {
+      models_mode custom;
+      path "/v1/models";
+      id_path "$.data[*].id";
+    }
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(providersDir, "demo.conf"), []byte(conf), 0o600); err != nil {
+		t.Fatalf("write provider conf: %v", err)
+	}
+	reg := dslconfig.NewRegistry()
+	if _, err := reg.ReloadFromDir(providersDir); err != nil {
+		t.Fatalf("ReloadFromDir: %v", err)
+	}
+
+	keysPath := filepath.Join(dir, "keys.yaml")
+	if err := os.WriteFile(keysPath, []byte("providers:\n  demo:\n    keys:\n      - name: k1\n        value: sk-demo\n"), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	ks, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("keystore.Load: %v", err)
+	}
+	modelsPath := filepath.Join(dir, "models.yaml")
+	if err := os.WriteFile(modelsPath, []byte("models:\n  demo-old:\n    providers:\n      - demo\n"), 0o600); err != nil {
+		t.Fatalf("write models: %v", err)
+	}
+	mr, err := models.Load(modelsPath)
+	if err != nil {
+		t.Fatalf("models.Load: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Models.File = modelsPath
+	cfg.Models.Sync.API = "chat.completions"
+	cfg.Models.Sync.Deny = []string{"-small$"}
+	logger, logs := newTestSystemLogger(t)
+	s := &modelsSyncer{
+		cfg:     cfg,
+		st:      &state{keys: ks, modelRouter: mr},
+		reg:     reg,
+		pclient: &proxy.Client{Registry: reg, HTTP: srv.Client()},
+		mu:      &sync.Mutex{},
+		logger:  logger,
+	}
+	if err := s.run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	b, err := os.ReadFile(modelsPath)
+	if err != nil {
+		t.Fatalf("read models: %v", err)
+	}
+	if got := string(b); !strings.Contains(got, "demo-large:") || strings.Contains(got, "demo-old") || strings.Contains(got, "demo-small") {
+		t.Fatalf("models.yaml=\n%s", got)
+	}
+	if _, ok := s.st.ModelRouter().PeekProvider("demo-large"); !ok {
+		t.Fatalf("model router was not swapped")
+	}
+	if !strings.Contains(logs.String(), "models.yaml synchronized") {
+		t.Fatalf("logs=%s", logs.String())
+	}
+}
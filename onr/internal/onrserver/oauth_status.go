@@ -0,0 +1,63 @@
+package onrserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+)
+
+// oauthStatusEntry is one provider key of GET /admin/oauth/status.
+type oauthStatusEntry struct {
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+	proxy.OAuthKeyStatus
+	Error string `json:"error,omitempty"`
+}
+
+// makeOAuthStatusHandler lists the OAuth token status of every key whose
+// provider authenticates with OAuth for ?api= (default chat.completions).
+func makeOAuthStatusHandler(st *state, reg *dslconfig.Registry, pclient *proxy.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		api := strings.TrimSpace(c.Query("api"))
+		if api == "" {
+			api = "chat.completions"
+		}
+		c.JSON(http.StatusOK, gin.H{"api": api, "keys": oauthStatusEntries(st, reg, pclient, api)})
+	}
+}
+
+func oauthStatusEntries(st *state, reg *dslconfig.Registry, pclient *proxy.Client, api string) []oauthStatusEntry {
+	out := []oauthStatusEntry{}
+	keys := st.Keys()
+	if keys == nil || pclient == nil {
+		return out
+	}
+	for _, provider := range reg.ListProviderNames() {
+		for i, k := range keys.Keys(provider) {
+			status, ok, err := pclient.OAuthStatus(provider, proxy.ProviderKey{
+				Name:               k.Name,
+				Value:              k.Value,
+				BaseURLOverride:    k.BaseURLOverride,
+				CredentialFile:     k.CredentialFile,
+				Location:           k.Location,
+				AWSAccessKeyID:     k.AWSAccessKeyID,
+				AWSSecretAccessKey: k.AWSSecretAccessKey,
+				AWSSessionToken:    k.AWSSessionToken,
+				AWSRegion:          k.AWSRegion,
+			}, api)
+			if err == nil && !ok {
+				continue
+			}
+			e := oauthStatusEntry{Provider: provider, Key: balanceKeyLabel(i, k), OAuthKeyStatus: status}
+			if err != nil {
+				e.Error = err.Error()
+			}
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,20 @@
+package onrserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminOAuthStatus_RequiresMasterKey(t *testing.T) {
+	r := newExplainTestRouter(t)
+	for key, want := range map[string]int{"ak-tenant": http.StatusUnauthorized, "master": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/oauth/status?api=chat.completions", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("key=%s status=%d want=%d body=%s", key, w.Code, want, w.Body.String())
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package onrserver
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+
 	"github.com/r9s-ai/open-next-router/onr/internal/logx"
 	"github.com/r9s-ai/open-next-router/pkg/config"
 )
@@ -115,3 +120,93 @@ func TestOpenAccessLogger_StdoutNoStdTimePrefix(t *testing.T) {
 		t.Fatalf("expected logger flags=0, got=%d", l.Flags())
 	}
 }
+
+func TestOpenAccessLogOutputs(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Logging.AccessLog = true
+	if outs, err := openAccessLogOutputs(cfg, nil); err != nil || outs != nil {
+		t.Fatalf("expected nil outputs for text encoding without outputs, got %v err=%v", outs, err)
+	}
+
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "access.log")
+	jsonPath := filepath.Join(dir, "access.jsonl")
+	logfmtPath := filepath.Join(dir, "access.logfmt")
+	cfg.Logging.AccessLogEncoding = "json"
+	cfg.Logging.AccessLogPath = legacyPath
+	outs, err := openAccessLogOutputs(cfg, nil)
+	if err != nil || outs == nil {
+		t.Fatalf("expected legacy path output, err=%v", err)
+	}
+	outs.Log(logx.AccessLogEntry{Status: 200, Method: "GET", Path: "/v1/models"})
+	_ = outs.Close()
+
+	cfg.Logging.AccessLogOutputs = []config.AccessLogOutputConfig{
+		{Type: "file", Path: jsonPath},
+		{Type: "file", Path: logfmtPath, Encoding: "logfmt"},
+	}
+	outs, err = openAccessLogOutputs(cfg, nil)
+	if err != nil {
+		t.Fatalf("openAccessLogOutputs err=%v", err)
+	}
+	r := gin.New()
+	r.Use(accessLogOutputsMiddleware(outs, "X-Onr-Request-Id", false, ""))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.Set("onr.provider", "openai")
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if err := outs.Close(); err != nil {
+		t.Fatalf("close err=%v", err)
+	}
+
+	for path, want := range map[string]string{
+		legacyPath: `"status":200,`,
+		jsonPath:   `"provider":"openai"`,
+		logfmtPath: "status=200 ",
+	} {
+		b, err := os.ReadFile(path) // #nosec G304 -- test temp file.
+		if err != nil || !strings.Contains(string(b), want) {
+			t.Fatalf("%s: want %q, got %q err=%v", path, want, b, err)
+		}
+	}
+
+	cfg.Logging.AccessLogOutputs = []config.AccessLogOutputConfig{{Type: "syslog", Address: "127.0.0.1:1", Facility: "bogus"}}
+	if _, err := openAccessLogOutputs(cfg, nil); err == nil {
+		t.Fatalf("expected syslog facility error")
+	}
+}
+
+type failingAccessLogSink struct{}
+
+func (failingAccessLogSink) Write([]byte) (int, error) { return 0, os.ErrClosed }
+func (failingAccessLogSink) Close() error              { return nil }
+
+func TestAdminMetrics_ReportsAccessLogCounters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enc, err := logx.NewAccessLogEncoder(logx.AccessLogEncodingJSON, nil, false)
+	if err != nil {
+		t.Fatalf("NewAccessLogEncoder: %v", err)
+	}
+	st := &state{}
+	st.SetAccessLogOutputs(logx.NewAccessLogOutputs(logx.AccessLogOutput{Name: "file", Encoder: enc, Sink: failingAccessLogSink{}}))
+	st.AccessLogOutputs().Log(logx.AccessLogEntry{Status: http.StatusOK})
+
+	cfg := &config.Config{}
+	cfg.Auth.APIKey = "master"
+	r := NewRouter(cfg, st, nil, nil, nil, false, "X-Onr-Request-Id", nil)
+	req := httptest.NewRequest(http.MethodGet, "/admin/metrics", nil)
+	req.Header.Set("Authorization", "Bearer master")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `onr_access_log_failed_lines_total{output="file"} 1`) {
+		t.Fatalf("missing failed counter:\n%s", body)
+	}
+	if strings.Contains(body, `onr_access_log_dropped_lines_total{output="file"}`) || strings.Contains(body, "onr_balance") {
+		t.Fatalf("unexpected series for unbuffered output or disabled balance monitor:\n%s", body)
+	}
+}
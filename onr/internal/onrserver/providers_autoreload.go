@@ -16,7 +16,7 @@ import (
 )
 
 // installProvidersAutoReload requires non-nil config, registry, and mutex from Run.
-func installProvidersAutoReload(cfg *config.Config, reg *dslconfig.Registry, mu *sync.Mutex, logger *logx.SystemLogger) (io.Closer, error) {
+func installProvidersAutoReload(cfg *config.Config, reg *dslconfig.Registry, auditor *reloadAuditor, mu *sync.Mutex, logger *logx.SystemLogger) (io.Closer, error) {
 	if !cfg.Providers.AutoReload.Enabled {
 		return nil, nil
 	}
@@ -64,6 +64,7 @@ func installProvidersAutoReload(cfg *config.Config, reg *dslconfig.Registry, mu
 		runReload := func() {
 			mu.Lock()
 			reloadRes, err := reloadProvidersRuntime(cfg, reg, logger)
+			auditor.Record(cfg, "providers_auto", true, err)
 			mu.Unlock()
 			if err != nil {
 				logReloadFailed(logger, "providers_auto", err)
@@ -3,6 +3,7 @@ package onrserver
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/apitransform"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestvalidate"
 )
 
@@ -136,3 +138,24 @@ func TestWriteProxyError_GenericError(t *testing.T) {
 		t.Fatalf("generic proxy error must not carry param: %#v", errObj)
 	}
 }
+
+func TestWriteProxyError_GuardRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(rec)
+	gc.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	writeProxyError(gc, "", fmt.Errorf("wrapped: %w", &contentguard.RejectError{Rule: "api_key"}))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	errObj, _ := out["error"].(map[string]any)
+	if errObj["code"] != "guardrail_rejected" || errObj["param"] != "api_key" {
+		t.Fatalf("unexpected error: %#v", errObj)
+	}
+}
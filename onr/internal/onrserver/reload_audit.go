@@ -0,0 +1,165 @@
+package onrserver
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+// reloadAuditor records config file changes applied by reloads in the audit log.
+// It diffs against the contents seen at the last successful load.
+type reloadAuditor struct {
+	logger *audit.Logger
+	actor  string
+
+	mu    sync.Mutex
+	files map[string]string
+}
+
+// newReloadAuditor returns nil when audit.file is not configured; cfg must be non-nil.
+func newReloadAuditor(cfg *config.Config) *reloadAuditor {
+	l := audit.NewLogger(cfg.Audit.File)
+	if l == nil {
+		return nil
+	}
+	a := &reloadAuditor{logger: l, actor: "onr@" + hostnameOrUnknown()}
+	a.files = readAuditedFiles(auditedPaths(cfg, false))
+	return a
+}
+
+// Record appends one entry per changed file. trigger is the reload source ("signal" or "providers_auto").
+// providersOnly limits the comparison to provider DSL files. A failed reload keeps the previous baseline.
+func (a *reloadAuditor) Record(cfg *config.Config, trigger string, providersOnly bool, reloadErr error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	paths := auditedPaths(cfg, providersOnly)
+	if providersOnly {
+		// Files removed from the providers source no longer show up in the walk.
+		providersPath, _ := config.ResolveProviderDSLSource(cfg)
+		for p := range a.files {
+			if isProviderAuditPath(p, providersPath) {
+				paths = append(paths, p)
+			}
+		}
+	}
+	current := readAuditedFiles(paths)
+	validation, errMsg := audit.ValidationOK, ""
+	if reloadErr != nil {
+		validation, errMsg = audit.ValidationFailed, reloadErr.Error()
+	}
+	recorded := false
+	for _, p := range sortedUnique(paths) {
+		before, after := a.files[p], current[p]
+		if before == after {
+			continue
+		}
+		a.appendEntry(audit.Entry{
+			Action:     "reload." + trigger,
+			Target:     p,
+			Diff:       audit.Diff(p, before, after),
+			Validation: validation,
+			Error:      errMsg,
+		})
+		recorded = true
+	}
+	if reloadErr != nil && !recorded {
+		a.appendEntry(audit.Entry{Action: "reload." + trigger, Validation: validation, Error: errMsg})
+	}
+	if reloadErr != nil {
+		return
+	}
+	for _, p := range paths {
+		if v, ok := current[p]; ok {
+			a.files[p] = v
+		} else {
+			delete(a.files, p)
+		}
+	}
+}
+
+func (a *reloadAuditor) appendEntry(e audit.Entry) {
+	e.Actor = a.actor
+	e.Source = audit.SourceReload
+	if err := a.logger.Append(e); err != nil {
+		_, _ = os.Stderr.WriteString("onr: write audit log " + a.logger.Path() + ": " + err.Error() + "\n")
+	}
+}
+
+// auditedPaths lists the provider DSL files and, unless providersOnly, the keys/models/pricing files.
+func auditedPaths(cfg *config.Config, providersOnly bool) []string {
+	var out []string
+	providersPath, isFile := config.ResolveProviderDSLSource(cfg)
+	if isFile {
+		out = append(out, providersPath)
+	} else if strings.TrimSpace(providersPath) != "" {
+		_ = filepath.WalkDir(providersPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".conf" {
+				out = append(out, p)
+			}
+			return nil
+		})
+	}
+	if providersOnly {
+		return out
+	}
+	for _, p := range []string{cfg.Keys.File, cfg.Models.File, cfg.Pricing.File, cfg.Pricing.OverridesFile} {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func isProviderAuditPath(p string, providersPath string) bool {
+	root := filepath.Clean(strings.TrimSpace(providersPath))
+	return p == root || strings.HasPrefix(p, root+string(filepath.Separator))
+}
+
+// readAuditedFiles skips missing or unreadable files; they compare as empty.
+func readAuditedFiles(paths []string) map[string]string {
+	out := make(map[string]string, len(paths))
+	for _, p := range paths {
+		// #nosec G304 -- paths come from trusted config.
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		out[p] = string(b)
+	}
+	return out
+}
+
+func sortedUnique(in []string) []string {
+	seen := make(map[string]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func hostnameOrUnknown() string {
+	h, err := os.Hostname()
+	if err != nil || strings.TrimSpace(h) == "" {
+		return "unknown"
+	}
+	return h
+}
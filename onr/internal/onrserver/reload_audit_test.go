@@ -0,0 +1,85 @@
+package onrserver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/pkg/audit"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+func TestReloadAuditorRecordsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	providersDir := filepath.Join(dir, "providers")
+	if err := os.MkdirAll(providersDir, 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile := func(p, body string) {
+		t.Helper()
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", p, err)
+		}
+	}
+	keysPath := filepath.Join(dir, "keys.yaml")
+	providerPath := filepath.Join(providersDir, "openai.conf")
+	writeFile(keysPath, "providers:\n  openai:\n    keys:\n      - value: sk-old-1234567890\n")
+	writeFile(providerPath, "syntax \"next-router/0.1\";\n")
+
+	cfg := &config.Config{}
+	cfg.Providers.Dir = providersDir
+	cfg.Keys.File = keysPath
+	cfg.Audit.File = filepath.Join(dir, "audit.jsonl")
+
+	a := newReloadAuditor(cfg)
+	if a == nil {
+		t.Fatalf("expected auditor")
+	}
+
+	writeFile(keysPath, "providers:\n  openai:\n    keys:\n      - value: sk-new-1234567890\n")
+	a.Record(cfg, "providers_auto", true, nil)
+	if _, err := os.Stat(cfg.Audit.File); !os.IsNotExist(err) {
+		t.Fatalf("providers-only reload must not record keys.yaml changes, stat err=%v", err)
+	}
+
+	a.Record(cfg, "signal", false, errors.New("boom"))
+	a.Record(cfg, "signal", false, nil)
+	a.Record(cfg, "signal", false, nil)
+
+	entries, err := audit.Read(cfg.Audit.File, audit.Filter{})
+	if err != nil {
+		t.Fatalf("Read err=%v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected failed and ok entries, got %#v", entries)
+	}
+	if entries[0].Validation != audit.ValidationFailed || entries[0].Error != "boom" {
+		t.Fatalf("unexpected failed entry: %#v", entries[0])
+	}
+	ok := entries[1]
+	if ok.Source != audit.SourceReload || ok.Action != "reload.signal" || ok.Target != keysPath || ok.Validation != audit.ValidationOK {
+		t.Fatalf("unexpected ok entry: %#v", ok)
+	}
+	if strings.Contains(ok.Diff, "sk-new-1234567890") || !strings.Contains(ok.Diff, "+      - value: ***") {
+		t.Fatalf("unexpected diff:\n%s", ok.Diff)
+	}
+
+	if err := os.Remove(providerPath); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	a.Record(cfg, "providers_auto", true, nil)
+	entries, _ = audit.Read(cfg.Audit.File, audit.Filter{Target: "openai.conf"})
+	if len(entries) != 1 || !strings.Contains(entries[0].Diff, "-syntax") {
+		t.Fatalf("expected provider removal entry, got %#v", entries)
+	}
+}
+
+func TestNewReloadAuditorDisabled(t *testing.T) {
+	if a := newReloadAuditor(&config.Config{}); a != nil {
+		t.Fatalf("expected nil auditor without audit.file")
+	}
+	var a *reloadAuditor
+	a.Record(&config.Config{}, "signal", false, nil)
+}
@@ -0,0 +1,58 @@
+package onrserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+func TestReloadRuntime_ReResolvesExecSecrets(t *testing.T) {
+	keystore.ResetSecretCache()
+	t.Cleanup(keystore.ResetSecretCache)
+
+	dir := t.TempDir()
+	providersDir := filepath.Join(dir, "providers")
+	if err := os.MkdirAll(providersDir, 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile := func(p, body string) {
+		t.Helper()
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", p, err)
+		}
+	}
+	secretPath := filepath.Join(dir, "secret.txt")
+	keysPath := filepath.Join(dir, "keys.yaml")
+	writeFile(filepath.Join(providersDir, "openai.conf"), "syntax \"next-router/0.1\";\n")
+	writeFile(secretPath, "sk-before\n")
+	writeFile(keysPath, "providers:\n  openai:\n    keys:\n      - name: main\n        value: \"ref+exec:cat "+secretPath+"\"\n")
+
+	cfg := &config.Config{}
+	cfg.Providers.Dir = providersDir
+	cfg.Keys.File = keysPath
+	cfg.Models.File = filepath.Join(dir, "models.yaml")
+
+	ks, err := keystore.Load(keysPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	st := &state{keys: ks}
+	if got := st.Keys().Keys("openai")[0].Value; got != "sk-before" {
+		t.Fatalf("initial key=%q", got)
+	}
+
+	// The exec output changes within the cache TTL; an explicit reload must still pick it up.
+	writeFile(secretPath, "sk-after\n")
+	logger, _ := newTestSystemLogger(t)
+	if _, err := reloadRuntime(cfg, st, dslconfig.NewRegistry(), &proxy.Client{}, logger); err != nil {
+		t.Fatalf("reloadRuntime: %v", err)
+	}
+	if got := st.Keys().Keys("openai")[0].Value; got != "sk-after" {
+		t.Fatalf("reloaded key=%q, want sk-after", got)
+	}
+}
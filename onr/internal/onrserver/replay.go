@@ -0,0 +1,211 @@
+package onrserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+const replayRedacted = "[REDACTED]"
+
+// ReplayOptions controls an in-process replay of a traffic dump.
+type ReplayOptions struct {
+	// FakeUpstream answers the upstream call with the captured upstream
+	// response instead of sending it over the network.
+	FakeUpstream bool
+	// Headers are set on the replayed origin request, e.g. a real
+	// Authorization header since dumps mask client credentials.
+	Headers map[string]string
+	// Provider is a shortcut for the x-onr-provider header.
+	Provider string
+	// RequestID is the request id of the replay (default "<orig>-replay").
+	RequestID string
+}
+
+// ReplayResult is what the client and the dump saw for the replayed request.
+type ReplayResult struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Dump is the traffic dump of the replay (upstream request, upstream and
+	// proxy responses), captured with the same masking as traffic_dump.
+	Dump *trafficdump.Dump
+}
+
+// Replay loads the config at cfgPath and re-sends the origin request captured
+// in orig through the proxy routes in-process, against the current provider DSL.
+func Replay(cfgPath string, orig *trafficdump.Dump, opts ReplayOptions) (*ReplayResult, error) {
+	if orig == nil {
+		return nil, errors.New("replay: dump is nil")
+	}
+	req, err := NewReplayRequest(orig, opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg, st, pclient, err := loadOffline(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.FakeUpstream {
+		upstream, err := newReplayUpstream(orig)
+		if err != nil {
+			return nil, err
+		}
+		pclient.HTTP.Transport = upstream
+		// Outbound proxies would replace the fake transport.
+		pclient.ProxyByProvider = nil
+	}
+
+	dir, err := os.MkdirTemp("", "onr-replay-")
+	if err != nil {
+		return nil, fmt.Errorf("create replay dump dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	dumpCfg := *cfg
+	dumpCfg.TrafficDump.Enabled = true
+	dumpCfg.TrafficDump.Dir = dir
+	dumpCfg.TrafficDump.FilePath = "replay.log"
+	dumpCfg.TrafficDump.MaxBytes = max(cfg.TrafficDump.MaxBytes, 1<<20)
+	dumpCfg.TrafficDump.Sections = nil
+	dumpCfg.TrafficDump.Sampling = config.TrafficDumpSamplingConfig{}
+
+	const requestIDHeaderKey = "X-Onr-Request-Id"
+	engine := newExplainEngine(cfg, st, pclient, requestIDHeaderKey, trafficDumpMiddleware(&dumpCfg, requestIDHeaderKey))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	d, err := trafficdump.ParseFile(filepath.Join(dir, "replay.log"))
+	if err != nil {
+		return nil, fmt.Errorf("read replay dump: %w", err)
+	}
+	return &ReplayResult{Status: rec.Code, Header: rec.Header(), Body: rec.Body.Bytes(), Dump: d}, nil
+}
+
+// NewReplayRequest rebuilds the client request captured in orig. Masked header
+// values and query parameters are dropped (opts.Headers can supply real ones);
+// a truncated or omitted origin body cannot be replayed.
+func NewReplayRequest(orig *trafficdump.Dump, opts ReplayOptions) (*http.Request, error) {
+	method := strings.ToUpper(strings.TrimSpace(orig.Method))
+	if method == "" || strings.TrimSpace(orig.Path) == "" {
+		return nil, errors.New("replay: dump has no META section (method/path)")
+	}
+	var body []byte
+	if o := orig.OriginRequest; o != nil {
+		if o.Omitted {
+			return nil, errors.New("replay: origin request body was omitted from the dump")
+		}
+		if o.Truncated {
+			return nil, errors.New("replay: origin request body is truncated (raise traffic_dump.max_bytes)")
+		}
+		body = o.Data
+	} else if method != http.MethodGet && method != http.MethodHead {
+		return nil, errors.New("replay: dump has no ORIGIN REQUEST section")
+	}
+	target, err := unmaskedRequestURI(orig.Path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build replay request: %w", err)
+	}
+	for k, vals := range orig.Header {
+		switch http.CanonicalHeaderKey(k) {
+		case "Content-Length", "Connection", "Accept-Encoding", "X-Onr-Request-Id":
+			continue
+		}
+		for _, v := range vals {
+			if v == replayRedacted {
+				continue
+			}
+			req.Header.Add(k, v)
+		}
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if p := strings.TrimSpace(opts.Provider); p != "" {
+		req.Header.Set("x-onr-provider", p)
+	}
+	rid := strings.TrimSpace(opts.RequestID)
+	if rid == "" {
+		rid = strings.TrimSpace(orig.RequestID) + "-replay"
+	}
+	req.Header.Set("X-Onr-Request-Id", rid)
+	return req, nil
+}
+
+// unmaskedRequestURI drops query parameters redacted by traffic_dump masking.
+func unmaskedRequestURI(raw string) (string, error) {
+	u, err := url.ParseRequestURI(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("replay: invalid request path %q: %w", raw, err)
+	}
+	q := u.Query()
+	changed := false
+	for k, vals := range q {
+		for _, v := range vals {
+			if v == replayRedacted {
+				q.Del(k)
+				changed = true
+				break
+			}
+		}
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+	return u.RequestURI(), nil
+}
+
+// replayUpstream answers every outbound request with the captured upstream response.
+type replayUpstream struct {
+	resp *trafficdump.Message
+}
+
+func newReplayUpstream(orig *trafficdump.Dump) (*replayUpstream, error) {
+	m := orig.UpstreamResponse
+	switch {
+	case m == nil:
+		return nil, errors.New("replay: dump has no UPSTREAM RESPONSE section to serve from the fake upstream")
+	case m.StatusCode() == 0:
+		return nil, fmt.Errorf("replay: invalid upstream status line %q", m.Line)
+	case m.Body.Omitted || m.Body.Truncated:
+		return nil, errors.New("replay: captured upstream response body is omitted or truncated")
+	}
+	return &replayUpstream{resp: m}, nil
+}
+
+func (u *replayUpstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+	header := u.resp.Header.Clone()
+	// The captured body is already decoded and re-framed.
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Del("Transfer-Encoding")
+	code := u.resp.StatusCode()
+	return &http.Response{
+		Status:        u.resp.Line,
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(u.resp.Body.Data)),
+		ContentLength: int64(len(u.resp.Body.Data)),
+		Request:       req,
+	}, nil
+}
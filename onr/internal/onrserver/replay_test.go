@@ -0,0 +1,109 @@
+package onrserver
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+)
+
+const replayTestDump = `=== META ===
+time=2026-01-02T03:04:05Z
+request_id=rid-orig
+method=POST
+path=/v1/chat/completions?key=[REDACTED]&trace=1
+client_ip=127.0.0.1
+headers:
+  Authorization: [REDACTED]
+  Content-Type: application/json
+  X-Onr-Provider: demo
+
+=== ORIGIN REQUEST ===
+{"model":"gpt-4o-mini","user":"u1","messages":[{"role":"user","content":"hi"}]}
+
+=== UPSTREAM RESPONSE ===
+200 OK
+  Content-Type: application/json
+  Content-Length: 999
+
+{"id":"c1","object":"chat.completion","model":"demo-small","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}
+
+`
+
+func writeReplayConfig(t *testing.T) string {
+	t.Helper()
+	providersDir, keysPath, modelsPath := writeExplainFixture(t)
+	cfgPath := filepath.Join(t.TempDir(), "onr.yaml")
+	cfg := "providers:\n  dir: " + providersDir + "\nkeys:\n  file: " + keysPath + "\nmodels:\n  file: " + modelsPath + "\n"
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	return cfgPath
+}
+
+func TestReplay_FakeUpstream(t *testing.T) {
+	orig, err := trafficdump.Parse(strings.NewReader(replayTestDump))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	res, err := Replay(writeReplayConfig(t), orig, ReplayOptions{FakeUpstream: true})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if res.Status != http.StatusOK || !strings.Contains(string(res.Body), `"hello"`) {
+		t.Fatalf("status=%d body=%s", res.Status, res.Body)
+	}
+	if res.Dump.RequestID != "rid-orig-replay" {
+		t.Fatalf("request_id=%q", res.Dump.RequestID)
+	}
+	up := res.Dump.UpstreamRequest
+	if up == nil {
+		t.Fatalf("missing upstream request in replay dump")
+	}
+	if method, u := up.RequestLine(); method != http.MethodPost || u != "https://api.demo.test/v1/chat/completions?api-version=2024-01-01&trace=1" {
+		t.Fatalf("upstream line=%q", up.Line)
+	}
+	body := string(up.Body.Data)
+	if !strings.Contains(body, `"demo-small"`) || strings.Contains(body, `"user":"u1"`) {
+		t.Fatalf("upstream body not rewritten by DSL: %s", body)
+	}
+	if res.Dump.ProxyStatus != http.StatusOK || res.Dump.ProxyResponse == nil {
+		t.Fatalf("proxy response status=%d", res.Dump.ProxyStatus)
+	}
+}
+
+func TestNewReplayRequest_DropsMaskedValues(t *testing.T) {
+	orig, err := trafficdump.Parse(strings.NewReader(replayTestDump))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	req, err := NewReplayRequest(orig, ReplayOptions{Headers: map[string]string{"X-Extra": "1"}, RequestID: "r2"})
+	if err != nil {
+		t.Fatalf("NewReplayRequest: %v", err)
+	}
+	if req.URL.RequestURI() != "/v1/chat/completions?trace=1" {
+		t.Fatalf("uri=%q", req.URL.RequestURI())
+	}
+	if req.Header.Get("Authorization") != "" || req.Header.Get("X-Extra") != "1" || req.Header.Get("X-Onr-Request-Id") != "r2" {
+		t.Fatalf("headers=%v", req.Header)
+	}
+
+	orig.OriginRequest.Truncated = true
+	if _, err := NewReplayRequest(orig, ReplayOptions{}); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestReplay_FakeUpstreamRequiresUpstreamResponse(t *testing.T) {
+	orig, err := trafficdump.Parse(strings.NewReader(replayTestDump))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	orig.UpstreamResponse = nil
+	if _, err := Replay(writeReplayConfig(t), orig, ReplayOptions{FakeUpstream: true}); err == nil || !strings.Contains(err.Error(), "UPSTREAM RESPONSE") {
+		t.Fatalf("err=%v", err)
+	}
+}
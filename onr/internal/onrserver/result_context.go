@@ -12,6 +12,9 @@ import (
 // setProxyResultContext requires a non-nil Gin context and proxy result.
 func setProxyResultContext(c *gin.Context, res *proxy.Result) {
 	c.Set("onr.latency_ms", res.LatencyMs)
+	if strings.TrimSpace(res.ProviderKey) != "" {
+		c.Set("onr.provider_key", strings.TrimSpace(res.ProviderKey))
+	}
 	if res.TTFTMs > 0 {
 		c.Set("onr.ttft_ms", res.TTFTMs)
 	}
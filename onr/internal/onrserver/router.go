@@ -1,6 +1,7 @@
 package onrserver
 
 import (
+	"crypto/x509"
 	"log"
 	"net/http"
 	"strings"
@@ -31,26 +32,86 @@ func NewRouter(
 	r := gin.New()
 	r.Use(requestIDMiddleware(resolvedRequestIDHeaderKey))
 	if cfg.Logging.AccessLog {
-		r.Use(requestLoggerWithColor(
-			accessLogger,
-			accessLoggerColor,
-			resolvedRequestIDHeaderKey,
-			cfg.Logging.AppNameInfer.Enabled,
-			cfg.Logging.AppNameInfer.Unknown,
-			accessFormatter,
-		))
+		if outs := st.AccessLogOutputs(); outs != nil {
+			r.Use(accessLogOutputsMiddleware(
+				outs,
+				resolvedRequestIDHeaderKey,
+				cfg.Logging.AppNameInfer.Enabled,
+				cfg.Logging.AppNameInfer.Unknown,
+			))
+		} else {
+			r.Use(requestLoggerWithColor(
+				accessLogger,
+				accessLoggerColor,
+				resolvedRequestIDHeaderKey,
+				cfg.Logging.AppNameInfer.Enabled,
+				cfg.Logging.AppNameInfer.Unknown,
+				accessFormatter,
+			))
+		}
 	}
 	r.Use(gin.Recovery())
 	if cfg.TrafficDump.Enabled {
 		r.Use(trafficDumpMiddleware(cfg, resolvedRequestIDHeaderKey))
 	}
+	if cfg.UsageLedger.Enabled {
+		r.Use(usageLedgerMiddleware(st, resolvedRequestIDHeaderKey, cfg.Logging.AppNameInfer.Enabled, cfg.Logging.AppNameInfer.Unknown))
+	}
 
 	r.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
 	secured := r.Group("/")
-	secured.Use(auth.Middleware(
+	if cfg.Server.TLS.Enabled {
+		secured.Use(auth.ClientCertMiddleware(func(cert *x509.Certificate) (string, bool) {
+			ks := st.Keys()
+			if ks == nil {
+				return "", false
+			}
+			ak, ok := ks.MatchClientCertSubject(cert.Subject.CommonName, cert.Subject.String())
+			if !ok || ak == nil {
+				return "", false
+			}
+			return strings.TrimSpace(ak.Name), true
+		}))
+	}
+	secured.Use(newAuthMiddleware(cfg, st))
+
+	secured.GET("/admin/providers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"providers": reg.ListProviderNames(),
+		})
+	})
+
+	registerProxyRoutes(secured, cfg, st, pclient, resolvedRequestIDHeaderKey)
+
+	// Endpoints that expose provider directives, key selection, balances or OAuth token
+	// state across every access key are restricted to the master key.
+	admin := r.Group("/admin", auth.MasterKeyMiddleware(cfg.Auth.APIKey))
+	admin.POST("/explain", makeExplainHandler(newExplainEngine(cfg, st, pclient, resolvedRequestIDHeaderKey)))
+	admin.GET("/oauth/status", makeOAuthStatusHandler(st, reg, pclient))
+	m := st.BalanceMonitor()
+	if m != nil {
+		admin.GET("/balances", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"balances": m.Entries()})
+		})
+	}
+	admin.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		if m != nil {
+			m.WriteMetrics(c.Writer)
+		}
+		writeAccessLogMetrics(c.Writer, st.AccessLogOutputs().Stats())
+	})
+
+	return r
+}
+
+// newAuthMiddleware returns the API auth middleware (master key, access keys, token keys).
+func newAuthMiddleware(cfg *config.Config, st *state) gin.HandlerFunc {
+	return auth.Middleware(
 		cfg.Auth.APIKey,
 		func(accessKey string) (string, bool) {
 			ks := st.Keys()
@@ -66,15 +127,12 @@ func NewRouter(
 		auth.TokenKeyOptions{
 			AllowBYOKWithoutK: cfg.Auth.TokenKey.AllowBYOKWithoutK,
 		},
-	))
-
-	secured.GET("/admin/providers", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"providers": reg.ListProviderNames(),
-		})
-	})
+	)
+}
 
-	v1 := secured.Group("/v1")
+// registerProxyRoutes registers the OpenAI/Anthropic/Gemini-compatible proxy routes on g.
+func registerProxyRoutes(g *gin.RouterGroup, cfg *config.Config, st *state, pclient *proxy.Client, resolvedRequestIDHeaderKey string) {
+	v1 := g.Group("/v1")
 	v1.POST("/completions", makeHandler(cfg, st, pclient, "completions", resolvedRequestIDHeaderKey))
 	v1.POST("/chat/completions", makeHandler(cfg, st, pclient, "chat.completions", resolvedRequestIDHeaderKey))
 	v1.POST("/responses", makeHandler(cfg, st, pclient, "responses", resolvedRequestIDHeaderKey))
@@ -89,7 +147,7 @@ func NewRouter(
 		c.JSON(http.StatusOK, st.ModelRouter().ToOpenAIListAt(st.StartedAtUnix()))
 	})
 
-	v1beta := secured.Group("/v1beta")
+	v1beta := g.Group("/v1beta")
 	// Gemini-style model listing.
 	v1beta.GET("/models", func(c *gin.Context) {
 		type geminiModel struct {
@@ -109,8 +167,6 @@ func NewRouter(
 	// Gemini native API paths: /v1beta/models/{model}:generateContent
 	// (Stage 1) Only generateContent / streamGenerateContent are supported.
 	v1beta.POST("/models/*path", makeGeminiHandler(cfg, st, pclient, resolvedRequestIDHeaderKey))
-
-	return r
 }
 
 func requestIDMiddleware(headerKey string) gin.HandlerFunc {
@@ -135,8 +191,20 @@ func trafficDumpMiddleware(cfg *config.Config, requestIDHeaderKey string) gin.Ha
 		MaxBytes:    cfg.TrafficDump.MaxBytes,
 		MaskSecrets: cfg.TrafficDump.MaskSecrets,
 		Sections:    cfg.TrafficDump.Sections,
+		Format:      cfg.TrafficDump.Format,
 	}
+	sampler, err := config.NewTrafficDumpSampler(cfg)
+	if err != nil {
+		log.Printf("[ONR] WARN | traffic_dump | invalid traffic dump sampling, keeping all dumps | error=%v", err)
+		sampler = nil
+	}
+	// With sampling, dumps stay in memory until the keep decision so dropped
+	// requests cost no file I/O.
+	tdcfg.Deferred = sampler != nil
 	return func(c *gin.Context) {
+		if sampler != nil {
+			c.Set(ctxKeyTrafficDumpSampler, sampler)
+		}
 		rec, err := trafficdump.StartWithHeaderKey(c, tdcfg, requestIDHeaderKey)
 		if err != nil {
 			log.Printf(
@@ -148,7 +216,11 @@ func trafficDumpMiddleware(cfg *config.Config, requestIDHeaderKey string) gin.Ha
 			return
 		}
 		c.Next()
-		rec.Close()
+		if sampler.Keep(c.GetString("onr.api"), c.Writer.Status()) {
+			rec.Close()
+		} else {
+			rec.Discard()
+		}
 		if werr := rec.Err(); werr != nil {
 			log.Printf(
 				"[ONR] WARN | traffic_dump | traffic dump write failed | request_id=%s error=%v path=%s",
@@ -159,3 +231,14 @@ func trafficDumpMiddleware(cfg *config.Config, requestIDHeaderKey string) gin.Ha
 		}
 	}
 }
+
+// setRequestAPI records the resolved API and stops the traffic dump when
+// sampling keeps no dump of that API whatever the response status.
+func setRequestAPI(c *gin.Context, api string) {
+	c.Set("onr.api", api)
+	if v, ok := c.Get(ctxKeyTrafficDumpSampler); ok {
+		if sampler, _ := v.(*trafficdump.Sampler); sampler.Drops(api) {
+			trafficdump.Drop(c)
+		}
+	}
+}
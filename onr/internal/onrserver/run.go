@@ -25,6 +25,7 @@ import (
 	"github.com/r9s-ai/open-next-router/onr/internal/logx"
 	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
 	"github.com/r9s-ai/open-next-router/pkg/config"
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
 )
 
 type providersReloadResult struct {
@@ -44,12 +45,34 @@ func Run(cfgPath string) error {
 		return fmt.Errorf("init system log: %w", err)
 	}
 
-	accessLogger, accessClose, accessColor, err := openAccessLogger(cfg)
+	accessFormat, err := logx.ResolveAccessLogFormat(cfg.Logging.AccessLogFormat, cfg.Logging.AccessLogFormatPreset)
+	if err != nil {
+		return fmt.Errorf("resolve access log format: %w", err)
+	}
+	accessFormatter, err := logx.CompileAccessLogFormat(accessFormat)
 	if err != nil {
-		return fmt.Errorf("init access log: %w", err)
+		return fmt.Errorf("compile access_log_format: %w", err)
 	}
-	if accessClose != nil {
-		defer func() { _ = accessClose.Close() }()
+	accessOutputs, err := openAccessLogOutputs(cfg, accessFormatter)
+	if err != nil {
+		return fmt.Errorf("init access log outputs: %w", err)
+	}
+	if accessOutputs != nil {
+		defer func() { _ = accessOutputs.Close() }()
+	}
+	var (
+		accessLogger *log.Logger
+		accessColor  bool
+	)
+	if accessOutputs == nil {
+		var accessClose io.Closer
+		accessLogger, accessClose, accessColor, err = openAccessLogger(cfg)
+		if err != nil {
+			return fmt.Errorf("init access log: %w", err)
+		}
+		if accessClose != nil {
+			defer func() { _ = accessClose.Close() }()
+		}
 	}
 
 	pidCleanup, err := writePIDFile(cfg)
@@ -80,9 +103,9 @@ func Run(cfgPath string) error {
 	readTimeout := time.Duration(cfg.Server.ReadTimeoutMs) * time.Millisecond
 	writeTimeout := time.Duration(cfg.Server.WriteTimeoutMs) * time.Millisecond
 
-	httpClient := &http.Client{
-		Timeout: writeTimeout,
-	}
+	// Upstream calls are bounded per call (DSL timeout_ms, else writeTimeout), not by a
+	// client-wide Timeout that would cut off longer DSL budgets.
+	httpClient := &http.Client{}
 
 	pclient := &proxy.Client{
 		HTTP:                     httpClient,
@@ -107,10 +130,25 @@ func Run(cfgPath string) error {
 		modelRouter: mr,
 	}
 	st.SetStartedAtUnix(startedAt)
+	st.SetAccessLogOutputs(accessOutputs)
+	if cfg.UsageLedger.Enabled {
+		ledger, err := usageledger.Open(cfg.UsageLedger.Dir, usageledger.Options{RetentionDays: cfg.UsageLedger.RetentionDays})
+		if err != nil {
+			return fmt.Errorf("open usage ledger: %w", err)
+		}
+		defer func() { _ = ledger.Close() }()
+		st.SetUsageLedger(ledger)
+	}
+
+	tlsr, err := newTLSReloader(cfg.Server.TLS)
+	if err != nil {
+		return fmt.Errorf("init tls: %w", err)
+	}
 
+	auditor := newReloadAuditor(cfg)
 	reloadMu := &sync.Mutex{}
-	installReloadSignalHandler(cfg, st, reg, pclient, reloadMu, sysLogger)
-	autoReloadClose, err := installProvidersAutoReload(cfg, reg, reloadMu, sysLogger)
+	installReloadSignalHandler(cfg, st, reg, pclient, tlsr, auditor, reloadMu, sysLogger)
+	autoReloadClose, err := installProvidersAutoReload(cfg, reg, auditor, reloadMu, sysLogger)
 	if err != nil {
 		return fmt.Errorf("init providers auto reload: %w", err)
 	}
@@ -118,27 +156,63 @@ func Run(cfgPath string) error {
 		defer func() { _ = autoReloadClose.Close() }()
 	}
 
-	accessFormat, err := logx.ResolveAccessLogFormat(cfg.Logging.AccessLogFormat, cfg.Logging.AccessLogFormatPreset)
-	if err != nil {
-		return fmt.Errorf("resolve access log format: %w", err)
+	if modelsSync := installModelsSync(cfg, st, reg, pclient, auditor, reloadMu, sysLogger); modelsSync != nil {
+		defer func() { _ = modelsSync.Close() }()
 	}
-	accessFormatter, err := logx.CompileAccessLogFormat(accessFormat)
-	if err != nil {
-		return fmt.Errorf("compile access_log_format: %w", err)
+	if balances := installBalanceMonitor(cfg, st, reg, pclient, sysLogger); balances != nil {
+		defer func() { _ = balances.Close() }()
+	}
+	if janitor := installTrafficDumpJanitor(cfg, sysLogger); janitor != nil {
+		defer func() { _ = janitor.Close() }()
 	}
+
 	engine := NewRouter(cfg, st, reg, pclient, accessLogger, accessColor, "X-Onr-Request-Id", accessFormatter)
 
 	logStartupSummary(sysLogger, cfg, cfgPath)
+	listenURL := resolveListenURL(cfg.Server.Listen)
+	if tlsr != nil {
+		listenURL = "https://" + strings.TrimPrefix(listenURL, "http://")
+	}
 	sysLogger.Info(logx.SystemCategoryServer, "open-next-router listening", map[string]any{
-		"listen_url":               resolveListenURL(cfg.Server.Listen),
+		"listen_url":               listenURL,
 		"providers_source_is_file": providersFromFile,
+		"tls_enabled":              tlsr != nil,
+		"tls_client_auth":          tlsClientAuthForLog(cfg),
 	})
-	if err := engine.Run(cfg.Server.Listen); err != nil {
+	srv := newHTTPServer(cfg.Server.Listen, engine.Handler(), tlsr, readTimeout)
+	if tlsr != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
 		return fmt.Errorf("run: %w", err)
 	}
 	return nil
 }
 
+// newHTTPServer builds the listener server. The plaintext listener keeps the
+// previous engine.Run behavior (no server-side timeouts); the TLS listener
+// bounds the handshake plus headers by read_timeout_ms. tlsr is nil when TLS
+// is disabled.
+func newHTTPServer(addr string, handler http.Handler, tlsr *tlsReloader, readTimeout time.Duration) *http.Server {
+	// #nosec G112 -- plaintext keeps the historical no-timeout behavior; see above.
+	srv := &http.Server{Addr: addr, Handler: handler}
+	if tlsr != nil {
+		srv.TLSConfig = tlsr.TLSConfig()
+		srv.ReadHeaderTimeout = readTimeout
+	}
+	return srv
+}
+
+// tlsClientAuthForLog requires a non-nil config loaded by Run.
+func tlsClientAuthForLog(cfg *config.Config) string {
+	if !cfg.Server.TLS.Enabled {
+		return "disabled"
+	}
+	return cfg.Server.TLS.ClientAuth
+}
+
 // openAccessLogger requires a non-nil config loaded by Run.
 func openAccessLogger(cfg *config.Config) (*log.Logger, io.Closer, bool, error) {
 	if !cfg.Logging.AccessLog {
@@ -167,14 +241,7 @@ func openAccessLogger(cfg *config.Config) (*log.Logger, io.Closer, bool, error)
 		return log.New(w, "", 0), w, false, nil
 	}
 
-	dir := filepath.Dir(path)
-	if strings.TrimSpace(dir) != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0o750); err != nil {
-			return nil, nil, false, err
-		}
-	}
-	// #nosec G304 -- access_log_path comes from trusted config/env.
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	f, err := logx.OpenAccessLogFile(path)
 	if err != nil {
 		return nil, nil, false, err
 	}
@@ -212,13 +279,20 @@ func writePIDFile(cfg *config.Config) (io.Closer, error) {
 }
 
 // installReloadSignalHandler requires non-nil config, state, registry, proxy client, and mutex from Run.
-func installReloadSignalHandler(cfg *config.Config, st *state, reg *dslconfig.Registry, pclient *proxy.Client, mu *sync.Mutex, logger *logx.SystemLogger) {
+// tlsr is nil when TLS is disabled and auditor is nil when audit.file is not configured.
+func installReloadSignalHandler(cfg *config.Config, st *state, reg *dslconfig.Registry, pclient *proxy.Client, tlsr *tlsReloader, auditor *reloadAuditor, mu *sync.Mutex, logger *logx.SystemLogger) {
 	ch := make(chan os.Signal, 2)
 	signal.Notify(ch, syscall.SIGHUP)
 	go func() {
 		for range ch {
 			mu.Lock()
 			providersRes, err := reloadRuntime(cfg, st, reg, pclient, logger)
+			if err == nil && tlsr != nil {
+				if tlsErr := tlsr.Reload(); tlsErr != nil {
+					err = fmt.Errorf("reload tls: %w", tlsErr)
+				}
+			}
+			auditor.Record(cfg, "signal", false, err)
 			mu.Unlock()
 			if err != nil {
 				logReloadFailed(logger, "signal", err)
@@ -251,6 +325,8 @@ func reloadRuntime(cfg *config.Config, st *state, reg *dslconfig.Registry, pclie
 	if err != nil {
 		return providersReloadResult{}, err
 	}
+	// An explicit reload re-resolves ref+exec/ref+vault secrets instead of serving cached values.
+	keystore.ResetSecretCache()
 	ks, err := keystore.Load(cfg.Keys.File)
 	if err != nil {
 		return providersReloadResult{}, fmt.Errorf("reload keys file %q: %w", cfg.Keys.File, err)
@@ -310,10 +386,16 @@ func logStartupSummary(logger *logx.SystemLogger, cfg *config.Config, cfgPath st
 		"traffic_dump_enabled":              cfg.TrafficDump.Enabled,
 		"traffic_dump_dir":                  cfg.TrafficDump.Dir,
 		"traffic_dump_max_bytes":            cfg.TrafficDump.MaxBytes,
+		"traffic_dump_format":               cfg.TrafficDump.Format,
+		"usage_ledger_enabled":              cfg.UsageLedger.Enabled,
+		"usage_ledger_dir":                  cfg.UsageLedger.Dir,
 		"access_log_enabled":                cfg.Logging.AccessLog,
 		"access_log_target":                 accessLogTarget(cfg),
+		"access_log_encoding":               accessLogEncoding(cfg),
 		"providers_auto_reload_enabled":     cfg.Providers.AutoReload.Enabled,
 		"providers_auto_reload_debounce_ms": cfg.Providers.AutoReload.DebounceMs,
+		"models_sync_enabled":               cfg.Models.Sync.Enabled,
+		"balance_monitor_enabled":           cfg.BalanceMonitor.Enabled,
 	})
 }
 
@@ -347,6 +429,22 @@ func accessLogTarget(cfg *config.Config) string {
 	if !cfg.Logging.AccessLog {
 		return "disabled"
 	}
+	if len(cfg.Logging.AccessLogOutputs) > 0 {
+		targets := make([]string, 0, len(cfg.Logging.AccessLogOutputs))
+		for _, o := range cfg.Logging.AccessLogOutputs {
+			switch strings.ToLower(strings.TrimSpace(o.Type)) {
+			case "file":
+				targets = append(targets, strings.TrimSpace(o.Path))
+			case "syslog":
+				targets = append(targets, "syslog:"+strings.TrimSpace(o.Address))
+			case "http":
+				targets = append(targets, "http")
+			default:
+				targets = append(targets, strings.ToLower(strings.TrimSpace(o.Type)))
+			}
+		}
+		return strings.Join(targets, ",")
+	}
 	if strings.TrimSpace(cfg.Logging.AccessLogPath) == "" {
 		return "stdout"
 	}
@@ -450,3 +548,11 @@ func diffChangedProviderNames(before map[string]string, after map[string]string)
 	sort.Strings(changed)
 	return changed
 }
+
+func accessLogEncoding(cfg *config.Config) string {
+	enc, err := logx.NormalizeAccessLogEncoding(cfg.Logging.AccessLogEncoding)
+	if err != nil {
+		return strings.TrimSpace(cfg.Logging.AccessLogEncoding)
+	}
+	return enc
+}
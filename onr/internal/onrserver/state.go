@@ -5,6 +5,8 @@ import (
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/keystore"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/models"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
 )
 
 type state struct {
@@ -12,6 +14,9 @@ type state struct {
 	keys        *keystore.Store
 	modelRouter *models.Router
 	startedAt   int64
+	usageLedger *usageledger.Ledger
+	accessLog   *logx.AccessLogOutputs
+	balances    *balanceMonitor
 }
 
 // Keys returns the current key store and may return nil before one is configured.
@@ -51,3 +56,43 @@ func (s *state) SetStartedAtUnix(ts int64) {
 	defer s.mu.Unlock()
 	s.startedAt = ts
 }
+
+// UsageLedger returns the usage ledger and may return nil when it is disabled.
+func (s *state) UsageLedger() *usageledger.Ledger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.usageLedger
+}
+
+func (s *state) SetUsageLedger(l *usageledger.Ledger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usageLedger = l
+}
+
+// AccessLogOutputs returns the multi-sink access log and may return nil when
+// the single access logger is used.
+func (s *state) AccessLogOutputs() *logx.AccessLogOutputs {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.accessLog
+}
+
+func (s *state) SetAccessLogOutputs(o *logx.AccessLogOutputs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessLog = o
+}
+
+// BalanceMonitor returns the balance monitor and may return nil when it is disabled.
+func (s *state) BalanceMonitor() *balanceMonitor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.balances
+}
+
+func (s *state) SetBalanceMonitor(m *balanceMonitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances = m
+}
@@ -0,0 +1,116 @@
+package onrserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+// tlsReloader holds the server certificate and client CA pool so they can be
+// swapped on SIGHUP without restarting the listener.
+type tlsReloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	minVersion uint16
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// newTLSReloader returns nil, nil when server.tls is disabled.
+func newTLSReloader(cfg config.ServerTLSConfig) (*tlsReloader, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	minVersion := uint16(tls.VersionTLS12)
+	if strings.TrimSpace(cfg.MinVersion) == "1.3" {
+		minVersion = tls.VersionTLS13
+	}
+	r := &tlsReloader{
+		certFile:   strings.TrimSpace(cfg.CertFile),
+		keyFile:    strings.TrimSpace(cfg.KeyFile),
+		caFile:     strings.TrimSpace(cfg.ClientCAFile),
+		clientAuth: clientAuth,
+		minVersion: minVersion,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseClientAuth(v string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("invalid server.tls.client_auth %q", v)
+	}
+}
+
+// Reload re-reads the certificate, key and client CA files. On error the previous
+// material stays in use.
+func (r *tlsReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		// #nosec G304 -- client_ca_file comes from trusted config/env.
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("read tls client ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("tls client ca file contains no PEM certificates")
+		}
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.pool = pool
+	r.mu.Unlock()
+	return nil
+}
+
+// TLSConfig returns a server config that resolves certificate and client CAs per handshake.
+// HTTP/2 is offered through ALPN.
+func (r *tlsReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			cert := r.cert
+			pool := r.pool
+			r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   r.minVersion,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    pool,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package onrserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA bool, client bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeTestFile(t *testing.T, path string, b []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestTLSReloader_MTLSAndHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil, true, false)
+	server := newTestCert(t, "server-1", ca, false, false)
+	client := newTestCert(t, "svc-a", ca, false, true)
+
+	certFile := filepath.Join(dir, "server.pem")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.pem")
+	writeTestFile(t, certFile, server.certPEM)
+	writeTestFile(t, keyFile, server.keyPEM)
+	writeTestFile(t, caFile, ca.certPEM)
+
+	r, err := newTLSReloader(config.ServerTLSConfig{
+		Enabled:      true,
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   "require",
+	})
+	if err != nil {
+		t.Fatalf("newTLSReloader: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cn := ""
+		if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+			cn = req.TLS.VerifiedChains[0][0].Subject.CommonName
+		}
+		_, _ = io.WriteString(w, req.Proto+" "+cn)
+	}))
+	srv.EnableHTTP2 = true
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientPair, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	if err != nil {
+		t.Fatalf("client key pair: %v", err)
+	}
+	var seenServerCN string
+	newClient := func(withCert bool) *http.Client {
+		tc := &tls.Config{
+			RootCAs:    roots,
+			ServerName: "localhost",
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				seenServerCN = cs.PeerCertificates[0].Subject.CommonName
+				return nil
+			},
+		}
+		if withCert {
+			tc.Certificates = []tls.Certificate{clientPair}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: tc, ForceAttemptHTTP2: true}}
+	}
+
+	resp, err := newClient(true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("mtls get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "HTTP/2.0 svc-a" {
+		t.Fatalf("body=%q", body)
+	}
+	if seenServerCN != "server-1" {
+		t.Fatalf("server cert CN=%q", seenServerCN)
+	}
+
+	if _, err := newClient(false).Get(srv.URL); err == nil {
+		t.Fatalf("expected handshake failure without client cert")
+	}
+
+	server2 := newTestCert(t, "server-2", ca, false, false)
+	writeTestFile(t, certFile, server2.certPEM)
+	writeTestFile(t, keyFile, server2.keyPEM)
+	if err := r.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	resp, err = newClient(true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("get after reload: %v", err)
+	}
+	_ = resp.Body.Close()
+	if seenServerCN != "server-2" {
+		t.Fatalf("server cert after reload CN=%q", seenServerCN)
+	}
+
+	writeTestFile(t, keyFile, []byte("broken"))
+	if err := r.Reload(); err == nil {
+		t.Fatalf("expected reload error for broken key")
+	}
+	resp, err = newClient(true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("previous certificate should stay active: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestNewTLSReloader_Disabled(t *testing.T) {
+	r, err := newTLSReloader(config.ServerTLSConfig{})
+	if err != nil || r != nil {
+		t.Fatalf("disabled tls: r=%v err=%v", r, err)
+	}
+	if _, err := newTLSReloader(config.ServerTLSConfig{Enabled: true, CertFile: "/missing", KeyFile: "/missing"}); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+}
+
+func TestNewHTTPServer_ReadHeaderTimeoutOnlyForTLS(t *testing.T) {
+	plain := newHTTPServer(":0", http.NotFoundHandler(), nil, 5*time.Second)
+	if plain.ReadHeaderTimeout != 0 || plain.TLSConfig != nil {
+		t.Fatalf("plaintext server should keep no header timeout: %+v", plain)
+	}
+
+	dir := t.TempDir()
+	cert := newTestCert(t, "server-1", nil, false, false)
+	certFile := filepath.Join(dir, "server.pem")
+	keyFile := filepath.Join(dir, "server.key")
+	writeTestFile(t, certFile, cert.certPEM)
+	writeTestFile(t, keyFile, cert.keyPEM)
+	r, err := newTLSReloader(config.ServerTLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("newTLSReloader: %v", err)
+	}
+	secure := newHTTPServer(":0", http.NotFoundHandler(), r, 5*time.Second)
+	if secure.ReadHeaderTimeout != 5*time.Second || secure.TLSConfig == nil {
+		t.Fatalf("tls server timeout=%v tls=%v", secure.ReadHeaderTimeout, secure.TLSConfig != nil)
+	}
+}
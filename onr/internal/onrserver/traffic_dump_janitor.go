@@ -0,0 +1,73 @@
+package onrserver
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/pkg/config"
+)
+
+// installTrafficDumpJanitor starts a background pass over traffic_dump.dir
+// that enforces traffic_dump.retention. It returns nil when dumps are off or
+// no limit is set.
+func installTrafficDumpJanitor(cfg *config.Config, sysLogger *logx.SystemLogger) io.Closer {
+	if !cfg.TrafficDump.Enabled {
+		return nil
+	}
+	opts := config.TrafficDumpRetention(cfg)
+	if !opts.Enabled() {
+		return nil
+	}
+	interval := time.Duration(cfg.TrafficDump.Retention.JanitorIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	dir := strings.TrimSpace(cfg.TrafficDump.Dir)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runTrafficDumpJanitor(dir, opts, sysLogger)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return closerFunc(func() error {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+		return nil
+	})
+}
+
+func runTrafficDumpJanitor(dir string, opts trafficdump.RetentionOptions, sysLogger *logx.SystemLogger) {
+	res, err := trafficdump.Prune(dir, opts, time.Now())
+	if err != nil {
+		sysLogger.Warn(logx.SystemCategoryTrafficDump, "traffic dump retention pass failed", map[string]any{
+			"dir":   dir,
+			"error": err.Error(),
+		})
+	}
+	if res.Removed > 0 {
+		sysLogger.Info(logx.SystemCategoryTrafficDump, "traffic dump retention removed files", map[string]any{
+			"dir":           dir,
+			"removed":       res.Removed,
+			"removed_bytes": res.RemovedBytes,
+			"files":         res.Files,
+			"bytes":         res.Bytes,
+		})
+	}
+}
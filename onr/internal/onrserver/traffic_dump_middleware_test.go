@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
 	"github.com/r9s-ai/open-next-router/pkg/config"
 )
 
@@ -59,3 +60,83 @@ func TestTrafficDumpMiddleware_LogsWarningWhenStartFails(t *testing.T) {
 		t.Fatalf("expected warning log for traffic dump start failure, got=%q", got)
 	}
 }
+
+func TestTrafficDumpMiddleware_SamplingDiscardsUnsampledDumps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmp := t.TempDir()
+	cfg := &config.Config{}
+	cfg.TrafficDump.Enabled = true
+	cfg.TrafficDump.Dir = tmp
+	cfg.TrafficDump.FilePath = "{{.request_id}}.log"
+	cfg.TrafficDump.MaxBytes = 1024
+	zero, one := 0.0, 1.0
+	cfg.TrafficDump.Sampling = config.TrafficDumpSamplingConfig{
+		DefaultRate: &zero,
+		Rules:       []config.TrafficDumpSamplingRule{{Status: "5xx", Rate: &one}},
+	}
+
+	r := gin.New()
+	r.Use(trafficDumpMiddleware(cfg, "X-Onr-Request-Id"))
+	r.GET("/status/:code", func(c *gin.Context) {
+		setRequestAPI(c, "chat.completions")
+		trafficdump.AppendOriginRequest(c, []byte(`{"model":"m"}`), false, false)
+		if _, err := os.Stat(filepath.Join(tmp, trafficdump.RequestIDWithHeaderKey(c, "X-Onr-Request-Id")+".log")); !os.IsNotExist(err) {
+			t.Errorf("sampled dump written before the keep decision, stat err=%v", err)
+		}
+		code := http.StatusOK
+		if c.Param("code") == "502" {
+			code = http.StatusBadGateway
+		}
+		c.Status(code)
+	})
+
+	for _, tc := range []struct{ rid, code string }{{"rid-ok", "200"}, {"rid-err", "502"}} {
+		req := httptest.NewRequest(http.MethodGet, "/status/"+tc.code, nil)
+		req.Header.Set("X-Onr-Request-Id", tc.rid)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "rid-ok.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected 2xx dump discarded, err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "rid-err.log")); err != nil {
+		t.Fatalf("expected 5xx dump kept: %v", err)
+	}
+}
+
+func TestTrafficDumpMiddleware_StopsWhenAPIIsNeverSampled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmp := t.TempDir()
+	cfg := &config.Config{}
+	cfg.TrafficDump.Enabled = true
+	cfg.TrafficDump.Dir = tmp
+	cfg.TrafficDump.FilePath = "{{.request_id}}.log"
+	cfg.TrafficDump.MaxBytes = 1024
+	zero := 0.0
+	cfg.TrafficDump.Sampling = config.TrafficDumpSamplingConfig{
+		Rules: []config.TrafficDumpSamplingRule{{API: "embeddings", Rate: &zero}},
+	}
+
+	r := gin.New()
+	r.Use(trafficDumpMiddleware(cfg, "X-Onr-Request-Id"))
+	r.POST("/:api", func(c *gin.Context) {
+		setRequestAPI(c, c.Param("api"))
+		if got := trafficdump.FromContext(c) != nil; got != (c.Param("api") != "embeddings") {
+			t.Errorf("api=%s recording=%v", c.Param("api"), got)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, api := range []string{"embeddings", "chat.completions"} {
+		req := httptest.NewRequest(http.MethodPost, "/"+api, nil)
+		req.Header.Set("X-Onr-Request-Id", "rid-"+api)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "rid-embeddings.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected embeddings dump dropped, err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "rid-chat.completions.log")); err != nil {
+		t.Fatalf("expected chat dump kept: %v", err)
+	}
+}
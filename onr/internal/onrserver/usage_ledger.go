@@ -0,0 +1,139 @@
+package onrserver
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/appnameinfer"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/pricing"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/requestid"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
+)
+
+// usageLedgerMiddleware appends one ledger record per proxied request after the
+// handler has set the onr.* context keys. Requests that never selected a
+// provider (auth failures, health checks, model listing) are skipped.
+func usageLedgerMiddleware(st *state, requestIDHeaderKey string, appnameInferEnabled bool, appnameInferUnknown string) gin.HandlerFunc {
+	requestIDHeaderKey = requestid.ResolveHeaderKey(requestIDHeaderKey)
+	appnameInferUnknown = strings.TrimSpace(appnameInferUnknown)
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ledger := st.UsageLedger()
+		if ledger == nil || strings.TrimSpace(c.GetString("onr.provider")) == "" {
+			return
+		}
+		rec := buildUsageLedgerRecord(c, requestIDHeaderKey, start)
+		rec.AppName = resolveLedgerAppName(c, appnameInferEnabled, appnameInferUnknown)
+		if err := ledger.Append(rec); err != nil {
+			log.Printf(
+				"[ONR] WARN | usage_ledger | usage ledger write failed | request_id=%s error=%v path=%s",
+				rec.RequestID,
+				err,
+				c.Request.URL.Path,
+			)
+		}
+	}
+}
+
+// buildUsageLedgerRecord requires a non-nil Gin context after the handler ran.
+func buildUsageLedgerRecord(c *gin.Context, requestIDHeaderKey string, start time.Time) usageledger.Record {
+	rec := usageledger.Record{
+		Time:         start.UTC(),
+		RequestID:    strings.TrimSpace(c.GetString(requestIDHeaderKey)),
+		AccessKey:    strings.TrimSpace(c.GetString("onr.access_key")),
+		Provider:     strings.TrimSpace(c.GetString("onr.provider")),
+		ProviderKey:  strings.TrimSpace(c.GetString("onr.provider_key")),
+		API:          strings.TrimSpace(c.GetString("onr.api")),
+		Stream:       c.GetBool("onr.stream"),
+		Model:        strings.TrimSpace(c.GetString("onr.model")),
+		Status:       c.Writer.Status(),
+		FinishReason: strings.TrimSpace(c.GetString("onr.finish_reason")),
+		UsageStage:   strings.TrimSpace(c.GetString("onr.usage_stage")),
+		CostUnit:     strings.TrimSpace(c.GetString("onr.cost_unit")),
+		LatencyMs:    time.Since(start).Milliseconds(),
+	}
+	if v, ok := c.Get("onr.latency_ms"); ok {
+		if n, ok := ledgerNumber(v); ok {
+			rec.LatencyMs = int64(n)
+		}
+	}
+	if v, ok := c.Get("onr.upstream_status"); ok {
+		if n, ok := ledgerNumber(v); ok {
+			rec.UpstreamStatus = int(n)
+		}
+	}
+
+	usage := map[string]float64{}
+	for _, s := range logx.StandardUsageContextFieldSpecs() {
+		if v, ok := c.Get(s.CtxKey); ok {
+			if n, ok := ledgerNumber(v); ok {
+				usage[s.LogKey] = n
+			}
+		}
+	}
+	for key, v := range c.Keys {
+		k, _ := key.(string)
+		name, ok := strings.CutPrefix(k, "onr.usage_extra.")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		if n, ok := ledgerNumber(v); ok {
+			usage[name] = n
+		}
+	}
+	if len(usage) > 0 {
+		rec.Usage = usage
+	}
+
+	if v, ok := c.Get("onr.cost_total"); ok {
+		if n, ok := ledgerNumber(v); ok {
+			rec.Cost = n
+		}
+	}
+	if v, ok := c.Get("onr.cost_line_items"); ok {
+		switch items := v.(type) {
+		case pricing.CostLineItems:
+			rec.CostItems = items
+		case []pricing.CostLineItem:
+			rec.CostItems = items
+		}
+	}
+	return rec
+}
+
+// resolveLedgerAppName mirrors the access log appname resolution.
+func resolveLedgerAppName(c *gin.Context, inferEnabled bool, unknown string) string {
+	if v := strings.TrimSpace(c.GetHeader("appname")); v != "" {
+		return v
+	}
+	if !inferEnabled {
+		return ""
+	}
+	if v, ok := appnameinfer.Infer(c.GetHeader("User-Agent")); ok {
+		return v
+	}
+	return unknown
+}
+
+func ledgerNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,79 @@
+package onrserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/pricing"
+	"github.com/r9s-ai/open-next-router/onr/internal/proxy"
+	"github.com/r9s-ai/open-next-router/pkg/usageledger"
+)
+
+func TestUsageLedgerMiddleware_RecordsProxiedRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	ledger, err := usageledger.Open(dir, usageledger.Options{})
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	st := &state{}
+	st.SetUsageLedger(ledger)
+
+	r := gin.New()
+	r.Use(usageLedgerMiddleware(st, "X-Onr-Request-Id", false, ""))
+	r.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Set("X-Onr-Request-Id", "rid-1")
+		c.Set("onr.access_key", "team-a")
+		c.Set("onr.api", "chat.completions")
+		c.Set("onr.provider", "openai")
+		c.Set("onr.model", "gpt-4o-mini")
+		c.Set("onr.stream", true)
+		setProxyResultContext(c, &proxy.Result{
+			ProviderKey: "key1",
+			Status:      http.StatusOK,
+			LatencyMs:   42,
+			Usage:       map[string]any{"input_tokens": 12, "output_tokens": 3, "image_count": 2},
+			Cost: map[string]any{
+				"cost_total":      0.5,
+				"cost_unit":       "USD",
+				"cost_line_items": pricing.CostLineItems{{Name: "input", Quantity: 12, Cost: 0.5}},
+			},
+		})
+		c.Status(http.StatusOK)
+	})
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil),
+		httptest.NewRequest(http.MethodGet, "/healthz", nil),
+	} {
+		req.Header.Set("appname", "my-app")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err := ledger.Close(); err != nil {
+		t.Fatalf("close ledger: %v", err)
+	}
+
+	recs, _, err := usageledger.Read(dir, usageledger.Filter{})
+	if err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("expected only the proxied request to be recorded, got %+v", recs)
+	}
+	got := recs[0]
+	if got.RequestID != "rid-1" || got.AccessKey != "team-a" || got.AppName != "my-app" || got.ProviderKey != "key1" ||
+		got.API != "chat.completions" || !got.Stream || got.Status != http.StatusOK || got.UpstreamStatus != http.StatusOK || got.LatencyMs != 42 {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+	if got.Usage["input_tokens"] != 12 || got.Usage["image_count"] != 2 {
+		t.Fatalf("unexpected usage: %+v", got.Usage)
+	}
+	if got.Cost != 0.5 || got.CostUnit != "USD" || len(got.CostItems) != 1 || got.CostItems[0].Name != "input" {
+		t.Fatalf("unexpected cost: %+v", got)
+	}
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/balancequery"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+// QueryBalance requires a non-nil Client receiver. It runs the provider's DSL
+// balance block for key, using the same OAuth token cache and outbound proxy
+// as proxied requests.
+func (c *Client) QueryBalance(ctx context.Context, provider string, key ProviderKey, api string) (balancequery.Result, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	pf, ok := c.Registry.GetProvider(provider)
+	if !ok {
+		return balancequery.Result{}, fmt.Errorf("provider not found: %s", provider)
+	}
+	m := &dslmeta.Meta{
+		API:                strings.TrimSpace(api),
+		APIKey:             strings.TrimSpace(key.Value),
+		CredentialFile:     strings.TrimSpace(key.CredentialFile),
+		ChannelLocation:    normalizeProviderLocation(key.Location, strings.TrimSpace(key.CredentialFile) != ""),
+		AWSAccessKeyID:     strings.TrimSpace(key.AWSAccessKeyID),
+		AWSSecretAccessKey: strings.TrimSpace(key.AWSSecretAccessKey),
+		AWSSessionToken:    strings.TrimSpace(key.AWSSessionToken),
+		AWSRegion:          normalizeProviderLocation(firstNonEmpty(key.AWSRegion, key.Location), false),
+	}
+	projectID, err := credentialProjectIDFromFile(m.CredentialFile)
+	if err != nil {
+		return balancequery.Result{}, err
+	}
+	m.CredentialProjectID = projectID
+	if err := c.prepareOAuthForUpstream(ctx, provider, pf, m); err != nil {
+		return balancequery.Result{}, err
+	}
+	hc, err := c.httpClientForProvider(provider)
+	if err != nil {
+		return balancequery.Result{}, err
+	}
+	return balancequery.Query(ctx, balancequery.Params{
+		Provider:   provider,
+		File:       pf,
+		Meta:       m,
+		BaseURL:    normalizeUpstreamBaseURL(key.BaseURLOverride),
+		APIKey:     m.APIKey,
+		HTTPClient: hc,
+	})
+}
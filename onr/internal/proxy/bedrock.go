@@ -33,9 +33,9 @@ func (c *Client) doBedrockRuntimeRequest(gc *gin.Context, provider string, pf *d
 	}
 	switch operation {
 	case "invoke":
-		return c.doBedrockInvokeModel(gc, provider, m, reqBody)
+		return c.doBedrockInvokeModel(gc, provider, pf, m, reqBody)
 	case "invoke-with-response-stream":
-		return c.doBedrockInvokeModelStream(gc, provider, m, reqBody)
+		return c.doBedrockInvokeModelStream(gc, provider, pf, m, reqBody)
 	case "http-passthrough":
 		return c.doBedrockHTTPPassthrough(gc, provider, pf, m, reqBody)
 	default:
@@ -44,7 +44,8 @@ func (c *Client) doBedrockRuntimeRequest(gc *gin.Context, provider string, pf *d
 }
 
 func (c *Client) doBedrockHTTPPassthrough(gc *gin.Context, provider string, pf *dslconfig.ProviderFile, m *dslmeta.Meta, reqBody []byte) (*http.Response, context.CancelFunc, error) {
-	reqCtx, cancel := context.WithTimeout(gc.Request.Context(), c.WriteTimeout)
+	call := c.newUpstreamCall(gc, pf, m)
+	reqCtx, cancel := call.ctx, call.cancel
 	httpc, err := c.httpClientForProvider(provider)
 	if err != nil {
 		cancel()
@@ -79,7 +80,7 @@ func (c *Client) doBedrockHTTPPassthrough(gc *gin.Context, provider string, pf *
 		limited, truncated := trafficdump.LimitBytes(reqBody, rec.MaxBytes())
 		trafficdump.AppendUpstreamRequest(gc, req.Method, req.URL.String(), req.Header, limited, false, truncated)
 	}
-	resp, err := httpc.Do(req)
+	resp, err := call.do(httpc, req)
 	if err != nil {
 		cancel()
 		return nil, func() {}, err
@@ -87,8 +88,9 @@ func (c *Client) doBedrockHTTPPassthrough(gc *gin.Context, provider string, pf *
 	return resp, cancel, nil
 }
 
-func (c *Client) doBedrockInvokeModel(gc *gin.Context, provider string, m *dslmeta.Meta, reqBody []byte) (*http.Response, context.CancelFunc, error) {
-	reqCtx, cancel := context.WithTimeout(gc.Request.Context(), c.WriteTimeout)
+func (c *Client) doBedrockInvokeModel(gc *gin.Context, provider string, pf *dslconfig.ProviderFile, m *dslmeta.Meta, reqBody []byte) (*http.Response, context.CancelFunc, error) {
+	call := c.newUpstreamCall(gc, pf, m)
+	reqCtx, cancel := call.ctx, call.cancel
 	req, err := c.newBedrockRuntimeHTTPRequest(reqCtx, m, reqBody)
 	if err != nil {
 		cancel()
@@ -105,7 +107,7 @@ func (c *Client) doBedrockInvokeModel(gc *gin.Context, provider string, m *dslme
 		cancel()
 		return nil, func() {}, err
 	}
-	resp, err := httpc.Do(req)
+	resp, err := call.do(httpc, req)
 	if err != nil {
 		cancel()
 		return nil, func() {}, err
@@ -113,8 +115,9 @@ func (c *Client) doBedrockInvokeModel(gc *gin.Context, provider string, m *dslme
 	return resp, cancel, nil
 }
 
-func (c *Client) doBedrockInvokeModelStream(gc *gin.Context, provider string, m *dslmeta.Meta, reqBody []byte) (*http.Response, context.CancelFunc, error) {
-	reqCtx, cancel := context.WithTimeout(gc.Request.Context(), c.WriteTimeout)
+func (c *Client) doBedrockInvokeModelStream(gc *gin.Context, provider string, pf *dslconfig.ProviderFile, m *dslmeta.Meta, reqBody []byte) (*http.Response, context.CancelFunc, error) {
+	call := c.newUpstreamCall(gc, pf, m)
+	reqCtx, cancel := call.ctx, call.cancel
 	req, err := c.newBedrockRuntimeHTTPRequest(reqCtx, m, reqBody)
 	if err != nil {
 		cancel()
@@ -131,7 +134,7 @@ func (c *Client) doBedrockInvokeModelStream(gc *gin.Context, provider string, m
 		cancel()
 		return nil, func() {}, err
 	}
-	upstreamResp, err := httpc.Do(req)
+	upstreamResp, err := call.do(httpc, req)
 	if err != nil {
 		cancel()
 		return nil, func() {}, err
@@ -69,7 +69,7 @@ func TestDoBedrockInvokeModelStreamUsesHTTPAndDecodesEventStream(t *testing.T) {
 	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
 	gc.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
 	c := &Client{HTTP: srv.Client(), WriteTimeout: 5 * time.Second}
-	resp, cancel, err := c.doBedrockInvokeModelStream(gc, "aws-bedrock", &dslmeta.Meta{
+	resp, cancel, err := c.doBedrockInvokeModelStream(gc, "aws-bedrock", nil, &dslmeta.Meta{
 		BaseURL:            srv.URL,
 		AWSAccessKeyID:     "AKID",
 		AWSSecretAccessKey: "SECRET",
@@ -105,6 +105,7 @@ func (c *Client) buildProxyCtx(gc *gin.Context, provider string, key ProviderKey
 		RequestContentType: gc.Request.Header.Get("Content-Type"),
 		RequestHeaders:     gc.Request.Header,
 		RequestBody:        bodyBytes,
+		AccessKeyName:      auth.AccessKeyName(gc),
 		StartTime:          time.Now(),
 	}
 	if projectID, err := credentialProjectIDFromFile(m.CredentialFile); err != nil {
@@ -113,6 +114,9 @@ func (c *Client) buildProxyCtx(gc *gin.Context, provider string, key ProviderKey
 		m.CredentialProjectID = projectID
 	}
 	m.SetRequestRoot(root)
+	if len(pf.Vars.Maps) > 0 {
+		m.Vars = pf.Vars
+	}
 	if mo := strings.TrimSpace(model); mo != "" {
 		if newPath, ok := replaceGeminiModelInPath(m.RequestURLPath, mo); ok {
 			m.RequestURLPath = newPath
@@ -123,6 +127,11 @@ func (c *Client) buildProxyCtx(gc *gin.Context, provider string, key ProviderKey
 		return nil, fmt.Errorf("dsl provider no match (provider=%s api=%s stream=%v)", provider, api, stream)
 	}
 
+	bodyBytes, err = applyRequestGuard(gc, pf, m, api, bodyBytes, root)
+	if err != nil {
+		return nil, err
+	}
+
 	respDir, _ := pf.Response.Select(m)
 
 	reqTransform, hasReqTransform := selectRequestTransform(pf, m)
@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestE2EMock_RequestExpressionVariables(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got atomic.Value
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Clone(r.Context()))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"x","choices":[]}`))
+	}))
+	t.Cleanup(mock.Close)
+
+	conf := fmt.Sprintf(`syntax "next-router/0.1";
+
+provider "exprs" {
+  defaults {
+    upstream_config {
+      base_url = %q;
+    }
+    auth {
+      auth_bearer;
+    }
+    request {
+      set_header "x-user" $request.header.x-user-id;
+      set_header "x-caller" coalesce($request.access_key, "anonymous");
+      set_header "x-tier" if(eq($request.body.metadata.tier, "2"), "gold", "basic");
+    }
+  }
+
+  match api = "chat.completions" {
+    upstream {
+      set_path "/v1/chat/completions";
+      set_query "tenant" lower($request.body.metadata.tenant);
+    }
+  }
+}
+`, mock.URL)
+	c := newMockE2EClient(t, map[string]string{"exprs.conf": conf})
+
+	body := `{"model":"m1","metadata":{"tenant":"ACME","tier":2},"messages":[{"role":"user","content":"hi"}]}`
+	gc, _ := newGinJSONRequest(t, []byte(body))
+	gc.Request.Header.Set("X-User-Id", "u-42")
+	gc.Set("onr.access_key", "team-a")
+	if _, err := c.ProxyJSON(gc, "exprs", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false); err != nil {
+		t.Fatalf("proxy error: %v", err)
+	}
+	req, _ := got.Load().(*http.Request)
+	if req == nil {
+		t.Fatalf("upstream was not called")
+	}
+	for header, want := range map[string]string{"X-User": "u-42", "X-Caller": "team-a", "X-Tier": "gold"} {
+		if v := req.Header.Get(header); v != want {
+			t.Fatalf("upstream %s=%q want %q", header, v, want)
+		}
+	}
+	if v := req.URL.Query().Get("tenant"); v != "acme" {
+		t.Fatalf("upstream tenant query=%q want acme", v)
+	}
+}
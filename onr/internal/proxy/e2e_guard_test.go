@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
+)
+
+func providerConfWithGuard(baseURL string) string {
+	return fmt.Sprintf(`syntax "next-router/0.1";
+
+provider "guarded" {
+  defaults {
+    upstream_config {
+      base_url = %q;
+    }
+    auth {
+      auth_bearer;
+    }
+    guard {
+      guard_builtin email action=redact on=both;
+      guard_builtin api_key action=reject;
+    }
+  }
+
+  match api = "chat.completions" {
+    guard {
+      guard_keywords codename "Project Falcon" action=log on=both;
+    }
+    upstream {
+      set_path "/v1/chat/completions";
+    }
+  }
+}
+`, baseURL)
+}
+
+func newGuardE2E(t *testing.T, respBody string, contentType string) (*Client, *atomic.Int64, *atomic.Value) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var upstreamCalls atomic.Int64
+	var lastBody atomic.Value
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		b, _ := io.ReadAll(r.Body)
+		lastBody.Store(string(b))
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(respBody))
+	}))
+	t.Cleanup(mock.Close)
+
+	c := newMockE2EClient(t, map[string]string{
+		"guarded.conf": providerConfWithGuard(mock.URL),
+	})
+	return c, &upstreamCalls, &lastBody
+}
+
+func TestE2EMock_Guard_RejectSkipsUpstream(t *testing.T) {
+	c, upstreamCalls, _ := newGuardE2E(t, `{}`, "application/json")
+
+	body := `{"model":"m1","messages":[{"role":"user","content":"my key is sk-abcdefghijklmnopqrstuvwxyz0123"}]}`
+	gc, _ := newGinJSONRequest(t, []byte(body))
+	_, err := c.ProxyJSON(gc, "guarded", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false)
+	var gerr *contentguard.RejectError
+	if !errors.As(err, &gerr) || gerr.Rule != "api_key" {
+		t.Fatalf("expected api_key RejectError, got %T: %v", err, err)
+	}
+	if n := upstreamCalls.Load(); n != 0 {
+		t.Fatalf("mock upstream received %d requests, expected 0", n)
+	}
+	if got := gc.GetString(ctxKeyGuardHits); got != "request:api_key=reject" {
+		t.Fatalf("unexpected guard hits: %q", got)
+	}
+}
+
+func TestE2EMock_Guard_RedactsRequestAndResponse(t *testing.T) {
+	resp := `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","content":"mail bob@example.com about Project Falcon"}}]}`
+	c, _, lastBody := newGuardE2E(t, resp, "application/json")
+
+	body := `{"model":"m1","messages":[{"role":"user","content":"contact alice@example.com"}]}`
+	gc, rec := newGinJSONRequest(t, []byte(body))
+	if _, err := c.ProxyJSON(gc, "guarded", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false); err != nil {
+		t.Fatalf("proxy error: %v", err)
+	}
+	upstream, _ := lastBody.Load().(string)
+	if strings.Contains(upstream, "alice@example.com") || !strings.Contains(upstream, "[REDACTED:email]") {
+		t.Fatalf("expected redacted upstream body, got %s", upstream)
+	}
+	out := rec.Body.String()
+	if strings.Contains(out, "bob@example.com") || !strings.Contains(out, "[REDACTED:email]") {
+		t.Fatalf("expected redacted downstream body, got %s", out)
+	}
+	want := "request:email=redact,response:email=redact,response:codename=log"
+	if got := gc.GetString(ctxKeyGuardHits); got != want {
+		t.Fatalf("guard hits=%q want %q", got, want)
+	}
+}
+
+func TestE2EMock_Guard_StreamIsLogOnly(t *testing.T) {
+	sse := "data: {\"choices\":[{\"delta\":{\"content\":\"reach me at bob@\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"example.com\"}}]}\n\n" +
+		"data: [DONE]\n\n"
+	c, _, _ := newGuardE2E(t, sse, "text/event-stream")
+
+	body := `{"model":"m1","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	gc, rec := newGinJSONRequest(t, []byte(body))
+	if _, err := c.ProxyJSON(gc, "guarded", ProviderKey{Name: "k", Value: "v"}, "chat.completions", true); err != nil {
+		t.Fatalf("proxy error: %v", err)
+	}
+	if !strings.Contains(rec.Body.String(), "bob@") {
+		t.Fatalf("stream bytes must pass through unchanged: %s", rec.Body.String())
+	}
+	if got := gc.GetString(ctxKeyGuardHits); got != "response:email=log" {
+		t.Fatalf("unexpected guard hits: %q", got)
+	}
+}
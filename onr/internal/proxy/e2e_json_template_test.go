@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/requesttransform"
+)
+
+func providerConfWithTemplates(baseURL string) string {
+	return fmt.Sprintf(`syntax "next-router/0.1";
+
+provider "inhouse" {
+  defaults {
+    upstream_config {
+      base_url = %q;
+    }
+    auth {
+      auth_bearer;
+    }
+  }
+
+  match api = "chat.completions" stream = false {
+    request {
+      req_template {
+        "engine": {"$expr": "$request.model_mapped"},
+        "prompt": {"$path": "$.messages[0].content", "$required": true},
+        "history": {"$for": "$.messages", "$each": {"speaker": "@.role", "text": "@.content"}},
+        "params": {"temperature": {"$path": "$.temperature", "$default": 0.2}}
+      }
+    }
+    upstream {
+      set_path "/generate";
+    }
+    response {
+      resp_template {
+        "object": "chat.completion",
+        "model": {"$expr": "$request.model"},
+        "choices": [{
+          "index": 0,
+          "message": {"role": "assistant", "content": "$.result.text"},
+          "finish_reason": {"$path": "$.result.stop_reason", "$default": "stop"}
+        }],
+        "usage": {
+          "prompt_tokens": {"$path": "$.meta.input_tokens", "$as": "integer"},
+          "completion_tokens": {"$path": "$.meta.output_tokens", "$as": "integer"}
+        }
+      }
+    }
+  }
+}
+`, baseURL)
+}
+
+func newTemplateE2E(t *testing.T) (*Client, *atomic.Int64, *atomic.Value) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var upstreamCalls atomic.Int64
+	var lastBody atomic.Value
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		b, _ := io.ReadAll(r.Body)
+		lastBody.Store(string(b))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result":{"text":"pong"},"meta":{"input_tokens":"3","output_tokens":1}}`))
+	}))
+	t.Cleanup(mock.Close)
+
+	c := newMockE2EClient(t, map[string]string{
+		"inhouse.conf": providerConfWithTemplates(mock.URL),
+	})
+	return c, &upstreamCalls, &lastBody
+}
+
+func TestE2EMock_JSONTemplate_MapsRequestAndResponse(t *testing.T) {
+	c, _, lastBody := newTemplateE2E(t)
+
+	body := `{"model":"m1","messages":[{"role":"user","content":"ping"}]}`
+	gc, rec := newGinJSONRequest(t, []byte(body))
+	if _, err := c.ProxyJSON(gc, "inhouse", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false); err != nil {
+		t.Fatalf("proxy error: %v", err)
+	}
+	wantReq := `{"engine":"m1","history":[{"speaker":"user","text":"ping"}],"params":{"temperature":0.2},"prompt":"ping"}`
+	if got, _ := lastBody.Load().(string); got != wantReq {
+		t.Fatalf("upstream body=%s\nwant          %s", got, wantReq)
+	}
+	wantResp := `{"choices":[{"finish_reason":"stop","index":0,"message":{"content":"pong","role":"assistant"}}],"model":"m1","object":"chat.completion","usage":{"completion_tokens":1,"prompt_tokens":3}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != wantResp {
+		t.Fatalf("downstream body=%s\nwant            %s", got, wantResp)
+	}
+}
+
+func TestE2EMock_JSONTemplate_RequiredMissingIsValidationError(t *testing.T) {
+	c, upstreamCalls, _ := newTemplateE2E(t)
+
+	gc, _ := newGinJSONRequest(t, []byte(`{"model":"m1","messages":[]}`))
+	_, err := c.ProxyJSON(gc, "inhouse", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false)
+	var verr *requesttransform.ValidationError
+	if !errors.As(err, &verr) || !strings.Contains(verr.Message, "$.prompt") {
+		t.Fatalf("expected ValidationError for $.prompt, got %T: %v", err, err)
+	}
+	if n := upstreamCalls.Load(); n != 0 {
+		t.Fatalf("mock upstream received %d requests, expected 0", n)
+	}
+}
@@ -1306,6 +1306,10 @@ entries:
 	if got, want := asString(res.Cost["cost_model"]), "gpt-4o-mini-tts"; got != want {
 		t.Fatalf("cost_model=%q want=%q", got, want)
 	}
+	items, ok := res.Cost["cost_line_items"].(pricing.CostLineItems)
+	if !ok || len(items) != 1 || items[0].Name != "audio_tts_seconds" {
+		t.Fatalf("cost_line_items=%#v", res.Cost["cost_line_items"])
+	}
 }
 
 func TestE2EMock_AudioSpeech_OpenAI_RealDerivedUsageWithOverrideOnlyPricing(t *testing.T) {
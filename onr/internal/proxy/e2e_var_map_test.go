@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func providerConfWithMaps(baseURL string) string {
+	return fmt.Sprintf(`syntax "next-router/0.1";
+
+provider "azure" {
+  map $request.model $deployment {
+    "gpt-4o" "prod-4o";
+    ~*"^O1" "reasoning";
+    default "general";
+  }
+  map $deployment $tier {
+    "reasoning" "high";
+    default "standard";
+  }
+
+  defaults {
+    upstream_config {
+      base_url = %q;
+    }
+    auth {
+      auth_header_key "api-key";
+    }
+    request {
+      set_header "x-tier" $tier;
+      json_set "$.metadata.deployment" $deployment;
+    }
+  }
+
+  match api = "chat.completions" {
+    upstream {
+      set_path template("/openai/deployments/${deployment}/chat/completions");
+    }
+  }
+}
+`, baseURL)
+}
+
+func TestE2EMock_VarMap_DrivesPathHeaderAndBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var lastPath, lastTier, lastBody atomic.Value
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		lastPath.Store(r.URL.Path)
+		lastTier.Store(r.Header.Get("x-tier"))
+		lastBody.Store(string(b))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"x","choices":[]}`))
+	}))
+	t.Cleanup(mock.Close)
+
+	c := newMockE2EClient(t, map[string]string{
+		"azure.conf": providerConfWithMaps(mock.URL),
+	})
+
+	cases := []struct {
+		model string
+		path  string
+		tier  string
+		body  string
+	}{
+		{model: "gpt-4o", path: "/openai/deployments/prod-4o/chat/completions", tier: "standard", body: `"deployment":"prod-4o"`},
+		{model: "o1-mini", path: "/openai/deployments/reasoning/chat/completions", tier: "high", body: `"deployment":"reasoning"`},
+		{model: "other", path: "/openai/deployments/general/chat/completions", tier: "standard", body: `"deployment":"general"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.model, func(t *testing.T) {
+			body := fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":"hi"}]}`, tc.model)
+			gc, _ := newGinJSONRequest(t, []byte(body))
+			if _, err := c.ProxyJSON(gc, "azure", ProviderKey{Name: "k", Value: "v"}, "chat.completions", false); err != nil {
+				t.Fatalf("proxy error: %v", err)
+			}
+			if got, _ := lastPath.Load().(string); got != tc.path {
+				t.Fatalf("upstream path=%q want %q", got, tc.path)
+			}
+			if got, _ := lastTier.Load().(string); got != tc.tier {
+				t.Fatalf("x-tier=%q want %q", got, tc.tier)
+			}
+			if got, _ := lastBody.Load().(string); !strings.Contains(got, tc.body) {
+				t.Fatalf("upstream body=%s want %s", got, tc.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+)
+
+type explainCtxKey struct{}
+
+// WithExplain marks a request context as a dry run: ProxyJSON resolves the upstream
+// call and writes an Explanation instead of sending anything upstream.
+func WithExplain(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explainCtxKey{}, true)
+}
+
+// IsExplain reports whether ctx was marked by WithExplain.
+func IsExplain(ctx context.Context) bool {
+	v, _ := ctx.Value(explainCtxKey{}).(bool)
+	return v
+}
+
+// Explanation describes the upstream call ONR would make for one request.
+type Explanation struct {
+	Provider       string             `json:"provider"`
+	ProviderSource string             `json:"provider_source,omitempty"`
+	ProviderFile   string             `json:"provider_file,omitempty"`
+	Key            string             `json:"key"`
+	API            string             `json:"api"`
+	Stream         bool               `json:"stream"`
+	Model          string             `json:"model,omitempty"`
+	MappedModel    string             `json:"mapped_model,omitempty"`
+	Match          *ExplainMatch      `json:"match,omitempty"`
+	Upstream       ExplainUpstream    `json:"upstream"`
+	Directives     []ExplainDirective `json:"directives"`
+	Notes          []string           `json:"notes,omitempty"`
+}
+
+// ExplainMatch identifies the selected match block (index in provider file order).
+type ExplainMatch struct {
+	Index  int    `json:"index"`
+	API    string `json:"api,omitempty"`
+	Stream *bool  `json:"stream,omitempty"`
+}
+
+// ExplainUpstream is the resolved upstream request. Credential-like header values and
+// query parameters are masked.
+type ExplainUpstream struct {
+	Method    string              `json:"method"`
+	URL       string              `json:"url"`
+	Transport string              `json:"transport,omitempty"`
+	Headers   map[string][]string `json:"headers"`
+	Body      json.RawMessage     `json:"body,omitempty"`
+	BodyText  string              `json:"body_text,omitempty"`
+}
+
+// ExplainDirective is one effective DSL directive. Source is "defaults", "match[N]"
+// or "key" (base_url overridden by the upstream key).
+type ExplainDirective struct {
+	Phase     string `json:"phase"`
+	Directive string `json:"directive"`
+	Args      string `json:"args,omitempty"`
+	Source    string `json:"source,omitempty"`
+}
+
+// writeExplanation requires a non-nil proxy context from buildProxyCtx.
+func (c *Client) writeExplanation(gc *gin.Context, bctx *proxyCtx) (*Result, error) {
+	ex, err := c.explain(gc, bctx)
+	if err != nil {
+		return nil, err
+	}
+	gc.JSON(http.StatusOK, ex)
+	return &Result{
+		Provider:       bctx.provider,
+		ProviderKey:    bctx.key.Name,
+		ProviderSource: "dsl",
+		API:            bctx.api,
+		Stream:         bctx.stream,
+		Model:          bctx.model,
+		Status:         http.StatusOK,
+	}, nil
+}
+
+func (c *Client) explain(gc *gin.Context, bctx *proxyCtx) (*Explanation, error) {
+	pf := bctx.pf
+	m := bctx.meta
+	ex := &Explanation{
+		Provider:     bctx.provider,
+		ProviderFile: pf.Path,
+		Key:          bctx.key.Name,
+		API:          bctx.api,
+		Stream:       bctx.stream,
+		Model:        bctx.model,
+		Directives:   []ExplainDirective{},
+	}
+	if src, ok := gc.Get("onr.provider_source"); ok {
+		ex.ProviderSource = fmt.Sprintf("%v", src)
+	}
+	if mapped := strings.TrimSpace(m.DSLModelMapped); mapped != "" && mapped != bctx.model {
+		ex.MappedModel = mapped
+	}
+	idx, hasMatch := pf.Routing.MatchIndex(m)
+	if hasMatch {
+		rm := pf.Routing.Matches[idx]
+		ex.Match = &ExplainMatch{Index: idx, API: rm.API, Stream: rm.Stream}
+	}
+
+	header := http.Header{}
+	if ct := strings.TrimSpace(gc.Request.Header.Get("Content-Type")); ct != "" {
+		header.Set("Content-Type", ct)
+	} else {
+		header.Set("Content-Type", "application/json")
+	}
+	if phase, ok := pf.Headers.Effective(m); ok {
+		if resolved, ok := phase.OAuth.Resolve(m); ok {
+			m.OAuthAccessToken = "<oauth-access-token>"
+			ex.Notes = append(ex.Notes, fmt.Sprintf("oauth %s token is not fetched in explain mode", resolved.Mode))
+		}
+	}
+	pf.Headers.Apply(m, gc.Request.Header, header)
+
+	ex.Upstream = ExplainUpstream{
+		Method:    gc.Request.Method,
+		URL:       trafficdump.MaskURL(m.BaseURL + m.RequestURLPath),
+		Transport: m.UpstreamTransport,
+		Headers:   maskExplainHeaders(header),
+	}
+	if strings.EqualFold(strings.TrimSpace(m.UpstreamTransport), "aws_sdk") {
+		ex.Notes = append(ex.Notes, "aws_sdk transport: the AWS SDK signs and sends the request; url and headers are approximate")
+	}
+	if body := bytes.TrimSpace(bctx.reqBody); len(body) > 0 {
+		if json.Valid(body) {
+			ex.Upstream.Body = json.RawMessage(body)
+		} else if trafficdump.IsBinaryPayload(header.Get("Content-Type"), body) {
+			ex.Upstream.BodyText = fmt.Sprintf("<%d bytes binary>", len(bctx.reqBody))
+		} else {
+			ex.Upstream.BodyText = string(bctx.reqBody)
+		}
+	}
+	ex.Directives = explainDirectives(pf, m, bctx.key, idx, hasMatch)
+	return ex, nil
+}
+
+func maskExplainHeaders(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for k, vs := range h {
+		masked := make([]string, 0, len(vs))
+		for _, v := range vs {
+			masked = append(masked, trafficdump.MaskHeaderValue(k, v))
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+// explainDirectives lists the effective directives in pipeline order: routing,
+// guard, request transform, headers, response and metrics.
+func explainDirectives(pf dslconfig.ProviderFile, m *dslmeta.Meta, key ProviderKey, idx int, hasMatch bool) []ExplainDirective {
+	var out []ExplainDirective
+	add := func(phase, directive, args, source string) {
+		out = append(out, ExplainDirective{Phase: phase, Directive: directive, Args: args, Source: source})
+	}
+	matchSrc := fmt.Sprintf("match[%d]", idx)
+
+	if strings.TrimSpace(key.BaseURLOverride) != "" {
+		add("upstream_config", "base_url", normalizeUpstreamBaseURL(key.BaseURLOverride), "key")
+	} else if v := strings.TrimSpace(pf.Routing.BaseURLExpr); v != "" {
+		add("upstream_config", "base_url", v, "defaults")
+	}
+	if v := strings.TrimSpace(pf.Routing.Transport); v != "" {
+		add("upstream_config", "transport", v, "defaults")
+	}
+	if hasMatch {
+		rm := pf.Routing.Matches[idx]
+		if rm.SetPath != "" {
+			add("upstream", "set_path", rm.SetPath, matchSrc)
+		}
+		for _, k := range sortedStringKeys(rm.QueryPairs) {
+			add("upstream", "set_query", k+" "+rm.QueryPairs[k], matchSrc)
+		}
+		for _, k := range rm.QueryDels {
+			add("upstream", "del_query", k, matchSrc)
+		}
+	}
+	explainTimeouts := func(t dslconfig.UpstreamTimeouts, source string) {
+		for _, d := range []struct {
+			name string
+			ms   int
+		}{
+			{"timeout_ms", t.TimeoutMs},
+			{"connect_timeout_ms", t.ConnectTimeoutMs},
+			{"first_byte_timeout_ms", t.FirstByteTimeoutMs},
+			{"stream_idle_timeout_ms", t.StreamIdleTimeoutMs},
+		} {
+			if d.ms > 0 {
+				add("upstream", d.name, strconv.Itoa(d.ms), source)
+			}
+		}
+	}
+	explainTimeouts(pf.Routing.Timeouts, "defaults")
+	if hasMatch {
+		explainTimeouts(pf.Routing.Matches[idx].Timeouts, matchSrc)
+	}
+
+	if g, ok := pf.Guard.Select(m); ok {
+		for _, r := range g.Rules {
+			add("guard", "guard_"+r.Kind, r.Name+" "+r.Action, "")
+		}
+	}
+
+	explainRequestTransform := func(t dslconfig.RequestTransform, source string) {
+		for _, r := range t.ValidationRules {
+			add("request", "req_"+r.Op, r.Source+" "+firstNonEmpty(r.Path, r.Name), source)
+		}
+		for _, op := range t.JSONOps {
+			add("request", op.Op, explainJSONOpArgs(op), source)
+		}
+		switch mode := strings.TrimSpace(t.ReqMapMode); mode {
+		case "":
+		case dslconfig.ReqMapModeTemplate:
+			add("request", "req_template", "", source)
+		default:
+			add("request", "req_map", mode, source)
+		}
+		for _, op := range t.AfterReqMapJSONOps {
+			add("request", op.Op, explainJSONOpArgs(op), source)
+		}
+	}
+	var matchTransform dslconfig.RequestTransform
+	if hasMatch && idx < len(pf.Request.Matches) {
+		matchTransform = pf.Request.Matches[idx].Transform
+	}
+	if directive, args, source := explainModelMap(pf.Request.Defaults.ModelMap, matchTransform.ModelMap, m.OriginModelName, matchSrc); directive != "" {
+		add("request", directive, args, source)
+	}
+	explainRequestTransform(pf.Request.Defaults, "defaults")
+	if hasMatch {
+		explainRequestTransform(matchTransform, matchSrc)
+	}
+
+	explainHeaders := func(ph dslconfig.PhaseHeaders, source string) {
+		if ph.OAuth.Mode != "" {
+			add("auth", "oauth_mode", ph.OAuth.Mode, source)
+		}
+		for _, op := range ph.Auth {
+			add("auth", op.Op, op.NameExpr, source)
+		}
+		for _, op := range ph.Request {
+			add("request", op.Op, op.NameExpr, source)
+		}
+		if ph.AWSSigV4 {
+			add("auth", "auth_sigv4_bedrock", "", source)
+		}
+	}
+	explainHeaders(pf.Headers.Defaults, "defaults")
+	if hasMatch && idx < len(pf.Headers.Matches) {
+		explainHeaders(pf.Headers.Matches[idx].Headers, matchSrc)
+	}
+
+	if rd, ok := pf.Response.Select(m); ok && rd != nil {
+		if op := strings.TrimSpace(rd.Op); op != "" {
+			switch {
+			case rd.Template != nil:
+				add("response", "resp_template", "", "")
+			default:
+				add("response", op, rd.Mode, "")
+			}
+		}
+		if rd.SSECollectMode != "" {
+			add("response", "sse_collect", rd.SSECollectMode, "")
+		}
+		for _, op := range rd.JSONOps {
+			add("response", op.Op, explainJSONOpArgs(op), "")
+		}
+	}
+	if u, ok := pf.Usage.Select(m); ok && u != nil {
+		add("metrics", "usage_extract", firstNonEmpty(u.SourceMode, u.Mode), "")
+	}
+	if f, ok := pf.Finish.Select(m); ok && f != nil && f.Mode != "" {
+		add("metrics", "finish_reason_extract", f.Mode, "")
+	}
+	return out
+}
+
+// explainModelMap mirrors RequestTransform.Apply: an exact model_map entry wins over
+// model_map_default, and match entries override defaults.
+func explainModelMap(defaults, match dslconfig.ModelMapConfig, model, matchSrc string) (directive, args, source string) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return "", "", ""
+	}
+	if expr, ok := match.Map[model]; ok && strings.TrimSpace(expr) != "" {
+		return "model_map", model + " " + expr, matchSrc
+	}
+	if expr, ok := defaults.Map[model]; ok && strings.TrimSpace(expr) != "" {
+		return "model_map", model + " " + expr, "defaults"
+	}
+	if v := strings.TrimSpace(match.DefaultExpr); v != "" {
+		return "model_map_default", v, matchSrc
+	}
+	if v := strings.TrimSpace(defaults.DefaultExpr); v != "" {
+		return "model_map_default", v, "defaults"
+	}
+	return "", "", ""
+}
+
+func explainJSONOpArgs(op dslconfig.JSONOp) string {
+	parts := make([]string, 0, 3)
+	for _, v := range []string{op.Path, op.FromPath, op.ToPath, op.HeaderName, op.FieldName, op.ValueExpr} {
+		if strings.TrimSpace(v) != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
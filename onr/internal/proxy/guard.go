@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/apitransform"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+)
+
+const ctxKeyGuardHits = "onr.guard_hits"
+
+// applyRequestGuard runs request-scope guard rules against the client request root
+// before any request transform. Redacted bodies replace both the forwarded bytes and the
+// cached request body so retries on another key never resend the original text.
+func applyRequestGuard(gc *gin.Context, pf dslconfig.ProviderFile, m *dslmeta.Meta, api string, bodyBytes []byte, root map[string]any) ([]byte, error) {
+	cfg, ok := pf.Guard.Select(m)
+	if !ok || root == nil {
+		return bodyBytes, nil
+	}
+	hits, changed, err := contentguard.ApplyRequest(cfg, api, root)
+	recordGuardHits(gc, contentguard.ScopeRequest, hits)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return bodyBytes, nil
+	}
+	out, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	m.RequestBody = out
+	m.SetRequestRoot(root)
+	gc.Set("onr.request_body", out)
+	return out, nil
+}
+
+// applyResponseGuard scans the final non-stream downstream body. Only successful JSON
+// responses are inspected; redaction re-encodes the body without Content-Encoding.
+func applyResponseGuard(gc *gin.Context, pf dslconfig.ProviderFile, m *dslmeta.Meta, api string, resp *http.Response, body []byte, outCT string, didTransform bool) ([]byte, string, bool, error) {
+	cfg, ok := pf.Guard.Select(m)
+	if !ok || !contentguard.HasResponseRules(cfg) || resp.StatusCode >= http.StatusBadRequest {
+		return body, outCT, didTransform, nil
+	}
+	if !apitransform.ResponseBodyLooksLikeJSON(outCT, body) {
+		return body, outCT, didTransform, nil
+	}
+	decoded := body
+	if !didTransform {
+		var err error
+		decoded, _, err = apitransform.DecodeResponseBody(body, resp.Header.Get("Content-Encoding"))
+		if err != nil {
+			return nil, "", false, err
+		}
+	}
+	var root map[string]any
+	if err := json.Unmarshal(decoded, &root); err != nil || root == nil {
+		return body, outCT, didTransform, nil
+	}
+	hits, changed := contentguard.ApplyResponse(cfg, api, root)
+	recordGuardHits(gc, contentguard.ScopeResponse, hits)
+	if !changed {
+		return body, outCT, didTransform, nil
+	}
+	out, err := json.Marshal(root)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return out, outCT, true, nil
+}
+
+// newStreamGuard returns nil when the selected guard has no response rules.
+func newStreamGuard(pf dslconfig.ProviderFile, m *dslmeta.Meta, api string) *contentguard.StreamScanner {
+	cfg, ok := pf.Guard.Select(m)
+	if !ok {
+		return nil
+	}
+	return contentguard.NewStreamScanner(cfg, api)
+}
+
+// recordGuardHits appends hits to the access-log field and the traffic dump.
+func recordGuardHits(gc *gin.Context, phase string, hits []contentguard.Hit) {
+	if len(hits) == 0 {
+		return
+	}
+	formatted := contentguard.FormatHits(hits)
+	if prev, ok := gc.Get(ctxKeyGuardHits); ok {
+		if s, _ := prev.(string); s != "" {
+			formatted = s + "," + formatted
+		}
+	}
+	gc.Set(ctxKeyGuardHits, formatted)
+	trafficdump.AppendGuardHits(gc, phase, contentguard.FormatHits(hits))
+}
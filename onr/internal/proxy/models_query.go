@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/modelsquery"
+)
+
+// QueryModels requires a non-nil Client receiver. It lists the upstream model ids
+// visible to key through the provider's DSL models block, using the same OAuth
+// token cache and outbound proxy as proxied requests.
+func (c *Client) QueryModels(ctx context.Context, provider string, key ProviderKey, api string) ([]string, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	pf, ok := c.Registry.GetProvider(provider)
+	if !ok {
+		return nil, fmt.Errorf("provider not found: %s", provider)
+	}
+	m := &dslmeta.Meta{
+		API:                strings.TrimSpace(api),
+		APIKey:             strings.TrimSpace(key.Value),
+		CredentialFile:     strings.TrimSpace(key.CredentialFile),
+		ChannelLocation:    normalizeProviderLocation(key.Location, strings.TrimSpace(key.CredentialFile) != ""),
+		AWSAccessKeyID:     strings.TrimSpace(key.AWSAccessKeyID),
+		AWSSecretAccessKey: strings.TrimSpace(key.AWSSecretAccessKey),
+		AWSSessionToken:    strings.TrimSpace(key.AWSSessionToken),
+		AWSRegion:          normalizeProviderLocation(firstNonEmpty(key.AWSRegion, key.Location), false),
+	}
+	projectID, err := credentialProjectIDFromFile(m.CredentialFile)
+	if err != nil {
+		return nil, err
+	}
+	m.CredentialProjectID = projectID
+	if err := c.prepareOAuthForUpstream(ctx, provider, pf, m); err != nil {
+		return nil, err
+	}
+	hc, err := c.httpClientForProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	res, err := modelsquery.Query(ctx, modelsquery.Params{
+		Provider:   provider,
+		File:       pf,
+		Meta:       m,
+		BaseURL:    normalizeUpstreamBaseURL(key.BaseURLOverride),
+		HTTPClient: hc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.IDs, nil
+}
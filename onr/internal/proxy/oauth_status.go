@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+// OAuthKeyStatus is the OAuth token state of one provider key.
+type OAuthKeyStatus struct {
+	// Requested is false until a request (or balance/models query) fetched a
+	// token for the key since startup.
+	Requested     bool       `json:"requested"`
+	Cached        bool       `json:"cached"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LastRefreshAt *time.Time `json:"last_refresh_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+}
+
+// OAuthStatus requires a non-nil Client receiver. It reports the cached OAuth
+// token of key without contacting the token endpoint. ok is false when the
+// provider does not use OAuth for api.
+func (c *Client) OAuthStatus(provider string, key ProviderKey, api string) (OAuthKeyStatus, bool, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	pf, ok := c.Registry.GetProvider(provider)
+	if !ok {
+		return OAuthKeyStatus{}, false, fmt.Errorf("provider not found: %s", provider)
+	}
+	m := &dslmeta.Meta{
+		API:                strings.TrimSpace(api),
+		APIKey:             strings.TrimSpace(key.Value),
+		CredentialFile:     strings.TrimSpace(key.CredentialFile),
+		ChannelLocation:    normalizeProviderLocation(key.Location, strings.TrimSpace(key.CredentialFile) != ""),
+		AWSAccessKeyID:     strings.TrimSpace(key.AWSAccessKeyID),
+		AWSSecretAccessKey: strings.TrimSpace(key.AWSSecretAccessKey),
+		AWSSessionToken:    strings.TrimSpace(key.AWSSessionToken),
+		AWSRegion:          normalizeProviderLocation(firstNonEmpty(key.AWSRegion, key.Location), false),
+	}
+	projectID, err := credentialProjectIDFromFile(m.CredentialFile)
+	if err != nil {
+		return OAuthKeyStatus{}, false, err
+	}
+	m.CredentialProjectID = projectID
+	phase, ok := pf.Headers.Effective(m)
+	if !ok {
+		return OAuthKeyStatus{}, false, nil
+	}
+	resolved, ok := phase.OAuth.Resolve(m)
+	if !ok {
+		return OAuthKeyStatus{}, false, nil
+	}
+	st, requested := c.oauthTokenClient().Status(buildOAuthCacheKey(provider, resolved.CacheIdentity(), m.APIKey))
+	if !requested {
+		return OAuthKeyStatus{}, true, nil
+	}
+	return OAuthKeyStatus{
+		Requested:     true,
+		Cached:        st.Cached,
+		ExpiresAt:     timePtr(st.ExpiresAt),
+		LastRefreshAt: timePtr(st.LastRefreshAt),
+		LastError:     st.LastError,
+		LastErrorAt:   timePtr(st.LastErrorAt),
+	}, true, nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -63,6 +63,18 @@ func TestProxyOAuth_CustomMode_Cache(t *testing.T) {
 	if got := upstreamCalls.Load(); got != 2 {
 		t.Fatalf("upstream calls=%d want=2", got)
 	}
+
+	st, ok, err := c.OAuthStatus("openai", ProviderKey{Name: "oauth-key", Value: "rk"}, "chat.completions")
+	if err != nil || !ok {
+		t.Fatalf("OAuthStatus ok=%v err=%v", ok, err)
+	}
+	if !st.Requested || !st.Cached || st.ExpiresAt == nil || st.LastError != "" {
+		t.Fatalf("status=%+v", st)
+	}
+	st, ok, _ = c.OAuthStatus("openai", ProviderKey{Name: "other", Value: "rk2"}, "chat.completions")
+	if !ok || st.Requested {
+		t.Fatalf("unrequested key status=%+v ok=%v", st, ok)
+	}
 }
 
 func TestProxyOAuth_401RetryInvalidate(t *testing.T) {
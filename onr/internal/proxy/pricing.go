@@ -55,25 +55,49 @@ func (c *Client) computeCost(
 	if model == "" {
 		model = strings.TrimSpace(meta.OriginModelName)
 	}
-	out, ok := resolver.Compute(provider, keyName, model, usage)
+	out, ok := resolver.ComputeWithOptions(provider, keyName, model, usage, pricing.ComputeOptions{
+		ServiceTier: requestServiceTier(meta),
+	})
 	if !ok || out == nil {
 		return nil
 	}
-	return map[string]any{
+	cost := map[string]any{
 		"cost_total":            out.TotalCost,
 		"cost_input":            out.InputCost,
 		"cost_output":           out.OutputCost,
+		"cost_reasoning":        out.ReasoningCost,
 		"cost_cache_read":       out.CacheReadCost,
 		"cost_cache_write":      out.CacheWriteCost,
+		"cost_modality":         out.ModalityCost,
+		"cost_extra":            out.ExtraCost,
 		"billable_input_tokens": out.BillableInputTokens,
 		"cost_multiplier":       out.Multiplier,
 		"cost_model":            out.Model,
 		"cost_channel":          out.Channel,
 		"cost_unit":             out.Unit,
 		"cost_rate_unit":        out.RateUnit,
+		"cost_line_items":       out.LineItems,
 		"price_input":           out.InputRate,
 		"price_output":          out.OutputRate,
+		"price_reasoning":       out.ReasoningRate,
 		"price_cache_read":      out.CacheReadRate,
 		"price_cache_write":     out.CacheWriteRate,
 	}
+	if out.Tier > 0 {
+		cost["cost_tier"] = out.Tier
+	}
+	if out.ServiceTier != "" {
+		cost["cost_service_tier"] = out.ServiceTier
+	}
+	return cost
+}
+
+// requestServiceTier returns the OpenAI-style `service_tier` of the request
+// body (e.g. "flex"), used to pick a price discount.
+func requestServiceTier(meta *dslmeta.Meta) string {
+	if meta == nil {
+		return ""
+	}
+	tier, _ := meta.RequestRoot()["service_tier"].(string)
+	return strings.TrimSpace(tier)
 }
@@ -1,6 +1,10 @@
 package proxy
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
 
 func (c *Client) ProxyJSON(
 	gc *gin.Context,
@@ -13,6 +17,9 @@ func (c *Client) ProxyJSON(
 	if err != nil {
 		return nil, err
 	}
+	if IsExplain(gc.Request.Context()) {
+		return c.writeExplanation(gc, bctx)
+	}
 	start := bctx.start
 	pf := bctx.pf
 	m := bctx.meta
@@ -22,6 +29,10 @@ func (c *Client) ProxyJSON(
 
 	resp, cancelUpstream, err := c.doUpstreamRequest(gc, provider, &pf, m, reqBody)
 	if err != nil {
+		var terr *UpstreamTimeoutError
+		if errors.As(err, &terr) {
+			c.logUpstreamTimeout(gc, provider, api, model, terr)
+		}
 		return nil, err
 	}
 	defer cancelUpstream()
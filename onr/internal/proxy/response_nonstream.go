@@ -46,7 +46,7 @@ func (c *Client) handleNonStreamResponse(
 		trafficdump.AppendUpstreamResponse(gc, resp.Status, resp.Header, limited, binary, truncated)
 	}
 
-	respOutBody, respOutObj, outCT, didTransform, err := mapNonStreamResponse(gc.Request.Context(), respBody, resp, respDir)
+	respOutBody, respOutObj, outCT, didTransform, err := mapNonStreamResponse(gc.Request.Context(), m, respBody, resp, respDir)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +68,7 @@ func (c *Client) handleNonStreamResponse(
 	usageStage := ""
 	var upstreamUsage *dslconfig.Usage
 	if estimateEnabled {
-		usage, usageStage, upstreamUsage = estimateNonStreamUsage(c.UsageEst, pf, m, api, model, reqBody, metricsBody)
+		usage, usageStage, upstreamUsage = c.estimateNonStreamUsage(pf, m, api, model, reqBody, metricsBody)
 	}
 	finishReason := ""
 	if estimateEnabled {
@@ -88,6 +88,10 @@ func (c *Client) handleNonStreamResponse(
 	if err != nil {
 		return nil, err
 	}
+	respOutBody, outCT, didTransform, err = applyResponseGuard(gc, pf, m, api, resp, respOutBody, outCT, didTransform)
+	if err != nil {
+		return nil, err
+	}
 
 	copyHeadersToClient(gc, resp.Header, didTransform)
 	if strings.TrimSpace(outCT) != "" {
@@ -124,7 +128,7 @@ func (c *Client) handleNonStreamResponse(
 }
 
 // mapNonStreamResponse requires a non-nil upstream response from the non-stream proxy path.
-func mapNonStreamResponse(ctx context.Context, respBody []byte, resp *http.Response, respDir *dslconfig.ResponseDirective) ([]byte, map[string]any, string, bool, error) {
+func mapNonStreamResponse(ctx context.Context, m *dslmeta.Meta, respBody []byte, resp *http.Response, respDir *dslconfig.ResponseDirective) ([]byte, map[string]any, string, bool, error) {
 	respOutBody := respBody
 	outCT := resp.Header.Get("Content-Type")
 	var root map[string]any
@@ -153,7 +157,8 @@ func mapNonStreamResponse(ctx context.Context, respBody []byte, resp *http.Respo
 	if resp.StatusCode >= http.StatusBadRequest {
 		return respOutBody, nil, outCT, false, nil
 	}
-	if !apitransform.SupportsResponseMapMode(respDir.Mode) {
+	isTemplate := respDir.Template != nil && strings.EqualFold(strings.TrimSpace(respDir.Mode), dslconfig.RespMapModeTemplate)
+	if !isTemplate && !apitransform.SupportsResponseMapMode(respDir.Mode) {
 		return respOutBody, nil, outCT, false, nil
 	}
 	if root == nil {
@@ -165,6 +170,13 @@ func mapNonStreamResponse(ctx context.Context, respBody []byte, resp *http.Respo
 			return nil, nil, outCT, false, err
 		}
 	}
+	if isTemplate {
+		outObj, err := respDir.Template.Render(m, root)
+		if err != nil {
+			return nil, nil, outCT, false, fmt.Errorf("resp_template: %w", err)
+		}
+		return nil, outObj, contentTypeJSON, true, nil
+	}
 	outObj, outCT, changed, err := apitransform.TransformNonStreamResponseBody(
 		resp.StatusCode,
 		respDir.Mode,
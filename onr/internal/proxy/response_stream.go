@@ -1,12 +1,14 @@
 package proxy
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/jsonutil"
@@ -49,10 +51,21 @@ func (c *Client) handleStreamResponse(
 	dump := newStreamDumpState(gc)
 	defer dump.Append(gc, resp)
 
-	n, firstWriteAt, err := streamToDownstream(gc, m, respDir, resp, usageTail, metricsTap, tapRawSSEForMetrics, dump)
+	guardTap := newStreamGuard(pf, m, api)
+	idleTimeout := time.Duration(pf.Routing.EffectiveTimeouts(m).StreamIdleTimeoutMs) * time.Millisecond
+	n, firstWriteAt, err := streamToDownstream(gc, m, respDir, resp, usageTail, metricsTap, tapRawSSEForMetrics, dump, guardTap, idleTimeout)
+	if guardTap != nil {
+		recordGuardHits(gc, contentguard.ScopeResponse, guardTap.Hits())
+	}
 	ignoredDisconnect := isClientDisconnectErr(err)
 	dump.SetStreamResult(n, err, ignoredDisconnect)
-	if err != nil && !ignoredDisconnect {
+	// The client already has the status and part of the stream: an idle timeout ends the
+	// stream and is reported through finish_reason instead of an error response.
+	var idleErr *UpstreamTimeoutError
+	idleTimedOut := errors.As(err, &idleErr) && idleErr.Kind == UpstreamTimeoutStreamIdle
+	if idleTimedOut {
+		c.logUpstreamTimeout(gc, provider, api, model, idleErr)
+	} else if err != nil && !ignoredDisconnect {
 		return nil, err
 	}
 	if f, ok := gc.Writer.(http.Flusher); ok {
@@ -79,7 +92,7 @@ func (c *Client) handleStreamResponse(
 	usageStage := ""
 	cost := map[string]any(nil)
 	if estimateEnabled {
-		out := usageestimate.Estimate(c.UsageEst, usageestimate.Input{
+		out := c.estimateUsage(usageestimate.Input{
 			API:           api,
 			Model:         model,
 			UpstreamUsage: upstreamUsage,
@@ -91,6 +104,9 @@ func (c *Client) handleStreamResponse(
 		usageStage = out.Stage
 		cost = c.computeCost(m, provider, key.Name, usage)
 	}
+	if idleTimedOut {
+		finishReason = UpstreamTimeoutStreamIdle
+	}
 	c.logUsageFactsDebug(gc, provider, api, true, model, usageStage, upstreamUsage)
 	ttftMs, tps := streamPerfMetrics(start, firstWriteAt, usage)
 	return &Result{
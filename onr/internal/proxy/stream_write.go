@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/apitransform"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/contentguard"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
@@ -31,6 +32,9 @@ func (w *countingWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+// streamToDownstream copies the upstream stream to the client. A positive idleTimeout
+// (stream_idle_timeout_ms) aborts the copy with *UpstreamTimeoutError when the upstream
+// sends nothing for that long.
 func streamToDownstream(
 	gc *gin.Context,
 	meta *dslmeta.Meta,
@@ -40,7 +44,15 @@ func streamToDownstream(
 	metricsTap *sseMetricsTap,
 	tapRawSSEForMetrics bool,
 	dump *streamDumpState,
+	guardTap *contentguard.StreamScanner,
+	idleTimeout time.Duration,
 ) (int64, time.Time, error) {
+	var idle *idleTimeoutBody
+	if idleTimeout > 0 {
+		idle = newIdleTimeoutBody(resp.Body, idleTimeout)
+		resp.Body = idle
+		defer idle.timer.Stop()
+	}
 	var (
 		needSSEOps bool
 		mode       string
@@ -67,6 +79,9 @@ func streamToDownstream(
 	if proxyDump != nil {
 		dst = io.MultiWriter(dst, proxyDump)
 	}
+	if guardTap != nil {
+		dst = io.MultiWriter(dst, guardTap)
+	}
 	cw := &countingWriter{w: dst}
 
 	var err error
@@ -106,6 +121,10 @@ func streamToDownstream(
 	if metricsTap != nil {
 		metricsTap.Finish()
 	}
+	if idle != nil && idle.TimedOut() {
+		// Transforms may wrap or replace the read error; report the timeout itself.
+		err = idle.err()
+	}
 
 	return cw.n, cw.firstWriteAt, err
 }
@@ -29,7 +29,8 @@ func (c *Client) doUpstreamRequest(gc *gin.Context, provider string, pf *dslconf
 	}
 	upstreamURL := baseURL + m.RequestURLPath
 
-	reqCtx, cancel := context.WithTimeout(gc.Request.Context(), c.WriteTimeout)
+	call := c.newUpstreamCall(gc, pf, m)
+	reqCtx, cancel := call.ctx, call.cancel
 	httpc, err := c.httpClientForProvider(provider)
 	if err != nil {
 		cancel()
@@ -60,7 +61,7 @@ func (c *Client) doUpstreamRequest(gc *gin.Context, provider string, pf *dslconf
 			trafficdump.AppendUpstreamRequest(gc, req.Method, upstreamURL, req.Header, limited, false, truncated)
 		}
 
-		resp, doErr := httpc.Do(req)
+		resp, doErr := call.do(httpc, req)
 		if doErr != nil {
 			cancel()
 			return nil, func() {}, doErr
@@ -157,10 +158,7 @@ func (c *Client) httpClientForProvider(provider string) (*http.Client, error) {
 		return nil, fmt.Errorf("unsupported upstream proxy scheme for provider=%s: %q", provider, u.Scheme)
 	}
 
-	hc := &http.Client{
-		Timeout:   base.Timeout,
-		Transport: rt,
-	}
+	hc := &http.Client{Transport: rt}
 	c.httpByProxy[u.String()] = hc
 	return hc, nil
 }
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+	"github.com/r9s-ai/open-next-router/onr/internal/logx"
+)
+
+// Upstream timeout kinds. They double as the finish_reason logged for the aborted call.
+const (
+	UpstreamTimeoutConnect    = "connect_timeout"
+	UpstreamTimeoutFirstByte  = "first_byte_timeout"
+	UpstreamTimeoutStreamIdle = "stream_idle_timeout"
+)
+
+// UpstreamTimeoutError reports an upstream call aborted by a DSL upstream timeout
+// directive (connect_timeout_ms, first_byte_timeout_ms or stream_idle_timeout_ms).
+type UpstreamTimeoutError struct {
+	Kind    string
+	Timeout time.Duration
+}
+
+func (e *UpstreamTimeoutError) Error() string {
+	return fmt.Sprintf("upstream %s after %s", strings.ReplaceAll(e.Kind, "_", " "), e.Timeout)
+}
+
+// upstreamCall is the context of one upstream call with its effective DSL timeouts.
+type upstreamCall struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	cancelCause context.CancelCauseFunc
+	timeouts    dslconfig.UpstreamTimeouts
+}
+
+// newUpstreamCall bounds the call by the DSL timeout_ms when set, otherwise by the
+// client write timeout. The budget lives only in the call context, so a timeout_ms
+// above write_timeout_ms is honored. pf may be nil.
+func (c *Client) newUpstreamCall(gc *gin.Context, pf *dslconfig.ProviderFile, m *dslmeta.Meta) *upstreamCall {
+	var timeouts dslconfig.UpstreamTimeouts
+	if pf != nil && m != nil {
+		timeouts = pf.Routing.EffectiveTimeouts(m)
+	}
+	total := c.WriteTimeout
+	if timeouts.TimeoutMs > 0 {
+		total = time.Duration(timeouts.TimeoutMs) * time.Millisecond
+	}
+	causeCtx, cancelCause := context.WithCancelCause(gc.Request.Context())
+	ctx, cancel := context.WithTimeout(causeCtx, total)
+	return &upstreamCall{
+		ctx: ctx,
+		cancel: func() {
+			cancel()
+			cancelCause(nil)
+		},
+		cancelCause: cancelCause,
+		timeouts:    timeouts,
+	}
+}
+
+// do sends req, which must be built with uc.ctx, and enforces connect_timeout_ms and
+// first_byte_timeout_ms. An expired phase timeout is returned as *UpstreamTimeoutError.
+// Any http.Client.Timeout is ignored: uc.ctx already carries the whole call budget.
+func (uc *upstreamCall) do(httpc *http.Client, req *http.Request) (*http.Response, error) {
+	if httpc.Timeout > 0 {
+		perCall := *httpc
+		perCall.Timeout = 0
+		httpc = &perCall
+	}
+	connect := time.Duration(uc.timeouts.ConnectTimeoutMs) * time.Millisecond
+	firstByte := time.Duration(uc.timeouts.FirstByteTimeoutMs) * time.Millisecond
+	if connect <= 0 && firstByte <= 0 {
+		return httpc.Do(req)
+	}
+
+	var (
+		mu     sync.Mutex
+		timers = map[string]*time.Timer{}
+	)
+	arm := func(kind string, d time.Duration) {
+		if d <= 0 {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if t := timers[kind]; t != nil {
+			t.Stop()
+		}
+		timers[kind] = time.AfterFunc(d, func() {
+			uc.cancelCause(&UpstreamTimeoutError{Kind: kind, Timeout: d})
+		})
+	}
+	disarm := func(kind string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if t := timers[kind]; t != nil {
+			t.Stop()
+			delete(timers, kind)
+		}
+	}
+	trace := &httptrace.ClientTrace{
+		GetConn:              func(string) { arm(UpstreamTimeoutConnect, connect) },
+		GotConn:              func(httptrace.GotConnInfo) { disarm(UpstreamTimeoutConnect) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { arm(UpstreamTimeoutFirstByte, firstByte) },
+		GotFirstResponseByte: func() { disarm(UpstreamTimeoutFirstByte) },
+	}
+	resp, err := httpc.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	disarm(UpstreamTimeoutConnect)
+	disarm(UpstreamTimeoutFirstByte)
+	if err != nil {
+		var terr *UpstreamTimeoutError
+		if errors.As(context.Cause(uc.ctx), &terr) {
+			return nil, terr
+		}
+	}
+	return resp, err
+}
+
+// idleTimeoutBody closes the wrapped body when no bytes arrive for timeout, which
+// unblocks a pending Read; that Read then reports *UpstreamTimeoutError.
+type idleTimeoutBody struct {
+	rc      io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	fired   atomic.Bool
+}
+
+func newIdleTimeoutBody(rc io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{rc: rc, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.fired.Store(true)
+		_ = rc.Close()
+	})
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if b.fired.Load() {
+		return n, b.err()
+	}
+	if err != nil {
+		b.timer.Stop()
+		return n, err
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, nil
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.rc.Close()
+}
+
+// TimedOut reports whether the idle timeout fired.
+func (b *idleTimeoutBody) TimedOut() bool {
+	return b.fired.Load()
+}
+
+func (b *idleTimeoutBody) err() error {
+	return &UpstreamTimeoutError{Kind: UpstreamTimeoutStreamIdle, Timeout: b.timeout}
+}
+
+// logUpstreamTimeout writes one warning per upstream call aborted by a DSL timeout.
+func (c *Client) logUpstreamTimeout(gc *gin.Context, provider, api, model string, terr *UpstreamTimeoutError) {
+	fields := map[string]any{
+		"provider":   provider,
+		"api":        strings.TrimSpace(api),
+		"model":      strings.TrimSpace(model),
+		"timeout":    terr.Kind,
+		"timeout_ms": terr.Timeout.Milliseconds(),
+	}
+	if rid := strings.TrimSpace(gc.GetString("X-Onr-Request-Id")); rid != "" {
+		fields["request_id"] = rid
+	} else if rid := strings.TrimSpace(gc.GetString("X-Request-Id")); rid != "" {
+		fields["request_id"] = rid
+	}
+	c.SystemLogger.Warn(logx.SystemCategoryServer, "upstream timeout", fields)
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslmeta"
+)
+
+func newTimeoutTestContext(t *testing.T) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(w)
+	gc.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+	return gc, w
+}
+
+func timeoutTestProvider(defaults, match dslconfig.UpstreamTimeouts) dslconfig.ProviderFile {
+	return dslconfig.ProviderFile{
+		Routing: dslconfig.ProviderRouting{
+			Timeouts: defaults,
+			Matches:  []dslconfig.RoutingMatch{{API: "chat.completions", Timeouts: match}},
+		},
+	}
+}
+
+func TestDoUpstreamRequest_FirstByteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	gc, _ := newTimeoutTestContext(t)
+	c := &Client{HTTP: &http.Client{}, WriteTimeout: 10 * time.Second}
+	pf := timeoutTestProvider(dslconfig.UpstreamTimeouts{FirstByteTimeoutMs: 10_000}, dslconfig.UpstreamTimeouts{FirstByteTimeoutMs: 50})
+	meta := &dslmeta.Meta{API: "chat.completions", BaseURL: srv.URL, RequestURLPath: "/"}
+
+	start := time.Now()
+	_, _, err := c.doUpstreamRequest(gc, "openai", &pf, meta, []byte(`{}`))
+	var terr *UpstreamTimeoutError
+	if !errors.As(err, &terr) || terr.Kind != UpstreamTimeoutFirstByte || terr.Timeout != 50*time.Millisecond {
+		t.Fatalf("expected first byte timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("match timeout did not override defaults: %s", elapsed)
+	}
+}
+
+func TestDoUpstreamRequest_TimeoutMsOverridesWriteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	gc, _ := newTimeoutTestContext(t)
+	c := &Client{HTTP: &http.Client{}, WriteTimeout: 10 * time.Second}
+	pf := timeoutTestProvider(dslconfig.UpstreamTimeouts{TimeoutMs: 50}, dslconfig.UpstreamTimeouts{})
+	meta := &dslmeta.Meta{API: "chat.completions", BaseURL: srv.URL, RequestURLPath: "/"}
+
+	_, _, err := c.doUpstreamRequest(gc, "openai", &pf, meta, []byte(`{}`))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestDoUpstreamRequest_TimeoutMsAboveWriteTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	gc, _ := newTimeoutTestContext(t)
+	// A client-wide Timeout equal to the write timeout must not cut off the DSL budget.
+	c := &Client{HTTP: &http.Client{Timeout: 50 * time.Millisecond}, WriteTimeout: 50 * time.Millisecond}
+	pf := timeoutTestProvider(dslconfig.UpstreamTimeouts{TimeoutMs: 2000}, dslconfig.UpstreamTimeouts{})
+	meta := &dslmeta.Meta{API: "chat.completions", BaseURL: srv.URL, RequestURLPath: "/"}
+
+	resp, cancel, err := c.doUpstreamRequest(gc, "openai", &pf, meta, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("doUpstreamRequest: %v", err)
+	}
+	defer cancel()
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != `{"ok":true}` {
+		t.Fatalf("unexpected body %q err=%v", body, err)
+	}
+}
+
+func TestHandleStreamResponse_StreamIdleTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	gc, w := newTimeoutTestContext(t)
+	c := &Client{HTTP: &http.Client{}, WriteTimeout: 10 * time.Second}
+	pf := timeoutTestProvider(dslconfig.UpstreamTimeouts{}, dslconfig.UpstreamTimeouts{StreamIdleTimeoutMs: 50})
+	meta := &dslmeta.Meta{API: "chat.completions", IsStream: true, BaseURL: srv.URL, RequestURLPath: "/"}
+
+	resp, cancel, err := c.doUpstreamRequest(gc, "openai", &pf, meta, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("doUpstreamRequest: %v", err)
+	}
+	defer cancel()
+
+	res, err := c.handleStreamResponse(gc, "openai", ProviderKey{Name: "k"}, "chat.completions", time.Now(), pf, meta, "gpt-4o-mini", nil, nil, resp)
+	if err != nil {
+		t.Fatalf("handleStreamResponse: %v", err)
+	}
+	if res.FinishReason != UpstreamTimeoutStreamIdle || res.Status != http.StatusOK {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if !strings.Contains(w.Body.String(), `"content":"hi"`) {
+		t.Fatalf("first chunk not forwarded: %q", w.Body.String())
+	}
+}
@@ -15,8 +15,20 @@ import (
 	"github.com/r9s-ai/open-next-router/onr/internal/logx"
 )
 
-func estimateNonStreamUsage(
-	estCfg *usageestimate.Config,
+// estimateUsage runs usage estimation with the client's config and warns when
+// an open-weights tokenizer failed to load.
+func (c *Client) estimateUsage(in usageestimate.Input) usageestimate.Output {
+	out := usageestimate.Estimate(c.UsageEst, in)
+	if out.TokenizerErr != nil {
+		c.SystemLogger.Warn(logx.SystemCategoryServer, "usage estimation tokenizer load failed; falling back to closed-source estimation", map[string]any{
+			"model": strings.TrimSpace(in.Model),
+			"error": out.TokenizerErr.Error(),
+		})
+	}
+	return out
+}
+
+func (c *Client) estimateNonStreamUsage(
 	pf dslconfig.ProviderFile,
 	meta *dslmeta.Meta,
 	api string,
@@ -27,7 +39,7 @@ func estimateNonStreamUsage(
 	if cfg, ok := pf.Usage.Select(meta); ok {
 		u, _, err := dslconfig.ExtractUsage(meta, cfg, metricsBody)
 		if err == nil && u != nil {
-			out := usageestimate.Estimate(estCfg, usageestimate.Input{
+			out := c.estimateUsage(usageestimate.Input{
 				API:           api,
 				Model:         model,
 				UpstreamUsage: u,
@@ -38,7 +50,7 @@ func estimateNonStreamUsage(
 			return usageMap(out.Usage), out.Stage, u
 		}
 	}
-	out := usageestimate.Estimate(estCfg, usageestimate.Input{
+	out := c.estimateUsage(usageestimate.Input{
 		API:          api,
 		Model:        model,
 		RequestBody:  reqBody,
@@ -207,9 +219,22 @@ func usageMap(u *dslconfig.Usage) map[string]any {
 		"output_tokens": u.OutputTokens,
 		"total_tokens":  u.TotalTokens,
 	}
-	if u.InputTokenDetails != nil {
-		m["cache_read_tokens"] = u.InputTokenDetails.CachedTokens
-		m["cache_write_tokens"] = u.InputTokenDetails.CacheWriteTokens
+	if d := u.InputTokenDetails; d != nil {
+		if d.CachedTokens != 0 || d.CacheWriteTokens != 0 {
+			m["cache_read_tokens"] = d.CachedTokens
+			m["cache_write_tokens"] = d.CacheWriteTokens
+		}
+		setNonZeroUsage(m, "input_text_tokens", d.TextTokens)
+		setNonZeroUsage(m, "input_audio_tokens", d.AudioTokens)
+		setNonZeroUsage(m, "input_image_tokens", d.ImageTokens)
+		setNonZeroUsage(m, "input_video_tokens", d.VideoTokens)
+	}
+	if d := u.OutputTokenDetails; d != nil {
+		setNonZeroUsage(m, "reasoning_tokens", d.ReasoningTokens)
+		setNonZeroUsage(m, "output_text_tokens", d.TextTokens)
+		setNonZeroUsage(m, "output_audio_tokens", d.AudioTokens)
+		setNonZeroUsage(m, "output_image_tokens", d.ImageTokens)
+		setNonZeroUsage(m, "output_video_tokens", d.VideoTokens)
 	}
 	for k, v := range u.FlatFields {
 		if strings.TrimSpace(k) == "" {
@@ -222,3 +247,9 @@ func usageMap(u *dslconfig.Usage) map[string]any {
 	}
 	return m
 }
+
+func setNonZeroUsage(m map[string]any, key string, v int) {
+	if v != 0 {
+		m[key] = v
+	}
+}
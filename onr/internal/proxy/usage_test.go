@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/dslconfig"
+)
+
+func TestUsageMapIncludesReasoningAndModalityBreakdown(t *testing.T) {
+	got := usageMap(&dslconfig.Usage{
+		InputTokens:  120,
+		OutputTokens: 80,
+		InputTokenDetails: &dslconfig.ResponseTokenDetails{
+			AudioTokens: 30,
+		},
+		OutputTokenDetails: &dslconfig.OutputTokenDetails{
+			ReasoningTokens: 64,
+			AudioTokens:     10,
+		},
+	})
+	want := map[string]any{
+		"input_tokens":        120,
+		"output_tokens":       80,
+		"total_tokens":        200,
+		"input_audio_tokens":  30,
+		"reasoning_tokens":    64,
+		"output_audio_tokens": 10,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("usageMap=%v want=%v", got, want)
+	}
+}
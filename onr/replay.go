@@ -0,0 +1,28 @@
+package onr
+
+import (
+	"net/http"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
+	"github.com/r9s-ai/open-next-router/onr/internal/onrserver"
+)
+
+// ReplayOptions controls Replay; see onrserver.ReplayOptions.
+type ReplayOptions = onrserver.ReplayOptions
+
+// ReplayResult is the client response and traffic dump of a replay.
+type ReplayResult = onrserver.ReplayResult
+
+// Replay re-sends the origin request captured in a traffic dump through the
+// proxy routes in-process, against the config and provider DSL at cfgPath.
+// With opts.FakeUpstream the captured upstream response is served instead of
+// calling the provider.
+func Replay(cfgPath string, dump *trafficdump.Dump, opts ReplayOptions) (*ReplayResult, error) {
+	return onrserver.Replay(cfgPath, dump, opts)
+}
+
+// NewReplayRequest rebuilds the client request captured in dump, e.g. to send
+// it to a running gateway. Masked credentials are dropped.
+func NewReplayRequest(dump *trafficdump.Dump, opts ReplayOptions) (*http.Request, error) {
+	return onrserver.NewReplayRequest(dump, opts)
+}
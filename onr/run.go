@@ -101,6 +101,12 @@ func runConfigTest(cfgPath string) error {
 	return nil
 }
 
+// SendReload asks the onr process whose pid_file is configured in cfgPath to
+// reload its config files, like `onr -s reload`.
+func SendReload(cfgPath string) error {
+	return sendReloadSignal(cfgPath)
+}
+
 func sendReloadSignal(cfgPath string) error {
 	pidFile, err := pidFileFromConfig(cfgPath)
 	if err != nil {
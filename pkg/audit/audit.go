@@ -0,0 +1,207 @@
+// Package audit records configuration changes made by onr-admin and the onr reload path
+// as an append-only JSONL log.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources recorded in Entry.Source.
+const (
+	SourceCLI    = "cli"
+	SourceTUI    = "tui"
+	SourceWeb    = "web"
+	SourceReload = "reload"
+)
+
+// Validation results recorded in Entry.Validation.
+const (
+	ValidationOK     = "ok"
+	ValidationFailed = "failed"
+)
+
+const actorEnv = "ONR_AUDIT_ACTOR"
+
+// Entry is one audit log line.
+type Entry struct {
+	Time       time.Time `json:"ts"`
+	Actor      string    `json:"actor"`
+	Source     string    `json:"source"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	Diff       string    `json:"diff,omitempty"`
+	Validation string    `json:"validation"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Logger appends entries to a JSONL file. A nil Logger discards entries.
+type Logger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogger returns nil when path is empty (audit disabled); otherwise it returns a non-nil logger.
+func NewLogger(path string) *Logger {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil
+	}
+	return &Logger{path: p}
+}
+
+// Path returns the audit file path, or "" for a nil logger.
+func (l *Logger) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Append writes e as one JSON line. Time defaults to now and Actor to DefaultActor.
+func (l *Logger) Append(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	if strings.TrimSpace(e.Actor) == "" {
+		e.Actor = DefaultActor()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return err
+	}
+	// #nosec G304 -- audit path comes from trusted config/env.
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DefaultActor returns ONR_AUDIT_ACTOR, or "<os user>@<hostname>" when it is unset.
+func DefaultActor() string {
+	if v := strings.TrimSpace(os.Getenv(actorEnv)); v != "" {
+		return v
+	}
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		name = strings.TrimSpace(os.Getenv("USER"))
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	if host, err := os.Hostname(); err == nil && strings.TrimSpace(host) != "" {
+		return name + "@" + host
+	}
+	return name
+}
+
+// Filter selects entries in Read. Zero fields match everything.
+type Filter struct {
+	Actor  string
+	Source string
+	Target string
+	// Contains matches a substring of the action, target, diff or error.
+	Contains string
+	Since    time.Time
+	Until    time.Time
+	// Limit keeps only the newest N matches when > 0.
+	Limit int
+}
+
+// Match reports whether e passes the filter. Actor/Target/Contains are case-insensitive substrings.
+func (f Filter) Match(e Entry) bool {
+	if !containsFold(e.Actor, f.Actor) || !containsFold(e.Target, f.Target) {
+		return false
+	}
+	if s := strings.TrimSpace(f.Source); s != "" && !strings.EqualFold(e.Source, s) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	if q := strings.TrimSpace(f.Contains); q != "" {
+		if !containsFold(e.Action, q) && !containsFold(e.Target, q) && !containsFold(e.Diff, q) && !containsFold(e.Error, q) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s, sub string) bool {
+	sub = strings.TrimSpace(sub)
+	if sub == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+// Read returns the entries in path that match f, oldest first.
+// Malformed lines are reported as an error together with their line number.
+func Read(path string, f Filter) ([]Entry, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, errors.New("audit file is empty")
+	}
+	// #nosec G304 -- audit path comes from trusted config/flag.
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var out []Entry
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", p, line, err)
+		}
+		if f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerAppendAndRead(t *testing.T) {
+	t.Setenv("ONR_AUDIT_ACTOR", "alice")
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	l := NewLogger(path)
+	if err := l.Append(Entry{Source: SourceCLI, Action: "write", Target: "keys.yaml", Validation: ValidationOK}); err != nil {
+		t.Fatalf("Append err=%v", err)
+	}
+	if err := l.Append(Entry{Actor: "bob", Source: SourceWeb, Action: "provider.save", Target: "providers/openai.conf", Validation: ValidationFailed, Error: "syntax error"}); err != nil {
+		t.Fatalf("Append err=%v", err)
+	}
+
+	all, err := Read(path, Filter{})
+	if err != nil {
+		t.Fatalf("Read err=%v", err)
+	}
+	if len(all) != 2 || all[0].Actor != "alice" || all[1].Actor != "bob" {
+		t.Fatalf("unexpected entries: %#v", all)
+	}
+	if all[0].Time.IsZero() {
+		t.Fatalf("expected timestamp")
+	}
+
+	got, err := Read(path, Filter{Source: "web", Contains: "SYNTAX"})
+	if err != nil || len(got) != 1 || got[0].Target != "providers/openai.conf" {
+		t.Fatalf("filtered got=%#v err=%v", got, err)
+	}
+	got, _ = Read(path, Filter{Since: time.Now().Add(time.Hour)})
+	if len(got) != 0 {
+		t.Fatalf("expected no entries after since, got %d", len(got))
+	}
+	got, _ = Read(path, Filter{Limit: 1})
+	if len(got) != 1 || got[0].Actor != "bob" {
+		t.Fatalf("expected newest entry, got %#v", got)
+	}
+}
+
+func TestNilLoggerDiscards(t *testing.T) {
+	l := NewLogger("  ")
+	if l != nil {
+		t.Fatalf("expected nil logger")
+	}
+	if err := l.Append(Entry{Action: "x"}); err != nil {
+		t.Fatalf("nil Append err=%v", err)
+	}
+}
+
+func TestDiffMasksSecrets(t *testing.T) {
+	before := "providers:\n  openai:\n    keys:\n      - name: main\n        value: sk-old-secret-123456\n"
+	after := "providers:\n  openai:\n    keys:\n      - name: main\n        value: sk-new-secret-123456\n      - name: enc\n        value: ENC[v2:aesgcm:abcd1234:AAAA]\n"
+	d := Diff("keys.yaml", before, after)
+	if strings.Contains(d, "old-secret") || strings.Contains(d, "new-secret") {
+		t.Fatalf("diff leaks secret:\n%s", d)
+	}
+	for _, want := range []string{"--- a/keys.yaml", "+++ b/keys.yaml", "@@ -2,4 +2,6 @@", "-        value: ***", "+        value: ***", "+        value: ENC[v2:aesgcm:abcd1234:AAAA]"} {
+		if !strings.Contains(d, want) {
+			t.Fatalf("diff missing %q:\n%s", want, d)
+		}
+	}
+	if Diff("x", "same", "same") != "" {
+		t.Fatalf("expected empty diff for equal content")
+	}
+}
+
+func TestDiffSeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 30; i++ {
+		a = append(a, "line")
+		b = append(b, "line")
+	}
+	a[2], b[2] = "x", "y"
+	a[25], b[25] = "p", "q"
+	d := Diff("f", strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if strings.Count(d, "@@ -") != 2 {
+		t.Fatalf("expected two hunks:\n%s", d)
+	}
+	if !strings.Contains(d, "@@ -1,6 +1,6 @@") || !strings.Contains(d, "@@ -23,7 +23,7 @@") {
+		t.Fatalf("unexpected hunk ranges:\n%s", d)
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	cases := map[string]string{
+		`    api_key: "abc"`:                               `    api_key: "***"`,
+		`  value: ref+vault://kv/a#k?token=x`:              `  value: "ref+vault://kv/a#k?..."`,
+		`  value: ${OPENAI_KEY}`:                           `  value: ${OPENAI_KEY}`,
+		`set_header Authorization "Bearer abcdefghijk";`:   `set_header Authorization "Bearer ***";`,
+		`# sk-proj-abcdefghijkl`:                           `# sk-***`,
+		`name: main`:                                       `name: main`,
+		`    aws_secret_access_key: wJalrXUtnFEMI/K7MDENG`: `    aws_secret_access_key: ***`,
+		`    aws_session_token: "FwoGZXIvYXdzEBYaDH"`:      `    aws_session_token: "***"`,
+		`    credential_file: /etc/onr/sa.json`:            `    credential_file: ***`,
+		`  max_tokens: 4096`:                               `  max_tokens: 4096`,
+	}
+	for in, want := range cases {
+		if got := MaskSecrets(in); got != want {
+			t.Fatalf("MaskSecrets(%q)=%q want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	diffContextLines = 3
+	// maxDiffCells bounds the LCS table; larger inputs fall back to a whole-file hunk.
+	maxDiffCells = 4_000_000
+	maskedValue  = "***"
+)
+
+var (
+	fieldPattern       = regexp.MustCompile(`^(\s*(?:-\s+)?"?([A-Za-z0-9_.\-]+)"?\s*[:=]\s*)(.+?)\s*$`)
+	secretTokenPattern = regexp.MustCompile(`\b(sk|pk|rk)([-_])[A-Za-z0-9_\-]{8,}`)
+	bearerPattern      = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=\-]{8,}`)
+)
+
+// Diff returns a unified diff between before and after with secrets masked.
+// It returns "" when the contents are equal.
+func Diff(target, before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := splitLines(before)
+	b := splitLines(after)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	name := strings.TrimPrefix(strings.TrimSpace(target), "/")
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	for _, h := range buildHunks(ops) {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, op := range ops[h.from:h.to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(MaskSecrets(op.line))
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// MaskSecrets masks secret-looking values in one line of YAML or DSL text:
+// values of secret fields, "sk-..." style tokens and bearer tokens.
+// ENC[...] ciphertext, ${ENV} placeholders and ref+ references stay visible (ref+ query strings are dropped).
+func MaskSecrets(line string) string {
+	if m := fieldPattern.FindStringSubmatch(line); m != nil && isSecretField(m[2]) {
+		return m[1] + maskFieldValue(m[3])
+	}
+	line = secretTokenPattern.ReplaceAllString(line, "$1$2"+maskedValue)
+	return bearerPattern.ReplaceAllString(line, "${1}"+maskedValue)
+}
+
+// isSecretField reports whether a YAML/DSL key holds a secret: value, api_key,
+// authorization, or any key naming a secret, token, password or credential
+// (aws_secret_access_key, aws_session_token, ...). Counters such as max_tokens are not secrets.
+func isSecretField(key string) bool {
+	k := strings.ToLower(key)
+	switch k {
+	case "value", "api_key", "apikey", "authorization":
+		return true
+	}
+	for _, s := range []string{"secret", "password", "passwd", "credential"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return strings.Contains(k, "token") && !strings.Contains(k, "tokens")
+}
+
+func maskFieldValue(v string) string {
+	raw := strings.Trim(strings.TrimSpace(v), `"'`)
+	switch {
+	case raw == "", raw == "null", strings.HasPrefix(raw, "ENC["), strings.HasPrefix(raw, "${"):
+		return v
+	case strings.HasPrefix(raw, "ref+"):
+		if i := strings.IndexByte(raw, '?'); i >= 0 {
+			return `"` + raw[:i] + `?..."`
+		}
+		return v
+	}
+	if strings.HasPrefix(strings.TrimSpace(v), `"`) {
+		return `"` + maskedValue + `"`
+	}
+	return maskedValue
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+	aIdx int
+	bIdx int
+}
+
+// diffLines computes a line diff from the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n*m > maxDiffCells {
+		ops := make([]diffOp, 0, n+m)
+		for i, l := range a {
+			ops = append(ops, diffOp{kind: '-', line: l, aIdx: i, bIdx: 0})
+		}
+		for j, l := range b {
+			ops = append(ops, diffOp{kind: '+', line: l, aIdx: n, bIdx: j})
+		}
+		return ops
+	}
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i], aIdx: i, bIdx: j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{kind: '+', line: b[j], aIdx: i, bIdx: j})
+			j++
+		default:
+			ops = append(ops, diffOp{kind: '-', line: a[i], aIdx: i, bIdx: j})
+			i++
+		}
+	}
+	return ops
+}
+
+type hunk struct {
+	from, to     int // op range
+	aStart, aLen int
+	bStart, bLen int
+}
+
+func buildHunks(ops []diffOp) []hunk {
+	var out []hunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		from := max(0, i-diffContextLines)
+		end := i
+		// Extend while the next change is within 2*context lines of the previous one.
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			k := end
+			for k < len(ops) && ops[k].kind == ' ' && k-end < 2*diffContextLines {
+				k++
+			}
+			if k < len(ops) && ops[k].kind != ' ' {
+				end = k
+				continue
+			}
+			break
+		}
+		to := min(len(ops), end+diffContextLines)
+		h := hunk{from: from, to: to, aStart: ops[from].aIdx, bStart: ops[from].bIdx}
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				h.aLen++
+			}
+			if op.kind != '-' {
+				h.bLen++
+			}
+		}
+		out = append(out, h)
+		i = to
+	}
+	return out
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
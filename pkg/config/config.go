@@ -3,13 +3,17 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/modelsync"
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/trafficdump"
 	"github.com/r9s-ai/open-next-router/onr-core/pkg/usageestimate"
 	"gopkg.in/yaml.v3"
 )
@@ -86,6 +90,125 @@ type AppNameInferConfig struct {
 	Unknown string `yaml:"unknown"`
 }
 
+// TrafficDumpSamplingConfig decides which finished requests keep their dump.
+// The first matching rule wins; default_rate (default 1) applies otherwise.
+type TrafficDumpSamplingConfig struct {
+	DefaultRate *float64                  `yaml:"default_rate"`
+	Rules       []TrafficDumpSamplingRule `yaml:"rules"`
+}
+
+// TrafficDumpSamplingRule keeps rate (0..1) of the dumps matching api and status.
+type TrafficDumpSamplingRule struct {
+	// API is the resolved API, e.g. chat.completions; empty matches any.
+	API string `yaml:"api"`
+	// Status is a comma-separated list of codes or classes, e.g. "5xx" or "429,5xx".
+	Status string   `yaml:"status"`
+	Rate   *float64 `yaml:"rate"`
+}
+
+// TrafficDumpRetentionConfig bounds the dump dir; a background janitor
+// enforces it. Zero disables a limit.
+type TrafficDumpRetentionConfig struct {
+	MaxTotalBytes          int64 `yaml:"max_total_bytes"`
+	MaxAgeHours            int   `yaml:"max_age_hours"`
+	JanitorIntervalSeconds int   `yaml:"janitor_interval_seconds"`
+}
+
+// ModelsSyncConfig controls how models.yaml routes are synchronized from the
+// providers' upstream model lists (onr-admin models sync and, when enabled,
+// a periodic in-server job that rewrites models.yaml and reloads it).
+type ModelsSyncConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalSeconds int  `yaml:"interval_seconds"`
+	// Providers limits the sync; empty means every provider with keys and a DSL models block.
+	Providers []string `yaml:"providers"`
+	// API selects the DSL auth/models variant used for the query (default chat.completions).
+	API string `yaml:"api"`
+	// Allow/Deny are regular expressions over the routed model id.
+	Allow   []string          `yaml:"allow"`
+	Deny    []string          `yaml:"deny"`
+	Aliases []modelsync.Alias `yaml:"aliases"`
+	// AddOnly never removes providers or routes.
+	AddOnly bool `yaml:"add_only"`
+	// OwnedBy is set on routes added by the sync.
+	OwnedBy string `yaml:"owned_by"`
+}
+
+// BalanceMonitorConfig polls the DSL balance block of every provider key,
+// exposes the results as gauges and alerts when a balance crosses a threshold.
+type BalanceMonitorConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalSeconds int  `yaml:"interval_seconds"`
+	// Providers limits the polling; empty means every provider with keys and a DSL balance block.
+	Providers []string `yaml:"providers"`
+	// API selects the DSL balance variant used for the query (default chat.completions).
+	API string `yaml:"api"`
+	// Low marks a key low when its balance is <= Low; unset disables the low state.
+	Low *float64 `yaml:"low"`
+	// Exhausted marks a key exhausted when its balance is <= Exhausted (default 0).
+	Exhausted *float64 `yaml:"exhausted"`
+	// Thresholds overrides low/exhausted by provider name.
+	Thresholds map[string]BalanceThresholdConfig `yaml:"thresholds"`
+	Webhook    BalanceWebhookConfig              `yaml:"webhook"`
+	// AutoBench makes routing skip exhausted keys until their balance recovers.
+	AutoBench bool `yaml:"auto_bench"`
+}
+
+type BalanceThresholdConfig struct {
+	Low       *float64 `yaml:"low"`
+	Exhausted *float64 `yaml:"exhausted"`
+}
+
+// BalanceWebhookConfig receives a JSON POST for every balance state change.
+type BalanceWebhookConfig struct {
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	TimeoutMs int               `yaml:"timeout_ms"`
+}
+
+// ThresholdsFor returns the low (nil when disabled) and exhausted thresholds of provider.
+func (c BalanceMonitorConfig) ThresholdsFor(provider string) (low *float64, exhausted float64) {
+	low = c.Low
+	if c.Exhausted != nil {
+		exhausted = *c.Exhausted
+	}
+	if t, ok := c.Thresholds[strings.ToLower(strings.TrimSpace(provider))]; ok {
+		if t.Low != nil {
+			low = t.Low
+		}
+		if t.Exhausted != nil {
+			exhausted = *t.Exhausted
+		}
+	}
+	return low, exhausted
+}
+
+// AccessLogOutputConfig is one access log sink. Fields apply by type:
+// file uses path/rotate, syslog uses network/address/tag/facility and http
+// uses url/headers and the batching fields.
+type AccessLogOutputConfig struct {
+	// Type is stdout, file, syslog or http.
+	Type string `yaml:"type"`
+	// Encoding overrides logging.access_log_encoding for this output.
+	Encoding string `yaml:"encoding"`
+
+	Path   string                `yaml:"path"`
+	Rotate AccessLogRotateConfig `yaml:"rotate"`
+
+	Network  string `yaml:"network"`
+	Address  string `yaml:"address"`
+	Tag      string `yaml:"tag"`
+	Facility string `yaml:"facility"`
+
+	URL             string            `yaml:"url"`
+	Headers         map[string]string `yaml:"headers"`
+	BatchSize       int               `yaml:"batch_size"`
+	FlushIntervalMs int               `yaml:"flush_interval_ms"`
+	BufferSize      int               `yaml:"buffer_size"`
+	BlockTimeoutMs  int               `yaml:"block_timeout_ms"`
+	TimeoutMs       int               `yaml:"timeout_ms"`
+}
+
 type LoggingConfig struct {
 	Level                 string                `yaml:"level"`
 	AccessLog             bool                  `yaml:"access_log"`
@@ -93,15 +216,35 @@ type LoggingConfig struct {
 	AccessLogFormat       string                `yaml:"access_log_format"`
 	AccessLogFormatPreset string                `yaml:"access_log_format_preset"`
 	AccessLogRotate       AccessLogRotateConfig `yaml:"access_log_rotate"`
-	AppNameInfer          AppNameInferConfig    `yaml:"appname_infer"`
+	// AccessLogEncoding is text (default, uses access_log_format), json or logfmt.
+	AccessLogEncoding string `yaml:"access_log_encoding"`
+	// AccessLogOutputs replaces access_log_path/access_log_rotate when non-empty.
+	AccessLogOutputs []AccessLogOutputConfig `yaml:"access_log_outputs"`
+	AppNameInfer     AppNameInferConfig      `yaml:"appname_infer"`
+}
+
+// ServerTLSConfig enables HTTPS (and HTTP/2 via ALPN) on the onr listener.
+// Certificate, key and client CA files are re-read on SIGHUP reload.
+type ServerTLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile is a PEM bundle used to verify client certificates (mTLS).
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth is one of: none, request, verify_if_given, require.
+	// Defaults to require when client_ca_file is set, otherwise none.
+	ClientAuth string `yaml:"client_auth"`
+	// MinVersion is "1.2" (default) or "1.3".
+	MinVersion string `yaml:"min_version"`
 }
 
 type Config struct {
 	Server struct {
-		Listen         string `yaml:"listen"`
-		ReadTimeoutMs  int    `yaml:"read_timeout_ms"`
-		WriteTimeoutMs int    `yaml:"write_timeout_ms"`
-		PidFile        string `yaml:"pid_file"`
+		Listen         string          `yaml:"listen"`
+		ReadTimeoutMs  int             `yaml:"read_timeout_ms"`
+		WriteTimeoutMs int             `yaml:"write_timeout_ms"`
+		PidFile        string          `yaml:"pid_file"`
+		TLS            ServerTLSConfig `yaml:"tls"`
 	} `yaml:"server"`
 
 	Auth struct {
@@ -129,7 +272,8 @@ type Config struct {
 
 	Models struct {
 		// File is an optional models list file. If not set or missing, /v1/models returns an empty list.
-		File string `yaml:"file"`
+		File string           `yaml:"file"`
+		Sync ModelsSyncConfig `yaml:"sync"`
 	} `yaml:"models"`
 
 	OAuth struct {
@@ -163,9 +307,30 @@ type Config struct {
 		MaxBytes    int      `yaml:"max_bytes"`
 		MaskSecrets bool     `yaml:"mask_secrets"`
 		Sections    []string `yaml:"sections"`
+		// Format is text (default) or jsonl (one typed JSON record per section, base64 bodies).
+		Format    string                     `yaml:"format"`
+		Sampling  TrafficDumpSamplingConfig  `yaml:"sampling"`
+		Retention TrafficDumpRetentionConfig `yaml:"retention"`
 	} `yaml:"traffic_dump"`
 
 	Logging LoggingConfig `yaml:"logging"`
+
+	UsageLedger struct {
+		// Enabled writes one usage/cost record per proxied request for chargeback reports.
+		Enabled bool `yaml:"enabled"`
+		// Dir holds daily usage-YYYY-MM-DD.jsonl files (UTC days).
+		Dir string `yaml:"dir"`
+		// RetentionDays deletes daily files older than N days; 0 keeps all.
+		RetentionDays int `yaml:"retention_days"`
+	} `yaml:"usage_ledger"`
+
+	BalanceMonitor BalanceMonitorConfig `yaml:"balance_monitor"`
+
+	Audit struct {
+		// File is the append-only JSONL audit log of config changes (onr-admin writes and onr reloads).
+		// Empty disables auditing.
+		File string `yaml:"file"`
+	} `yaml:"audit"`
 }
 
 func Load(path string) (*Config, error) {
@@ -252,6 +417,16 @@ func applyDefaults(cfg *Config) {
 	if strings.TrimSpace(cfg.Server.PidFile) == "" {
 		cfg.Server.PidFile = "/var/run/onr.pid"
 	}
+	if strings.TrimSpace(cfg.Server.TLS.ClientAuth) == "" {
+		if strings.TrimSpace(cfg.Server.TLS.ClientCAFile) != "" {
+			cfg.Server.TLS.ClientAuth = "require"
+		} else {
+			cfg.Server.TLS.ClientAuth = "none"
+		}
+	}
+	if strings.TrimSpace(cfg.Server.TLS.MinVersion) == "" {
+		cfg.Server.TLS.MinVersion = "1.2"
+	}
 	if cfg.Providers.AutoReload.DebounceMs <= 0 {
 		cfg.Providers.AutoReload.DebounceMs = 300
 	}
@@ -261,6 +436,22 @@ func applyDefaults(cfg *Config) {
 	if strings.TrimSpace(cfg.Models.File) == "" {
 		cfg.Models.File = "./models.yaml"
 	}
+	if cfg.Models.Sync.IntervalSeconds == 0 {
+		cfg.Models.Sync.IntervalSeconds = 3600
+	}
+	if strings.TrimSpace(cfg.Models.Sync.API) == "" {
+		cfg.Models.Sync.API = "chat.completions"
+	}
+	if cfg.BalanceMonitor.IntervalSeconds == 0 {
+		cfg.BalanceMonitor.IntervalSeconds = 900
+	}
+	if strings.TrimSpace(cfg.BalanceMonitor.API) == "" {
+		cfg.BalanceMonitor.API = "chat.completions"
+	}
+	if cfg.BalanceMonitor.Webhook.TimeoutMs == 0 {
+		cfg.BalanceMonitor.Webhook.TimeoutMs = 5000
+	}
+	cfg.BalanceMonitor.Thresholds = normalizeBalanceThresholds(cfg.BalanceMonitor.Thresholds)
 	if strings.TrimSpace(cfg.OAuth.TokenPersist.Dir) == "" {
 		cfg.OAuth.TokenPersist.Dir = "./run/oauth"
 	}
@@ -291,6 +482,15 @@ func applyDefaults(cfg *Config) {
 	if !cfg.TrafficDump.MaskSecrets {
 		cfg.TrafficDump.MaskSecrets = true
 	}
+	if strings.TrimSpace(cfg.TrafficDump.Format) == "" {
+		cfg.TrafficDump.Format = trafficdump.FormatText
+	}
+	if cfg.TrafficDump.Retention.JanitorIntervalSeconds == 0 {
+		cfg.TrafficDump.Retention.JanitorIntervalSeconds = 60
+	}
+	if strings.TrimSpace(cfg.UsageLedger.Dir) == "" {
+		cfg.UsageLedger.Dir = "./usage"
+	}
 	if strings.TrimSpace(cfg.Logging.Level) == "" {
 		cfg.Logging.Level = "info"
 	}
@@ -298,14 +498,21 @@ func applyDefaults(cfg *Config) {
 	if !cfg.Logging.AccessLog {
 		cfg.Logging.AccessLog = true
 	}
-	if !cfg.Logging.AccessLogRotate.maxSizeMBSet {
-		cfg.Logging.AccessLogRotate.MaxSizeMB = defaultAccessLogRotateMaxSizeMB
+	applyAccessLogRotateDefaults(&cfg.Logging.AccessLogRotate)
+	for i := range cfg.Logging.AccessLogOutputs {
+		applyAccessLogRotateDefaults(&cfg.Logging.AccessLogOutputs[i].Rotate)
+	}
+}
+
+func applyAccessLogRotateDefaults(r *AccessLogRotateConfig) {
+	if !r.maxSizeMBSet {
+		r.MaxSizeMB = defaultAccessLogRotateMaxSizeMB
 	}
-	if !cfg.Logging.AccessLogRotate.maxBackupsSet {
-		cfg.Logging.AccessLogRotate.MaxBackups = defaultAccessLogRotateMaxBackups
+	if !r.maxBackupsSet {
+		r.MaxBackups = defaultAccessLogRotateMaxBackups
 	}
-	if !cfg.Logging.AccessLogRotate.maxAgeDaysSet {
-		cfg.Logging.AccessLogRotate.MaxAgeDays = defaultAccessLogRotateMaxAgeDays
+	if !r.maxAgeDaysSet {
+		r.MaxAgeDays = defaultAccessLogRotateMaxAgeDays
 	}
 }
 
@@ -334,6 +541,19 @@ func applyEnvServerAuthOverrides(cfg *Config) {
 	if v := strings.TrimSpace(os.Getenv("ONR_PID_FILE")); v != "" {
 		cfg.Server.PidFile = v
 	}
+	cfg.Server.TLS.Enabled = envBool("ONR_TLS_ENABLED", cfg.Server.TLS.Enabled)
+	if v := strings.TrimSpace(os.Getenv("ONR_TLS_CERT_FILE")); v != "" {
+		cfg.Server.TLS.CertFile = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ONR_TLS_KEY_FILE")); v != "" {
+		cfg.Server.TLS.KeyFile = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ONR_TLS_CLIENT_CA_FILE")); v != "" {
+		cfg.Server.TLS.ClientCAFile = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ONR_TLS_CLIENT_AUTH")); v != "" {
+		cfg.Server.TLS.ClientAuth = v
+	}
 }
 
 func applyEnvProviderAndDataOverrides(cfg *Config) {
@@ -350,6 +570,14 @@ func applyEnvProviderAndDataOverrides(cfg *Config) {
 	if v := strings.TrimSpace(os.Getenv("ONR_MODELS_FILE")); v != "" {
 		cfg.Models.File = v
 	}
+	cfg.Models.Sync.Enabled = envBool("ONR_MODELS_SYNC_ENABLED", cfg.Models.Sync.Enabled)
+	if n, ok := envInt("ONR_MODELS_SYNC_INTERVAL_SECONDS"); ok {
+		cfg.Models.Sync.IntervalSeconds = n
+	}
+	cfg.BalanceMonitor.Enabled = envBool("ONR_BALANCE_MONITOR_ENABLED", cfg.BalanceMonitor.Enabled)
+	if v := strings.TrimSpace(os.Getenv("ONR_BALANCE_MONITOR_WEBHOOK_URL")); v != "" {
+		cfg.BalanceMonitor.Webhook.URL = v
+	}
 	cfg.OAuth.TokenPersist.Enabled = envBool("ONR_OAUTH_TOKEN_PERSIST_ENABLED", cfg.OAuth.TokenPersist.Enabled)
 	if v := strings.TrimSpace(os.Getenv("ONR_OAUTH_TOKEN_PERSIST_DIR")); v != "" {
 		cfg.OAuth.TokenPersist.Dir = v
@@ -363,6 +591,17 @@ func applyEnvProviderAndDataOverrides(cfg *Config) {
 	}
 
 	cfg.UsageEstimation.Enabled = envBool("ONR_USAGE_ESTIMATION_ENABLED", cfg.UsageEstimation.Enabled)
+	if v := strings.TrimSpace(os.Getenv("ONR_USAGE_ESTIMATION_TOKENIZER_DIR")); v != "" {
+		cfg.UsageEstimation.TokenizerDir = v
+	}
+
+	cfg.UsageLedger.Enabled = envBool("ONR_USAGE_LEDGER_ENABLED", cfg.UsageLedger.Enabled)
+	if v := strings.TrimSpace(os.Getenv("ONR_USAGE_LEDGER_DIR")); v != "" {
+		cfg.UsageLedger.Dir = v
+	}
+	if n, ok := envInt("ONR_USAGE_LEDGER_RETENTION_DAYS"); ok {
+		cfg.UsageLedger.RetentionDays = n
+	}
 }
 
 func applyEnvTrafficDumpOverrides(cfg *Config) {
@@ -380,9 +619,21 @@ func applyEnvTrafficDumpOverrides(cfg *Config) {
 	if v := strings.TrimSpace(os.Getenv("ONR_TRAFFIC_DUMP_SECTIONS")); v != "" {
 		cfg.TrafficDump.Sections = splitCommaTrim(v)
 	}
+	if v := strings.TrimSpace(os.Getenv("ONR_TRAFFIC_DUMP_FORMAT")); v != "" {
+		cfg.TrafficDump.Format = v
+	}
+	if n, ok := envInt("ONR_TRAFFIC_DUMP_MAX_TOTAL_BYTES"); ok {
+		cfg.TrafficDump.Retention.MaxTotalBytes = int64(n)
+	}
+	if n, ok := envInt("ONR_TRAFFIC_DUMP_MAX_AGE_HOURS"); ok {
+		cfg.TrafficDump.Retention.MaxAgeHours = n
+	}
 }
 
 func applyEnvLoggingOverrides(cfg *Config) {
+	if v := strings.TrimSpace(os.Getenv("ONR_AUDIT_FILE")); v != "" {
+		cfg.Audit.File = v
+	}
 	if v := strings.TrimSpace(os.Getenv("ONR_LOG_LEVEL")); v != "" {
 		cfg.Logging.Level = v
 	}
@@ -395,6 +646,9 @@ func applyEnvLoggingOverrides(cfg *Config) {
 	if v := strings.TrimSpace(os.Getenv("ONR_ACCESS_LOG_FORMAT_PRESET")); v != "" {
 		cfg.Logging.AccessLogFormatPreset = v
 	}
+	if v := strings.TrimSpace(os.Getenv("ONR_ACCESS_LOG_ENCODING")); v != "" {
+		cfg.Logging.AccessLogEncoding = v
+	}
 	cfg.Logging.AccessLogRotate.Enabled = envBool("ONR_ACCESS_LOG_ROTATE_ENABLED", cfg.Logging.AccessLogRotate.Enabled)
 	if n, ok := envInt("ONR_ACCESS_LOG_ROTATE_MAX_SIZE_MB"); ok {
 		cfg.Logging.AccessLogRotate.MaxSizeMB = n
@@ -430,6 +684,10 @@ func validate(cfg *Config) error {
 		cfg.Logging.Level = normalized
 	}
 
+	if err := validateServerTLS(&cfg.Server.TLS); err != nil {
+		return err
+	}
+
 	for prov, raw := range cfg.UpstreamProxies.ByProvider {
 		p := strings.ToLower(strings.TrimSpace(prov))
 		v := strings.TrimSpace(raw)
@@ -450,11 +708,26 @@ func validate(cfg *Config) error {
 	if cfg.TrafficDump.MaxBytes < 0 {
 		return errors.New("traffic_dump.max_bytes must be non-negative")
 	}
+	if cfg.UsageLedger.RetentionDays < 0 {
+		return errors.New("usage_ledger.retention_days must be non-negative")
+	}
+	if cfg.Models.Sync.IntervalSeconds < 0 {
+		return errors.New("models.sync.interval_seconds must be non-negative")
+	}
+	if _, err := NewModelsSyncRules(cfg); err != nil {
+		return fmt.Errorf("models.sync: %w", err)
+	}
+	if err := validateBalanceMonitor(&cfg.BalanceMonitor); err != nil {
+		return err
+	}
 	normalizedSections, err := normalizeTrafficDumpSections(cfg.TrafficDump.Sections)
 	if err != nil {
 		return err
 	}
 	cfg.TrafficDump.Sections = normalizedSections
+	if err := validateTrafficDumpRetention(cfg); err != nil {
+		return err
+	}
 	if cfg.OAuth.TokenPersist.Enabled && strings.TrimSpace(cfg.OAuth.TokenPersist.Dir) == "" {
 		return errors.New("oauth.token_persist.dir is required when oauth.token_persist.enabled=true")
 	}
@@ -475,6 +748,122 @@ func validate(cfg *Config) error {
 	if cfg.Logging.AccessLogRotate.MaxAgeDays < 0 {
 		return errors.New("logging.access_log_rotate.max_age_days must be >= 0")
 	}
+	if err := validateAccessLogEncoding("logging.access_log_encoding", cfg.Logging.AccessLogEncoding); err != nil {
+		return err
+	}
+	for i := range cfg.Logging.AccessLogOutputs {
+		if err := validateAccessLogOutput(fmt.Sprintf("logging.access_log_outputs[%d]", i), &cfg.Logging.AccessLogOutputs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateBalanceMonitor(c *BalanceMonitorConfig) error {
+	if c.IntervalSeconds < 0 {
+		return errors.New("balance_monitor.interval_seconds must be non-negative")
+	}
+	if c.Webhook.TimeoutMs < 0 {
+		return errors.New("balance_monitor.webhook.timeout_ms must be non-negative")
+	}
+	if u := strings.TrimSpace(c.Webhook.URL); u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return errors.New("balance_monitor.webhook.url must be an http(s) URL")
+	}
+	for _, p := range append([]string{""}, slices.Sorted(maps.Keys(c.Thresholds))...) {
+		low, exhausted := c.ThresholdsFor(p)
+		if low != nil && *low < exhausted {
+			field := "balance_monitor"
+			if p != "" {
+				field = "balance_monitor.thresholds." + p
+			}
+			return fmt.Errorf("%s: low (%g) must be >= exhausted (%g)", field, *low, exhausted)
+		}
+	}
+	return nil
+}
+
+func normalizeBalanceThresholds(in map[string]BalanceThresholdConfig) map[string]BalanceThresholdConfig {
+	if len(in) == 0 {
+		return in
+	}
+	out := make(map[string]BalanceThresholdConfig, len(in))
+	for k, v := range in {
+		if p := strings.ToLower(strings.TrimSpace(k)); p != "" {
+			out[p] = v
+		}
+	}
+	return out
+}
+
+func validateAccessLogEncoding(field, v string) error {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "text", "json", "logfmt":
+		return nil
+	default:
+		return fmt.Errorf("%s must be text, json or logfmt, got %q", field, v)
+	}
+}
+
+// validateAccessLogOutput requires a non-nil output; it normalizes Type.
+func validateAccessLogOutput(field string, o *AccessLogOutputConfig) error {
+	o.Type = strings.ToLower(strings.TrimSpace(o.Type))
+	if err := validateAccessLogEncoding(field+".encoding", o.Encoding); err != nil {
+		return err
+	}
+	switch o.Type {
+	case "stdout":
+	case "file":
+		if strings.TrimSpace(o.Path) == "" {
+			return fmt.Errorf("%s.path is required for type=file", field)
+		}
+		if o.Rotate.Enabled && (o.Rotate.MaxSizeMB <= 0 || o.Rotate.MaxBackups <= 0 || o.Rotate.MaxAgeDays < 0) {
+			return fmt.Errorf("%s.rotate needs max_size_mb > 0, max_backups > 0 and max_age_days >= 0", field)
+		}
+	case "syslog":
+		if strings.TrimSpace(o.Address) == "" {
+			return fmt.Errorf("%s.address is required for type=syslog", field)
+		}
+		switch strings.ToLower(strings.TrimSpace(o.Network)) {
+		case "", "udp", "tcp":
+		default:
+			return fmt.Errorf("%s.network must be udp or tcp, got %q", field, o.Network)
+		}
+	case "http":
+		u := strings.TrimSpace(o.URL)
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("%s.url must be an http(s) URL for type=http", field)
+		}
+	default:
+		return fmt.Errorf("%s.type must be stdout, file, syslog or http, got %q", field, o.Type)
+	}
+	if o.BatchSize < 0 || o.FlushIntervalMs < 0 || o.BufferSize < 0 || o.BlockTimeoutMs < 0 || o.TimeoutMs < 0 {
+		return fmt.Errorf("%s batch_size, flush_interval_ms, buffer_size, block_timeout_ms and timeout_ms must be non-negative", field)
+	}
+	return nil
+}
+
+// validateServerTLS requires a non-nil TLS config with defaults applied.
+func validateServerTLS(t *ServerTLSConfig) error {
+	t.ClientAuth = strings.ToLower(strings.TrimSpace(t.ClientAuth))
+	switch t.ClientAuth {
+	case "", "none", "request", "verify_if_given", "require":
+	default:
+		return errors.New("server.tls.client_auth must be one of: none, request, verify_if_given, require")
+	}
+	switch strings.TrimSpace(t.MinVersion) {
+	case "", "1.2", "1.3":
+	default:
+		return errors.New("server.tls.min_version must be one of: 1.2, 1.3")
+	}
+	if !t.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(t.CertFile) == "" || strings.TrimSpace(t.KeyFile) == "" {
+		return errors.New("server.tls.cert_file and server.tls.key_file are required when server.tls.enabled=true")
+	}
+	if (t.ClientAuth == "verify_if_given" || t.ClientAuth == "require") && strings.TrimSpace(t.ClientCAFile) == "" {
+		return fmt.Errorf("server.tls.client_ca_file is required when server.tls.client_auth=%s", t.ClientAuth)
+	}
 	return nil
 }
 
@@ -597,3 +986,66 @@ func normalizeProviderStringMap(in map[string]string) map[string]string {
 	}
 	return out
 }
+
+// validateTrafficDumpRetention checks format, sampling and retention and
+// normalizes the format name.
+func validateTrafficDumpRetention(cfg *Config) error {
+	td := &cfg.TrafficDump
+	format, err := trafficdump.NormalizeFormat(td.Format)
+	if err != nil {
+		return fmt.Errorf("traffic_dump.format: %w", err)
+	}
+	td.Format = format
+	if _, err := NewTrafficDumpSampler(cfg); err != nil {
+		return err
+	}
+	if td.Retention.MaxTotalBytes < 0 {
+		return errors.New("traffic_dump.retention.max_total_bytes must be non-negative")
+	}
+	if td.Retention.MaxAgeHours < 0 {
+		return errors.New("traffic_dump.retention.max_age_hours must be non-negative")
+	}
+	if td.Retention.JanitorIntervalSeconds < 0 {
+		return errors.New("traffic_dump.retention.janitor_interval_seconds must be non-negative")
+	}
+	return nil
+}
+
+// NewTrafficDumpSampler builds the sampler for traffic_dump.sampling; it
+// returns nil when every request is kept.
+func NewTrafficDumpSampler(cfg *Config) (*trafficdump.Sampler, error) {
+	sc := cfg.TrafficDump.Sampling
+	if len(sc.Rules) == 0 && (sc.DefaultRate == nil || *sc.DefaultRate == 1) {
+		return nil, nil
+	}
+	defaultRate := 1.0
+	if sc.DefaultRate != nil {
+		defaultRate = *sc.DefaultRate
+	}
+	rules := make([]trafficdump.SampleRule, 0, len(sc.Rules))
+	for i, r := range sc.Rules {
+		if r.Rate == nil {
+			return nil, fmt.Errorf("traffic_dump.sampling.rules[%d].rate is required", i)
+		}
+		rules = append(rules, trafficdump.SampleRule{API: r.API, Status: r.Status, Rate: *r.Rate})
+	}
+	s, err := trafficdump.NewSampler(rules, defaultRate)
+	if err != nil {
+		return nil, fmt.Errorf("traffic_dump.sampling: %w", err)
+	}
+	return s, nil
+}
+
+// TrafficDumpRetention returns the traffic_dump.retention limits.
+func TrafficDumpRetention(cfg *Config) trafficdump.RetentionOptions {
+	return trafficdump.RetentionOptions{
+		MaxTotalBytes: cfg.TrafficDump.Retention.MaxTotalBytes,
+		MaxAge:        time.Duration(cfg.TrafficDump.Retention.MaxAgeHours) * time.Hour,
+	}
+}
+
+// NewModelsSyncRules compiles the allow/deny/alias rules of models.sync.
+func NewModelsSyncRules(cfg *Config) (*modelsync.Rules, error) {
+	sc := cfg.Models.Sync
+	return modelsync.Compile(modelsync.RulesConfig{Allow: sc.Allow, Deny: sc.Deny, Aliases: sc.Aliases})
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func writeConfigFile(t *testing.T, content string) string {
@@ -70,6 +71,9 @@ auth:
 	if cfg.Logging.AccessLogRotate.Compress {
 		t.Fatalf("logging.access_log_rotate.compress default should be false")
 	}
+	if cfg.Server.TLS.Enabled || cfg.Server.TLS.ClientAuth != "none" || cfg.Server.TLS.MinVersion != "1.2" {
+		t.Fatalf("unexpected server.tls defaults: %+v", cfg.Server.TLS)
+	}
 }
 
 func TestResolveProviderDSLSource_DefaultsToOnrConfWhenPresent(t *testing.T) {
@@ -169,6 +173,7 @@ upstream_proxies:
 	t.Setenv("ONR_ACCESS_LOG_ROTATE_MAX_BACKUPS", "30")
 	t.Setenv("ONR_ACCESS_LOG_ROTATE_MAX_AGE_DAYS", "7")
 	t.Setenv("ONR_ACCESS_LOG_ROTATE_COMPRESS", "1")
+	t.Setenv("ONR_AUDIT_FILE", "/tmp/audit.jsonl")
 
 	cfg, err := Load(path)
 	if err != nil {
@@ -195,6 +200,9 @@ upstream_proxies:
 	if !cfg.TrafficDump.Enabled || cfg.TrafficDump.MaxBytes != 1024 || cfg.TrafficDump.MaskSecrets {
 		t.Fatalf("traffic_dump not overridden: %+v", cfg.TrafficDump)
 	}
+	if cfg.Audit.File != "/tmp/audit.jsonl" {
+		t.Fatalf("audit file not overridden: %q", cfg.Audit.File)
+	}
 	if !reflect.DeepEqual(cfg.TrafficDump.Sections, []string{"meta", "origin_request", "upstream_response"}) {
 		t.Fatalf("traffic_dump.sections not overridden/normalized: %v", cfg.TrafficDump.Sections)
 	}
@@ -284,6 +292,51 @@ logging:
 	})
 }
 
+func TestLoad_AccessLogOutputsYAML(t *testing.T) {
+	path := writeConfigFile(t, `
+auth:
+  api_key: "k"
+logging:
+  access_log_encoding: json
+  access_log_outputs:
+    - type: STDOUT
+      encoding: text
+    - type: file
+      path: "./logs/access.jsonl"
+      rotate:
+        enabled: true
+    - type: syslog
+      address: "127.0.0.1:514"
+    - type: http
+      url: "https://logs.example.com/ingest"
+      batch_size: 50
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	outs := cfg.Logging.AccessLogOutputs
+	if len(outs) != 4 || outs[0].Type != "stdout" || outs[3].BatchSize != 50 {
+		t.Fatalf("unexpected outputs: %+v", outs)
+	}
+	if outs[1].Rotate.MaxSizeMB != defaultAccessLogRotateMaxSizeMB || outs[1].Rotate.MaxBackups != defaultAccessLogRotateMaxBackups {
+		t.Fatalf("expected rotate defaults on file output, got %+v", outs[1].Rotate)
+	}
+
+	for name, body := range map[string]string{
+		"bad encoding":   "  access_log_encoding: xml\n",
+		"unknown type":   "  access_log_outputs:\n    - type: kafka\n",
+		"file no path":   "  access_log_outputs:\n    - type: file\n",
+		"syslog no addr": "  access_log_outputs:\n    - type: syslog\n",
+		"http bad url":   "  access_log_outputs:\n    - type: http\n      url: logs.example.com\n",
+	} {
+		path := writeConfigFile(t, "auth:\n  api_key: \"k\"\nlogging:\n"+body)
+		if _, err := Load(path); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
 func TestValidate(t *testing.T) {
 	newValidConfig := func() *Config {
 		cfg := &Config{}
@@ -372,6 +425,38 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("tls enabled requires cert and key", func(t *testing.T) {
+		cfg := newValidConfig()
+		cfg.Server.TLS.Enabled = true
+		cfg.Server.TLS.CertFile = "cert.pem"
+		if err := validate(cfg); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+
+	t.Run("tls require client cert needs client ca", func(t *testing.T) {
+		cfg := newValidConfig()
+		cfg.Server.TLS.Enabled = true
+		cfg.Server.TLS.CertFile = "cert.pem"
+		cfg.Server.TLS.KeyFile = "key.pem"
+		cfg.Server.TLS.ClientAuth = "require"
+		if err := validate(cfg); err == nil {
+			t.Fatalf("expected error")
+		}
+		cfg.Server.TLS.ClientCAFile = "ca.pem"
+		if err := validate(cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid tls client auth", func(t *testing.T) {
+		cfg := newValidConfig()
+		cfg.Server.TLS.ClientAuth = "always"
+		if err := validate(cfg); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+
 	t.Run("invalid logging level", func(t *testing.T) {
 		cfg := newValidConfig()
 		cfg.Logging.Level = "verbose"
@@ -449,3 +534,134 @@ traffic_dump:
 		t.Fatalf("expected error")
 	}
 }
+
+func TestLoad_TrafficDumpFormatSamplingRetentionYAML(t *testing.T) {
+	path := writeConfigFile(t, `
+auth:
+  api_key: "k"
+traffic_dump:
+  format: JSONL
+  sampling:
+    default_rate: 0.5
+    rules:
+      - status: 5xx
+        rate: 1
+      - api: chat.completions
+        status: 2xx
+        rate: 0.01
+  retention:
+    max_total_bytes: 1048576
+    max_age_hours: 24
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	if cfg.TrafficDump.Format != "jsonl" || cfg.TrafficDump.Retention.JanitorIntervalSeconds != 60 {
+		t.Fatalf("traffic_dump=%+v", cfg.TrafficDump)
+	}
+	s, err := NewTrafficDumpSampler(cfg)
+	if err != nil || s == nil {
+		t.Fatalf("sampler=%v err=%v", s, err)
+	}
+	if s.Rate("chat.completions", 200) != 0.01 || s.Rate("embeddings", 502) != 1 || s.Rate("embeddings", 200) != 0.5 {
+		t.Fatalf("unexpected sampling rates")
+	}
+	if r := TrafficDumpRetention(cfg); r.MaxTotalBytes != 1048576 || r.MaxAge != 24*time.Hour {
+		t.Fatalf("retention=%+v", r)
+	}
+
+	defaults, err := Load(writeConfigFile(t, "auth:\n  api_key: \"k\"\n"))
+	if err != nil {
+		t.Fatalf("Load defaults err=%v", err)
+	}
+	if s, err := NewTrafficDumpSampler(defaults); s != nil || err != nil {
+		t.Fatalf("expected no sampler by default, got %v %v", s, err)
+	}
+
+	for name, body := range map[string]string{
+		"bad format":      "  format: har\n",
+		"rule no rate":    "  sampling:\n    rules:\n      - status: 5xx\n",
+		"bad status":      "  sampling:\n    rules:\n      - status: 7xx\n        rate: 1\n",
+		"rate over 1":     "  sampling:\n    default_rate: 2\n",
+		"negative age":    "  retention:\n    max_age_hours: -1\n",
+		"negative budget": "  retention:\n    max_total_bytes: -1\n",
+	} {
+		if _, err := Load(writeConfigFile(t, "auth:\n  api_key: \"k\"\ntraffic_dump:\n"+body)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_ModelsSyncYAML(t *testing.T) {
+	path := writeConfigFile(t, `
+auth:
+  api_key: "k"
+models:
+  sync:
+    enabled: true
+    providers: [openai, gemini]
+    deny: ["-audio-"]
+    aliases:
+      - provider: gemini
+        match: "^models/(.+)$"
+        replace: "$1"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	sc := cfg.Models.Sync
+	if !sc.Enabled || sc.IntervalSeconds != 3600 || sc.API != "chat.completions" || len(sc.Providers) != 2 {
+		t.Fatalf("models.sync=%+v", sc)
+	}
+	rules, err := NewModelsSyncRules(cfg)
+	if err != nil {
+		t.Fatalf("NewModelsSyncRules err=%v", err)
+	}
+	if id, ok := rules.Route("gemini", "models/gemini-2.5-flash"); !ok || id != "gemini-2.5-flash" {
+		t.Fatalf("Route=%q,%v", id, ok)
+	}
+
+	if _, err := Load(writeConfigFile(t, "auth:\n  api_key: \"k\"\nmodels:\n  sync:\n    allow: [\"(\"]\n")); err == nil {
+		t.Fatalf("expected invalid allow pattern error, got %v", err)
+	}
+}
+
+func TestLoad_BalanceMonitorThresholds(t *testing.T) {
+	path := writeConfigFile(t, `
+auth:
+  api_key: "k"
+balance_monitor:
+  enabled: true
+  low: 20
+  auto_bench: true
+  thresholds:
+    DeepSeek:
+      low: 5
+      exhausted: 1
+  webhook:
+    url: "https://hooks.example.com/onr"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load err=%v", err)
+	}
+	bm := cfg.BalanceMonitor
+	if !bm.Enabled || !bm.AutoBench || bm.IntervalSeconds != 900 || bm.API != "chat.completions" || bm.Webhook.TimeoutMs != 5000 {
+		t.Fatalf("balance_monitor=%+v", bm)
+	}
+	if low, exhausted := bm.ThresholdsFor("openai"); low == nil || *low != 20 || exhausted != 0 {
+		t.Fatalf("openai thresholds=%v,%v", low, exhausted)
+	}
+	if low, exhausted := bm.ThresholdsFor("deepseek"); low == nil || *low != 5 || exhausted != 1 {
+		t.Fatalf("deepseek thresholds=%v,%v", low, exhausted)
+	}
+
+	if _, err := Load(writeConfigFile(t, "auth:\n  api_key: \"k\"\nbalance_monitor:\n  low: 1\n  exhausted: 2\n")); err == nil {
+		t.Fatalf("expected low < exhausted error")
+	}
+	if _, err := Load(writeConfigFile(t, "auth:\n  api_key: \"k\"\nbalance_monitor:\n  webhook:\n    url: \"hooks.example.com\"\n")); err == nil {
+		t.Fatalf("expected webhook url error")
+	}
+}
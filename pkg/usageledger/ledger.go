@@ -0,0 +1,270 @@
+// Package usageledger records one usage/cost record per proxied request as
+// daily JSONL files and aggregates them for chargeback reports.
+package usageledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/pricing"
+)
+
+const (
+	filePrefix = "usage-"
+	fileSuffix = ".jsonl"
+	dayLayout  = time.DateOnly
+)
+
+// Record is one ledger line.
+type Record struct {
+	Time           time.Time              `json:"ts"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	AccessKey      string                 `json:"access_key,omitempty"`
+	AppName        string                 `json:"appname,omitempty"`
+	Provider       string                 `json:"provider,omitempty"`
+	ProviderKey    string                 `json:"provider_key,omitempty"`
+	API            string                 `json:"api,omitempty"`
+	Stream         bool                   `json:"stream,omitempty"`
+	Model          string                 `json:"model,omitempty"`
+	Status         int                    `json:"status"`
+	UpstreamStatus int                    `json:"upstream_status,omitempty"`
+	FinishReason   string                 `json:"finish_reason,omitempty"`
+	LatencyMs      int64                  `json:"latency_ms"`
+	UsageStage     string                 `json:"usage_stage,omitempty"`
+	Usage          map[string]float64     `json:"usage,omitempty"`
+	Cost           float64                `json:"cost,omitempty"`
+	CostUnit       string                 `json:"cost_unit,omitempty"`
+	CostItems      []pricing.CostLineItem `json:"cost_items,omitempty"`
+}
+
+// Options configures a Ledger.
+type Options struct {
+	// RetentionDays deletes daily files older than N days on rollover; 0 keeps all.
+	RetentionDays int
+	// Now overrides the clock in tests.
+	Now func() time.Time
+}
+
+// Ledger appends records to <dir>/usage-YYYY-MM-DD.jsonl (UTC days).
+// A nil Ledger discards records.
+type Ledger struct {
+	dir       string
+	retention int
+	now       func() time.Time
+
+	mu  sync.Mutex
+	day string
+	f   *os.File
+}
+
+// Open returns a non-nil ledger writing to dir, creating it when missing.
+func Open(dir string, opts Options) (*Ledger, error) {
+	d := strings.TrimSpace(dir)
+	if d == "" {
+		return nil, errors.New("usage ledger dir is empty")
+	}
+	if err := os.MkdirAll(d, 0o750); err != nil {
+		return nil, fmt.Errorf("create usage ledger dir: %w", err)
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &Ledger{dir: d, retention: max(opts.RetentionDays, 0), now: now}, nil
+}
+
+// Dir returns the ledger directory, or "" for a nil ledger.
+func (l *Ledger) Dir() string {
+	if l == nil {
+		return ""
+	}
+	return l.dir
+}
+
+// Append writes r as one JSON line. Time defaults to now.
+func (l *Ledger) Append(r Record) error {
+	if l == nil {
+		return nil
+	}
+	if r.Time.IsZero() {
+		r.Time = l.now()
+	}
+	r.Time = r.Time.UTC()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.rotateLocked(r.Time.Format(dayLayout)); err != nil {
+		return err
+	}
+	_, err = l.f.Write(b)
+	return err
+}
+
+func (l *Ledger) rotateLocked(day string) error {
+	if l.f != nil && day == l.day {
+		return nil
+	}
+	if l.f != nil {
+		_ = l.f.Close()
+		l.f = nil
+	}
+	path := filepath.Join(l.dir, filePrefix+day+fileSuffix)
+	// #nosec G304 -- ledger dir comes from trusted config/env.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open usage ledger file: %w", err)
+	}
+	l.f = f
+	l.day = day
+	l.pruneLocked()
+	return nil
+}
+
+// pruneLocked removes daily files older than the retention window.
+func (l *Ledger) pruneLocked() {
+	if l.retention <= 0 {
+		return
+	}
+	cutoff := l.now().UTC().AddDate(0, 0, -l.retention).Format(dayLayout)
+	files, _ := listFiles(l.dir)
+	for _, f := range files {
+		if f.day < cutoff {
+			_ = os.Remove(f.path)
+		}
+	}
+}
+
+// Close closes the current file. It is safe on a nil ledger.
+func (l *Ledger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// Filter selects records in Read: Since is inclusive and Until exclusive, so
+// consecutive windows do not count a record twice. Zero fields match everything.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether r passes the filter.
+func (f Filter) Match(r Record) bool {
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+type ledgerFile struct {
+	path string
+	day  string
+}
+
+func listFiles(dir string) ([]ledgerFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ledgerFile, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+		if _, err := time.Parse(dayLayout, day); err != nil {
+			continue
+		}
+		out = append(out, ledgerFile{path: filepath.Join(dir, name), day: day})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].day < out[j].day })
+	return out, nil
+}
+
+// Read returns the records in dir that match f, oldest file first. Files whose
+// day lies outside [Since, Until] are skipped without being opened.
+// Writes are not fsynced, so a crash can leave a torn line; malformed lines are
+// skipped and returned as skipped instead of failing the whole read.
+func Read(dir string, f Filter) (records []Record, skipped int, err error) {
+	d := strings.TrimSpace(dir)
+	if d == "" {
+		return nil, 0, errors.New("usage ledger dir is empty")
+	}
+	files, err := listFiles(d)
+	if err != nil {
+		return nil, 0, err
+	}
+	var out []Record
+	for _, lf := range files {
+		if !f.Since.IsZero() && lf.day < f.Since.UTC().Format(dayLayout) {
+			continue
+		}
+		if !f.Until.IsZero() && lf.day > f.Until.UTC().Format(dayLayout) {
+			continue
+		}
+		records, n, err := readFile(lf.path, f)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, records...)
+		skipped += n
+	}
+	return out, skipped, nil
+}
+
+func readFile(path string, f Filter) ([]Record, int, error) {
+	// #nosec G304 -- ledger path comes from trusted config/flag.
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var out []Record
+	skipped := 0
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			skipped++
+			continue
+		}
+		if f.Match(r) {
+			out = append(out, r)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, skipped, nil
+}
@@ -0,0 +1,149 @@
+package usageledger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r9s-ai/open-next-router/onr-core/pkg/pricing"
+)
+
+func TestLedgerAppendReadAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	stale := filepath.Join(dir, "usage-2026-03-01.jsonl")
+	if err := os.WriteFile(stale, []byte("{}\n"), 0o600); err != nil {
+		t.Fatalf("write stale: %v", err)
+	}
+
+	l, err := Open(dir, Options{RetentionDays: 3, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	recs := []Record{
+		{Time: now.Add(-24 * time.Hour), AccessKey: "team-a", Model: "gpt-4o-mini", Status: 200, Usage: map[string]float64{"input_tokens": 10}, Cost: 0.5},
+		{Time: now, AccessKey: "team-a", Model: "gpt-4o-mini", Status: 200, Usage: map[string]float64{"input_tokens": 5}, Cost: 0.25,
+			CostItems: []pricing.CostLineItem{{Name: "input", Quantity: 5, Unit: "tokens", Rate: 0.05, RateUnit: "1M", Cost: 0.25}}},
+	}
+	for _, r := range recs {
+		if err := l.Append(r); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale file pruned, stat err=%v", err)
+	}
+	for _, day := range []string{"2026-03-09", "2026-03-10"} {
+		if _, err := os.Stat(filepath.Join(dir, "usage-"+day+".jsonl")); err != nil {
+			t.Fatalf("expected daily file for %s: %v", day, err)
+		}
+	}
+
+	all, _, err := Read(dir, Filter{})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(all) != 2 || all[1].CostItems[0].Name != "input" {
+		t.Fatalf("unexpected records: %+v", all)
+	}
+	recent, _, err := Read(dir, Filter{Since: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Read since: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Cost != 0.25 {
+		t.Fatalf("unexpected filtered records: %+v", recent)
+	}
+	// Until is exclusive: a record at exactly Until belongs to the next window.
+	before, _, err := Read(dir, Filter{Until: now})
+	if err != nil {
+		t.Fatalf("Read until: %v", err)
+	}
+	if len(before) != 1 || before[0].Cost != 0.5 {
+		t.Fatalf("unexpected records before until: %+v", before)
+	}
+}
+
+func TestReadSkipsTornLines(t *testing.T) {
+	dir := t.TempDir()
+	body := `{"ts":"2026-03-10T10:00:00Z","access_key":"a","cost":1}
+not json
+{"ts":"2026-03-10T11:00:00Z","access_key":"b","cost":2}
+{"ts":"2026-03-10T12:00:00Z","access_ke`
+	if err := os.WriteFile(filepath.Join(dir, "usage-2026-03-10.jsonl"), []byte(body), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	recs, skipped, err := Read(dir, Filter{})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(recs) != 2 || recs[1].AccessKey != "b" || skipped != 2 {
+		t.Fatalf("records=%+v skipped=%d, want 2 records and 2 skipped lines", recs, skipped)
+	}
+}
+
+func TestNilLedgerIsNoop(t *testing.T) {
+	var l *Ledger
+	if err := l.Append(Record{}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestAggregateAndExport(t *testing.T) {
+	records := []Record{
+		{AccessKey: "a", Model: "m1", Status: 200, LatencyMs: 100, Usage: map[string]float64{"input_tokens": 10, "output_tokens": 2}, Cost: 1, CostUnit: "USD"},
+		{AccessKey: "a", Model: "m1", Status: 500, LatencyMs: 300, Cost: 0},
+		{AccessKey: "b", Model: "m1", Status: 200, LatencyMs: 50, Usage: map[string]float64{"input_tokens": 3, "image_count": 1}, Cost: 2, CostUnit: "USD"},
+	}
+	if _, err := Aggregate(records, []string{"nope"}); err == nil {
+		t.Fatalf("expected unsupported group-by error")
+	}
+	rep, err := Aggregate(records, []string{"access_key", "model"})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	if len(rep.Groups) != 2 || rep.Groups[0].Keys[0] != "b" {
+		t.Fatalf("expected groups sorted by cost desc: %+v", rep.Groups)
+	}
+	a := rep.Groups[1]
+	if a.Requests != 2 || a.Errors != 1 || a.avgLatencyMs() != 200 || a.Usage["input_tokens"] != 10 {
+		t.Fatalf("unexpected group a: %+v", a)
+	}
+	if got := rep.UsageKeys[len(rep.UsageKeys)-1]; got != "image_count" {
+		t.Fatalf("expected extra usage key last, got %q", got)
+	}
+
+	var csvBuf bytes.Buffer
+	if err := rep.WriteCSV(&csvBuf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvBuf.String()), "\n")
+	if lines[0] != "access_key,model,requests,errors,avg_latency_ms,input_tokens,output_tokens,total_tokens,cache_read_tokens,cache_write_tokens,image_count,cost" {
+		t.Fatalf("unexpected header: %s", lines[0])
+	}
+	if lines[2] != "a,m1,2,1,200,10,2,0,0,0,0,1" {
+		t.Fatalf("unexpected row: %s", lines[2])
+	}
+
+	var pq bytes.Buffer
+	if err := rep.WriteParquet(&pq); err != nil {
+		t.Fatalf("WriteParquet: %v", err)
+	}
+	names, values, _, _ := readParquet(t, pq.Bytes())
+	if !reflect.DeepEqual(names, rep.Columns()) {
+		t.Fatalf("parquet columns %v, want %v", names, rep.Columns())
+	}
+	if len(values[0]) != len(rep.Groups) || values[0][0] != lines[1][:strings.IndexByte(lines[1], ',')] {
+		t.Fatalf("unexpected parquet access_key column %v for csv %q", values[0], lines[1])
+	}
+}
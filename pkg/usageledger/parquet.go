@@ -0,0 +1,367 @@
+package usageledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// A minimal Parquet writer for flat reports: PLAIN-encoded uncompressed data
+// pages and only REQUIRED columns, so no definition/repetition levels are
+// written. Rows are split into row groups of parquetRowGroupRows and each
+// column chunk into pages of about parquetPageBytes, which keeps every int32
+// field of the format in range; values that still do not fit are rejected.
+// Metadata uses the Thrift compact protocol as defined by parquet-format.
+
+const parquetMagic = "PAR1"
+
+// Row group and page limits. Variables so tests can exercise the splitting.
+var (
+	parquetRowGroupRows = 1 << 20
+	parquetPageBytes    = 1 << 20
+)
+
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota
+	parquetInt64
+	parquetDouble
+)
+
+type parquetColumn struct {
+	name    string
+	kind    parquetKind
+	strings []string
+	ints    []int64
+	doubles []float64
+}
+
+// parquet-format enum values.
+const (
+	pqTypeInt64         = 2
+	pqTypeDouble        = 5
+	pqTypeByteArray     = 6
+	pqRepRequired       = 0
+	pqConvertedUTF8     = 0
+	pqEncodingPlain     = 0
+	pqEncodingRLE       = 3
+	pqCodecUncompressed = 0
+	pqPageData          = 0
+)
+
+func (c parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case parquetInt64:
+		return pqTypeInt64
+	case parquetDouble:
+		return pqTypeDouble
+	default:
+		return pqTypeByteArray
+	}
+}
+
+func (c parquetColumn) len() int {
+	switch c.kind {
+	case parquetInt64:
+		return len(c.ints)
+	case parquetDouble:
+		return len(c.doubles)
+	default:
+		return len(c.strings)
+	}
+}
+
+// plainSize is the PLAIN-encoded size of value i.
+func (c parquetColumn) plainSize(i int) int {
+	if c.kind == parquetString {
+		return 4 + len(c.strings[i])
+	}
+	return 8
+}
+
+// appendPlain appends the PLAIN encoding of value i to b.
+func (c parquetColumn) appendPlain(b []byte, i int) []byte {
+	switch c.kind {
+	case parquetInt64:
+		return binary.LittleEndian.AppendUint64(b, uint64(c.ints[i])) // #nosec G115 -- two's complement bit pattern.
+	case parquetDouble:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(c.doubles[i]))
+	default:
+		v := c.strings[i]
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v))) // #nosec G115 -- bounded by the page size check in writeChunk.
+		return append(b, v...)
+	}
+}
+
+// parquetInt32 converts a count or size stored as an i32 in Parquet metadata.
+func parquetInt32(v int, what string) (int32, error) {
+	if v < 0 || v > math.MaxInt32 {
+		return 0, fmt.Errorf("parquet: %s %d exceeds int32", what, v)
+	}
+	return int32(v), nil
+}
+
+type parquetChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type parquetRowGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// parquetFile tracks the write offset, which chunk metadata refers to.
+type parquetFile struct {
+	w   io.Writer
+	off int64
+}
+
+func (f *parquetFile) write(b []byte) error {
+	n, err := f.w.Write(b)
+	f.off += int64(n)
+	return err
+}
+
+// writePage writes one data page holding n PLAIN-encoded values.
+func (f *parquetFile) writePage(values []byte, n int) error {
+	size, err := parquetInt32(len(values), "page size")
+	if err != nil {
+		return err
+	}
+	count, err := parquetInt32(n, "page value count")
+	if err != nil {
+		return err
+	}
+	var header thriftWriter
+	header.i32(1, pqPageData)
+	header.i32(2, size)
+	header.i32(3, size)
+	header.structBegin(5)
+	header.i32(1, count)
+	header.i32(2, pqEncodingPlain)
+	header.i32(3, pqEncodingRLE)
+	header.i32(4, pqEncodingRLE)
+	header.structEnd()
+	header.stop()
+	if err := f.write(header.buf.Bytes()); err != nil {
+		return err
+	}
+	return f.write(values)
+}
+
+// writeChunk writes rows [from, to) of c as a column chunk split into pages.
+func (f *parquetFile) writeChunk(c parquetColumn, from, to int) (parquetChunk, error) {
+	ch := parquetChunk{offset: f.off, numValues: int64(to - from)}
+	page := make([]byte, 0, min(parquetPageBytes, 64<<10))
+	n := 0
+	for i := from; i < to; i++ {
+		size := c.plainSize(i)
+		if n > 0 && len(page)+size > parquetPageBytes {
+			if err := f.writePage(page, n); err != nil {
+				return ch, err
+			}
+			page, n = page[:0], 0
+		}
+		if size > math.MaxInt32 {
+			return ch, fmt.Errorf("parquet: value %d of column %q is %d bytes, exceeds int32", i, c.name, size)
+		}
+		page = c.appendPlain(page, i)
+		n++
+	}
+	if n > 0 || from == to {
+		if err := f.writePage(page, n); err != nil {
+			return ch, err
+		}
+	}
+	ch.size = f.off - ch.offset
+	return ch, nil
+}
+
+func writeParquet(w io.Writer, numRows int64, cols []parquetColumn) error {
+	if _, err := parquetInt32(len(cols), "column count"); err != nil {
+		return err
+	}
+	for _, c := range cols {
+		if int64(c.len()) != numRows {
+			return fmt.Errorf("parquet: column %q has %d values, want %d", c.name, c.len(), numRows)
+		}
+	}
+	rows := int(numRows)
+	groupRows := max(parquetRowGroupRows, 1)
+
+	f := &parquetFile{w: w}
+	if err := f.write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+	var groups []parquetRowGroup
+	for from := 0; from < rows || (rows == 0 && len(groups) == 0); from += groupRows {
+		to := min(from+groupRows, rows)
+		g := parquetRowGroup{rows: int64(to - from), chunks: make([]parquetChunk, len(cols))}
+		for i, c := range cols {
+			ch, err := f.writeChunk(c, from, to)
+			if err != nil {
+				return err
+			}
+			g.chunks[i] = ch
+		}
+		groups = append(groups, g)
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.listBegin(2, thriftStruct, len(cols)+1)
+	meta.elemBegin()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(cols))) // #nosec G115 -- checked by parquetInt32 above.
+	meta.elemEnd()
+	for _, c := range cols {
+		meta.elemBegin()
+		meta.i32(1, c.physicalType())
+		meta.i32(3, pqRepRequired)
+		meta.binary(4, c.name)
+		if c.kind == parquetString {
+			meta.i32(6, pqConvertedUTF8)
+		}
+		meta.elemEnd()
+	}
+	meta.i64(3, numRows)
+	meta.listBegin(4, thriftStruct, len(groups))
+	for _, g := range groups {
+		meta.elemBegin()
+		meta.listBegin(1, thriftStruct, len(cols))
+		total := int64(0)
+		for i, c := range cols {
+			ch := g.chunks[i]
+			total += ch.size
+			meta.elemBegin()
+			meta.i64(2, ch.offset)
+			meta.structBegin(3)
+			meta.i32(1, c.physicalType())
+			meta.listBegin(2, thriftI32, 2)
+			meta.varint(pqEncodingPlain)
+			meta.varint(pqEncodingRLE)
+			meta.listBegin(3, thriftBinary, 1)
+			meta.rawBinary(c.name)
+			meta.i32(4, pqCodecUncompressed)
+			meta.i64(5, ch.numValues)
+			meta.i64(6, ch.size)
+			meta.i64(7, ch.size)
+			meta.i64(9, ch.offset)
+			meta.structEnd()
+			meta.elemEnd()
+		}
+		meta.i64(2, total)
+		meta.i64(3, g.rows)
+		meta.elemEnd()
+	}
+	meta.binary(6, "open-next-router usageledger")
+	meta.stop()
+
+	footerLen, err := parquetInt32(meta.buf.Len(), "footer size")
+	if err != nil {
+		return err
+	}
+	if err := f.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	var footer [4]byte
+	binary.LittleEndian.PutUint32(footer[:], uint32(footerLen)) // #nosec G115 -- non-negative int32.
+	if err := f.write(footer[:]); err != nil {
+		return err
+	}
+	return f.write([]byte(parquetMagic))
+}
+
+// Thrift compact protocol type ids.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol. Field ids are
+// delta-encoded against the last id of the enclosing struct.
+type thriftWriter struct {
+	buf   bytes.Buffer
+	last  int16
+	stack []int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+// varint writes a zigzag-encoded integer.
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63))) // #nosec G115 -- zigzag encoding.
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	t.buf.Write(scratch[:n])
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.rawBinary(s)
+}
+
+func (t *thriftWriter) rawBinary(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) listBegin(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xF0 | elemType)
+	t.uvarint(uint64(size))
+}
+
+// structBegin starts a struct-typed field; elemBegin starts a struct list element.
+func (t *thriftWriter) structBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) elemBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) structEnd() { t.elemEnd() }
+
+func (t *thriftWriter) elemEnd() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) stop() { t.buf.WriteByte(0) }
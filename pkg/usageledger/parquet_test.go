@@ -0,0 +1,261 @@
+package usageledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// thriftReader decodes the Thrift compact protocol into generic values: structs
+// become map[int16]any, lists []any, integers int64 and binaries []byte.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+var errThriftShort = errors.New("thrift: unexpected end of input")
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errThriftShort
+	}
+	c := r.b[r.pos]
+	r.pos++
+	return c, nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		return 0, errThriftShort
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	u, err := r.uvarint()
+	return int64(u>>1) ^ -int64(u&1), err // #nosec G115 -- zigzag decoding.
+}
+
+func (r *thriftReader) value(typ byte) (any, error) {
+	switch typ {
+	case 1, 2:
+		return typ == 1, nil
+	case 3:
+		c, err := r.byte()
+		return int64(int8(c)), err // #nosec G115 -- signed byte.
+	case 4, thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(r.b)-r.pos) < n {
+			return nil, errThriftShort
+		}
+		v := r.b[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case thriftList:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(h >> 4)
+		if size == 15 {
+			if size, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		out := make([]any, 0, size)
+		for i := uint64(0); i < size; i++ {
+			v, err := r.value(h & 0x0F)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case thriftStruct:
+		return r.structValue()
+	default:
+		return nil, fmt.Errorf("thrift: unsupported type %d", typ)
+	}
+}
+
+func (r *thriftReader) structValue() (map[int16]any, error) {
+	out := map[int16]any{}
+	last := int16(0)
+	for {
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return out, nil
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v) // #nosec G115 -- field ids are small.
+		}
+		v, err := r.value(h & 0x0F)
+		if err != nil {
+			return nil, err
+		}
+		out[id] = v
+		last = id
+	}
+}
+
+func decodePlain(t *testing.T, typ int64, b []byte, n int) []any {
+	t.Helper()
+	out := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		switch typ {
+		case pqTypeInt64:
+			out = append(out, int64(binary.LittleEndian.Uint64(b))) // #nosec G115 -- two's complement bit pattern.
+			b = b[8:]
+		case pqTypeDouble:
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			b = b[8:]
+		default:
+			l := int(binary.LittleEndian.Uint32(b))
+			out = append(out, string(b[4:4+l]))
+			b = b[4+l:]
+		}
+	}
+	if len(b) != 0 {
+		t.Fatalf("%d trailing bytes in page", len(b))
+	}
+	return out
+}
+
+// readParquet decodes the footer and every data page and returns the column
+// names, the values per column and the number of row groups and data pages.
+func readParquet(t *testing.T, file []byte) ([]string, [][]any, int, int) {
+	t.Helper()
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatalf("missing parquet magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	r := &thriftReader{b: file[len(file)-8-footerLen : len(file)-8]}
+	meta, err := r.structValue()
+	if err != nil || r.pos != len(r.b) {
+		t.Fatalf("decode footer: err=%v consumed=%d of %d", err, r.pos, len(r.b))
+	}
+
+	schema := meta[2].([]any)
+	root := schema[0].(map[int16]any)
+	if root[5].(int64) != int64(len(schema)-1) {
+		t.Fatalf("root num_children=%v, schema has %d leaves", root[5], len(schema)-1)
+	}
+	names := make([]string, 0, len(schema)-1)
+	types := make([]int64, 0, len(schema)-1)
+	for _, e := range schema[1:] {
+		el := e.(map[int16]any)
+		names = append(names, string(el[4].([]byte)))
+		types = append(types, el[1].(int64))
+	}
+
+	values := make([][]any, len(names))
+	rowGroups := meta[4].([]any)
+	rows, pages := int64(0), 0
+	for _, g := range rowGroups {
+		rg := g.(map[int16]any)
+		rows += rg[3].(int64)
+		for i, c := range rg[1].([]any) {
+			cm := c.(map[int16]any)[3].(map[int16]any)
+			if cm[5].(int64) != rg[3].(int64) {
+				t.Fatalf("column %s num_values=%v, row group rows=%v", names[i], cm[5], rg[3])
+			}
+			start := int(cm[9].(int64))
+			chunk := &thriftReader{b: file[start : start+int(cm[7].(int64))]}
+			got := int64(0)
+			for chunk.pos < len(chunk.b) {
+				ph, err := chunk.structValue()
+				if err != nil {
+					t.Fatalf("decode page header: %v", err)
+				}
+				size := int(ph[3].(int64))
+				n := int(ph[5].(map[int16]any)[1].(int64))
+				values[i] = append(values[i], decodePlain(t, types[i], chunk.b[chunk.pos:chunk.pos+size], n)...)
+				chunk.pos += size
+				got += int64(n)
+				pages++
+			}
+			if got != cm[5].(int64) {
+				t.Fatalf("column %s pages hold %d values, metadata says %v", names[i], got, cm[5])
+			}
+		}
+	}
+	if rows != meta[3].(int64) {
+		t.Fatalf("row groups hold %d rows, file metadata says %v", rows, meta[3])
+	}
+	return names, values, len(rowGroups), pages
+}
+
+func TestWriteParquet_RoundTripAcrossRowGroupsAndPages(t *testing.T) {
+	defer func(rows, page int) { parquetRowGroupRows, parquetPageBytes = rows, page }(parquetRowGroupRows, parquetPageBytes)
+	parquetRowGroupRows, parquetPageBytes = 7, 24
+
+	const n = 20
+	cols := []parquetColumn{
+		{name: "model", kind: parquetString},
+		{name: "requests", kind: parquetInt64},
+		{name: "cost", kind: parquetDouble},
+	}
+	want := make([][]any, len(cols))
+	for i := 0; i < n; i++ {
+		s := strings.Repeat("m", i%5) + fmt.Sprint(i)
+		cols[0].strings = append(cols[0].strings, s)
+		cols[1].ints = append(cols[1].ints, int64(i*i)-50)
+		cols[2].doubles = append(cols[2].doubles, float64(i)/3)
+		want[0] = append(want[0], s)
+		want[1] = append(want[1], int64(i*i)-50)
+		want[2] = append(want[2], float64(i)/3)
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, n, cols); err != nil {
+		t.Fatalf("writeParquet: %v", err)
+	}
+	names, got, groups, pages := readParquet(t, buf.Bytes())
+	if !reflect.DeepEqual(names, []string{"model", "requests", "cost"}) {
+		t.Fatalf("unexpected columns %v", names)
+	}
+	if groups != 3 || pages <= groups*len(cols) {
+		t.Fatalf("expected 3 row groups split into several pages, got %d groups and %d pages", groups, pages)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\ngot  %v\nwant %v", got, want)
+	}
+
+	buf.Reset()
+	if err := writeParquet(&buf, 0, []parquetColumn{{name: "model", kind: parquetString}}); err != nil {
+		t.Fatalf("writeParquet empty: %v", err)
+	}
+	if _, got, _, _ := readParquet(t, buf.Bytes()); len(got[0]) != 0 {
+		t.Fatalf("expected no values, got %v", got)
+	}
+}
+
+func TestWriteParquet_RejectsInvalidSizes(t *testing.T) {
+	err := writeParquet(&bytes.Buffer{}, 2, []parquetColumn{{name: "requests", kind: parquetInt64, ints: []int64{1}}})
+	if err == nil || !strings.Contains(err.Error(), `column "requests" has 1 values, want 2`) {
+		t.Fatalf("expected row count error, got %v", err)
+	}
+	if _, err := parquetInt32(math.MaxInt32+1, "page size"); err == nil || !strings.Contains(err.Error(), "page size 2147483648 exceeds int32") {
+		t.Fatalf("expected int32 overflow error, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package usageledger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Standard usage keys always present as report columns, in this order.
+var standardUsageKeys = []string{
+	"input_tokens",
+	"output_tokens",
+	"total_tokens",
+	"cache_read_tokens",
+	"cache_write_tokens",
+}
+
+// groupByFields maps --group-by names to record accessors.
+var groupByFields = map[string]func(Record) string{
+	"access_key":   func(r Record) string { return r.AccessKey },
+	"appname":      func(r Record) string { return r.AppName },
+	"provider":     func(r Record) string { return r.Provider },
+	"provider_key": func(r Record) string { return r.ProviderKey },
+	"api":          func(r Record) string { return r.API },
+	"model":        func(r Record) string { return r.Model },
+	"status":       func(r Record) string { return strconv.Itoa(r.Status) },
+	"day":          func(r Record) string { return r.Time.UTC().Format(dayLayout) },
+	"hour":         func(r Record) string { return r.Time.UTC().Format("2006-01-02T15") },
+}
+
+// GroupByFields returns the supported group-by field names, sorted.
+func GroupByFields() []string {
+	out := make([]string, 0, len(groupByFields))
+	for k := range groupByFields {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Group is one aggregated report row. Keys follow the group-by order.
+type Group struct {
+	Keys      []string           `json:"keys"`
+	Requests  int64              `json:"requests"`
+	Errors    int64              `json:"errors"`
+	LatencyMs int64              `json:"latency_ms_total"`
+	Usage     map[string]float64 `json:"usage"`
+	Cost      float64            `json:"cost"`
+	CostUnit  string             `json:"cost_unit,omitempty"`
+}
+
+// Report is the aggregation of a set of records.
+type Report struct {
+	GroupBy   []string `json:"group_by"`
+	UsageKeys []string `json:"usage_keys"`
+	Groups    []Group  `json:"groups"`
+}
+
+// Aggregate groups records by the given fields. Records with status >= 400
+// count as errors. Groups are sorted by cost, then by keys.
+func Aggregate(records []Record, groupBy []string) (Report, error) {
+	fields := make([]func(Record) string, 0, len(groupBy))
+	names := make([]string, 0, len(groupBy))
+	for _, name := range groupBy {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		fn, ok := groupByFields[name]
+		if !ok {
+			return Report{}, fmt.Errorf("unsupported group-by field %q (want %s)", name, strings.Join(GroupByFields(), ", "))
+		}
+		fields = append(fields, fn)
+		names = append(names, name)
+	}
+
+	index := map[string]*Group{}
+	order := make([]*Group, 0, 16)
+	extra := map[string]struct{}{}
+	for _, r := range records {
+		keys := make([]string, len(fields))
+		for i, fn := range fields {
+			keys[i] = fn(r)
+		}
+		id := strings.Join(keys, "\x00")
+		g, ok := index[id]
+		if !ok {
+			g = &Group{Keys: keys, Usage: map[string]float64{}}
+			index[id] = g
+			order = append(order, g)
+		}
+		g.Requests++
+		if r.Status >= 400 {
+			g.Errors++
+		}
+		g.LatencyMs += r.LatencyMs
+		for k, v := range r.Usage {
+			g.Usage[k] += v
+			extra[k] = struct{}{}
+		}
+		g.Cost += r.Cost
+		if g.CostUnit == "" {
+			g.CostUnit = r.CostUnit
+		}
+	}
+
+	usageKeys := append([]string(nil), standardUsageKeys...)
+	for _, k := range standardUsageKeys {
+		delete(extra, k)
+	}
+	extraKeys := make([]string, 0, len(extra))
+	for k := range extra {
+		extraKeys = append(extraKeys, k)
+	}
+	sort.Strings(extraKeys)
+	usageKeys = append(usageKeys, extraKeys...)
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Cost != order[j].Cost {
+			return order[i].Cost > order[j].Cost
+		}
+		return strings.Join(order[i].Keys, "\x00") < strings.Join(order[j].Keys, "\x00")
+	})
+	groups := make([]Group, 0, len(order))
+	for _, g := range order {
+		groups = append(groups, *g)
+	}
+	return Report{GroupBy: names, UsageKeys: usageKeys, Groups: groups}, nil
+}
+
+// Columns returns the report header: group-by fields, counters, usage keys and cost.
+func (r Report) Columns() []string {
+	out := make([]string, 0, len(r.GroupBy)+len(r.UsageKeys)+4)
+	out = append(out, r.GroupBy...)
+	out = append(out, "requests", "errors", "avg_latency_ms")
+	out = append(out, r.UsageKeys...)
+	return append(out, "cost")
+}
+
+// WriteCSV writes the report as CSV with a header row.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns()); err != nil {
+		return err
+	}
+	for _, g := range r.Groups {
+		row := make([]string, 0, len(r.Columns()))
+		row = append(row, g.Keys...)
+		row = append(row, strconv.FormatInt(g.Requests, 10), strconv.FormatInt(g.Errors, 10), strconv.FormatInt(g.avgLatencyMs(), 10))
+		for _, k := range r.UsageKeys {
+			row = append(row, strconv.FormatFloat(g.Usage[k], 'f', -1, 64))
+		}
+		row = append(row, strconv.FormatFloat(g.Cost, 'f', -1, 64))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteParquet writes the report as a single-row-group Parquet file with the
+// same columns as WriteCSV.
+func (r Report) WriteParquet(w io.Writer) error {
+	cols := make([]parquetColumn, 0, len(r.Columns()))
+	for i, name := range r.GroupBy {
+		c := parquetColumn{name: name, kind: parquetString}
+		for _, g := range r.Groups {
+			c.strings = append(c.strings, g.Keys[i])
+		}
+		cols = append(cols, c)
+	}
+	counters := []struct {
+		name string
+		get  func(Group) int64
+	}{
+		{"requests", func(g Group) int64 { return g.Requests }},
+		{"errors", func(g Group) int64 { return g.Errors }},
+		{"avg_latency_ms", Group.avgLatencyMs},
+	}
+	for _, ctr := range counters {
+		c := parquetColumn{name: ctr.name, kind: parquetInt64}
+		for _, g := range r.Groups {
+			c.ints = append(c.ints, ctr.get(g))
+		}
+		cols = append(cols, c)
+	}
+	for _, k := range r.UsageKeys {
+		c := parquetColumn{name: k, kind: parquetDouble}
+		for _, g := range r.Groups {
+			c.doubles = append(c.doubles, g.Usage[k])
+		}
+		cols = append(cols, c)
+	}
+	c := parquetColumn{name: "cost", kind: parquetDouble}
+	for _, g := range r.Groups {
+		c.doubles = append(c.doubles, g.Cost)
+	}
+	cols = append(cols, c)
+	return writeParquet(w, int64(len(r.Groups)), cols)
+}
+
+func (g Group) avgLatencyMs() int64 {
+	if g.Requests == 0 {
+		return 0
+	}
+	return g.LatencyMs / g.Requests
+}